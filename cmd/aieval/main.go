@@ -0,0 +1,135 @@
+// Command aieval прогоняет корпус кейсов через AI агентов и сравнивает версии промптов/моделей.
+//
+//	go run ./cmd/aieval                                  # replay записанных ответов
+//	go run ./cmd/aieval -mode record -label v2           # прогон на локальной модели с записью
+//	go run ./cmd/aieval -out head.json -baseline base.json
+//	go run ./cmd/aieval -mode record -prompts-dir prompts -prompt decision=v2
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/ai/eval"
+	"github.com/kirillm/dca-bot/internal/ai/prompts"
+)
+
+func main() {
+	corpusPath := flag.String("corpus", "configs/eval/corpus.json", "корпус кейсов")
+	recordingsPath := flag.String("recordings", "configs/eval/recordings.json", "записанные ответы моделей")
+	mode := flag.String("mode", eval.ModeReplay, "replay | live | record")
+	provider := flag.String("provider", getEnv("LOCAL_AI_PROVIDER", ai.ProviderOllama), "тип провайдера для live/record: ollama, openai, anthropic")
+	baseURL := flag.String("url", getEnv("LOCAL_AI_URL", "http://localhost:11434"), "URL модели для live/record")
+	model := flag.String("model", getEnv("LOCAL_AI_MODEL", "qwen2.5-coder:14b"), "модель для live/record")
+	apiKey := flag.String("api-key", os.Getenv("CLOUD_AI_API_KEY"), "API ключ облачного провайдера")
+	policyPath := flag.String("policy", "configs/policy.yaml", "политика риска (пусто - без проверки)")
+	minConfidence := flag.Float64("min-confidence", 0.6, "порог уверенности маршрутизации")
+	promptsDir := flag.String("prompts-dir", os.Getenv("AI_PROMPTS_DIR"), "каталог с версиями промптов <name>/<version>.tmpl")
+	promptVersions := flag.String("prompt", "", "версии промптов для прогона: decision=v2,chat=v1")
+	label := flag.String("label", "", "метка прогона (по умолчанию версия промпта решений)")
+	out := flag.String("out", "", "сохранить отчет в JSON")
+	baseline := flag.String("baseline", "", "отчет для сравнения; код выхода 1 при ухудшениях")
+	flag.Parse()
+
+	corpus, err := eval.LoadCorpus(*corpusPath)
+	if err != nil {
+		fail(err)
+	}
+
+	recordings, err := eval.LoadRecordings(*recordingsPath)
+	if err != nil {
+		fail(err)
+	}
+
+	var upstream ai.Provider
+	if *mode != eval.ModeReplay {
+		upstream, err = ai.NewProvider(ai.ProviderConfig{
+			Name:    *provider,
+			Kind:    *provider,
+			BaseURL: *baseURL,
+			APIKey:  *apiKey,
+			Model:   *model,
+			Timeout: 2 * time.Minute,
+		})
+		if err != nil {
+			fail(err)
+		}
+	}
+
+	recorder, err := eval.NewRecorder(*mode, upstream, recordings)
+	if err != nil {
+		fail(err)
+	}
+
+	var checker *eval.PolicyChecker
+	if *policyPath != "" {
+		if checker, err = eval.NewPolicyChecker(*policyPath); err != nil {
+			fail(err)
+		}
+	}
+
+	registry, err := prompts.NewRegistry(*promptsDir)
+	if err != nil {
+		fail(err)
+	}
+	if *promptVersions != "" {
+		for _, pair := range strings.Split(*promptVersions, ",") {
+			name, version, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				fail(fmt.Errorf("invalid -prompt %q, expected name=version", pair))
+			}
+			if err := registry.SetActive(name, version); err != nil {
+				fail(err)
+			}
+		}
+	}
+
+	runner := eval.NewRunner(recorder, checker, *minConfidence)
+	runner.SetPrompts(registry)
+
+	report := runner.Run(context.Background(), corpus, *label)
+	fmt.Println(report.Format())
+
+	if *mode == eval.ModeRecord {
+		if err := recorder.Recordings().Save(*recordingsPath); err != nil {
+			fail(err)
+		}
+		fmt.Printf("\n💾 Записи сохранены: %s\n", *recordingsPath)
+	}
+
+	if *out != "" {
+		if err := report.Save(*out); err != nil {
+			fail(err)
+		}
+	}
+
+	if *baseline != "" {
+		base, err := eval.LoadReport(*baseline)
+		if err != nil {
+			fail(err)
+		}
+		cmp := eval.Compare(base, report)
+		fmt.Println()
+		fmt.Println(cmp.Format())
+		if cmp.HasRegressions() {
+			os.Exit(1)
+		}
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "❌", err)
+	os.Exit(1)
+}
@@ -0,0 +1,95 @@
+// Command migrate применяет и откатывает версионные миграции схемы PostgreSQL или SQLite.
+// Подключение берется из DB_* переменных окружения (.env), как у бота (DB_DRIVER выбирает базу).
+//
+//	go run ./cmd/migrate status
+//	go run ./cmd/migrate up
+//	go run ./cmd/migrate down -steps 2
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/kirillm/dca-bot/internal/config"
+	"github.com/kirillm/dca-bot/internal/storage"
+	"github.com/kirillm/dca-bot/internal/storage/migrations"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: migrate [flags] up | down | status")
+		flag.PrintDefaults()
+	}
+	steps := flag.Int("steps", 1, "сколько миграций откатить командой down")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fail(err)
+	}
+	db, dialect, err := storage.OpenDB(cfg.Database)
+	if err != nil {
+		fail(err)
+	}
+	defer db.Close()
+
+	migrator, err := migrations.NewForDialect(db, dialect)
+	if err != nil {
+		fail(err)
+	}
+	ctx := context.Background()
+
+	switch flag.Arg(0) {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("✅ %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fail(err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("Схема актуальна")
+		}
+	case "down":
+		if *steps < 1 {
+			fail(fmt.Errorf("-steps must be positive"))
+		}
+		reverted, err := migrator.Down(ctx, *steps)
+		for _, m := range reverted {
+			fmt.Printf("↩️  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fail(err)
+		}
+		if len(reverted) == 0 {
+			fmt.Println("Нет примененных миграций")
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		for _, s := range statuses {
+			if s.Applied {
+				fmt.Printf("✅ %04d_%-25s %s\n", s.Version, s.Name, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Printf("⏳ %04d_%-25s pending\n", s.Version, s.Name)
+			}
+		}
+		if err != nil {
+			fail(err)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "❌", err)
+	os.Exit(1)
+}
@@ -0,0 +1,392 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// ActionClass класс действия AI по степени влияния на систему
+type ActionClass string
+
+const (
+	ActionClassRead      ActionClass = "read"      // только чтение, выполняется сразу
+	ActionClassMutate    ActionClass = "mutate"    // меняет конфигурацию, нужно подтверждение
+	ActionClassDangerous ActionClass = "dangerous" // останавливает торговлю/перераспределяет капитал, нужны права админа
+)
+
+// actionClasses классы всех действий ActionExecutor
+var actionClasses = map[string]ActionClass{
+	// Чтение
+	"list_assets":         ActionClassRead,
+	"grid_status":         ActionClassRead,
+	"portfolio_summary":   ActionClassRead,
+	"asset_allocation":    ActionClassRead,
+	"risk_status":         ActionClassRead,
+	"performance_metrics": ActionClassRead,
+	"pnl_history":         ActionClassRead,
+	"get_status":          ActionClassRead,
+	"get_history":         ActionClassRead,
+	"get_price":           ActionClassRead,
+
+	// Изменение конфигурации
+	"add_asset":               ActionClassMutate,
+	"update_asset":            ActionClassMutate,
+	"enable_asset":            ActionClassMutate,
+	"disable_asset":           ActionClassMutate,
+	"init_grid":               ActionClassMutate,
+	"set_stop_loss":           ActionClassMutate,
+	"set_take_profit":         ActionClassMutate,
+	"update_dca_amount":       ActionClassMutate,
+	"update_autosell_trigger": ActionClassMutate,
+	"enable_autosell":         ActionClassMutate,
+	"disable_autosell":        ActionClassMutate,
+
+	// Опасные
+	"remove_asset":        ActionClassDangerous,
+	"stop_grid":           ActionClassDangerous,
+	"allocate_capital":    ActionClassDangerous,
+	"rebalance_portfolio": ActionClassDangerous,
+	"update_risk_limits":  ActionClassDangerous,
+	"emergency_stop":      ActionClassDangerous,
+
+	// Legacy инструменты Bot.executeAIAction
+	"get_portfolio":          ActionClassRead,
+	"get_grid_status":        ActionClassRead,
+	"update_autosell_amount": ActionClassMutate,
+	"manual_buy":             ActionClassMutate,
+	"manual_sell":            ActionClassDangerous,
+}
+
+// ClassifyAction возвращает класс действия. Неизвестные действия считаются опасными.
+func ClassifyAction(actionType string) ActionClass {
+	if class, ok := actionClasses[actionType]; ok {
+		return class
+	}
+	return ActionClassDangerous
+}
+
+// Class класс действия
+func (a AIAction) Class() ActionClass {
+	return ClassifyAction(a.Type)
+}
+
+// FieldChange изменение одного поля: текущее → предлагаемое значение
+type FieldChange struct {
+	Target   string // символ актива или "risk_limits"
+	Field    string
+	Current  string
+	Proposed string
+}
+
+// ActionPreview предпросмотр действия перед подтверждением
+type ActionPreview struct {
+	Action  AIAction
+	Class   ActionClass
+	Changes []FieldChange
+	Notes   []string
+}
+
+// Format форматирует предпросмотр для Telegram
+func (p *ActionPreview) Format() string {
+	var sb strings.Builder
+
+	icon := "✏️"
+	if p.Class == ActionClassDangerous {
+		icon = "🚨"
+	}
+	sb.WriteString(fmt.Sprintf("%s %s (%s)\n", icon, p.Action.Type, p.Class))
+
+	for _, c := range p.Changes {
+		sb.WriteString(fmt.Sprintf("  %s.%s: %s → %s\n", c.Target, c.Field, c.Current, c.Proposed))
+	}
+	for _, note := range p.Notes {
+		sb.WriteString("  ℹ️ " + note + "\n")
+	}
+	if len(p.Changes) == 0 && len(p.Notes) == 0 {
+		sb.WriteString("  без изменений конфигурации\n")
+	}
+
+	return sb.String()
+}
+
+// Preview строит diff текущей и предлагаемой конфигурации без выполнения действия
+func (e *ActionExecutor) Preview(action AIAction) (*ActionPreview, error) {
+	ctx := context.Background()
+	preview := &ActionPreview{Action: action, Class: action.Class()}
+	params := action.Parameters
+	symbol, _ := params["symbol"].(string)
+
+	switch action.Type {
+	case "add_asset":
+		current := &domain.Asset{Symbol: symbol}
+		if existing, err := e.storage.Assets().Get(ctx, symbol); err == nil && existing != nil {
+			current = existing
+			preview.Notes = append(preview.Notes, "актив уже существует и будет перезаписан")
+		}
+		preview.Changes = assetChanges(current, params)
+
+	case "update_asset":
+		asset, err := e.requireAsset(symbol)
+		if err != nil {
+			return nil, err
+		}
+		preview.Changes = assetChanges(asset, params)
+
+	case "enable_asset", "disable_asset", "remove_asset":
+		asset, err := e.requireAsset(symbol)
+		if err != nil {
+			return nil, err
+		}
+		preview.Changes = assetChanges(asset, map[string]interface{}{"enabled": action.Type == "enable_asset"})
+		if action.Type == "remove_asset" {
+			preview.Notes = append(preview.Notes, "все Grid ордера "+symbol+" будут отменены")
+		}
+
+	case "init_grid":
+		asset, err := e.requireAsset(symbol)
+		if err != nil {
+			return nil, err
+		}
+		preview.Notes = append(preview.Notes, fmt.Sprintf("будет выставлена сетка %s: %d уровней, %.2f%%, $%.2f на ордер",
+			symbol, asset.GridLevels, asset.GridSpacingPercent, asset.GridOrderSize))
+
+	case "stop_grid":
+		preview.Notes = append(preview.Notes, "все Grid ордера "+symbol+" будут отменены")
+
+	case "set_stop_loss", "set_take_profit":
+		field := "stop_loss_percent"
+		if action.Type == "set_take_profit" {
+			field = "take_profit_percent"
+		}
+		changes, err := e.assetsChanges(symbol, map[string]interface{}{field: params["percent"]}, nil)
+		if err != nil {
+			return nil, err
+		}
+		preview.Changes = changes
+
+	case "update_dca_amount":
+		changes, err := e.assetsChanges("", map[string]interface{}{"dca_amount": params["amount"]}, func(a *domain.Asset) bool {
+			return a.StrategyType == "DCA" || a.StrategyType == "HYBRID"
+		})
+		if err != nil {
+			return nil, err
+		}
+		preview.Changes = changes
+
+	case "update_autosell_trigger", "enable_autosell", "disable_autosell":
+		update := map[string]interface{}{"auto_sell_trigger_percent": params["percent"]}
+		if action.Type != "update_autosell_trigger" {
+			update = map[string]interface{}{"auto_sell_enabled": action.Type == "enable_autosell"}
+		}
+		changes, err := e.assetsChanges("", update, nil)
+		if err != nil {
+			return nil, err
+		}
+		preview.Changes = changes
+
+	case "update_risk_limits":
+		limits, err := e.storage.Risk().GetLimits(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось получить риск-лимиты: %w", err)
+		}
+		preview.Changes = riskLimitChanges(limits, params)
+
+	case "emergency_stop":
+		enabled, ok := params["enabled"].(bool)
+		if !ok {
+			enabled = true
+		}
+		current := "?"
+		if status, err := e.riskManager.GetRiskStatus(); err == nil {
+			current = fmt.Sprint(status["emergency_stop_enabled"])
+		}
+		preview.Changes = []FieldChange{{Target: "risk", Field: "emergency_stop", Current: current, Proposed: fmt.Sprint(enabled)}}
+		if enabled {
+			preview.Notes = append(preview.Notes, "вся автоматическая торговля будет остановлена")
+		}
+
+	case "allocate_capital":
+		total, _ := params["total_capital"].(float64)
+		preview.Notes = append(preview.Notes, fmt.Sprintf("капитал $%.2f будет распределен между активными активами", total))
+		assets, err := e.storage.Assets().GetEnabled(ctx)
+		if err == nil {
+			for _, asset := range assets {
+				preview.Notes = append(preview.Notes, fmt.Sprintf("%s сейчас: $%.2f", asset.Symbol, asset.AllocatedCapital))
+			}
+		}
+
+	case "rebalance_portfolio":
+		preview.Notes = append(preview.Notes, "будут выставлены ордера для возврата к целевому распределению")
+	}
+
+	return preview, nil
+}
+
+// requireAsset загружает актив или возвращает ошибку
+func (e *ActionExecutor) requireAsset(symbol string) (*domain.Asset, error) {
+	ctx := context.Background()
+	if symbol == "" {
+		return nil, fmt.Errorf("параметр symbol обязателен")
+	}
+	asset, err := e.storage.Assets().Get(ctx, symbol)
+	if err != nil || asset == nil {
+		return nil, fmt.Errorf("актив %s не найден", symbol)
+	}
+	return asset, nil
+}
+
+// OrderSizes суммы ордеров в USD, которые действие разместит или задаст стратегии -
+// к каждой применяется лимит ордера роли. Не указанные в параметрах суммы берутся
+// из актива (init_grid, manual_buy) или котировки продажи (manual_sell)
+func (e *ActionExecutor) OrderSizes(action AIAction) ([]float64, error) {
+	ctx := context.Background()
+	params := action.Parameters
+	symbol, _ := params["symbol"].(string)
+
+	switch action.Type {
+	case "init_grid":
+		asset, err := e.requireAsset(symbol)
+		if err != nil {
+			return nil, err
+		}
+		return []float64{asset.GridOrderSize}, nil
+
+	case "manual_buy":
+		if getFloatParam(params, "amount", 0) > 0 {
+			break
+		}
+		asset, err := e.requireAsset(symbol)
+		if err != nil {
+			return nil, err
+		}
+		return []float64{asset.DCAAmount}, nil
+
+	case "manual_sell":
+		percent, _ := params["percent"].(float64)
+		quantity, price, err := e.trader.Quote(ctx, symbol, percent)
+		if err != nil {
+			return nil, err
+		}
+		return []float64{quantity * price}, nil
+	}
+
+	return ParamOrderSizes(action), nil
+}
+
+// ParamOrderSizes суммы ордеров, заданные параметрами действия (с умолчаниями исполнителя)
+func ParamOrderSizes(action AIAction) []float64 {
+	params := action.Parameters
+	var sizes []float64
+
+	switch action.Type {
+	case "add_asset":
+		sizes = append(sizes, getFloatParam(params, "dca_amount", 10))
+		if size := getFloatParam(params, "grid_order_size", 0); size > 0 {
+			sizes = append(sizes, size)
+		}
+	case "update_asset":
+		if size := getFloatParam(params, "dca_amount", 0); size > 0 {
+			sizes = append(sizes, size)
+		}
+	case "update_dca_amount", "manual_buy":
+		if size := getFloatParam(params, "amount", 0); size > 0 {
+			sizes = append(sizes, size)
+		}
+	}
+	return sizes
+}
+
+// assetsChanges diff для одного актива (symbol) или всех активных, прошедших filter
+func (e *ActionExecutor) assetsChanges(symbol string, params map[string]interface{}, filter func(*domain.Asset) bool) ([]FieldChange, error) {
+	ctx := context.Background()
+	if symbol != "" {
+		asset, err := e.requireAsset(symbol)
+		if err != nil {
+			return nil, err
+		}
+		return assetChanges(asset, params), nil
+	}
+
+	assets, err := e.storage.Assets().GetEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []FieldChange
+	for i := range assets {
+		if filter != nil && !filter(&assets[i]) {
+			continue
+		}
+		changes = append(changes, assetChanges(&assets[i], params)...)
+	}
+	return changes, nil
+}
+
+// assetFields текущие значения полей актива, которые может менять AI
+func assetFields(a *domain.Asset) map[string]interface{} {
+	return map[string]interface{}{
+		"enabled":                   a.Enabled,
+		"strategy_type":             a.StrategyType,
+		"allocated_capital":         a.AllocatedCapital,
+		"max_position_size":         a.MaxPositionSize,
+		"dca_amount":                a.DCAAmount,
+		"dca_interval_hours":        float64(a.DCAInterval) / 60,
+		"auto_sell_enabled":         a.AutoSellEnabled,
+		"auto_sell_trigger_percent": a.AutoSellTriggerPercent,
+		"auto_sell_amount_percent":  a.AutoSellAmountPercent,
+		"grid_levels":               float64(a.GridLevels),
+		"grid_spacing_percent":      a.GridSpacingPercent,
+		"grid_order_size":           a.GridOrderSize,
+		"stop_loss_percent":         a.StopLossPercent,
+		"take_profit_percent":       a.TakeProfitPercent,
+	}
+}
+
+// assetChanges сравнивает актив с предлагаемыми параметрами; неизвестные ключи игнорируются
+func assetChanges(asset *domain.Asset, params map[string]interface{}) []FieldChange {
+	return diffFields(asset.Symbol, assetFields(asset), params)
+}
+
+// riskLimitChanges сравнивает риск-лимиты с предлагаемыми параметрами
+func riskLimitChanges(limits *domain.RiskLimit, params map[string]interface{}) []FieldChange {
+	current := map[string]interface{}{
+		"max_daily_loss":        limits.MaxDailyLoss,
+		"max_total_exposure":    limits.MaxTotalExposure,
+		"max_position_size_usd": limits.MaxPositionSizeUSD,
+		"max_order_size_usd":    limits.MaxOrderSizeUSD,
+	}
+	return diffFields("risk_limits", current, params)
+}
+
+// diffFields возвращает изменившиеся поля в стабильном порядке
+func diffFields(target string, current, proposed map[string]interface{}) []FieldChange {
+	keys := make([]string, 0, len(proposed))
+	for key := range proposed {
+		if _, known := current[key]; known && proposed[key] != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []FieldChange
+	for _, key := range keys {
+		cur, next := formatValue(current[key]), formatValue(proposed[key])
+		if cur == next {
+			continue
+		}
+		changes = append(changes, FieldChange{Target: target, Field: key, Current: cur, Proposed: next})
+	}
+	return changes
+}
+
+// formatValue единый формат чисел, чтобы 10 и 10.0 не давали ложный diff
+func formatValue(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.4f", f), "0"), ".")
+	}
+	return fmt.Sprint(v)
+}
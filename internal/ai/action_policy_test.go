@@ -0,0 +1,102 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+func TestClassifyAction(t *testing.T) {
+	tests := []struct {
+		action string
+		want   ActionClass
+	}{
+		{"get_price", ActionClassRead},
+		{"portfolio_summary", ActionClassRead},
+		{"grid_status", ActionClassRead},
+		{"get_grid_status", ActionClassRead},
+		{"update_asset", ActionClassMutate},
+		{"enable_autosell", ActionClassMutate},
+		{"emergency_stop", ActionClassDangerous},
+		{"update_risk_limits", ActionClassDangerous},
+		{"remove_asset", ActionClassDangerous},
+		{"drop_database", ActionClassDangerous}, // неизвестное - опасное
+	}
+
+	for _, tt := range tests {
+		if got := ClassifyAction(tt.action); got != tt.want {
+			t.Errorf("ClassifyAction(%q) = %s, want %s", tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestBuildToolsHaveExplicitClass(t *testing.T) {
+	// Инструмент без класса считался бы опасным - даже запрос статуса требовал бы прав админа
+	for _, tool := range (&AIClient{}).buildTools() {
+		if _, ok := actionClasses[tool.Function.Name]; !ok {
+			t.Errorf("tool %s has no entry in actionClasses", tool.Function.Name)
+		}
+	}
+}
+
+func TestParamOrderSizes(t *testing.T) {
+	tests := []struct {
+		action AIAction
+		want   []float64
+	}{
+		{AIAction{Type: "update_dca_amount", Parameters: map[string]interface{}{"amount": 250.0}}, []float64{250}},
+		{AIAction{Type: "manual_buy", Parameters: map[string]interface{}{"symbol": "BTCUSDT", "amount": 40.0}}, []float64{40}},
+		{AIAction{Type: "add_asset", Parameters: map[string]interface{}{"symbol": "ETHUSDT", "grid_order_size": 75.0}}, []float64{10, 75}},
+		{AIAction{Type: "update_asset", Parameters: map[string]interface{}{"symbol": "ETHUSDT", "stop_loss_percent": 5.0}}, nil},
+		{AIAction{Type: "get_price", Parameters: map[string]interface{}{"symbol": "BTCUSDT"}}, nil},
+	}
+
+	for _, tt := range tests {
+		got := ParamOrderSizes(tt.action)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: sizes = %v, want %v", tt.action.Type, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: sizes = %v, want %v", tt.action.Type, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestAssetChanges(t *testing.T) {
+	asset := &domain.Asset{Symbol: "BTCUSDT", Enabled: true, DCAAmount: 10, StopLossPercent: 5}
+
+	changes := assetChanges(asset, map[string]interface{}{
+		"dca_amount":        25.0,
+		"stop_loss_percent": 5.0, // без изменений
+		"unknown_field":     1.0, // игнорируется
+		"enabled":           false,
+	})
+
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", changes)
+	}
+	if changes[0].Field != "dca_amount" || changes[0].Current != "10" || changes[0].Proposed != "25" {
+		t.Errorf("unexpected dca change %+v", changes[0])
+	}
+	if changes[1].Field != "enabled" || changes[1].Current != "true" || changes[1].Proposed != "false" {
+		t.Errorf("unexpected enabled change %+v", changes[1])
+	}
+}
+
+func TestRiskLimitChangesPreview(t *testing.T) {
+	limits := &domain.RiskLimit{MaxDailyLoss: 100, MaxOrderSizeUSD: 50}
+	preview := &ActionPreview{
+		Action:  AIAction{Type: "update_risk_limits"},
+		Class:   ActionClassDangerous,
+		Changes: riskLimitChanges(limits, map[string]interface{}{"max_daily_loss": 500.0}),
+	}
+
+	text := preview.Format()
+	if !strings.Contains(text, "risk_limits.max_daily_loss: 100 → 500") || !strings.Contains(text, "🚨") {
+		t.Fatalf("unexpected preview:\n%s", text)
+	}
+}
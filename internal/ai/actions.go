@@ -1,9 +1,11 @@
 package ai
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
+	"github.com/kirillm/dca-bot/internal/domain"
 	"github.com/kirillm/dca-bot/internal/exchange"
 	"github.com/kirillm/dca-bot/internal/storage"
 	"github.com/kirillm/dca-bot/internal/strategy"
@@ -12,15 +14,16 @@ import (
 
 // ActionExecutor выполняет действия, запрошенные AI
 type ActionExecutor struct {
-	storage          *storage.PostgresStorage
+	storage          *storage.Storage
 	exchange         *exchange.BybitClient
 	portfolioManager *strategy.PortfolioManager
 	riskManager      *strategy.RiskManager
 	gridStrategy     *strategy.GridStrategy
+	trader           *strategy.ManualTrader
 }
 
 func NewActionExecutor(
-	storage *storage.PostgresStorage,
+	storage *storage.Storage,
 	exchange *exchange.BybitClient,
 	portfolioManager *strategy.PortfolioManager,
 	riskManager *strategy.RiskManager,
@@ -32,9 +35,15 @@ func NewActionExecutor(
 		portfolioManager: portfolioManager,
 		riskManager:      riskManager,
 		gridStrategy:     gridStrategy,
+		trader:           strategy.NewManualTrader(storage, exchange),
 	}
 }
 
+// SetManualTrader использует общий ManualTrader (с подключенной шиной событий) для manual_buy/manual_sell
+func (e *ActionExecutor) SetManualTrader(trader *strategy.ManualTrader) {
+	e.trader = trader
+}
+
 // ExecuteAction выполняет действие AI
 func (e *ActionExecutor) ExecuteAction(action AIAction) (string, error) {
 	utils.LogInfo(fmt.Sprintf("Выполнение AI действия: %s", action.Type))
@@ -59,11 +68,11 @@ func (e *ActionExecutor) ExecuteAction(action AIAction) (string, error) {
 		return e.initGrid(action.Parameters)
 	case "stop_grid":
 		return e.stopGrid(action.Parameters)
-	case "grid_status":
+	case "grid_status", "get_grid_status":
 		return e.gridStatus(action.Parameters)
 
 	// ===== ПОРТФЕЛЬ =====
-	case "portfolio_summary":
+	case "portfolio_summary", "get_portfolio":
 		return e.portfolioSummary()
 	case "asset_allocation":
 		return e.assetAllocation()
@@ -97,6 +106,8 @@ func (e *ActionExecutor) ExecuteAction(action AIAction) (string, error) {
 		return e.updateDCAAmount(action.Parameters)
 	case "update_autosell_trigger":
 		return e.updateAutoSellTrigger(action.Parameters)
+	case "update_autosell_amount":
+		return e.updateAutoSellAmount(action.Parameters)
 	case "enable_autosell":
 		return e.enableAutoSell(action.Parameters)
 	case "disable_autosell":
@@ -105,6 +116,10 @@ func (e *ActionExecutor) ExecuteAction(action AIAction) (string, error) {
 		return e.getHistory(action.Parameters)
 	case "get_price":
 		return e.getPrice(action.Parameters)
+	case "manual_buy":
+		return e.manualBuy(action.Parameters)
+	case "manual_sell":
+		return e.manualSell(action.Parameters)
 
 	default:
 		return "", fmt.Errorf("неизвестное действие: %s", action.Type)
@@ -114,6 +129,7 @@ func (e *ActionExecutor) ExecuteAction(action AIAction) (string, error) {
 // ===== MULTI-ASSET УПРАВЛЕНИЕ =====
 
 func (e *ActionExecutor) addAsset(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
 	symbol, ok := params["symbol"].(string)
 	if !ok {
 		return "", fmt.Errorf("параметр symbol обязателен")
@@ -124,7 +140,7 @@ func (e *ActionExecutor) addAsset(params map[string]interface{}) (string, error)
 		strategyType = "DCA"
 	}
 
-	asset := &storage.Asset{
+	asset := &domain.Asset{
 		Symbol:                symbol,
 		Enabled:               true,
 		StrategyType:          strategyType,
@@ -142,7 +158,7 @@ func (e *ActionExecutor) addAsset(params map[string]interface{}) (string, error)
 		TakeProfitPercent:     getFloatParam(params, "take_profit_percent", 0),
 	}
 
-	if err := e.storage.CreateOrUpdateAsset(asset); err != nil {
+	if err := e.storage.Assets().CreateOrUpdate(ctx, asset); err != nil {
 		return "", fmt.Errorf("не удалось добавить актив: %w", err)
 	}
 
@@ -157,17 +173,18 @@ func (e *ActionExecutor) addAsset(params map[string]interface{}) (string, error)
 }
 
 func (e *ActionExecutor) removeAsset(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
 	symbol, ok := params["symbol"].(string)
 	if !ok {
 		return "", fmt.Errorf("параметр symbol обязателен")
 	}
 
-	if err := e.storage.DisableAsset(symbol); err != nil {
+	if err := e.storage.Assets().Disable(ctx, symbol); err != nil {
 		return "", fmt.Errorf("не удалось удалить актив: %w", err)
 	}
 
 	// Отменяем все Grid ордера
-	if err := e.storage.CancelGridOrders(symbol); err != nil {
+	if err := e.storage.GridOrders().CancelAll(ctx, symbol); err != nil {
 		utils.LogError(fmt.Sprintf("Не удалось отменить Grid ордера для %s: %v", symbol, err))
 	}
 
@@ -175,12 +192,13 @@ func (e *ActionExecutor) removeAsset(params map[string]interface{}) (string, err
 }
 
 func (e *ActionExecutor) updateAsset(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
 	symbol, ok := params["symbol"].(string)
 	if !ok {
 		return "", fmt.Errorf("параметр symbol обязателен")
 	}
 
-	asset, err := e.storage.GetAsset(symbol)
+	asset, err := e.storage.Assets().Get(ctx, symbol)
 	if err != nil || asset == nil {
 		return "", fmt.Errorf("актив %s не найден", symbol)
 	}
@@ -208,7 +226,7 @@ func (e *ActionExecutor) updateAsset(params map[string]interface{}) (string, err
 		asset.GridSpacingPercent = val.(float64)
 	}
 
-	if err := e.storage.CreateOrUpdateAsset(asset); err != nil {
+	if err := e.storage.Assets().CreateOrUpdate(ctx, asset); err != nil {
 		return "", fmt.Errorf("не удалось обновить актив: %w", err)
 	}
 
@@ -216,12 +234,13 @@ func (e *ActionExecutor) updateAsset(params map[string]interface{}) (string, err
 }
 
 func (e *ActionExecutor) enableAsset(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
 	symbol, ok := params["symbol"].(string)
 	if !ok {
 		return "", fmt.Errorf("параметр symbol обязателен")
 	}
 
-	if err := e.storage.EnableAsset(symbol); err != nil {
+	if err := e.storage.Assets().Enable(ctx, symbol); err != nil {
 		return "", err
 	}
 
@@ -229,12 +248,13 @@ func (e *ActionExecutor) enableAsset(params map[string]interface{}) (string, err
 }
 
 func (e *ActionExecutor) disableAsset(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
 	symbol, ok := params["symbol"].(string)
 	if !ok {
 		return "", fmt.Errorf("параметр symbol обязателен")
 	}
 
-	if err := e.storage.DisableAsset(symbol); err != nil {
+	if err := e.storage.Assets().Disable(ctx, symbol); err != nil {
 		return "", err
 	}
 
@@ -242,7 +262,8 @@ func (e *ActionExecutor) disableAsset(params map[string]interface{}) (string, er
 }
 
 func (e *ActionExecutor) listAssets() (string, error) {
-	assets, err := e.storage.GetAllAssets()
+	ctx := context.Background()
+	assets, err := e.storage.Assets().GetAll(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -280,12 +301,13 @@ func (e *ActionExecutor) listAssets() (string, error) {
 // ===== GRID СТРАТЕГИЯ =====
 
 func (e *ActionExecutor) initGrid(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
 	symbol, ok := params["symbol"].(string)
 	if !ok {
 		return "", fmt.Errorf("параметр symbol обязателен")
 	}
 
-	asset, err := e.storage.GetAsset(symbol)
+	asset, err := e.storage.Assets().Get(ctx, symbol)
 	if err != nil || asset == nil {
 		return "", fmt.Errorf("актив %s не найден", symbol)
 	}
@@ -298,12 +320,13 @@ func (e *ActionExecutor) initGrid(params map[string]interface{}) (string, error)
 }
 
 func (e *ActionExecutor) stopGrid(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
 	symbol, ok := params["symbol"].(string)
 	if !ok {
 		return "", fmt.Errorf("параметр symbol обязателен")
 	}
 
-	if err := e.storage.CancelGridOrders(symbol); err != nil {
+	if err := e.storage.GridOrders().CancelAll(ctx, symbol); err != nil {
 		return "", err
 	}
 
@@ -374,6 +397,7 @@ func (e *ActionExecutor) rebalancePortfolio() (string, error) {
 // ===== РИСК МЕНЕДЖМЕНТ =====
 
 func (e *ActionExecutor) setStopLoss(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
 	symbol, _ := params["symbol"].(string)
 	percent, ok := params["percent"].(float64)
 	if !ok {
@@ -382,22 +406,22 @@ func (e *ActionExecutor) setStopLoss(params map[string]interface{}) (string, err
 
 	if symbol == "" {
 		// Устанавливаем для всех активов
-		assets, err := e.storage.GetEnabledAssets()
+		assets, err := e.storage.Assets().GetEnabled(ctx)
 		if err != nil {
 			return "", err
 		}
 		for _, asset := range assets {
 			asset.StopLossPercent = percent
-			e.storage.CreateOrUpdateAsset(&asset)
+			e.storage.Assets().CreateOrUpdate(ctx, &asset)
 		}
 		return fmt.Sprintf("Stop-Loss %.2f%% установлен для всех активов", percent), nil
 	} else {
-		asset, err := e.storage.GetAsset(symbol)
+		asset, err := e.storage.Assets().Get(ctx, symbol)
 		if err != nil || asset == nil {
 			return "", fmt.Errorf("актив не найден")
 		}
 		asset.StopLossPercent = percent
-		if err := e.storage.CreateOrUpdateAsset(asset); err != nil {
+		if err := e.storage.Assets().CreateOrUpdate(ctx, asset); err != nil {
 			return "", err
 		}
 		return fmt.Sprintf("Stop-Loss %.2f%% установлен для %s", percent, symbol), nil
@@ -405,6 +429,7 @@ func (e *ActionExecutor) setStopLoss(params map[string]interface{}) (string, err
 }
 
 func (e *ActionExecutor) setTakeProfit(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
 	symbol, _ := params["symbol"].(string)
 	percent, ok := params["percent"].(float64)
 	if !ok {
@@ -412,22 +437,22 @@ func (e *ActionExecutor) setTakeProfit(params map[string]interface{}) (string, e
 	}
 
 	if symbol == "" {
-		assets, err := e.storage.GetEnabledAssets()
+		assets, err := e.storage.Assets().GetEnabled(ctx)
 		if err != nil {
 			return "", err
 		}
 		for _, asset := range assets {
 			asset.TakeProfitPercent = percent
-			e.storage.CreateOrUpdateAsset(&asset)
+			e.storage.Assets().CreateOrUpdate(ctx, &asset)
 		}
 		return fmt.Sprintf("Take-Profit %.2f%% установлен для всех активов", percent), nil
 	} else {
-		asset, err := e.storage.GetAsset(symbol)
+		asset, err := e.storage.Assets().Get(ctx, symbol)
 		if err != nil || asset == nil {
 			return "", fmt.Errorf("актив не найден")
 		}
 		asset.TakeProfitPercent = percent
-		if err := e.storage.CreateOrUpdateAsset(asset); err != nil {
+		if err := e.storage.Assets().CreateOrUpdate(ctx, asset); err != nil {
 			return "", err
 		}
 		return fmt.Sprintf("Take-Profit %.2f%% установлен для %s", percent, symbol), nil
@@ -435,7 +460,8 @@ func (e *ActionExecutor) setTakeProfit(params map[string]interface{}) (string, e
 }
 
 func (e *ActionExecutor) updateRiskLimits(params map[string]interface{}) (string, error) {
-	limits, err := e.storage.GetRiskLimits()
+	ctx := context.Background()
+	limits, err := e.storage.Risk().GetLimits(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -453,7 +479,7 @@ func (e *ActionExecutor) updateRiskLimits(params map[string]interface{}) (string
 		limits.MaxOrderSizeUSD = val.(float64)
 	}
 
-	if err := e.storage.UpdateRiskLimits(limits); err != nil {
+	if err := e.storage.Risk().UpdateLimits(ctx, limits); err != nil {
 		return "", err
 	}
 
@@ -504,6 +530,7 @@ func (e *ActionExecutor) performanceMetrics(params map[string]interface{}) (stri
 }
 
 func (e *ActionExecutor) pnlHistory(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
 	symbol, ok := params["symbol"].(string)
 	if !ok {
 		return "", fmt.Errorf("параметр symbol обязателен")
@@ -511,7 +538,7 @@ func (e *ActionExecutor) pnlHistory(params map[string]interface{}) (string, erro
 
 	limit := int(getFloatParam(params, "limit", 7))
 
-	history, err := e.storage.GetPnLHistory(symbol, "DAILY", limit)
+	history, err := e.storage.PnL().GetHistory(ctx, symbol, "DAILY", limit)
 	if err != nil {
 		return "", err
 	}
@@ -536,13 +563,14 @@ func (e *ActionExecutor) getStatus(params map[string]interface{}) (string, error
 }
 
 func (e *ActionExecutor) updateDCAAmount(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
 	amount, ok := params["amount"].(float64)
 	if !ok {
 		return "", fmt.Errorf("параметр amount обязателен")
 	}
 
 	// Обновляем для дефолтного актива или всех DCA активов
-	assets, err := e.storage.GetEnabledAssets()
+	assets, err := e.storage.Assets().GetEnabled(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -551,7 +579,7 @@ func (e *ActionExecutor) updateDCAAmount(params map[string]interface{}) (string,
 	for _, asset := range assets {
 		if asset.StrategyType == "DCA" || asset.StrategyType == "HYBRID" {
 			asset.DCAAmount = amount
-			e.storage.CreateOrUpdateAsset(&asset)
+			e.storage.Assets().CreateOrUpdate(ctx, &asset)
 			updated++
 		}
 	}
@@ -560,56 +588,80 @@ func (e *ActionExecutor) updateDCAAmount(params map[string]interface{}) (string,
 }
 
 func (e *ActionExecutor) updateAutoSellTrigger(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
 	percent, ok := params["percent"].(float64)
 	if !ok {
 		return "", fmt.Errorf("параметр percent обязателен")
 	}
 
-	assets, err := e.storage.GetEnabledAssets()
+	assets, err := e.storage.Assets().GetEnabled(ctx)
 	if err != nil {
 		return "", err
 	}
 
 	for _, asset := range assets {
 		asset.AutoSellTriggerPercent = percent
-		e.storage.CreateOrUpdateAsset(&asset)
+		e.storage.Assets().CreateOrUpdate(ctx, &asset)
 	}
 
 	return fmt.Sprintf("Триггер Auto-Sell установлен на %.2f%%", percent), nil
 }
 
+func (e *ActionExecutor) updateAutoSellAmount(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
+	percent, ok := params["percent"].(float64)
+	if !ok || percent <= 0 || percent > 100 {
+		return "", fmt.Errorf("параметр percent обязателен (1-100)")
+	}
+
+	assets, err := e.storage.Assets().GetEnabled(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	for _, asset := range assets {
+		asset.AutoSellAmountPercent = percent
+		e.storage.Assets().CreateOrUpdate(ctx, &asset)
+	}
+
+	return fmt.Sprintf("Auto-Sell продает %.2f%% позиции", percent), nil
+}
+
 func (e *ActionExecutor) enableAutoSell(params map[string]interface{}) (string, error) {
-	assets, err := e.storage.GetEnabledAssets()
+	ctx := context.Background()
+	assets, err := e.storage.Assets().GetEnabled(ctx)
 	if err != nil {
 		return "", err
 	}
 
 	for _, asset := range assets {
 		asset.AutoSellEnabled = true
-		e.storage.CreateOrUpdateAsset(&asset)
+		e.storage.Assets().CreateOrUpdate(ctx, &asset)
 	}
 
 	return "Auto-Sell включен для всех активов", nil
 }
 
 func (e *ActionExecutor) disableAutoSell(params map[string]interface{}) (string, error) {
-	assets, err := e.storage.GetEnabledAssets()
+	ctx := context.Background()
+	assets, err := e.storage.Assets().GetEnabled(ctx)
 	if err != nil {
 		return "", err
 	}
 
 	for _, asset := range assets {
 		asset.AutoSellEnabled = false
-		e.storage.CreateOrUpdateAsset(&asset)
+		e.storage.Assets().CreateOrUpdate(ctx, &asset)
 	}
 
 	return "Auto-Sell выключен для всех активов", nil
 }
 
 func (e *ActionExecutor) getHistory(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
 	limit := int(getFloatParam(params, "limit", 10))
 
-	trades, err := e.storage.GetAllRecentTrades(limit)
+	trades, err := e.storage.Trades().GetAllRecent(ctx, limit)
 	if err != nil {
 		return "", err
 	}
@@ -624,10 +676,11 @@ func (e *ActionExecutor) getHistory(params map[string]interface{}) (string, erro
 }
 
 func (e *ActionExecutor) getPrice(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
 	symbol, ok := params["symbol"].(string)
 	if !ok {
 		// Получаем цены всех активов
-		assets, err := e.storage.GetEnabledAssets()
+		assets, err := e.storage.Assets().GetEnabled(ctx)
 		if err != nil {
 			return "", err
 		}
@@ -650,6 +703,51 @@ func (e *ActionExecutor) getPrice(params map[string]interface{}) (string, error)
 	}
 }
 
+func (e *ActionExecutor) manualBuy(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
+	symbol, ok := params["symbol"].(string)
+	if !ok {
+		return "", fmt.Errorf("параметр symbol обязателен")
+	}
+
+	// Без amount - сумма DCA актива
+	amount := getFloatParam(params, "amount", 0)
+	if amount == 0 {
+		asset, err := e.storage.Assets().Get(ctx, symbol)
+		if err != nil || asset == nil {
+			return "", fmt.Errorf("актив %s не найден", symbol)
+		}
+		amount = asset.DCAAmount
+	}
+
+	trade, err := e.trader.Buy(ctx, symbol, amount)
+	if err != nil {
+		return "", fmt.Errorf("не удалось купить %s: %w", symbol, err)
+	}
+
+	return fmt.Sprintf("Куплено %.8f %s @ $%.2f на $%.2f", trade.Quantity, symbol, trade.Price, trade.Amount), nil
+}
+
+func (e *ActionExecutor) manualSell(params map[string]interface{}) (string, error) {
+	ctx := context.Background()
+	symbol, ok := params["symbol"].(string)
+	if !ok {
+		return "", fmt.Errorf("параметр symbol обязателен")
+	}
+	percent, ok := params["percent"].(float64)
+	if !ok {
+		return "", fmt.Errorf("параметр percent обязателен")
+	}
+
+	result, err := e.trader.Sell(ctx, symbol, percent)
+	if err != nil {
+		return "", fmt.Errorf("не удалось продать %s: %w", symbol, err)
+	}
+
+	return fmt.Sprintf("Продано %.0f%% позиции %s: %.8f @ $%.2f, прибыль $%.2f",
+		result.Percent, symbol, result.Trade.Quantity, result.Trade.Price, result.Profit), nil
+}
+
 // ===== ВСПОМОГАТЕЛЬНЫЕ ФУНКЦИИ =====
 
 func getFloatParam(params map[string]interface{}, key string, defaultVal float64) float64 {
@@ -0,0 +1,33 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExecuteActionHandlesAllTools(t *testing.T) {
+	executor := &ActionExecutor{}
+
+	for _, tool := range (&AIClient{}).buildTools() {
+		name := tool.Function.Name
+		if _, err := executeRecovered(executor, AIAction{Type: name, Parameters: map[string]interface{}{}}); err != nil &&
+			strings.Contains(err.Error(), "неизвестное действие") {
+			t.Errorf("tool %s offered to the model has no ExecuteAction case", name)
+		}
+	}
+
+	// Классы без инструмента тоже должны исполняться (legacy update_autosell_amount)
+	for name := range actionClasses {
+		if _, err := executeRecovered(executor, AIAction{Type: name, Parameters: map[string]interface{}{}}); err != nil &&
+			strings.Contains(err.Error(), "неизвестное действие") {
+			t.Errorf("classified action %s has no ExecuteAction case", name)
+		}
+	}
+}
+
+// executeRecovered вызывает ExecuteAction у исполнителя без зависимостей:
+// паника на nil-зависимости означает, что обработчик действия найден
+func executeRecovered(executor *ActionExecutor, action AIAction) (result string, err error) {
+	defer func() { recover() }()
+	return executor.ExecuteAction(action)
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/ai/prompts"
 	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
@@ -17,8 +18,11 @@ type AnalysisAgent struct {
 
 // NewAnalysisAgent создает новый analysis agent
 func NewAnalysisAgent(localAIURL, localModel string) *AnalysisAgent {
-	client := ai.NewAIClient("ollama", "", localAIURL, localModel)
+	return NewAnalysisAgentWithClient(ai.NewAIClient(ai.ProviderOllama, "", localAIURL, localModel))
+}
 
+// NewAnalysisAgentWithClient создает analysis agent поверх готового клиента
+func NewAnalysisAgentWithClient(client *ai.AIClient) *AnalysisAgent {
 	return &AnalysisAgent{
 		client: client,
 	}
@@ -26,14 +30,20 @@ func NewAnalysisAgent(localAIURL, localModel string) *AnalysisAgent {
 
 // Process обрабатывает запрос на анализ
 func (aa *AnalysisAgent) Process(ctx context.Context, userMessage string, contextInfo string) (string, []ai.AIAction, error) {
-	utils.LogInfo(fmt.Sprintf("[AnalysisAgent] Processing: %s", userMessage))
+	return aa.ProcessWithHistory(ctx, userMessage, contextInfo, nil)
+}
+
+// ProcessWithHistory обрабатывает запрос на анализ с учетом предыдущих реплик диалога
+// ("а что по эфиру?" после анализа BTC)
+func (aa *AnalysisAgent) ProcessWithHistory(ctx context.Context, userMessage string, contextInfo string, history []ai.Message) (string, []ai.AIAction, error) {
+	utils.LogInfo(fmt.Sprintf("[AnalysisAgent] Processing: %s (history: %d)", userMessage, len(history)))
 
 	// Парсим запрос, чтобы понять, что анализировать
-	symbol, currentPrice, avgEntry := aa.parseAnalysisRequest(userMessage, contextInfo)
+	symbol, currentPrice, avgEntry := aa.parseAnalysisRequest(userMessage, history, contextInfo)
 
 	// Если удалось распарсить цены, используем GetMarketAnalysis
 	if symbol != "" && currentPrice > 0 {
-		analysis, err := aa.client.GetMarketAnalysis(symbol, currentPrice, avgEntry)
+		analysis, err := aa.client.GetTechnicalAnalysisWithHistory(symbol, currentPrice, avgEntry, extractIndicators(contextInfo), history)
 		if err != nil {
 			return "", nil, fmt.Errorf("analysis failed: %w", err)
 		}
@@ -43,7 +53,7 @@ func (aa *AnalysisAgent) Process(ctx context.Context, userMessage string, contex
 	}
 
 	// Иначе используем обычный ProcessMessage
-	response, actions, err := aa.client.ProcessMessage(userMessage, contextInfo)
+	response, actions, err := aa.client.ProcessMessageWithHistory(userMessage, contextInfo, history)
 	if err != nil {
 		return "", nil, fmt.Errorf("analysis agent failed: %w", err)
 	}
@@ -51,17 +61,15 @@ func (aa *AnalysisAgent) Process(ctx context.Context, userMessage string, contex
 	return response, actions, nil
 }
 
-// parseAnalysisRequest пытается извлечь symbol и цены из запроса/контекста
-func (aa *AnalysisAgent) parseAnalysisRequest(userMessage, contextInfo string) (string, float64, float64) {
-	// Ищем упоминания символов
-	symbols := []string{"BTC", "ETH", "SOL", "BNB", "XRP"}
-	var symbol string
-
-	messageLower := strings.ToLower(userMessage + " " + contextInfo)
+// parseAnalysisRequest пытается извлечь symbol и цены из запроса/контекста.
+// Символ ищется сначала в сообщении, затем в истории диалога, затем в контексте.
+func (aa *AnalysisAgent) parseAnalysisRequest(userMessage string, history []ai.Message, contextInfo string) (string, float64, float64) {
+	sources := append([]string{userMessage}, userTurns(history)...)
+	sources = append(sources, contextInfo)
 
-	for _, sym := range symbols {
-		if strings.Contains(messageLower, strings.ToLower(sym)) {
-			symbol = sym + "USDT"
+	var symbol string
+	for _, text := range sources {
+		if symbol = findSymbol(text); symbol != "" {
 			break
 		}
 	}
@@ -91,6 +99,39 @@ func (aa *AnalysisAgent) parseAnalysisRequest(userMessage, contextInfo string) (
 	return symbol, currentPrice, avgEntry
 }
 
+// findSymbol ищет упоминание поддерживаемого символа
+func findSymbol(text string) string {
+	symbols := []string{"BTC", "ETH", "SOL", "BNB", "XRP"}
+	textLower := strings.ToLower(text)
+
+	for _, sym := range symbols {
+		if strings.Contains(textLower, strings.ToLower(sym)) {
+			return sym + "USDT"
+		}
+	}
+	return ""
+}
+
+// userTurns реплики пользователя из истории, последние - первыми
+func userTurns(history []ai.Message) []string {
+	var turns []string
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == "user" {
+			turns = append(turns, history[i].Content)
+		}
+	}
+	return turns
+}
+
+// extractIndicators извлекает блок индикаторов из контекста (см. marketdata.Indicators.Format)
+func extractIndicators(contextInfo string) string {
+	idx := strings.Index(contextInfo, "Indicators (")
+	if idx == -1 {
+		return ""
+	}
+	return contextInfo[idx:]
+}
+
 // extractNumber извлекает первое число из строки
 func extractNumber(s string) string {
 	var result strings.Builder
@@ -108,25 +149,7 @@ func extractNumber(s string) string {
 	return result.String()
 }
 
-// GetSystemPrompt возвращает системный промпт для analysis agent
+// GetSystemPrompt возвращает системный промпт для analysis agent (шаблон prompts.AnalysisAgent)
 func (aa *AnalysisAgent) GetSystemPrompt() string {
-	return `Вы — криптотрейдер-аналитик с опытом технического анализа.
-
-Ваша задача:
-- Анализировать текущую рыночную ситуацию
-- Определять тренды и уровни поддержки/сопротивления
-- Оценивать риски и возможности
-- Давать краткие и объективные рекомендации
-
-Формат ответа:
-1. Текущая ситуация (1 предложение)
-2. Технический анализ (1-2 предложения)
-3. Рекомендация (1 предложение)
-
-Будьте объективны! Не давайте гарантий. Используйте фразы:
-- "Возможно...", "Вероятно...", "Рекомендуется..."
-- "Следует рассмотреть...", "При условии..."
-
-НЕ давайте финансовых советов! Только информационный анализ.
-Максимум 3-4 предложения.`
+	return aa.client.RenderPrompt(prompts.AnalysisAgent, prompts.SectionSystem, nil)
 }
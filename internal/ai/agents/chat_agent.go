@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/ai/prompts"
 	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
@@ -16,8 +17,11 @@ type ChatAgent struct {
 // NewChatAgent создает новый chat agent
 func NewChatAgent(localAIURL, localModel string) *ChatAgent {
 	// Создаем клиент для локальной модели (Ollama)
-	client := ai.NewAIClient("ollama", "", localAIURL, localModel)
+	return NewChatAgentWithClient(ai.NewAIClient(ai.ProviderOllama, "", localAIURL, localModel))
+}
 
+// NewChatAgentWithClient создает chat agent поверх готового клиента (цепочка провайдеров)
+func NewChatAgentWithClient(client *ai.AIClient) *ChatAgent {
 	return &ChatAgent{
 		client: client,
 	}
@@ -25,10 +29,14 @@ func NewChatAgent(localAIURL, localModel string) *ChatAgent {
 
 // Process обрабатывает сообщение пользователя
 func (ca *ChatAgent) Process(ctx context.Context, userMessage string, contextInfo string) (string, []ai.AIAction, error) {
-	utils.LogInfo(fmt.Sprintf("[ChatAgent] Processing: %s", userMessage))
+	return ca.ProcessWithHistory(ctx, userMessage, contextInfo, nil)
+}
 
-	// Используем существующий метод ProcessMessage из AIClient
-	response, actions, err := ca.client.ProcessMessage(userMessage, contextInfo)
+// ProcessWithHistory обрабатывает сообщение с учетом предыдущих реплик диалога
+func (ca *ChatAgent) ProcessWithHistory(ctx context.Context, userMessage string, contextInfo string, history []ai.Message) (string, []ai.AIAction, error) {
+	utils.LogInfo(fmt.Sprintf("[ChatAgent] Processing: %s (history: %d)", userMessage, len(history)))
+
+	response, actions, err := ca.client.ProcessMessageWithHistory(userMessage, contextInfo, history)
 	if err != nil {
 		return "", nil, fmt.Errorf("chat agent failed: %w", err)
 	}
@@ -38,29 +46,9 @@ func (ca *ChatAgent) Process(ctx context.Context, userMessage string, contextInf
 	return response, actions, nil
 }
 
-// GetSystemPrompt возвращает системный промпт для чат агента
+// GetSystemPrompt возвращает системный промпт для чат агента (шаблон prompts.ChatAgent)
 func (ca *ChatAgent) GetSystemPrompt() string {
-	return `Вы — дружелюбный AI-ассистент для управления криптотрейдинг-ботом.
-
-Ваша задача:
-- Отвечать на вопросы пользователя о статусе бота, позициях, ценах
-- Выполнять простые команды через function calling
-- Давать краткие и понятные ответы на русском языке
-- Использовать emoji для наглядности 😊 📊 💰
-
-Доступные функции (ToolCalls):
-- get_status: получить статус позиций
-- get_price: узнать текущую цену актива
-- get_history: посмотреть историю сделок
-- get_portfolio: обзор портфеля
-- init_grid: запустить Grid стратегию
-- enable_autosell / disable_autosell: управление авто-продажей
-- manual_buy / manual_sell: ручная торговля
-
-Если пользователь спрашивает о стратегических решениях ("что делать", "какая стратегия"),
-скажите, что это задача для DecisionAgent и предложите использовать команду /ai_decision.
-
-Будьте краткими! Максимум 2-3 предложения на ответ.`
+	return ca.client.RenderPrompt(prompts.ChatAgent, prompts.SectionSystem, nil)
 }
 
 func truncateResponse(s string) string {
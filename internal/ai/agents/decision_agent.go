@@ -3,35 +3,52 @@ package agents
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/kirillm/dca-bot/internal/ai"
 	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
+// ModeController общий контроллер режима (orchestrator.ModeController)
+type ModeController interface {
+	CurrentMode() string
+	RequestMode(mode string, adminID int64) (bool, error)
+}
+
 // DecisionAgent облачный агент для стратегических решений
 type DecisionAgent struct {
 	decisionClient *ai.DecisionClient
-	mode           string // shadow, pilot, full
+	mode           string // shadow, pilot, full (используется без ModeController)
+	modes          ModeController
+	mu             sync.RWMutex
 }
 
 // NewDecisionAgent создает новый decision agent
 func NewDecisionAgent(cloudAIURL, cloudAPIKey, cloudModel, mode string) *DecisionAgent {
 	// Создаем клиент для облачной модели (Moonshot Kimi K2)
-	baseClient := ai.NewAIClient("moonshot", cloudAPIKey, cloudAIURL, cloudModel)
-	decisionClient := ai.NewDecisionClient(baseClient)
+	return NewDecisionAgentWithClient(ai.NewAIClient("moonshot", cloudAPIKey, cloudAIURL, cloudModel), mode)
+}
 
+// NewDecisionAgentWithClient создает decision agent поверх готового клиента
+func NewDecisionAgentWithClient(baseClient *ai.AIClient, mode string) *DecisionAgent {
 	return &DecisionAgent{
-		decisionClient: decisionClient,
+		decisionClient: ai.NewDecisionClient(baseClient),
 		mode:           mode,
 	}
 }
 
+// SetMaxAttempts задает число попыток получить валидное решение (AI_DECISION_MAX_ATTEMPTS)
+func (da *DecisionAgent) SetMaxAttempts(n int) {
+	da.decisionClient.SetMaxAttempts(n)
+}
+
 // RequestDecision запрашивает стратегическое решение
 func (da *DecisionAgent) RequestDecision(ctx context.Context, req ai.DecisionRequest) (*ai.DecisionResponse, error) {
-	utils.LogInfo(fmt.Sprintf("[DecisionAgent] Requesting decision in %s mode", da.mode))
+	mode := da.GetMode()
+	utils.LogInfo(fmt.Sprintf("[DecisionAgent] Requesting decision in %s mode", mode))
 
 	// Устанавливаем режим
-	req.Mode = da.mode
+	req.Mode = mode
 
 	// Отправляем запрос к облачной модели
 	decision, err := da.decisionClient.RequestDecision(ctx, req)
@@ -46,14 +63,20 @@ func (da *DecisionAgent) RequestDecision(ctx context.Context, req ai.DecisionReq
 		len(decision.Actions),
 	))
 
+	// Решение резервной модели (облако недоступно/бюджет исчерпан) не исполняем
+	if decision.ReducedTrust && len(decision.Actions) > 0 {
+		utils.LogWarn(fmt.Sprintf("[DecisionAgent] REDUCED TRUST (%s/%s) - actions not executed: %v", decision.Provider, decision.Model, decision.Actions))
+		decision.Actions = nil
+	}
+
 	// В режиме shadow только логируем, не возвращаем действия
-	if da.mode == "shadow" {
+	if mode == "shadow" {
 		utils.LogInfo(fmt.Sprintf("[DecisionAgent] SHADOW MODE - actions not executed: %v", decision.Actions))
 		decision.Actions = nil // Очищаем действия
 	}
 
 	// В режиме pilot ограничиваем параметры
-	if da.mode == "pilot" {
+	if mode == "pilot" {
 		decision.Actions = da.applyPilotLimits(decision.Actions)
 	}
 
@@ -69,17 +92,19 @@ func (da *DecisionAgent) applyPilotLimits(actions []ai.Action) []ai.Action {
 
 		// Уменьшаем суммы на 50% в pilot режиме
 		switch action.Type {
-		case "set_dca":
-			if quoteUSDT, ok := action.Parameters["quote_usdt"].(float64); ok {
-				limitedAction.Parameters["quote_usdt"] = quoteUSDT * 0.5
+		case ai.ActionSetDCA:
+			if params, err := action.DCA(); err == nil {
+				params.QuoteUSDT *= 0.5
+				limitedAction.SetParams(params)
 			}
 
-		case "set_grid":
-			if orderSize, ok := action.Parameters["order_size_quote"].(float64); ok {
-				limitedAction.Parameters["order_size_quote"] = orderSize * 0.5
+		case ai.ActionSetGrid:
+			if params, err := action.Grid(); err == nil {
+				params.OrderSizeQuote *= 0.5
+				limitedAction.SetParams(params)
 			}
 
-		case "rebalance":
+		case ai.ActionRebalance:
 			// В pilot режиме не выполняем rebalance
 			utils.LogInfo("[DecisionAgent] PILOT MODE - skipping rebalance action")
 			continue
@@ -99,7 +124,31 @@ func (da *DecisionAgent) applyPilotLimits(actions []ai.Action) []ai.Action {
 	return limitedActions
 }
 
-// SetMode изменяет режим работы агента
+// SetModeController подключает общий контроллер режима
+func (da *DecisionAgent) SetModeController(modes ModeController) {
+	da.mu.Lock()
+	defer da.mu.Unlock()
+	da.modes = modes
+}
+
+// RequestMode запрашивает смену режима через общий контроллер.
+// Без контроллера режим меняется локально.
+func (da *DecisionAgent) RequestMode(mode string, adminID int64) (bool, error) {
+	da.mu.RLock()
+	modes := da.modes
+	da.mu.RUnlock()
+
+	if modes == nil {
+		if err := da.SetMode(mode); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	return modes.RequestMode(mode, adminID)
+}
+
+// SetMode изменяет режим работы агента (только без ModeController)
 func (da *DecisionAgent) SetMode(mode string) error {
 	validModes := map[string]bool{
 		"shadow": true,
@@ -111,6 +160,13 @@ func (da *DecisionAgent) SetMode(mode string) error {
 		return fmt.Errorf("invalid mode: %s (valid: shadow, pilot, full)", mode)
 	}
 
+	da.mu.Lock()
+	defer da.mu.Unlock()
+
+	if da.modes != nil {
+		return fmt.Errorf("mode is managed by orchestrator mode controller")
+	}
+
 	da.mode = mode
 	utils.LogInfo(fmt.Sprintf("[DecisionAgent] Mode changed to: %s", mode))
 	return nil
@@ -118,6 +174,12 @@ func (da *DecisionAgent) SetMode(mode string) error {
 
 // GetMode возвращает текущий режим
 func (da *DecisionAgent) GetMode() string {
+	da.mu.RLock()
+	defer da.mu.RUnlock()
+
+	if da.modes != nil {
+		return da.modes.CurrentMode()
+	}
 	return da.mode
 }
 
@@ -170,52 +232,62 @@ func (da *DecisionAgent) FormatDecision(decision *ai.DecisionResponse) string {
 	}
 
 	// Предупреждение для pilot/shadow режимов
-	if da.mode == "shadow" {
+	mode := da.GetMode()
+	if mode == "shadow" {
 		result += "\n\n⚠️ SHADOW MODE: решение не будет выполнено автоматически"
-	} else if da.mode == "pilot" {
+	} else if mode == "pilot" {
 		result += "\n\n⚠️ PILOT MODE: параметры ограничены (50% от рекомендованных)"
 	}
 
+	if decision.PromptVersion != "" {
+		result += fmt.Sprintf("\n\n📝 Промпт %s · %s", decision.PromptVersion, decision.Model)
+	}
+
 	return result
 }
 
 // formatAction форматирует действие для отображения
 func (da *DecisionAgent) formatAction(action ai.Action) string {
-	switch action.Type {
-	case "set_dca":
+	params, err := action.Params()
+	if err != nil {
+		return fmt.Sprintf("%s для %s (некорректные параметры: %v)", action.Type, action.Symbol, err)
+	}
+
+	switch p := params.(type) {
+	case *ai.DCAParams:
 		return fmt.Sprintf(
 			"DCA для %s: $%.2f каждые %d минут",
 			action.Symbol,
-			action.Parameters["quote_usdt"],
-			action.Parameters["interval_min"],
+			p.QuoteUSDT,
+			p.IntervalMin,
 		)
 
-	case "set_grid":
+	case *ai.GridParams:
 		return fmt.Sprintf(
-			"Grid для %s: %v уровней, %.1f%% spacing, $%.2f за уровень",
+			"Grid для %s: %d уровней, %.1f%% spacing, $%.2f за уровень",
 			action.Symbol,
-			action.Parameters["levels"],
-			action.Parameters["spacing_pct"],
-			action.Parameters["order_size_quote"],
+			p.Levels,
+			p.SpacingPct,
+			p.OrderSizeQuote,
 		)
 
-	case "set_autosell":
+	case *ai.AutoSellParams:
 		return fmt.Sprintf(
 			"Auto-Sell для %s: триггер %.0f%%, продажа %.0f%%",
 			action.Symbol,
-			action.Parameters["trigger_pct"],
-			action.Parameters["sell_pct"],
+			p.TriggerPct,
+			p.SellPct,
 		)
 
-	case "pause_strategy":
+	case *ai.PauseParams:
 		return fmt.Sprintf(
 			"Пауза для %s (причина: %s)",
 			action.Symbol,
-			action.Parameters["reason"],
+			p.Reason,
 		)
 
-	case "rebalance":
-		return fmt.Sprintf("Ребалансировка портфеля: %v", action.Parameters["target_allocation"])
+	case *ai.RebalanceParams:
+		return fmt.Sprintf("Ребалансировка портфеля: %v", p.TargetAllocation)
 
 	default:
 		return fmt.Sprintf("%s для %s", action.Type, action.Symbol)
@@ -0,0 +1,139 @@
+package agents
+
+import (
+	"fmt"
+
+	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/ai/memory"
+	"github.com/kirillm/dca-bot/internal/ai/prompts"
+	"github.com/kirillm/dca-bot/internal/config"
+)
+
+// NewRouterFromConfig собирает агентов с провайдерами из конфигурации.
+// Облачные вызовы учитываются в tracker и блокируются при превышении дневного бюджета;
+// при FallbackToLocal решения уходят на локальную модель с флагом ReducedTrust.
+func NewRouterFromConfig(cfg config.AIConfig, tracker *ai.CostTracker) (*AgentRouter, error) {
+	local, err := ai.NewProvider(ai.ProviderConfig{
+		Name:    "local-" + cfg.Local.Provider,
+		Kind:    cfg.Local.Provider,
+		BaseURL: cfg.Local.URL,
+		Model:   cfg.Local.Model,
+		Cloud:   false,
+		Timeout: cfg.Local.Timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("local provider: %w", err)
+	}
+
+	cloud, err := ai.NewProvider(ai.ProviderConfig{
+		Name:       cfg.Cloud.Provider,
+		Kind:       cloudKind(cfg.Cloud.Provider),
+		BaseURL:    cfg.Cloud.URL,
+		APIKey:     cfg.Cloud.APIKey,
+		Model:      cfg.Cloud.Model,
+		Cloud:      true,
+		Timeout:    cfg.Cloud.Timeout,
+		MaxRetries: cfg.Cloud.MaxRetries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cloud provider: %w", err)
+	}
+
+	registry, err := NewPromptRegistryFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cloudPricing := ai.Pricing{
+		InputPerMillion:  cfg.Cloud.InputPricePerM,
+		OutputPerMillion: cfg.Cloud.OutputPricePerM,
+	}
+
+	// clientFor строит клиента для задачи: основной провайдер + локальный fallback
+	clientFor := func(purpose string, useLocal bool) *ai.AIClient {
+		localMetered := ai.Metered(local, ai.Pricing{}, tracker, purpose)
+
+		var client *ai.AIClient
+		switch {
+		case useLocal || !cfg.Cloud.Enabled:
+			client = ai.NewAIClientWithProvider(localMetered)
+		case cfg.Router.FallbackToLocal && cfg.Local.Enabled:
+			client = ai.NewAIClientWithProvider(ai.NewFallbackChain(ai.Metered(cloud, cloudPricing, tracker, purpose), localMetered))
+		default:
+			client = ai.NewAIClientWithProvider(ai.Metered(cloud, cloudPricing, tracker, purpose))
+		}
+		client.SetPrompts(registry)
+		return client
+	}
+
+	chatAgent := NewChatAgentWithClient(clientFor(ai.PurposeChat, cfg.Router.UseLocalForChat))
+	analysisAgent := NewAnalysisAgentWithClient(clientFor(ai.PurposeAnalysis, cfg.Router.UseLocalForAnalysis))
+	decisionAgent := NewDecisionAgentWithClient(clientFor(ai.PurposeDecision, !cfg.Router.UseCloudForDecisions), cfg.Router.DecisionMode)
+	if cfg.Router.DecisionMaxAttempts > 0 {
+		decisionAgent.SetMaxAttempts(cfg.Router.DecisionMaxAttempts)
+	}
+
+	router := NewAgentRouter(chatAgent, decisionAgent, analysisAgent)
+	router.SetCostTracker(tracker)
+	router.SetPrompts(registry)
+	if cfg.Local.Enabled {
+		classifierClient := ai.NewAIClientWithProvider(ai.Metered(local, ai.Pricing{}, tracker, ai.PurposeRouting))
+		router.SetClassifier(NewLLMIntentClassifier(classifierClient), cfg.Router.IntentMinConfidence)
+	}
+	return router, nil
+}
+
+// NewPromptRegistryFromConfig загружает встроенные промпты и версии из AI_PROMPTS_DIR,
+// активирует AI_DECISION_PROMPT (если задан)
+func NewPromptRegistryFromConfig(cfg config.AIConfig) (*prompts.Registry, error) {
+	registry, err := prompts.NewRegistry(cfg.Router.PromptsDir)
+	if err != nil {
+		return nil, fmt.Errorf("prompts: %w", err)
+	}
+	if cfg.Router.DecisionPrompt != "" {
+		if err := registry.SetActive(prompts.Decision, cfg.Router.DecisionPrompt); err != nil {
+			return nil, fmt.Errorf("AI_DECISION_PROMPT: %w", err)
+		}
+	}
+	return registry, nil
+}
+
+// NewMemoryFromConfig создает память диалогов; старые сообщения сжимает локальная модель
+func NewMemoryFromConfig(cfg config.AIConfig, store memory.Store, tracker *ai.CostTracker) (*memory.Memory, error) {
+	var summarizer memory.Summarizer
+	if cfg.Local.Enabled {
+		client, err := NewLocalClientFromConfig(cfg, tracker, ai.PurposeChat)
+		if err != nil {
+			return nil, err
+		}
+		summarizer = client
+	}
+
+	return memory.New(store, summarizer, memory.Config{
+		WindowSize: cfg.Router.MemoryWindow,
+		PendingTTL: cfg.Router.PendingTTL,
+	}), nil
+}
+
+// NewLocalClientFromConfig клиент локальной модели для фоновых задач (резюме, отчеты, инциденты)
+func NewLocalClientFromConfig(cfg config.AIConfig, tracker *ai.CostTracker, purpose string) (*ai.AIClient, error) {
+	local, err := ai.NewProvider(ai.ProviderConfig{
+		Name:    "local-" + cfg.Local.Provider,
+		Kind:    cfg.Local.Provider,
+		BaseURL: cfg.Local.URL,
+		Model:   cfg.Local.Model,
+		Timeout: cfg.Local.Timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("local provider: %w", err)
+	}
+	return ai.NewAIClientWithProvider(ai.Metered(local, ai.Pricing{}, tracker, purpose)), nil
+}
+
+// cloudKind сопоставляет имя облачного провайдера с типом API
+func cloudKind(provider string) string {
+	if provider == ai.ProviderAnthropic {
+		return ai.ProviderAnthropic
+	}
+	return ai.ProviderOpenAI
+}
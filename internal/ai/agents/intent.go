@@ -0,0 +1,155 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Методы классификации намерения
+const (
+	IntentMethodLLM      = "llm"
+	IntentMethodKeywords = "keywords"
+)
+
+// DefaultIntentConfidence порог уверенности; ниже - запрос уходит в chat
+const DefaultIntentConfidence = 0.6
+
+// Intent результат классификации сообщения
+type Intent struct {
+	Type       RequestType
+	Confidence float64 // 0.0 - 1.0
+	Method     string  // llm, keywords
+	Fallback   bool    // уверенность ниже порога, использован chat
+}
+
+// IntentClassifier определяет тип запроса пользователя
+type IntentClassifier interface {
+	Classify(ctx context.Context, message string) (*Intent, error)
+}
+
+// Completer LLM клиент для классификации (ai.AIClient)
+type Completer interface {
+	Complete(systemPrompt, userPrompt string) (string, error)
+}
+
+// LLMIntentClassifier классификация локальной моделью с фиксированным набором меток
+type LLMIntentClassifier struct {
+	client Completer
+}
+
+// NewLLMIntentClassifier создает классификатор на базе LLM
+func NewLLMIntentClassifier(client Completer) *LLMIntentClassifier {
+	return &LLMIntentClassifier{client: client}
+}
+
+const intentSystemPrompt = `You classify messages sent to a crypto trading bot into exactly one intent.
+
+Intents:
+- chat: greetings, questions about the bot, status/balance/history requests, commands to change settings or modes, anything else
+- analysis: requests to analyze a market, asset, trend, indicators, sentiment or forecast
+- decision: requests for a strategic portfolio decision: what strategy to run, whether to buy/sell/rebalance now, what the bot should do next
+
+Return ONLY JSON: {"intent": "chat|analysis|decision", "confidence": 0.0-1.0}`
+
+// Classify классифицирует сообщение через LLM
+func (c *LLMIntentClassifier) Classify(ctx context.Context, message string) (*Intent, error) {
+	response, err := c.client.Complete(intentSystemPrompt, "Message: "+message)
+	if err != nil {
+		return nil, fmt.Errorf("intent request failed: %w", err)
+	}
+
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start == -1 || end <= start {
+		return nil, fmt.Errorf("no JSON in intent response: %s", truncate(response, 100))
+	}
+
+	var result struct {
+		Intent     string  `json:"intent"`
+		Confidence float64 `json:"confidence"`
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse intent: %w", err)
+	}
+
+	intentType := RequestType(strings.ToLower(strings.TrimSpace(result.Intent)))
+	switch intentType {
+	case RequestTypeChat, RequestTypeAnalysis, RequestTypeDecision:
+	default:
+		return nil, fmt.Errorf("unknown intent label: %q", result.Intent)
+	}
+
+	if result.Confidence < 0 || result.Confidence > 1 {
+		return nil, fmt.Errorf("confidence out of range: %.2f", result.Confidence)
+	}
+
+	return &Intent{Type: intentType, Confidence: result.Confidence, Method: IntentMethodLLM}, nil
+}
+
+// KeywordClassifier классификация по ключевым словам (fallback при недоступности LLM)
+type KeywordClassifier struct{}
+
+var (
+	decisionKeywords = []string{
+		"стратеги", "strategy",
+		"решение", "decision",
+		"что делать", "what should i do",
+		"стоит ли", "should i",
+		"рекомендуешь", "recommend",
+		"посоветуй", "advise",
+		"ребаланс", "rebalance",
+	}
+
+	analysisKeywords = []string{
+		"анализ", "analysis", "analyze",
+		"прогноз", "forecast", "predict",
+		"оцен", "assess", "evaluate",
+		"тренд", "trend",
+		"техническ", "technical",
+		"индикатор", "indicator",
+		"настроение", "sentiment",
+	}
+)
+
+// Classify считает совпадения ключевых слов. Уверенность растет с числом совпадений.
+func (KeywordClassifier) Classify(ctx context.Context, message string) (*Intent, error) {
+	messageLower := strings.ToLower(message)
+
+	decisionHits := countKeywords(messageLower, decisionKeywords)
+	analysisHits := countKeywords(messageLower, analysisKeywords)
+
+	intent := &Intent{Type: RequestTypeChat, Confidence: 0.5, Method: IntentMethodKeywords}
+	switch {
+	case decisionHits > 0 && decisionHits >= analysisHits:
+		intent.Type = RequestTypeDecision
+		intent.Confidence = keywordConfidence(decisionHits, analysisHits)
+	case analysisHits > 0:
+		intent.Type = RequestTypeAnalysis
+		intent.Confidence = keywordConfidence(analysisHits, decisionHits)
+	}
+
+	return intent, nil
+}
+
+// countKeywords число ключевых слов, встречающихся в сообщении
+func countKeywords(message string, keywords []string) int {
+	hits := 0
+	for _, keyword := range keywords {
+		if strings.Contains(message, keyword) {
+			hits++
+		}
+	}
+	return hits
+}
+
+// keywordConfidence уверенность: 0.65 за одно совпадение, до 0.85; конкурирующая метка снижает
+func keywordConfidence(hits, competing int) float64 {
+	confidence := 0.55 + 0.1*float64(hits)
+	if confidence > 0.85 {
+		confidence = 0.85
+	}
+	confidence -= 0.1 * float64(competing)
+	return confidence
+}
@@ -0,0 +1,100 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type stubCompleter struct {
+	response string
+	err      error
+}
+
+func (s stubCompleter) Complete(systemPrompt, userPrompt string) (string, error) {
+	return s.response, s.err
+}
+
+func TestKeywordClassifier(t *testing.T) {
+	tests := []struct {
+		message string
+		want    RequestType
+	}{
+		{"привет, как дела?", RequestTypeChat},
+		{"переключи режим в shadow", RequestTypeChat},
+		{"сделай анализ BTC", RequestTypeAnalysis},
+		{"какой тренд у ETH и что показывают индикаторы", RequestTypeAnalysis},
+		{"какую стратегию посоветуешь сейчас?", RequestTypeDecision},
+		{"should I rebalance my portfolio?", RequestTypeDecision},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			intent, err := KeywordClassifier{}.Classify(context.Background(), tt.message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if intent.Type != tt.want {
+				t.Errorf("Classify(%q) = %s, want %s", tt.message, intent.Type, tt.want)
+			}
+		})
+	}
+}
+
+func TestLLMIntentClassifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     RequestType
+		wantErr  bool
+	}{
+		{"plain json", `{"intent": "decision", "confidence": 0.9}`, RequestTypeDecision, false},
+		{"wrapped json", "Sure: {\"intent\": \"Analysis\", \"confidence\": 0.7}", RequestTypeAnalysis, false},
+		{"unknown label", `{"intent": "trade", "confidence": 0.9}`, "", true},
+		{"bad confidence", `{"intent": "chat", "confidence": 7}`, "", true},
+		{"no json", "chat", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intent, err := NewLLMIntentClassifier(stubCompleter{response: tt.response}).Classify(context.Background(), "msg")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", intent)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if intent.Type != tt.want || intent.Method != IntentMethodLLM {
+				t.Errorf("got %+v, want %s", intent, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouterClassifyFallback(t *testing.T) {
+	router := NewAgentRouter(nil, nil, nil)
+
+	// Низкая уверенность → chat
+	router.SetClassifier(NewLLMIntentClassifier(stubCompleter{response: `{"intent": "decision", "confidence": 0.4}`}), 0.6)
+	intent := router.Classify(context.Background(), "хм")
+	if intent.Type != RequestTypeChat || !intent.Fallback {
+		t.Fatalf("expected chat fallback, got %+v", intent)
+	}
+
+	// Уверенное решение проходит
+	router.SetClassifier(NewLLMIntentClassifier(stubCompleter{response: `{"intent": "decision", "confidence": 0.8}`}), 0.6)
+	intent = router.Classify(context.Background(), "что делать с портфелем?")
+	if intent.Type != RequestTypeDecision || intent.Fallback {
+		t.Fatalf("expected decision, got %+v", intent)
+	}
+
+	// Ошибка LLM → ключевые слова
+	router.SetClassifier(NewLLMIntentClassifier(stubCompleter{err: errors.New("ollama down")}), 0.6)
+	intent = router.Classify(context.Background(), "сделай анализ BTC")
+	if intent.Type != RequestTypeAnalysis || intent.Method != IntentMethodKeywords {
+		t.Fatalf("expected keyword analysis, got %+v", intent)
+	}
+}
@@ -3,10 +3,13 @@ package agents
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/ai/prompts"
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/metrics"
+	"github.com/kirillm/dca-bot/internal/tracing"
 	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
@@ -25,8 +28,39 @@ type AgentRouter struct {
 	decisionAgent *DecisionAgent
 	analysisAgent *AnalysisAgent
 
+	// Классификация намерений
+	classifier    IntentClassifier
+	minConfidence float64
+
+	// Свежий контекст для decision запросов
+	decisionContext DecisionContextBuilder
+
+	// Память диалогов (nil - каждый запрос без истории)
+	memory ConversationMemory
+
 	// Метрики
-	metrics *RouterMetrics
+	metrics  *RouterMetrics
+	costs    *ai.CostTracker
+	outcomes RoutingStore
+
+	// Реестр версий промптов агентов (для переключения админом)
+	prompts *prompts.Registry
+}
+
+// DecisionContextBuilder собирает актуальный контекст (портфель, рынок, новости, лимиты)
+type DecisionContextBuilder interface {
+	BuildDecisionRequest(ctx context.Context) (ai.DecisionRequest, error)
+}
+
+// ConversationMemory история диалога пользователя (memory.Memory)
+type ConversationMemory interface {
+	History(ctx context.Context, userID int64) ([]ai.Message, error)
+	Append(ctx context.Context, userID int64, role, content string) error
+}
+
+// RoutingStore хранилище результатов маршрутизации (RoutingOutcomeRepository)
+type RoutingStore interface {
+	Save(ctx context.Context, outcome *domain.RoutingOutcome) error
 }
 
 // RouterMetrics метрики роутера
@@ -38,6 +72,9 @@ type RouterMetrics struct {
 	AvgLatency     time.Duration
 	CloudRequests  int
 	LocalRequests  int
+	FallbackCount  int // низкая уверенность → chat
+	CostTodayUSD   float64 // облачные расходы за день (из llm_usage)
+	DailyBudgetUSD float64
 }
 
 // NewAgentRouter создает новый роутер
@@ -46,98 +83,216 @@ func NewAgentRouter(chatAgent *ChatAgent, decisionAgent *DecisionAgent, analysis
 		chatAgent:     chatAgent,
 		decisionAgent: decisionAgent,
 		analysisAgent: analysisAgent,
+		classifier:    KeywordClassifier{},
+		minConfidence: DefaultIntentConfidence,
 		metrics:       &RouterMetrics{},
 	}
 }
 
+// SetClassifier устанавливает классификатор намерений (по умолчанию - ключевые слова)
+func (r *AgentRouter) SetClassifier(classifier IntentClassifier, minConfidence float64) {
+	r.classifier = classifier
+	if minConfidence > 0 {
+		r.minConfidence = minConfidence
+	}
+}
+
+// SetDecisionContext подключает сборщик контекста для decision запросов
+func (r *AgentRouter) SetDecisionContext(builder DecisionContextBuilder) {
+	r.decisionContext = builder
+}
+
+// SetOutcomeStore подключает запись результатов маршрутизации
+func (r *AgentRouter) SetOutcomeStore(store RoutingStore) {
+	r.outcomes = store
+}
+
+// SetMemory подключает память диалогов для ProcessConversation
+func (r *AgentRouter) SetMemory(memory ConversationMemory) {
+	r.memory = memory
+}
+
+// SetCostTracker подключает учет стоимости вызовов для метрик
+func (r *AgentRouter) SetCostTracker(tracker *ai.CostTracker) {
+	r.costs = tracker
+}
+
+// SetPrompts сохраняет реестр промптов, которым пользуются клиенты агентов
+func (r *AgentRouter) SetPrompts(registry *prompts.Registry) {
+	r.prompts = registry
+}
+
+// Prompts возвращает реестр промптов (nil, если агенты используют встроенные шаблоны)
+func (r *AgentRouter) Prompts() *prompts.Registry {
+	return r.prompts
+}
+
 // Process обрабатывает запрос, автоматически выбирая нужный агент
 func (r *AgentRouter) Process(ctx context.Context, userMessage string, context string) (string, []ai.AIAction, error) {
+	return r.process(ctx, userMessage, context, nil)
+}
+
+// ProcessConversation обрабатывает сообщение в рамках диалога пользователя:
+// подгружает историю, передает ее chat/analysis агентам и сохраняет обе реплики
+func (r *AgentRouter) ProcessConversation(ctx context.Context, userID int64, userMessage string, contextInfo string) (string, []ai.AIAction, error) {
+	if r.memory == nil {
+		return r.Process(ctx, userMessage, contextInfo)
+	}
+
+	history, err := r.memory.History(ctx, userID)
+	if err != nil {
+		utils.LogWarn(fmt.Sprintf("[AI Router] Failed to load history for %d: %v", userID, err))
+		history = nil
+	}
+
+	response, actions, err := r.process(ctx, userMessage, contextInfo, history)
+	if err != nil {
+		return response, actions, err
+	}
+
+	if err := r.memory.Append(ctx, userID, "user", userMessage); err != nil {
+		utils.LogWarn(fmt.Sprintf("[AI Router] Failed to save user turn: %v", err))
+	}
+	if err := r.memory.Append(ctx, userID, "assistant", response); err != nil {
+		utils.LogWarn(fmt.Sprintf("[AI Router] Failed to save assistant turn: %v", err))
+	}
+
+	return response, actions, nil
+}
+
+// process общий путь обработки: классификация → агент → запись результата
+func (r *AgentRouter) process(ctx context.Context, userMessage string, context string, history []ai.Message) (string, []ai.AIAction, error) {
+	ctx, span := tracing.Start(ctx, "ai.router.process", tracing.KindInternal)
+	defer span.End()
+
 	startTime := time.Now()
 	defer func() {
 		r.metrics.AvgLatency = (r.metrics.AvgLatency*time.Duration(r.metrics.TotalRequests) + time.Since(startTime)) / time.Duration(r.metrics.TotalRequests+1)
 		r.metrics.TotalRequests++
 	}()
 
-	// Определяем тип запроса
-	requestType := r.Route(userMessage)
+	// Определяем намерение
+	intent := r.Classify(ctx, userMessage)
 
-	utils.LogInfo(fmt.Sprintf("[AI Router] Request type: %s | Message: %s", requestType, truncate(userMessage, 50)))
+	utils.LogInfo(fmt.Sprintf("[AI Router] Intent: %s (%.2f, %s, fallback=%v) | Message: %s",
+		intent.Type, intent.Confidence, intent.Method, intent.Fallback, truncate(userMessage, 50)))
 
-	// Направляем к нужному агенту
-	switch requestType {
-	case RequestTypeChat:
-		r.metrics.ChatCount++
-		r.metrics.LocalRequests++
-		return r.chatAgent.Process(ctx, userMessage, context)
+	response, actions, agent, err := r.dispatch(ctx, intent, userMessage, context, history)
+	metrics.AIRouterDuration.With(agent).Observe(metrics.Since(startTime))
+	span.SetAttributes(
+		tracing.String("ai.intent", string(intent.Type)),
+		tracing.Float("ai.intent.confidence", intent.Confidence),
+		tracing.Bool("ai.intent.fallback", intent.Fallback),
+		tracing.String("ai.agent", agent),
+		tracing.Int("ai.actions", len(actions)),
+	)
+	span.RecordError(err)
+	r.recordOutcome(ctx, userMessage, intent, agent, err, time.Since(startTime))
+
+	return response, actions, err
+}
+
+// Classify определяет намерение: классификатор → ключевые слова при ошибке → chat при низкой уверенности
+func (r *AgentRouter) Classify(ctx context.Context, message string) *Intent {
+	intent, err := r.classifier.Classify(ctx, message)
+	if err != nil {
+		utils.LogWarn(fmt.Sprintf("[AI Router] Classifier failed, using keywords: %v", err))
+		intent, _ = KeywordClassifier{}.Classify(ctx, message)
+	}
+
+	if intent.Type != RequestTypeChat && intent.Confidence < r.minConfidence {
+		intent.Type = RequestTypeChat
+		intent.Fallback = true
+	}
+
+	return intent
+}
+
+// dispatch направляет запрос к агенту. Возвращает имя фактически использованного агента.
+func (r *AgentRouter) dispatch(ctx context.Context, intent *Intent, userMessage, contextInfo string, history []ai.Message) (string, []ai.AIAction, string, error) {
+	if intent.Fallback {
+		r.metrics.FallbackCount++
+		metrics.AIRouterFallbacks.Inc()
+	}
 
+	switch intent.Type {
 	case RequestTypeDecision:
-		r.metrics.DecisionCount++
-		r.metrics.CloudRequests++
-		// DecisionAgent требует специальной структуры, поэтому вызывается напрямую
-		// через DecisionClient.RequestDecision() с полным контекстом
-		return "", nil, fmt.Errorf("decision requests должны вызываться через DecisionAgent.RequestDecision() с полным контекстом")
+		if r.decisionAgent != nil && r.decisionContext != nil {
+			r.metrics.DecisionCount++
+			r.metrics.CloudRequests++
+			response, err := r.processDecision(ctx)
+			return response, nil, string(RequestTypeDecision), err
+		}
+		// Без контекста решение невозможно - отвечает chat
+		utils.LogWarn("[AI Router] Decision context not configured, routing to chat")
 
 	case RequestTypeAnalysis:
 		r.metrics.AnalysisCount++
 		r.metrics.LocalRequests++
-		return r.analysisAgent.Process(ctx, userMessage, context)
-
-	default:
-		return "", nil, fmt.Errorf("unknown request type: %s", requestType)
+		response, actions, err := r.analysisAgent.ProcessWithHistory(ctx, userMessage, contextInfo, history)
+		return response, actions, string(RequestTypeAnalysis), err
 	}
-}
 
-// Route определяет тип запроса на основе содержимого
-func (r *AgentRouter) Route(message string) RequestType {
-	messageLower := strings.ToLower(message)
+	r.metrics.ChatCount++
+	r.metrics.LocalRequests++
+	response, actions, err := r.chatAgent.ProcessWithHistory(ctx, userMessage, contextInfo, history)
+	return response, actions, string(RequestTypeChat), err
+}
 
-	// Ключевые слова для стратегических решений
-	decisionKeywords := []string{
-		"стратегия", "strategy",
-		"решение", "decision",
-		"что делать", "what should i do",
-		"стоит ли", "should i",
-		"рекомендуешь", "recommend",
-		"посоветуй", "advise",
-		"автономн", "autonomous",
-		"режим", "regime",
+// processDecision запрашивает решение со свежим контекстом. Действия не исполняются из чата -
+// это делает Orchestrator по расписанию или /ai_decision.
+func (r *AgentRouter) processDecision(ctx context.Context) (string, error) {
+	req, err := r.decisionContext.BuildDecisionRequest(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to build decision context: %w", err)
 	}
 
-	for _, keyword := range decisionKeywords {
-		if strings.Contains(messageLower, keyword) {
-			return RequestTypeDecision
-		}
+	decision, err := r.decisionAgent.RequestDecision(ctx, req)
+	if err != nil {
+		return "", err
 	}
 
-	// Ключевые слова для анализа
-	analysisKeywords := []string{
-		"анализ", "analysis", "analyze",
-		"прогноз", "forecast", "predict",
-		"оцен", "assess", "evaluate",
-		"тренд", "trend",
-		"техническ", "technical",
-		"индикатор", "indicator",
-		"настроение", "sentiment",
+	return r.decisionAgent.FormatDecision(decision) + "\n\nℹ️ Рекомендация не исполняется автоматически из чата. Для исполнения: /ai_decision", nil
+}
+
+// recordOutcome сохраняет результат маршрутизации для последующей настройки
+func (r *AgentRouter) recordOutcome(ctx context.Context, message string, intent *Intent, agent string, err error, latency time.Duration) {
+	if r.outcomes == nil {
+		return
 	}
 
-	for _, keyword := range analysisKeywords {
-		if strings.Contains(messageLower, keyword) {
-			return RequestTypeAnalysis
-		}
+	outcome := &domain.RoutingOutcome{
+		Timestamp:  time.Now(),
+		Message:    truncate(message, 500),
+		Intent:     string(intent.Type),
+		Agent:      agent,
+		Confidence: intent.Confidence,
+		Method:     intent.Method,
+		Fallback:   intent.Fallback,
+		Success:    err == nil,
+		LatencyMs:  latency.Milliseconds(),
+	}
+	if err != nil {
+		outcome.Error = err.Error()
 	}
 
-	// По умолчанию - чат
-	return RequestTypeChat
+	if saveErr := r.outcomes.Save(ctx, outcome); saveErr != nil {
+		utils.LogWarn(fmt.Sprintf("[AI Router] Failed to save routing outcome: %v", saveErr))
+	}
 }
 
 // GetMetrics возвращает метрики роутера
 func (r *AgentRouter) GetMetrics() *RouterMetrics {
+	if r.costs != nil {
+		r.metrics.CostTodayUSD = r.costs.SpentToday()
+		r.metrics.DailyBudgetUSD = r.costs.DailyBudget()
+	}
 	return r.metrics
 }
 
 // FormatMetrics форматирует метрики для отображения
 func (r *AgentRouter) FormatMetrics() string {
-	m := r.metrics
+	m := r.GetMetrics()
 	if m.TotalRequests == 0 {
 		return "Нет запросов к AI Router"
 	}
@@ -152,21 +307,36 @@ func (r *AgentRouter) FormatMetrics() string {
 ├─ Decision: %d (%.1f%%)
 └─ Analysis: %d (%.1f%%)
 
+Низкая уверенность → chat: %d
+
 Модели:
 ├─ Локальная: %d (%.1f%%)
 └─ Облачная: %d (%.1f%%)
 
-Средняя латентность: %dms`,
+Средняя латентность: %dms%s`,
 		m.TotalRequests,
 		m.ChatCount, float64(m.ChatCount)/float64(m.TotalRequests)*100,
 		m.DecisionCount, float64(m.DecisionCount)/float64(m.TotalRequests)*100,
 		m.AnalysisCount, float64(m.AnalysisCount)/float64(m.TotalRequests)*100,
+		m.FallbackCount,
 		m.LocalRequests, localPercent,
 		m.CloudRequests, cloudPercent,
 		m.AvgLatency.Milliseconds(),
+		formatCost(m),
 	)
 }
 
+// formatCost форматирует дневные расходы на облачную модель
+func formatCost(m *RouterMetrics) string {
+	if m.DailyBudgetUSD <= 0 {
+		if m.CostTodayUSD == 0 {
+			return ""
+		}
+		return fmt.Sprintf("\n💰 Облако сегодня: $%.4f", m.CostTodayUSD)
+	}
+	return fmt.Sprintf("\n💰 Облако сегодня: $%.4f / $%.2f (%.0f%%)", m.CostTodayUSD, m.DailyBudgetUSD, m.CostTodayUSD/m.DailyBudgetUSD*100)
+}
+
 // truncate обрезает строку до заданной длины
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
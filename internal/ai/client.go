@@ -1,26 +1,25 @@
 package ai
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"time"
+
+	"github.com/kirillm/dca-bot/internal/ai/prompts"
+	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
 type AIClient struct {
-	provider  string
-	apiKey    string
-	baseURL   string
-	model     string
-	client    *http.Client
+	llm     Provider
+	prompts *prompts.Registry // nil - встроенные шаблоны
 }
 
 type ChatRequest struct {
 	Model    string    `json:"model"`
 	Messages []Message `json:"messages"`
 	Tools    []Tool    `json:"tools,omitempty"`
+	// ToolChoice "auto", "none" или {"type":"function","function":{"name":...}}
+	ToolChoice interface{} `json:"tool_choice,omitempty"`
 }
 
 type Message struct {
@@ -36,6 +35,7 @@ type ChatResponse struct {
 			ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage Usage `json:"usage"`
 }
 
 // Tool представляет функцию, которую AI может вызвать
@@ -66,25 +66,85 @@ type AIAction struct {
 	Parameters map[string]interface{} `json:"parameters,omitempty"`
 }
 
+// NewAIClient создает клиента для одного провайдера (ollama - локальный, остальные - облачные)
 func NewAIClient(provider, apiKey, baseURL, model string) *AIClient {
-	return &AIClient{
-		provider: provider,
-		apiKey:   apiKey,
-		baseURL:  baseURL,
-		model:    model,
-		client:   &http.Client{Timeout: 60 * time.Second},
+	llm, err := NewProvider(ProviderConfig{
+		Name:    provider,
+		Kind:    provider,
+		BaseURL: baseURL,
+		APIKey:  apiKey,
+		Model:   model,
+		Cloud:   provider != ProviderOllama,
+	})
+	if err != nil {
+		// Неизвестный провайдер - считаем OpenAI-совместимым
+		llm, _ = NewProvider(ProviderConfig{
+			Name:    provider,
+			Kind:    ProviderOpenAI,
+			BaseURL: baseURL,
+			APIKey:  apiKey,
+			Model:   model,
+			Cloud:   true,
+		})
+	}
+	return NewAIClientWithProvider(llm)
+}
+
+// NewAIClientWithProvider создает клиента поверх провайдера (цепочки, учета стоимости)
+func NewAIClientWithProvider(llm Provider) *AIClient {
+	return &AIClient{llm: llm}
+}
+
+// Provider возвращает используемого провайдера
+func (a *AIClient) Provider() Provider {
+	return a.llm
+}
+
+// SetPrompts задает реестр шаблонов промптов (версии, A/B тесты)
+func (a *AIClient) SetPrompts(registry *prompts.Registry) {
+	a.prompts = registry
+}
+
+// Prompts возвращает реестр шаблонов промптов
+func (a *AIClient) Prompts() *prompts.Registry {
+	if a.prompts == nil {
+		return prompts.Default()
 	}
+	return a.prompts
+}
+
+// RenderPrompt заполняет секцию шаблона из реестра клиента.
+// Если выбранная версия не рендерится, используется встроенная активная версия.
+func (a *AIClient) RenderPrompt(name, section string, data interface{}) string {
+	text, p, err := a.Prompts().Render(name, section, data)
+	if err == nil {
+		return text
+	}
+
+	utils.LogWarn(fmt.Sprintf("[AIClient] prompt %s/%s failed, using embedded: %v", name, section, err))
+	if p, err = prompts.Default().Select(name); err == nil {
+		if text, err = p.Render(section, data); err == nil {
+			return text
+		}
+	}
+	utils.LogWarn(fmt.Sprintf("[AIClient] embedded prompt %s/%s failed: %v", name, section, err))
+	return ""
 }
 
 // ProcessMessage обрабатывает сообщение пользователя и возвращает ответ и действия
 func (a *AIClient) ProcessMessage(userMessage string, context string) (string, []AIAction, error) {
+	return a.ProcessMessageWithHistory(userMessage, context, nil)
+}
+
+// ProcessMessageWithHistory обрабатывает сообщение с учетом предыдущих реплик диалога
+func (a *AIClient) ProcessMessageWithHistory(userMessage string, context string, history []Message) (string, []AIAction, error) {
 	systemPrompt := a.buildSystemPrompt(context)
 	tools := a.buildTools()
 
-	messages := []Message{
-		{Role: "system", Content: systemPrompt},
-		{Role: "user", Content: userMessage},
-	}
+	messages := make([]Message, 0, len(history)+2)
+	messages = append(messages, Message{Role: "system", Content: systemPrompt})
+	messages = append(messages, history...)
+	messages = append(messages, Message{Role: "user", Content: userMessage})
 
 	response, toolCalls, err := a.chatWithTools(messages, tools)
 	if err != nil {
@@ -97,16 +157,9 @@ func (a *AIClient) ProcessMessage(userMessage string, context string) (string, [
 	return response, actions, nil
 }
 
-// buildSystemPrompt создает системный промпт для AI (сильно сокращенный)
+// buildSystemPrompt создает системный промпт для AI (шаблон prompts.Chat)
 func (a *AIClient) buildSystemPrompt(context string) string {
-	return fmt.Sprintf(`Вы — AI-ассистент для управления криптотрейдинг-ботом.
-
-Текущий контекст:
-%s
-
-Используйте доступные функции для выполнения команд пользователя.
-Если пользователь просто общается - отвечайте без вызова функций.
-Всегда давайте краткие и понятные ответы.`, context)
+	return a.RenderPrompt(prompts.Chat, prompts.SectionSystem, struct{ Context string }{context})
 }
 
 // buildTools создает список доступных инструментов для AI
@@ -231,6 +284,20 @@ func (a *AIClient) buildTools() []Tool {
 			Function: FunctionDefinition{
 				Name:        "manual_buy",
 				Description: "Выполнить ручную покупку DCA",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"symbol": map[string]interface{}{
+							"type":        "string",
+							"description": "Торговая пара (например BTCUSDT)",
+						},
+						"amount": map[string]interface{}{
+							"type":        "number",
+							"description": "Сумма в USDT (по умолчанию сумма DCA актива)",
+						},
+					},
+					"required": []string{"symbol"},
+				},
 			},
 		},
 		{
@@ -241,12 +308,16 @@ func (a *AIClient) buildTools() []Tool {
 				Parameters: map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
+						"symbol": map[string]interface{}{
+							"type":        "string",
+							"description": "Торговая пара (например BTCUSDT)",
+						},
 						"percent": map[string]interface{}{
 							"type":        "number",
 							"description": "Процент позиции для продажи (1-100)",
 						},
 					},
-					"required": []string{"percent"},
+					"required": []string{"symbol", "percent"},
 				},
 			},
 		},
@@ -273,58 +344,26 @@ func (a *AIClient) buildTools() []Tool {
 
 // chatWithTools отправляет запрос к AI API с поддержкой tools
 func (a *AIClient) chatWithTools(messages []Message, tools []Tool) (string, []ToolCall, error) {
-	requestBody := ChatRequest{
-		Model:    a.model,
+	result, err := a.llm.Chat(context.Background(), ChatRequest{
 		Messages: messages,
 		Tools:    tools,
-	}
-
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", nil, err
-	}
-
-	// Build endpoint, avoiding double /v1 if baseURL already contains it
-	endpoint := a.baseURL
-	if !bytes.HasSuffix([]byte(endpoint), []byte("/v1")) {
-		endpoint += "/v1"
-	}
-	endpoint += "/chat/completions"
-
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", nil, err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", a.apiKey))
-
-	resp, err := a.client.Do(req)
+	})
 	if err != nil {
 		return "", nil, err
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", nil, err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", nil, fmt.Errorf("AI API error: %s", string(body))
-	}
-
-	var chatResp ChatResponse
-	if err := json.Unmarshal(body, &chatResp); err != nil {
-		return "", nil, err
-	}
-
-	if len(chatResp.Choices) == 0 {
-		return "", nil, fmt.Errorf("no response from AI")
-	}
+	return result.Content, result.ToolCalls, nil
+}
 
-	message := chatResp.Choices[0].Message
-	return message.Content, message.ToolCalls, nil
+// chatWithRequiredTool заставляет модель вызвать указанную функцию (structured output)
+func (a *AIClient) chatWithRequiredTool(ctx context.Context, messages []Message, tool Tool) (*ChatResult, error) {
+	return a.llm.Chat(ctx, ChatRequest{
+		Messages: messages,
+		Tools:    []Tool{tool},
+		ToolChoice: map[string]interface{}{
+			"type":     "function",
+			"function": map[string]string{"name": tool.Function.Name},
+		},
+	})
 }
 
 // parseToolCalls конвертирует tool_calls в AIAction
@@ -352,61 +391,38 @@ func (a *AIClient) parseToolCalls(toolCalls []ToolCall) []AIAction {
 
 // chat отправляет запрос к AI API без tools (для GetMarketAnalysis)
 func (a *AIClient) chat(messages []Message) (string, error) {
-	requestBody := ChatRequest{
-		Model:    a.model,
-		Messages: messages,
-	}
-
-	jsonData, err := json.Marshal(requestBody)
+	result, err := a.llm.Chat(context.Background(), ChatRequest{Messages: messages})
 	if err != nil {
 		return "", err
 	}
+	return result.Content, nil
+}
 
-	// Build endpoint, avoiding double /v1 if baseURL already contains it
-	endpoint := a.baseURL
-	if !bytes.HasSuffix([]byte(endpoint), []byte("/v1")) {
-		endpoint += "/v1"
-	}
-	endpoint += "/chat/completions"
-
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", a.apiKey))
-
-	resp, err := a.client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("AI API error: %s", string(body))
-	}
-
-	var chatResp ChatResponse
-	if err := json.Unmarshal(body, &chatResp); err != nil {
-		return "", err
-	}
-
-	if len(chatResp.Choices) == 0 {
-		return "", fmt.Errorf("no response from AI")
+// Complete отправляет системный и пользовательский промпт без tools
+func (a *AIClient) Complete(systemPrompt, userPrompt string) (string, error) {
+	messages := []Message{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: userPrompt},
 	}
-
-	return chatResp.Choices[0].Message.Content, nil
+	return a.chat(messages)
 }
 
 // GetMarketAnalysis получает анализ рынка от AI
 func (a *AIClient) GetMarketAnalysis(symbol string, currentPrice float64, avgEntry float64) (string, error) {
-	profitPercent := ((currentPrice - avgEntry) / avgEntry) * 100
+	return a.GetTechnicalAnalysis(symbol, currentPrice, avgEntry, "")
+}
+
+// GetTechnicalAnalysis получает анализ рынка от AI с учетом технических индикаторов
+func (a *AIClient) GetTechnicalAnalysis(symbol string, currentPrice float64, avgEntry float64, indicators string) (string, error) {
+	return a.GetTechnicalAnalysisWithHistory(symbol, currentPrice, avgEntry, indicators, nil)
+}
+
+// GetTechnicalAnalysisWithHistory технический анализ с учетом предыдущих реплик диалога
+func (a *AIClient) GetTechnicalAnalysisWithHistory(symbol string, currentPrice float64, avgEntry float64, indicators string, history []Message) (string, error) {
+	profitPercent := 0.0
+	if avgEntry > 0 {
+		profitPercent = ((currentPrice - avgEntry) / avgEntry) * 100
+	}
 
 	prompt := fmt.Sprintf(
 		"Проанализируй текущую ситуацию: %s торгуется по цене %.2f USDT. "+
@@ -415,16 +431,18 @@ func (a *AIClient) GetMarketAnalysis(symbol string, currentPrice float64, avgEnt
 		symbol, currentPrice, avgEntry, profitPercent,
 	)
 
+	if indicators != "" {
+		prompt += "\n\nТехнические индикаторы:\n" + indicators
+	}
+
 	messages := []Message{
 		{
 			Role:    "system",
 			Content: "Вы — криптотрейдер-аналитик. Давайте краткие и объективные рекомендации.",
 		},
-		{
-			Role:    "user",
-			Content: prompt,
-		},
 	}
+	messages = append(messages, history...)
+	messages = append(messages, Message{Role: "user", Content: prompt})
 
 	return a.chat(messages)
 }
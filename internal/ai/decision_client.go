@@ -4,19 +4,38 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/kirillm/dca-bot/internal/ai/prompts"
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/tracing"
+	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
+// DefaultDecisionAttempts число попыток получить валидное решение (первая + ремонт)
+const DefaultDecisionAttempts = 3
+
 // DecisionClient клиент для получения стратегических решений от AI
 type DecisionClient struct {
-	baseClient *AIClient
+	baseClient  *AIClient
+	maxAttempts int
 }
 
 // NewDecisionClient создает новый decision client
 func NewDecisionClient(baseClient *AIClient) *DecisionClient {
 	return &DecisionClient{
-		baseClient: baseClient,
+		baseClient:  baseClient,
+		maxAttempts: DefaultDecisionAttempts,
+	}
+}
+
+// SetMaxAttempts задает число попыток (включая repair) для невалидных ответов
+func (dc *DecisionClient) SetMaxAttempts(n int) {
+	if n < 1 {
+		n = 1
 	}
+	dc.maxAttempts = n
 }
 
 // DecisionRequest запрос на принятие решения
@@ -26,6 +45,10 @@ type DecisionRequest struct {
 	RecentNews       []NewsSignal      `json:"recent_news"`
 	RiskLimits       RiskLimits        `json:"risk_limits"`
 	Mode             string            `json:"mode"` // shadow, pilot, full
+
+	// PromptVersion версия prompts.Decision, выбранная на цикл (NextPromptVersion).
+	// Пусто - активная версия: ручные запросы не сдвигают A/B тест
+	PromptVersion string `json:"-"`
 }
 
 // PortfolioSnapshot снимок портфеля
@@ -66,6 +89,21 @@ type NewsSignal struct {
 	Symbols        []string `json:"symbols"`
 }
 
+// NewsSignalsFromDomain преобразует сигналы из БД в формат запроса к AI
+func NewsSignalsFromDomain(signals []domain.NewsSignal) []NewsSignal {
+	result := make([]NewsSignal, 0, len(signals))
+	for _, s := range signals {
+		result = append(result, NewsSignal{
+			Headline:       s.Headline,
+			Sentiment:      s.Sentiment,
+			SentimentScore: s.SentimentScore,
+			Topics:         s.Topics,
+			Symbols:        s.Symbols,
+		})
+	}
+	return result
+}
+
 // RiskLimits лимиты рисков
 type RiskLimits struct {
 	MaxOrderUSDT     float64 `json:"max_order_usdt"`
@@ -80,6 +118,13 @@ type DecisionResponse struct {
 	Confidence float64  `json:"confidence"` // 0.0 - 1.0
 	Rationale  string   `json:"rationale"`
 	Actions    []Action `json:"actions"`
+
+	// Заполняются клиентом, не моделью
+	Provider      string `json:"-"`
+	Model         string `json:"-"`
+	PromptVersion string `json:"-"` // версия шаблона prompts.Decision
+	ReducedTrust  bool   `json:"-"` // решение от резервной модели - не исполняется
+	TraceID       string `json:"-"` // трейс запроса решения (пусто при выключенном трейсинге)
 }
 
 // Action действие для выполнения
@@ -89,108 +134,135 @@ type Action struct {
 	Parameters map[string]interface{} `json:"parameters"`
 }
 
-// RequestDecision запрашивает стратегическое решение у AI
+// NextPromptVersion выбирает версию prompts.Decision для нового цикла решений
+// (при A/B тесте версии чередуются между циклами)
+func (dc *DecisionClient) NextPromptVersion() string {
+	return dc.baseClient.Prompts().Variant(prompts.Decision)
+}
+
+// RequestDecision запрашивает стратегическое решение у AI.
+// Решение возвращается через вызов функции submit_decision со строгой схемой;
+// невалидный ответ отправляется модели обратно с ошибками (до maxAttempts попыток).
+// Версия шаблона берется из req.PromptVersion (при A/B тесте ее выбирает цикл Orchestrator).
 func (dc *DecisionClient) RequestDecision(ctx context.Context, req DecisionRequest) (*DecisionResponse, error) {
+	registry := dc.baseClient.Prompts()
+	version := req.PromptVersion
+	if version == "" {
+		version = registry.Active(prompts.Decision)
+	}
+	tmpl, err := registry.Get(prompts.Decision, version)
+	if err != nil {
+		return nil, fmt.Errorf("decision prompt: %w", err)
+	}
+
+	systemPrompt, err := tmpl.Render(prompts.SectionSystem, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decision prompt: %w", err)
+	}
+
 	// Строим промпт для AI
-	prompt := dc.buildDecisionPrompt(req)
+	prompt, err := dc.buildDecisionPrompt(tmpl, req)
+	if err != nil {
+		return nil, fmt.Errorf("decision prompt: %w", err)
+	}
 
-	// Отправляем запрос к AI
 	messages := []Message{
-		{Role: "system", Content: GetDecisionSystemPrompt()},
+		{Role: "system", Content: systemPrompt},
 		{Role: "user", Content: prompt},
 	}
 
-	response, err := dc.baseClient.chat(messages)
-	if err != nil {
-		return nil, fmt.Errorf("AI request failed: %w", err)
-	}
+	var lastErr error
+	for attempt := 1; attempt <= dc.maxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 
-	// Парсим JSON ответ
-	var decision DecisionResponse
-	if err := json.Unmarshal([]byte(response), &decision); err != nil {
-		// Попытка извлечь JSON из markdown code block
-		if cleanJSON := extractJSON(response); cleanJSON != "" {
-			if err := json.Unmarshal([]byte(cleanJSON), &decision); err != nil {
-				return nil, fmt.Errorf("failed to parse AI response: %w\nRaw response: %s", err, response)
-			}
-		} else {
-			return nil, fmt.Errorf("failed to parse AI response: %w\nRaw response: %s", err, response)
+		result, err := dc.baseClient.chatWithRequiredTool(ctx, messages, DecisionTool())
+		if err != nil {
+			return nil, fmt.Errorf("AI request failed: %w", err)
+		}
+
+		raw := decisionPayload(result.Content, result.ToolCalls)
+		decision, err := parseDecision(raw)
+		if err == nil {
+			err = dc.validateDecision(decision)
+		}
+		if err == nil {
+			decision.Provider = result.Provider
+			decision.Model = result.Model
+			decision.PromptVersion = tmpl.Version
+			decision.ReducedTrust = result.ReducedTrust
+			decision.TraceID = tracing.TraceID(ctx)
+			return decision, nil
 		}
+
+		lastErr = err
+		utils.LogWarn(fmt.Sprintf("[DecisionClient] invalid decision (attempt %d/%d, %s): %v", attempt, dc.maxAttempts, tmpl.ID(), err))
+
+		// Repair: показываем модели ее ответ и ошибку валидации
+		messages = append(messages,
+			Message{Role: "assistant", Content: raw},
+			Message{Role: "user", Content: buildRepairPrompt(err)},
+		)
 	}
 
-	// Валидация ответа
-	if err := dc.validateDecision(&decision); err != nil {
-		return nil, fmt.Errorf("invalid decision: %w", err)
+	return nil, fmt.Errorf("invalid decision after %d attempts: %w", dc.maxAttempts, lastErr)
+}
+
+// decisionPayload возвращает аргументы submit_decision, либо текст ответа,
+// если провайдер не поддерживает function-calling
+func decisionPayload(content string, toolCalls []ToolCall) string {
+	for _, tc := range toolCalls {
+		if tc.Function.Name == DecisionToolName {
+			return tc.Function.Arguments
+		}
 	}
+	return strings.TrimSpace(extractJSON(content))
+}
 
-	return &decision, nil
+// buildRepairPrompt формирует запрос на исправление невалидного решения
+func buildRepairPrompt(err error) string {
+	return fmt.Sprintf(`Your previous decision was rejected by the validator:
+%v
+
+Call %s again with a corrected decision that strictly follows the schema.
+Use only the parameters defined for each action type.`, err, DecisionToolName)
 }
 
-// buildDecisionPrompt строит промпт для принятия решения
-func (dc *DecisionClient) buildDecisionPrompt(req DecisionRequest) string {
+// buildDecisionPrompt строит промпт для принятия решения (секция user шаблона)
+func (dc *DecisionClient) buildDecisionPrompt(tmpl *prompts.Prompt, req DecisionRequest) (string, error) {
 	portfolioJSON, _ := json.MarshalIndent(req.CurrentPortfolio, "", "  ")
 	marketJSON, _ := json.MarshalIndent(req.MarketConditions, "", "  ")
 	newsJSON, _ := json.MarshalIndent(req.RecentNews, "", "  ")
 	limitsJSON, _ := json.MarshalIndent(req.RiskLimits, "", "  ")
 
-	return fmt.Sprintf(`Analyze the current situation and provide a strategic trading decision.
-
-Current Context:
-- Mode: %s
-- Time: %s
-
-Portfolio:
-%s
-
-Market Conditions:
-%s
-
-Recent News (last 1 hour):
-%s
-
-Risk Limits:
-%s
-
-Provide your decision in JSON format (no markdown, pure JSON):
-{
-  "regime": "ACCUMULATE|TREND_FOLLOW|RANGE_GRID|DEFENSE",
-  "confidence": 0.0-1.0,
-  "rationale": "Brief explanation of your decision",
-  "actions": [
-    {
-      "type": "set_dca|set_grid|set_autosell|rebalance|pause_strategy",
-      "symbol": "BTCUSDT",
-      "parameters": {"quote_usdt": 100, "interval_min": 720}
-    }
-  ]
-}
-
-Rules:
-1. NEVER exceed risk limits
-2. If confidence < 0.6, return empty actions array
-3. Max 3 actions per decision
-4. Consider news sentiment
-5. Adjust strategy based on market conditions`,
-		req.Mode,
-		time.Now().Format(time.RFC3339),
-		string(portfolioJSON),
-		string(marketJSON),
-		string(newsJSON),
-		string(limitsJSON),
-	)
-}
-
-// validateDecision проверяет корректность решения
-func (dc *DecisionClient) validateDecision(decision *DecisionResponse) error {
-	// Проверка режима
-	validRegimes := map[string]bool{
-		"ACCUMULATE":   true,
-		"TREND_FOLLOW": true,
-		"RANGE_GRID":   true,
-		"DEFENSE":      true,
-	}
+	return tmpl.Render(prompts.SectionUser, decisionPromptData{
+		Mode:      req.Mode,
+		Time:      time.Now().Format(time.RFC3339),
+		Portfolio: string(portfolioJSON),
+		Market:    string(marketJSON),
+		News:      string(newsJSON),
+		Limits:    string(limitsJSON),
+		ToolName:  DecisionToolName,
+	})
+}
+
+// decisionPromptData поля секции user шаблона prompts.Decision
+type decisionPromptData struct {
+	Mode      string
+	Time      string
+	Portfolio string
+	Market    string
+	News      string
+	Limits    string
+	ToolName  string
+}
 
-	if !validRegimes[decision.Regime] {
+// validateDecision проверяет корректность решения и типизированные параметры действий
+func (dc *DecisionClient) validateDecision(decision *DecisionResponse) error {
+	switch decision.Regime {
+	case RegimeAccumulate, RegimeTrendFollow, RegimeRangeGrid, RegimeDefense:
+	default:
 		return fmt.Errorf("invalid regime: %s", decision.Regime)
 	}
 
@@ -200,23 +272,22 @@ func (dc *DecisionClient) validateDecision(decision *DecisionResponse) error {
 	}
 
 	// Проверка количества действий
-	if len(decision.Actions) > 3 {
-		return fmt.Errorf("too many actions: %d (max 3)", len(decision.Actions))
-	}
-
-	// Проверка типов действий
-	validActionTypes := map[string]bool{
-		"set_dca":        true,
-		"set_grid":       true,
-		"set_autosell":   true,
-		"rebalance":      true,
-		"pause_strategy": true,
+	if len(decision.Actions) > MaxDecisionActions {
+		return fmt.Errorf("too many actions: %d (max %d)", len(decision.Actions), MaxDecisionActions)
 	}
 
+	var errs []string
 	for i, action := range decision.Actions {
-		if !validActionTypes[action.Type] {
-			return fmt.Errorf("invalid action type at index %d: %s", i, action.Type)
+		if action.Symbol == "" && action.Type != ActionRebalance {
+			errs = append(errs, fmt.Sprintf("action %d (%s): symbol is required", i, action.Type))
+			continue
 		}
+		if _, err := action.Params(); err != nil {
+			errs = append(errs, fmt.Sprintf("action %d: %v", i, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
 	}
 
 	return nil
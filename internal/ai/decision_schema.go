@@ -0,0 +1,337 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Типы действий стратегического решения
+const (
+	ActionSetDCA        = "set_dca"
+	ActionSetGrid       = "set_grid"
+	ActionSetAutoSell   = "set_autosell"
+	ActionRebalance     = "rebalance"
+	ActionPauseStrategy = "pause_strategy"
+)
+
+// Режимы рынка
+const (
+	RegimeAccumulate  = "ACCUMULATE"
+	RegimeTrendFollow = "TREND_FOLLOW"
+	RegimeRangeGrid   = "RANGE_GRID"
+	RegimeDefense     = "DEFENSE"
+)
+
+// MaxDecisionActions максимум действий в одном решении
+const MaxDecisionActions = 3
+
+// DecisionToolName имя функции, через которую модель возвращает решение
+const DecisionToolName = "submit_decision"
+
+// DCAParams параметры set_dca
+type DCAParams struct {
+	QuoteUSDT   float64 `json:"quote_usdt"`
+	IntervalMin int     `json:"interval_min"`
+}
+
+// Validate проверяет параметры DCA
+func (p DCAParams) Validate() error {
+	if p.QuoteUSDT <= 0 {
+		return fmt.Errorf("quote_usdt must be > 0, got %.2f", p.QuoteUSDT)
+	}
+	if p.IntervalMin < 1 {
+		return fmt.Errorf("interval_min must be >= 1, got %d", p.IntervalMin)
+	}
+	return nil
+}
+
+// GridParams параметры set_grid
+type GridParams struct {
+	Levels         int     `json:"levels"`
+	SpacingPct     float64 `json:"spacing_pct"`
+	OrderSizeQuote float64 `json:"order_size_quote"`
+}
+
+// Validate проверяет параметры Grid
+func (p GridParams) Validate() error {
+	if p.Levels < 2 || p.Levels > 50 {
+		return fmt.Errorf("levels must be between 2 and 50, got %d", p.Levels)
+	}
+	if p.SpacingPct <= 0 || p.SpacingPct > 20 {
+		return fmt.Errorf("spacing_pct must be in (0, 20], got %.2f", p.SpacingPct)
+	}
+	if p.OrderSizeQuote <= 0 {
+		return fmt.Errorf("order_size_quote must be > 0, got %.2f", p.OrderSizeQuote)
+	}
+	return nil
+}
+
+// AutoSellParams параметры set_autosell
+type AutoSellParams struct {
+	TriggerPct float64 `json:"trigger_pct"`
+	SellPct    float64 `json:"sell_pct"`
+}
+
+// Validate проверяет параметры Auto-Sell
+func (p AutoSellParams) Validate() error {
+	if p.TriggerPct <= 0 {
+		return fmt.Errorf("trigger_pct must be > 0, got %.2f", p.TriggerPct)
+	}
+	if p.SellPct <= 0 || p.SellPct > 100 {
+		return fmt.Errorf("sell_pct must be in (0, 100], got %.2f", p.SellPct)
+	}
+	return nil
+}
+
+// RebalanceParams параметры rebalance (символ → доля портфеля 0..1)
+type RebalanceParams struct {
+	TargetAllocation map[string]float64 `json:"target_allocation"`
+}
+
+// Validate проверяет параметры ребалансировки
+func (p RebalanceParams) Validate() error {
+	if len(p.TargetAllocation) == 0 {
+		return fmt.Errorf("target_allocation must not be empty")
+	}
+	total := 0.0
+	for symbol, share := range p.TargetAllocation {
+		if share < 0 || share > 1 {
+			return fmt.Errorf("target_allocation[%s] must be in [0, 1], got %.2f", symbol, share)
+		}
+		total += share
+	}
+	if math.Abs(total-1) > 0.01 {
+		return fmt.Errorf("target_allocation must sum to 1.0, got %.2f", total)
+	}
+	return nil
+}
+
+// PauseParams параметры pause_strategy
+type PauseParams struct {
+	Reason      string `json:"reason"`
+	DurationMin int    `json:"duration_min,omitempty"`
+}
+
+// Validate проверяет параметры паузы
+func (p PauseParams) Validate() error {
+	if strings.TrimSpace(p.Reason) == "" {
+		return fmt.Errorf("reason must not be empty")
+	}
+	if p.DurationMin < 0 {
+		return fmt.Errorf("duration_min must be >= 0, got %d", p.DurationMin)
+	}
+	return nil
+}
+
+// ActionParams общий интерфейс типизированных параметров
+type ActionParams interface {
+	Validate() error
+}
+
+// newActionParams возвращает пустую структуру параметров для типа действия
+func newActionParams(actionType string) (ActionParams, error) {
+	switch actionType {
+	case ActionSetDCA:
+		return &DCAParams{}, nil
+	case ActionSetGrid:
+		return &GridParams{}, nil
+	case ActionSetAutoSell:
+		return &AutoSellParams{}, nil
+	case ActionRebalance:
+		return &RebalanceParams{}, nil
+	case ActionPauseStrategy:
+		return &PauseParams{}, nil
+	default:
+		return nil, fmt.Errorf("unknown action type: %s", actionType)
+	}
+}
+
+// Params декодирует и валидирует параметры действия в типизированную структуру
+func (a Action) Params() (ActionParams, error) {
+	params, err := newActionParams(a.Type)
+	if err != nil {
+		return nil, err
+	}
+	if err := decodeStrict(a.Parameters, params); err != nil {
+		return nil, fmt.Errorf("%s parameters: %w", a.Type, err)
+	}
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%s parameters: %w", a.Type, err)
+	}
+	return params, nil
+}
+
+// DCA возвращает параметры set_dca
+func (a Action) DCA() (*DCAParams, error) {
+	var p DCAParams
+	return &p, a.decodeAs(ActionSetDCA, &p)
+}
+
+// Grid возвращает параметры set_grid
+func (a Action) Grid() (*GridParams, error) {
+	var p GridParams
+	return &p, a.decodeAs(ActionSetGrid, &p)
+}
+
+// AutoSell возвращает параметры set_autosell
+func (a Action) AutoSell() (*AutoSellParams, error) {
+	var p AutoSellParams
+	return &p, a.decodeAs(ActionSetAutoSell, &p)
+}
+
+// Rebalance возвращает параметры rebalance
+func (a Action) Rebalance() (*RebalanceParams, error) {
+	var p RebalanceParams
+	return &p, a.decodeAs(ActionRebalance, &p)
+}
+
+// Pause возвращает параметры pause_strategy
+func (a Action) Pause() (*PauseParams, error) {
+	var p PauseParams
+	return &p, a.decodeAs(ActionPauseStrategy, &p)
+}
+
+// SetParams записывает типизированные параметры обратно в Parameters
+func (a *Action) SetParams(params ActionParams) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	a.Parameters = m
+	return nil
+}
+
+// decodeAs декодирует параметры, проверяя тип действия
+func (a Action) decodeAs(actionType string, out ActionParams) error {
+	if a.Type != actionType {
+		return fmt.Errorf("action type is %s, not %s", a.Type, actionType)
+	}
+	return decodeStrict(a.Parameters, out)
+}
+
+// decodeStrict декодирует map в структуру, отклоняя неизвестные поля
+func decodeStrict(params map[string]interface{}, out interface{}) error {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(out)
+}
+
+// parseDecision строго разбирает JSON решения (неизвестные поля = ошибка)
+func parseDecision(data string) (*DecisionResponse, error) {
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var decision DecisionResponse
+	if err := dec.Decode(&decision); err != nil {
+		return nil, err
+	}
+	return &decision, nil
+}
+
+// DecisionTool описание функции submit_decision со строгой JSON-схемой
+func DecisionTool() Tool {
+	return Tool{
+		Type: "function",
+		Function: FunctionDefinition{
+			Name:        DecisionToolName,
+			Description: "Submit the strategic trading decision. Parameters must match the action type.",
+			Parameters:  DecisionSchema(),
+		},
+	}
+}
+
+// DecisionSchema JSON-схема DecisionResponse
+func DecisionSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"regime", "confidence", "rationale", "actions"},
+		"properties": map[string]interface{}{
+			"regime": map[string]interface{}{
+				"type": "string",
+				"enum": []string{RegimeAccumulate, RegimeTrendFollow, RegimeRangeGrid, RegimeDefense},
+			},
+			"confidence": map[string]interface{}{
+				"type":    "number",
+				"minimum": 0,
+				"maximum": 1,
+			},
+			"rationale": map[string]interface{}{
+				"type":        "string",
+				"description": "Brief explanation of the decision",
+			},
+			"actions": map[string]interface{}{
+				"type":     "array",
+				"maxItems": MaxDecisionActions,
+				"items": map[string]interface{}{
+					"oneOf": []interface{}{
+						actionSchema(ActionSetDCA, objectSchema([]string{"quote_usdt", "interval_min"}, map[string]interface{}{
+							"quote_usdt":   numberSchema(0, "USDT per purchase"),
+							"interval_min": integerSchema(1, "Minutes between purchases"),
+						})),
+						actionSchema(ActionSetGrid, objectSchema([]string{"levels", "spacing_pct", "order_size_quote"}, map[string]interface{}{
+							"levels":           integerSchema(2, "Number of grid levels (2-50)"),
+							"spacing_pct":      numberSchema(0, "Distance between levels, %"),
+							"order_size_quote": numberSchema(0, "USDT per level"),
+						})),
+						actionSchema(ActionSetAutoSell, objectSchema([]string{"trigger_pct", "sell_pct"}, map[string]interface{}{
+							"trigger_pct": numberSchema(0, "Profit % that triggers a sell"),
+							"sell_pct":    numberSchema(0, "Share of position to sell, %"),
+						})),
+						actionSchema(ActionRebalance, objectSchema([]string{"target_allocation"}, map[string]interface{}{
+							"target_allocation": map[string]interface{}{
+								"type":                 "object",
+								"description":          "Symbol to target share (0..1), must sum to 1.0",
+								"additionalProperties": map[string]interface{}{"type": "number", "minimum": 0, "maximum": 1},
+							},
+						})),
+						actionSchema(ActionPauseStrategy, objectSchema([]string{"reason"}, map[string]interface{}{
+							"reason":       map[string]interface{}{"type": "string"},
+							"duration_min": integerSchema(0, "Pause duration in minutes, 0 = until resumed"),
+						})),
+					},
+				},
+			},
+		},
+	}
+}
+
+// actionSchema схема одного действия конкретного типа
+func actionSchema(actionType string, params map[string]interface{}) map[string]interface{} {
+	return objectSchema([]string{"type", "symbol", "parameters"}, map[string]interface{}{
+		"type":       map[string]interface{}{"type": "string", "const": actionType},
+		"symbol":     map[string]interface{}{"type": "string", "pattern": "^[A-Z0-9]+$"},
+		"parameters": params,
+	})
+}
+
+func objectSchema(required []string, properties map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             required,
+		"properties":           properties,
+	}
+}
+
+func numberSchema(minimum float64, description string) map[string]interface{} {
+	return map[string]interface{}{"type": "number", "exclusiveMinimum": minimum, "description": description}
+}
+
+func integerSchema(minimum int, description string) map[string]interface{} {
+	return map[string]interface{}{"type": "integer", "minimum": minimum, "description": description}
+}
@@ -0,0 +1,233 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kirillm/dca-bot/internal/ai/prompts"
+)
+
+func TestActionParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  Action
+		wantErr string
+	}{
+		{
+			name:   "valid dca",
+			action: Action{Type: ActionSetDCA, Symbol: "BTCUSDT", Parameters: map[string]interface{}{"quote_usdt": 50.0, "interval_min": 720.0}},
+		},
+		{
+			name:    "dca interval not integer",
+			action:  Action{Type: ActionSetDCA, Symbol: "BTCUSDT", Parameters: map[string]interface{}{"quote_usdt": 50.0, "interval_min": 12.5}},
+			wantErr: "interval_min",
+		},
+		{
+			name:    "dca amount as string",
+			action:  Action{Type: ActionSetDCA, Symbol: "BTCUSDT", Parameters: map[string]interface{}{"quote_usdt": "50", "interval_min": 60.0}},
+			wantErr: "quote_usdt",
+		},
+		{
+			name:    "grid unknown field",
+			action:  Action{Type: ActionSetGrid, Symbol: "ETHUSDT", Parameters: map[string]interface{}{"levels": 10.0, "spacing_pct": 2.0, "order_size_quote": 50.0, "leverage": 5.0}},
+			wantErr: "unknown field",
+		},
+		{
+			name:    "grid too few levels",
+			action:  Action{Type: ActionSetGrid, Symbol: "ETHUSDT", Parameters: map[string]interface{}{"levels": 1.0, "spacing_pct": 2.0, "order_size_quote": 50.0}},
+			wantErr: "levels",
+		},
+		{
+			name:    "autosell sell over 100",
+			action:  Action{Type: ActionSetAutoSell, Symbol: "BTCUSDT", Parameters: map[string]interface{}{"trigger_pct": 15.0, "sell_pct": 150.0}},
+			wantErr: "sell_pct",
+		},
+		{
+			name:   "valid rebalance",
+			action: Action{Type: ActionRebalance, Symbol: "PORTFOLIO", Parameters: map[string]interface{}{"target_allocation": map[string]interface{}{"BTCUSDT": 0.6, "ETHUSDT": 0.4}}},
+		},
+		{
+			name:    "rebalance does not sum to one",
+			action:  Action{Type: ActionRebalance, Symbol: "PORTFOLIO", Parameters: map[string]interface{}{"target_allocation": map[string]interface{}{"BTCUSDT": 0.6}}},
+			wantErr: "sum to 1.0",
+		},
+		{
+			name:    "pause without reason",
+			action:  Action{Type: ActionPauseStrategy, Symbol: "BTCUSDT", Parameters: map[string]interface{}{}},
+			wantErr: "reason",
+		},
+		{
+			name:    "unknown action",
+			action:  Action{Type: "market_buy", Symbol: "BTCUSDT"},
+			wantErr: "unknown action type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.action.Params()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetParamsRoundTrip(t *testing.T) {
+	action := Action{Type: ActionSetDCA, Symbol: "BTCUSDT", Parameters: map[string]interface{}{"quote_usdt": 100.0, "interval_min": 60.0}}
+
+	params, err := action.DCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	params.QuoteUSDT *= 0.5
+	if err := action.SetParams(params); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := action.DCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.QuoteUSDT != 50 || got.IntervalMin != 60 {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestRequestDecisionRepairsInvalidOutput(t *testing.T) {
+	replies := []string{
+		`{"regime":"ACCUMULATE","confidence":0.8,"rationale":"dip","actions":[{"type":"set_dca","symbol":"BTCUSDT","parameters":{"quote_usdt":"lots"}}]}`,
+		`{"regime":"ACCUMULATE","confidence":0.8,"rationale":"dip","actions":[{"type":"set_dca","symbol":"BTCUSDT","parameters":{"quote_usdt":40,"interval_min":360}}]}`,
+	}
+
+	var requests []ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+
+		call := ToolCall{ID: "call_1", Type: "function"}
+		call.Function.Name = DecisionToolName
+		call.Function.Arguments = replies[len(requests)-1]
+
+		var resp ChatResponse
+		resp.Choices = make([]struct {
+			Message struct {
+				Role      string     `json:"role"`
+				Content   string     `json:"content"`
+				ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+			} `json:"message"`
+		}, 1)
+		resp.Choices[0].Message.Role = "assistant"
+		resp.Choices[0].Message.ToolCalls = []ToolCall{call}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client := NewDecisionClient(NewAIClient("test", "key", server.URL, "model"))
+	decision, err := client.RequestDecision(context.Background(), DecisionRequest{Mode: "pilot"})
+	if err != nil {
+		t.Fatalf("RequestDecision: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+	if requests[0].ToolChoice == nil || len(requests[0].Tools) != 1 {
+		t.Fatal("expected forced submit_decision tool")
+	}
+	last := requests[1].Messages[len(requests[1].Messages)-1]
+	if !strings.Contains(last.Content, "quote_usdt") {
+		t.Fatalf("repair prompt should contain validation error, got %q", last.Content)
+	}
+
+	params, err := decision.Actions[0].DCA()
+	if err != nil || params.QuoteUSDT != 40 {
+		t.Fatalf("unexpected params %+v (%v)", params, err)
+	}
+	if decision.PromptVersion != "v1" {
+		t.Fatalf("PromptVersion = %q, want embedded v1", decision.PromptVersion)
+	}
+}
+
+func TestRequestDecisionGivesUp(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"regime\":\"MOON\",\"confidence\":1,\"rationale\":\"\",\"actions\":[]}"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewDecisionClient(NewAIClient("test", "key", server.URL, "model"))
+	client.SetMaxAttempts(2)
+
+	if _, err := client.RequestDecision(context.Background(), DecisionRequest{}); err == nil {
+		t.Fatal("expected error for invalid regime")
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 attempts, got %d", calls)
+	}
+}
+
+func TestRequestDecisionPromptVariantPerCycle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"regime\":\"DEFENSE\",\"confidence\":0.5,\"rationale\":\"wait\",\"actions\":[]}"}}]}`))
+	}))
+	defer server.Close()
+
+	// v2 - копия встроенной v1 в каталоге AI_PROMPTS_DIR
+	v1, err := os.ReadFile(filepath.Join("prompts", "templates", prompts.Decision, "v1.tmpl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, prompts.Decision), 0o755)
+	if err := os.WriteFile(filepath.Join(dir, prompts.Decision, "v2.tmpl"), v1, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	registry, err := prompts.NewRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.StartExperiment(prompts.Decision, "v1", "v2"); err != nil {
+		t.Fatal(err)
+	}
+
+	base := NewAIClient("test", "key", server.URL, "model")
+	base.SetPrompts(registry)
+	client := NewDecisionClient(base)
+
+	request := func(version string) string {
+		t.Helper()
+		decision, err := client.RequestDecision(context.Background(), DecisionRequest{PromptVersion: version})
+		if err != nil {
+			t.Fatalf("RequestDecision(%q): %v", version, err)
+		}
+		return decision.PromptVersion
+	}
+
+	// Ручные запросы без версии идут на активную и не сдвигают A/B тест
+	if got := request(""); got != "v2" {
+		t.Errorf("manual request used %s, want active v2", got)
+	}
+	var cycles []string
+	for i := 0; i < 3; i++ {
+		cycles = append(cycles, request(client.NextPromptVersion()))
+		request("")
+	}
+	if strings.Join(cycles, ",") != "v1,v2,v1" {
+		t.Errorf("cycle variants = %v, want v1,v2,v1", cycles)
+	}
+}
@@ -0,0 +1,137 @@
+package eval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/ai/prompts"
+)
+
+func toolResult(name, args string) ai.ChatResult {
+	var tc ai.ToolCall
+	tc.Type = "function"
+	tc.Function.Name = name
+	tc.Function.Arguments = args
+	return ai.ChatResult{ToolCalls: []ai.ToolCall{tc}, Provider: "ollama", Model: "test-model"}
+}
+
+func testCorpus() *Corpus {
+	decision := &ai.DecisionRequest{Mode: "pilot"}
+	return &Corpus{Cases: []Case{
+		{Name: "dca_ok", Kind: KindDecision, Decision: decision, Expect: Expectation{AllowedActions: []string{"set_dca"}}},
+		{Name: "dca_too_big", Kind: KindDecision, Decision: decision, Expect: Expectation{AllowedActions: []string{"set_dca"}}},
+		{Name: "grid_not_allowed", Kind: KindDecision, Decision: decision, Expect: Expectation{AllowedActions: []string{"set_dca"}}},
+		{Name: "broken_schema", Kind: KindDecision, Decision: decision},
+		{Name: "route_ok", Kind: KindRouting, Message: "анализ BTC", Expect: Expectation{Intent: "analysis"}},
+		{Name: "route_low_confidence", Kind: KindRouting, Message: "хм", Expect: Expectation{Intent: "decision"}},
+		{Name: "chat_tool", Kind: KindChat, Message: "цена BTC", Expect: Expectation{RequiredTool: "get_price"}},
+	}}
+}
+
+func testRecordings() Recordings {
+	broken := toolResult("submit_decision", `{"regime":"MOON","confidence":2}`)
+	return Recordings{
+		"dca_ok":               {{Result: toolResult("submit_decision", `{"regime":"ACCUMULATE","confidence":0.7,"rationale":"ok","actions":[{"type":"set_dca","symbol":"BTCUSDT","parameters":{"quote_usdt":50,"interval_min":60}}]}`)}},
+		"dca_too_big":          {{Result: toolResult("submit_decision", `{"regime":"ACCUMULATE","confidence":0.7,"rationale":"all in","actions":[{"type":"set_dca","symbol":"BTCUSDT","parameters":{"quote_usdt":5000,"interval_min":60}}]}`)}},
+		"grid_not_allowed":     {{Result: toolResult("submit_decision", `{"regime":"RANGE_GRID","confidence":0.7,"rationale":"grid","actions":[{"type":"set_grid","symbol":"BTCUSDT","parameters":{"levels":10,"spacing_pct":1,"order_size_quote":10}}]}`)}},
+		"broken_schema":        {{Result: broken}, {Result: broken}, {Result: broken}},
+		"route_ok":             {{Result: ai.ChatResult{Content: `{"intent":"analysis","confidence":0.9}`}}},
+		"route_low_confidence": {{Result: ai.ChatResult{Content: `{"intent":"decision","confidence":0.3}`}}},
+		"chat_tool":            {{Result: toolResult("get_price", `{"symbol":"BTCUSDT"}`)}},
+	}
+}
+
+func TestRunnerReplayScores(t *testing.T) {
+	recorder, err := NewRecorder(ModeReplay, nil, testRecordings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker, err := NewPolicyChecker("../../../configs/policy.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := NewRunner(recorder, checker, 0.6).Run(context.Background(), testCorpus(), "test")
+	s := report.Summary
+
+	if s.Total != 7 || s.Passed != 3 {
+		t.Fatalf("passed %d/%d, want 3/7\n%s", s.Passed, s.Total, report.Format())
+	}
+	if s.SchemaValidity != 0.75 {
+		t.Errorf("SchemaValidity = %v, want 0.75", s.SchemaValidity)
+	}
+	// 3 действия: одно превышает max_order_usdt, одно не разрешено кейсом
+	if s.ActionsTotal != 3 || s.PolicyViolationRate < 0.66 || s.PolicyViolationRate > 0.67 {
+		t.Errorf("PolicyViolationRate = %v over %d actions, want 2/3", s.PolicyViolationRate, s.ActionsTotal)
+	}
+	if s.RoutingAccuracy != 0.5 {
+		t.Errorf("RoutingAccuracy = %v, want 0.5 (low confidence falls back to chat)", s.RoutingAccuracy)
+	}
+	if s.ToolCallAccuracy != 1 {
+		t.Errorf("ToolCallAccuracy = %v, want 1", s.ToolCallAccuracy)
+	}
+	if report.Model != "test-model" {
+		t.Errorf("Model = %q, want recorded model", report.Model)
+	}
+
+	// Хэши в тестовых записях пустые - все кейсы помечены устаревшими
+	if s.Stale != s.Total {
+		t.Errorf("Stale = %d, want %d", s.Stale, s.Total)
+	}
+}
+
+func TestCompareDetectsRegressions(t *testing.T) {
+	base := &Report{Label: "v1", Cases: []CaseResult{
+		{Name: "a", Kind: KindRouting, Passed: true},
+		{Name: "b", Kind: KindRouting, Passed: false},
+	}}
+	base.Summary = summarize(base.Cases)
+
+	head := &Report{Label: "v2", Cases: []CaseResult{
+		{Name: "a", Kind: KindRouting, Passed: false},
+		{Name: "b", Kind: KindRouting, Passed: true},
+	}}
+	head.Summary = summarize(head.Cases)
+
+	cmp := Compare(base, head)
+	if !cmp.HasRegressions() {
+		t.Fatal("expected regression")
+	}
+	if len(cmp.Regressed) != 1 || cmp.Regressed[0] != "a" || len(cmp.Fixed) != 1 || cmp.Fixed[0] != "b" {
+		t.Fatalf("regressed=%v fixed=%v", cmp.Regressed, cmp.Fixed)
+	}
+
+	// Одинаковые прогоны - без регрессий
+	if Compare(base, base).HasRegressions() {
+		t.Fatal("identical reports must not regress")
+	}
+}
+
+// Изменение промпта или схемы инструментов требует перезаписи configs/eval/recordings.json
+func TestCommittedRecordingsUpToDate(t *testing.T) {
+	corpus, err := LoadCorpus("../../../configs/eval/corpus.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recordings, err := LoadRecordings("../../../configs/eval/recordings.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder, err := NewRecorder(ModeReplay, nil, recordings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry, err := prompts.NewRegistry("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := NewRunner(recorder, nil, 0.6)
+	runner.SetPrompts(registry)
+
+	for _, c := range runner.Run(context.Background(), corpus, "").Cases {
+		if c.Stale {
+			t.Errorf("%s: recording is stale, re-record with go run ./cmd/aieval -mode record", c.Name)
+		}
+	}
+}
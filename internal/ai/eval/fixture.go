@@ -0,0 +1,82 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kirillm/dca-bot/internal/ai"
+)
+
+// Типы кейсов
+const (
+	KindDecision = "decision" // DecisionClient: валидность схемы, разрешенные действия, policy
+	KindRouting  = "routing"  // классификатор намерений: метка
+	KindChat     = "chat"     // ChatAgent: обязательный tool call
+)
+
+// Case один кейс корпуса
+type Case struct {
+	Name     string              `json:"name"`
+	Kind     string              `json:"kind"`
+	Message  string              `json:"message,omitempty"`  // routing, chat
+	Context  string              `json:"context,omitempty"`  // chat
+	Decision *ai.DecisionRequest `json:"decision,omitempty"` // decision
+	Expect   Expectation         `json:"expect"`
+}
+
+// Expectation ожидаемый результат кейса
+type Expectation struct {
+	Intent         string   `json:"intent,omitempty"`          // routing: chat|analysis|decision
+	AllowedActions []string `json:"allowed_actions,omitempty"` // decision: допустимые типы действий (пусто - любые)
+	Regimes        []string `json:"regimes,omitempty"`         // decision: допустимые режимы (пусто - любые)
+	RequiredTool   string   `json:"required_tool,omitempty"`   // chat: функция, которую модель должна вызвать
+}
+
+// Corpus набор кейсов
+type Corpus struct {
+	Cases []Case `json:"cases"`
+}
+
+// LoadCorpus загружает корпус из JSON файла
+func LoadCorpus(path string) (*Corpus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read corpus: %w", err)
+	}
+
+	var corpus Corpus
+	if err := json.Unmarshal(data, &corpus); err != nil {
+		return nil, fmt.Errorf("failed to parse corpus: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i, c := range corpus.Cases {
+		if c.Name == "" {
+			return nil, fmt.Errorf("case %d: name is required", i)
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("duplicate case name %q", c.Name)
+		}
+		seen[c.Name] = true
+
+		switch c.Kind {
+		case KindDecision:
+			if c.Decision == nil {
+				return nil, fmt.Errorf("case %q: decision request is required", c.Name)
+			}
+		case KindRouting:
+			if c.Message == "" || c.Expect.Intent == "" {
+				return nil, fmt.Errorf("case %q: message and expect.intent are required", c.Name)
+			}
+		case KindChat:
+			if c.Message == "" {
+				return nil, fmt.Errorf("case %q: message is required", c.Name)
+			}
+		default:
+			return nil, fmt.Errorf("case %q: unknown kind %q", c.Name, c.Kind)
+		}
+	}
+
+	return &corpus, nil
+}
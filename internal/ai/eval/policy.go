@@ -0,0 +1,80 @@
+package eval
+
+import (
+	"context"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/policy"
+)
+
+// PolicyChecker проверяет действия решений той же политикой, что и Orchestrator,
+// с портфелем из кейса вместо БД
+type PolicyChecker struct {
+	engine  *policy.Engine
+	storage *snapshotStorage
+}
+
+// NewPolicyChecker загружает политику (configs/policy.yaml, профиль из POLICY_PROFILE)
+func NewPolicyChecker(policyPath string) (*PolicyChecker, error) {
+	storage := &snapshotStorage{}
+	engine, err := policy.NewEngine(policyPath, storage)
+	if err != nil {
+		return nil, err
+	}
+	return &PolicyChecker{engine: engine, storage: storage}, nil
+}
+
+// Check возвращает критические нарушения политики для действия
+func (p *PolicyChecker) Check(ctx context.Context, portfolio ai.PortfolioSnapshot, action ai.Action) ([]string, error) {
+	p.storage.setPortfolio(portfolio)
+
+	result, err := p.engine.ValidateAction(ctx, policy.ActionRequest{
+		Type:       action.Type,
+		Symbol:     action.Symbol,
+		Parameters: action.Parameters,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []string
+	for _, v := range result.Violations {
+		if v.Severity == "critical" {
+			violations = append(violations, v.Type+": "+v.Message)
+		}
+	}
+	return violations, nil
+}
+
+// snapshotStorage policy.Storage поверх снимка портфеля кейса
+type snapshotStorage struct {
+	balances []policy.Balance
+}
+
+func (s *snapshotStorage) setPortfolio(portfolio ai.PortfolioSnapshot) {
+	s.balances = s.balances[:0]
+	for _, asset := range portfolio.Assets {
+		s.balances = append(s.balances, policy.Balance{
+			Symbol:        asset.Symbol,
+			TotalInvested: asset.InvestedUSDT,
+			UnrealizedPnL: asset.PnL,
+		})
+	}
+}
+
+func (s *snapshotStorage) GetAllBalances(ctx context.Context) ([]policy.Balance, error) {
+	return s.balances, nil
+}
+
+func (s *snapshotStorage) GetRecentTrades(ctx context.Context, since time.Time) ([]policy.Trade, error) {
+	return nil, nil
+}
+
+func (s *snapshotStorage) SavePolicyViolation(ctx context.Context, violation *policy.PolicyViolation) error {
+	return nil
+}
+
+func (s *snapshotStorage) SaveCircuitBreakerEvent(ctx context.Context, event *policy.CircuitBreakerEvent) error {
+	return nil
+}
@@ -0,0 +1,185 @@
+package eval
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/kirillm/dca-bot/internal/ai"
+)
+
+// Режимы провайдера
+const (
+	ModeReplay = "replay" // только записанные ответы, без сети
+	ModeLive   = "live"   // реальная модель (локальная Ollama или облако)
+	ModeRecord = "record" // реальная модель + запись ответов для replay
+)
+
+// RecordedCall записанный ответ модели на один вызов внутри кейса
+type RecordedCall struct {
+	RequestHash string        `json:"request_hash"` // хэш запроса - отличие означает, что промпт изменился
+	Result      ai.ChatResult `json:"result"`
+}
+
+// Recordings записанные ответы: кейс → вызовы по порядку
+type Recordings map[string][]RecordedCall
+
+// LoadRecordings загружает записи; отсутствующий файл - пустые записи
+func LoadRecordings(path string) (Recordings, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Recordings{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recordings: %w", err)
+	}
+
+	var rec Recordings
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to parse recordings: %w", err)
+	}
+	return rec, nil
+}
+
+// Save сохраняет записи в файл
+func (r Recordings) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Recorder провайдер для eval: проигрывает записи (replay) или вызывает модель и записывает (record)
+type Recorder struct {
+	mode       string
+	upstream   ai.Provider
+	recordings Recordings
+
+	mu      sync.Mutex
+	current string // текущий кейс
+	call    int    // номер вызова в кейсе
+	stale   bool   // запрос отличается от записанного
+
+	provider, model string // из последнего ответа (для replay - из записей)
+}
+
+// NewRecorder создает провайдер. upstream обязателен для live/record.
+func NewRecorder(mode string, upstream ai.Provider, recordings Recordings) (*Recorder, error) {
+	switch mode {
+	case ModeReplay:
+	case ModeLive, ModeRecord:
+		if upstream == nil {
+			return nil, fmt.Errorf("mode %s requires upstream provider", mode)
+		}
+	default:
+		return nil, fmt.Errorf("unknown mode %q", mode)
+	}
+	if recordings == nil {
+		recordings = Recordings{}
+	}
+	return &Recorder{mode: mode, upstream: upstream, recordings: recordings}, nil
+}
+
+// Begin начинает новый кейс
+func (r *Recorder) Begin(caseName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.current = caseName
+	r.call = 0
+	r.stale = false
+	if r.mode == ModeRecord {
+		delete(r.recordings, caseName)
+	}
+}
+
+// Stale true, если в текущем кейсе запрос отличался от записанного (промпт изменился)
+func (r *Recorder) Stale() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stale
+}
+
+// Recordings возвращает записи (для сохранения после record)
+func (r *Recorder) Recordings() Recordings {
+	return r.recordings
+}
+
+func (r *Recorder) Name() string {
+	if r.upstream != nil {
+		return r.upstream.Name()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.provider
+}
+
+func (r *Recorder) Model() string {
+	if r.upstream != nil {
+		return r.upstream.Model()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.model
+}
+
+func (r *Recorder) Cloud() bool { return false }
+
+// Chat возвращает записанный ответ или вызывает модель
+func (r *Recorder) Chat(ctx context.Context, req ai.ChatRequest) (*ai.ChatResult, error) {
+	hash := requestHash(req)
+
+	r.mu.Lock()
+	caseName, call := r.current, r.call
+	r.call++
+	r.mu.Unlock()
+
+	if r.mode == ModeReplay {
+		calls := r.recordings[caseName]
+		if call >= len(calls) {
+			return nil, fmt.Errorf("no recording for case %q call %d", caseName, call)
+		}
+		result := calls[call].Result
+
+		r.mu.Lock()
+		if calls[call].RequestHash != hash {
+			r.stale = true
+		}
+		r.provider, r.model = result.Provider, result.Model
+		r.mu.Unlock()
+		return &result, nil
+	}
+
+	result, err := r.upstream.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == ModeRecord {
+		r.mu.Lock()
+		r.recordings[caseName] = append(r.recordings[caseName], RecordedCall{RequestHash: hash, Result: *result})
+		r.mu.Unlock()
+	}
+	return result, nil
+}
+
+// timestampRe время в промптах (Time: в DecisionRequest) не должно влиять на хэш
+var timestampRe = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+
+// requestHash хэш сообщений и инструментов без модели - модель сравнивается отдельно
+func requestHash(req ai.ChatRequest) string {
+	data, _ := json.Marshal(struct {
+		Messages   []ai.Message `json:"messages"`
+		Tools      []ai.Tool    `json:"tools"`
+		ToolChoice interface{}  `json:"tool_choice"`
+	}{req.Messages, req.Tools, req.ToolChoice})
+
+	sum := sha256.Sum256(timestampRe.ReplaceAll(data, []byte("<time>")))
+	return hex.EncodeToString(sum[:8])
+}
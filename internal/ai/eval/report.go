@@ -0,0 +1,278 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CaseResult результат одного кейса
+type CaseResult struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Passed    bool   `json:"passed"`
+	Error     string `json:"error,omitempty"`
+	Stale     bool   `json:"stale,omitempty"` // промпт изменился с момента записи
+	LatencyMs int64  `json:"latency_ms"`
+
+	// decision
+	SchemaValid       bool     `json:"schema_valid,omitempty"`
+	Regime            string   `json:"regime,omitempty"`
+	Actions           []string `json:"actions,omitempty"`
+	DisallowedActions []string `json:"disallowed_actions,omitempty"`
+	PolicyViolations  []string `json:"policy_violations,omitempty"`
+	ViolatingActions  int      `json:"violating_actions,omitempty"`
+
+	// routing
+	Intent         string  `json:"intent,omitempty"`
+	ExpectedIntent string  `json:"expected_intent,omitempty"`
+	Confidence     float64 `json:"confidence,omitempty"`
+
+	// chat
+	ToolCalled bool `json:"tool_called,omitempty"`
+}
+
+// Summary агрегированные метрики
+type Summary struct {
+	Total  int `json:"total"`
+	Passed int `json:"passed"`
+	Stale  int `json:"stale"`
+
+	DecisionCases       int     `json:"decision_cases"`
+	SchemaValidity      float64 `json:"schema_validity"` // доля валидных решений
+	ActionsTotal        int     `json:"actions_total"`
+	PolicyViolationRate float64 `json:"policy_violation_rate"` // доля действий, нарушивших политику или запрещенных кейсом
+
+	RoutingCases    int     `json:"routing_cases"`
+	RoutingAccuracy float64 `json:"routing_accuracy"`
+
+	ChatCases        int     `json:"chat_cases"`
+	ToolCallAccuracy float64 `json:"tool_call_accuracy"`
+}
+
+// Report отчет прогона для одной версии промптов/модели
+type Report struct {
+	Label     string            `json:"label"` // версия промптов или произвольная метка
+	Mode      string            `json:"mode"`
+	Provider  string            `json:"provider"`
+	Model     string            `json:"model"`
+	Prompts   map[string]string `json:"prompts,omitempty"` // активные версии промптов
+	CreatedAt time.Time         `json:"created_at"`
+	Summary   Summary           `json:"summary"`
+	Cases     []CaseResult      `json:"cases"`
+}
+
+// summarize считает метрики по кейсам
+func summarize(cases []CaseResult) Summary {
+	var s Summary
+	var schemaValid, violatingActions, routingCorrect, toolsCorrect int
+
+	for _, c := range cases {
+		s.Total++
+		if c.Passed {
+			s.Passed++
+		}
+		if c.Stale {
+			s.Stale++
+		}
+
+		switch c.Kind {
+		case KindDecision:
+			s.DecisionCases++
+			if c.SchemaValid {
+				schemaValid++
+			}
+			s.ActionsTotal += len(c.Actions)
+			violatingActions += c.ViolatingActions + len(c.DisallowedActions)
+		case KindRouting:
+			s.RoutingCases++
+			if c.Passed {
+				routingCorrect++
+			}
+		case KindChat:
+			s.ChatCases++
+			if c.Passed {
+				toolsCorrect++
+			}
+		}
+	}
+
+	s.SchemaValidity = ratio(schemaValid, s.DecisionCases)
+	s.PolicyViolationRate = ratio(violatingActions, s.ActionsTotal)
+	s.RoutingAccuracy = ratio(routingCorrect, s.RoutingCases)
+	s.ToolCallAccuracy = ratio(toolsCorrect, s.ChatCases)
+	return s
+}
+
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// Save сохраняет отчет в JSON
+func (r *Report) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadReport загружает отчет из JSON
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report: %w", err)
+	}
+	return &report, nil
+}
+
+// Format форматирует отчет для консоли
+func (r *Report) Format() string {
+	var sb strings.Builder
+	s := r.Summary
+
+	sb.WriteString(fmt.Sprintf("📋 Eval %s (%s, %s/%s)\n", r.Label, r.Mode, r.Provider, r.Model))
+	if len(r.Prompts) > 0 {
+		names := make([]string, 0, len(r.Prompts))
+		for name := range r.Prompts {
+			names = append(names, name+"@"+r.Prompts[name])
+		}
+		sort.Strings(names)
+		sb.WriteString("📝 Промпты: " + strings.Join(names, ", ") + "\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("Пройдено: %d/%d\n", s.Passed, s.Total))
+	sb.WriteString(fmt.Sprintf("Валидность схемы: %.0f%% (%d решений)\n", s.SchemaValidity*100, s.DecisionCases))
+	sb.WriteString(fmt.Sprintf("Нарушения политики: %.0f%% (%d действий)\n", s.PolicyViolationRate*100, s.ActionsTotal))
+	sb.WriteString(fmt.Sprintf("Точность маршрутизации: %.0f%% (%d кейсов)\n", s.RoutingAccuracy*100, s.RoutingCases))
+	sb.WriteString(fmt.Sprintf("Вызов нужной функции: %.0f%% (%d кейсов)\n", s.ToolCallAccuracy*100, s.ChatCases))
+	if s.Stale > 0 {
+		sb.WriteString(fmt.Sprintf("⚠️ Устаревшие записи: %d (промпт изменился, перезапишите -mode record)\n", s.Stale))
+	}
+
+	for _, c := range r.Cases {
+		if c.Passed {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n❌ %s [%s]", c.Name, c.Kind))
+		switch {
+		case c.Error != "":
+			sb.WriteString(": " + c.Error)
+		case c.Kind == KindRouting:
+			sb.WriteString(fmt.Sprintf(": %s, ожидалось %s", c.Intent, c.ExpectedIntent))
+		case len(c.DisallowedActions) > 0:
+			sb.WriteString(": запрещенные действия " + strings.Join(c.DisallowedActions, ", "))
+		case len(c.PolicyViolations) > 0:
+			sb.WriteString(": " + strings.Join(c.PolicyViolations, "; "))
+		}
+	}
+
+	return sb.String()
+}
+
+// MetricDelta изменение метрики между прогонами
+type MetricDelta struct {
+	Name           string
+	Base, Head     float64
+	HigherIsBetter bool
+}
+
+// Regressed метрика ухудшилась
+func (d MetricDelta) Regressed() bool {
+	if d.HigherIsBetter {
+		return d.Head < d.Base
+	}
+	return d.Head > d.Base
+}
+
+// Comparison сравнение двух прогонов (например, разных версий промпта или моделей)
+type Comparison struct {
+	Base, Head *Report
+	Metrics    []MetricDelta
+	Regressed  []string // кейсы: были пройдены, стали провалены
+	Fixed      []string // кейсы: были провалены, стали пройдены
+}
+
+// Compare сравнивает прогоны по метрикам и кейсам
+func Compare(base, head *Report) *Comparison {
+	cmp := &Comparison{
+		Base: base,
+		Head: head,
+		Metrics: []MetricDelta{
+			{"schema_validity", base.Summary.SchemaValidity, head.Summary.SchemaValidity, true},
+			{"policy_violation_rate", base.Summary.PolicyViolationRate, head.Summary.PolicyViolationRate, false},
+			{"routing_accuracy", base.Summary.RoutingAccuracy, head.Summary.RoutingAccuracy, true},
+			{"tool_call_accuracy", base.Summary.ToolCallAccuracy, head.Summary.ToolCallAccuracy, true},
+		},
+	}
+
+	passed := make(map[string]bool, len(base.Cases))
+	for _, c := range base.Cases {
+		passed[c.Name] = c.Passed
+	}
+	for _, c := range head.Cases {
+		was, ok := passed[c.Name]
+		if !ok {
+			continue
+		}
+		switch {
+		case was && !c.Passed:
+			cmp.Regressed = append(cmp.Regressed, c.Name)
+		case !was && c.Passed:
+			cmp.Fixed = append(cmp.Fixed, c.Name)
+		}
+	}
+	sort.Strings(cmp.Regressed)
+	sort.Strings(cmp.Fixed)
+
+	return cmp
+}
+
+// HasRegressions есть ухудшения метрик или кейсов
+func (c *Comparison) HasRegressions() bool {
+	if len(c.Regressed) > 0 {
+		return true
+	}
+	for _, m := range c.Metrics {
+		if m.Regressed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Format форматирует сравнение для консоли
+func (c *Comparison) Format() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔀 %s (%s) → %s (%s)\n\n", c.Base.Label, c.Base.Model, c.Head.Label, c.Head.Model))
+
+	for _, m := range c.Metrics {
+		icon := "➖"
+		if m.Head != m.Base {
+			icon = "✅"
+			if m.Regressed() {
+				icon = "🔻"
+			}
+		}
+		sb.WriteString(fmt.Sprintf("%s %-22s %5.1f%% → %5.1f%%\n", icon, m.Name, m.Base*100, m.Head*100))
+	}
+
+	if len(c.Regressed) > 0 {
+		sb.WriteString("\n🔻 Стали проваливаться: " + strings.Join(c.Regressed, ", ") + "\n")
+	}
+	if len(c.Fixed) > 0 {
+		sb.WriteString("\n✅ Исправлены: " + strings.Join(c.Fixed, ", ") + "\n")
+	}
+
+	return sb.String()
+}
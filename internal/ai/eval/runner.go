@@ -0,0 +1,166 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/ai/agents"
+	"github.com/kirillm/dca-bot/internal/ai/prompts"
+)
+
+// Runner прогоняет корпус через клиентов AI поверх Recorder
+type Runner struct {
+	recorder  *Recorder
+	client    *ai.AIClient
+	decisions *ai.DecisionClient
+	router    *agents.AgentRouter
+	policy    *PolicyChecker
+}
+
+// NewRunner создает раннер. policy может быть nil - тогда нарушения политики не считаются.
+func NewRunner(recorder *Recorder, policy *PolicyChecker, minConfidence float64) *Runner {
+	client := ai.NewAIClientWithProvider(recorder)
+
+	// Маршрутизация как в проде: LLM классификатор + порог уверенности + fallback на ключевые слова
+	router := agents.NewAgentRouter(nil, nil, nil)
+	router.SetClassifier(agents.NewLLMIntentClassifier(client), minConfidence)
+
+	return &Runner{
+		recorder:  recorder,
+		client:    client,
+		decisions: ai.NewDecisionClient(client),
+		router:    router,
+		policy:    policy,
+	}
+}
+
+// SetPrompts задает реестр промптов с проверяемыми версиями
+func (r *Runner) SetPrompts(registry *prompts.Registry) {
+	r.client.SetPrompts(registry)
+}
+
+// Run прогоняет все кейсы и возвращает отчет. Пустая метка - версия промпта решений (decision@v1).
+func (r *Runner) Run(ctx context.Context, corpus *Corpus, label string) *Report {
+	registry := r.client.Prompts()
+	report := &Report{
+		Label:     label,
+		Mode:      r.recorder.mode,
+		Prompts:   make(map[string]string),
+		CreatedAt: time.Now(),
+	}
+	for _, st := range registry.Status() {
+		report.Prompts[st.Name] = st.Active
+	}
+	if report.Label == "" {
+		report.Label = prompts.Decision + "@" + registry.Active(prompts.Decision)
+	}
+
+	for _, c := range corpus.Cases {
+		r.recorder.Begin(c.Name)
+		start := time.Now()
+
+		var result CaseResult
+		switch c.Kind {
+		case KindDecision:
+			result = r.runDecision(ctx, c)
+		case KindRouting:
+			result = r.runRouting(ctx, c)
+		case KindChat:
+			result = r.runChat(c)
+		}
+
+		result.Name = c.Name
+		result.Kind = c.Kind
+		result.Stale = r.recorder.Stale()
+		result.LatencyMs = time.Since(start).Milliseconds()
+		report.Cases = append(report.Cases, result)
+	}
+
+	report.Provider = r.recorder.Name()
+	report.Model = r.recorder.Model()
+	report.Summary = summarize(report.Cases)
+	return report
+}
+
+// runDecision: схема (через submit_decision + repair), разрешенные действия, политика
+func (r *Runner) runDecision(ctx context.Context, c Case) CaseResult {
+	result := CaseResult{}
+
+	decision, err := r.decisions.RequestDecision(ctx, *c.Decision)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.SchemaValid = true
+	result.Regime = decision.Regime
+
+	allowed := toSet(c.Expect.AllowedActions)
+	for _, action := range decision.Actions {
+		result.Actions = append(result.Actions, action.Type)
+
+		if len(allowed) > 0 && !allowed[action.Type] {
+			result.DisallowedActions = append(result.DisallowedActions, action.Type)
+		}
+
+		if r.policy != nil {
+			violations, err := r.policy.Check(ctx, c.Decision.CurrentPortfolio, action)
+			if err != nil {
+				result.Error = fmt.Sprintf("policy check failed: %v", err)
+				return result
+			}
+			if len(violations) > 0 {
+				result.PolicyViolations = append(result.PolicyViolations, violations...)
+				result.ViolatingActions++
+			}
+		}
+	}
+
+	regimeOK := len(c.Expect.Regimes) == 0 || toSet(c.Expect.Regimes)[decision.Regime]
+	result.Passed = regimeOK && len(result.DisallowedActions) == 0 && result.ViolatingActions == 0
+	return result
+}
+
+// runRouting: метка намерения после порога уверенности
+func (r *Runner) runRouting(ctx context.Context, c Case) CaseResult {
+	intent := r.router.Classify(ctx, c.Message)
+	result := CaseResult{
+		Intent:         string(intent.Type),
+		ExpectedIntent: c.Expect.Intent,
+		Confidence:     intent.Confidence,
+	}
+	if intent.Method != agents.IntentMethodLLM {
+		result.Error = "LLM classifier failed, keyword fallback used"
+	}
+	result.Passed = result.Intent == c.Expect.Intent
+	return result
+}
+
+// runChat: модель вызвала обязательную функцию
+func (r *Runner) runChat(c Case) CaseResult {
+	result := CaseResult{}
+
+	_, actions, err := r.client.ProcessMessage(c.Message, c.Context)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	for _, action := range actions {
+		result.Actions = append(result.Actions, action.Type)
+		if action.Type == c.Expect.RequiredTool {
+			result.ToolCalled = true
+		}
+	}
+	result.Passed = c.Expect.RequiredTool == "" || result.ToolCalled
+	return result
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
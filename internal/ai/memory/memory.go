@@ -0,0 +1,292 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/pkg/utils"
+)
+
+// Роли сообщений диалога
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Значения по умолчанию
+const (
+	DefaultWindowSize     = 12
+	DefaultSummarizeEvery = 6
+	DefaultPendingTTL     = 10 * time.Minute
+)
+
+// Store хранилище диалогов (repository.ConversationRepository)
+type Store interface {
+	AddMessage(ctx context.Context, msg *domain.ConversationMessage) error
+	GetMessagesAfter(ctx context.Context, userID, afterID int64) ([]domain.ConversationMessage, error)
+	GetState(ctx context.Context, userID int64) (*domain.ConversationState, error)
+	SaveState(ctx context.Context, state *domain.ConversationState) error
+	DeleteSummarized(ctx context.Context, userID, untilID int64) error
+	DeleteUser(ctx context.Context, userID int64) error
+}
+
+// Summarizer LLM для сжатия старых сообщений (ai.AIClient)
+type Summarizer interface {
+	Complete(systemPrompt, userPrompt string) (string, error)
+}
+
+// Config параметры памяти
+type Config struct {
+	WindowSize     int           // сколько последних сообщений передается модели целиком
+	SummarizeEvery int           // сколько сообщений сверх окна копится до сжатия
+	PendingTTL     time.Duration // время жизни неподтвержденных действий
+}
+
+// Memory память диалогов: окно последних сообщений + summary более старых
+type Memory struct {
+	store      Store
+	summarizer Summarizer
+	config     Config
+	mu         sync.Mutex
+}
+
+// New создает память диалогов. summarizer может быть nil - тогда старые сообщения отбрасываются.
+func New(store Store, summarizer Summarizer, config Config) *Memory {
+	if config.WindowSize <= 0 {
+		config.WindowSize = DefaultWindowSize
+	}
+	if config.SummarizeEvery <= 0 {
+		config.SummarizeEvery = DefaultSummarizeEvery
+	}
+	if config.PendingTTL <= 0 {
+		config.PendingTTL = DefaultPendingTTL
+	}
+
+	return &Memory{
+		store:      store,
+		summarizer: summarizer,
+		config:     config,
+	}
+}
+
+// History возвращает историю для модели: summary (system) + последние сообщения
+func (m *Memory) History(ctx context.Context, userID int64) ([]ai.Message, error) {
+	state, err := m.state(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := m.store.GetMessagesAfter(ctx, userID, state.SummarizedUntil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+	if len(messages) > m.config.WindowSize {
+		messages = messages[len(messages)-m.config.WindowSize:]
+	}
+
+	history := make([]ai.Message, 0, len(messages)+1)
+	if state.Summary != "" {
+		history = append(history, ai.Message{
+			Role:    "system",
+			Content: "Краткое содержание предыдущего диалога:\n" + state.Summary,
+		})
+	}
+	for _, msg := range messages {
+		history = append(history, ai.Message{Role: msg.Role, Content: msg.Content})
+	}
+
+	return history, nil
+}
+
+// Append сохраняет сообщение и сжимает историю, если она вышла за окно
+func (m *Memory) Append(ctx context.Context, userID int64, role, content string) error {
+	if strings.TrimSpace(content) == "" {
+		return nil
+	}
+
+	if err := m.store.AddMessage(ctx, &domain.ConversationMessage{
+		UserID:  userID,
+		Role:    role,
+		Content: content,
+	}); err != nil {
+		return fmt.Errorf("failed to save message: %w", err)
+	}
+
+	return m.compact(ctx, userID)
+}
+
+// compact переносит сообщения старше окна в summary. LLM вызывается без блокировки,
+// чтобы SetPending, ClearPending и Forget других вызовов не ждали summarizer
+func (m *Memory) compact(ctx context.Context, userID int64) error {
+	base, older, err := m.olderMessages(ctx, userID)
+	if err != nil || len(older) == 0 {
+		return err
+	}
+
+	summary := m.summarize(base.Summary, older)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Пока шло сжатие, другой вызов мог уже сжать историю, а /forget - удалить ее
+	state, err := m.state(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if state.SummarizedUntil != base.SummarizedUntil {
+		return nil
+	}
+	rest, err := m.store.GetMessagesAfter(ctx, userID, state.SummarizedUntil)
+	if err != nil {
+		return fmt.Errorf("failed to load conversation: %w", err)
+	}
+	if len(rest) == 0 || rest[0].ID != older[0].ID {
+		return nil
+	}
+
+	state.Summary = summary
+	state.SummarizedUntil = older[len(older)-1].ID
+
+	if err := m.store.SaveState(ctx, state); err != nil {
+		return fmt.Errorf("failed to save conversation state: %w", err)
+	}
+	if err := m.store.DeleteSummarized(ctx, userID, state.SummarizedUntil); err != nil {
+		utils.LogWarn(fmt.Sprintf("[Memory] Failed to delete summarized messages: %v", err))
+	}
+
+	return nil
+}
+
+// olderMessages состояние и сообщения старше окна, которые пора сжать (пусто - рано)
+func (m *Memory) olderMessages(ctx context.Context, userID int64) (*domain.ConversationState, []domain.ConversationMessage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, err := m.state(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	messages, err := m.store.GetMessagesAfter(ctx, userID, state.SummarizedUntil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+	if len(messages) <= m.config.WindowSize+m.config.SummarizeEvery {
+		return state, nil, nil
+	}
+	return state, messages[:len(messages)-m.config.WindowSize], nil
+}
+
+// summarize дополняет summary старыми сообщениями. При ошибке LLM summary не меняется.
+func (m *Memory) summarize(summary string, messages []domain.ConversationMessage) string {
+	if m.summarizer == nil {
+		return summary
+	}
+
+	var prompt strings.Builder
+	if summary != "" {
+		prompt.WriteString("Текущее краткое содержание:\n")
+		prompt.WriteString(summary)
+		prompt.WriteString("\n\n")
+	}
+	prompt.WriteString("Новые сообщения:\n")
+	for _, msg := range messages {
+		prompt.WriteString(fmt.Sprintf("%s: %s\n", msg.Role, msg.Content))
+	}
+
+	result, err := m.summarizer.Complete(summarySystemPrompt, prompt.String())
+	if err != nil {
+		utils.LogWarn(fmt.Sprintf("[Memory] Summarization failed, dropping old turns: %v", err))
+		return summary
+	}
+
+	return strings.TrimSpace(result)
+}
+
+const summarySystemPrompt = `Сожми диалог пользователя с торговым ботом в краткое содержание (до 5 предложений).
+Сохрани: упомянутые активы, суммы, настройки, принятые и отклоненные действия, открытые вопросы.
+Не добавляй ничего, чего не было в диалоге. Ответь только текстом summary.`
+
+// SetPending сохраняет действия, ожидающие подтверждения пользователя
+func (m *Memory) SetPending(ctx context.Context, userID int64, actions []ai.AIAction) error {
+	data, err := json.Marshal(actions)
+	if err != nil {
+		return fmt.Errorf("failed to encode pending actions: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, err := m.state(ctx, userID)
+	if err != nil {
+		return err
+	}
+	state.PendingActions = string(data)
+	state.PendingAt = time.Now()
+
+	return m.store.SaveState(ctx, state)
+}
+
+// Pending возвращает неподтвержденные действия (nil, если их нет или истек TTL)
+func (m *Memory) Pending(ctx context.Context, userID int64) ([]ai.AIAction, error) {
+	state, err := m.state(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if state.PendingActions == "" || time.Since(state.PendingAt) > m.config.PendingTTL {
+		return nil, nil
+	}
+
+	var actions []ai.AIAction
+	if err := json.Unmarshal([]byte(state.PendingActions), &actions); err != nil {
+		return nil, fmt.Errorf("failed to decode pending actions: %w", err)
+	}
+	return actions, nil
+}
+
+// ClearPending удаляет неподтвержденные действия
+func (m *Memory) ClearPending(ctx context.Context, userID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, err := m.state(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if state.PendingActions == "" {
+		return nil
+	}
+	state.PendingActions = ""
+	state.PendingAt = time.Time{}
+
+	return m.store.SaveState(ctx, state)
+}
+
+// Forget удаляет всю историю и ожидающие действия пользователя
+func (m *Memory) Forget(ctx context.Context, userID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.store.DeleteUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to forget conversation: %w", err)
+	}
+	return nil
+}
+
+// state загружает состояние пользователя или создает пустое
+func (m *Memory) state(ctx context.Context, userID int64) (*domain.ConversationState, error) {
+	state, err := m.store.GetState(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation state: %w", err)
+	}
+	if state == nil {
+		state = &domain.ConversationState{UserID: userID}
+	}
+	return state, nil
+}
@@ -0,0 +1,224 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+type memoryStore struct {
+	messages []domain.ConversationMessage
+	states   map[int64]domain.ConversationState
+	nextID   int64
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{states: make(map[int64]domain.ConversationState)}
+}
+
+func (s *memoryStore) AddMessage(ctx context.Context, msg *domain.ConversationMessage) error {
+	s.nextID++
+	msg.ID = s.nextID
+	s.messages = append(s.messages, *msg)
+	return nil
+}
+
+func (s *memoryStore) GetMessagesAfter(ctx context.Context, userID, afterID int64) ([]domain.ConversationMessage, error) {
+	var result []domain.ConversationMessage
+	for _, m := range s.messages {
+		if m.UserID == userID && m.ID > afterID {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+func (s *memoryStore) GetState(ctx context.Context, userID int64) (*domain.ConversationState, error) {
+	state, ok := s.states[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+func (s *memoryStore) SaveState(ctx context.Context, state *domain.ConversationState) error {
+	s.states[state.UserID] = *state
+	return nil
+}
+
+func (s *memoryStore) DeleteSummarized(ctx context.Context, userID, untilID int64) error {
+	kept := s.messages[:0]
+	for _, m := range s.messages {
+		if m.UserID != userID || m.ID > untilID {
+			kept = append(kept, m)
+		}
+	}
+	s.messages = kept
+	return nil
+}
+
+func (s *memoryStore) DeleteUser(ctx context.Context, userID int64) error {
+	s.DeleteSummarized(ctx, userID, s.nextID)
+	delete(s.states, userID)
+	return nil
+}
+
+type stubSummarizer struct {
+	calls  int
+	err    error
+	during func() // вызывается, пока "LLM" отвечает
+}
+
+func (s *stubSummarizer) Complete(systemPrompt, userPrompt string) (string, error) {
+	s.calls++
+	if s.during != nil {
+		s.during()
+	}
+	if s.err != nil {
+		return "", s.err
+	}
+	return fmt.Sprintf("summary #%d", s.calls), nil
+}
+
+func TestMemoryWindowAndSummary(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	summarizer := &stubSummarizer{}
+	m := New(store, summarizer, Config{WindowSize: 4, SummarizeEvery: 2})
+
+	for i := 0; i < 6; i++ {
+		if err := m.Append(ctx, 1, RoleUser, "msg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if summarizer.calls != 0 {
+		t.Fatalf("summarized too early: %d calls", summarizer.calls)
+	}
+
+	// 7-е сообщение превышает окно + порог → 3 старых уходят в summary
+	m.Append(ctx, 1, RoleAssistant, "reply")
+	if summarizer.calls != 1 {
+		t.Fatalf("expected one summarization, got %d", summarizer.calls)
+	}
+
+	history, err := m.History(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 5 || history[0].Role != "system" || !strings.Contains(history[0].Content, "summary #1") {
+		t.Fatalf("unexpected history %+v", history)
+	}
+	if history[4].Content != "reply" {
+		t.Fatalf("last message should be newest, got %+v", history[4])
+	}
+
+	// Другой пользователь изолирован
+	if other, _ := m.History(ctx, 2); len(other) != 0 {
+		t.Fatalf("history leaked to another user: %+v", other)
+	}
+
+	if err := m.Forget(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if history, _ := m.History(ctx, 1); len(history) != 0 {
+		t.Fatalf("history after forget: %+v", history)
+	}
+}
+
+func TestMemoryCompactDoesNotBlock(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	summarizer := &stubSummarizer{}
+	m := New(store, summarizer, Config{WindowSize: 2, SummarizeEvery: 1})
+
+	// Пока идет сжатие, операции памяти выполняются, а /forget отменяет сохранение summary
+	summarizer.during = func() {
+		done := make(chan error, 1)
+		go func() {
+			if err := m.SetPending(ctx, 2, []ai.AIAction{{Type: "set_dca"}}); err != nil {
+				done <- err
+				return
+			}
+			done <- m.Forget(ctx, 1)
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Error(err)
+			}
+		case <-time.After(time.Second):
+			t.Error("memory locked while summarizing")
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		if err := m.Append(ctx, 1, RoleUser, "msg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if summarizer.calls != 1 {
+		t.Fatalf("expected one summarization, got %d", summarizer.calls)
+	}
+	if state, _ := store.GetState(ctx, 1); state != nil {
+		t.Errorf("summary saved after forget: %+v", state)
+	}
+}
+
+func TestMemorySummarizerFailureKeepsWindow(t *testing.T) {
+	ctx := context.Background()
+	m := New(newMemoryStore(), &stubSummarizer{err: errors.New("ollama down")}, Config{WindowSize: 2, SummarizeEvery: 1})
+
+	for i := 0; i < 5; i++ {
+		if err := m.Append(ctx, 1, RoleUser, "msg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := m.History(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected window of 2 without summary, got %+v", history)
+	}
+}
+
+func TestMemoryPendingActions(t *testing.T) {
+	ctx := context.Background()
+	m := New(newMemoryStore(), nil, Config{PendingTTL: time.Minute})
+	actions := []ai.AIAction{{Type: "manual_buy", Parameters: map[string]interface{}{"symbol": "BTCUSDT"}}}
+
+	if err := m.SetPending(ctx, 1, actions); err != nil {
+		t.Fatal(err)
+	}
+
+	// Новый экземпляр поверх того же хранилища (рестарт бота) видит ожидающие действия
+	restored := New(m.store, nil, Config{PendingTTL: time.Minute})
+	pending, err := restored.Pending(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Type != "manual_buy" || pending[0].Parameters["symbol"] != "BTCUSDT" {
+		t.Fatalf("unexpected pending %+v", pending)
+	}
+
+	// Истекший TTL
+	state, _ := m.store.GetState(ctx, 1)
+	state.PendingAt = time.Now().Add(-2 * time.Minute)
+	m.store.SaveState(ctx, state)
+	if pending, _ := m.Pending(ctx, 1); pending != nil {
+		t.Fatalf("expired pending returned: %+v", pending)
+	}
+
+	m.SetPending(ctx, 1, actions)
+	m.ClearPending(ctx, 1)
+	if pending, _ := m.Pending(ctx, 1); pending != nil {
+		t.Fatalf("cleared pending returned: %+v", pending)
+	}
+}
@@ -0,0 +1,339 @@
+package prompts
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Имена промптов
+const (
+	Decision      = "decision"       // DecisionClient: секции system и user
+	Chat          = "chat"           // AIClient.ProcessMessage: секция system
+	ChatAgent     = "chat_agent"     // ChatAgent: секция system
+	AnalysisAgent = "analysis_agent" // AnalysisAgent: секция system
+	Report        = "report"         // reports.Generator: секция system
+	Incident      = "incident"       // incidents.Analyzer: секция system
+)
+
+// Секции шаблона ({{define "system"}} / {{define "user"}})
+const (
+	SectionSystem = "system"
+	SectionUser   = "user"
+)
+
+// templateExt расширение файлов шаблонов: <name>/<version>.tmpl
+const templateExt = ".tmpl"
+
+//go:embed templates
+var embedded embed.FS
+
+// Prompt одна версия шаблона промпта
+type Prompt struct {
+	Name    string
+	Version string
+	tmpl    *template.Template
+}
+
+// ID идентификатор версии для логов и БД: decision@v2
+func (p *Prompt) ID() string {
+	return p.Name + "@" + p.Version
+}
+
+// Render заполняет секцию шаблона данными
+func (p *Prompt) Render(section string, data interface{}) (string, error) {
+	if p.tmpl.Lookup(section) == nil {
+		return "", fmt.Errorf("prompt %s: section %q not defined", p.ID(), section)
+	}
+
+	var sb strings.Builder
+	if err := p.tmpl.ExecuteTemplate(&sb, section, data); err != nil {
+		return "", fmt.Errorf("prompt %s: %w", p.ID(), err)
+	}
+	return sb.String(), nil
+}
+
+// Experiment A/B тест двух версий: версии чередуются между вызовами Variant
+type Experiment struct {
+	A, B  string
+	calls uint64
+}
+
+// Status состояние промпта для /prompts
+type Status struct {
+	Name       string
+	Active     string
+	Versions   []string
+	Experiment *Experiment
+}
+
+// Registry реестр версий промптов с переключением активной версии во время работы
+type Registry struct {
+	mu          sync.RWMutex
+	prompts     map[string]map[string]*Prompt
+	active      map[string]string
+	experiments map[string]*Experiment
+	dir         string // каталог с дополнительными версиями (AI_PROMPTS_DIR)
+}
+
+// NewRegistry создает реестр со встроенными шаблонами и версиями из dir (если задан).
+// Версии из dir с тем же именем заменяют встроенные.
+func NewRegistry(dir string) (*Registry, error) {
+	r := &Registry{
+		prompts:     make(map[string]map[string]*Prompt),
+		active:      make(map[string]string),
+		experiments: make(map[string]*Experiment),
+		dir:         dir,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultRegistry *Registry
+)
+
+// Default реестр только со встроенными шаблонами (для клиентов без явного реестра)
+func Default() *Registry {
+	defaultOnce.Do(func() {
+		r, err := NewRegistry("")
+		if err != nil {
+			// Встроенные шаблоны проверяются тестами - ошибка здесь означает битую сборку
+			panic(fmt.Sprintf("embedded prompts: %v", err))
+		}
+		defaultRegistry = r
+	})
+	return defaultRegistry
+}
+
+// Reload перечитывает встроенные шаблоны и каталог. Активные версии и эксперименты
+// сохраняются, если эти версии остались; иначе активной становится последняя версия.
+func (r *Registry) Reload() error {
+	sub, err := fs.Sub(embedded, "templates")
+	if err != nil {
+		return err
+	}
+	loaded, err := loadFS(sub)
+	if err != nil {
+		return fmt.Errorf("embedded prompts: %w", err)
+	}
+
+	if r.dir != "" {
+		extra, err := loadFS(os.DirFS(r.dir))
+		if err != nil {
+			return fmt.Errorf("prompts dir %s: %w", r.dir, err)
+		}
+		for name, versions := range extra {
+			if loaded[name] == nil {
+				loaded[name] = make(map[string]*Prompt)
+			}
+			for version, p := range versions {
+				loaded[name][version] = p
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prompts = loaded
+	for name, versions := range loaded {
+		if _, ok := versions[r.active[name]]; !ok {
+			sorted := sortedVersions(versions)
+			r.active[name] = sorted[len(sorted)-1]
+		}
+	}
+	for name, exp := range r.experiments {
+		if r.prompts[name][exp.A] == nil || r.prompts[name][exp.B] == nil {
+			delete(r.experiments, name)
+		}
+	}
+	return nil
+}
+
+// loadFS разбирает файлы <name>/<version>.tmpl
+func loadFS(fsys fs.FS) (map[string]map[string]*Prompt, error) {
+	result := make(map[string]map[string]*Prompt)
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != templateExt {
+			return nil
+		}
+
+		name := path.Dir(p)
+		if name == "." || strings.Contains(name, "/") {
+			return fmt.Errorf("%s: expected <name>/<version>%s", p, templateExt)
+		}
+		version := strings.TrimSuffix(path.Base(p), templateExt)
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(string(data))
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		if tmpl.Lookup(SectionSystem) == nil {
+			return fmt.Errorf("%s: section %q not defined", p, SectionSystem)
+		}
+
+		if result[name] == nil {
+			result[name] = make(map[string]*Prompt)
+		}
+		result[name][version] = &Prompt{Name: name, Version: version, tmpl: tmpl}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Variant выбирает версию для очередного вызова или цикла решений: при A/B тесте
+// версии чередуются, иначе - активная версия. Каждый вызов сдвигает A/B тест,
+// поэтому вариант выбирается один раз и передается дальше
+func (r *Registry) Variant(name string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	version := r.active[name]
+	if exp := r.experiments[name]; exp != nil {
+		version = exp.A
+		if exp.calls%2 == 1 {
+			version = exp.B
+		}
+		exp.calls++
+	}
+	return version
+}
+
+// Get возвращает конкретную версию промпта
+func (r *Registry) Get(name, version string) (*Prompt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if err := r.checkVersion(name, version); err != nil {
+		return nil, err
+	}
+	return r.prompts[name][version], nil
+}
+
+// Select выбирает версию через Variant и возвращает ее
+func (r *Registry) Select(name string) (*Prompt, error) {
+	return r.Get(name, r.Variant(name))
+}
+
+// Render выбирает версию и заполняет секцию; возвращает текст и версию
+func (r *Registry) Render(name, section string, data interface{}) (string, *Prompt, error) {
+	p, err := r.Select(name)
+	if err != nil {
+		return "", nil, err
+	}
+	text, err := p.Render(section, data)
+	return text, p, err
+}
+
+// SetActive переключает активную версию и останавливает A/B тест этого промпта
+func (r *Registry) SetActive(name, version string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkVersion(name, version); err != nil {
+		return err
+	}
+	r.active[name] = version
+	delete(r.experiments, name)
+	return nil
+}
+
+// StartExperiment запускает A/B тест двух версий
+func (r *Registry) StartExperiment(name, a, b string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if a == b {
+		return fmt.Errorf("A/B test needs two different versions")
+	}
+	for _, version := range []string{a, b} {
+		if err := r.checkVersion(name, version); err != nil {
+			return err
+		}
+	}
+	r.experiments[name] = &Experiment{A: a, B: b}
+	return nil
+}
+
+// StopExperiment останавливает A/B тест; дальше используется активная версия
+func (r *Registry) StopExperiment(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.experiments, name)
+}
+
+// Active активная версия промпта
+func (r *Registry) Active(name string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active[name]
+}
+
+// Status состояние всех промптов, отсортированное по имени
+func (r *Registry) Status() []Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]Status, 0, len(r.prompts))
+	for name, versions := range r.prompts {
+		st := Status{Name: name, Active: r.active[name], Versions: sortedVersions(versions)}
+		if exp := r.experiments[name]; exp != nil {
+			copied := *exp
+			st.Experiment = &copied
+		}
+		result = append(result, st)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (r *Registry) checkVersion(name, version string) error {
+	versions, ok := r.prompts[name]
+	if !ok {
+		return fmt.Errorf("prompt %s not found", name)
+	}
+	if _, ok := versions[version]; !ok {
+		return fmt.Errorf("prompt %s: version %s not found (available: %s)",
+			name, version, strings.Join(sortedVersions(versions), ", "))
+	}
+	return nil
+}
+
+// sortedVersions сортирует версии с учетом номера: v2 < v10
+func sortedVersions(versions map[string]*Prompt) []string {
+	result := make([]string, 0, len(versions))
+	for version := range versions {
+		result = append(result, version)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		ni, erri := strconv.Atoi(strings.TrimPrefix(result[i], "v"))
+		nj, errj := strconv.Atoi(strings.TrimPrefix(result[j], "v"))
+		if erri == nil && errj == nil {
+			return ni < nj
+		}
+		return result[i] < result[j]
+	})
+	return result
+}
@@ -0,0 +1,163 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedPromptsRender(t *testing.T) {
+	r := Default()
+
+	tests := []struct {
+		name    string
+		section string
+		data    interface{}
+		want    string
+	}{
+		{Decision, SectionSystem, nil, "submit_decision"},
+		{Decision, SectionUser, map[string]string{"Mode": "pilot", "Time": "now", "Portfolio": "{}", "Market": "{}", "News": "[]", "Limits": "{}", "ToolName": "submit_decision"}, "- Mode: pilot"},
+		{Chat, SectionSystem, struct{ Context string }{"BTC 65000"}, "BTC 65000"},
+		{ChatAgent, SectionSystem, nil, "/ai_decision"},
+		{AnalysisAgent, SectionSystem, nil, "аналитик"},
+	}
+
+	for _, tt := range tests {
+		text, p, err := r.Render(tt.name, tt.section, tt.data)
+		if err != nil {
+			t.Fatalf("%s/%s: %v", tt.name, tt.section, err)
+		}
+		if p.Version != "v1" || !strings.Contains(text, tt.want) {
+			t.Errorf("%s/%s (%s): %q does not contain %q", tt.name, tt.section, p.Version, text, tt.want)
+		}
+	}
+
+	// Незаполненное поле шаблона - ошибка, а не "<no value>" в промпте
+	if _, _, err := r.Render(Decision, SectionUser, map[string]string{"Mode": "pilot"}); err == nil {
+		t.Error("expected error for missing template field")
+	}
+}
+
+func writePrompt(t *testing.T, dir, name, version, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name, version+templateExt), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistryVersionsAndExperiment(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, dir, Decision, "v2", `{{define "system"}}decision v2{{end}}{{define "user"}}mode {{.Mode}}{{end}}`)
+	writePrompt(t, dir, Decision, "v10", `{{define "system"}}decision v10{{end}}`)
+
+	r, err := NewRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Последняя версия по номеру, а не по строке
+	if got := r.Active(Decision); got != "v10" {
+		t.Fatalf("Active = %s, want v10", got)
+	}
+
+	if err := r.SetActive(Decision, "v3"); err == nil {
+		t.Fatal("expected error for unknown version")
+	}
+	if err := r.StartExperiment(Decision, "v1", "v1"); err == nil {
+		t.Fatal("expected error for A/B with the same version")
+	}
+
+	if err := r.StartExperiment(Decision, "v1", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for i := 0; i < 4; i++ {
+		p, err := r.Select(Decision)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, p.Version)
+	}
+	if strings.Join(got, ",") != "v1,v2,v1,v2" {
+		t.Fatalf("A/B selection = %v", got)
+	}
+
+	// Get не сдвигает A/B тест: выбранный вариант можно перечитывать в течение цикла
+	if p, err := r.Get(Decision, "v2"); err != nil || p.Version != "v2" {
+		t.Fatalf("Get = %+v, %v", p, err)
+	}
+	if got := r.Variant(Decision); got != "v1" {
+		t.Fatalf("Variant after Get = %s, want v1", got)
+	}
+	if _, err := r.Get(Decision, "v3"); err == nil {
+		t.Fatal("expected error for unknown version")
+	}
+
+	// Эксперимент переживает reload, пока версии на месте
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if st := status(r, Decision); st.Experiment == nil {
+		t.Fatal("experiment lost after reload")
+	}
+
+	// Переключение активной версии останавливает A/B тест
+	if err := r.SetActive(Decision, "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if st := status(r, Decision); st.Experiment != nil || st.Active != "v2" {
+		t.Fatalf("status after SetActive = %+v", st)
+	}
+
+	// Версия удалена из каталога - активной становится последняя оставшаяся
+	if err := os.Remove(filepath.Join(dir, Decision, "v2"+templateExt)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Active(Decision); got != "v10" {
+		t.Fatalf("Active after reload = %s, want v10", got)
+	}
+}
+
+func TestRegistryRejectsInvalidTemplates(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		body string
+	}{
+		{"parse error", "decision/v2.tmpl", `{{define "system"}}{{.Mode{{end}}`},
+		{"no system section", "decision/v2.tmpl", `just text`},
+		{"nested directory", "decision/extra/v2.tmpl", `{{define "system"}}x{{end}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, filepath.FromSlash(tt.file))
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(tt.body), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := NewRegistry(dir); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func status(r *Registry, name string) Status {
+	for _, st := range r.Status() {
+		if st.Name == name {
+			return st
+		}
+	}
+	return Status{}
+}
@@ -0,0 +1,182 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/tracing"
+)
+
+// Типы провайдеров LLM
+const (
+	ProviderOpenAI    = "openai"    // OpenAI-совместимый /v1/chat/completions (Moonshot, Qwen, vLLM)
+	ProviderOllama    = "ollama"    // нативный Ollama /api/chat
+	ProviderAnthropic = "anthropic" // Anthropic Messages API /v1/messages
+)
+
+// ErrBudgetExceeded дневной бюджет облачных вызовов исчерпан
+var ErrBudgetExceeded = errors.New("daily LLM budget exceeded")
+
+// Usage токены, потраченные на вызов
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// ChatResult нормализованный ответ провайдера
+type ChatResult struct {
+	Content   string     `json:"content,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Usage     Usage      `json:"usage"`
+	Provider  string     `json:"provider,omitempty"`
+	Model     string     `json:"model,omitempty"`
+	// ReducedTrust ответ получен от резервной модели (fallback chain)
+	ReducedTrust bool `json:"reduced_trust,omitempty"`
+}
+
+// Provider абстракция над API конкретного LLM
+type Provider interface {
+	Name() string
+	Model() string
+	// Cloud true для платных облачных провайдеров (учитываются в бюджете)
+	Cloud() bool
+	Chat(ctx context.Context, req ChatRequest) (*ChatResult, error)
+}
+
+// ProviderConfig настройки провайдера
+type ProviderConfig struct {
+	Name       string // произвольное имя для логов и учета (moonshot, ollama-local)
+	Kind       string // openai, ollama, anthropic
+	BaseURL    string
+	APIKey     string
+	Model      string
+	Cloud      bool
+	Timeout    time.Duration
+	MaxRetries int
+}
+
+// NewProvider создает провайдер по типу с таймаутом и повторами
+func NewProvider(cfg ProviderConfig) (Provider, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 60 * time.Second
+	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.Kind
+	}
+
+	httpClient := &http.Client{Timeout: cfg.Timeout}
+
+	var p Provider
+	switch cfg.Kind {
+	case ProviderOpenAI, "moonshot", "qwen", "":
+		p = &openAIProvider{config: cfg, client: httpClient}
+	case ProviderOllama:
+		p = &ollamaProvider{config: cfg, client: httpClient}
+	case ProviderAnthropic:
+		p = &anthropicProvider{config: cfg, client: httpClient}
+	default:
+		return nil, fmt.Errorf("unknown LLM provider kind: %s", cfg.Kind)
+	}
+
+	if cfg.MaxRetries > 0 {
+		p = &retryingProvider{Provider: p, maxRetries: cfg.MaxRetries, backoff: time.Second}
+	}
+	return p, nil
+}
+
+// APIError ошибка HTTP API провайдера
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API error (%d): %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// Retryable true для 429 и 5xx
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// retryingProvider повторяет вызов при сетевых ошибках, 429 и 5xx
+type retryingProvider struct {
+	Provider
+	maxRetries int
+	backoff    time.Duration
+}
+
+func (p *retryingProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResult, error) {
+	var lastErr error
+	for attempt := 0; attempt <= p.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(p.backoff * time.Duration(1<<(attempt-1))):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		result, err := p.Provider.Chat(ctx, req)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && !apiErr.Retryable() {
+			return nil, err
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("%s: %d retries failed: %w", p.Name(), p.maxRetries, lastErr)
+}
+
+// doJSON отправляет POST и возвращает тело ответа
+func doJSON(ctx context.Context, client *http.Client, provider, endpoint string, body []byte, headers map[string]string) (data []byte, err error) {
+	// Полный URL в спан не пишем: у некоторых провайдеров ключ передается в query
+	host := ""
+	if u, parseErr := url.Parse(endpoint); parseErr == nil {
+		host = u.Host
+	}
+	ctx, span := tracing.Start(ctx, "llm.chat", tracing.KindClient,
+		tracing.String("llm.provider", provider), tracing.String("server.address", host))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	span.SetAttributes(tracing.Int("http.status_code", resp.StatusCode))
+
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{Provider: provider, StatusCode: resp.StatusCode, Body: string(data)}
+	}
+	return data, nil
+}
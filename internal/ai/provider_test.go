@@ -0,0 +1,214 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+type stubProvider struct {
+	name  string
+	cloud bool
+	usage Usage
+	err   error
+	calls int
+}
+
+func (p *stubProvider) Name() string  { return p.name }
+func (p *stubProvider) Model() string { return p.name + "-model" }
+func (p *stubProvider) Cloud() bool   { return p.cloud }
+
+func (p *stubProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResult, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &ChatResult{Content: "ok from " + p.name, Usage: p.usage, Provider: p.name}, nil
+}
+
+type memoryUsageStore struct {
+	records []domain.LLMUsage
+}
+
+func (s *memoryUsageStore) Save(ctx context.Context, usage *domain.LLMUsage) error {
+	s.records = append(s.records, *usage)
+	return nil
+}
+
+func (s *memoryUsageStore) GetCostSince(ctx context.Context, since time.Time) (float64, error) {
+	total := 0.0
+	for _, r := range s.records {
+		if r.Cloud && !r.Timestamp.Before(since) {
+			total += r.CostUSD
+		}
+	}
+	return total, nil
+}
+
+func TestFallbackChainMarksReducedTrust(t *testing.T) {
+	store := &memoryUsageStore{}
+	tracker := NewCostTracker(store, 0)
+
+	cloud := &stubProvider{name: "cloud", cloud: true, err: &APIError{Provider: "cloud", StatusCode: 503}}
+	local := &stubProvider{name: "local", usage: Usage{PromptTokens: 10, CompletionTokens: 5}}
+
+	chain := NewFallbackChain(
+		Metered(cloud, Pricing{InputPerMillion: 1, OutputPerMillion: 2}, tracker, PurposeDecision),
+		Metered(local, Pricing{}, tracker, PurposeDecision),
+	)
+
+	result, err := chain.Chat(context.Background(), ChatRequest{})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if !result.ReducedTrust || result.Provider != "local" {
+		t.Fatalf("expected reduced trust answer from local, got %+v", result)
+	}
+
+	if len(store.records) != 2 {
+		t.Fatalf("expected 2 usage records, got %d", len(store.records))
+	}
+	if store.records[0].Success || store.records[0].Provider != "cloud" {
+		t.Errorf("first record should be failed cloud call: %+v", store.records[0])
+	}
+	if !store.records[1].ReducedTrust || store.records[1].PromptTokens != 10 {
+		t.Errorf("second record should be reduced-trust local call: %+v", store.records[1])
+	}
+}
+
+func TestBudgetBlocksCloudCalls(t *testing.T) {
+	store := &memoryUsageStore{}
+	tracker := NewCostTracker(store, 0.01)
+
+	cloud := &stubProvider{name: "cloud", cloud: true, usage: Usage{PromptTokens: 1_000_000}}
+	local := &stubProvider{name: "local"}
+	metered := Metered(cloud, Pricing{InputPerMillion: 0.02}, tracker, PurposeChat)
+
+	if _, err := metered.Chat(context.Background(), ChatRequest{}); err != nil {
+		t.Fatalf("first call should pass: %v", err)
+	}
+	if got := tracker.SpentToday(); got < 0.0199 || got > 0.0201 {
+		t.Fatalf("SpentToday = %v, want 0.02", got)
+	}
+
+	if _, err := metered.Chat(context.Background(), ChatRequest{}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	if cloud.calls != 1 {
+		t.Fatalf("cloud should not be called over budget, calls=%d", cloud.calls)
+	}
+
+	// Локальные вызовы бюджетом не ограничены
+	if _, err := Metered(local, Pricing{}, tracker, PurposeChat).Chat(context.Background(), ChatRequest{}); err != nil {
+		t.Fatalf("local call blocked: %v", err)
+	}
+
+	// После рестарта расходы подгружаются из хранилища
+	if err := NewCostTracker(store, 0.01).Allow(cloud); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("restored tracker should block, got %v", err)
+	}
+}
+
+func TestRetryingProvider(t *testing.T) {
+	flaky := &stubProvider{name: "cloud", err: &APIError{StatusCode: 429}}
+	p := &retryingProvider{Provider: flaky, maxRetries: 2, backoff: time.Millisecond}
+	if _, err := p.Chat(context.Background(), ChatRequest{}); err == nil || flaky.calls != 3 {
+		t.Fatalf("expected 3 calls and error, got calls=%d err=%v", flaky.calls, err)
+	}
+
+	bad := &stubProvider{name: "cloud", err: &APIError{StatusCode: 401}}
+	p = &retryingProvider{Provider: bad, maxRetries: 2, backoff: time.Millisecond}
+	if _, err := p.Chat(context.Background(), ChatRequest{}); err == nil || bad.calls != 1 {
+		t.Fatalf("4xx must not be retried, calls=%d", bad.calls)
+	}
+}
+
+func TestProviderWireFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     string
+		path     string
+		response string
+		check    func(t *testing.T, body map[string]interface{})
+	}{
+		{
+			name:     "ollama native",
+			kind:     ProviderOllama,
+			path:     "/api/chat",
+			response: `{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"submit_decision","arguments":{"regime":"DEFENSE"}}}]},"prompt_eval_count":12,"eval_count":7}`,
+			check: func(t *testing.T, body map[string]interface{}) {
+				if body["stream"] != false {
+					t.Errorf("stream must be false")
+				}
+			},
+		},
+		{
+			name:     "anthropic messages",
+			kind:     ProviderAnthropic,
+			path:     "/v1/messages",
+			response: `{"content":[{"type":"tool_use","id":"tu_1","name":"submit_decision","input":{"regime":"DEFENSE"}}],"usage":{"input_tokens":12,"output_tokens":7}}`,
+			check: func(t *testing.T, body map[string]interface{}) {
+				if body["system"] != "sys" {
+					t.Errorf("system prompt must be top-level, got %v", body["system"])
+				}
+				choice, _ := body["tool_choice"].(map[string]interface{})
+				if choice["type"] != "tool" || choice["name"] != DecisionToolName {
+					t.Errorf("unexpected tool_choice %v", body["tool_choice"])
+				}
+			},
+		},
+		{
+			name:     "openai compatible",
+			kind:     ProviderOpenAI,
+			path:     "/v1/chat/completions",
+			response: `{"choices":[{"message":{"role":"assistant","tool_calls":[{"id":"c1","type":"function","function":{"name":"submit_decision","arguments":"{\"regime\":\"DEFENSE\"}"}}]}}],"usage":{"prompt_tokens":12,"completion_tokens":7}}`,
+			check: func(t *testing.T, body map[string]interface{}) {
+				if body["tool_choice"] == nil {
+					t.Errorf("tool_choice must be sent")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.path {
+					t.Errorf("path = %s, want %s", r.URL.Path, tt.path)
+				}
+				var body map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&body)
+				tt.check(t, body)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			p, err := NewProvider(ProviderConfig{Kind: tt.kind, BaseURL: server.URL, Model: "m"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client := NewAIClientWithProvider(p)
+			result, err := client.chatWithRequiredTool(context.Background(), []Message{
+				{Role: "system", Content: "sys"},
+				{Role: "user", Content: "decide"},
+			}, DecisionTool())
+			if err != nil {
+				t.Fatalf("chat: %v", err)
+			}
+
+			if len(result.ToolCalls) != 1 || result.ToolCalls[0].Function.Arguments != `{"regime":"DEFENSE"}` {
+				t.Fatalf("unexpected tool calls %+v", result.ToolCalls)
+			}
+			if result.Usage.PromptTokens != 12 || result.Usage.CompletionTokens != 7 {
+				t.Fatalf("unexpected usage %+v", result.Usage)
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ==================== OpenAI-compatible ====================
+
+// openAIProvider OpenAI-совместимый API (Moonshot, Qwen, OpenAI)
+type openAIProvider struct {
+	config ProviderConfig
+	client *http.Client
+}
+
+func (p *openAIProvider) Name() string  { return p.config.Name }
+func (p *openAIProvider) Model() string { return p.config.Model }
+func (p *openAIProvider) Cloud() bool   { return p.config.Cloud }
+
+func (p *openAIProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResult, error) {
+	req.Model = p.config.Model
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build endpoint, avoiding double /v1 if baseURL already contains it
+	endpoint := strings.TrimRight(p.config.BaseURL, "/")
+	if !strings.HasSuffix(endpoint, "/v1") {
+		endpoint += "/v1"
+	}
+	endpoint += "/chat/completions"
+
+	headers := map[string]string{}
+	if p.config.APIKey != "" {
+		headers["Authorization"] = "Bearer " + p.config.APIKey
+	}
+
+	data, err := doJSON(ctx, p.client, p.config.Name, endpoint, body, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	var chatResp ChatResponse
+	if err := json.Unmarshal(data, &chatResp); err != nil {
+		return nil, err
+	}
+	if len(chatResp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from AI")
+	}
+
+	message := chatResp.Choices[0].Message
+	return &ChatResult{
+		Content:   message.Content,
+		ToolCalls: message.ToolCalls,
+		Usage:     chatResp.Usage,
+		Provider:  p.config.Name,
+		Model:     p.config.Model,
+	}, nil
+}
+
+// ==================== Ollama native ====================
+
+// ollamaProvider нативный API Ollama (/api/chat)
+type ollamaProvider struct {
+	config ProviderConfig
+	client *http.Client
+}
+
+type ollamaChatRequest struct {
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	Tools    []Tool    `json:"tools,omitempty"`
+	Stream   bool      `json:"stream"`
+}
+
+type ollamaChatResponse struct {
+	Message struct {
+		Role      string `json:"role"`
+		Content   string `json:"content"`
+		ToolCalls []struct {
+			Function struct {
+				Name      string          `json:"name"`
+				Arguments json.RawMessage `json:"arguments"`
+			} `json:"function"`
+		} `json:"tool_calls"`
+	} `json:"message"`
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
+
+func (p *ollamaProvider) Name() string  { return p.config.Name }
+func (p *ollamaProvider) Model() string { return p.config.Model }
+func (p *ollamaProvider) Cloud() bool   { return p.config.Cloud }
+
+func (p *ollamaProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResult, error) {
+	// Ollama не поддерживает tool_choice - DecisionClient разбирает текстовый ответ
+	body, err := json.Marshal(ollamaChatRequest{
+		Model:    p.config.Model,
+		Messages: req.Messages,
+		Tools:    req.Tools,
+		Stream:   false,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimSuffix(strings.TrimRight(p.config.BaseURL, "/"), "/v1") + "/api/chat"
+
+	data, err := doJSON(ctx, p.client, p.config.Name, endpoint, body, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp ollamaChatResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+
+	result := &ChatResult{
+		Content: resp.Message.Content,
+		Usage: Usage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+		},
+		Provider: p.config.Name,
+		Model:    p.config.Model,
+	}
+
+	// В Ollama arguments - JSON объект, приводим к строке как в OpenAI
+	for i, tc := range resp.Message.ToolCalls {
+		call := ToolCall{ID: fmt.Sprintf("call_%d", i), Type: "function"}
+		call.Function.Name = tc.Function.Name
+		call.Function.Arguments = string(tc.Function.Arguments)
+		result.ToolCalls = append(result.ToolCalls, call)
+	}
+
+	return result, nil
+}
+
+// ==================== Anthropic ====================
+
+// anthropicProvider Anthropic Messages API
+type anthropicProvider struct {
+	config ProviderConfig
+	client *http.Client
+}
+
+const (
+	anthropicVersion   = "2023-06-01"
+	anthropicMaxTokens = 4096
+)
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model      string             `json:"model"`
+	MaxTokens  int                `json:"max_tokens"`
+	System     string             `json:"system,omitempty"`
+	Messages   []anthropicMessage `json:"messages"`
+	Tools      []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice interface{}        `json:"tool_choice,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		ID    string          `json:"id"`
+		Name  string          `json:"name"`
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func (p *anthropicProvider) Name() string  { return p.config.Name }
+func (p *anthropicProvider) Model() string { return p.config.Model }
+func (p *anthropicProvider) Cloud() bool   { return p.config.Cloud }
+
+func (p *anthropicProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResult, error) {
+	areq := anthropicRequest{
+		Model:     p.config.Model,
+		MaxTokens: anthropicMaxTokens,
+	}
+
+	// System prompt передается отдельным полем
+	var system []string
+	for _, m := range req.Messages {
+		if m.Role == "system" {
+			system = append(system, m.Content)
+			continue
+		}
+		areq.Messages = append(areq.Messages, anthropicMessage{Role: m.Role, Content: m.Content})
+	}
+	areq.System = strings.Join(system, "\n\n")
+
+	for _, t := range req.Tools {
+		schema := t.Function.Parameters
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		areq.Tools = append(areq.Tools, anthropicTool{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			InputSchema: schema,
+		})
+	}
+	if name := forcedToolName(req.ToolChoice); name != "" {
+		areq.ToolChoice = map[string]string{"type": "tool", "name": name}
+	}
+
+	body, err := json.Marshal(areq)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimSuffix(strings.TrimRight(p.config.BaseURL, "/"), "/v1") + "/v1/messages"
+	headers := map[string]string{
+		"x-api-key":         p.config.APIKey,
+		"anthropic-version": anthropicVersion,
+	}
+
+	data, err := doJSON(ctx, p.client, p.config.Name, endpoint, body, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp anthropicResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+
+	result := &ChatResult{
+		Usage: Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+		},
+		Provider: p.config.Name,
+		Model:    p.config.Model,
+	}
+
+	var text []string
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			text = append(text, block.Text)
+		case "tool_use":
+			call := ToolCall{ID: block.ID, Type: "function"}
+			call.Function.Name = block.Name
+			call.Function.Arguments = string(block.Input)
+			result.ToolCalls = append(result.ToolCalls, call)
+		}
+	}
+	result.Content = strings.Join(text, "\n")
+
+	return result, nil
+}
+
+// forcedToolName возвращает имя функции из tool_choice в формате OpenAI
+func forcedToolName(choice interface{}) string {
+	m, ok := choice.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	switch fn := m["function"].(type) {
+	case map[string]string:
+		return fn["name"]
+	case map[string]interface{}:
+		name, _ := fn["name"].(string)
+		return name
+	}
+	return ""
+}
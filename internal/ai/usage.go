@@ -0,0 +1,223 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/metrics"
+	"github.com/kirillm/dca-bot/pkg/utils"
+)
+
+// Назначения вызовов для учета
+const (
+	PurposeChat     = "chat"
+	PurposeAnalysis = "analysis"
+	PurposeDecision = "decision"
+	PurposeNews     = "news"
+	PurposeRouting  = "routing"
+	PurposeReport   = "report"
+	PurposeIncident = "incident"
+)
+
+// Pricing цена провайдера в USD за 1M токенов
+type Pricing struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// Cost рассчитывает стоимость вызова
+func (p Pricing) Cost(u Usage) float64 {
+	return float64(u.PromptTokens)/1e6*p.InputPerMillion + float64(u.CompletionTokens)/1e6*p.OutputPerMillion
+}
+
+// UsageStore хранилище учета вызовов (LLMUsageRepository)
+type UsageStore interface {
+	Save(ctx context.Context, usage *domain.LLMUsage) error
+	GetCostSince(ctx context.Context, since time.Time) (float64, error)
+}
+
+// CostTracker учитывает стоимость вызовов и блокирует облачные вызовы сверх дневного бюджета
+type CostTracker struct {
+	store       UsageStore
+	dailyBudget float64 // USD, 0 = без ограничения
+
+	mu     sync.Mutex
+	day    time.Time
+	spent  float64
+	loaded bool
+}
+
+// NewCostTracker создает трекер. store может быть nil (только in-memory учет).
+func NewCostTracker(store UsageStore, dailyBudgetUSD float64) *CostTracker {
+	metrics.AIDailyBudgetUSD.Set(dailyBudgetUSD)
+	return &CostTracker{
+		store:       store,
+		dailyBudget: dailyBudgetUSD,
+	}
+}
+
+// Allow проверяет, можно ли вызвать провайдера в рамках дневного бюджета
+func (t *CostTracker) Allow(p Provider) error {
+	if !p.Cloud() || t.dailyBudget <= 0 {
+		return nil
+	}
+
+	spent := t.SpentToday()
+	if spent >= t.dailyBudget {
+		return fmt.Errorf("%w: $%.2f of $%.2f spent today", ErrBudgetExceeded, spent, t.dailyBudget)
+	}
+	return nil
+}
+
+// Record сохраняет запись о вызове и обновляет дневные расходы
+func (t *CostTracker) Record(ctx context.Context, usage *domain.LLMUsage) {
+	observeUsage(usage)
+	if usage.Cloud {
+		t.mu.Lock()
+		t.rolloverLocked(ctx)
+		t.spent += usage.CostUSD
+		metrics.AICostTodayUSD.Set(t.spent)
+		t.mu.Unlock()
+	}
+
+	if t.store == nil {
+		return
+	}
+	if err := t.store.Save(ctx, usage); err != nil {
+		utils.LogWarn(fmt.Sprintf("Failed to save LLM usage: %v", err))
+	}
+}
+
+// observeUsage учитывает вызов LLM в метриках Prometheus; purpose - агент
+func observeUsage(usage *domain.LLMUsage) {
+	result := "ok"
+	if !usage.Success {
+		result = "error"
+	}
+	metrics.AIRequestsTotal.With(usage.Purpose, usage.Provider, result).Inc()
+	metrics.AIRequestDuration.With(usage.Purpose, usage.Provider).Observe(float64(usage.LatencyMs) / 1000)
+	metrics.AICostUSDTotal.With(usage.Purpose, usage.Provider).Add(usage.CostUSD)
+}
+
+// SpentToday возвращает расходы на облачные вызовы с начала дня (UTC)
+func (t *CostTracker) SpentToday() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rolloverLocked(context.Background())
+	return t.spent
+}
+
+// DailyBudget возвращает дневной бюджет в USD
+func (t *CostTracker) DailyBudget() float64 {
+	return t.dailyBudget
+}
+
+// rolloverLocked сбрасывает счетчик при смене дня и подгружает расходы из БД после рестарта
+func (t *CostTracker) rolloverLocked(ctx context.Context) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if t.loaded && t.day.Equal(today) {
+		return
+	}
+
+	t.day = today
+	t.spent = 0
+	t.loaded = true
+
+	if t.store != nil {
+		spent, err := t.store.GetCostSince(ctx, today)
+		if err != nil {
+			utils.LogWarn(fmt.Sprintf("Failed to load LLM spend: %v", err))
+			return
+		}
+		t.spent = spent
+	}
+}
+
+// meteredProvider проверяет бюджет и записывает токены/стоимость каждого вызова
+type meteredProvider struct {
+	Provider
+	pricing Pricing
+	tracker *CostTracker
+	purpose string
+	// fallback провайдер резервный в FallbackChain (ответы с пониженным доверием)
+	fallback bool
+}
+
+// Metered оборачивает провайдера учетом стоимости
+func Metered(p Provider, pricing Pricing, tracker *CostTracker, purpose string) Provider {
+	return &meteredProvider{Provider: p, pricing: pricing, tracker: tracker, purpose: purpose}
+}
+
+func (p *meteredProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResult, error) {
+	if err := p.tracker.Allow(p.Provider); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	result, err := p.Provider.Chat(ctx, req)
+
+	usage := &domain.LLMUsage{
+		Timestamp: start,
+		Provider:  p.Name(),
+		Model:     p.Model(),
+		Purpose:   p.purpose,
+		LatencyMs: time.Since(start).Milliseconds(),
+		Cloud:     p.Cloud(),
+		Success:   err == nil,
+	}
+	if err != nil {
+		usage.Error = err.Error()
+	} else {
+		usage.PromptTokens = result.Usage.PromptTokens
+		usage.CompletionTokens = result.Usage.CompletionTokens
+		usage.CostUSD = p.pricing.Cost(result.Usage)
+		usage.ReducedTrust = p.fallback
+	}
+	p.tracker.Record(ctx, usage)
+
+	return result, err
+}
+
+// FallbackChain перебирает провайдеров по порядку. Ответ не от первого
+// провайдера помечается ReducedTrust (например, облако недоступно → локальная модель).
+type FallbackChain struct {
+	providers []Provider
+}
+
+// NewFallbackChain создает цепочку: primary, затем резервные провайдеры
+func NewFallbackChain(primary Provider, fallbacks ...Provider) *FallbackChain {
+	for _, p := range fallbacks {
+		if m, ok := p.(*meteredProvider); ok {
+			m.fallback = true
+		}
+	}
+	return &FallbackChain{providers: append([]Provider{primary}, fallbacks...)}
+}
+
+func (c *FallbackChain) Name() string  { return c.providers[0].Name() }
+func (c *FallbackChain) Model() string { return c.providers[0].Model() }
+func (c *FallbackChain) Cloud() bool   { return c.providers[0].Cloud() }
+
+// Chat вызывает провайдеров по очереди до первого успешного ответа
+func (c *FallbackChain) Chat(ctx context.Context, req ChatRequest) (*ChatResult, error) {
+	var errs []error
+	for i, p := range c.providers {
+		result, err := p.Chat(ctx, req)
+		if err == nil {
+			if i > 0 {
+				result.ReducedTrust = true
+				utils.LogWarn(fmt.Sprintf("⚠️ LLM fallback: %s → %s (reduced trust)", c.providers[0].Name(), p.Name()))
+			}
+			return result, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, fmt.Errorf("all LLM providers failed: %v", errs)
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/rbac"
+)
+
+// Authenticator checks API keys and role order caps (rbac.Service)
+type Authenticator interface {
+	Authenticate(ctx context.Context, secret string) (domain.User, domain.APIKey, error)
+	CheckOrderSize(role string, amountUSD float64) error
+}
+
+type userContextKey struct{}
+
+// SetAuthenticator enables API key authentication with the same roles as Telegram
+func (s *Server) SetAuthenticator(auth Authenticator) {
+	s.auth = auth
+}
+
+// protect applies the IP allowlist and, with an authenticator, requires an API key
+// whose owner role has the permission: key IPs, signature and rate limit are checked too.
+// Mutating calls are audited, including rejected ones.
+// Without an authenticator only read-only calls stay open (legacy setups): mutating ones are refused
+func (s *Server) protect(perm rbac.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		body, err := readBody(w, r)
+		if err != nil {
+			s.sendError(w, "Request body is too large or unreadable", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		ip := s.security.clientIP(r)
+		if s.audit == nil || !isMutating(r.Method) {
+			s.serveProtected(w, r, perm, next, body, ip, &domain.APIAuditEntry{})
+			return
+		}
+
+		entry := &domain.APIAuditEntry{}
+		if ip != nil {
+			entry.RemoteIP = ip.String()
+		}
+		rec := &auditRecorder{ResponseWriter: w}
+		s.serveProtected(rec, r, perm, next, body, ip, entry)
+		s.saveAudit(entry, r, body, rec, started)
+	}
+}
+
+// serveProtected runs the checks in order and fills the audit entry with the key owner
+func (s *Server) serveProtected(w http.ResponseWriter, r *http.Request, perm rbac.Permission, next http.HandlerFunc,
+	body []byte, ip net.IP, entry *domain.APIAuditEntry) {
+	if !s.security.allowedIP(ip) {
+		s.sendError(w, "Client IP is not allowed", http.StatusForbidden)
+		return
+	}
+
+	if s.auth == nil {
+		if isMutating(r.Method) {
+			s.sendError(w, "API keys are not configured: mutating endpoints are disabled", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+		return
+	}
+
+	secret := apiKeyFromRequest(r)
+	if secret == "" {
+		s.sendError(w, "API key required", http.StatusUnauthorized)
+		return
+	}
+
+	user, key, err := s.auth.Authenticate(r.Context(), secret)
+	if errors.Is(err, rbac.ErrInvalidAPIKey) {
+		s.sendError(w, "Invalid API key", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		s.logger.Error("API key check failed: %v", err)
+		s.sendError(w, "Authentication unavailable", http.StatusInternalServerError)
+		return
+	}
+	entry.KeyID, entry.TelegramID, entry.Role = key.ID, user.TelegramID, user.Role
+
+	allowed, err := keyAllowsIP(key, ip)
+	if err != nil {
+		s.logger.Error("API key #%d has invalid IP restriction: %v", key.ID, err)
+	}
+	if !allowed {
+		s.sendError(w, "Client IP is not allowed for this API key", http.StatusForbidden)
+		return
+	}
+
+	if key.RequireSignature || r.Header.Get(headerSignature) != "" {
+		if err := s.security.verifySignature(key, r, body, time.Now()); err != nil {
+			s.sendError(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if ok, retryAfter := s.security.allow(key, time.Now()); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+		s.sendError(w, "Rate limit exceeded", http.StatusTooManyRequests)
+		return
+	}
+
+	if err := rbac.Authorize(user.Role, perm); err != nil {
+		s.sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	next(w, r.WithContext(context.WithValue(r.Context(), userContextKey{}, user)))
+}
+
+// checkOrderSize applies the role order cap of the authenticated user
+func (s *Server) checkOrderSize(r *http.Request, amountUSD float64) error {
+	user, ok := requestUser(r)
+	if !ok || s.auth == nil {
+		return nil
+	}
+	return s.auth.CheckOrderSize(user.Role, amountUSD)
+}
+
+// authorize checks a permission that depends on the request body
+func (s *Server) authorize(r *http.Request, perm rbac.Permission) error {
+	user, ok := requestUser(r)
+	if !ok || s.auth == nil {
+		return nil
+	}
+	return rbac.Authorize(user.Role, perm)
+}
+
+// requestUser returns the API key owner put into the context by protect
+func requestUser(r *http.Request) (domain.User, bool) {
+	user, ok := r.Context().Value(userContextKey{}).(domain.User)
+	return user, ok
+}
+
+// apiKeyFromRequest reads the key from X-API-Key or Authorization: Bearer.
+// Event streams also accept ?api_key=: browser EventSource and WebSocket cannot set headers
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, v1Prefix+"/events") {
+		return strings.TrimSpace(r.URL.Query().Get("api_key"))
+	}
+	return ""
+}
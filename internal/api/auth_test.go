@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/rbac"
+	"github.com/kirillm/dca-bot/pkg/utils"
+)
+
+// fakeAuth ключи -> пользователи для тестов
+type fakeAuth map[string]domain.User
+
+func (f fakeAuth) Authenticate(ctx context.Context, secret string) (domain.User, domain.APIKey, error) {
+	user, ok := f[secret]
+	if !ok {
+		return domain.User{}, domain.APIKey{}, rbac.ErrInvalidAPIKey
+	}
+	return user, domain.APIKey{ID: user.TelegramID, UserID: user.ID}, nil
+}
+
+func (f fakeAuth) CheckOrderSize(role string, amountUSD float64) error {
+	if role == domain.RoleTrader && amountUSD > 100 {
+		return rbac.ErrPermissionDenied
+	}
+	return nil
+}
+
+func TestProtect(t *testing.T) {
+	s := &Server{logger: utils.NewLogger("error")}
+	s.SetAuthenticator(fakeAuth{
+		"viewer-key": {TelegramID: 1, Role: domain.RoleViewer},
+		"trader-key": {TelegramID: 2, Role: domain.RoleTrader},
+	})
+
+	handler := s.protect(rbac.PermTrade, func(w http.ResponseWriter, r *http.Request) {
+		if err := s.checkOrderSize(r, 500); err != nil {
+			s.sendError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		s.sendSuccess(w, "ok")
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{"no key", "", "", http.StatusUnauthorized},
+		{"unknown key", "X-API-Key", "nope", http.StatusUnauthorized},
+		{"viewer cannot trade", "X-API-Key", "viewer-key", http.StatusForbidden},
+		{"trader over cap", "Authorization", "Bearer trader-key", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/buy", nil)
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.name, rec.Code, tt.want, rec.Body.String())
+		}
+	}
+
+	// Без authenticator открыто только чтение, изменяющие вызовы отклоняются
+	open := &Server{logger: utils.NewLogger("error")}
+	openHandler := open.protect(rbac.PermTrade, func(w http.ResponseWriter, r *http.Request) {
+		open.sendSuccess(w, "ok")
+	})
+	for method, want := range map[string]int{http.MethodGet: http.StatusOK, http.MethodPost: http.StatusForbidden} {
+		rec := httptest.NewRecorder()
+		openHandler(rec, httptest.NewRequest(method, "/buy", nil))
+		if rec.Code != want {
+			t.Errorf("open API %s status = %d, want %d", method, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,457 @@
+// Панель управления DCA Bot: читает тот же /api/v1 с X-API-Key
+'use strict';
+
+const KEY_STORAGE = 'dca-bot-api-key';
+const SVG_NS = 'http://www.w3.org/2000/svg';
+// Сервер шлет именованные SSE события, onmessage их не получает
+const EVENT_TYPES = [
+  'trade.filled',
+  'order.placed',
+  'grid.level_filled',
+  'breaker.tripped',
+  'ai.decision',
+  'mode.changed',
+  'kill_switch.changed',
+];
+
+let apiKey = sessionStorage.getItem(KEY_STORAGE) || '';
+let killActive = false;
+let eventSource = null;
+let refreshTimer = null;
+
+const $ = (id) => document.getElementById(id);
+
+// el создает DOM-элемент; текст только через textContent
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [k, v] of Object.entries(attrs || {})) {
+    if (k === 'class') node.className = v;
+    else node.setAttribute(k, v);
+  }
+  for (const child of children) {
+    node.append(child instanceof Node ? child : String(child ?? ''));
+  }
+  return node;
+}
+
+function svg(tag, attrs) {
+  const node = document.createElementNS(SVG_NS, tag);
+  for (const [k, v] of Object.entries(attrs || {})) node.setAttribute(k, v);
+  return node;
+}
+
+class APIError extends Error {
+  constructor(status, message) {
+    super(message);
+    this.status = status;
+  }
+}
+
+async function api(method, path, body) {
+  const opts = { method, headers: { 'X-API-Key': apiKey } };
+  if (body !== undefined) {
+    opts.headers['Content-Type'] = 'application/json';
+    opts.body = JSON.stringify(body);
+  }
+  const res = await fetch('/api/v1' + path, opts);
+  let payload = {};
+  try {
+    payload = await res.json();
+  } catch (e) {
+    // пустое или не-JSON тело
+  }
+  if (res.status === 401) {
+    logout(payload.error || 'Неверный API ключ');
+    throw new APIError(401, payload.error || 'unauthorized');
+  }
+  if (!res.ok || payload.success === false) {
+    throw new APIError(res.status, payload.error || res.statusText);
+  }
+  return payload.data;
+}
+
+// optional возвращает null, если раздел не подключен на сервере (503)
+async function optional(path) {
+  try {
+    return await api('GET', path);
+  } catch (e) {
+    if (e.status === 503 || e.status === 404) return null;
+    throw e;
+  }
+}
+
+const num = (v, digits = 2) => Number(v || 0).toLocaleString('ru-RU', { maximumFractionDigits: digits });
+const usd = (v) => '$' + num(v, 2);
+const time = (v) => (v ? new Date(v).toLocaleString('ru-RU') : '—');
+const signed = (v) => el('span', { class: v > 0 ? 'pos' : v < 0 ? 'neg' : '' }, usd(v));
+
+function notice(message) {
+  const node = $('notice');
+  node.textContent = message || '';
+  node.hidden = !message;
+}
+
+function fillTable(tbody, rows, empty) {
+  tbody.replaceChildren();
+  if (!rows || rows.length === 0) {
+    const cols = tbody.parentElement.querySelectorAll('th').length;
+    tbody.append(el('tr', {}, el('td', { colspan: cols, class: 'muted' }, empty)));
+    return;
+  }
+  for (const cells of rows) {
+    tbody.append(el('tr', {}, ...cells.map((c) => el('td', {}, c))));
+  }
+}
+
+// --- Авторизация ---
+
+function showLogin(error) {
+  $('app').hidden = true;
+  $('login').hidden = false;
+  $('login-error').textContent = error || '';
+  $('login-key').focus();
+}
+
+function logout(error) {
+  apiKey = '';
+  sessionStorage.removeItem(KEY_STORAGE);
+  if (eventSource) {
+    eventSource.close();
+    eventSource = null;
+  }
+  showLogin(error);
+}
+
+$('login-form').addEventListener('submit', async (e) => {
+  e.preventDefault();
+  apiKey = $('login-key').value.trim();
+  try {
+    await api('GET', '/mode').catch((err) => {
+      if (err.status !== 503) throw err;
+    });
+    sessionStorage.setItem(KEY_STORAGE, apiKey);
+    $('login-key').value = '';
+    start();
+  } catch (err) {
+    if (err.status !== 401) showLogin(err.message);
+  }
+});
+
+$('logout').addEventListener('click', () => logout());
+
+// --- Режим и kill switch ---
+
+async function loadControls() {
+  const mode = await optional('/mode');
+  $('mode-badge').textContent = mode ? mode.mode : 'нет';
+  $('mode-select').disabled = !mode;
+  $('mode-apply').disabled = !mode;
+  if (mode) $('mode-select').value = mode.mode;
+
+  const ks = await api('GET', '/kill-switch');
+  killActive = ks.emergency_stop || Boolean(ks.execution && ks.execution.active);
+  const badge = $('kill-badge');
+  badge.textContent = killActive ? 'ВКЛ' : 'выкл';
+  badge.className = 'badge ' + (killActive ? 'on' : 'off');
+  badge.title = (ks.execution && ks.execution.reason) || '';
+  $('kill-toggle').textContent = killActive ? 'Снять' : 'Остановить';
+  $('kill-toggle').className = killActive ? 'secondary' : 'danger';
+}
+
+$('mode-apply').addEventListener('click', async () => {
+  const mode = $('mode-select').value;
+  if (!confirm('Сменить режим на ' + mode + '?')) return;
+  try {
+    const res = await api('PUT', '/mode', { mode });
+    notice(res.applied === false ? 'Запрос на ' + mode + ' ждет подтверждения других админов' : '');
+  } catch (err) {
+    notice('Режим не изменен: ' + err.message);
+  }
+  loadControls().catch(reportError);
+});
+
+$('kill-toggle').addEventListener('click', async () => {
+  const active = !killActive;
+  let reason = '';
+  if (active) {
+    reason = prompt('Причина остановки торговли:', 'остановлено из панели');
+    if (reason === null) return;
+  } else if (!confirm('Снять kill switch и возобновить торговлю?')) {
+    return;
+  }
+  try {
+    await api('PUT', '/kill-switch', { active, reason });
+    notice('');
+  } catch (err) {
+    notice('Kill switch не изменен: ' + err.message);
+  }
+  loadControls().catch(reportError);
+});
+
+// --- Портфель ---
+
+// lineChart рисует серии точек {t, v} с общей осью
+function lineChart(container, series) {
+  const W = 800, H = 240, L = 60, R = 10, T = 10, B = 24;
+  const points = series.flatMap((s) => s.points);
+  container.replaceChildren();
+  if (points.length === 0) {
+    container.append(el('p', { class: 'muted' }, 'Нет снимков P&L'));
+    return;
+  }
+
+  const tMin = Math.min(...points.map((p) => p.t));
+  const tMax = Math.max(...points.map((p) => p.t));
+  let vMin = Math.min(...points.map((p) => p.v));
+  let vMax = Math.max(...points.map((p) => p.v));
+  if (vMin === vMax) {
+    vMin -= 1;
+    vMax += 1;
+  }
+  const x = (t) => L + (tMax === tMin ? (W - L - R) / 2 : ((t - tMin) / (tMax - tMin)) * (W - L - R));
+  const y = (v) => T + (1 - (v - vMin) / (vMax - vMin)) * (H - T - B);
+
+  const root = svg('svg', { viewBox: `0 0 ${W} ${H}`, preserveAspectRatio: 'none' });
+  for (const v of [vMin, (vMin + vMax) / 2, vMax]) {
+    root.append(svg('line', { class: 'axis', x1: L, x2: W - R, y1: y(v), y2: y(v) }));
+    const label = svg('text', { x: 4, y: y(v) + 4 });
+    label.textContent = num(v, 0);
+    root.append(label);
+  }
+  for (const [t, anchor] of [[tMin, 'start'], [tMax, 'end']]) {
+    const label = svg('text', { x: x(t), y: H - 6, 'text-anchor': anchor });
+    label.textContent = new Date(t).toLocaleDateString('ru-RU');
+    root.append(label);
+  }
+  for (const s of series) {
+    const d = s.points.map((p, i) => (i ? 'L' : 'M') + x(p.t).toFixed(1) + ' ' + y(p.v).toFixed(1)).join(' ');
+    root.append(svg('path', { class: s.class, d }));
+  }
+  container.append(root);
+}
+
+async function loadPortfolio() {
+  const type = $('pnl-type').value;
+  const history = (await api('GET', '/pnl/portfolio?type=' + type + '&limit=200')) || [];
+  history.reverse(); // API отдает от новых к старым
+
+  const toPoints = (field) => history.map((h) => ({ t: new Date(h.created_at).getTime(), v: h[field] }));
+  lineChart($('portfolio-chart'), [
+    { class: 'invested', points: toPoints('total_invested') },
+    { class: 'value', points: toPoints('current_value') },
+  ]);
+
+  const summary = $('portfolio-summary');
+  summary.replaceChildren();
+  const last = history[history.length - 1];
+  if (last) {
+    summary.append(
+      el('div', {}, el('span', { class: 'muted' }, 'Стоимость'), el('b', {}, usd(last.current_value))),
+      el('div', {}, el('span', { class: 'muted' }, 'Вложено'), el('b', {}, usd(last.total_invested))),
+      el('div', {}, el('span', { class: 'muted' }, 'P&L'), el('b', {}, signed(last.total_pnl))),
+      el('div', {}, el('span', { class: 'muted' }, 'Доходность'), el('b', {}, num(last.return_percent) + '%')),
+    );
+  }
+}
+
+$('pnl-type').addEventListener('change', () => loadPortfolio().catch(reportError));
+
+async function loadPositions() {
+  const balances = (await api('GET', '/balances')) || [];
+  fillTable(
+    $('positions'),
+    balances
+      .filter((b) => b.total_quantity > 0)
+      .map((b) => [b.symbol, num(b.total_quantity, 8), usd(b.avg_entry_price), usd(b.total_invested), signed(b.realized_profit), signed(b.unrealized_pnl)]),
+    'Нет открытых позиций',
+  );
+}
+
+// --- Grid ---
+
+async function loadGridSymbols() {
+  const assets = (await api('GET', '/assets')) || [];
+  const symbols = assets.filter((a) => a.strategy_type === 'GRID' || a.grid_levels > 0).map((a) => a.symbol);
+  const select = $('grid-symbol');
+  const current = select.value;
+  select.replaceChildren(...symbols.map((s) => el('option', { value: s }, s)));
+  if (symbols.includes(current)) select.value = current;
+}
+
+async function loadGrid() {
+  const symbol = $('grid-symbol').value;
+  const chart = $('grid-chart');
+  const info = $('grid-info');
+  chart.replaceChildren();
+  info.textContent = '';
+  if (!symbol) {
+    chart.append(el('p', { class: 'muted' }, 'Нет активов с grid-стратегией'));
+    return;
+  }
+
+  const status = await api('GET', '/grid/' + encodeURIComponent(symbol));
+  const trades = await api('GET', '/trades?limit=50&strategy=GRID&symbol=' + encodeURIComponent(symbol));
+  const orders = (status.orders || []).filter((o) => o.status === 'PENDING' || o.status === 'PLACED');
+  const fills = (trades && trades.trades) || [];
+  const price = status.metrics ? Number(status.metrics.current_price || 0) : 0;
+
+  const prices = orders.map((o) => o.price).concat(fills.map((f) => f.price));
+  if (price > 0) prices.push(price);
+  if (prices.length === 0) {
+    chart.append(el('p', { class: 'muted' }, 'Сетка не запущена'));
+    return;
+  }
+
+  const W = 800, H = 240, L = 70, R = 10, T = 10, B = 10;
+  let pMin = Math.min(...prices);
+  let pMax = Math.max(...prices);
+  const pad = (pMax - pMin) * 0.05 || pMax * 0.01 || 1;
+  pMin -= pad;
+  pMax += pad;
+  const y = (p) => T + (1 - (p - pMin) / (pMax - pMin)) * (H - T - B);
+
+  const root = svg('svg', { viewBox: `0 0 ${W} ${H}`, preserveAspectRatio: 'none' });
+  for (const o of orders) {
+    const side = o.side === 'BUY' ? 'buy' : 'sell';
+    root.append(svg('line', { class: side, x1: L, x2: W - R, y1: y(o.price), y2: y(o.price) }));
+    const label = svg('text', { x: 4, y: y(o.price) + 4 });
+    label.textContent = num(o.price, 4);
+    root.append(label);
+  }
+  if (price > 0) {
+    root.append(svg('line', { class: 'price', x1: L, x2: W - R, y1: y(price), y2: y(price) }));
+  }
+  // Исполненные ордера по времени слева направо
+  const chronological = fills.slice().reverse();
+  chronological.forEach((f, i) => {
+    const cx = L + ((i + 1) / (chronological.length + 1)) * (W - L - R);
+    root.append(svg('circle', { class: f.side === 'BUY' ? 'fill-buy' : 'fill-sell', cx, cy: y(f.price), r: 4 }));
+  });
+  chart.append(root);
+
+  info.textContent = `Цена ${price > 0 ? num(price, 4) : '—'} · открытых уровней ${orders.length} · исполнено ${fills.length}`;
+}
+
+$('grid-symbol').addEventListener('change', () => loadGrid().catch(reportError));
+
+// --- Сделки, решения, нарушения ---
+
+async function loadTrades() {
+  const page = await api('GET', '/trades?limit=20');
+  fillTable(
+    $('trades'),
+    ((page && page.trades) || []).map((t) => [
+      time(t.created_at),
+      t.symbol,
+      el('span', { class: t.side === 'BUY' ? 'pos' : 'neg' }, t.side),
+      num(t.quantity, 8),
+      num(t.price, 4),
+      usd(t.amount),
+      t.strategy + (t.grid_level ? ' #' + t.grid_level : ''),
+    ]),
+    'Сделок пока нет',
+  );
+}
+
+async function loadDecisions() {
+  const box = $('decisions');
+  const decisions = await optional('/ai/decisions?limit=10');
+  box.replaceChildren();
+  if (decisions === null) {
+    box.append(el('p', { class: 'muted' }, 'AI оркестратор не подключен'));
+    return;
+  }
+  if (decisions.length === 0) {
+    box.append(el('p', { class: 'muted' }, 'Решений пока нет'));
+    return;
+  }
+  for (const d of decisions) {
+    const verdict = d.approved ? 'одобрено' : 'отклонено' + (d.rejection_reason ? ': ' + d.rejection_reason : '');
+    box.append(
+      el('details', {},
+        el('summary', {}, `${time(d.timestamp)} · ${d.regime} · ${num(d.confidence * 100, 0)}% · ${d.mode} · `,
+          el('span', { class: d.approved ? 'pos' : 'neg' }, verdict)),
+        el('p', {}, d.rationale || '—'),
+      ),
+    );
+  }
+}
+
+async function loadViolations() {
+  const violations = await optional('/policy/violations?limit=10');
+  fillTable(
+    $('violations'),
+    (violations || []).map((v) => [time(v.timestamp), v.violation_type, `${v.limit_name} ${num(v.limit_value)}`, num(v.attempted_value), v.severity]),
+    violations === null ? 'Policy engine не подключен' : 'Нарушений нет',
+  );
+}
+
+// --- Поток событий ---
+
+function logEvent(event) {
+  const list = $('events');
+  const data = event.data || {};
+  const details = [data.symbol, data.side, data.price && num(data.price, 4), data.reason, data.to, data.regime]
+    .filter(Boolean)
+    .join(' ');
+  list.prepend(el('li', {}, `${time(event.time)} ${event.type} ${details}`));
+  while (list.children.length > 100) list.lastChild.remove();
+}
+
+// scheduleRefresh склеивает пачку событий в одно обновление
+function scheduleRefresh() {
+  clearTimeout(refreshTimer);
+  refreshTimer = setTimeout(refresh, 1000);
+}
+
+function connectEvents() {
+  if (eventSource) eventSource.close();
+  // EventSource не умеет заголовки, поэтому ключ идет в query
+  eventSource = new EventSource('/api/v1/events?api_key=' + encodeURIComponent(apiKey));
+  eventSource.onopen = () => $('live').classList.add('connected');
+  eventSource.onerror = () => $('live').classList.remove('connected');
+  const onEvent = (msg) => {
+    try {
+      logEvent(JSON.parse(msg.data));
+    } catch (e) {
+      return;
+    }
+    scheduleRefresh();
+  };
+  for (const type of EVENT_TYPES) eventSource.addEventListener(type, onEvent);
+}
+
+// --- Запуск ---
+
+function reportError(err) {
+  if (err.status !== 401) notice(err.message);
+}
+
+async function refresh() {
+  const results = await Promise.allSettled([
+    loadControls(),
+    loadPortfolio(),
+    loadPositions(),
+    loadGrid(),
+    loadTrades(),
+    loadDecisions(),
+    loadViolations(),
+  ]);
+  const failed = results.find((r) => r.status === 'rejected');
+  if (failed) reportError(failed.reason);
+}
+
+async function start() {
+  $('login').hidden = true;
+  $('app').hidden = false;
+  try {
+    await loadGridSymbols();
+  } catch (err) {
+    reportError(err);
+  }
+  await refresh();
+  if (apiKey) connectEvents();
+}
+
+if (apiKey) start();
+else showLogin();
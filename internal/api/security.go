@@ -0,0 +1,350 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/config"
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/rbac"
+)
+
+const (
+	defaultSignatureTTL = 5 * time.Minute
+	maxRequestBody      = 1 << 20
+	maxAuditParams      = 1000
+	maxNonceLength      = 64
+)
+
+// Signed request headers
+const (
+	headerTimestamp = "X-Timestamp"
+	headerNonce     = "X-Nonce"
+	headerSignature = "X-Signature"
+)
+
+// AuditStore persists mutating API calls (APIAuditRepository)
+type AuditStore interface {
+	Save(ctx context.Context, entry *domain.APIAuditEntry) error
+}
+
+// apiSecurity holds network restrictions, rate windows and seen nonces.
+// The zero value allows any IP, has no default rate limit and a 5m signature window
+type apiSecurity struct {
+	allowlist    []*net.IPNet
+	rateLimit    int
+	signatureTTL time.Duration
+	trustProxy   bool
+
+	mu      sync.Mutex
+	windows map[int64]*rateWindow
+	nonces  map[string]time.Time
+}
+
+// rateWindow fixed one-minute window of a key
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// SetSecurity applies the IP allowlist, default rate limit and signature window
+func (s *Server) SetSecurity(cfg config.AccessConfig) error {
+	allowlist, err := parseIPNets(cfg.APIIPAllowlist)
+	if err != nil {
+		return fmt.Errorf("invalid API_IP_ALLOWLIST: %w", err)
+	}
+
+	s.security.mu.Lock()
+	defer s.security.mu.Unlock()
+	s.security.allowlist = allowlist
+	s.security.rateLimit = cfg.APIRateLimit
+	s.security.signatureTTL = cfg.APISignatureTTL
+	s.security.trustProxy = cfg.APITrustProxy
+	return nil
+}
+
+// SetAuditLog enables persistent audit of mutating calls
+func (s *Server) SetAuditLog(store AuditStore) {
+	s.audit = store
+}
+
+// Signature computes the hex HMAC-SHA256 a client puts into X-Signature:
+// method \n request URI \n timestamp \n nonce \n sha256(body)
+func Signature(secret, method, requestURI, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	canonical := strings.Join([]string{
+		strings.ToUpper(method),
+		requestURI,
+		timestamp,
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySignature checks the HMAC, the timestamp window and nonce reuse
+func (sec *apiSecurity) verifySignature(key domain.APIKey, r *http.Request, body []byte, now time.Time) error {
+	if key.SigningSecret == "" {
+		return errors.New("API key has no signing secret")
+	}
+
+	timestamp := r.Header.Get(headerTimestamp)
+	nonce := r.Header.Get(headerNonce)
+	signature := r.Header.Get(headerSignature)
+	if timestamp == "" || nonce == "" || signature == "" {
+		return fmt.Errorf("signed request requires %s, %s and %s", headerTimestamp, headerNonce, headerSignature)
+	}
+	if len(nonce) > maxNonceLength {
+		return errors.New("nonce is too long")
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid request timestamp")
+	}
+	ttl := sec.window()
+	if skew := now.Sub(time.Unix(unix, 0)); skew > ttl || skew < -ttl {
+		return errors.New("request timestamp is outside the allowed window")
+	}
+
+	expected := Signature(key.SigningSecret, r.Method, r.URL.RequestURI(), timestamp, nonce, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return errors.New("invalid request signature")
+	}
+
+	// Nonces are stored only after a valid signature, so strangers cannot fill the cache
+	if !sec.useNonce(fmt.Sprintf("%d:%s", key.ID, nonce), now) {
+		return errors.New("nonce has already been used")
+	}
+	return nil
+}
+
+func (sec *apiSecurity) window() time.Duration {
+	sec.mu.Lock()
+	defer sec.mu.Unlock()
+	if sec.signatureTTL <= 0 {
+		return defaultSignatureTTL
+	}
+	return sec.signatureTTL
+}
+
+// useNonce records a nonce; false if it was seen within the window
+func (sec *apiSecurity) useNonce(id string, now time.Time) bool {
+	ttl := sec.window()
+
+	sec.mu.Lock()
+	defer sec.mu.Unlock()
+
+	if sec.nonces == nil {
+		sec.nonces = make(map[string]time.Time)
+	}
+	for seen, expires := range sec.nonces {
+		if now.After(expires) {
+			delete(sec.nonces, seen)
+		}
+	}
+	if _, ok := sec.nonces[id]; ok {
+		return false
+	}
+	// The timestamp may drift by ttl in both directions
+	sec.nonces[id] = now.Add(2 * ttl)
+	return true
+}
+
+// allow counts a request of the key; returns the wait time when over the limit
+func (sec *apiSecurity) allow(key domain.APIKey, now time.Time) (bool, time.Duration) {
+	sec.mu.Lock()
+	defer sec.mu.Unlock()
+
+	limit := key.RateLimit
+	if limit <= 0 {
+		limit = sec.rateLimit
+	}
+	if limit <= 0 {
+		return true, 0
+	}
+
+	if sec.windows == nil {
+		sec.windows = make(map[int64]*rateWindow)
+	}
+	window, ok := sec.windows[key.ID]
+	if !ok || now.Sub(window.start) >= time.Minute {
+		window = &rateWindow{start: now}
+		sec.windows[key.ID] = window
+	}
+	if window.count >= limit {
+		return false, window.start.Add(time.Minute).Sub(now)
+	}
+	window.count++
+	return true, 0
+}
+
+// allowedIP checks the global allowlist
+func (sec *apiSecurity) allowedIP(ip net.IP) bool {
+	sec.mu.Lock()
+	defer sec.mu.Unlock()
+	return len(sec.allowlist) == 0 || containsIP(sec.allowlist, ip)
+}
+
+// clientIP returns the remote address, or the last X-Forwarded-For hop behind a trusted proxy.
+// The last hop is appended by the proxy itself; earlier hops come from the client and can be spoofed
+func (sec *apiSecurity) clientIP(r *http.Request) net.IP {
+	sec.mu.Lock()
+	trustProxy := sec.trustProxy
+	sec.mu.Unlock()
+
+	if trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			hops := strings.Split(forwarded, ",")
+			if ip := net.ParseIP(strings.TrimSpace(hops[len(hops)-1])); ip != nil {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// keyAllowsIP checks the per-key allowlist
+func keyAllowsIP(key domain.APIKey, ip net.IP) (bool, error) {
+	if len(key.AllowedIPs) == 0 {
+		return true, nil
+	}
+	nets, err := parseIPNets(key.AllowedIPs)
+	if err != nil {
+		return false, err
+	}
+	return containsIP(nets, ip), nil
+}
+
+func parseIPNets(values []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		ipNet, err := rbac.ParseIPNet(value)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// readBody buffers the request body for signing and audit, then restores it
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// auditRecorder captures the status and the start of the response for the audit entry
+type auditRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (a *auditRecorder) WriteHeader(status int) {
+	if a.status == 0 {
+		a.status = status
+	}
+	a.ResponseWriter.WriteHeader(status)
+}
+
+func (a *auditRecorder) Write(p []byte) (int, error) {
+	if a.status == 0 {
+		a.status = http.StatusOK
+	}
+	if remaining := maxAuditParams - a.body.Len(); remaining > 0 {
+		a.body.Write(p[:min(len(p), remaining)])
+	}
+	return a.ResponseWriter.Write(p)
+}
+
+// result extracts the error of the JSON envelope
+func (a *auditRecorder) result() string {
+	if a.status < http.StatusBadRequest {
+		return "ok"
+	}
+	var resp Response
+	if err := json.Unmarshal(a.body.Bytes(), &resp); err == nil && resp.Error != "" {
+		return resp.Error
+	}
+	return http.StatusText(a.status)
+}
+
+// saveAudit writes the entry; failures are logged and never affect the response
+func (s *Server) saveAudit(entry *domain.APIAuditEntry, r *http.Request, body []byte, rec *auditRecorder, started time.Time) {
+	params := r.URL.RawQuery
+	if len(body) > 0 {
+		if params != "" {
+			params += " "
+		}
+		params += string(body)
+	}
+	if len(params) > maxAuditParams {
+		params = params[:maxAuditParams] + "..."
+	}
+
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	entry.CreatedAt = started
+	entry.Method = r.Method
+	entry.Path = r.URL.Path
+	entry.Params = params
+	entry.Status = rec.status
+	entry.Result = rec.result()
+	entry.DurationMs = time.Since(started).Milliseconds()
+
+	// Ответ уже отправлен: отключение клиента не должно терять запись аудита
+	if err := s.audit.Save(context.WithoutCancel(r.Context()), entry); err != nil {
+		s.logger.Error("Failed to save API audit entry: %v", err)
+	}
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/config"
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/rbac"
+)
+
+// keyAuth ключи с настройками безопасности
+type keyAuth map[string]domain.APIKey
+
+func (k keyAuth) Authenticate(ctx context.Context, secret string) (domain.User, domain.APIKey, error) {
+	key, ok := k[secret]
+	if !ok {
+		return domain.User{}, domain.APIKey{}, rbac.ErrInvalidAPIKey
+	}
+	return domain.User{ID: key.UserID, TelegramID: 100 + key.ID, Role: domain.RoleAdmin}, key, nil
+}
+
+func (k keyAuth) CheckOrderSize(role string, amountUSD float64) error { return nil }
+
+// memoryAudit журнал аудита в памяти
+type memoryAudit struct{ entries []domain.APIAuditEntry }
+
+func (m *memoryAudit) Save(ctx context.Context, entry *domain.APIAuditEntry) error {
+	m.entries = append(m.entries, *entry)
+	return nil
+}
+
+func okHandler(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) { s.sendSuccess(w, "ok") }
+}
+
+func signedRequest(secret, method, target, body, nonce string, ts time.Time) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	req.Header.Set("X-API-Key", "signed")
+	req.Header.Set(headerTimestamp, timestamp)
+	req.Header.Set(headerNonce, nonce)
+	req.Header.Set(headerSignature, Signature(secret, method, req.URL.RequestURI(), timestamp, nonce, []byte(body)))
+	return req
+}
+
+func TestProtect_Signature(t *testing.T) {
+	s := newTestServer()
+	s.SetAuthenticator(keyAuth{
+		"signed": {ID: 1, SigningSecret: "topsecret", RequireSignature: true},
+	})
+	handler := s.protect(rbac.PermTrade, okHandler(s))
+	now := time.Now()
+	body := `{"symbol":"BTCUSDT","amount_usd":10}`
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{"valid", signedRequest("topsecret", http.MethodPost, "/api/v1/trades/buy?x=1", body, "n1", now), http.StatusOK},
+		{"replay", signedRequest("topsecret", http.MethodPost, "/api/v1/trades/buy?x=1", body, "n1", now), http.StatusUnauthorized},
+		{"wrong secret", signedRequest("other", http.MethodPost, "/api/v1/trades/buy", body, "n2", now), http.StatusUnauthorized},
+		{"stale timestamp", signedRequest("topsecret", http.MethodPost, "/api/v1/trades/buy", body, "n3", now.Add(-10*time.Minute)), http.StatusUnauthorized},
+		{"new nonce", signedRequest("topsecret", http.MethodPost, "/api/v1/trades/buy", body, "n4", now), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler(rec, tt.req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.name, rec.Code, tt.want, rec.Body.String())
+		}
+	}
+
+	// Тело изменено после подписи
+	req := signedRequest("topsecret", http.MethodPost, "/api/v1/trades/buy", body, "n5", now)
+	req.Body = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"symbol":"BTCUSDT","amount_usd":1000}`)).Body
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("tampered body: status = %d", rec.Code)
+	}
+
+	// Подпись обязательна
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/trades/buy", strings.NewReader(body))
+	req.Header.Set("X-API-Key", "signed")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned: status = %d", rec.Code)
+	}
+}
+
+func TestProtect_IPAllowlist(t *testing.T) {
+	s := newTestServer()
+	if err := s.SetSecurity(config.AccessConfig{APIIPAllowlist: []string{"bad-ip"}}); err == nil {
+		t.Error("invalid allowlist accepted")
+	}
+	if err := s.SetSecurity(config.AccessConfig{APIIPAllowlist: []string{"10.0.0.0/8"}, APITrustProxy: true}); err != nil {
+		t.Fatalf("SetSecurity: %v", err)
+	}
+	s.SetAuthenticator(keyAuth{
+		"office": {ID: 1, AllowedIPs: []string{"10.1.0.0/16"}},
+		"any":    {ID: 2},
+	})
+	handler := s.protect(rbac.PermView, okHandler(s))
+
+	tests := []struct {
+		key  string
+		ip   string
+		want int
+	}{
+		{"any", "192.168.1.1", http.StatusForbidden},
+		{"any", "10.9.9.9", http.StatusOK},
+		{"office", "10.9.9.9", http.StatusForbidden},
+		{"office", "10.1.2.3", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/status", nil)
+		req.RemoteAddr = "127.0.0.1:5000"
+		// Левые хопы задает клиент: разрешенный IP в начале заголовка не должен помогать
+		req.Header.Set("X-Forwarded-For", "10.1.2.3, "+tt.ip)
+		req.Header.Set("X-API-Key", tt.key)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s from %s: status = %d, want %d", tt.key, tt.ip, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestProtect_RateLimit(t *testing.T) {
+	s := newTestServer()
+	s.SetSecurity(config.AccessConfig{APIRateLimit: 3})
+	s.SetAuthenticator(keyAuth{
+		"default": {ID: 1},
+		"custom":  {ID: 2, RateLimit: 1},
+	})
+	handler := s.protect(rbac.PermView, okHandler(s))
+
+	call := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/status", nil)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 3; i++ {
+		if rec := call("default"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d", i+1, rec.Code)
+		}
+	}
+	rec := call("default")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("over limit: status = %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	// Лимит ключа важнее общего, окна ключей независимы
+	if rec := call("custom"); rec.Code != http.StatusOK {
+		t.Errorf("custom first: status = %d", rec.Code)
+	}
+	if rec := call("custom"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("custom second: status = %d", rec.Code)
+	}
+
+	// Новое окно через минуту
+	if ok, _ := s.security.allow(domain.APIKey{ID: 1}, time.Now().Add(time.Minute)); !ok {
+		t.Error("window was not reset")
+	}
+}
+
+func TestProtect_Audit(t *testing.T) {
+	s := newTestServer()
+	audit := &memoryAudit{}
+	s.SetAuditLog(audit)
+	s.SetAuthenticator(fakeAuth{
+		"viewer": {ID: 7, TelegramID: 1, Role: domain.RoleViewer},
+		"admin":  {ID: 8, TelegramID: 3, Role: domain.RoleAdmin},
+	})
+
+	handler := s.protect(rbac.PermTrade, okHandler(s))
+	call := func(method, key, body string) {
+		req := httptest.NewRequest(method, "/api/v1/trades/buy?dry=1", strings.NewReader(body))
+		req.Header.Set("X-API-Key", key)
+		handler(httptest.NewRecorder(), req)
+	}
+
+	call(http.MethodPost, "admin", `{"amount_usd":10}`)
+	call(http.MethodPost, "viewer", `{"amount_usd":20}`)
+	call(http.MethodPost, "nope", "")
+	call(http.MethodGet, "admin", "")
+
+	if len(audit.entries) != 3 {
+		t.Fatalf("entries = %d, want 3 (GET is not audited)", len(audit.entries))
+	}
+
+	ok := audit.entries[0]
+	if ok.TelegramID != 3 || ok.Role != domain.RoleAdmin || ok.Status != http.StatusOK || ok.Result != "ok" ||
+		ok.Method != http.MethodPost || ok.Path != "/api/v1/trades/buy" || ok.Params != `dry=1 {"amount_usd":10}` {
+		t.Errorf("ok entry = %+v", ok)
+	}
+	if denied := audit.entries[1]; denied.Status != http.StatusForbidden || denied.TelegramID != 1 || !strings.Contains(denied.Result, "permission") {
+		t.Errorf("denied entry = %+v", denied)
+	}
+	if unknown := audit.entries[2]; unknown.Status != http.StatusUnauthorized || unknown.KeyID != 0 || unknown.Result != "Invalid API key" {
+		t.Errorf("unknown key entry = %+v", unknown)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	s := newTestServer()
+	if rec := serve(s, http.MethodGet, "/metrics", "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("not configured: status = %d", rec.Code)
+	}
+
+	s.SetMetrics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("up 1\n"))
+	}), "scrape-token")
+
+	tests := []struct {
+		auth string
+		want int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"Bearer scrape-token", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%q: status = %d, want %d", tt.auth, rec.Code, tt.want)
+		}
+	}
+
+	// Без токена метрики открыты, как /health
+	s.SetMetrics(s.metrics, "")
+	if rec := serve(s, http.MethodGet, "/metrics", "", ""); rec.Code != http.StatusOK || rec.Body.String() != "up 1\n" {
+		t.Errorf("open metrics: status = %d body %q", rec.Code, rec.Body.String())
+	}
+}
@@ -1,13 +1,16 @@
 package api
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kirillm/dca-bot/internal/exchange"
+	"github.com/kirillm/dca-bot/internal/rbac"
 	"github.com/kirillm/dca-bot/internal/storage"
 	"github.com/kirillm/dca-bot/internal/strategy"
 	"github.com/kirillm/dca-bot/pkg/utils"
@@ -16,12 +19,29 @@ import (
 type Server struct {
 	logger           *utils.Logger
 	exchange         *exchange.BybitClient
-	storage          *storage.PostgresStorage
+	storage          *storage.Storage
 	dcaStrategy      *strategy.DCAStrategy
 	autoSell         *strategy.AutoSellStrategy
 	gridStrategy     *strategy.GridStrategy
 	portfolioManager *strategy.PortfolioManager
 	port             int
+	newsWebhook      http.Handler
+	metrics          http.Handler // nil - /metrics is not served
+	metricsToken     string
+	auth             Authenticator // nil - API without keys
+	security         apiSecurity
+	audit            AuditStore // nil - mutating calls are not audited
+	trader           *strategy.ManualTrader
+
+	// Optional /api/v1 components (nil - endpoint answers 503)
+	riskManager  *strategy.RiskManager
+	decisions    DecisionStore
+	actions      ActionStore
+	violations   ViolationStore
+	breakers     BreakerStore
+	orchestrator Orchestrator
+	killSwitch   KillSwitch
+	events       EventBus
 }
 
 type Response struct {
@@ -45,7 +65,7 @@ type GridInitRequest struct {
 func NewServer(
 	logger *utils.Logger,
 	exchange *exchange.BybitClient,
-	storage *storage.PostgresStorage,
+	storage *storage.Storage,
 	dcaStrategy *strategy.DCAStrategy,
 	autoSell *strategy.AutoSellStrategy,
 	gridStrategy *strategy.GridStrategy,
@@ -61,26 +81,57 @@ func NewServer(
 		gridStrategy:     gridStrategy,
 		portfolioManager: portfolioManager,
 		port:             port,
+		trader:           strategy.NewManualTrader(storage, exchange),
 	}
 }
 
-func (s *Server) Start() error {
+// SetNewsWebhook registers the news ingestion webhook handler
+func (s *Server) SetNewsWebhook(handler http.Handler) {
+	s.newsWebhook = handler
+}
+
+// SetMetrics exposes Prometheus metrics at /metrics. With a non-empty token
+// the scraper must send it as a bearer token
+func (s *Server) SetMetrics(handler http.Handler, token string) {
+	s.metrics = handler
+	s.metricsToken = token
+}
+
+// Handler builds the router with legacy endpoints and /api/v1
+func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 
 	// Register routes
 	mux.HandleFunc("/health", s.handleHealth)
-	mux.HandleFunc("/status", s.handleStatus)
-	mux.HandleFunc("/balance", s.handleBalance)
-	mux.HandleFunc("/buy", s.handleBuy)
-	mux.HandleFunc("/grid/init", s.handleGridInit)
-	mux.HandleFunc("/portfolio", s.handlePortfolio)
+	mux.HandleFunc("/status", s.protect(rbac.PermView, s.handleStatus))
+	mux.HandleFunc("/balance", s.protect(rbac.PermView, s.handleBalance))
+	mux.HandleFunc("/buy", s.protect(rbac.PermTrade, s.handleBuy))
+	mux.HandleFunc("/grid/init", s.protect(rbac.PermGrid, s.handleGridInit))
+	mux.HandleFunc("/portfolio", s.protect(rbac.PermView, s.handlePortfolio))
+	s.registerV1(mux)
+	s.registerDashboard(mux)
+
+	if s.newsWebhook != nil {
+		mux.Handle("/news/webhook", s.newsWebhook)
+	}
+	if s.metrics != nil {
+		mux.HandleFunc("GET /metrics", s.handleMetrics)
+	}
+
+	return mux
+}
+
+func (s *Server) Start() error {
+	if s.auth == nil {
+		s.logger.Warn("HTTP API runs without API keys: mutating endpoints are disabled until SetAuthenticator is called")
+	}
 
 	addr := fmt.Sprintf(":%d", s.port)
 	s.logger.Info("Starting HTTP server on %s", addr)
 
 	server := &http.Server{
 		Addr:         addr,
-		Handler:      mux,
+		Handler:      s.Handler(),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -89,6 +140,19 @@ func (s *Server) Start() error {
 	return server.ListenAndServe()
 }
 
+// handleMetrics - Prometheus scrape endpoint, outside API keys and roles
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if s.metricsToken != "" {
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.metricsToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+	s.metrics.ServeHTTP(w, r)
+}
+
 // handleHealth - health check endpoint
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -137,6 +201,7 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 
 // handleBalance - get account balance
 func (s *Server) handleBalance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	if r.Method != http.MethodGet {
 		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -150,7 +215,7 @@ func (s *Server) handleBalance(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get all balances from DB
-	balances, err := s.storage.GetAllBalances()
+	balances, err := s.storage.Balances().GetAll(ctx)
 	if err != nil {
 		s.sendError(w, fmt.Sprintf("Failed to get balances: %v", err), http.StatusInternalServerError)
 		return
@@ -188,16 +253,25 @@ func (s *Server) handleBuy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := s.checkOrderSize(r, req.QuoteAmount); err != nil {
+		s.sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
 	// Execute buy
-	if err := s.dcaStrategy.ExecuteManualBuy(); err != nil {
+	trade, err := s.trader.Buy(r.Context(), req.Symbol, req.QuoteAmount)
+	if err != nil {
 		s.sendError(w, fmt.Sprintf("Buy failed: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	result := map[string]interface{}{
 		"message":   "Buy executed successfully",
-		"symbol":    req.Symbol,
-		"amount":    req.QuoteAmount,
+		"symbol":    trade.Symbol,
+		"amount":    trade.Amount,
+		"quantity":  trade.Quantity,
+		"price":     trade.Price,
+		"order_id":  trade.OrderID,
 		"timestamp": time.Now().Unix(),
 	}
 
@@ -237,33 +311,20 @@ func (s *Server) handleGridInit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Initialize grid
-	if s.gridStrategy != nil {
-		// Create or get asset
-		asset, err := s.storage.GetAsset(req.Symbol)
-		if err != nil || asset == nil {
-			asset = &storage.Asset{
-				Symbol:             req.Symbol,
-				Enabled:            true,
-				StrategyType:       "GRID",
-				GridLevels:         req.Levels,
-				GridSpacingPercent: req.SpacingPercent,
-				GridOrderSize:      req.OrderSizeQuote,
-			}
-			if err := s.storage.CreateOrUpdateAsset(asset); err != nil {
-				s.sendError(w, fmt.Sprintf("Failed to create asset: %v", err), http.StatusInternalServerError)
-				return
-			}
-		}
+	if err := s.checkOrderSize(r, req.OrderSizeQuote); err != nil {
+		s.sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
 
-		if err := s.gridStrategy.InitializeGrid(asset); err != nil {
-			s.sendError(w, fmt.Sprintf("Grid initialization failed: %v", err), http.StatusInternalServerError)
-			return
-		}
-	} else {
+	// Initialize grid
+	if s.gridStrategy == nil {
 		s.sendError(w, "Grid strategy not available", http.StatusServiceUnavailable)
 		return
 	}
+	if _, err := s.startGrid(req.Symbol, req.Levels, req.SpacingPercent, req.OrderSizeQuote); err != nil {
+		s.sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	result := map[string]interface{}{
 		"message":          "Grid initialized successfully",
@@ -0,0 +1,282 @@
+package api
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/rbac"
+	"github.com/kirillm/dca-bot/internal/strategy"
+)
+
+const (
+	v1Prefix         = "/api/v1"
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// DecisionStore reads AI decisions (AIDecisionRepository)
+type DecisionStore interface {
+	GetRecent(ctx context.Context, limit int) ([]domain.AIDecision, error)
+	GetByID(ctx context.Context, id int64) (*domain.AIDecision, error)
+	GetByMode(ctx context.Context, mode string, limit int) ([]domain.AIDecision, error)
+}
+
+// ActionStore reads actions planned by AI (AIActionRepository)
+type ActionStore interface {
+	GetRecent(ctx context.Context, limit int) ([]domain.AIAction, error)
+	GetByStatus(ctx context.Context, status string, limit int) ([]domain.AIAction, error)
+	GetByDecisionID(ctx context.Context, decisionID int64) ([]domain.AIAction, error)
+}
+
+// ViolationStore reads policy violations (PolicyViolationRepository)
+type ViolationStore interface {
+	GetRecent(ctx context.Context, limit int) ([]domain.PolicyViolation, error)
+	GetBySeverity(ctx context.Context, severity string, limit int) ([]domain.PolicyViolation, error)
+}
+
+// BreakerStore reads circuit breaker events (CircuitBreakerRepository)
+type BreakerStore interface {
+	GetActive(ctx context.Context) ([]domain.CircuitBreakerEvent, error)
+	GetRecent(ctx context.Context, limit int) ([]domain.CircuitBreakerEvent, error)
+}
+
+// Orchestrator switches the autonomy mode (orchestrator.Orchestrator)
+type Orchestrator interface {
+	CurrentMode() string
+	RequestMode(mode string, adminID int64) (bool, error)
+	IsRunning() bool
+}
+
+// KillSwitch stops AI execution (execution.KillSwitch)
+type KillSwitch interface {
+	Activate(reason string)
+	Deactivate()
+	GetStatus() (bool, string, time.Time)
+}
+
+// SetRiskManager enables /api/v1/risk/status
+func (s *Server) SetRiskManager(riskManager *strategy.RiskManager) {
+	s.riskManager = riskManager
+}
+
+// SetDecisionStore enables /api/v1/ai/decisions
+func (s *Server) SetDecisionStore(decisions DecisionStore) {
+	s.decisions = decisions
+}
+
+// SetActionStore enables /api/v1/ai/actions and actions of a decision
+func (s *Server) SetActionStore(actions ActionStore) {
+	s.actions = actions
+}
+
+// SetViolationStore enables /api/v1/policy/violations
+func (s *Server) SetViolationStore(violations ViolationStore) {
+	s.violations = violations
+}
+
+// SetBreakerStore enables /api/v1/circuit-breakers
+func (s *Server) SetBreakerStore(breakers BreakerStore) {
+	s.breakers = breakers
+}
+
+// SetOrchestrator enables /api/v1/mode
+func (s *Server) SetOrchestrator(orchestrator Orchestrator) {
+	s.orchestrator = orchestrator
+}
+
+// SetKillSwitch adds the AI execution kill switch to /api/v1/kill-switch
+func (s *Server) SetKillSwitch(killSwitch KillSwitch) {
+	s.killSwitch = killSwitch
+}
+
+// route is one /api/v1 endpoint; an empty perm means public
+type route struct {
+	method  string
+	path    string
+	perm    rbac.Permission
+	handler http.HandlerFunc
+}
+
+// v1Routes is the whole /api/v1 surface, openapi.yaml documents the same list
+func (s *Server) v1Routes() []route {
+	return []route{
+		{http.MethodGet, "/openapi.yaml", "", s.handleOpenAPI},
+
+		{http.MethodGet, "/assets", rbac.PermView, s.handleListAssets},
+		{http.MethodPost, "/assets", rbac.PermConfig, s.handleCreateAsset},
+		{http.MethodGet, "/assets/{symbol}", rbac.PermView, s.handleGetAsset},
+		{http.MethodPatch, "/assets/{symbol}", rbac.PermConfig, s.handleUpdateAsset},
+		{http.MethodDelete, "/assets/{symbol}", rbac.PermConfig, s.handleDeleteAsset},
+		{http.MethodGet, "/assets/{symbol}/autosell", rbac.PermView, s.handleGetAutoSell},
+		{http.MethodPut, "/assets/{symbol}/autosell", rbac.PermTrade, s.handleUpdateAutoSell},
+
+		{http.MethodGet, "/trades", rbac.PermView, s.handleListTrades},
+		{http.MethodPost, "/trades/buy", rbac.PermTrade, s.handleV1Buy},
+		{http.MethodPost, "/trades/sell", rbac.PermTrade, s.handleV1Sell},
+
+		{http.MethodGet, "/grid/{symbol}", rbac.PermView, s.handleGridStatus},
+		{http.MethodPost, "/grid/{symbol}/start", rbac.PermGrid, s.handleGridStart},
+		{http.MethodPost, "/grid/{symbol}/stop", rbac.PermRisk, s.handleGridStop},
+
+		{http.MethodGet, "/balances", rbac.PermView, s.handleListBalances},
+		{http.MethodGet, "/portfolio", rbac.PermView, s.handlePortfolio},
+		{http.MethodGet, "/pnl", rbac.PermView, s.handlePnLHistory},
+		{http.MethodGet, "/pnl/portfolio", rbac.PermView, s.handlePortfolioPnL},
+
+		{http.MethodGet, "/risk/limits", rbac.PermView, s.handleGetRiskLimits},
+		{http.MethodPut, "/risk/limits", rbac.PermRisk, s.handleUpdateRiskLimits},
+		{http.MethodGet, "/risk/status", rbac.PermView, s.handleRiskStatus},
+		{http.MethodGet, "/kill-switch", rbac.PermView, s.handleGetKillSwitch},
+		{http.MethodPut, "/kill-switch", rbac.PermRisk, s.handleSetKillSwitch},
+		{http.MethodGet, "/circuit-breakers", rbac.PermView, s.handleListBreakers},
+		{http.MethodGet, "/policy/violations", rbac.PermView, s.handleListViolations},
+
+		{http.MethodGet, "/ai/decisions", rbac.PermView, s.handleListDecisions},
+		{http.MethodGet, "/ai/decisions/{id}", rbac.PermView, s.handleGetDecision},
+		{http.MethodGet, "/ai/actions", rbac.PermView, s.handleListActions},
+
+		{http.MethodGet, "/events", rbac.PermView, s.handleEventStream},
+		{http.MethodGet, "/events/ws", rbac.PermView, s.handleEventSocket},
+		// Shadow needs risk, pilot/full need autonomy: checked in the handler by the body
+		{http.MethodGet, "/mode", rbac.PermView, s.handleGetMode},
+		{http.MethodPut, "/mode", rbac.PermView, s.handleSetMode},
+	}
+}
+
+// registerV1 mounts /api/v1 routes with method patterns and role checks
+func (s *Server) registerV1(mux *http.ServeMux) {
+	for _, rt := range s.v1Routes() {
+		handler := rt.handler
+		if rt.perm != "" {
+			handler = s.protect(rt.perm, handler)
+		}
+		mux.HandleFunc(rt.method+" "+v1Prefix+rt.path, handler)
+	}
+}
+
+// handleOpenAPI serves the embedded OpenAPI 3 spec
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(openAPISpec)
+}
+
+// decodeJSON reads the request body into dst and rejects unknown fields
+func decodeJSON(r *http.Request, dst interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return fmt.Errorf("%w: invalid request body: %v", domain.ErrInvalidInput, err)
+	}
+	return nil
+}
+
+// pathSymbol reads {symbol} from the path in upper case
+func pathSymbol(r *http.Request) string {
+	return strings.ToUpper(r.PathValue("symbol"))
+}
+
+// pageLimit reads ?limit= within [1, maxPageLimit]
+func pageLimit(r *http.Request) (int, error) {
+	limit := getQueryParamInt(r, "limit", defaultPageLimit)
+	if limit <= 0 || limit > maxPageLimit {
+		return 0, fmt.Errorf("%w: limit must be between 1 and %d", domain.ErrInvalidInput, maxPageLimit)
+	}
+	return limit, nil
+}
+
+// parseTradeFilter reads trade filters and pagination from the query string
+func parseTradeFilter(r *http.Request) (domain.TradeFilter, error) {
+	query := r.URL.Query()
+	filter := domain.TradeFilter{
+		Symbol:   strings.ToUpper(query.Get("symbol")),
+		Side:     strings.ToUpper(query.Get("side")),
+		Strategy: strings.ToUpper(query.Get("strategy")),
+	}
+
+	if filter.Side != "" && filter.Side != "BUY" && filter.Side != "SELL" {
+		return filter, fmt.Errorf("%w: side must be BUY or SELL", domain.ErrInvalidInput)
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(query.Get("from")); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTimeParam(query.Get("to")); err != nil {
+		return filter, err
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, fmt.Errorf("%w: from must be before to", domain.ErrInvalidInput)
+	}
+
+	if filter.Limit, err = pageLimit(r); err != nil {
+		return filter, err
+	}
+	filter.Offset, err = strconv.Atoi(getQueryParam(r, "offset", "0"))
+	if err != nil || filter.Offset < 0 {
+		return filter, fmt.Errorf("%w: offset must be a non-negative integer", domain.ErrInvalidInput)
+	}
+
+	return filter, nil
+}
+
+// parseTimeParam accepts RFC 3339 timestamps or YYYY-MM-DD dates (UTC)
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%w: invalid time %q, use RFC 3339 or YYYY-MM-DD", domain.ErrInvalidInput, value)
+}
+
+// sendV1Error maps domain errors to HTTP status codes
+func (s *Server) sendV1Error(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		status = http.StatusBadRequest
+	case errors.Is(err, domain.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, rbac.ErrPermissionDenied):
+		status = http.StatusForbidden
+	case errors.Is(err, domain.ErrEmergencyStop),
+		errors.Is(err, domain.ErrRiskLimitExceeded),
+		errors.Is(err, domain.ErrInsufficientBalance):
+		status = http.StatusConflict
+	}
+
+	if status == http.StatusInternalServerError {
+		s.logger.Error("API request failed: %v", err)
+	}
+	s.sendError(w, err.Error(), status)
+}
+
+// sendCreated writes 201 with the created resource
+func (s *Server) sendCreated(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Data:    data,
+	})
+}
+
+// notConfigured answers 503 for optional components that were not wired
+func (s *Server) notConfigured(w http.ResponseWriter, component string) {
+	s.sendError(w, component+" not available", http.StatusServiceUnavailable)
+}
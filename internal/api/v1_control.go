@@ -0,0 +1,337 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/rbac"
+)
+
+// handleGetRiskLimits - GET /api/v1/risk/limits
+func (s *Server) handleGetRiskLimits(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	limits, err := s.storage.Risk().GetLimits(ctx)
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get risk limits: %w", err))
+		return
+	}
+
+	s.sendSuccess(w, toRiskLimitsResponse(*limits))
+}
+
+// handleUpdateRiskLimits - PUT /api/v1/risk/limits
+func (s *Server) handleUpdateRiskLimits(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req RiskLimitsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	limits, err := s.storage.Risk().GetLimits(ctx)
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get risk limits: %w", err))
+		return
+	}
+
+	setFloat(&limits.MaxDailyLoss, req.MaxDailyLoss)
+	setFloat(&limits.MaxTotalExposure, req.MaxTotalExposure)
+	setFloat(&limits.MaxPositionSizeUSD, req.MaxPositionSizeUSD)
+	setFloat(&limits.MaxOrderSizeUSD, req.MaxOrderSizeUSD)
+
+	if limits.MaxDailyLoss <= 0 || limits.MaxTotalExposure <= 0 || limits.MaxPositionSizeUSD <= 0 || limits.MaxOrderSizeUSD <= 0 {
+		s.sendError(w, "Risk limits must be positive", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.storage.Risk().UpdateLimits(ctx, limits); err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to update risk limits: %w", err))
+		return
+	}
+
+	s.sendSuccess(w, toRiskLimitsResponse(*limits))
+}
+
+// handleRiskStatus - GET /api/v1/risk/status
+func (s *Server) handleRiskStatus(w http.ResponseWriter, r *http.Request) {
+	if s.riskManager == nil {
+		s.notConfigured(w, "Risk manager")
+		return
+	}
+
+	status, err := s.riskManager.GetRiskStatus()
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get risk status: %w", err))
+		return
+	}
+
+	s.sendSuccess(w, status)
+}
+
+// handleGetKillSwitch - GET /api/v1/kill-switch
+func (s *Server) handleGetKillSwitch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	limits, err := s.storage.Risk().GetLimits(ctx)
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get risk limits: %w", err))
+		return
+	}
+
+	s.sendSuccess(w, s.killSwitchStatus(limits.EnableEmergencyStop))
+}
+
+// handleSetKillSwitch - PUT /api/v1/kill-switch
+// Toggles the emergency stop and, when wired, the AI execution kill switch
+func (s *Server) handleSetKillSwitch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req KillSwitchRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	limits, err := s.storage.Risk().GetLimits(ctx)
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get risk limits: %w", err))
+		return
+	}
+
+	limits.EnableEmergencyStop = req.Active
+	if err := s.storage.Risk().UpdateLimits(ctx, limits); err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to update emergency stop: %w", err))
+		return
+	}
+
+	if s.killSwitch != nil {
+		if req.Active {
+			reason := req.Reason
+			if reason == "" {
+				reason = "activated via API"
+			}
+			s.killSwitch.Activate(reason)
+		} else {
+			s.killSwitch.Deactivate()
+		}
+	}
+
+	if req.Active {
+		s.logger.Warn("Kill switch activated via API: %s", req.Reason)
+	} else {
+		s.logger.Info("Kill switch deactivated via API")
+	}
+
+	s.sendSuccess(w, s.killSwitchStatus(req.Active))
+}
+
+func (s *Server) killSwitchStatus(emergencyStop bool) KillSwitchResponse {
+	status := KillSwitchResponse{EmergencyStop: emergencyStop}
+	if s.killSwitch != nil {
+		active, reason, activatedAt := s.killSwitch.GetStatus()
+		status.Execution = &ExecutionKillSwitch{Active: active, Reason: reason}
+		if active {
+			status.Execution.ActivatedAt = optionalTime(activatedAt)
+		}
+	}
+	return status
+}
+
+// handleListBreakers - GET /api/v1/circuit-breakers[?active=true&limit=]
+func (s *Server) handleListBreakers(w http.ResponseWriter, r *http.Request) {
+	if s.breakers == nil {
+		s.notConfigured(w, "Circuit breaker history")
+		return
+	}
+
+	limit, err := pageLimit(r)
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	var events []domain.CircuitBreakerEvent
+	if r.URL.Query().Get("active") == "true" {
+		events, err = s.breakers.GetActive(r.Context())
+	} else {
+		events, err = s.breakers.GetRecent(r.Context(), limit)
+	}
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get circuit breaker events: %w", err))
+		return
+	}
+
+	s.sendSuccess(w, convertAll(events, toBreakerEventResponse))
+}
+
+// handleListViolations - GET /api/v1/policy/violations[?severity=&limit=]
+func (s *Server) handleListViolations(w http.ResponseWriter, r *http.Request) {
+	if s.violations == nil {
+		s.notConfigured(w, "Policy violation history")
+		return
+	}
+
+	limit, err := pageLimit(r)
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	var violations []domain.PolicyViolation
+	if severity := strings.ToLower(r.URL.Query().Get("severity")); severity != "" {
+		violations, err = s.violations.GetBySeverity(r.Context(), severity, limit)
+	} else {
+		violations, err = s.violations.GetRecent(r.Context(), limit)
+	}
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get policy violations: %w", err))
+		return
+	}
+
+	s.sendSuccess(w, convertAll(violations, toViolationResponse))
+}
+
+// handleListDecisions - GET /api/v1/ai/decisions[?mode=&limit=]
+func (s *Server) handleListDecisions(w http.ResponseWriter, r *http.Request) {
+	if s.decisions == nil {
+		s.notConfigured(w, "Decision history")
+		return
+	}
+
+	limit, err := pageLimit(r)
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	var decisions []domain.AIDecision
+	if mode := strings.ToLower(r.URL.Query().Get("mode")); mode != "" {
+		decisions, err = s.decisions.GetByMode(r.Context(), mode, limit)
+	} else {
+		decisions, err = s.decisions.GetRecent(r.Context(), limit)
+	}
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get AI decisions: %w", err))
+		return
+	}
+
+	s.sendSuccess(w, convertAll(decisions, toDecisionResponse))
+}
+
+// handleGetDecision - GET /api/v1/ai/decisions/{id}
+func (s *Server) handleGetDecision(w http.ResponseWriter, r *http.Request) {
+	if s.decisions == nil {
+		s.notConfigured(w, "Decision history")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		s.sendError(w, "Invalid decision id", http.StatusBadRequest)
+		return
+	}
+
+	decision, err := s.decisions.GetByID(r.Context(), id)
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get AI decision: %w", err))
+		return
+	}
+	if decision == nil {
+		s.sendV1Error(w, fmt.Errorf("%w: decision %d", domain.ErrNotFound, id))
+		return
+	}
+
+	detail := DecisionDetailResponse{
+		DecisionResponse: toDecisionResponse(*decision),
+		Actions:          []ActionResponse{},
+	}
+	if s.actions != nil {
+		actions, err := s.actions.GetByDecisionID(r.Context(), id)
+		if err != nil {
+			s.sendV1Error(w, fmt.Errorf("failed to get AI actions: %w", err))
+			return
+		}
+		detail.Actions = convertAll(actions, toActionResponse)
+	}
+
+	s.sendSuccess(w, detail)
+}
+
+// handleListActions - GET /api/v1/ai/actions[?status=&limit=]
+func (s *Server) handleListActions(w http.ResponseWriter, r *http.Request) {
+	if s.actions == nil {
+		s.notConfigured(w, "AI action history")
+		return
+	}
+
+	limit, err := pageLimit(r)
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	var actions []domain.AIAction
+	if status := strings.ToLower(r.URL.Query().Get("status")); status != "" {
+		actions, err = s.actions.GetByStatus(r.Context(), status, limit)
+	} else {
+		actions, err = s.actions.GetRecent(r.Context(), limit)
+	}
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get AI actions: %w", err))
+		return
+	}
+
+	s.sendSuccess(w, convertAll(actions, toActionResponse))
+}
+
+// handleGetMode - GET /api/v1/mode
+func (s *Server) handleGetMode(w http.ResponseWriter, r *http.Request) {
+	if s.orchestrator == nil {
+		s.notConfigured(w, "Orchestrator")
+		return
+	}
+
+	s.sendSuccess(w, ModeResponse{
+		Mode:    s.orchestrator.CurrentMode(),
+		Running: s.orchestrator.IsRunning(),
+	})
+}
+
+// handleSetMode - PUT /api/v1/mode
+// Same rules as Telegram /mode: shadow needs risk, pilot and full need autonomy
+func (s *Server) handleSetMode(w http.ResponseWriter, r *http.Request) {
+	if s.orchestrator == nil {
+		s.notConfigured(w, "Orchestrator")
+		return
+	}
+
+	var req ModeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	mode := strings.ToLower(req.Mode)
+	perm := rbac.PermAutonomy
+	if mode == "shadow" {
+		perm = rbac.PermRisk
+	}
+	if err := s.authorize(r, perm); err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	user, _ := requestUser(r)
+	applied, err := s.orchestrator.RequestMode(mode, user.TelegramID)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	s.sendSuccess(w, ModeResponse{
+		Mode:    s.orchestrator.CurrentMode(),
+		Running: s.orchestrator.IsRunning(),
+		Applied: &applied,
+	})
+}
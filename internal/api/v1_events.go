@@ -0,0 +1,200 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/events"
+)
+
+// streamHeartbeat keeps proxies from closing idle streams
+const streamHeartbeat = 15 * time.Second
+
+// EventBus publishes and streams bot events (events.Bus)
+type EventBus interface {
+	Publish(eventType string, data interface{})
+	Subscribe(topics []string, lastID uint64) (*events.Subscription, []events.Event)
+}
+
+// SetEventBus enables /api/v1/events and publishes manual API trades
+func (s *Server) SetEventBus(bus EventBus) {
+	s.events = bus
+	if s.trader != nil {
+		s.trader.SetEventPublisher(bus)
+	}
+}
+
+// parseStreamRequest reads ?topics=trade,grid and the replay position
+// from Last-Event-ID (EventSource reconnects) or ?last_event_id=
+func parseStreamRequest(r *http.Request) ([]string, uint64, error) {
+	var topics []string
+	if value := r.URL.Query().Get("topics"); value != "" {
+		for _, topic := range strings.Split(value, ",") {
+			topic = strings.ToLower(strings.TrimSpace(topic))
+			if !events.IsKnownTopic(topic) {
+				return nil, 0, fmt.Errorf("%w: unknown topic %q (known: %s)",
+					domain.ErrInvalidInput, topic, strings.Join(events.Topics, ", "))
+			}
+			topics = append(topics, topic)
+		}
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	if lastID == "" {
+		return topics, 0, nil
+	}
+	id, err := strconv.ParseUint(lastID, 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: invalid last event id", domain.ErrInvalidInput)
+	}
+	return topics, id, nil
+}
+
+// handleEventStream - GET /api/v1/events (text/event-stream)
+func (s *Server) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	if s.events == nil {
+		s.notConfigured(w, "Event bus")
+		return
+	}
+
+	topics, lastID, err := parseStreamRequest(r)
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// The stream outlives the server WriteTimeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		s.logger.Warn("Event stream: cannot disable write deadline: %v", err)
+	}
+
+	sub, replay := s.events.Subscribe(topics, lastID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+
+	for _, event := range replay {
+		if err := writeSSE(w, event); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Lagged: the client reconnects with Last-Event-ID and replays the gap
+				return
+			}
+			if err := writeSSE(w, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// handleEventSocket - GET /api/v1/events/ws (WebSocket, one JSON event per text frame)
+func (s *Server) handleEventSocket(w http.ResponseWriter, r *http.Request) {
+	if s.events == nil {
+		s.notConfigured(w, "Event bus")
+		return
+	}
+	if !isWebSocketUpgrade(r) {
+		s.sendError(w, "WebSocket upgrade required", http.StatusUpgradeRequired)
+		return
+	}
+
+	topics, lastID, err := parseStreamRequest(r)
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sub, replay := s.events.Subscribe(topics, lastID)
+	defer sub.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		conn.readLoop()
+		close(closed)
+	}()
+
+	send := func(event events.Event) bool {
+		data, err := json.Marshal(event)
+		return err == nil && conn.WriteText(data) == nil
+	}
+
+	for _, event := range replay {
+		if !send(event) {
+			conn.Close(wsCloseGoingAway, "")
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				conn.Close(wsCloseTryAgain, "lagged, reconnect with last_event_id")
+				return
+			}
+			if !send(event) {
+				conn.Close(wsCloseGoingAway, "")
+				return
+			}
+		case <-heartbeat.C:
+			if conn.Ping() != nil {
+				conn.Close(wsCloseGoingAway, "")
+				return
+			}
+		}
+	}
+}
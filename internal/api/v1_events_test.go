@@ -0,0 +1,178 @@
+package api
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/events"
+)
+
+// waitSubscribers ждет подключения клиента к шине
+func waitSubscribers(t *testing.T, bus *events.Bus, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for bus.Subscribers() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("subscribers = %d, want %d", bus.Subscribers(), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// readSSE читает n событий потока
+func readSSE(t *testing.T, reader *bufio.Reader, n int) []events.Event {
+	t.Helper()
+	var result []events.Event
+	for len(result) < n {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			var event events.Event
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				t.Fatalf("decode %q: %v", data, err)
+			}
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+func TestV1_EventStream(t *testing.T) {
+	s := newTestServer()
+	bus := events.NewBus(10)
+	s.SetEventBus(bus)
+	s.SetAuthenticator(fakeAuth{"viewer": {TelegramID: 1, Role: domain.RoleViewer}})
+
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	bus.Publish(events.TradeFilled, events.TradeData{Symbol: "BTCUSDT"})
+	bus.Publish(events.ModeChanged, events.ModeData{From: "pilot", To: "shadow"})
+
+	// Ключ в query - EventSource не умеет заголовки; replay после события 1
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/events?topics=trade,mode&api_key=viewer", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	if got := readSSE(t, reader, 1); got[0].ID != 2 || got[0].Type != events.ModeChanged {
+		t.Errorf("replay = %+v", got)
+	}
+
+	waitSubscribers(t, bus, 1)
+	bus.Publish(events.GridLevelFilled, events.GridFillData{Symbol: "ETHUSDT"}) // не подписан
+	bus.Publish(events.TradeFilled, events.TradeData{Symbol: "ETHUSDT", Side: "SELL"})
+	got := readSSE(t, reader, 1)
+	if got[0].ID != 4 || got[0].Topic != "trade" {
+		t.Errorf("live = %+v", got)
+	}
+	if data, _ := got[0].Data.(map[string]interface{}); data["side"] != "SELL" {
+		t.Errorf("data = %+v", got[0].Data)
+	}
+
+	for query, want := range map[string]int{
+		"topics=trades&api_key=viewer":     http.StatusBadRequest,
+		"last_event_id=abc&api_key=viewer": http.StatusBadRequest,
+		"topics=trade":                     http.StatusUnauthorized,
+	} {
+		if rec := serve(s, http.MethodGet, "/api/v1/events?"+query, "", ""); rec.Code != want {
+			t.Errorf("%s: status = %d, want %d", query, rec.Code, want)
+		}
+	}
+}
+
+func TestV1_EventSocket(t *testing.T) {
+	s := newTestServer()
+	bus := events.NewBus(10)
+	s.SetEventBus(bus)
+
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	if rec := serve(s, http.MethodGet, "/api/v1/events/ws", "", ""); rec.Code != http.StatusUpgradeRequired {
+		t.Errorf("plain GET status = %d", rec.Code)
+	}
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+	io.WriteString(conn, "GET /api/v1/events/ws?topics=kill_switch HTTP/1.1\r\n"+
+		"Host: test\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n"+
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: "+key+"\r\n\r\n")
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != webSocketAccept(key) {
+		t.Fatalf("handshake status %d, accept %q", resp.StatusCode, resp.Header.Get("Sec-WebSocket-Accept"))
+	}
+
+	waitSubscribers(t, bus, 1)
+	bus.Publish(events.TradeFilled, events.TradeData{})
+	bus.Publish(events.KillSwitchChanged, events.KillSwitchData{Active: true, Reason: "manual"})
+
+	var head [2]byte
+	if _, err := io.ReadFull(reader, head[:]); err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	if head[0] != 0x80|wsOpText || head[1]&0x80 != 0 {
+		t.Fatalf("frame header %x", head)
+	}
+	length := int(head[1] & 0x7F)
+	if length == 126 {
+		var ext [2]byte
+		io.ReadFull(reader, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	io.ReadFull(reader, payload)
+
+	var event events.Event
+	if err := json.Unmarshal(payload, &event); err != nil || event.ID != 2 || event.Type != events.KillSwitchChanged {
+		t.Errorf("event = %+v, %v (%s)", event, err, payload)
+	}
+
+	// Маскированный close от клиента - сервер отвечает close и отписывается
+	mask := []byte{1, 2, 3, 4}
+	closePayload := []byte{0x03, 0xE8}
+	frame := []byte{0x80 | wsOpClose, 0x80 | byte(len(closePayload))}
+	frame = append(frame, mask...)
+	for i, b := range closePayload {
+		frame = append(frame, b^mask[i%4])
+	}
+	conn.Write(frame)
+
+	if _, err := io.ReadFull(reader, head[:]); err != nil || head[0] != 0x80|wsOpClose {
+		t.Errorf("close reply %x, %v", head, err)
+	}
+	waitSubscribers(t, bus, 0)
+}
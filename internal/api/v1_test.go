@@ -0,0 +1,275 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/pkg/utils"
+)
+
+// fakeDecisions решения и действия AI в памяти
+type fakeDecisions struct {
+	decisions []domain.AIDecision
+	actions   []domain.AIAction
+}
+
+func (f *fakeDecisions) GetRecent(ctx context.Context, limit int) ([]domain.AIDecision, error) {
+	return f.decisions, nil
+}
+
+func (f *fakeDecisions) GetByID(ctx context.Context, id int64) (*domain.AIDecision, error) {
+	for _, d := range f.decisions {
+		if d.ID == id {
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeDecisions) GetByMode(ctx context.Context, mode string, limit int) ([]domain.AIDecision, error) {
+	var result []domain.AIDecision
+	for _, d := range f.decisions {
+		if d.Mode == mode {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+// fakeActions реализует ActionStore поверх тех же данных
+type fakeActions struct{ *fakeDecisions }
+
+func (f fakeActions) GetRecent(ctx context.Context, limit int) ([]domain.AIAction, error) {
+	return f.actions, nil
+}
+
+func (f fakeActions) GetByStatus(ctx context.Context, status string, limit int) ([]domain.AIAction, error) {
+	return nil, errors.New("not used")
+}
+
+func (f fakeActions) GetByDecisionID(ctx context.Context, decisionID int64) ([]domain.AIAction, error) {
+	var result []domain.AIAction
+	for _, a := range f.actions {
+		if a.DecisionID == decisionID {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+// fakeOrchestrator запоминает запрошенный режим
+type fakeOrchestrator struct {
+	mode      string
+	requester int64
+}
+
+func (f *fakeOrchestrator) CurrentMode() string { return f.mode }
+func (f *fakeOrchestrator) IsRunning() bool     { return true }
+
+func (f *fakeOrchestrator) RequestMode(mode string, adminID int64) (bool, error) {
+	if mode == f.mode {
+		return false, errors.New("already in this mode")
+	}
+	f.mode, f.requester = mode, adminID
+	return true, nil
+}
+
+func newTestServer() *Server {
+	return &Server{logger: utils.NewLogger("error")}
+}
+
+func serve(s *Server, method, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestParseTradeFilter(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet,
+		"/api/v1/trades?symbol=btcusdt&side=sell&from=2026-01-01&to=2026-02-01T00:00:00Z&limit=20&offset=40", nil)
+	filter, err := parseTradeFilter(req)
+	if err != nil {
+		t.Fatalf("parseTradeFilter: %v", err)
+	}
+	if filter.Symbol != "BTCUSDT" || filter.Side != "SELL" || filter.Limit != 20 || filter.Offset != 40 {
+		t.Errorf("filter = %+v", filter)
+	}
+	if !filter.From.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) || filter.To.Month() != time.February {
+		t.Errorf("period = %s - %s", filter.From, filter.To)
+	}
+
+	// Без параметров - первая страница по умолчанию
+	filter, err = parseTradeFilter(httptest.NewRequest(http.MethodGet, "/api/v1/trades", nil))
+	if err != nil || filter.Limit != defaultPageLimit || filter.Offset != 0 {
+		t.Errorf("defaults = %+v, %v", filter, err)
+	}
+
+	for _, query := range []string{"side=hold", "from=yesterday", "limit=0", "limit=501", "offset=-1",
+		"from=2026-02-01&to=2026-01-01"} {
+		_, err := parseTradeFilter(httptest.NewRequest(http.MethodGet, "/api/v1/trades?"+query, nil))
+		if !errors.Is(err, domain.ErrInvalidInput) {
+			t.Errorf("%s: err = %v", query, err)
+		}
+	}
+}
+
+func TestV1_Decisions(t *testing.T) {
+	s := newTestServer()
+	store := &fakeDecisions{
+		decisions: []domain.AIDecision{
+			{ID: 1, Regime: "ACCUMULATE", Mode: "shadow"},
+			{ID: 2, Regime: "DEFENSE", Mode: "pilot"},
+		},
+		actions: []domain.AIAction{{ID: 10, DecisionID: 2, ActionType: "pause_strategy", Status: "executed"}},
+	}
+	s.SetDecisionStore(store)
+	s.SetActionStore(fakeActions{store})
+
+	rec := serve(s, http.MethodGet, "/api/v1/ai/decisions/2", "", "")
+	var resp struct {
+		Data DecisionDetailResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	if resp.Data.Regime != "DEFENSE" || len(resp.Data.Actions) != 1 || resp.Data.Actions[0].ExecutedAt != nil {
+		t.Errorf("decision = %+v", resp.Data)
+	}
+
+	// Фильтр по режиму и пустой список как []
+	rec = serve(s, http.MethodGet, "/api/v1/ai/decisions?mode=full", "", "")
+	if !strings.Contains(rec.Body.String(), `"data":[]`) {
+		t.Errorf("empty list = %s", rec.Body.String())
+	}
+
+	if rec := serve(s, http.MethodGet, "/api/v1/ai/decisions/3", "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("missing decision status = %d", rec.Code)
+	}
+	if rec := serve(s, http.MethodGet, "/api/v1/ai/decisions/abc", "", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad id status = %d", rec.Code)
+	}
+	if rec := serve(s, http.MethodPost, "/api/v1/ai/decisions", "", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("wrong method status = %d", rec.Code)
+	}
+}
+
+func TestV1_NotConfigured(t *testing.T) {
+	s := newTestServer()
+	for _, path := range []string{"/api/v1/ai/actions", "/api/v1/policy/violations", "/api/v1/circuit-breakers", "/api/v1/mode", "/api/v1/risk/status"} {
+		if rec := serve(s, http.MethodGet, path, "", ""); rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: status = %d", path, rec.Code)
+		}
+	}
+}
+
+func TestV1_ModePermissions(t *testing.T) {
+	s := newTestServer()
+	orchestrator := &fakeOrchestrator{mode: "shadow"}
+	s.SetOrchestrator(orchestrator)
+	s.SetAuthenticator(fakeAuth{
+		"viewer": {TelegramID: 1, Role: domain.RoleViewer},
+		"risk":   {TelegramID: 2, Role: domain.RoleRiskOfficer},
+		"admin":  {TelegramID: 3, Role: domain.RoleAdmin},
+		"trader": {TelegramID: 4, Role: domain.RoleTrader},
+	})
+
+	tests := []struct {
+		key  string
+		body string
+		want int
+	}{
+		{"", `{"mode":"pilot"}`, http.StatusUnauthorized},
+		{"viewer", `{"mode":"pilot"}`, http.StatusForbidden},
+		{"risk", `{"mode":"pilot"}`, http.StatusForbidden},
+		{"trader", `{"mode":"shadow"}`, http.StatusForbidden},
+		{"admin", `{"mode":"pilot", "extra":1}`, http.StatusBadRequest},
+		{"admin", `{"mode":"pilot"}`, http.StatusOK},
+		{"risk", `{"mode":"shadow"}`, http.StatusOK},
+		{"risk", `{"mode":"shadow"}`, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		rec := serve(s, http.MethodPut, "/api/v1/mode", tt.key, tt.body)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: status = %d, want %d (%s)", tt.key, tt.body, rec.Code, tt.want, rec.Body.String())
+		}
+	}
+
+	if orchestrator.mode != "shadow" || orchestrator.requester != 2 {
+		t.Errorf("orchestrator = %+v", orchestrator)
+	}
+
+	if rec := serve(s, http.MethodGet, "/api/v1/mode", "viewer", ""); rec.Code != http.StatusOK {
+		t.Errorf("viewer GET mode = %d", rec.Code)
+	}
+}
+
+func TestV1_OpenAPICoversRoutes(t *testing.T) {
+	s := newTestServer()
+	s.SetAuthenticator(fakeAuth{})
+
+	// Спецификация доступна без ключа
+	rec := serve(s, http.MethodGet, "/api/v1/openapi.yaml", "", "")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "openapi: 3") {
+		t.Fatalf("spec status %d", rec.Code)
+	}
+	spec := rec.Body.String()
+
+	for _, rt := range s.v1Routes() {
+		path := v1Prefix + rt.path
+		start := strings.Index(spec, "\n  "+path+":\n")
+		if start < 0 {
+			t.Errorf("%s missing in openapi.yaml", path)
+			continue
+		}
+		block := spec[start+1:]
+		if end := strings.Index(block, "\n\n  /"); end >= 0 {
+			block = block[:end]
+		}
+		if !strings.Contains(block, "\n    "+strings.ToLower(rt.method)+":") {
+			t.Errorf("%s %s missing in openapi.yaml", rt.method, path)
+		}
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	s := newTestServer()
+	s.SetAuthenticator(fakeAuth{"viewer": {ID: 1, TelegramID: 1, Role: domain.RoleViewer}})
+
+	if rec := serve(s, http.MethodGet, "/dashboard", "", ""); rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/dashboard/" {
+		t.Errorf("/dashboard: status = %d, location %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	// Статика открыта без ключа и закрыта CSP
+	rec := serve(s, http.MethodGet, "/dashboard/", "", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `<script src="app.js">`) {
+		t.Fatalf("index: status = %d", rec.Code)
+	}
+	if csp := rec.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "default-src 'self'") {
+		t.Errorf("CSP = %q", csp)
+	}
+	for _, file := range []string{"app.js", "style.css"} {
+		if rec := serve(s, http.MethodGet, "/dashboard/"+file, "", ""); rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+			t.Errorf("%s: status = %d", file, rec.Code)
+		}
+	}
+	if rec := serve(s, http.MethodPost, "/dashboard/", "", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d", rec.Code)
+	}
+
+	// Данные панели по-прежнему требуют ключ
+	if rec := serve(s, http.MethodGet, "/api/v1/pnl/portfolio", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("API without key: status = %d", rec.Code)
+	}
+}
@@ -0,0 +1,471 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+var strategyTypes = map[string]bool{"DCA": true, "GRID": true, "HYBRID": true}
+
+// validateAsset checks asset config written through the API
+func validateAsset(a *domain.Asset) error {
+	if !strategyTypes[a.StrategyType] {
+		return fmt.Errorf("%w: strategy_type must be DCA, GRID or HYBRID", domain.ErrInvalidInput)
+	}
+	for name, value := range map[string]float64{
+		"allocated_capital":    a.AllocatedCapital,
+		"max_position_size":    a.MaxPositionSize,
+		"dca_amount":           a.DCAAmount,
+		"grid_spacing_percent": a.GridSpacingPercent,
+		"grid_order_size":      a.GridOrderSize,
+		"stop_loss_percent":    a.StopLossPercent,
+		"take_profit_percent":  a.TakeProfitPercent,
+	} {
+		if value < 0 {
+			return fmt.Errorf("%w: %s must not be negative", domain.ErrInvalidInput, name)
+		}
+	}
+	if a.DCAInterval < 0 || a.GridLevels < 0 {
+		return fmt.Errorf("%w: dca_interval_minutes and grid_levels must not be negative", domain.ErrInvalidInput)
+	}
+	return validateAutoSell(a.AutoSellTriggerPercent, a.AutoSellAmountPercent)
+}
+
+func validateAutoSell(triggerPercent, sellPercent float64) error {
+	if triggerPercent < 0 || triggerPercent > 1000 {
+		return fmt.Errorf("%w: auto-sell trigger must be between 0 and 1000%%", domain.ErrInvalidInput)
+	}
+	if sellPercent < 0 || sellPercent > 100 {
+		return fmt.Errorf("%w: auto-sell amount must be between 0 and 100%%", domain.ErrInvalidInput)
+	}
+	return nil
+}
+
+// loadAsset returns the asset from the path or ErrNotFound
+func (s *Server) loadAsset(ctx context.Context, symbol string) (*domain.Asset, error) {
+	asset, err := s.storage.Assets().Get(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset: %w", err)
+	}
+	if asset == nil {
+		return nil, fmt.Errorf("%w: asset %s", domain.ErrNotFound, symbol)
+	}
+	return asset, nil
+}
+
+// handleListAssets - GET /api/v1/assets[?enabled=true]
+func (s *Server) handleListAssets(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var assets []domain.Asset
+	var err error
+	if r.URL.Query().Get("enabled") == "true" {
+		assets, err = s.storage.Assets().GetEnabled(ctx)
+	} else {
+		assets, err = s.storage.Assets().GetAll(ctx)
+	}
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get assets: %w", err))
+		return
+	}
+
+	s.sendSuccess(w, convertAll(assets, toAssetResponse))
+}
+
+// handleCreateAsset - POST /api/v1/assets
+func (s *Server) handleCreateAsset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req AssetRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	symbol := strings.ToUpper(req.Symbol)
+	if symbol == "" {
+		s.sendError(w, "Symbol is required", http.StatusBadRequest)
+		return
+	}
+
+	existing, err := s.storage.Assets().Get(ctx, symbol)
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get asset: %w", err))
+		return
+	}
+	if existing != nil {
+		s.sendError(w, fmt.Sprintf("Asset %s already exists", symbol), http.StatusConflict)
+		return
+	}
+
+	asset := &domain.Asset{Symbol: symbol, Enabled: true, StrategyType: "DCA"}
+	req.apply(asset)
+	if err := validateAsset(asset); err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	if err := s.storage.Assets().CreateOrUpdate(ctx, asset); err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to create asset: %w", err))
+		return
+	}
+
+	s.sendCreated(w, toAssetResponse(*asset))
+}
+
+// handleGetAsset - GET /api/v1/assets/{symbol}
+func (s *Server) handleGetAsset(w http.ResponseWriter, r *http.Request) {
+	asset, err := s.loadAsset(r.Context(), pathSymbol(r))
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	s.sendSuccess(w, toAssetResponse(*asset))
+}
+
+// handleUpdateAsset - PATCH /api/v1/assets/{symbol}
+func (s *Server) handleUpdateAsset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req AssetRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	asset, err := s.loadAsset(r.Context(), pathSymbol(r))
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	if req.Symbol != "" && strings.ToUpper(req.Symbol) != asset.Symbol {
+		s.sendError(w, "Symbol cannot be changed", http.StatusBadRequest)
+		return
+	}
+
+	req.apply(asset)
+	if err := validateAsset(asset); err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	if err := s.storage.Assets().CreateOrUpdate(ctx, asset); err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to update asset: %w", err))
+		return
+	}
+
+	s.sendSuccess(w, toAssetResponse(*asset))
+}
+
+// handleDeleteAsset - DELETE /api/v1/assets/{symbol}
+func (s *Server) handleDeleteAsset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	symbol := pathSymbol(r)
+
+	deleted, err := s.storage.Assets().Delete(ctx, symbol)
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to delete asset: %w", err))
+		return
+	}
+	if !deleted {
+		s.sendV1Error(w, fmt.Errorf("%w: asset %s", domain.ErrNotFound, symbol))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetAutoSell - GET /api/v1/assets/{symbol}/autosell
+func (s *Server) handleGetAutoSell(w http.ResponseWriter, r *http.Request) {
+	asset, err := s.loadAsset(r.Context(), pathSymbol(r))
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	s.sendSuccess(w, AutoSellResponse{
+		Symbol:         asset.Symbol,
+		Enabled:        asset.AutoSellEnabled,
+		TriggerPercent: asset.AutoSellTriggerPercent,
+		SellPercent:    asset.AutoSellAmountPercent,
+	})
+}
+
+// handleUpdateAutoSell - PUT /api/v1/assets/{symbol}/autosell
+func (s *Server) handleUpdateAutoSell(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req AutoSellRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	asset, err := s.loadAsset(r.Context(), pathSymbol(r))
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	setBool(&asset.AutoSellEnabled, req.Enabled)
+	setFloat(&asset.AutoSellTriggerPercent, req.TriggerPercent)
+	setFloat(&asset.AutoSellAmountPercent, req.SellPercent)
+	if err := validateAutoSell(asset.AutoSellTriggerPercent, asset.AutoSellAmountPercent); err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	if err := s.storage.Assets().CreateOrUpdate(ctx, asset); err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to update auto-sell: %w", err))
+		return
+	}
+
+	s.sendSuccess(w, AutoSellResponse{
+		Symbol:         asset.Symbol,
+		Enabled:        asset.AutoSellEnabled,
+		TriggerPercent: asset.AutoSellTriggerPercent,
+		SellPercent:    asset.AutoSellAmountPercent,
+	})
+}
+
+// handleListTrades - GET /api/v1/trades?symbol=&side=&strategy=&from=&to=&limit=&offset=
+func (s *Server) handleListTrades(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	filter, err := parseTradeFilter(r)
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	trades, total, err := s.storage.Trades().Find(ctx, filter)
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get trades: %w", err))
+		return
+	}
+
+	s.sendSuccess(w, TradePage{
+		Trades: convertAll(trades, toTradeResponse),
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+}
+
+// handleV1Buy - POST /api/v1/trades/buy
+func (s *Server) handleV1Buy(w http.ResponseWriter, r *http.Request) {
+	var req TradeBuyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	if err := s.checkOrderSize(r, req.AmountUSD); err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	trade, err := s.trader.Buy(r.Context(), strings.ToUpper(req.Symbol), req.AmountUSD)
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	s.sendCreated(w, toTradeResponse(*trade))
+}
+
+// handleV1Sell - POST /api/v1/trades/sell
+func (s *Server) handleV1Sell(w http.ResponseWriter, r *http.Request) {
+	var req TradeSellRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	symbol := strings.ToUpper(req.Symbol)
+	if symbol == "" {
+		s.sendError(w, "Symbol is required", http.StatusBadRequest)
+		return
+	}
+
+	quantity, price, err := s.trader.Quote(r.Context(), symbol, req.Percent)
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+	if err := s.checkOrderSize(r, quantity*price); err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	result, err := s.trader.Sell(r.Context(), symbol, req.Percent)
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	s.sendCreated(w, SellResponse{
+		Trade:   toTradeResponse(*result.Trade),
+		Percent: result.Percent,
+		Profit:  result.Profit,
+	})
+}
+
+// handleGridStatus - GET /api/v1/grid/{symbol}
+func (s *Server) handleGridStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	symbol := pathSymbol(r)
+
+	orders, err := s.storage.GridOrders().GetActive(ctx, symbol)
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get grid orders: %w", err))
+		return
+	}
+
+	status := GridStatusResponse{
+		Symbol: symbol,
+		Orders: convertAll(orders, toGridOrderResponse),
+	}
+	if s.gridStrategy != nil && len(orders) > 0 {
+		if status.Metrics, err = s.gridStrategy.CalculateGridMetrics(symbol); err != nil {
+			s.sendV1Error(w, fmt.Errorf("failed to get grid metrics: %w", err))
+			return
+		}
+	}
+
+	s.sendSuccess(w, status)
+}
+
+// handleGridStart - POST /api/v1/grid/{symbol}/start
+func (s *Server) handleGridStart(w http.ResponseWriter, r *http.Request) {
+	var req GridStartRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	// Zero spacing lets the grid strategy derive it from ATR
+	if req.Levels <= 0 || req.OrderSizeQuote <= 0 || req.SpacingPercent < 0 {
+		s.sendError(w, "Levels and order size must be positive, spacing must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.checkOrderSize(r, req.OrderSizeQuote); err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	asset, err := s.startGrid(pathSymbol(r), req.Levels, req.SpacingPercent, req.OrderSizeQuote)
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	s.sendSuccess(w, toAssetResponse(*asset))
+}
+
+// handleGridStop - POST /api/v1/grid/{symbol}/stop
+func (s *Server) handleGridStop(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	symbol := pathSymbol(r)
+
+	if err := s.storage.GridOrders().CancelAll(ctx, symbol); err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to cancel grid orders: %w", err))
+		return
+	}
+
+	s.sendSuccess(w, GridStatusResponse{Symbol: symbol, Orders: []GridOrderResponse{}})
+}
+
+// startGrid saves grid params on the asset (creating it if needed) and places the grid
+func (s *Server) startGrid(symbol string, levels int, spacingPercent, orderSize float64) (*domain.Asset, error) {
+	ctx := context.Background()
+	if s.gridStrategy == nil {
+		return nil, fmt.Errorf("grid strategy not available")
+	}
+
+	asset, err := s.storage.Assets().Get(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset: %w", err)
+	}
+	if asset == nil {
+		asset = &domain.Asset{
+			Symbol:           symbol,
+			Enabled:          true,
+			StrategyType:     "GRID",
+			AllocatedCapital: float64(levels) * orderSize * 2,
+			MaxPositionSize:  float64(levels) * orderSize * 3,
+		}
+	}
+	asset.GridLevels = levels
+	asset.GridSpacingPercent = spacingPercent
+	asset.GridOrderSize = orderSize
+
+	if err := s.storage.Assets().CreateOrUpdate(ctx, asset); err != nil {
+		return nil, fmt.Errorf("failed to save asset: %w", err)
+	}
+
+	if err := s.gridStrategy.InitializeGrid(asset); err != nil {
+		return nil, fmt.Errorf("grid initialization failed: %w", err)
+	}
+
+	return asset, nil
+}
+
+// handleListBalances - GET /api/v1/balances
+func (s *Server) handleListBalances(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	balances, err := s.storage.Balances().GetAll(ctx)
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get balances: %w", err))
+		return
+	}
+
+	s.sendSuccess(w, convertAll(balances, toBalanceResponse))
+}
+
+// handlePnLHistory - GET /api/v1/pnl?symbol=&type=DAILY&limit=
+func (s *Server) handlePnLHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	symbol := strings.ToUpper(r.URL.Query().Get("symbol"))
+	if symbol == "" {
+		s.sendError(w, "Symbol is required", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := pageLimit(r)
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	snapshotType := strings.ToUpper(getQueryParam(r, "type", "DAILY"))
+	history, err := s.storage.PnL().GetHistory(ctx, symbol, snapshotType, limit)
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get PnL history: %w", err))
+		return
+	}
+
+	s.sendSuccess(w, convertAll(history, toPnLSnapshotResponse))
+}
+
+// handlePortfolioPnL - GET /api/v1/pnl/portfolio?type=DAILY&limit=
+// Portfolio value over time: snapshots of all symbols summed per period
+func (s *Server) handlePortfolioPnL(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	limit, err := pageLimit(r)
+	if err != nil {
+		s.sendV1Error(w, err)
+		return
+	}
+
+	snapshotType := strings.ToUpper(getQueryParam(r, "type", "DAILY"))
+	history, err := s.storage.PnL().GetPortfolioHistory(ctx, snapshotType, limit)
+	if err != nil {
+		s.sendV1Error(w, fmt.Errorf("failed to get portfolio history: %w", err))
+		return
+	}
+
+	s.sendSuccess(w, convertAll(history, toPnLSnapshotResponse))
+}
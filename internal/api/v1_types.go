@@ -0,0 +1,448 @@
+package api
+
+import (
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// AssetRequest creates or updates an asset. Omitted fields keep their current values
+type AssetRequest struct {
+	Symbol                 string   `json:"symbol,omitempty"`
+	Enabled                *bool    `json:"enabled,omitempty"`
+	StrategyType           *string  `json:"strategy_type,omitempty"`
+	AllocatedCapital       *float64 `json:"allocated_capital,omitempty"`
+	MaxPositionSize        *float64 `json:"max_position_size,omitempty"`
+	DCAAmount              *float64 `json:"dca_amount,omitempty"`
+	DCAIntervalMinutes     *int     `json:"dca_interval_minutes,omitempty"`
+	AutoSellEnabled        *bool    `json:"auto_sell_enabled,omitempty"`
+	AutoSellTriggerPercent *float64 `json:"auto_sell_trigger_percent,omitempty"`
+	AutoSellAmountPercent  *float64 `json:"auto_sell_amount_percent,omitempty"`
+	GridLevels             *int     `json:"grid_levels,omitempty"`
+	GridSpacingPercent     *float64 `json:"grid_spacing_percent,omitempty"`
+	GridOrderSize          *float64 `json:"grid_order_size,omitempty"`
+	StopLossPercent        *float64 `json:"stop_loss_percent,omitempty"`
+	TakeProfitPercent      *float64 `json:"take_profit_percent,omitempty"`
+}
+
+type AssetResponse struct {
+	ID                     int64     `json:"id"`
+	Symbol                 string    `json:"symbol"`
+	Enabled                bool      `json:"enabled"`
+	StrategyType           string    `json:"strategy_type"`
+	AllocatedCapital       float64   `json:"allocated_capital"`
+	MaxPositionSize        float64   `json:"max_position_size"`
+	DCAAmount              float64   `json:"dca_amount"`
+	DCAIntervalMinutes     int       `json:"dca_interval_minutes"`
+	AutoSellEnabled        bool      `json:"auto_sell_enabled"`
+	AutoSellTriggerPercent float64   `json:"auto_sell_trigger_percent"`
+	AutoSellAmountPercent  float64   `json:"auto_sell_amount_percent"`
+	GridLevels             int       `json:"grid_levels"`
+	GridSpacingPercent     float64   `json:"grid_spacing_percent"`
+	GridOrderSize          float64   `json:"grid_order_size"`
+	StopLossPercent        float64   `json:"stop_loss_percent"`
+	TakeProfitPercent      float64   `json:"take_profit_percent"`
+	CreatedAt              time.Time `json:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at"`
+}
+
+type AutoSellRequest struct {
+	Enabled        *bool    `json:"enabled,omitempty"`
+	TriggerPercent *float64 `json:"trigger_percent,omitempty"`
+	SellPercent    *float64 `json:"sell_percent,omitempty"`
+}
+
+type AutoSellResponse struct {
+	Symbol         string  `json:"symbol"`
+	Enabled        bool    `json:"enabled"`
+	TriggerPercent float64 `json:"trigger_percent"`
+	SellPercent    float64 `json:"sell_percent"`
+}
+
+type TradeResponse struct {
+	ID        int64     `json:"id"`
+	Symbol    string    `json:"symbol"`
+	Side      string    `json:"side"`
+	Quantity  float64   `json:"quantity"`
+	Price     float64   `json:"price"`
+	Amount    float64   `json:"amount"`
+	OrderID   string    `json:"order_id"`
+	Status    string    `json:"status"`
+	Strategy  string    `json:"strategy"`
+	GridLevel int       `json:"grid_level,omitempty"`
+	TraceID   string    `json:"trace_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TradePage is one page of trades matching the filter
+type TradePage struct {
+	Trades []TradeResponse `json:"trades"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+type TradeBuyRequest struct {
+	Symbol    string  `json:"symbol"`
+	AmountUSD float64 `json:"amount_usd"`
+}
+
+type TradeSellRequest struct {
+	Symbol  string  `json:"symbol"`
+	Percent float64 `json:"percent"`
+}
+
+type SellResponse struct {
+	Trade   TradeResponse `json:"trade"`
+	Percent float64       `json:"percent"`
+	Profit  float64       `json:"profit"`
+}
+
+type BalanceResponse struct {
+	Symbol         string    `json:"symbol"`
+	TotalQuantity  float64   `json:"total_quantity"`
+	AvailableQty   float64   `json:"available_qty"`
+	AvgEntryPrice  float64   `json:"avg_entry_price"`
+	TotalInvested  float64   `json:"total_invested"`
+	TotalSold      float64   `json:"total_sold"`
+	RealizedProfit float64   `json:"realized_profit"`
+	UnrealizedPnL  float64   `json:"unrealized_pnl"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type GridStartRequest struct {
+	Levels         int     `json:"levels"`
+	SpacingPercent float64 `json:"spacing_percent"`
+	OrderSizeQuote float64 `json:"order_size_quote"`
+}
+
+type GridOrderResponse struct {
+	ID        int64     `json:"id"`
+	Level     int       `json:"level"`
+	Side      string    `json:"side"`
+	Price     float64   `json:"price"`
+	Quantity  float64   `json:"quantity"`
+	Status    string    `json:"status"`
+	OrderID   string    `json:"order_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GridStatusResponse struct {
+	Symbol  string                 `json:"symbol"`
+	Orders  []GridOrderResponse    `json:"orders"`
+	Metrics map[string]interface{} `json:"metrics,omitempty"`
+}
+
+// RiskLimitsRequest updates risk limits. Omitted fields keep their current values
+type RiskLimitsRequest struct {
+	MaxDailyLoss       *float64 `json:"max_daily_loss,omitempty"`
+	MaxTotalExposure   *float64 `json:"max_total_exposure,omitempty"`
+	MaxPositionSizeUSD *float64 `json:"max_position_size_usd,omitempty"`
+	MaxOrderSizeUSD    *float64 `json:"max_order_size_usd,omitempty"`
+}
+
+type RiskLimitsResponse struct {
+	MaxDailyLoss       float64   `json:"max_daily_loss"`
+	MaxTotalExposure   float64   `json:"max_total_exposure"`
+	MaxPositionSizeUSD float64   `json:"max_position_size_usd"`
+	MaxOrderSizeUSD    float64   `json:"max_order_size_usd"`
+	EmergencyStop      bool      `json:"emergency_stop"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+type PnLSnapshotResponse struct {
+	Symbol        string    `json:"symbol"`
+	SnapshotType  string    `json:"snapshot_type"`
+	RealizedPnL   float64   `json:"realized_pnl"`
+	UnrealizedPnL float64   `json:"unrealized_pnl"`
+	TotalPnL      float64   `json:"total_pnl"`
+	TotalInvested float64   `json:"total_invested"`
+	CurrentValue  float64   `json:"current_value"`
+	ReturnPercent float64   `json:"return_percent"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type DecisionResponse struct {
+	ID              int64     `json:"id"`
+	Timestamp       time.Time `json:"timestamp"`
+	Regime          string    `json:"regime"`
+	Confidence      float64   `json:"confidence"`
+	Rationale       string    `json:"rationale"`
+	Approved        bool      `json:"approved"`
+	RejectionReason string    `json:"rejection_reason,omitempty"`
+	Mode            string    `json:"mode"`
+	PromptVersion   string    `json:"prompt_version,omitempty"`
+	Provider        string    `json:"provider,omitempty"`
+	Model           string    `json:"model,omitempty"`
+	TraceID         string    `json:"trace_id,omitempty"`
+}
+
+// DecisionDetailResponse is a decision with its planned actions
+type DecisionDetailResponse struct {
+	DecisionResponse
+	Actions []ActionResponse `json:"actions"`
+}
+
+type ActionResponse struct {
+	ID           int64      `json:"id"`
+	DecisionID   int64      `json:"decision_id"`
+	ActionType   string     `json:"action_type"`
+	Symbol       string     `json:"symbol"`
+	Parameters   string     `json:"parameters"`
+	Status       string     `json:"status"`
+	RiskScore    float64    `json:"risk_score"`
+	ExecutedAt   *time.Time `json:"executed_at,omitempty"`
+	ErrorMessage string     `json:"error_message,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type ViolationResponse struct {
+	ID             int64     `json:"id"`
+	Timestamp      time.Time `json:"timestamp"`
+	ActionID       int64     `json:"action_id"`
+	ViolationType  string    `json:"violation_type"`
+	LimitName      string    `json:"limit_name"`
+	LimitValue     float64   `json:"limit_value"`
+	AttemptedValue float64   `json:"attempted_value"`
+	Severity       string    `json:"severity"`
+}
+
+type BreakerEventResponse struct {
+	ID          int64      `json:"id"`
+	TriggeredAt time.Time  `json:"triggered_at"`
+	Reason      string     `json:"reason"`
+	Details     string     `json:"details,omitempty"`
+	PausedUntil time.Time  `json:"paused_until"`
+	ResumedAt   *time.Time `json:"resumed_at,omitempty"`
+}
+
+type KillSwitchRequest struct {
+	Active bool   `json:"active"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type KillSwitchResponse struct {
+	// EmergencyStop pauses DCA, grid and manual orders (risk limits flag)
+	EmergencyStop bool `json:"emergency_stop"`
+	// Execution is the AI executor kill switch, absent when not wired
+	Execution *ExecutionKillSwitch `json:"execution,omitempty"`
+}
+
+type ExecutionKillSwitch struct {
+	Active      bool       `json:"active"`
+	Reason      string     `json:"reason,omitempty"`
+	ActivatedAt *time.Time `json:"activated_at,omitempty"`
+}
+
+type ModeRequest struct {
+	Mode string `json:"mode"`
+}
+
+type ModeResponse struct {
+	Mode    string `json:"mode"`
+	Running bool   `json:"running"`
+	// Applied is false while an upgrade waits for more admin approvals
+	Applied *bool `json:"applied,omitempty"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func toAssetResponse(a domain.Asset) AssetResponse {
+	return AssetResponse{
+		ID:                     a.ID,
+		Symbol:                 a.Symbol,
+		Enabled:                a.Enabled,
+		StrategyType:           a.StrategyType,
+		AllocatedCapital:       a.AllocatedCapital,
+		MaxPositionSize:        a.MaxPositionSize,
+		DCAAmount:              a.DCAAmount,
+		DCAIntervalMinutes:     a.DCAInterval,
+		AutoSellEnabled:        a.AutoSellEnabled,
+		AutoSellTriggerPercent: a.AutoSellTriggerPercent,
+		AutoSellAmountPercent:  a.AutoSellAmountPercent,
+		GridLevels:             a.GridLevels,
+		GridSpacingPercent:     a.GridSpacingPercent,
+		GridOrderSize:          a.GridOrderSize,
+		StopLossPercent:        a.StopLossPercent,
+		TakeProfitPercent:      a.TakeProfitPercent,
+		CreatedAt:              a.CreatedAt,
+		UpdatedAt:              a.UpdatedAt,
+	}
+}
+
+// apply copies the provided fields onto the asset
+func (req AssetRequest) apply(a *domain.Asset) {
+	setBool(&a.Enabled, req.Enabled)
+	if req.StrategyType != nil {
+		a.StrategyType = *req.StrategyType
+	}
+	setFloat(&a.AllocatedCapital, req.AllocatedCapital)
+	setFloat(&a.MaxPositionSize, req.MaxPositionSize)
+	setFloat(&a.DCAAmount, req.DCAAmount)
+	setInt(&a.DCAInterval, req.DCAIntervalMinutes)
+	setBool(&a.AutoSellEnabled, req.AutoSellEnabled)
+	setFloat(&a.AutoSellTriggerPercent, req.AutoSellTriggerPercent)
+	setFloat(&a.AutoSellAmountPercent, req.AutoSellAmountPercent)
+	setInt(&a.GridLevels, req.GridLevels)
+	setFloat(&a.GridSpacingPercent, req.GridSpacingPercent)
+	setFloat(&a.GridOrderSize, req.GridOrderSize)
+	setFloat(&a.StopLossPercent, req.StopLossPercent)
+	setFloat(&a.TakeProfitPercent, req.TakeProfitPercent)
+}
+
+func toTradeResponse(t domain.Trade) TradeResponse {
+	return TradeResponse{
+		ID:        t.ID,
+		Symbol:    t.Symbol,
+		Side:      t.Side,
+		Quantity:  t.Quantity,
+		Price:     t.Price,
+		Amount:    t.Amount,
+		OrderID:   t.OrderID,
+		Status:    t.Status,
+		Strategy:  t.StrategyType,
+		GridLevel: t.GridLevel,
+		TraceID:   t.TraceID,
+		CreatedAt: t.CreatedAt,
+	}
+}
+
+func toBalanceResponse(b domain.Balance) BalanceResponse {
+	return BalanceResponse{
+		Symbol:         b.Symbol,
+		TotalQuantity:  b.TotalQuantity,
+		AvailableQty:   b.AvailableQty,
+		AvgEntryPrice:  b.AvgEntryPrice,
+		TotalInvested:  b.TotalInvested,
+		TotalSold:      b.TotalSold,
+		RealizedProfit: b.RealizedProfit,
+		UnrealizedPnL:  b.UnrealizedPnL,
+		UpdatedAt:      b.UpdatedAt,
+	}
+}
+
+func toGridOrderResponse(o domain.GridOrder) GridOrderResponse {
+	return GridOrderResponse{
+		ID:        o.ID,
+		Level:     o.Level,
+		Side:      o.Side,
+		Price:     o.Price,
+		Quantity:  o.Quantity,
+		Status:    o.Status,
+		OrderID:   o.OrderID,
+		CreatedAt: o.CreatedAt,
+	}
+}
+
+func toRiskLimitsResponse(l domain.RiskLimit) RiskLimitsResponse {
+	return RiskLimitsResponse{
+		MaxDailyLoss:       l.MaxDailyLoss,
+		MaxTotalExposure:   l.MaxTotalExposure,
+		MaxPositionSizeUSD: l.MaxPositionSizeUSD,
+		MaxOrderSizeUSD:    l.MaxOrderSizeUSD,
+		EmergencyStop:      l.EnableEmergencyStop,
+		UpdatedAt:          l.UpdatedAt,
+	}
+}
+
+func toPnLSnapshotResponse(p domain.PnLHistory) PnLSnapshotResponse {
+	return PnLSnapshotResponse{
+		Symbol:        p.Symbol,
+		SnapshotType:  p.SnapshotType,
+		RealizedPnL:   p.RealizedPnL,
+		UnrealizedPnL: p.UnrealizedPnL,
+		TotalPnL:      p.TotalPnL,
+		TotalInvested: p.TotalInvested,
+		CurrentValue:  p.CurrentValue,
+		ReturnPercent: p.ReturnPercent,
+		CreatedAt:     p.CreatedAt,
+	}
+}
+
+func toDecisionResponse(d domain.AIDecision) DecisionResponse {
+	return DecisionResponse{
+		ID:              d.ID,
+		Timestamp:       d.Timestamp,
+		Regime:          d.Regime,
+		Confidence:      d.Confidence,
+		Rationale:       d.Rationale,
+		Approved:        d.Approved,
+		RejectionReason: d.RejectionReason,
+		Mode:            d.Mode,
+		PromptVersion:   d.PromptVersion,
+		Provider:        d.Provider,
+		Model:           d.Model,
+		TraceID:         d.TraceID,
+	}
+}
+
+func toActionResponse(a domain.AIAction) ActionResponse {
+	return ActionResponse{
+		ID:           a.ID,
+		DecisionID:   a.DecisionID,
+		ActionType:   a.ActionType,
+		Symbol:       a.Symbol,
+		Parameters:   a.Parameters,
+		Status:       a.Status,
+		RiskScore:    a.RiskScore,
+		ExecutedAt:   optionalTime(a.ExecutedAt),
+		ErrorMessage: a.ErrorMessage,
+		CreatedAt:    a.CreatedAt,
+	}
+}
+
+func toViolationResponse(v domain.PolicyViolation) ViolationResponse {
+	return ViolationResponse{
+		ID:             v.ID,
+		Timestamp:      v.Timestamp,
+		ActionID:       v.ActionID,
+		ViolationType:  v.ViolationType,
+		LimitName:      v.LimitName,
+		LimitValue:     v.LimitValue,
+		AttemptedValue: v.AttemptedValue,
+		Severity:       v.Severity,
+	}
+}
+
+func toBreakerEventResponse(e domain.CircuitBreakerEvent) BreakerEventResponse {
+	return BreakerEventResponse{
+		ID:          e.ID,
+		TriggeredAt: e.TriggeredAt,
+		Reason:      e.Reason,
+		Details:     e.Details,
+		PausedUntil: e.PausedUntil,
+		ResumedAt:   optionalTime(e.ResumedAt),
+	}
+}
+
+// convertAll maps a slice and never returns nil, so lists encode as []
+func convertAll[T, R any](items []T, convert func(T) R) []R {
+	result := make([]R, 0, len(items))
+	for _, item := range items {
+		result = append(result, convert(item))
+	}
+	return result
+}
+
+func setFloat(dst *float64, src *float64) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func setInt(dst *int, src *int) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func setBool(dst *bool, src *bool) {
+	if src != nil {
+		*dst = *src
+	}
+}
@@ -4,19 +4,43 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
 // Config содержит все настройки приложения
 type Config struct {
-	Telegram TelegramConfig
-	Bybit    BybitConfig
-	Database DatabaseConfig
-	AI       AIConfig
-	Strategy StrategyConfig
-	LogLevel string
+	Telegram  TelegramConfig
+	Bybit     BybitConfig
+	Database  DatabaseConfig
+	AI        AIConfig
+	Strategy  StrategyConfig
+	News      NewsConfig
+	Reports   ReportsConfig
+	Incidents IncidentsConfig
+	Access    AccessConfig
+	LogLevel  string
+	Logging   LoggingConfig
+	Tracing   TracingConfig
+}
+
+// LoggingConfig формат логов и сохранение WARN+ в таблицу logs
+type LoggingConfig struct {
+	Format       string        // json или text
+	PersistLevel string        // минимальный уровень для таблицы logs, пусто - не сохранять
+	Retention    time.Duration // сколько хранить записи в таблице logs, 0 - бессрочно
+}
+
+// TracingConfig экспорт трейсов OpenTelemetry (tracing.NewExporter)
+type TracingConfig struct {
+	Exporter     string // none, file или otlp
+	File         string // файл OTLP/JSON для exporter=file
+	OTLPEndpoint string // OTLP/HTTP коллектор для exporter=otlp
+	OTLPHeaders  string // заголовки коллектора: key1=value1,key2=value2
+	ServiceName  string
 }
 
 type TelegramConfig struct {
@@ -31,6 +55,8 @@ type BybitConfig struct {
 }
 
 type DatabaseConfig struct {
+	Driver          string // postgres или sqlite
+	Path            string // файл базы SQLite
 	Host            string
 	Port            int
 	User            string
@@ -56,17 +82,24 @@ type AIConfig struct {
 }
 
 type LocalAIConfig struct {
-	Enabled bool
-	URL     string
-	Model   string
-}
-
-type CloudAIConfig struct {
 	Enabled  bool
-	Provider string
+	Provider string // ollama (нативный API) или openai (OpenAI-совместимый)
 	URL      string
-	APIKey   string
 	Model    string
+	Timeout  time.Duration
+}
+
+type CloudAIConfig struct {
+	Enabled         bool
+	Provider        string // moonshot/openai (OpenAI-совместимый) или anthropic
+	URL             string
+	APIKey          string
+	Model           string
+	Timeout         time.Duration
+	MaxRetries      int
+	DailyBudgetUSD  float64 // 0 = без ограничения
+	InputPricePerM  float64 // USD за 1M входных токенов
+	OutputPricePerM float64 // USD за 1M выходных токенов
 }
 
 type RouterConfig struct {
@@ -75,6 +108,40 @@ type RouterConfig struct {
 	UseCloudForDecisions bool
 	DecisionMode        string // shadow, pilot, full
 	DecisionInterval    int    // seconds
+	DecisionMaxAttempts int    // попытки получить валидное решение (с repair)
+	FallbackToLocal     bool   // облако недоступно → локальная модель (пониженное доверие)
+	IntentMinConfidence float64 // ниже - сообщение обрабатывает chat
+	MemoryWindow        int           // последних сообщений диалога в запросе к модели
+	PendingTTL          time.Duration // время жизни неподтвержденных AI действий
+	PromptsDir          string        // каталог с версиями промптов <name>/<version>.tmpl (пусто - только встроенные)
+	DecisionPrompt      string        // активная версия промпта решений при старте (пусто - последняя)
+}
+
+type NewsConfig struct {
+	Enabled    bool
+	ConfigPath string // YAML с источниками (configs/news.yaml)
+}
+
+type ReportsConfig struct {
+	Enabled      bool
+	DeliveryTime time.Duration // время отправки от начала суток (REPORT_TIME=09:00)
+	Periods      []string      // DAILY, WEEKLY, MONTHLY
+	FeeRate      float64       // доля комиссии от объема для оценки комиссий
+}
+
+type IncidentsConfig struct {
+	Enabled  bool          // разбор сбоев локальной моделью
+	Window   time.Duration // контекст до события (логи, сделки, нарушения)
+	Cooldown time.Duration // повтор того же сбоя не разбирается
+}
+
+type AccessConfig struct {
+	OrderCaps       []string      // лимиты ордера по ролям role:USD (0 - без лимита)
+	APIIPAllowlist  []string      // IP/CIDR, с которых разрешен HTTP API (пусто - все)
+	APIRateLimit    int           // запросов в минуту на ключ по умолчанию (0 - без лимита)
+	APISignatureTTL time.Duration // окно допустимого X-Timestamp подписанного запроса
+	APITrustProxy   bool          // брать IP клиента из X-Forwarded-For
+	MetricsToken    string        // bearer токен для /metrics (пусто - без авторизации)
 }
 
 type StrategyConfig struct {
@@ -91,7 +158,7 @@ type StrategyConfig struct {
 func Load() (*Config, error) {
 	// Загружаем .env файл (если есть)
 	if err := godotenv.Load(); err != nil {
-		fmt.Println("Warning: .env file not found, using environment variables")
+		utils.LogWarn(".env file not found, using environment variables")
 	}
 
 	chatID, err := strconv.ParseInt(getEnv("TELEGRAM_CHAT_ID", "0"), 10, 64)
@@ -156,6 +223,52 @@ func Load() (*Config, error) {
 	useLocalForAnalysis, _ := strconv.ParseBool(getEnv("AI_USE_LOCAL_FOR_ANALYSIS", "true"))
 	useCloudForDecisions, _ := strconv.ParseBool(getEnv("AI_USE_CLOUD_FOR_DECISIONS", "true"))
 	decisionInterval, _ := strconv.Atoi(getEnv("AI_DECISION_INTERVAL", "3600"))
+	newsEnabled, _ := strconv.ParseBool(getEnv("NEWS_ENABLED", "false"))
+	decisionMaxAttempts, _ := strconv.Atoi(getEnv("AI_DECISION_MAX_ATTEMPTS", "3"))
+	fallbackToLocal, _ := strconv.ParseBool(getEnv("AI_FALLBACK_TO_LOCAL", "true"))
+	intentMinConfidence, _ := strconv.ParseFloat(getEnv("AI_INTENT_MIN_CONFIDENCE", "0.6"), 64)
+	memoryWindow, _ := strconv.Atoi(getEnv("AI_MEMORY_WINDOW", "12"))
+	pendingTTL, err := time.ParseDuration(getEnv("AI_PENDING_TTL", "10m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AI_PENDING_TTL: %w", err)
+	}
+	reportsEnabled, _ := strconv.ParseBool(getEnv("REPORTS_ENABLED", "true"))
+	reportTime, err := parseClock(getEnv("REPORT_TIME", "09:00"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REPORT_TIME: %w", err)
+	}
+	reportFeeRate, _ := strconv.ParseFloat(getEnv("REPORT_FEE_RATE", "0.001"), 64)
+	incidentsEnabled, _ := strconv.ParseBool(getEnv("INCIDENT_ANALYSIS_ENABLED", "true"))
+	incidentWindow, err := time.ParseDuration(getEnv("INCIDENT_WINDOW", "30m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid INCIDENT_WINDOW: %w", err)
+	}
+	incidentCooldown, err := time.ParseDuration(getEnv("INCIDENT_COOLDOWN", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid INCIDENT_COOLDOWN: %w", err)
+	}
+	localAITimeout, err := time.ParseDuration(getEnv("LOCAL_AI_TIMEOUT", "120s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOCAL_AI_TIMEOUT: %w", err)
+	}
+	cloudAITimeout, err := time.ParseDuration(getEnv("CLOUD_AI_TIMEOUT", "60s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CLOUD_AI_TIMEOUT: %w", err)
+	}
+	cloudAIMaxRetries, _ := strconv.Atoi(getEnv("CLOUD_AI_MAX_RETRIES", "2"))
+	apiRateLimit, _ := strconv.Atoi(getEnv("API_RATE_LIMIT", "60"))
+	logRetention, err := time.ParseDuration(getEnv("LOG_RETENTION", "720h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOG_RETENTION: %w", err)
+	}
+	apiSignatureTTL, err := time.ParseDuration(getEnv("API_SIGNATURE_WINDOW", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid API_SIGNATURE_WINDOW: %w", err)
+	}
+	apiTrustProxy, _ := strconv.ParseBool(getEnv("API_TRUST_PROXY", "false"))
+	cloudAIDailyBudget, _ := strconv.ParseFloat(getEnv("CLOUD_AI_DAILY_BUDGET_USD", "5"), 64)
+	cloudAIInputPrice, _ := strconv.ParseFloat(getEnv("CLOUD_AI_PRICE_INPUT_PER_M", "0.6"), 64)
+	cloudAIOutputPrice, _ := strconv.ParseFloat(getEnv("CLOUD_AI_PRICE_OUTPUT_PER_M", "2.5"), 64)
 
 	config := &Config{
 		Telegram: TelegramConfig{
@@ -168,6 +281,8 @@ func Load() (*Config, error) {
 			BaseURL:   getEnv("BYBIT_BASE_URL", "https://api.bybit.com"),
 		},
 		Database: DatabaseConfig{
+			Driver:          strings.ToLower(getEnv("DB_DRIVER", "postgres")),
+			Path:            getEnv("DB_PATH", "data/dca-bot.db"),
 			Host:            getEnv("DB_HOST", "localhost"),
 			Port:            dbPort,
 			User:            getEnv("DB_USER", "postgres"),
@@ -187,16 +302,23 @@ func Load() (*Config, error) {
 
 			// Stage 5: Dual-model setup
 			Local: LocalAIConfig{
-				Enabled: localAIEnabled,
-				URL:     getEnv("LOCAL_AI_URL", "http://localhost:11434"),
-				Model:   getEnv("LOCAL_AI_MODEL", "qwen2.5-coder:14b"),
+				Enabled:  localAIEnabled,
+				Provider: getEnv("LOCAL_AI_PROVIDER", "ollama"),
+				URL:      getEnv("LOCAL_AI_URL", "http://localhost:11434"),
+				Model:    getEnv("LOCAL_AI_MODEL", "qwen2.5-coder:14b"),
+				Timeout:  localAITimeout,
 			},
 			Cloud: CloudAIConfig{
-				Enabled:  cloudAIEnabled,
-				Provider: getEnv("CLOUD_AI_PROVIDER", "moonshot"),
-				URL:      getEnv("CLOUD_AI_URL", "https://api.moonshot.ai"),
-				APIKey:   getEnv("CLOUD_AI_KEY", ""),
-				Model:    getEnv("CLOUD_AI_MODEL", "kimi-k2-turbo-preview"),
+				Enabled:         cloudAIEnabled,
+				Provider:        getEnv("CLOUD_AI_PROVIDER", "moonshot"),
+				URL:             getEnv("CLOUD_AI_URL", "https://api.moonshot.ai"),
+				APIKey:          getEnv("CLOUD_AI_KEY", ""),
+				Model:           getEnv("CLOUD_AI_MODEL", "kimi-k2-turbo-preview"),
+				Timeout:         cloudAITimeout,
+				MaxRetries:      cloudAIMaxRetries,
+				DailyBudgetUSD:  cloudAIDailyBudget,
+				InputPricePerM:  cloudAIInputPrice,
+				OutputPricePerM: cloudAIOutputPrice,
 			},
 			Router: RouterConfig{
 				UseLocalForChat:      useLocalForChat,
@@ -204,6 +326,13 @@ func Load() (*Config, error) {
 				UseCloudForDecisions: useCloudForDecisions,
 				DecisionMode:         getEnv("AI_DECISION_MODE", "pilot"),
 				DecisionInterval:     decisionInterval,
+				DecisionMaxAttempts:  decisionMaxAttempts,
+				FallbackToLocal:      fallbackToLocal,
+				IntentMinConfidence:  intentMinConfidence,
+				MemoryWindow:         memoryWindow,
+				PendingTTL:           pendingTTL,
+				PromptsDir:           getEnv("AI_PROMPTS_DIR", ""),
+				DecisionPrompt:       getEnv("AI_DECISION_PROMPT", ""),
 			},
 		},
 		Strategy: StrategyConfig{
@@ -215,7 +344,42 @@ func Load() (*Config, error) {
 			AutoSellAmountPercent:  autoSellAmount,
 			PriceCheckInterval:     priceCheckInterval,
 		},
+		News: NewsConfig{
+			Enabled:    newsEnabled,
+			ConfigPath: getEnv("NEWS_CONFIG_PATH", "configs/news.yaml"),
+		},
+		Reports: ReportsConfig{
+			Enabled:      reportsEnabled,
+			DeliveryTime: reportTime,
+			Periods:      splitList(getEnv("REPORT_PERIODS", "DAILY,WEEKLY")),
+			FeeRate:      reportFeeRate,
+		},
+		Incidents: IncidentsConfig{
+			Enabled:  incidentsEnabled,
+			Window:   incidentWindow,
+			Cooldown: incidentCooldown,
+		},
+		Access: AccessConfig{
+			OrderCaps:       splitList(getEnv("RBAC_ORDER_CAPS", "trader:100,admin:0")),
+			APIIPAllowlist:  splitList(getEnv("API_IP_ALLOWLIST", "")),
+			APIRateLimit:    apiRateLimit,
+			APISignatureTTL: apiSignatureTTL,
+			APITrustProxy:   apiTrustProxy,
+			MetricsToken:    getEnv("METRICS_TOKEN", ""),
+		},
 		LogLevel: getEnv("LOG_LEVEL", "info"),
+		Logging: LoggingConfig{
+			Format:       getEnv("LOG_FORMAT", "json"),
+			PersistLevel: getEnv("LOG_PERSIST_LEVEL", "warn"),
+			Retention:    logRetention,
+		},
+		Tracing: TracingConfig{
+			Exporter:     getEnv("TRACING_EXPORTER", "none"),
+			File:         getEnv("TRACING_FILE", "traces.jsonl"),
+			OTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+			OTLPHeaders:  getEnv("OTEL_EXPORTER_OTLP_HEADERS", ""),
+			ServiceName:  getEnv("OTEL_SERVICE_NAME", "dca-bot"),
+		},
 	}
 
 	if err := config.Validate(); err != nil {
@@ -236,8 +400,22 @@ func (c *Config) Validate() error {
 	if c.Bybit.APISecret == "" {
 		return fmt.Errorf("BYBIT_API_SECRET is required")
 	}
-	if c.Database.Password == "" {
-		return fmt.Errorf("DB_PASSWORD is required")
+	switch c.Database.Driver {
+	case "postgres":
+		if c.Database.Password == "" {
+			return fmt.Errorf("DB_PASSWORD is required")
+		}
+	case "sqlite":
+		if c.Database.Path == "" {
+			return fmt.Errorf("DB_PATH is required for sqlite")
+		}
+	default:
+		return fmt.Errorf("invalid DB_DRIVER %q (valid: postgres, sqlite)", c.Database.Driver)
+	}
+	switch strings.ToLower(c.Tracing.Exporter) {
+	case "none", "file", "otlp":
+	default:
+		return fmt.Errorf("invalid TRACING_EXPORTER %q (valid: none, file, otlp)", c.Tracing.Exporter)
 	}
 	return nil
 }
@@ -248,3 +426,23 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// parseClock разбирает время суток HH:MM в смещение от полуночи
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// splitList разбирает список через запятую в верхнем регистре
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToUpper(strings.TrimSpace(item)); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -31,6 +31,16 @@ const (
 	SnapshotMonthly = "MONTHLY"
 )
 
+// Candle intervals
+const (
+	Interval1m  = "1m"
+	Interval5m  = "5m"
+	Interval15m = "15m"
+	Interval1h  = "1h"
+	Interval4h  = "4h"
+	Interval1d  = "1d"
+)
+
 // Log levels
 const (
 	LogLevelInfo  = "INFO"
@@ -55,3 +65,11 @@ const (
 	BybitAccountUnified = "UNIFIED"
 	BybitRecvWindow     = "5000"
 )
+
+// User roles
+const (
+	RoleViewer      = "viewer"
+	RoleTrader      = "trader"
+	RoleRiskOfficer = "risk_officer"
+	RoleAdmin       = "admin"
+)
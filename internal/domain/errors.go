@@ -26,4 +26,7 @@ var (
 
 	// ErrDatabaseConnection возвращается при ошибке подключения к БД
 	ErrDatabaseConnection = errors.New("database connection error")
+
+	// ErrLastAdmin возвращается при попытке удалить или понизить последнего админа
+	ErrLastAdmin = errors.New("cannot remove the last admin")
 )
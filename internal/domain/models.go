@@ -15,6 +15,18 @@ type Trade struct {
 	StrategyType string    `db:"strategy_type"` // "DCA", "GRID", "AUTO_SELL"
 	GridLevel    int       `db:"grid_level"`    // для Grid стратегии
 	CreatedAt    time.Time `db:"created_at"`
+	TraceID      string    `db:"trace_id"` // трейс OpenTelemetry, в котором размещен ордер
+}
+
+// TradeFilter фильтр и страница выборки сделок (пустые поля не фильтруют)
+type TradeFilter struct {
+	Symbol   string
+	Side     string
+	Strategy string
+	From     time.Time
+	To       time.Time
+	Limit    int
+	Offset   int
 }
 
 // Balance представляет баланс актива
@@ -127,6 +139,19 @@ type AIDecision struct {
 	Approved        bool      `db:"approved"`
 	RejectionReason string    `db:"rejection_reason"`
 	Mode            string    `db:"mode"` // shadow, pilot, full
+	PromptVersion   string    `db:"prompt_version"` // версия шаблона решений (v1, v2)
+	Provider        string    `db:"provider"`
+	Model           string    `db:"model"`
+	TraceID         string    `db:"trace_id"` // трейс цикла решения
+}
+
+// PromptVersionStats статистика решений по версии промпта и модели (A/B тесты)
+type PromptVersionStats struct {
+	PromptVersion string
+	Model         string
+	Decisions     int
+	Approved      int
+	AvgConfidence float64
 }
 
 // AIAction представляет действие, запланированное AI
@@ -157,6 +182,7 @@ type NewsSignal struct {
 	Signal         string    `db:"signal"` // BUY, SELL, HOLD
 	Symbols        []string  `db:"symbols"`
 	Processed      bool      `db:"processed"`
+	IngestedAt     time.Time `db:"ingested_at"` // время загрузки (окно дедупликации)
 }
 
 // CircuitBreakerEvent представляет событие триггера circuit breaker
@@ -180,3 +206,138 @@ type PolicyViolation struct {
 	AttemptedValue float64   `db:"attempted_value"`
 	Severity       string    `db:"severity"` // warning, critical
 }
+
+// Candle представляет свечу (kline) по символу и таймфрейму
+type Candle struct {
+	ID        int64     `db:"id"`
+	Symbol    string    `db:"symbol"`
+	Interval  string    `db:"interval"` // 1m, 5m, 15m, 1h, 4h, 1d
+	OpenTime  time.Time `db:"open_time"`
+	Open      float64   `db:"open"`
+	High      float64   `db:"high"`
+	Low       float64   `db:"low"`
+	Close     float64   `db:"close"`
+	Volume    float64   `db:"volume"`
+	Turnover  float64   `db:"turnover"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// LLMUsage представляет учет одного вызова LLM (токены и стоимость)
+type LLMUsage struct {
+	ID               int64     `db:"id"`
+	Timestamp        time.Time `db:"timestamp"`
+	Provider         string    `db:"provider"`
+	Model            string    `db:"model"`
+	Purpose          string    `db:"purpose"` // chat, analysis, decision, news
+	PromptTokens     int       `db:"prompt_tokens"`
+	CompletionTokens int       `db:"completion_tokens"`
+	CostUSD          float64   `db:"cost_usd"`
+	LatencyMs        int64     `db:"latency_ms"`
+	Cloud            bool      `db:"cloud"`
+	Success          bool      `db:"success"`
+	ReducedTrust     bool      `db:"reduced_trust"` // ответ резервной модели
+	Error            string    `db:"error"`
+}
+
+// RoutingOutcome представляет результат маршрутизации сообщения в AgentRouter
+type RoutingOutcome struct {
+	ID         int64     `db:"id"`
+	Timestamp  time.Time `db:"timestamp"`
+	Message    string    `db:"message"`
+	Intent     string    `db:"intent"`     // классифицированное намерение
+	Agent      string    `db:"agent"`      // фактически использованный агент
+	Confidence float64   `db:"confidence"`
+	Method     string    `db:"method"`     // llm, keywords
+	Fallback   bool      `db:"fallback"`   // низкая уверенность → chat
+	Success    bool      `db:"success"`
+	Error      string    `db:"error"`
+	LatencyMs  int64     `db:"latency_ms"`
+}
+
+// ConversationMessage представляет сообщение в диалоге пользователя с AI
+type ConversationMessage struct {
+	ID        int64     `db:"id"`
+	UserID    int64     `db:"user_id"`
+	Role      string    `db:"role"` // user, assistant
+	Content   string    `db:"content"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// ConversationState представляет сжатую историю и ожидающие подтверждения пользователя
+type ConversationState struct {
+	UserID          int64     `db:"user_id"`
+	Summary         string    `db:"summary"`           // краткое содержание старых сообщений
+	SummarizedUntil int64     `db:"summarized_until"`  // ID последнего сообщения в summary
+	PendingActions  string    `db:"pending_actions"`   // JSON tool calls, ожидающих подтверждения
+	PendingAt       time.Time `db:"pending_at"`
+	UpdatedAt       time.Time `db:"updated_at"`
+}
+
+// PortfolioReport сгенерированный отчет по портфелю за период
+type PortfolioReport struct {
+	ID          int64     `db:"id"`
+	Period      string    `db:"period"` // DAILY, WEEKLY, MONTHLY
+	PeriodStart time.Time `db:"period_start"`
+	PeriodEnd   time.Time `db:"period_end"`
+	Content     string    `db:"content"`   // текст, отправленный в Telegram
+	Narrative   string    `db:"narrative"` // резюме от локальной модели
+	Delivered   bool      `db:"delivered"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// Incident разобранный сбой (неудачная сделка, срабатывание breaker) с выводом локальной модели
+type Incident struct {
+	ID           int64     `db:"id"`
+	Kind         string    `db:"kind"`   // dca_failed, execution_failed, circuit_breaker
+	Source       string    `db:"source"` // стратегия или компонент, где произошел сбой
+	Symbol       string    `db:"symbol"`
+	Error        string    `db:"error"`
+	Context      string    `db:"context"`    // собранные логи, сделки, нарушения
+	RootCause    string    `db:"root_cause"` // пусто, если модель недоступна
+	SuggestedFix string    `db:"suggested_fix"`
+	Model        string    `db:"model"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// User пользователь бота и API с ролью (viewer, trader, risk_officer, admin)
+type User struct {
+	ID         int64     `db:"id"`
+	TelegramID int64     `db:"telegram_id"`
+	Name       string    `db:"name"`
+	Role       string    `db:"role"`
+	CreatedAt  time.Time `db:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at"`
+}
+
+// APIKey ключ доступа к HTTP API; права - по роли владельца
+type APIKey struct {
+	ID         int64      `db:"id"`
+	UserID     int64      `db:"user_id"`
+	Name       string     `db:"name"`
+	Prefix     string     `db:"prefix"`   // первые символы ключа для отображения
+	KeyHash    string     `db:"key_hash"` // SHA-256, сам ключ не хранится
+	Revoked    bool       `db:"revoked"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+
+	AllowedIPs       []string `db:"allowed_ips"`       // IP/CIDR клиента (пусто - любые)
+	RateLimit        int      `db:"rate_limit"`        // запросов в минуту (0 - по умолчанию)
+	SigningSecret    string   `db:"signing_secret"`    // секрет HMAC подписи запросов
+	RequireSignature bool     `db:"require_signature"` // запросы без подписи отклоняются
+}
+
+// APIAuditEntry запись аудита изменяющего вызова HTTP API
+type APIAuditEntry struct {
+	ID         int64     `db:"id"`
+	CreatedAt  time.Time `db:"created_at"`
+	KeyID      int64     `db:"key_id"`      // 0 - без ключа
+	TelegramID int64     `db:"telegram_id"` // владелец ключа
+	Role       string    `db:"role"`
+	RemoteIP   string    `db:"remote_ip"`
+	Method     string    `db:"method"`
+	Path       string    `db:"path"`
+	Params     string    `db:"params"` // query и тело запроса
+	Status     int       `db:"status"`
+	Result     string    `db:"result"` // ошибка или "ok"
+	DurationMs int64     `db:"duration_ms"`
+}
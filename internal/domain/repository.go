@@ -1,56 +1,98 @@
 package domain
 
+import (
+	"context"
+	"time"
+)
+
 // TradeRepository определяет интерфейс для работы с торговыми операциями
 type TradeRepository interface {
-	Save(trade *Trade) error
-	GetRecent(symbol string, limit int) ([]Trade, error)
-	GetAllRecent(limit int) ([]Trade, error)
+	Save(ctx context.Context, trade *Trade) error
+	GetRecent(ctx context.Context, symbol string, limit int) ([]Trade, error)
+	GetAllRecent(ctx context.Context, limit int) ([]Trade, error)
+	GetBetween(ctx context.Context, from, to time.Time) ([]Trade, error)
+	Find(ctx context.Context, filter TradeFilter) ([]Trade, int, error)
 }
 
 // BalanceRepository определяет интерфейс для работы с балансами
 type BalanceRepository interface {
-	Get(symbol string) (*Balance, error)
-	GetAll() ([]Balance, error)
-	Update(balance *Balance) error
+	Get(ctx context.Context, symbol string) (*Balance, error)
+	// GetForUpdate получает баланс и блокирует его до конца транзакции (чтение перед Update)
+	GetForUpdate(ctx context.Context, symbol string) (*Balance, error)
+	GetAll(ctx context.Context) ([]Balance, error)
+	Update(ctx context.Context, balance *Balance) error
 }
 
 // AssetRepository определяет интерфейс для работы с активами
 type AssetRepository interface {
-	CreateOrUpdate(asset *Asset) error
-	Get(symbol string) (*Asset, error)
-	GetEnabled() ([]Asset, error)
-	GetAll() ([]Asset, error)
-	Disable(symbol string) error
-	Enable(symbol string) error
+	CreateOrUpdate(ctx context.Context, asset *Asset) error
+	Get(ctx context.Context, symbol string) (*Asset, error)
+	GetEnabled(ctx context.Context) ([]Asset, error)
+	GetAll(ctx context.Context) ([]Asset, error)
+	Disable(ctx context.Context, symbol string) error
+	Enable(ctx context.Context, symbol string) error
+	Delete(ctx context.Context, symbol string) (bool, error)
 }
 
 // GridOrderRepository определяет интерфейс для работы с Grid ордерами
 type GridOrderRepository interface {
-	Save(order *GridOrder) error
-	Update(order *GridOrder) error
-	GetActive(symbol string) ([]GridOrder, error)
-	CancelAll(symbol string) error
+	Save(ctx context.Context, order *GridOrder) error
+	Update(ctx context.Context, order *GridOrder) error
+	GetActive(ctx context.Context, symbol string) ([]GridOrder, error)
+	CancelAll(ctx context.Context, symbol string) error
 }
 
 // PnLRepository определяет интерфейс для работы с PnL историей
 type PnLRepository interface {
-	SaveSnapshot(pnl *PnLHistory) error
-	GetHistory(symbol string, snapshotType string, limit int) ([]PnLHistory, error)
+	SaveSnapshot(ctx context.Context, pnl *PnLHistory) error
+	GetHistory(ctx context.Context, symbol string, snapshotType string, limit int) ([]PnLHistory, error)
+	GetPortfolioHistory(ctx context.Context, snapshotType string, limit int) ([]PnLHistory, error)
+	GetLatestBefore(ctx context.Context, t time.Time) ([]PnLHistory, error)
+	GetLastSnapshotTime(ctx context.Context, snapshotType string) (time.Time, error)
 }
 
 // RiskRepository определяет интерфейс для работы с лимитами риска
 type RiskRepository interface {
-	GetLimits() (*RiskLimit, error)
-	UpdateLimits(limits *RiskLimit) error
+	GetLimits(ctx context.Context) (*RiskLimit, error)
+	UpdateLimits(ctx context.Context, limits *RiskLimit) error
 }
 
 // ConfigRepository определяет интерфейс для работы с конфигурацией
 type ConfigRepository interface {
-	Set(key, value string) error
-	Get(key string) (string, error)
+	Set(ctx context.Context, key, value string) error
+	Get(ctx context.Context, key string) (string, error)
 }
 
 // LogRepository определяет интерфейс для работы с логами
 type LogRepository interface {
-	Save(level, message, data string) error
+	Save(ctx context.Context, level, message, data string) error
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// CandleRepository определяет интерфейс для работы со свечами
+type CandleRepository interface {
+	SaveBatch(ctx context.Context, candles []Candle) error
+	GetRecent(ctx context.Context, symbol, interval string, limit int) ([]Candle, error)
+	GetRange(ctx context.Context, symbol, interval string, from, to time.Time) ([]Candle, error)
+	DeleteOlderThan(ctx context.Context, interval string, before time.Time) (int64, error)
+}
+
+// Repositories репозитории, работающие поверх одного соединения или одной транзакции
+type Repositories interface {
+	Trades() TradeRepository
+	Balances() BalanceRepository
+	Assets() AssetRepository
+	GridOrders() GridOrderRepository
+	PnL() PnLRepository
+	Risk() RiskRepository
+	Config() ConfigRepository
+	Logs() LogRepository
+	Candles() CandleRepository
+}
+
+// UnitOfWork хранилище с транзакциями: изменения внутри WithinTx применяются атомарно.
+// fn получает репозитории транзакции; ошибка или паника в fn откатывает все изменения
+type UnitOfWork interface {
+	Repositories
+	WithinTx(ctx context.Context, fn func(tx Repositories) error) error
 }
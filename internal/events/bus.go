@@ -0,0 +1,193 @@
+package events
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Типы событий; топик - часть до точки
+const (
+	TradeFilled       = "trade.filled"
+	OrderPlaced       = "order.placed"
+	GridLevelFilled   = "grid.level_filled"
+	BreakerTripped    = "breaker.tripped"
+	AIDecision        = "ai.decision"
+	ModeChanged       = "mode.changed"
+	KillSwitchChanged = "kill_switch.changed"
+)
+
+// Topics известные топики для фильтрации подписки
+var Topics = []string{"trade", "order", "grid", "breaker", "ai", "mode", "kill_switch"}
+
+// IsKnownTopic проверяет топик или полный тип события
+func IsKnownTopic(value string) bool {
+	for _, topic := range Topics {
+		if value == topic {
+			return true
+		}
+	}
+	for _, eventType := range []string{TradeFilled, OrderPlaced, GridLevelFilled, BreakerTripped, AIDecision, ModeChanged, KillSwitchChanged} {
+		if value == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	DefaultHistorySize = 1000
+	DefaultBufferSize  = 64
+)
+
+// Event событие бота с возрастающим ID для replay
+type Event struct {
+	ID    uint64      `json:"id"`
+	Type  string      `json:"type"`
+	Topic string      `json:"topic"`
+	Time  time.Time   `json:"time"`
+	Data  interface{} `json:"data"`
+}
+
+// Topic возвращает топик типа события: trade.filled -> trade
+func Topic(eventType string) string {
+	topic, _, _ := strings.Cut(eventType, ".")
+	return topic
+}
+
+// Bus шина событий в памяти: хранит последние события для replay
+// и раздает новые подписчикам. Медленный подписчик отключается,
+// клиент переподключается с Last-Event-ID и догоняет пропущенное
+type Bus struct {
+	mu          sync.Mutex
+	lastID      uint64
+	history     []Event
+	historySize int
+	subs        map[*Subscription]struct{}
+}
+
+// NewBus создает шину; historySize - сколько событий доступно для replay
+func NewBus(historySize int) *Bus {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Bus{
+		historySize: historySize,
+		subs:        make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish публикует событие всем подписчикам его топика
+func (b *Bus) Publish(eventType string, data interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := Event{
+		ID:    b.lastID,
+		Type:  eventType,
+		Topic: Topic(eventType),
+		Time:  time.Now(),
+		Data:  data,
+	}
+
+	b.history = append(b.history, event)
+	if len(b.history) > b.historySize {
+		b.history = b.history[len(b.history)-b.historySize:]
+	}
+
+	for sub := range b.subs {
+		if !sub.matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// Буфер полон - отключаем, клиент догонит через replay
+			sub.lagged = true
+			b.removeLocked(sub)
+		}
+	}
+}
+
+// Subscribe подписывает на топики (пусто - все) и возвращает события после lastID.
+// Replay и подписка атомарны: событие не потеряется и не придет дважды
+func (b *Bus) Subscribe(topics []string, lastID uint64) (*Subscription, []Event) {
+	sub := &Subscription{
+		events: make(chan Event, DefaultBufferSize),
+		bus:    b,
+	}
+	if len(topics) > 0 {
+		sub.topics = make(map[string]bool, len(topics))
+		for _, topic := range topics {
+			sub.topics[strings.ToLower(strings.TrimSpace(topic))] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Event
+	if lastID > 0 {
+		for _, event := range b.history {
+			if event.ID > lastID && sub.matches(event) {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	b.subs[sub] = struct{}{}
+	return sub, replay
+}
+
+// LastID ID последнего опубликованного события
+func (b *Bus) LastID() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastID
+}
+
+// Subscribers число активных подписчиков
+func (b *Bus) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+func (b *Bus) removeLocked(sub *Subscription) {
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.events)
+	}
+}
+
+// Subscription подписка на события шины
+type Subscription struct {
+	events chan Event
+	topics map[string]bool // nil - все топики
+	lagged bool
+	bus    *Bus
+}
+
+// Events канал событий; закрывается при Close или отставании подписчика
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Lagged подписчик отключен, потому что не успевал читать
+func (s *Subscription) Lagged() bool {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	return s.lagged
+}
+
+// Close отписывает от шины
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.removeLocked(s)
+}
+
+func (s *Subscription) matches(event Event) bool {
+	return s.topics == nil || s.topics[event.Topic] || s.topics[event.Type]
+}
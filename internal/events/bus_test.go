@@ -0,0 +1,113 @@
+package events
+
+import "testing"
+
+func drain(sub *Subscription) []Event {
+	var result []Event
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return result
+			}
+			result = append(result, event)
+		default:
+			return result
+		}
+	}
+}
+
+func TestBus_TopicFilter(t *testing.T) {
+	bus := NewBus(10)
+	trades, _ := bus.Subscribe([]string{"trade", "mode.changed"}, 0)
+	all, _ := bus.Subscribe(nil, 0)
+	defer trades.Close()
+	defer all.Close()
+
+	bus.Publish(TradeFilled, TradeData{Symbol: "BTCUSDT"})
+	bus.Publish(GridLevelFilled, GridFillData{Symbol: "BTCUSDT", Level: -1})
+	bus.Publish(ModeChanged, ModeData{From: "pilot", To: "shadow"})
+
+	got := drain(trades)
+	if len(got) != 2 || got[0].Type != TradeFilled || got[0].Topic != "trade" || got[1].Type != ModeChanged {
+		t.Errorf("filtered = %+v", got)
+	}
+	if got := drain(all); len(got) != 3 || got[2].ID != 3 {
+		t.Errorf("all = %+v", got)
+	}
+}
+
+func TestBus_Replay(t *testing.T) {
+	bus := NewBus(3)
+	for i := 0; i < 5; i++ {
+		bus.Publish(OrderPlaced, OrderData{GridLevel: i})
+	}
+
+	// В истории только последние 3 события
+	sub, replay := bus.Subscribe(nil, 1)
+	if len(replay) != 3 || replay[0].ID != 3 || replay[2].ID != 5 {
+		t.Errorf("replay = %+v", replay)
+	}
+	sub.Close()
+
+	sub, replay = bus.Subscribe([]string{"order"}, 4)
+	if len(replay) != 1 || replay[0].ID != 5 {
+		t.Errorf("replay after 4 = %+v", replay)
+	}
+
+	// Без Last-Event-ID - только новые события
+	fresh, replay := bus.Subscribe(nil, 0)
+	if len(replay) != 0 {
+		t.Errorf("replay without id = %+v", replay)
+	}
+	bus.Publish(AIDecision, DecisionData{Regime: "DEFENSE"})
+	if got := drain(fresh); len(got) != 1 || got[0].ID != 6 {
+		t.Errorf("fresh = %+v", got)
+	}
+	if got := drain(sub); len(got) != 0 {
+		t.Errorf("order subscriber got %+v", got)
+	}
+
+	sub.Close()
+	fresh.Close()
+	fresh.Close() // повторный Close безопасен
+	if bus.Subscribers() != 0 {
+		t.Errorf("subscribers = %d", bus.Subscribers())
+	}
+}
+
+func TestBus_LaggedSubscriber(t *testing.T) {
+	bus := NewBus(DefaultBufferSize * 2)
+	slow, _ := bus.Subscribe(nil, 0)
+
+	for i := 0; i <= DefaultBufferSize; i++ {
+		bus.Publish(TradeFilled, TradeData{})
+	}
+
+	if !slow.Lagged() || bus.Subscribers() != 0 {
+		t.Fatalf("lagged = %v, subscribers = %d", slow.Lagged(), bus.Subscribers())
+	}
+
+	got := drain(slow)
+	if len(got) != DefaultBufferSize {
+		t.Errorf("buffered = %d", len(got))
+	}
+
+	// Переподключение с последним полученным ID догоняет пропущенное
+	_, replay := bus.Subscribe(nil, got[len(got)-1].ID)
+	if len(replay) != 1 || replay[0].ID != DefaultBufferSize+1 {
+		t.Errorf("replay = %+v", replay)
+	}
+	slow.Close()
+}
+
+func TestIsKnownTopic(t *testing.T) {
+	for _, value := range []string{"trade", "kill_switch", "grid.level_filled"} {
+		if !IsKnownTopic(value) {
+			t.Errorf("%s unknown", value)
+		}
+	}
+	if IsKnownTopic("trades") || Topic(BreakerTripped) != "breaker" {
+		t.Error("topic parsing")
+	}
+}
@@ -0,0 +1,66 @@
+package events
+
+import "time"
+
+// TradeData исполненная сделка (trade.filled)
+type TradeData struct {
+	TradeID   int64   `json:"trade_id,omitempty"`
+	Symbol    string  `json:"symbol"`
+	Side      string  `json:"side"`
+	Strategy  string  `json:"strategy"`
+	Quantity  float64 `json:"quantity"`
+	Price     float64 `json:"price"`
+	AmountUSD float64 `json:"amount_usd"`
+	OrderID   string  `json:"order_id,omitempty"`
+}
+
+// OrderData размещенный ордер (order.placed)
+type OrderData struct {
+	Symbol    string  `json:"symbol"`
+	Side      string  `json:"side"`
+	Strategy  string  `json:"strategy"`
+	Quantity  float64 `json:"quantity"`
+	Price     float64 `json:"price,omitempty"`
+	OrderID   string  `json:"order_id,omitempty"`
+	GridLevel int     `json:"grid_level,omitempty"`
+}
+
+// GridFillData исполненный уровень сетки (grid.level_filled)
+type GridFillData struct {
+	Symbol   string  `json:"symbol"`
+	Level    int     `json:"level"`
+	Side     string  `json:"side"`
+	Quantity float64 `json:"quantity"`
+	Price    float64 `json:"price"`
+	OrderID  string  `json:"order_id,omitempty"`
+}
+
+// BreakerData срабатывание circuit breaker (breaker.tripped)
+type BreakerData struct {
+	Reason      string    `json:"reason"`
+	PausedUntil time.Time `json:"paused_until"`
+}
+
+// DecisionData решение AI (ai.decision)
+type DecisionData struct {
+	Mode          string  `json:"mode"`
+	Regime        string  `json:"regime"`
+	Confidence    float64 `json:"confidence"`
+	Actions       int     `json:"actions"`
+	Rationale     string  `json:"rationale"`
+	PromptVersion string  `json:"prompt_version,omitempty"`
+	Provider      string  `json:"provider,omitempty"`
+}
+
+// ModeData смена режима автономии (mode.changed)
+type ModeData struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// KillSwitchData включение или выключение kill switch (kill_switch.changed)
+type KillSwitchData struct {
+	Active bool   `json:"active"`
+	Reason string `json:"reason,omitempty"`
+}
@@ -14,9 +14,14 @@ import (
 	"time"
 
 	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/metrics"
+	"github.com/kirillm/dca-bot/internal/tracing"
+	"github.com/kirillm/dca-bot/pkg/utils"
 	"golang.org/x/time/rate"
 )
 
+var logger = utils.DefaultLogger().With("component", "bybit")
+
 type BybitClient struct {
 	apiKey           string
 	apiSecret        string
@@ -64,6 +69,26 @@ type OrderResponse struct {
 	} `json:"result"`
 }
 
+type KlineResponse struct {
+	RetCode int    `json:"retCode"`
+	RetMsg  string `json:"retMsg"`
+	Result  struct {
+		Symbol   string     `json:"symbol"`
+		Category string     `json:"category"`
+		List     [][]string `json:"list"` // [startTime, open, high, low, close, volume, turnover]
+	} `json:"result"`
+}
+
+// bybitIntervals соответствие таймфреймов domain и Bybit API
+var bybitIntervals = map[string]string{
+	domain.Interval1m:  "1",
+	domain.Interval5m:  "5",
+	domain.Interval15m: "15",
+	domain.Interval1h:  "60",
+	domain.Interval4h:  "240",
+	domain.Interval1d:  "D",
+}
+
 type OrderInfo struct {
 	OrderID       string
 	ClientOrderID string
@@ -91,20 +116,41 @@ func NewBybitClient(apiKey, apiSecret, baseURL string) *BybitClient {
 }
 
 // doWithRetry executes HTTP request with exponential backoff retry logic
-func (b *BybitClient) doWithRetry(req *http.Request) (*http.Response, error) {
-	var resp *http.Response
-	var err error
+func (b *BybitClient) doWithRetry(req *http.Request) (resp *http.Response, err error) {
+	endpoint := req.URL.Path
+	start := time.Now()
+
+	ctx, span := tracing.Start(req.Context(), "bybit "+req.Method+" "+endpoint, tracing.KindClient,
+		tracing.String("http.method", req.Method), tracing.String("url.path", endpoint))
+	req = req.WithContext(ctx)
+	defer func() {
+		metrics.ExchangeRequestDuration.With(endpoint).Observe(metrics.Since(start))
+		if resp != nil {
+			span.SetAttributes(tracing.Int("http.status_code", resp.StatusCode))
+		}
+		span.RecordError(err)
+		span.End()
+	}()
 
 	for attempt := 0; attempt < b.maxRetries; attempt++ {
+		if attempt > 0 {
+			metrics.ExchangeRetriesTotal.With(endpoint).Inc()
+			span.SetAttributes(tracing.Int("http.retry", attempt))
+		}
+
 		// Rate limiting: wait for permission to make request
-		if err := b.rateLimiter.Wait(context.Background()); err != nil {
+		waitStart := time.Now()
+		if err := b.rateLimiter.Wait(ctx); err != nil {
+			metrics.ExchangeRequestsTotal.With(endpoint, "error").Inc()
 			return nil, fmt.Errorf("rate limiter error: %w", err)
 		}
+		metrics.RateLimitWait.Observe(metrics.Since(waitStart))
 
 		resp, err = b.client.Do(req)
 
 		// Success if no error and not a 5xx server error
 		if err == nil && resp.StatusCode < 500 {
+			metrics.ExchangeRequestsTotal.With(endpoint, "ok").Inc()
 			return resp, nil
 		}
 
@@ -121,12 +167,12 @@ func (b *BybitClient) doWithRetry(req *http.Request) (*http.Response, error) {
 		// Calculate exponential backoff: initialDelay * 2^attempt
 		backoff := b.initialRetryDelay * time.Duration(1<<uint(attempt))
 
-		// Log retry attempt (in production, use proper logger)
+		retryLogger := logger.WithContext(req.Context()).With("endpoint", endpoint)
 		if err != nil {
-			fmt.Printf("Request failed (attempt %d/%d): %v. Retrying in %v...\n",
+			retryLogger.Warn("Request failed (attempt %d/%d): %v. Retrying in %v...",
 				attempt+1, b.maxRetries, err, backoff)
 		} else {
-			fmt.Printf("Request failed with status %d (attempt %d/%d). Retrying in %v...\n",
+			retryLogger.Warn("Request failed with status %d (attempt %d/%d). Retrying in %v...",
 				resp.StatusCode, attempt+1, b.maxRetries, backoff)
 		}
 
@@ -134,6 +180,7 @@ func (b *BybitClient) doWithRetry(req *http.Request) (*http.Response, error) {
 	}
 
 	// Return last error
+	metrics.ExchangeRequestsTotal.With(endpoint, "error").Inc()
 	if err != nil {
 		return nil, fmt.Errorf("request failed after %d retries: %w", b.maxRetries, err)
 	}
@@ -142,12 +189,17 @@ func (b *BybitClient) doWithRetry(req *http.Request) (*http.Response, error) {
 
 // GetPrice получает текущую цену актива
 func (b *BybitClient) GetPrice(symbol string) (float64, error) {
+	return b.GetPriceContext(context.Background(), symbol)
+}
+
+// GetPriceContext GetPrice с контекстом: спан запроса становится дочерним к спану из ctx
+func (b *BybitClient) GetPriceContext(ctx context.Context, symbol string) (float64, error) {
 	endpoint := "/v5/market/tickers"
 	params := fmt.Sprintf("category=%s&symbol=%s", domain.BybitCategorySpot, symbol)
 
 	url := fmt.Sprintf("%s%s?%s", b.baseURL, endpoint, params)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -189,8 +241,100 @@ func (b *BybitClient) GetPrice(symbol string) (float64, error) {
 	return price, nil
 }
 
+// GetKlines получает свечи по символу и таймфрейму (от старых к новым)
+func (b *BybitClient) GetKlines(symbol, interval string, limit int) ([]domain.Candle, error) {
+	bybitInterval, ok := bybitIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("unsupported interval: %s", interval)
+	}
+
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+
+	endpoint := "/v5/market/kline"
+	params := fmt.Sprintf("category=%s&symbol=%s&interval=%s&limit=%d",
+		domain.BybitCategorySpot, symbol, bybitInterval, limit)
+
+	url := fmt.Sprintf("%s%s?%s", b.baseURL, endpoint, params)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := b.doWithRetry(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var klineResp KlineResponse
+	if err := json.Unmarshal(body, &klineResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if klineResp.RetCode != 0 {
+		return nil, fmt.Errorf("%w: %s", domain.ErrExchangeAPI, klineResp.RetMsg)
+	}
+
+	// Bybit возвращает свечи от новых к старым - разворачиваем
+	candles := make([]domain.Candle, 0, len(klineResp.Result.List))
+	for i := len(klineResp.Result.List) - 1; i >= 0; i-- {
+		candle, err := parseKline(symbol, interval, klineResp.Result.List[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse kline for %s: %w", symbol, err)
+		}
+		candles = append(candles, candle)
+	}
+
+	return candles, nil
+}
+
+// parseKline преобразует строку ответа Bybit в свечу
+func parseKline(symbol, interval string, row []string) (domain.Candle, error) {
+	if len(row) < 7 {
+		return domain.Candle{}, fmt.Errorf("unexpected kline format: %v", row)
+	}
+
+	startMs, err := strconv.ParseInt(row[0], 10, 64)
+	if err != nil {
+		return domain.Candle{}, err
+	}
+
+	values := make([]float64, 6)
+	for i := range values {
+		values[i], err = strconv.ParseFloat(row[i+1], 64)
+		if err != nil {
+			return domain.Candle{}, err
+		}
+	}
+
+	return domain.Candle{
+		Symbol:   symbol,
+		Interval: interval,
+		OpenTime: time.UnixMilli(startMs).UTC(),
+		Open:     values[0],
+		High:     values[1],
+		Low:      values[2],
+		Close:    values[3],
+		Volume:   values[4],
+		Turnover: values[5],
+	}, nil
+}
+
 // GetBalance получает баланс монеты
 func (b *BybitClient) GetBalance(coin string) (float64, error) {
+	return b.GetBalanceContext(context.Background(), coin)
+}
+
+// GetBalanceContext GetBalance с контекстом трейса
+func (b *BybitClient) GetBalanceContext(ctx context.Context, coin string) (float64, error) {
 	endpoint := "/v5/account/wallet-balance"
 	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
 	params := fmt.Sprintf("accountType=%s&coin=%s", domain.BybitAccountUnified, coin)
@@ -199,7 +343,7 @@ func (b *BybitClient) GetBalance(coin string) (float64, error) {
 
 	url := fmt.Sprintf("%s%s?%s", b.baseURL, endpoint, params)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -249,6 +393,11 @@ func (b *BybitClient) GetBalance(coin string) (float64, error) {
 
 // PlaceOrder размещает рыночный ордер
 func (b *BybitClient) PlaceOrder(symbol, side string, quantity float64) (*OrderInfo, error) {
+	return b.PlaceOrderContext(context.Background(), symbol, side, quantity)
+}
+
+// PlaceOrderContext PlaceOrder с контекстом трейса
+func (b *BybitClient) PlaceOrderContext(ctx context.Context, symbol, side string, quantity float64) (*OrderInfo, error) {
 	endpoint := "/v5/order/create"
 	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
 
@@ -274,7 +423,7 @@ func (b *BybitClient) PlaceOrder(symbol, side string, quantity float64) (*OrderI
 
 	url := fmt.Sprintf("%s%s", b.baseURL, endpoint)
 
-	req, err := http.NewRequest("POST", url, strings.NewReader(string(jsonData)))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonData)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
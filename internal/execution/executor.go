@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/kirillm/dca-bot/internal/events"
+	"github.com/kirillm/dca-bot/internal/metrics"
 	"github.com/kirillm/dca-bot/internal/policy"
+	"github.com/kirillm/dca-bot/internal/tracing"
 )
 
 var (
@@ -29,6 +32,11 @@ type PolicyEngine interface {
 	ValidateAction(ctx context.Context, action policy.ActionRequest) (*policy.ValidationResult, error)
 }
 
+// EventPublisher шина событий для дашбордов (events.Bus)
+type EventPublisher interface {
+	Publish(eventType string, data interface{})
+}
+
 // ExecutionRequest запрос на исполнение
 type ExecutionRequest struct {
 	Action   policy.ActionRequest
@@ -61,6 +69,7 @@ type Executor struct {
 	priceFailover *PriceFailover
 	killSwitch    *KillSwitch
 	slippageGuard *SlippageGuard
+	events        EventPublisher
 }
 
 // NewExecutor создает новый executor
@@ -80,6 +89,19 @@ func NewExecutor(
 
 // Execute выполняет торговую операцию
 func (e *Executor) Execute(ctx context.Context, req ExecutionRequest) (*ExecutionResult, error) {
+	ctx, span := tracing.Start(ctx, "execution.execute", tracing.KindInternal,
+		tracing.String("action.type", req.Action.Type), tracing.String("symbol", req.Action.Symbol))
+	defer span.End()
+
+	result, err := e.execute(ctx, req)
+	span.RecordError(err)
+	if result != nil {
+		span.SetAttributes(tracing.Bool("execution.success", result.Success), tracing.String("order_id", result.OrderID))
+	}
+	return result, err
+}
+
+func (e *Executor) execute(ctx context.Context, req ExecutionRequest) (*ExecutionResult, error) {
 	// 1. Проверка kill switch
 	if e.killSwitch.IsActive() {
 		return &ExecutionResult{
@@ -136,8 +158,8 @@ func (e *Executor) Execute(ctx context.Context, req ExecutionRequest) (*Executio
 	}
 
 	// 6. Логирование результата
-	fmt.Printf("✅ Execution successful: %s %s @ $%.2f (OrderID: %s)\n",
-		req.Action.Type, symbol, price, result.OrderID)
+	logger.WithContext(ctx).With("symbol", symbol, "strategy", "AI", "order_id", result.OrderID).
+		Info("✅ Execution successful: %s @ $%.2f", req.Action.Type, price)
 
 	return result, nil
 }
@@ -197,6 +219,7 @@ func (e *Executor) executeBuy(ctx context.Context, symbol string, params map[str
 
 	// Размещаем market order
 	orderID, err := e.exchange.PlaceMarketOrder(ctx, symbol, "BUY", quantity)
+	metrics.ObserveOrder(symbol, "BUY", "AI", "", err)
 	if err != nil {
 		return &ExecutionResult{
 			Success:    false,
@@ -204,6 +227,7 @@ func (e *Executor) executeBuy(ctx context.Context, symbol string, params map[str
 			Error:      err,
 		}, err
 	}
+	e.publishMarketOrder(symbol, "BUY", quantity, price, orderID)
 
 	return &ExecutionResult{
 		Success:      true,
@@ -243,6 +267,7 @@ func (e *Executor) executeSell(ctx context.Context, symbol string, params map[st
 
 	// Размещаем market order
 	orderID, err := e.exchange.PlaceMarketOrder(ctx, symbol, "SELL", quantity)
+	metrics.ObserveOrder(symbol, "SELL", "AI", "", err)
 	if err != nil {
 		return &ExecutionResult{
 			Success:    false,
@@ -250,6 +275,7 @@ func (e *Executor) executeSell(ctx context.Context, symbol string, params map[st
 			Error:      err,
 		}, err
 	}
+	e.publishMarketOrder(symbol, "SELL", quantity, price, orderID)
 
 	return &ExecutionResult{
 		Success:      true,
@@ -273,6 +299,35 @@ func (e *Executor) executeGrid(ctx context.Context, symbol string, params map[st
 	}, fmt.Errorf("grid execution requires GridStrategy")
 }
 
+// SetEventPublisher подключает шину событий (order.placed, trade.filled)
+func (e *Executor) SetEventPublisher(publisher EventPublisher) {
+	e.events = publisher
+}
+
+// publishMarketOrder публикует размещение и исполнение market ордера AI
+func (e *Executor) publishMarketOrder(symbol, side string, quantity, price float64, orderID string) {
+	if e.events == nil {
+		return
+	}
+	e.events.Publish(events.OrderPlaced, events.OrderData{
+		Symbol:   symbol,
+		Side:     side,
+		Strategy: "AI",
+		Quantity: quantity,
+		Price:    price,
+		OrderID:  orderID,
+	})
+	e.events.Publish(events.TradeFilled, events.TradeData{
+		Symbol:    symbol,
+		Side:      side,
+		Strategy:  "AI",
+		Quantity:  quantity,
+		Price:     price,
+		AmountUSD: quantity * price,
+		OrderID:   orderID,
+	})
+}
+
 // SetSlippageThreshold устанавливает порог slippage
 func (e *Executor) SetSlippageThreshold(thresholdPercent float64) {
 	e.slippageGuard.SetThreshold(thresholdPercent)
@@ -3,14 +3,21 @@ package execution
 import (
 	"sync"
 	"time"
+
+	"github.com/kirillm/dca-bot/internal/events"
+	"github.com/kirillm/dca-bot/internal/metrics"
+	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
+var logger = utils.DefaultLogger().With("component", "execution")
+
 // KillSwitch аварийная остановка торговли
 type KillSwitch struct {
 	mu         sync.RWMutex
 	active     bool
 	activatedAt time.Time
 	reason     string
+	events     EventPublisher
 }
 
 // NewKillSwitch создает новый kill switch
@@ -30,7 +37,8 @@ func (ks *KillSwitch) Activate(reason string) {
 	ks.reason = reason
 
 	// Логируем критическое событие
-	println("🚨 KILL SWITCH ACTIVATED:", reason)
+	logger.Error("🚨 KILL SWITCH ACTIVATED: %s", reason)
+	ks.publish(true, reason)
 }
 
 // Deactivate деактивирует kill switch (требует ручного вмешательства)
@@ -41,7 +49,23 @@ func (ks *KillSwitch) Deactivate() {
 	ks.active = false
 	ks.reason = ""
 
-	println("✅ Kill switch deactivated")
+	logger.Warn("✅ Kill switch deactivated")
+	ks.publish(false, "")
+}
+
+// SetEventPublisher подключает шину событий (kill_switch.changed)
+func (ks *KillSwitch) SetEventPublisher(publisher EventPublisher) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.events = publisher
+}
+
+// publish вызывается под ks.mu
+func (ks *KillSwitch) publish(active bool, reason string) {
+	metrics.KillSwitchActive.SetBool(active)
+	if ks.events != nil {
+		ks.events.Publish(events.KillSwitchChanged, events.KillSwitchData{Active: active, Reason: reason})
+	}
 }
 
 // IsActive проверяет активен ли kill switch
@@ -2,7 +2,6 @@ package execution
 
 import (
 	"context"
-	"fmt"
 	"time"
 )
 
@@ -54,7 +53,7 @@ func (pf *PriceFailover) GetPrice(ctx context.Context, symbol string) (float64,
 	for i, source := range pf.fallbackSources {
 		price, err := source.GetPrice(ctx, symbol)
 		if err == nil {
-			fmt.Printf("⚠️ Using fallback source #%d for %s price\n", i+1, symbol)
+			logger.WithContext(ctx).With("symbol", symbol).Warn("⚠️ Using fallback price source #%d", i+1)
 			pf.cache[symbol] = cachedPrice{
 				price:     price,
 				timestamp: time.Now(),
@@ -67,7 +66,7 @@ func (pf *PriceFailover) GetPrice(ctx context.Context, symbol string) (float64,
 	if cached, ok := pf.cache[symbol]; ok {
 		age := time.Since(cached.timestamp)
 		if age < 5*time.Minute { // Кеш валиден 5 минут
-			fmt.Printf("⚠️ Using cached price for %s (age: %v)\n", symbol, age)
+			logger.WithContext(ctx).With("symbol", symbol).Warn("⚠️ Using cached price (age: %v)", age)
 			return cached.price, nil
 		}
 	}
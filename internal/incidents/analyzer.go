@@ -0,0 +1,336 @@
+package incidents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/ai/prompts"
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/pkg/utils"
+)
+
+// Типы инцидентов
+const (
+	KindDCAFailed       = "dca_failed"
+	KindExecutionFailed = "execution_failed"
+	KindCircuitBreaker  = "circuit_breaker"
+)
+
+// Значения по умолчанию
+const (
+	DefaultWindow   = 30 * time.Minute // контекст до события
+	DefaultCooldown = time.Hour        // повтор того же инцидента не разбирается
+	maxLogLines     = 40
+	maxContextRows  = 20
+)
+
+// Event сбой, который нужно разобрать
+type Event struct {
+	Kind    string
+	Source  string // dca, orchestrator, circuit_breaker
+	Symbol  string
+	Message string // исходный текст алерта ("DCA failed: ...")
+	Err     error
+	Time    time.Time
+}
+
+// key ключ дедупликации: один разбор на тип/источник/символ за Cooldown
+func (e Event) key() string {
+	return e.Kind + "|" + e.Source + "|" + e.Symbol
+}
+
+// TradeStore сделки (TradeRepository)
+type TradeStore interface {
+	GetBetween(ctx context.Context, from, to time.Time) ([]domain.Trade, error)
+}
+
+// ViolationStore нарушения политики (PolicyViolationRepository)
+type ViolationStore interface {
+	GetBetween(ctx context.Context, from, to time.Time) ([]domain.PolicyViolation, error)
+}
+
+// BreakerStore события circuit breaker (CircuitBreakerRepository)
+type BreakerStore interface {
+	GetBetween(ctx context.Context, from, to time.Time) ([]domain.CircuitBreakerEvent, error)
+}
+
+// Store хранилище инцидентов (IncidentRepository)
+type Store interface {
+	Save(ctx context.Context, incident *domain.Incident) error
+}
+
+// Narrator локальная модель для разбора (ai.AIClient)
+type Narrator interface {
+	Complete(systemPrompt, userPrompt string) (string, error)
+}
+
+// Stores источники контекста инцидента
+type Stores struct {
+	Trades     TradeStore
+	Violations ViolationStore
+	Breakers   BreakerStore
+	Incidents  Store
+}
+
+// Config параметры анализатора
+type Config struct {
+	Window   time.Duration // за сколько до события собирать контекст
+	Cooldown time.Duration
+	Model    string // модель narrator для записи инцидента
+}
+
+// Analyzer собирает контекст сбоя, просит локальную модель найти причину
+// и отправляет алерт с разбором
+type Analyzer struct {
+	config     Config
+	stores     Stores
+	narrator   Narrator
+	prompts    *prompts.Registry
+	notifyFunc func(string)
+	logs       func(since time.Time) []utils.LogLine
+
+	mu       sync.Mutex
+	lastSeen map[string]time.Time
+}
+
+// NewAnalyzer создает анализатор. narrator может быть nil - тогда инцидент
+// сохраняется с контекстом, а алерт уходит без разбора.
+func NewAnalyzer(config Config, stores Stores, narrator Narrator, registry *prompts.Registry, notifyFunc func(string)) *Analyzer {
+	if config.Window <= 0 {
+		config.Window = DefaultWindow
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = DefaultCooldown
+	}
+	if registry == nil {
+		registry = prompts.Default()
+	}
+
+	return &Analyzer{
+		config:     config,
+		stores:     stores,
+		narrator:   narrator,
+		prompts:    registry,
+		notifyFunc: notifyFunc,
+		logs:       utils.RecentLogs,
+		lastSeen:   make(map[string]time.Time),
+	}
+}
+
+// Report разбирает сбой в фоне и отправляет алерт. Повторы того же сбоя в пределах
+// Cooldown отправляются как есть, без разбора и новой записи.
+func (a *Analyzer) Report(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	if !a.claim(event) {
+		a.notify(event.Message)
+		return
+	}
+
+	go func() {
+		// Инцидент без записи в БД все равно отправляется с разбором
+		incident, err := a.Analyze(context.Background(), event)
+		if err != nil {
+			utils.LogError(fmt.Sprintf("Incident analysis failed: %v", err))
+		}
+		a.notify(FormatAlert(event.Message, incident))
+	}()
+}
+
+// claim отмечает событие; false, если такое уже разбиралось в пределах Cooldown
+func (a *Analyzer) claim(event Event) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := event.key()
+	if last, ok := a.lastSeen[key]; ok && event.Time.Sub(last) < a.config.Cooldown {
+		return false
+	}
+	a.lastSeen[key] = event.Time
+	return true
+}
+
+// Analyze собирает контекст, разбирает сбой и сохраняет инцидент
+func (a *Analyzer) Analyze(ctx context.Context, event Event) (*domain.Incident, error) {
+	errText := event.Message
+	if event.Err != nil {
+		errText = event.Err.Error()
+	}
+
+	incident := &domain.Incident{
+		Kind:      event.Kind,
+		Source:    event.Source,
+		Symbol:    event.Symbol,
+		Error:     errText,
+		Context:   a.collect(ctx, event),
+		CreatedAt: event.Time,
+	}
+
+	if a.narrator != nil {
+		rootCause, fix, err := a.explain(ctx, incident)
+		if err != nil {
+			utils.LogWarn(fmt.Sprintf("Incident explanation failed: %v", err))
+		} else {
+			incident.RootCause = rootCause
+			incident.SuggestedFix = fix
+			incident.Model = a.config.Model
+		}
+	}
+
+	if a.stores.Incidents != nil {
+		if err := a.stores.Incidents.Save(ctx, incident); err != nil {
+			return incident, fmt.Errorf("failed to save incident: %w", err)
+		}
+	}
+	return incident, nil
+}
+
+// collect собирает логи, сделки, нарушения и срабатывания breaker за окно до события.
+// Ошибка одного источника не мешает остальным - она попадает в контекст.
+func (a *Analyzer) collect(ctx context.Context, event Event) string {
+	from := event.Time.Add(-a.config.Window)
+	to := event.Time.Add(time.Second)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Событие: %s (%s", event.Kind, event.Source))
+	if event.Symbol != "" {
+		sb.WriteString(", " + event.Symbol)
+	}
+	sb.WriteString(fmt.Sprintf(") в %s\n", event.Time.Format("2006-01-02 15:04:05")))
+	if event.Err != nil {
+		sb.WriteString("Ошибка: " + event.Err.Error() + "\n")
+	} else {
+		sb.WriteString("Сообщение: " + event.Message + "\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("\nЛоги за %v:\n", a.config.Window))
+	lines := a.logs(from)
+	if len(lines) > maxLogLines {
+		lines = lines[len(lines)-maxLogLines:]
+	}
+	if len(lines) == 0 {
+		sb.WriteString("нет\n")
+	}
+	for _, l := range lines {
+		sb.WriteString(fmt.Sprintf("%s [%s] %s\n", l.Time.Format("15:04:05"), l.Level, l.Message))
+	}
+
+	if a.stores.Trades != nil {
+		sb.WriteString("\nСделки:\n")
+		trades, err := a.stores.Trades.GetBetween(ctx, from, to)
+		switch {
+		case err != nil:
+			sb.WriteString(fmt.Sprintf("недоступны: %v\n", err))
+		case len(trades) == 0:
+			sb.WriteString("нет\n")
+		}
+		for _, t := range lastN(trades, maxContextRows) {
+			sb.WriteString(fmt.Sprintf("%s %s %s %.8f @ %.2f ($%.2f) %s\n",
+				t.CreatedAt.Format("15:04:05"), t.Side, t.Symbol, t.Quantity, t.Price, t.Amount, t.Status))
+		}
+	}
+
+	if a.stores.Violations != nil {
+		sb.WriteString("\nНарушения политики:\n")
+		violations, err := a.stores.Violations.GetBetween(ctx, from, to)
+		switch {
+		case err != nil:
+			sb.WriteString(fmt.Sprintf("недоступны: %v\n", err))
+		case len(violations) == 0:
+			sb.WriteString("нет\n")
+		}
+		for _, v := range lastN(violations, maxContextRows) {
+			sb.WriteString(fmt.Sprintf("%s %s %s: %.2f > %.2f (%s)\n",
+				v.Timestamp.Format("15:04:05"), v.ViolationType, v.LimitName, v.AttemptedValue, v.LimitValue, v.Severity))
+		}
+	}
+
+	if a.stores.Breakers != nil {
+		sb.WriteString("\nCircuit breaker:\n")
+		events, err := a.stores.Breakers.GetBetween(ctx, from, to)
+		switch {
+		case err != nil:
+			sb.WriteString(fmt.Sprintf("недоступны: %v\n", err))
+		case len(events) == 0:
+			sb.WriteString("нет\n")
+		}
+		for _, e := range lastN(events, maxContextRows) {
+			sb.WriteString(fmt.Sprintf("%s %s\n", e.TriggeredAt.Format("15:04:05"), e.Reason))
+		}
+	}
+
+	return sb.String()
+}
+
+// explanation ответ модели
+type explanation struct {
+	RootCause    string `json:"root_cause"`
+	SuggestedFix string `json:"suggested_fix"`
+}
+
+// explain просит модель найти причину; ответ не в JSON целиком считается причиной
+func (a *Analyzer) explain(ctx context.Context, incident *domain.Incident) (string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", "", err
+	}
+
+	system, prompt, err := a.prompts.Render(prompts.Incident, prompts.SectionSystem, nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	response, err := a.narrator.Complete(system, incident.Context)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", prompt.ID(), err)
+	}
+
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start != -1 && end > start {
+		var result explanation
+		if err := json.Unmarshal([]byte(response[start:end+1]), &result); err == nil && result.RootCause != "" {
+			return strings.TrimSpace(result.RootCause), strings.TrimSpace(result.SuggestedFix), nil
+		}
+	}
+	return strings.TrimSpace(response), "", nil
+}
+
+// FormatAlert исходный алерт с разбором инцидента
+func FormatAlert(message string, incident *domain.Incident) string {
+	if incident == nil || incident.RootCause == "" {
+		return message
+	}
+
+	var sb strings.Builder
+	sb.WriteString(message)
+	sb.WriteString("\n\n🔍 Разбор инцидента")
+	if incident.ID > 0 {
+		sb.WriteString(fmt.Sprintf(" #%d", incident.ID))
+	}
+	sb.WriteString("\n")
+	sb.WriteString("Причина: " + incident.RootCause + "\n")
+	if incident.SuggestedFix != "" {
+		sb.WriteString("Что сделать: " + incident.SuggestedFix + "\n")
+	}
+	return sb.String()
+}
+
+func (a *Analyzer) notify(message string) {
+	if a.notifyFunc != nil {
+		a.notifyFunc(message)
+	}
+}
+
+// lastN последние n элементов
+func lastN[T any](items []T, n int) []T {
+	if len(items) > n {
+		return items[len(items)-n:]
+	}
+	return items
+}
@@ -0,0 +1,155 @@
+package incidents
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/pkg/utils"
+)
+
+// memoryStores контекст инцидента в памяти для тестов
+type memoryStores struct {
+	trades     []domain.Trade
+	violations []domain.PolicyViolation
+	saved      []domain.Incident
+}
+
+func (m *memoryStores) GetBetween(ctx context.Context, from, to time.Time) ([]domain.Trade, error) {
+	return m.trades, nil
+}
+
+func (m *memoryStores) Save(ctx context.Context, incident *domain.Incident) error {
+	incident.ID = int64(len(m.saved) + 1)
+	m.saved = append(m.saved, *incident)
+	return nil
+}
+
+type violationStore struct{ m *memoryStores }
+
+func (v violationStore) GetBetween(ctx context.Context, from, to time.Time) ([]domain.PolicyViolation, error) {
+	return v.m.violations, nil
+}
+
+type brokenBreakers struct{}
+
+func (brokenBreakers) GetBetween(ctx context.Context, from, to time.Time) ([]domain.CircuitBreakerEvent, error) {
+	return nil, errors.New("db down")
+}
+
+type fakeNarrator struct {
+	response string
+	err      error
+	prompts  []string
+}
+
+func (n *fakeNarrator) Complete(systemPrompt, userPrompt string) (string, error) {
+	n.prompts = append(n.prompts, userPrompt)
+	return n.response, n.err
+}
+
+func newAnalyzer(m *memoryStores, narrator Narrator, notify func(string)) *Analyzer {
+	a := NewAnalyzer(Config{Model: "qwen-test"}, Stores{
+		Trades:     m,
+		Violations: violationStore{m},
+		Breakers:   brokenBreakers{},
+		Incidents:  m,
+	}, narrator, nil, notify)
+
+	a.logs = func(since time.Time) []utils.LogLine {
+		return []utils.LogLine{{Time: since.Add(time.Minute), Level: "ERROR", Message: "bybit: retCode 170131 insufficient balance"}}
+	}
+	return a
+}
+
+func TestAnalyzer_Analyze(t *testing.T) {
+	m := &memoryStores{
+		trades:     []domain.Trade{{Symbol: "BTCUSDT", Side: domain.SideBuy, Amount: 10, Status: domain.StatusFilled}},
+		violations: []domain.PolicyViolation{{ViolationType: "order_size", LimitName: "max_order_usd", LimitValue: 50, AttemptedValue: 80, Severity: "critical"}},
+	}
+	narrator := &fakeNarrator{response: "Ответ:\n```json\n{\"root_cause\": \"Недостаточно USDT\", \"suggested_fix\": \"Пополните баланс\"}\n```"}
+	a := newAnalyzer(m, narrator, nil)
+
+	incident, err := a.Analyze(context.Background(), Event{
+		Kind:   KindDCAFailed,
+		Source: "dca",
+		Symbol: "BTCUSDT",
+		Err:    errors.New("insufficient USDT balance: have 3.00, need 10.00"),
+		Time:   time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	if incident.RootCause != "Недостаточно USDT" || incident.SuggestedFix != "Пополните баланс" {
+		t.Errorf("explanation = %q / %q", incident.RootCause, incident.SuggestedFix)
+	}
+	if incident.Model != "qwen-test" || len(m.saved) != 1 {
+		t.Errorf("incident not saved with model: %+v", m.saved)
+	}
+
+	prompt := narrator.prompts[0]
+	for _, want := range []string{"insufficient USDT balance", "retCode 170131", "BUY BTCUSDT", "max_order_usd", "недоступны: db down"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("context missing %q:\n%s", want, prompt)
+		}
+	}
+}
+
+func TestAnalyzer_FreeTextAndFailure(t *testing.T) {
+	m := &memoryStores{}
+
+	a := newAnalyzer(m, &fakeNarrator{response: "  Биржа недоступна.  "}, nil)
+	incident, err := a.Analyze(context.Background(), Event{Kind: KindCircuitBreaker, Message: "breaker", Time: time.Now()})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if incident.RootCause != "Биржа недоступна." || incident.SuggestedFix != "" {
+		t.Errorf("free text explanation = %q / %q", incident.RootCause, incident.SuggestedFix)
+	}
+
+	// Модель недоступна: инцидент сохраняется с контекстом, алерт без разбора
+	a = newAnalyzer(m, &fakeNarrator{err: errors.New("ollama down")}, nil)
+	incident, err = a.Analyze(context.Background(), Event{Kind: KindCircuitBreaker, Message: "breaker", Time: time.Now()})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if incident.RootCause != "" || incident.Context == "" || len(m.saved) != 2 {
+		t.Errorf("incident without explanation = %+v", incident)
+	}
+	if got := FormatAlert("⛔ breaker", incident); got != "⛔ breaker" {
+		t.Errorf("alert without explanation = %q", got)
+	}
+}
+
+func TestAnalyzer_ReportCooldown(t *testing.T) {
+	m := &memoryStores{}
+	sent := make(chan string, 4)
+	a := newAnalyzer(m, &fakeNarrator{response: `{"root_cause": "Нет денег", "suggested_fix": "Пополнить"}`}, func(s string) { sent <- s })
+
+	now := time.Now()
+	event := Event{Kind: KindDCAFailed, Source: "dca", Symbol: "BTCUSDT", Message: "❌ DCA failed: boom", Time: now}
+
+	a.Report(event)
+	select {
+	case alert := <-sent:
+		if !strings.Contains(alert, "❌ DCA failed: boom\n\n🔍 Разбор инцидента #1\nПричина: Нет денег\nЧто сделать: Пополнить") {
+			t.Errorf("alert = %q", alert)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("alert not sent")
+	}
+
+	// Повтор в пределах cooldown: исходный алерт без нового разбора
+	event.Time = now.Add(time.Minute)
+	a.Report(event)
+	if alert := <-sent; alert != "❌ DCA failed: boom" {
+		t.Errorf("repeat alert = %q", alert)
+	}
+	if len(m.saved) != 1 {
+		t.Errorf("repeat saved an incident: %d", len(m.saved))
+	}
+}
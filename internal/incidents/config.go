@@ -0,0 +1,15 @@
+package incidents
+
+import (
+	"github.com/kirillm/dca-bot/internal/config"
+)
+
+// ConfigFrom параметры анализатора из INCIDENT_* переменных.
+// Narrator - клиент локальной модели: agents.NewLocalClientFromConfig(cfg.AI, tracker, ai.PurposeIncident).
+func ConfigFrom(cfg *config.Config) Config {
+	return Config{
+		Window:   cfg.Incidents.Window,
+		Cooldown: cfg.Incidents.Cooldown,
+		Model:    cfg.AI.Local.Model,
+	}
+}
@@ -1,10 +1,12 @@
 package manager
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/kirillm/dca-bot/internal/domain"
 	"github.com/kirillm/dca-bot/internal/exchange"
 	"github.com/kirillm/dca-bot/internal/storage"
 	"github.com/kirillm/dca-bot/internal/strategy"
@@ -13,7 +15,7 @@ import (
 
 // MultiAssetManager manages multiple assets and their strategies
 type MultiAssetManager struct {
-	storage            *storage.PostgresStorage
+	storage            *storage.Storage
 	exchange           *exchange.BybitClient
 	logger             *utils.Logger
 	dcaStrategies      map[string]*strategy.DCAStrategy
@@ -25,7 +27,7 @@ type MultiAssetManager struct {
 }
 
 func NewMultiAssetManager(
-	storage *storage.PostgresStorage,
+	storage *storage.Storage,
 	exchange *exchange.BybitClient,
 	logger *utils.Logger,
 	notifyFunc func(string),
@@ -44,10 +46,11 @@ func NewMultiAssetManager(
 
 // Start starts the manager
 func (m *MultiAssetManager) Start() {
+	ctx := context.Background()
 	m.logger.Info("Multi-Asset Manager starting...")
 
 	// Load all enabled assets
-	assets, err := m.storage.GetEnabledAssets()
+	assets, err := m.storage.Assets().GetEnabled(ctx)
 	if err != nil {
 		m.logger.Error("Failed to load enabled assets: %v", err)
 		return
@@ -90,7 +93,7 @@ func (m *MultiAssetManager) Stop() {
 }
 
 // InitializeAsset initializes strategies for an asset
-func (m *MultiAssetManager) InitializeAsset(asset *storage.Asset) error {
+func (m *MultiAssetManager) InitializeAsset(asset *domain.Asset) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -113,7 +116,7 @@ func (m *MultiAssetManager) InitializeAsset(asset *storage.Asset) error {
 }
 
 // initializeDCAStrategy initializes DCA strategy
-func (m *MultiAssetManager) initializeDCAStrategy(asset *storage.Asset) error {
+func (m *MultiAssetManager) initializeDCAStrategy(asset *domain.Asset) error {
 	// Check if strategy already exists
 	if _, exists := m.dcaStrategies[asset.Symbol]; exists {
 		return nil
@@ -139,7 +142,7 @@ func (m *MultiAssetManager) initializeDCAStrategy(asset *storage.Asset) error {
 }
 
 // initializeGridStrategy initializes Grid strategy
-func (m *MultiAssetManager) initializeGridStrategy(asset *storage.Asset) error {
+func (m *MultiAssetManager) initializeGridStrategy(asset *domain.Asset) error {
 	gridStrategy := strategy.NewGridStrategy(m.storage, m.exchange)
 
 	if err := gridStrategy.InitializeGrid(asset); err != nil {
@@ -152,7 +155,7 @@ func (m *MultiAssetManager) initializeGridStrategy(asset *storage.Asset) error {
 }
 
 // initializeAutoSellStrategy initializes Auto-Sell strategy
-func (m *MultiAssetManager) initializeAutoSellStrategy(asset *storage.Asset) error {
+func (m *MultiAssetManager) initializeAutoSellStrategy(asset *domain.Asset) error {
 	if _, exists := m.autoSellStrategies[asset.Symbol]; exists {
 		return nil
 	}
@@ -180,11 +183,12 @@ func (m *MultiAssetManager) initializeAutoSellStrategy(asset *storage.Asset) err
 }
 
 // AddAsset adds a new asset
-func (m *MultiAssetManager) AddAsset(asset *storage.Asset) error {
+func (m *MultiAssetManager) AddAsset(asset *domain.Asset) error {
+	ctx := context.Background()
 	m.logger.Info("Adding new asset: %s", asset.Symbol)
 
 	// Save to database
-	if err := m.storage.CreateOrUpdateAsset(asset); err != nil {
+	if err := m.storage.Assets().CreateOrUpdate(ctx, asset); err != nil {
 		return fmt.Errorf("failed to save asset: %w", err)
 	}
 
@@ -204,6 +208,7 @@ func (m *MultiAssetManager) AddAsset(asset *storage.Asset) error {
 
 // RemoveAsset removes an asset
 func (m *MultiAssetManager) RemoveAsset(symbol string) error {
+	ctx := context.Background()
 	m.logger.Info("Removing asset: %s", symbol)
 
 	// Stop all strategies
@@ -220,7 +225,7 @@ func (m *MultiAssetManager) RemoveAsset(symbol string) error {
 	m.mu.Unlock()
 
 	// Deactivate in database
-	if err := m.storage.DisableAsset(symbol); err != nil {
+	if err := m.storage.Assets().Disable(ctx, symbol); err != nil {
 		return fmt.Errorf("failed to disable asset: %w", err)
 	}
 
@@ -248,11 +253,12 @@ func (m *MultiAssetManager) monitor() {
 
 // monitorGridStrategies monitors Grid strategies
 func (m *MultiAssetManager) monitorGridStrategies() {
+	ctx := context.Background()
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	for symbol, gridStrategy := range m.gridStrategies {
-		asset, err := m.storage.GetAsset(symbol)
+		asset, err := m.storage.Assets().Get(ctx, symbol)
 		if err != nil || asset == nil || !asset.Enabled {
 			continue
 		}
@@ -265,7 +271,8 @@ func (m *MultiAssetManager) monitorGridStrategies() {
 
 // GetAssetStatus returns asset status
 func (m *MultiAssetManager) GetAssetStatus(symbol string) (string, error) {
-	asset, err := m.storage.GetAsset(symbol)
+	ctx := context.Background()
+	asset, err := m.storage.Assets().Get(ctx, symbol)
 	if err != nil {
 		return "", err
 	}
@@ -273,7 +280,7 @@ func (m *MultiAssetManager) GetAssetStatus(symbol string) (string, error) {
 		return "", fmt.Errorf("asset not found: %s", symbol)
 	}
 
-	balance, err := m.storage.GetBalance(symbol)
+	balance, err := m.storage.Balances().Get(ctx, symbol)
 	if err != nil {
 		return "", err
 	}
@@ -321,11 +328,13 @@ func (m *MultiAssetManager) GetAssetStatus(symbol string) (string, error) {
 }
 
 // GetAllAssets returns list of all assets
-func (m *MultiAssetManager) GetAllAssets() ([]storage.Asset, error) {
-	return m.storage.GetAllAssets()
+func (m *MultiAssetManager) GetAllAssets() ([]domain.Asset, error) {
+	ctx := context.Background()
+	return m.storage.Assets().GetAll(ctx)
 }
 
 // UpdateAsset updates asset parameters
-func (m *MultiAssetManager) UpdateAsset(asset *storage.Asset) error {
-	return m.storage.CreateOrUpdateAsset(asset)
+func (m *MultiAssetManager) UpdateAsset(asset *domain.Asset) error {
+	ctx := context.Background()
+	return m.storage.Assets().CreateOrUpdate(ctx, asset)
 }
@@ -1,23 +1,26 @@
 package manager
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/kirillm/dca-bot/internal/domain"
 	"github.com/kirillm/dca-bot/internal/exchange"
+	"github.com/kirillm/dca-bot/internal/metrics"
 	"github.com/kirillm/dca-bot/internal/storage"
 	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
 // PortfolioManager manages user's portfolio
 type PortfolioManager struct {
-	storage  *storage.PostgresStorage
+	storage  *storage.Storage
 	exchange *exchange.BybitClient
 	logger   *utils.Logger
 }
 
 func NewPortfolioManager(
-	storage *storage.PostgresStorage,
+	storage *storage.Storage,
 	exchange *exchange.BybitClient,
 	logger *utils.Logger,
 ) *PortfolioManager {
@@ -30,7 +33,8 @@ func NewPortfolioManager(
 
 // GetPortfolioSummary returns portfolio summary
 func (p *PortfolioManager) GetPortfolioSummary() (string, error) {
-	assets, err := p.storage.GetEnabledAssets()
+	ctx := context.Background()
+	assets, err := p.storage.Assets().GetEnabled(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to get assets: %w", err)
 	}
@@ -47,7 +51,7 @@ func (p *PortfolioManager) GetPortfolioSummary() (string, error) {
 	summary := "📊 **Portfolio Summary**\n\n"
 
 	for _, asset := range assets {
-		balance, err := p.storage.GetBalance(asset.Symbol)
+		balance, err := p.storage.Balances().Get(ctx, asset.Symbol)
 		if err != nil {
 			continue
 		}
@@ -92,7 +96,8 @@ func (p *PortfolioManager) GetPortfolioSummary() (string, error) {
 
 // GetAssetAllocation returns asset allocation in portfolio
 func (p *PortfolioManager) GetAssetAllocation() (string, error) {
-	assets, err := p.storage.GetEnabledAssets()
+	ctx := context.Background()
+	assets, err := p.storage.Assets().GetEnabled(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -105,7 +110,7 @@ func (p *PortfolioManager) GetAssetAllocation() (string, error) {
 	assetValues := make(map[string]float64)
 
 	for _, asset := range assets {
-		balance, err := p.storage.GetBalance(asset.Symbol)
+		balance, err := p.storage.Balances().Get(ctx, asset.Symbol)
 		if err != nil {
 			continue
 		}
@@ -132,13 +137,14 @@ func (p *PortfolioManager) GetAssetAllocation() (string, error) {
 
 // SavePnLSnapshot saves PnL snapshot for analytics
 func (p *PortfolioManager) SavePnLSnapshot(snapshotType string) error {
-	assets, err := p.storage.GetEnabledAssets()
+	ctx := context.Background()
+	assets, err := p.storage.Assets().GetEnabled(ctx)
 	if err != nil {
 		return err
 	}
 
 	for _, asset := range assets {
-		balance, err := p.storage.GetBalance(asset.Symbol)
+		balance, err := p.storage.Balances().Get(ctx, asset.Symbol)
 		if err != nil {
 			continue
 		}
@@ -157,7 +163,7 @@ func (p *PortfolioManager) SavePnLSnapshot(snapshotType string) error {
 			returnPercent = (totalPnL / balance.TotalInvested) * 100
 		}
 
-		snapshot := &storage.PnLHistory{
+		snapshot := &domain.PnLHistory{
 			Symbol:        asset.Symbol,
 			RealizedPnL:   balance.RealizedProfit,
 			UnrealizedPnL: unrealizedPnL,
@@ -169,9 +175,10 @@ func (p *PortfolioManager) SavePnLSnapshot(snapshotType string) error {
 			CreatedAt:     time.Now(),
 		}
 
-		if err := p.storage.SavePnLSnapshot(snapshot); err != nil {
+		if err := p.storage.PnL().SaveSnapshot(ctx, snapshot); err != nil {
 			p.logger.Error("Failed to save PnL snapshot for %s: %v", asset.Symbol, err)
 		}
+		metrics.ObservePosition(asset.Symbol, currentValue, balance.RealizedProfit, unrealizedPnL)
 	}
 
 	return nil
@@ -179,8 +186,9 @@ func (p *PortfolioManager) SavePnLSnapshot(snapshotType string) error {
 
 // GetPerformanceMetrics returns performance metrics for a period
 func (p *PortfolioManager) GetPerformanceMetrics(symbol string, days int) (string, error) {
+	ctx := context.Background()
 	// Get historical data
-	history, err := p.storage.GetPnLHistory(symbol, "DAILY", days)
+	history, err := p.storage.PnL().GetHistory(ctx, symbol, "DAILY", days)
 	if err != nil {
 		return "", err
 	}
@@ -190,7 +198,7 @@ func (p *PortfolioManager) GetPerformanceMetrics(symbol string, days int) (strin
 	}
 
 	// Current position
-	balance, err := p.storage.GetBalance(symbol)
+	balance, err := p.storage.Balances().Get(ctx, symbol)
 	if err != nil {
 		return "", err
 	}
@@ -236,7 +244,8 @@ func (p *PortfolioManager) GetPerformanceMetrics(symbol string, days int) (strin
 
 // AllocateCapital allocates capital between assets
 func (p *PortfolioManager) AllocateCapital(totalCapital float64) error {
-	assets, err := p.storage.GetEnabledAssets()
+	ctx := context.Background()
+	assets, err := p.storage.Assets().GetEnabled(ctx)
 	if err != nil {
 		return err
 	}
@@ -250,7 +259,7 @@ func (p *PortfolioManager) AllocateCapital(totalCapital float64) error {
 
 	for _, asset := range assets {
 		asset.AllocatedCapital = capitalPerAsset
-		if err := p.storage.CreateOrUpdateAsset(&asset); err != nil {
+		if err := p.storage.Assets().CreateOrUpdate(ctx, &asset); err != nil {
 			p.logger.Error("Failed to update allocated capital for %s: %v", asset.Symbol, err)
 		}
 	}
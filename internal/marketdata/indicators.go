@@ -0,0 +1,163 @@
+package marketdata
+
+import (
+	"math"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// Closes возвращает цены закрытия свечей
+func Closes(candles []domain.Candle) []float64 {
+	closes := make([]float64, len(candles))
+	for i, c := range candles {
+		closes[i] = c.Close
+	}
+	return closes
+}
+
+// SMA простая скользящая средняя за последние period значений
+func SMA(values []float64, period int) float64 {
+	if period <= 0 || len(values) < period {
+		return 0
+	}
+
+	sum := 0.0
+	for _, v := range values[len(values)-period:] {
+		sum += v
+	}
+	return sum / float64(period)
+}
+
+// EMA экспоненциальная скользящая средняя (инициализация через SMA первых period значений)
+func EMA(values []float64, period int) float64 {
+	if period <= 0 || len(values) < period {
+		return 0
+	}
+
+	k := 2.0 / float64(period+1)
+	ema := SMA(values[:period], period)
+	for _, v := range values[period:] {
+		ema = v*k + ema*(1-k)
+	}
+	return ema
+}
+
+// RSI индекс относительной силы по Уайлдеру (0-100)
+func RSI(values []float64, period int) float64 {
+	if period <= 0 || len(values) < period+1 {
+		return 0
+	}
+
+	var gain, loss float64
+	for i := 1; i <= period; i++ {
+		change := values[i] - values[i-1]
+		if change > 0 {
+			gain += change
+		} else {
+			loss -= change
+		}
+	}
+	avgGain := gain / float64(period)
+	avgLoss := loss / float64(period)
+
+	for i := period + 1; i < len(values); i++ {
+		change := values[i] - values[i-1]
+		g, l := 0.0, 0.0
+		if change > 0 {
+			g = change
+		} else {
+			l = -change
+		}
+		avgGain = (avgGain*float64(period-1) + g) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + l) / float64(period)
+	}
+
+	if avgLoss == 0 {
+		if avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+
+	rs := avgGain / avgLoss
+	return 100 - 100/(1+rs)
+}
+
+// ATR средний истинный диапазон по Уайлдеру
+func ATR(candles []domain.Candle, period int) float64 {
+	if period <= 0 || len(candles) < period+1 {
+		return 0
+	}
+
+	trueRanges := make([]float64, 0, len(candles)-1)
+	for i := 1; i < len(candles); i++ {
+		prevClose := candles[i-1].Close
+		tr := math.Max(candles[i].High-candles[i].Low,
+			math.Max(math.Abs(candles[i].High-prevClose), math.Abs(candles[i].Low-prevClose)))
+		trueRanges = append(trueRanges, tr)
+	}
+
+	atr := SMA(trueRanges[:period], period)
+	for _, tr := range trueRanges[period:] {
+		atr = (atr*float64(period-1) + tr) / float64(period)
+	}
+	return atr
+}
+
+// Bollinger полосы Боллинджера (верхняя, средняя, нижняя)
+func Bollinger(values []float64, period int, k float64) (upper, middle, lower float64) {
+	if period <= 0 || len(values) < period {
+		return 0, 0, 0
+	}
+
+	middle = SMA(values, period)
+	window := values[len(values)-period:]
+
+	variance := 0.0
+	for _, v := range window {
+		variance += (v - middle) * (v - middle)
+	}
+	stdDev := math.Sqrt(variance / float64(period))
+
+	return middle + k*stdDev, middle, middle - k*stdDev
+}
+
+// RealizedVolatility стандартное отклонение лог-доходностей в процентах (за один период свечи)
+func RealizedVolatility(values []float64) float64 {
+	if len(values) < 3 {
+		return 0
+	}
+
+	returns := make([]float64, 0, len(values)-1)
+	for i := 1; i < len(values); i++ {
+		if values[i-1] <= 0 || values[i] <= 0 {
+			continue
+		}
+		returns = append(returns, math.Log(values[i]/values[i-1]))
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+
+	return math.Sqrt(variance) * 100
+}
+
+// ChangePercent изменение в процентах между двумя ценами
+func ChangePercent(from, to float64) float64 {
+	if from == 0 {
+		return 0
+	}
+	return (to - from) / from * 100
+}
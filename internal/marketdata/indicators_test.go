@@ -0,0 +1,109 @@
+package marketdata
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestSMA(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		period int
+		want   float64
+	}{
+		{"last three", []float64{1, 2, 3, 4, 5}, 3, 4},
+		{"full window", []float64{2, 4, 6}, 3, 4},
+		{"not enough data", []float64{1, 2}, 3, 0},
+		{"zero period", []float64{1, 2}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SMA(tt.values, tt.period); !almostEqual(got, tt.want) {
+				t.Errorf("SMA() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEMA(t *testing.T) {
+	// Постоянный ряд: EMA равна значению
+	if got := EMA([]float64{5, 5, 5, 5, 5}, 3); !almostEqual(got, 5) {
+		t.Errorf("EMA() constant = %v, want 5", got)
+	}
+
+	// SMA(1,2,3)=2, k=0.5: 4*0.5+2*0.5=3
+	if got := EMA([]float64{1, 2, 3, 4}, 3); !almostEqual(got, 3) {
+		t.Errorf("EMA() = %v, want 3", got)
+	}
+}
+
+func TestRSI(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   float64
+	}{
+		{"only gains", []float64{1, 2, 3, 4, 5}, 100},
+		{"flat", []float64{3, 3, 3, 3, 3}, 50},
+		{"only losses", []float64{5, 4, 3, 2, 1}, 0},
+		{"equal gains and losses", []float64{1, 2, 1, 2, 1}, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RSI(tt.values, 4); !almostEqual(got, tt.want) {
+				t.Errorf("RSI() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestATR(t *testing.T) {
+	candles := []domain.Candle{
+		{High: 11, Low: 9, Close: 10},
+		{High: 12, Low: 10, Close: 11},
+		{High: 13, Low: 11, Close: 12},
+		{High: 14, Low: 12, Close: 13},
+	}
+
+	// Истинный диапазон каждой свечи = 2
+	if got := ATR(candles, 3); !almostEqual(got, 2) {
+		t.Errorf("ATR() = %v, want 2", got)
+	}
+}
+
+func TestBollinger(t *testing.T) {
+	upper, middle, lower := Bollinger([]float64{2, 4, 4, 4, 5, 5, 7, 9}, 8, 2)
+
+	// Среднее 5, стандартное отклонение 2
+	if !almostEqual(middle, 5) || !almostEqual(upper, 9) || !almostEqual(lower, 1) {
+		t.Errorf("Bollinger() = %v/%v/%v, want 9/5/1", upper, middle, lower)
+	}
+}
+
+func TestRealizedVolatility(t *testing.T) {
+	if got := RealizedVolatility([]float64{100, 100, 100, 100}); got != 0 {
+		t.Errorf("RealizedVolatility() flat = %v, want 0", got)
+	}
+
+	if got := RealizedVolatility([]float64{100, 110, 100, 110, 100}); got <= 0 {
+		t.Errorf("RealizedVolatility() = %v, want > 0", got)
+	}
+}
+
+func TestChangePercent(t *testing.T) {
+	if got := ChangePercent(100, 110); !almostEqual(got, 10) {
+		t.Errorf("ChangePercent() = %v, want 10", got)
+	}
+	if got := ChangePercent(0, 110); got != 0 {
+		t.Errorf("ChangePercent() from zero = %v, want 0", got)
+	}
+}
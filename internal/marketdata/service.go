@@ -0,0 +1,216 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/pkg/utils"
+)
+
+// KlineSource источник свечей (BybitClient)
+type KlineSource interface {
+	GetKlines(symbol, interval string, limit int) ([]domain.Candle, error)
+}
+
+// CandleStore хранилище свечей (CandleRepository)
+type CandleStore interface {
+	SaveBatch(ctx context.Context, candles []domain.Candle) error
+	GetRecent(ctx context.Context, symbol, interval string, limit int) ([]domain.Candle, error)
+}
+
+// Indicators набор индикаторов по символу (на часовых свечах)
+type Indicators struct {
+	Symbol          string
+	Price           float64
+	Change24h       float64 // %
+	SMA20           float64
+	SMA50           float64
+	EMA12           float64
+	EMA26           float64
+	RSI14           float64
+	ATR14           float64
+	ATRPercent      float64 // ATR относительно цены, %
+	BollingerUpper  float64
+	BollingerMiddle float64
+	BollingerLower  float64
+	Volatility      float64 // реализованная часовая волатильность за 24ч, %
+	Trend           string  // bullish, bearish, neutral
+	UpdatedAt       time.Time
+}
+
+// cacheEntry закешированные свечи
+type cacheEntry struct {
+	candles   []domain.Candle
+	fetchedAt time.Time
+}
+
+// cacheTTL время жизни кеша для каждого таймфрейма
+var cacheTTL = map[string]time.Duration{
+	domain.Interval1m:  30 * time.Second,
+	domain.Interval5m:  time.Minute,
+	domain.Interval15m: 2 * time.Minute,
+	domain.Interval1h:  5 * time.Minute,
+	domain.Interval4h:  10 * time.Minute,
+	domain.Interval1d:  30 * time.Minute,
+}
+
+// indicatorCandles количество часовых свечей для расчета индикаторов
+const indicatorCandles = 100
+
+// Service сервис рыночных данных: свечи, кеш и индикаторы
+type Service struct {
+	exchange KlineSource
+	storage  CandleStore
+	cache    map[string]*cacheEntry
+	mu       sync.RWMutex
+}
+
+// NewService создает сервис рыночных данных
+func NewService(exchange KlineSource, storage CandleStore) *Service {
+	return &Service{
+		exchange: exchange,
+		storage:  storage,
+		cache:    make(map[string]*cacheEntry),
+	}
+}
+
+// GetCandles возвращает последние свечи (от старых к новым).
+// Порядок: кеш → биржа (с сохранением в БД) → БД как fallback.
+func (s *Service) GetCandles(symbol, interval string, limit int) ([]domain.Candle, error) {
+	ttl, ok := cacheTTL[interval]
+	if !ok {
+		return nil, fmt.Errorf("unsupported interval: %s", interval)
+	}
+
+	key := symbol + ":" + interval
+
+	s.mu.RLock()
+	entry := s.cache[key]
+	s.mu.RUnlock()
+
+	if entry != nil && time.Since(entry.fetchedAt) < ttl && len(entry.candles) >= limit {
+		return tail(entry.candles, limit), nil
+	}
+
+	candles, err := s.exchange.GetKlines(symbol, interval, limit)
+	if err != nil {
+		// Биржа недоступна - пробуем БД
+		if s.storage != nil {
+			stored, dbErr := s.storage.GetRecent(context.Background(), symbol, interval, limit)
+			if dbErr == nil && len(stored) > 0 {
+				utils.LogWarn(fmt.Sprintf("Klines для %s %s взяты из БД: %v", symbol, interval, err))
+				return stored, nil
+			}
+		}
+		return nil, fmt.Errorf("failed to get klines for %s %s: %w", symbol, interval, err)
+	}
+
+	s.mu.Lock()
+	s.cache[key] = &cacheEntry{candles: candles, fetchedAt: time.Now()}
+	s.mu.Unlock()
+
+	if s.storage != nil {
+		if err := s.storage.SaveBatch(context.Background(), candles); err != nil {
+			utils.LogWarn(fmt.Sprintf("Не удалось сохранить свечи %s %s: %v", symbol, interval, err))
+		}
+	}
+
+	return candles, nil
+}
+
+// GetIndicators рассчитывает индикаторы по часовым свечам
+func (s *Service) GetIndicators(symbol string) (*Indicators, error) {
+	candles, err := s.GetCandles(symbol, domain.Interval1h, indicatorCandles)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(candles) < 25 {
+		return nil, fmt.Errorf("not enough candles for %s: %d", symbol, len(candles))
+	}
+
+	closes := Closes(candles)
+	price := closes[len(closes)-1]
+
+	ind := &Indicators{
+		Symbol:    symbol,
+		Price:     price,
+		Change24h: ChangePercent(candles[len(candles)-24].Open, price),
+		SMA20:     SMA(closes, 20),
+		SMA50:     SMA(closes, 50),
+		EMA12:     EMA(closes, 12),
+		EMA26:     EMA(closes, 26),
+		RSI14:     RSI(closes, 14),
+		ATR14:     ATR(candles, 14),
+		// Последние 25 закрытий дают 24 часовые доходности
+		Volatility: RealizedVolatility(closes[len(closes)-25:]),
+		UpdatedAt:  time.Now(),
+	}
+	ind.BollingerUpper, ind.BollingerMiddle, ind.BollingerLower = Bollinger(closes, 20, 2)
+
+	if price > 0 {
+		ind.ATRPercent = ind.ATR14 / price * 100
+	}
+	ind.Trend = trend(ind)
+
+	return ind, nil
+}
+
+// GetVolatility возвращает реализованную часовую волатильность за 24ч, %
+func (s *Service) GetVolatility(symbol string) (float64, error) {
+	ind, err := s.GetIndicators(symbol)
+	if err != nil {
+		return 0, err
+	}
+	return ind.Volatility, nil
+}
+
+// GetChange24h возвращает изменение цены за 24ч, %
+func (s *Service) GetChange24h(symbol string) (float64, error) {
+	ind, err := s.GetIndicators(symbol)
+	if err != nil {
+		return 0, err
+	}
+	return ind.Change24h, nil
+}
+
+// Format форматирует индикаторы для AI контекста
+func (i *Indicators) Format() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Indicators (%s, 1h):\n", i.Symbol))
+	sb.WriteString(fmt.Sprintf("24h change: %.2f%%\n", i.Change24h))
+	sb.WriteString(fmt.Sprintf("Trend: %s\n", i.Trend))
+	sb.WriteString(fmt.Sprintf("SMA20: %.2f, SMA50: %.2f\n", i.SMA20, i.SMA50))
+	sb.WriteString(fmt.Sprintf("EMA12: %.2f, EMA26: %.2f\n", i.EMA12, i.EMA26))
+	sb.WriteString(fmt.Sprintf("RSI14: %.1f\n", i.RSI14))
+	sb.WriteString(fmt.Sprintf("ATR14: %.2f (%.2f%%)\n", i.ATR14, i.ATRPercent))
+	sb.WriteString(fmt.Sprintf("Bollinger: %.2f / %.2f / %.2f\n", i.BollingerLower, i.BollingerMiddle, i.BollingerUpper))
+	sb.WriteString(fmt.Sprintf("Volatility (hourly, 24h): %.2f%%", i.Volatility))
+	return sb.String()
+}
+
+// trend определяет направление тренда по средним
+func trend(ind *Indicators) string {
+	if ind.SMA50 == 0 {
+		return "neutral"
+	}
+	if ind.Price > ind.SMA50 && ind.EMA12 > ind.EMA26 {
+		return "bullish"
+	}
+	if ind.Price < ind.SMA50 && ind.EMA12 < ind.EMA26 {
+		return "bearish"
+	}
+	return "neutral"
+}
+
+// tail возвращает последние n элементов
+func tail(candles []domain.Candle, n int) []domain.Candle {
+	if n <= 0 || n >= len(candles) {
+		return candles
+	}
+	return candles[len(candles)-n:]
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Default реестр бота, его отдает /metrics
+var Default = NewRegistry()
+
+var (
+	latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+	aiBuckets      = []float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120}
+)
+
+// Торговля
+var (
+	OrdersTotal = Default.NewCounterVec("orders_total",
+		"Orders sent to the exchange by symbol, side, strategy and status",
+		"symbol", "side", "strategy", "status")
+	PositionValueUSDT = Default.NewGaugeVec("position_value_usdt",
+		"Position value at the last PnL snapshot", "symbol")
+	PnLRealizedUSDT = Default.NewGaugeVec("pnl_realized_usdt",
+		"Realized profit at the last PnL snapshot", "symbol")
+	PnLUnrealizedUSDT = Default.NewGaugeVec("pnl_unrealized_usdt",
+		"Unrealized profit at the last PnL snapshot", "symbol")
+)
+
+// Биржа
+var (
+	ExchangeRequestDuration = Default.NewHistogramVec("exchange_request_duration_seconds",
+		"Bybit API request latency including retries", latencyBuckets, "endpoint")
+	ExchangeRequestsTotal = Default.NewCounterVec("exchange_requests_total",
+		"Bybit API requests by endpoint and result", "endpoint", "result")
+	ExchangeRetriesTotal = Default.NewCounterVec("exchange_retries_total",
+		"Bybit API request retries after a network error or 5xx", "endpoint")
+	RateLimitWait = Default.NewHistogram("exchange_rate_limit_wait_seconds",
+		"Time spent waiting for the Bybit rate limiter", []float64{0.001, 0.01, 0.05, 0.1, 0.25, 0.5, 1})
+)
+
+// Риск
+var (
+	RiskExposureUSDT  = Default.NewGauge("risk_exposure_usdt", "Total invested across open positions")
+	RiskDailyLossUSDT = Default.NewGauge("risk_daily_loss_usdt", "Loss over the last 24 hours")
+	RiskDrawdown      = Default.NewGauge("risk_drawdown_percent", "Current drawdown of open positions")
+	RiskVolatility    = Default.NewGauge("risk_volatility_percent", "Max volatility of watched symbols")
+	RiskTrades24h     = Default.NewGauge("risk_trades_24h", "Trades over the last 24 hours")
+	PolicyRiskScore   = Default.NewGauge("policy_risk_score", "Risk score of the last policy check (0.0-1.0)")
+
+	PolicyValidationsTotal = Default.NewCounterVec("policy_validations_total",
+		"Policy engine validations by result", "result")
+	PolicyViolationsTotal = Default.NewCounterVec("policy_violations_total",
+		"Policy violations by type and severity", "type", "severity")
+
+	CircuitBreakerActive   = Default.NewGauge("circuit_breaker_active", "1 while a circuit breaker is tripped")
+	CircuitBreakerTriggers = Default.NewCounterVec("circuit_breaker_triggers_total",
+		"Circuit breaker trips by breaker type", "type")
+	KillSwitchActive = Default.NewGauge("kill_switch_active", "1 while the execution kill switch is on")
+)
+
+// AI
+var (
+	AIRequestDuration = Default.NewHistogramVec("ai_request_duration_seconds",
+		"LLM call latency by agent and provider", aiBuckets, "agent", "provider")
+	AIRequestsTotal = Default.NewCounterVec("ai_requests_total",
+		"LLM calls by agent, provider and result", "agent", "provider", "result")
+	AICostUSDTotal = Default.NewCounterVec("ai_cost_usd_total",
+		"LLM spend by agent and provider", "agent", "provider")
+	AICostTodayUSD   = Default.NewGauge("ai_cost_today_usd", "Cloud LLM spend today")
+	AIDailyBudgetUSD = Default.NewGauge("ai_daily_budget_usd", "Cloud LLM daily budget (0 - unlimited)")
+
+	AIRouterDuration = Default.NewHistogramVec("ai_router_request_duration_seconds",
+		"End-to-end AI router request latency by agent", aiBuckets, "agent")
+	AIRouterFallbacks = Default.NewCounter("ai_router_fallbacks_total",
+		"Low-confidence intents routed to chat")
+
+	AIDecisionsTotal = Default.NewCounterVec("ai_decisions_total",
+		"AI trading decisions by mode and regime", "mode", "regime")
+	AIDecisionConfidence = Default.NewGauge("ai_decision_confidence", "Confidence of the last AI decision")
+)
+
+func init() {
+	Default.NewGaugeFunc("portfolio_value_usdt", "Sum of position values at the last PnL snapshot", PositionValueUSDT.Sum)
+}
+
+// Handler отдает метрики Default для Prometheus
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// ObserveOrder учитывает ордер; err != nil - ордер отклонен биржей
+func ObserveOrder(symbol, side, strategy, status string, err error) {
+	if err != nil {
+		status = "FAILED"
+	} else if status == "" {
+		status = "PLACED"
+	}
+	OrdersTotal.With(symbol, strings.ToUpper(side), strategy, strings.ToUpper(status)).Inc()
+}
+
+// ObservePosition запоминает позицию из снимка PnL
+func ObservePosition(symbol string, value, realized, unrealized float64) {
+	PositionValueUSDT.With(symbol).Set(value)
+	PnLRealizedUSDT.With(symbol).Set(realized)
+	PnLUnrealizedUSDT.With(symbol).Set(unrealized)
+}
+
+// Since секунды с момента start
+func Since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
@@ -0,0 +1,342 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry набор метрик, отдаваемых в текстовом формате Prometheus 0.0.4
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]family
+}
+
+// family семейство метрик с одним именем
+type family interface {
+	write(w *bufio.Writer)
+}
+
+// NewRegistry создает пустой реестр
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]family)}
+}
+
+func (r *Registry) register(name string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.families[name]; exists {
+		panic(fmt.Sprintf("metrics: %s already registered", name))
+	}
+	r.families[name] = f
+}
+
+// NewCounterVec регистрирует счетчик с метками
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{newVec(name, help, "counter", labels, func() *Counter { return &Counter{} })}
+	r.register(name, v)
+	return v
+}
+
+// NewCounter регистрирует счетчик без меток
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).With()
+}
+
+// NewGaugeVec регистрирует gauge с метками
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	v := &GaugeVec{newVec(name, help, "gauge", labels, func() *Gauge { return &Gauge{} })}
+	r.register(name, v)
+	return v
+}
+
+// NewGauge регистрирует gauge без меток
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	return r.NewGaugeVec(name, help).With()
+}
+
+// NewGaugeFunc регистрирует gauge, значение которого считается в момент scrape
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(name, &gaugeFunc{name: name, help: help, fn: fn})
+}
+
+// NewHistogramVec регистрирует гистограмму с метками; buckets - верхние границы по возрастанию
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	v := &HistogramVec{newVec(name, help, "histogram", labels, func() *Histogram { return newHistogram(buckets) })}
+	r.register(name, v)
+	return v
+}
+
+// NewHistogram регистрирует гистограмму без меток
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	return r.NewHistogramVec(name, help, buckets).With()
+}
+
+// WriteTo пишет все метрики, отсортированные по имени
+func (r *Registry) WriteTo(w *bufio.Writer) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	families := make([]family, len(names))
+	for i, name := range names {
+		families[i] = r.families[name]
+	}
+	r.mu.Unlock()
+
+	for _, f := range families {
+		f.write(w)
+	}
+}
+
+// Handler отдает метрики реестра для Prometheus
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		buf := bufio.NewWriter(w)
+		r.WriteTo(buf)
+		buf.Flush()
+	})
+}
+
+// value float64 с атомарным обновлением
+type value struct {
+	bits uint64
+}
+
+func (v *value) add(delta float64) {
+	for {
+		old := atomic.LoadUint64(&v.bits)
+		updated := math.Float64bits(math.Float64frombits(old) + delta)
+		if atomic.CompareAndSwapUint64(&v.bits, old, updated) {
+			return
+		}
+	}
+}
+
+func (v *value) set(x float64) { atomic.StoreUint64(&v.bits, math.Float64bits(x)) }
+func (v *value) get() float64  { return math.Float64frombits(atomic.LoadUint64(&v.bits)) }
+
+// Counter монотонно растущий счетчик
+type Counter struct{ v value }
+
+// Inc увеличивает счетчик на 1
+func (c *Counter) Inc() { c.v.add(1) }
+
+// Add увеличивает счетчик; отрицательные значения игнорируются
+func (c *Counter) Add(delta float64) {
+	if delta > 0 {
+		c.v.add(delta)
+	}
+}
+
+// Value текущее значение
+func (c *Counter) Value() float64 { return c.v.get() }
+
+// Gauge произвольное значение
+type Gauge struct{ v value }
+
+func (g *Gauge) Set(x float64)     { g.v.set(x) }
+func (g *Gauge) Add(delta float64) { g.v.add(delta) }
+func (g *Gauge) Value() float64    { return g.v.get() }
+
+// SetBool 1 - true, 0 - false
+func (g *Gauge) SetBool(on bool) {
+	if on {
+		g.Set(1)
+		return
+	}
+	g.Set(0)
+}
+
+// Histogram распределение наблюдений по корзинам
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64 // не кумулятивные, по корзинам
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+// Observe добавляет наблюдение
+func (h *Histogram) Observe(x float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i := sort.SearchFloat64s(h.buckets, x); i < len(h.buckets) {
+		h.counts[i]++
+	}
+	h.sum += x
+	h.count++
+}
+
+// Count число наблюдений
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// vec дочерние метрики по значениям меток
+type vec[T any] struct {
+	name, help, kind string
+	labels           []string
+	create           func() *T
+
+	mu       sync.Mutex
+	children map[string]*child[T]
+}
+
+type child[T any] struct {
+	values []string
+	metric *T
+}
+
+func newVec[T any](name, help, kind string, labels []string, create func() *T) *vec[T] {
+	return &vec[T]{name: name, help: help, kind: kind, labels: labels, create: create, children: make(map[string]*child[T])}
+}
+
+// With возвращает метрику для значений меток (в порядке объявления)
+func (v *vec[T]) With(values ...string) *T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, ok := v.children[key]
+	if !ok {
+		c = &child[T]{values: append([]string(nil), values...), metric: v.create()}
+		v.children[key] = c
+	}
+	return c.metric
+}
+
+// sorted дочерние метрики в стабильном порядке
+func (v *vec[T]) sorted() []*child[T] {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	keys := make([]string, 0, len(v.children))
+	for key := range v.children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]*child[T], len(keys))
+	for i, key := range keys {
+		result[i] = v.children[key]
+	}
+	return result
+}
+
+func (v *vec[T]) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, escapeHelp(v.help), v.name, v.kind)
+}
+
+// CounterVec счетчики с метками
+type CounterVec struct{ *vec[Counter] }
+
+func (v *CounterVec) write(w *bufio.Writer) {
+	v.header(w)
+	for _, c := range v.sorted() {
+		writeSample(w, v.name, v.labels, c.values, c.metric.Value())
+	}
+}
+
+// GaugeVec gauge с метками
+type GaugeVec struct{ *vec[Gauge] }
+
+func (v *GaugeVec) write(w *bufio.Writer) {
+	v.header(w)
+	for _, c := range v.sorted() {
+		writeSample(w, v.name, v.labels, c.values, c.metric.Value())
+	}
+}
+
+// Sum сумма значений всех дочерних gauge
+func (v *GaugeVec) Sum() float64 {
+	total := 0.0
+	for _, c := range v.sorted() {
+		total += c.metric.Value()
+	}
+	return total
+}
+
+// HistogramVec гистограммы с метками
+type HistogramVec struct{ *vec[Histogram] }
+
+func (v *HistogramVec) write(w *bufio.Writer) {
+	v.header(w)
+	labels := append(append([]string(nil), v.labels...), "le")
+	for _, c := range v.sorted() {
+		h := c.metric
+		h.mu.Lock()
+		cumulative := uint64(0)
+		for i, bound := range h.buckets {
+			cumulative += h.counts[i]
+			writeSample(w, v.name+"_bucket", labels, append(append([]string(nil), c.values...), formatFloat(bound)), float64(cumulative))
+		}
+		writeSample(w, v.name+"_bucket", labels, append(append([]string(nil), c.values...), "+Inf"), float64(h.count))
+		writeSample(w, v.name+"_sum", v.labels, c.values, h.sum)
+		writeSample(w, v.name+"_count", v.labels, c.values, float64(h.count))
+		h.mu.Unlock()
+	}
+}
+
+type gaugeFunc struct {
+	name, help string
+	fn         func() float64
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, escapeHelp(g.help), g.name)
+	writeSample(w, g.name, nil, nil, g.fn())
+}
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", label, escapeLabel(values[i]))
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
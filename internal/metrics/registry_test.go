@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func render(r *Registry) string {
+	var sb strings.Builder
+	w := bufio.NewWriter(&sb)
+	r.WriteTo(w)
+	w.Flush()
+	return sb.String()
+}
+
+func TestRegistry_Exposition(t *testing.T) {
+	r := NewRegistry()
+	orders := r.NewCounterVec("orders_total", "Orders", "symbol", "side")
+	orders.With("BTCUSDT", "BUY").Inc()
+	orders.With("BTCUSDT", "BUY").Add(2)
+	orders.With("ETHUSDT", "SELL").Add(-5) // счетчик не уменьшается
+
+	value := r.NewGaugeVec("position_value_usdt", "Position value", "symbol")
+	value.With("BTCUSDT").Set(100.5)
+	value.With("ETH\"USDT").Set(20)
+	r.NewGaugeFunc("portfolio_value_usdt", "Portfolio", value.Sum)
+
+	latency := r.NewHistogram("latency_seconds", "Latency\nmultiline", []float64{0.1, 1})
+	latency.Observe(0.05)
+	latency.Observe(0.5)
+	latency.Observe(3)
+
+	want := `# HELP latency_seconds Latency\nmultiline
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="1"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 3.55
+latency_seconds_count 3
+# HELP orders_total Orders
+# TYPE orders_total counter
+orders_total{symbol="BTCUSDT",side="BUY"} 3
+orders_total{symbol="ETHUSDT",side="SELL"} 0
+# HELP portfolio_value_usdt Portfolio
+# TYPE portfolio_value_usdt gauge
+portfolio_value_usdt 120.5
+# HELP position_value_usdt Position value
+# TYPE position_value_usdt gauge
+position_value_usdt{symbol="BTCUSDT"} 100.5
+position_value_usdt{symbol="ETH\"USDT"} 20
+`
+	if got := render(r); got != want {
+		t.Errorf("exposition:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRegistry_Panics(t *testing.T) {
+	r := NewRegistry()
+	vec := r.NewCounterVec("x_total", "x", "a")
+
+	// Повторная регистрация и неверное число меток - ошибки программиста
+	for name, fn := range map[string]func(){
+		"duplicate":    func() { r.NewGauge("x_total", "x") },
+		"label values": func() { vec.With("a", "b") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestObserveOrder(t *testing.T) {
+	ObserveOrder("BTCUSDT", "Buy", "DCA", "Filled", nil)
+	ObserveOrder("BTCUSDT", "buy", "DCA", "", nil)
+	ObserveOrder("BTCUSDT", "BUY", "DCA", "Filled", errors.New("rejected"))
+
+	for status, want := range map[string]float64{"FILLED": 1, "PLACED": 1, "FAILED": 1} {
+		if got := OrdersTotal.With("BTCUSDT", "BUY", "DCA", status).Value(); got != want {
+			t.Errorf("%s = %v, want %v", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package news
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Типы источников
+const (
+	SourceTypeRSS     = "rss"
+	SourceTypeWebhook = "webhook"
+)
+
+// Config конфигурация ingestion pipeline (configs/news.yaml)
+type Config struct {
+	PollInterval    time.Duration       `yaml:"poll_interval"`
+	DedupWindow     time.Duration       `yaml:"dedup_window"`
+	MaxItemsPerPoll int                 `yaml:"max_items_per_poll"`
+	Sources         []SourceConfig      `yaml:"sources"`
+	Symbols         map[string][]string `yaml:"symbols"`
+	Topics          map[string][]string `yaml:"topics"`
+}
+
+// SourceConfig конфигурация одного источника
+type SourceConfig struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"` // rss, webhook
+	URL      string `yaml:"url"`
+	Enabled  bool   `yaml:"enabled"`
+	TokenEnv string `yaml:"token_env"` // для webhook
+}
+
+// LoadConfig загружает конфигурацию из YAML
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	if config.PollInterval <= 0 {
+		config.PollInterval = 10 * time.Minute
+	}
+	if config.DedupWindow <= 0 {
+		config.DedupWindow = 48 * time.Hour
+	}
+	if config.MaxItemsPerPoll <= 0 {
+		config.MaxItemsPerPoll = 50
+	}
+
+	for _, src := range config.Sources {
+		if src.Type != SourceTypeRSS && src.Type != SourceTypeWebhook {
+			return nil, fmt.Errorf("unknown source type %q for %s", src.Type, src.Name)
+		}
+		if src.Type == SourceTypeRSS && src.URL == "" {
+			return nil, fmt.Errorf("rss source %s has no url", src.Name)
+		}
+	}
+
+	return &config, nil
+}
+
+// Token возвращает токен webhook из переменной окружения
+func (s SourceConfig) Token() string {
+	if s.TokenEnv == "" {
+		return ""
+	}
+	return os.Getenv(s.TokenEnv)
+}
+
+// WebhookSource возвращает конфигурацию включенного webhook источника
+func (c *Config) WebhookSource() (SourceConfig, bool) {
+	for _, src := range c.Sources {
+		if src.Enabled && src.Type == SourceTypeWebhook {
+			return src, true
+		}
+	}
+	return SourceConfig{}, false
+}
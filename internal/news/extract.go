@@ -0,0 +1,62 @@
+package news
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Extractor извлекает символы и темы по ключевым словам
+type Extractor struct {
+	symbols map[string][]string
+	topics  map[string][]string
+}
+
+// NewExtractor создает extractor из словарей конфигурации
+func NewExtractor(symbols, topics map[string][]string) *Extractor {
+	return &Extractor{
+		symbols: symbols,
+		topics:  topics,
+	}
+}
+
+// Symbols возвращает торговые пары, упомянутые в тексте
+func (e *Extractor) Symbols(text string) []string {
+	return matchKeywords(text, e.symbols)
+}
+
+// Topics возвращает темы, упомянутые в тексте
+func (e *Extractor) Topics(text string) []string {
+	return matchKeywords(text, e.topics)
+}
+
+// matchKeywords ищет ключевые слова целыми словами (без учета регистра)
+func matchKeywords(text string, dictionary map[string][]string) []string {
+	normalized := " " + strings.Join(tokenize(text), " ") + " "
+
+	var matches []string
+	for key, keywords := range dictionary {
+		for _, kw := range keywords {
+			needle := " " + strings.Join(tokenize(kw), " ") + " "
+			if strings.Contains(normalized, needle) {
+				matches = append(matches, key)
+				break
+			}
+		}
+	}
+
+	sort.Strings(matches)
+	return matches
+}
+
+// tokenize разбивает текст на слова в нижнем регистре
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// DedupKey нормализованный ключ заголовка для дедупликации
+func DedupKey(headline string) string {
+	return strings.Join(tokenize(headline), " ")
+}
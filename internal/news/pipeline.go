@@ -0,0 +1,230 @@
+package news
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/pkg/utils"
+)
+
+// Store хранилище новостных сигналов (NewsSignalRepository)
+type Store interface {
+	Save(ctx context.Context, signal *domain.NewsSignal) error
+	ExistsSince(ctx context.Context, url, headline string, since time.Time) (bool, error)
+}
+
+// Pipeline ingestion pipeline: источники → дедупликация → извлечение → сентимент → news_signals
+type Pipeline struct {
+	config    *Config
+	sources   []Source
+	store     Store
+	scorer    SentimentScorer
+	fallback  SentimentScorer
+	extractor *Extractor
+
+	mu       sync.Mutex
+	seen     map[string]time.Time
+	stopChan chan struct{}
+	running  bool
+}
+
+// NewPipeline создает pipeline и RSS источники из конфигурации.
+// scorer может быть nil - тогда используется оценка по ключевым словам.
+func NewPipeline(config *Config, store Store, scorer SentimentScorer) *Pipeline {
+	p := &Pipeline{
+		config:    config,
+		store:     store,
+		scorer:    scorer,
+		fallback:  KeywordScorer{},
+		extractor: NewExtractor(config.Symbols, config.Topics),
+		seen:      make(map[string]time.Time),
+	}
+
+	for _, src := range config.Sources {
+		if src.Enabled && src.Type == SourceTypeRSS {
+			p.sources = append(p.sources, NewRSSSource(src.Name, src.URL))
+		}
+	}
+
+	return p
+}
+
+// AddSource добавляет пользовательский источник
+func (p *Pipeline) AddSource(source Source) {
+	p.sources = append(p.sources, source)
+}
+
+// Start запускает периодический опрос источников
+func (p *Pipeline) Start(ctx context.Context) {
+	p.mu.Lock()
+	if p.running {
+		p.mu.Unlock()
+		return
+	}
+	p.running = true
+	p.stopChan = make(chan struct{})
+	stopChan := p.stopChan
+	p.mu.Unlock()
+
+	utils.LogInfo(fmt.Sprintf("📰 News pipeline started: %d sources, interval %v", len(p.sources), p.config.PollInterval))
+
+	go func() {
+		ticker := time.NewTicker(p.config.PollInterval)
+		defer ticker.Stop()
+
+		p.poll(ctx)
+		for {
+			select {
+			case <-ticker.C:
+				p.poll(ctx)
+			case <-stopChan:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop останавливает опрос
+func (p *Pipeline) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.running {
+		return
+	}
+	close(p.stopChan)
+	p.running = false
+}
+
+// poll опрашивает все источники
+func (p *Pipeline) poll(ctx context.Context) {
+	for _, src := range p.sources {
+		items, err := src.Fetch(ctx)
+		if err != nil {
+			utils.LogWarn(fmt.Sprintf("News source %s failed: %v", src.Name(), err))
+			continue
+		}
+
+		if len(items) > p.config.MaxItemsPerPoll {
+			items = items[:p.config.MaxItemsPerPoll]
+		}
+
+		saved, err := p.Ingest(ctx, items)
+		if err != nil {
+			utils.LogWarn(fmt.Sprintf("News ingest from %s failed: %v", src.Name(), err))
+		}
+		if saved > 0 {
+			utils.LogInfo(fmt.Sprintf("📰 %s: saved %d new signals", src.Name(), saved))
+		}
+	}
+
+	p.cleanupSeen()
+}
+
+// Ingest обрабатывает новости и сохраняет новые сигналы. Возвращает число сохраненных.
+func (p *Pipeline) Ingest(ctx context.Context, items []Item) (int, error) {
+	saved := 0
+	since := time.Now().Add(-p.config.DedupWindow)
+
+	for _, item := range items {
+		item.Headline = strings.TrimSpace(item.Headline)
+		if item.Headline == "" {
+			continue
+		}
+
+		key := DedupKey(item.Headline)
+		if p.isSeen(key) {
+			continue
+		}
+
+		exists, err := p.store.ExistsSince(ctx, item.URL, item.Headline, since)
+		if err != nil {
+			return saved, fmt.Errorf("dedup check failed: %w", err)
+		}
+		if exists {
+			p.markSeen(key)
+			continue
+		}
+
+		signal := p.buildSignal(ctx, item)
+		if err := p.store.Save(ctx, signal); err != nil {
+			return saved, fmt.Errorf("failed to save news signal: %w", err)
+		}
+
+		p.markSeen(key)
+		saved++
+	}
+
+	return saved, nil
+}
+
+// buildSignal извлекает символы/темы и оценивает сентимент
+func (p *Pipeline) buildSignal(ctx context.Context, item Item) *domain.NewsSignal {
+	text := item.Headline + " " + item.Summary
+
+	sentiment, err := p.score(ctx, item)
+	if err != nil {
+		utils.LogWarn(fmt.Sprintf("Sentiment scoring failed, using keywords: %v", err))
+		sentiment, _ = p.fallback.Score(ctx, item.Headline, item.Summary)
+	}
+
+	timestamp := item.PublishedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	return &domain.NewsSignal{
+		Timestamp:      timestamp,
+		Source:         item.Source,
+		Headline:       item.Headline,
+		URL:            item.URL,
+		Sentiment:      sentiment.Label,
+		SentimentScore: sentiment.Score,
+		Topics:         p.extractor.Topics(text),
+		Signal:         sentiment.Signal,
+		Symbols:        p.extractor.Symbols(text),
+		Processed:      false,
+	}
+}
+
+// score оценивает сентимент основным scorer
+func (p *Pipeline) score(ctx context.Context, item Item) (*Sentiment, error) {
+	if p.scorer == nil {
+		return p.fallback.Score(ctx, item.Headline, item.Summary)
+	}
+	return p.scorer.Score(ctx, item.Headline, item.Summary)
+}
+
+// isSeen проверяет in-memory кеш дедупликации
+func (p *Pipeline) isSeen(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	seenAt, ok := p.seen[key]
+	return ok && time.Since(seenAt) < p.config.DedupWindow
+}
+
+// markSeen добавляет ключ в кеш дедупликации
+func (p *Pipeline) markSeen(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.seen[key] = time.Now()
+}
+
+// cleanupSeen удаляет устаревшие ключи
+func (p *Pipeline) cleanupSeen() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, seenAt := range p.seen {
+		if time.Since(seenAt) > p.config.DedupWindow {
+			delete(p.seen, key)
+		}
+	}
+}
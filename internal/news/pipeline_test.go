@@ -0,0 +1,133 @@
+package news
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// memoryStore хранилище сигналов в памяти для тестов
+type memoryStore struct {
+	signals []domain.NewsSignal
+}
+
+func (s *memoryStore) Save(ctx context.Context, signal *domain.NewsSignal) error {
+	signal.ID = int64(len(s.signals) + 1)
+	if signal.IngestedAt.IsZero() {
+		signal.IngestedAt = time.Now()
+	}
+	s.signals = append(s.signals, *signal)
+	return nil
+}
+
+// ExistsSince как в NewsSignalRepository: URL - за все время, заголовок - загруженный после since
+func (s *memoryStore) ExistsSince(ctx context.Context, url, headline string, since time.Time) (bool, error) {
+	for _, sig := range s.signals {
+		if url != "" && sig.URL == url {
+			return true, nil
+		}
+		if !sig.IngestedAt.Before(since) && strings.EqualFold(sig.Headline, headline) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func testConfig() *Config {
+	return &Config{
+		DedupWindow:     time.Hour,
+		MaxItemsPerPoll: 10,
+		Symbols: map[string][]string{
+			"BTCUSDT": {"bitcoin", "btc"},
+			"ETHUSDT": {"ethereum", "eth"},
+		},
+		Topics: map[string][]string{
+			"etf":      {"etf"},
+			"security": {"hack", "hacked", "exploit"},
+		},
+	}
+}
+
+func TestPipeline_Ingest(t *testing.T) {
+	store := &memoryStore{}
+	p := NewPipeline(testConfig(), store, nil)
+
+	items := []Item{
+		{Source: "rss", Headline: "SEC approves Bitcoin ETF", URL: "https://x/1"},
+		{Source: "rss", Headline: "sec approves bitcoin etf!", URL: "https://x/2"}, // дубль заголовка
+		{Source: "rss", Headline: "Ethereum bridge hacked", URL: "https://x/3"},
+		{Source: "rss", Headline: "   "},
+	}
+
+	saved, err := p.Ingest(context.Background(), items)
+	if err != nil {
+		t.Fatalf("Ingest() error = %v", err)
+	}
+	if saved != 2 {
+		t.Fatalf("Ingest() saved = %d, want 2", saved)
+	}
+
+	btc := store.signals[0]
+	if !reflect.DeepEqual(btc.Symbols, []string{"BTCUSDT"}) {
+		t.Errorf("Symbols = %v, want [BTCUSDT]", btc.Symbols)
+	}
+	if !reflect.DeepEqual(btc.Topics, []string{"etf"}) {
+		t.Errorf("Topics = %v, want [etf]", btc.Topics)
+	}
+	if btc.Sentiment != "positive" {
+		t.Errorf("Sentiment = %q, want positive", btc.Sentiment)
+	}
+
+	hack := store.signals[1]
+	if hack.Sentiment != "negative" || hack.SentimentScore >= 0 {
+		t.Errorf("hack sentiment = %q (%.2f), want negative", hack.Sentiment, hack.SentimentScore)
+	}
+	if !reflect.DeepEqual(hack.Topics, []string{"security"}) {
+		t.Errorf("Topics = %v, want [security]", hack.Topics)
+	}
+
+	// Повторная загрузка не создает дублей
+	saved, err = p.Ingest(context.Background(), items)
+	if err != nil || saved != 0 {
+		t.Errorf("second Ingest() saved = %d, err = %v, want 0", saved, err)
+	}
+}
+
+func TestPipeline_IngestOldArticleAfterRestart(t *testing.T) {
+	store := &memoryStore{}
+	// Статья опубликована давно (дольше окна дедупликации), RSS продолжает ее отдавать
+	old := Item{Source: "rss", Headline: "Bitcoin ETF approved", URL: "https://example.com/etf", PublishedAt: time.Now().Add(-30 * 24 * time.Hour)}
+
+	if saved, err := NewPipeline(testConfig(), store, nil).Ingest(context.Background(), []Item{old}); err != nil || saved != 1 {
+		t.Fatalf("first ingest: saved=%d err=%v", saved, err)
+	}
+
+	// Новый pipeline - пустой кэш в памяти, как после рестарта или cleanupSeen
+	if saved, err := NewPipeline(testConfig(), store, nil).Ingest(context.Background(), []Item{old}); err != nil || saved != 0 {
+		t.Errorf("after restart: saved=%d err=%v, want duplicate skipped", saved, err)
+	}
+
+	// Заголовок без URL - дубликат только в окне от времени загрузки
+	store.signals[0].URL = ""
+	store.signals[0].IngestedAt = time.Now().Add(-2 * time.Hour)
+	repost := Item{Source: "webhook", Headline: old.Headline, PublishedAt: old.PublishedAt}
+	if saved, _ := NewPipeline(testConfig(), store, nil).Ingest(context.Background(), []Item{repost}); saved != 1 {
+		t.Errorf("headline outside window: saved=%d, want 1", saved)
+	}
+}
+
+func TestExtractor_WholeWords(t *testing.T) {
+	e := NewExtractor(testConfig().Symbols, nil)
+
+	// "ethos" и "btcx" не должны совпадать с eth/btc
+	if got := e.Symbols("Ethos protocol lists BTCX token"); len(got) != 0 {
+		t.Errorf("Symbols() = %v, want none", got)
+	}
+	if got := e.Symbols("ETH and BTC rally"); !reflect.DeepEqual(got, []string{"BTCUSDT", "ETHUSDT"}) {
+		t.Errorf("Symbols() = %v, want [BTCUSDT ETHUSDT]", got)
+	}
+}
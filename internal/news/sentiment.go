@@ -0,0 +1,135 @@
+package news
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Sentiment результат оценки новости
+type Sentiment struct {
+	Label  string  `json:"sentiment"` // positive, negative, neutral
+	Score  float64 `json:"score"`     // -1.0 .. 1.0
+	Signal string  `json:"signal"`    // BUY, SELL, HOLD
+}
+
+// SentimentScorer оценивает сентимент новости
+type SentimentScorer interface {
+	Score(ctx context.Context, headline, summary string) (*Sentiment, error)
+}
+
+// Completer LLM клиент (ai.AIClient для локальной модели Ollama)
+type Completer interface {
+	Complete(systemPrompt, userPrompt string) (string, error)
+}
+
+// LLMScorer оценка сентимента локальной моделью
+type LLMScorer struct {
+	client Completer
+}
+
+// NewLLMScorer создает scorer на базе LLM
+func NewLLMScorer(client Completer) *LLMScorer {
+	return &LLMScorer{client: client}
+}
+
+const sentimentSystemPrompt = `You are a crypto news sentiment classifier.
+Classify the market impact of the headline for crypto prices.
+Return ONLY JSON: {"sentiment": "positive|negative|neutral", "score": -1.0..1.0, "signal": "BUY|SELL|HOLD"}`
+
+// Score оценивает сентимент через LLM
+func (s *LLMScorer) Score(ctx context.Context, headline, summary string) (*Sentiment, error) {
+	prompt := "Headline: " + headline
+	if summary != "" {
+		prompt += "\nSummary: " + truncate(summary, 500)
+	}
+
+	response, err := s.client.Complete(sentimentSystemPrompt, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("sentiment request failed: %w", err)
+	}
+
+	// Модель может обернуть JSON в текст - берем первый объект
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start == -1 || end <= start {
+		return nil, fmt.Errorf("no JSON in sentiment response: %s", truncate(response, 100))
+	}
+
+	var result Sentiment
+	if err := json.Unmarshal([]byte(response[start:end+1]), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse sentiment: %w", err)
+	}
+
+	return normalizeSentiment(&result), nil
+}
+
+// KeywordScorer простая оценка по словарю (fallback при недоступности LLM)
+type KeywordScorer struct{}
+
+var (
+	positiveWords = []string{"approve", "approves", "approved", "surge", "surges", "rally", "record", "inflows", "adoption", "bullish", "gains", "soars", "partnership", "launch", "launches"}
+	negativeWords = []string{"hack", "hacked", "exploit", "ban", "bans", "lawsuit", "sues", "crash", "plunge", "plunges", "outflows", "bearish", "fraud", "stolen", "liquidation", "collapse"}
+)
+
+// Score оценивает сентимент по ключевым словам
+func (KeywordScorer) Score(ctx context.Context, headline, summary string) (*Sentiment, error) {
+	words := tokenize(headline)
+	score := 0.0
+	for _, w := range words {
+		for _, p := range positiveWords {
+			if w == p {
+				score += 0.4
+			}
+		}
+		for _, n := range negativeWords {
+			if w == n {
+				score -= 0.4
+			}
+		}
+	}
+
+	return normalizeSentiment(&Sentiment{Score: score}), nil
+}
+
+// normalizeSentiment ограничивает score и выводит label/signal из score при необходимости
+func normalizeSentiment(s *Sentiment) *Sentiment {
+	s.Score = math.Max(-1, math.Min(1, s.Score))
+	s.Label = strings.ToLower(s.Label)
+	s.Signal = strings.ToUpper(s.Signal)
+
+	if s.Label != "positive" && s.Label != "negative" && s.Label != "neutral" {
+		switch {
+		case s.Score > 0.2:
+			s.Label = "positive"
+		case s.Score < -0.2:
+			s.Label = "negative"
+		default:
+			s.Label = "neutral"
+		}
+	}
+
+	if s.Signal != "BUY" && s.Signal != "SELL" && s.Signal != "HOLD" {
+		switch {
+		case s.Score >= 0.5:
+			s.Signal = "BUY"
+		case s.Score <= -0.5:
+			s.Signal = "SELL"
+		default:
+			s.Signal = "HOLD"
+		}
+	}
+
+	return s
+}
+
+// truncate обрезает строку до n символов
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
@@ -0,0 +1,199 @@
+package news
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Item сырая новость из источника
+type Item struct {
+	Source      string    `json:"source"`
+	Headline    string    `json:"headline"`
+	URL         string    `json:"url"`
+	Summary     string    `json:"summary,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Source источник новостей
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context) ([]Item, error)
+}
+
+// RSSSource источник RSS 2.0 / Atom
+type RSSSource struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+// NewRSSSource создает RSS/Atom источник
+func NewRSSSource(name, url string) *RSSSource {
+	return &RSSSource{
+		name:   name,
+		url:    url,
+		client: &http.Client{Timeout: 20 * time.Second},
+	}
+}
+
+// Name возвращает имя источника
+func (s *RSSSource) Name() string {
+	return s.name
+}
+
+// Fetch загружает и разбирает фид
+func (s *RSSSource) Fetch(ctx context.Context) ([]Item, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "dca-bot/2.0 news-ingest")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", s.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed %s returned status %d", s.name, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 5<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed %s: %w", s.name, err)
+	}
+
+	return ParseFeed(s.name, body)
+}
+
+// rssFeed структура RSS 2.0
+type rssFeed struct {
+	Channel struct {
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			PubDate     string `xml:"pubDate"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// atomFeed структура Atom
+type atomFeed struct {
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+		Updated   string `xml:"updated"`
+		Published string `xml:"published"`
+	} `xml:"entry"`
+}
+
+// ParseFeed разбирает RSS 2.0 или Atom документ
+func ParseFeed(source string, data []byte) ([]Item, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid feed %s: %w", source, err)
+	}
+
+	var items []Item
+
+	switch strings.ToLower(root.XMLName.Local) {
+	case "rss":
+		var feed rssFeed
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return nil, fmt.Errorf("invalid rss feed %s: %w", source, err)
+		}
+		for _, it := range feed.Channel.Items {
+			items = append(items, Item{
+				Source:      source,
+				Headline:    strings.TrimSpace(it.Title),
+				URL:         strings.TrimSpace(it.Link),
+				Summary:     stripTags(it.Description),
+				PublishedAt: parseTime(it.PubDate),
+			})
+		}
+
+	case "feed":
+		var feed atomFeed
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return nil, fmt.Errorf("invalid atom feed %s: %w", source, err)
+		}
+		for _, e := range feed.Entries {
+			link := ""
+			for _, l := range e.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					link = l.Href
+					break
+				}
+			}
+			summary := e.Summary
+			if summary == "" {
+				summary = e.Content
+			}
+			published := e.Published
+			if published == "" {
+				published = e.Updated
+			}
+			items = append(items, Item{
+				Source:      source,
+				Headline:    strings.TrimSpace(e.Title),
+				URL:         strings.TrimSpace(link),
+				Summary:     stripTags(summary),
+				PublishedAt: parseTime(published),
+			})
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported feed format %q in %s", root.XMLName.Local, source)
+	}
+
+	return items, nil
+}
+
+// parseTime разбирает дату в форматах RSS/Atom
+func parseTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	layouts := []string{
+		time.RFC1123Z,
+		time.RFC1123,
+		time.RFC3339,
+		"Mon, 2 Jan 2006 15:04:05 -0700",
+		"Mon, 2 Jan 2006 15:04:05 MST",
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
+
+// stripTags удаляет HTML теги из описания
+func stripTags(s string) string {
+	var sb strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+		case !inTag:
+			sb.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
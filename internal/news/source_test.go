@@ -0,0 +1,61 @@
+package news
+
+import "testing"
+
+func TestParseFeed(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		wantCount    int
+		wantHeadline string
+		wantURL      string
+		wantErr      bool
+	}{
+		{
+			name: "rss",
+			data: `<?xml version="1.0"?>
+<rss version="2.0"><channel>
+<item><title> SEC approves spot Bitcoin ETF </title><link>https://example.com/a</link>
+<description><![CDATA[<p>Big <b>news</b></p>]]></description><pubDate>Mon, 02 Jan 2006 15:04:05 -0700</pubDate></item>
+<item><title>Second</title><link>https://example.com/b</link></item>
+</channel></rss>`,
+			wantCount:    2,
+			wantHeadline: "SEC approves spot Bitcoin ETF",
+			wantURL:      "https://example.com/a",
+		},
+		{
+			name: "atom",
+			data: `<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+<entry><title>Ethereum upgrade ships</title><link rel="alternate" href="https://example.com/eth"/>
+<summary>Details</summary><updated>2006-01-02T15:04:05Z</updated></entry>
+</feed>`,
+			wantCount:    1,
+			wantHeadline: "Ethereum upgrade ships",
+			wantURL:      "https://example.com/eth",
+		},
+		{"unknown root", `<html></html>`, 0, "", "", true},
+		{"invalid xml", `not xml`, 0, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := ParseFeed("test", []byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFeed() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(items) != tt.wantCount {
+				t.Fatalf("ParseFeed() items = %d, want %d", len(items), tt.wantCount)
+			}
+			if tt.wantCount == 0 {
+				return
+			}
+			if items[0].Headline != tt.wantHeadline {
+				t.Errorf("Headline = %q, want %q", items[0].Headline, tt.wantHeadline)
+			}
+			if items[0].URL != tt.wantURL {
+				t.Errorf("URL = %q, want %q", items[0].URL, tt.wantURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package news
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// WebhookHandler принимает новости в JSON (объект или массив Item)
+type WebhookHandler struct {
+	pipeline *Pipeline
+	source   string
+	token    string
+}
+
+// NewWebhookHandler создает обработчик webhook. С пустым token webhook отклоняет все запросы:
+// новости без проверки отправителя попали бы в AI решения
+func NewWebhookHandler(pipeline *Pipeline, source, token string) *WebhookHandler {
+	return &WebhookHandler{
+		pipeline: pipeline,
+		source:   source,
+		token:    token,
+	}
+}
+
+// ServeHTTP обрабатывает POST запрос с новостями
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"success": false, "error": "Method not allowed"})
+		return
+	}
+
+	if h.token == "" {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{"success": false, "error": "Webhook token is not configured"})
+		return
+	}
+	got := r.Header.Get("X-Webhook-Token")
+	if subtle.ConstantTimeCompare([]byte(got), []byte(h.token)) != 1 {
+		writeJSON(w, http.StatusUnauthorized, map[string]interface{}{"success": false, "error": "Unauthorized"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"success": false, "error": "Failed to read body"})
+		return
+	}
+
+	items, err := decodeItems(body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"success": false, "error": "Invalid JSON: " + err.Error()})
+		return
+	}
+
+	for i := range items {
+		if items[i].Source == "" {
+			items[i].Source = h.source
+		}
+	}
+
+	saved, err := h.pipeline.Ingest(r.Context(), items)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"success": false, "error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]int{
+			"received": len(items),
+			"saved":    saved,
+		},
+	})
+}
+
+// decodeItems разбирает один объект или массив новостей
+func decodeItems(body []byte) ([]Item, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var items []Item
+		err := json.Unmarshal(body, &items)
+		return items, err
+	}
+
+	var item Item
+	if err := json.Unmarshal(body, &item); err != nil {
+		return nil, err
+	}
+	return []Item{item}, nil
+}
+
+// writeJSON отправляет JSON ответ
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,42 @@
+package news
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhookHandler_Token(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   int
+		saved  int
+	}{
+		// Без токена webhook закрыт: иначе любой мог бы подмешать новости в решения
+		{"token not configured", "", "", http.StatusServiceUnavailable, 0},
+		{"missing header", "secret", "", http.StatusUnauthorized, 0},
+		{"wrong token", "secret", "guess", http.StatusUnauthorized, 0},
+		{"valid token", "secret", "secret", http.StatusOK, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryStore{}
+			h := NewWebhookHandler(NewPipeline(testConfig(), store, nil), "webhook", tt.token)
+
+			req := httptest.NewRequest(http.MethodPost, "/news/webhook", strings.NewReader(`{"headline":"Bitcoin ETF approved"}`))
+			if tt.header != "" {
+				req.Header.Set("X-Webhook-Token", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want || len(store.signals) != tt.saved {
+				t.Errorf("status = %d, saved = %d; want %d, %d (%s)", rec.Code, len(store.signals), tt.want, tt.saved, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/events"
+	"github.com/kirillm/dca-bot/pkg/utils"
+)
+
+const (
+	// ConfigKeyMode ключ config_params с текущим режимом
+	ConfigKeyMode = "orchestrator_mode"
+	// ConfigKeyModeSince ключ config_params со временем входа в режим (RFC3339)
+	ConfigKeyModeSince = "orchestrator_mode_since"
+
+	// approvalTTL время жизни незавершенного запроса на повышение режима
+	approvalTTL = 15 * time.Minute
+)
+
+var (
+	ErrInvalidMode          = errors.New("invalid mode (valid: shadow, pilot, full)")
+	ErrSameMode             = errors.New("mode already active")
+	ErrTransitionNotAllowed = errors.New("transition not allowed")
+	ErrInsufficientHistory  = errors.New("insufficient shadow history")
+	ErrDuplicateApproval    = errors.New("admin already approved this transition")
+)
+
+// ModeStore интерфейс персистентного хранения режима (config_params, ConfigRepository)
+type ModeStore interface {
+	Set(ctx context.Context, key, value string) error
+	Get(ctx context.Context, key string) (string, error)
+}
+
+// ModeControllerConfig правила переходов между режимами
+type ModeControllerConfig struct {
+	MinShadowDuration time.Duration // Минимальное время в shadow перед переходом в pilot
+	PilotApprovals    int           // Число подтверждений админов для pilot
+	FullApprovals     int           // Число различных админов для full
+}
+
+// DefaultModeControllerConfig возвращает правила по умолчанию
+func DefaultModeControllerConfig() ModeControllerConfig {
+	return ModeControllerConfig{
+		MinShadowDuration: 7 * 24 * time.Hour,
+		PilotApprovals:    1,
+		FullApprovals:     2,
+	}
+}
+
+// modeRequest незавершенный запрос на повышение режима
+type modeRequest struct {
+	target    Mode
+	approvals map[int64]bool
+	createdAt time.Time
+}
+
+// ModeController единый потокобезопасный контроллер режима.
+// Является источником истины для Orchestrator и DecisionAgent.
+type ModeController struct {
+	mu         sync.RWMutex
+	mode       Mode
+	since      time.Time
+	pending    *modeRequest
+	config     ModeControllerConfig
+	store      ModeStore
+	notifyFunc func(string)
+	events     EventPublisher
+	logger     *utils.Logger
+}
+
+// NewModeController создает контроллер режима.
+// Если в store уже сохранен режим, он имеет приоритет над defaultMode.
+func NewModeController(defaultMode Mode, config ModeControllerConfig, store ModeStore, notifyFunc func(string)) *ModeController {
+	mc := &ModeController{
+		mode:       defaultMode,
+		since:      time.Now(),
+		config:     config,
+		store:      store,
+		notifyFunc: notifyFunc,
+		logger:     utils.DefaultLogger().With("component", "mode_controller"),
+	}
+
+	if !IsValidMode(defaultMode) {
+		mc.mode = ModeShadow
+	}
+
+	mc.load()
+	return mc
+}
+
+// IsValidMode проверяет корректность режима
+func IsValidMode(mode Mode) bool {
+	return mode == ModeShadow || mode == ModePilot || mode == ModeFull
+}
+
+// modeRank возвращает уровень автономии режима
+func modeRank(mode Mode) int {
+	switch mode {
+	case ModePilot:
+		return 1
+	case ModeFull:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// load восстанавливает режим из config_params
+func (mc *ModeController) load() {
+	if mc.store == nil {
+		return
+	}
+
+	value, err := mc.store.Get(context.Background(), ConfigKeyMode)
+	if err != nil {
+		mc.logger.Warn("⚠️ Failed to load orchestrator mode: %v", err)
+		return
+	}
+
+	if value == "" {
+		// Первый запуск - сохраняем режим по умолчанию
+		if err := mc.persist(mc.mode, mc.since); err != nil {
+			mc.logger.Warn("⚠️ Failed to persist orchestrator mode: %v", err)
+		}
+		return
+	}
+
+	if !IsValidMode(Mode(value)) {
+		mc.logger.Warn("⚠️ Invalid stored orchestrator mode %q, keeping %s", value, mc.mode)
+		return
+	}
+	mc.mode = Mode(value)
+
+	if sinceStr, err := mc.store.Get(context.Background(), ConfigKeyModeSince); err == nil && sinceStr != "" {
+		if since, err := time.Parse(time.RFC3339, sinceStr); err == nil {
+			mc.since = since
+		}
+	}
+}
+
+// persist сохраняет режим в config_params
+func (mc *ModeController) persist(mode Mode, since time.Time) error {
+	if mc.store == nil {
+		return nil
+	}
+	ctx := context.Background()
+	if err := mc.store.Set(ctx, ConfigKeyMode, string(mode)); err != nil {
+		return err
+	}
+	return mc.store.Set(ctx, ConfigKeyModeSince, since.Format(time.RFC3339))
+}
+
+// GetMode возвращает текущий режим
+func (mc *ModeController) GetMode() Mode {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return mc.mode
+}
+
+// CurrentMode возвращает текущий режим строкой
+func (mc *ModeController) CurrentMode() string {
+	return string(mc.GetMode())
+}
+
+// Since возвращает время входа в текущий режим
+func (mc *ModeController) Since() time.Time {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return mc.since
+}
+
+// RequestMode запрашивает смену режима от имени администратора.
+// Понижение режима применяется сразу. Повышение требует подтверждений:
+// shadow→pilot - истории в shadow и подтверждения админа, pilot→full - двух разных админов.
+// Возвращает true, если режим был изменен, и false, если запрос ожидает подтверждений.
+func (mc *ModeController) RequestMode(mode string, adminID int64) (bool, error) {
+	target := Mode(mode)
+	if !IsValidMode(target) {
+		return false, fmt.Errorf("%w: %s", ErrInvalidMode, mode)
+	}
+
+	mc.mu.Lock()
+
+	current := mc.mode
+	if target == current {
+		mc.mu.Unlock()
+		return false, ErrSameMode
+	}
+
+	// Понижение всегда разрешено
+	if modeRank(target) < modeRank(current) {
+		if err := mc.applyLocked(target, fmt.Sprintf("admin %d", adminID)); err != nil {
+			mc.logger.Warn("⚠️ Failed to persist demoted mode: %v", err)
+		}
+		mc.mu.Unlock()
+		mc.notify(fmt.Sprintf("🔽 Режим понижен: %s → %s (admin %d)", current, target, adminID))
+		return true, nil
+	}
+
+	// Повышение только на один уровень
+	if modeRank(target)-modeRank(current) > 1 {
+		mc.mu.Unlock()
+		return false, fmt.Errorf("%w: %s → %s (switch to pilot first)", ErrTransitionNotAllowed, current, target)
+	}
+
+	required := mc.config.FullApprovals
+	if target == ModePilot {
+		shadowTime := time.Since(mc.since)
+		if shadowTime < mc.config.MinShadowDuration {
+			mc.mu.Unlock()
+			return false, fmt.Errorf("%w: %s in shadow, need %s",
+				ErrInsufficientHistory,
+				shadowTime.Round(time.Minute),
+				mc.config.MinShadowDuration,
+			)
+		}
+		required = mc.config.PilotApprovals
+	}
+	if required < 1 {
+		required = 1
+	}
+
+	// Новый запрос или запрос на другой режим сбрасывает подтверждения
+	if mc.pending == nil || mc.pending.target != target || time.Since(mc.pending.createdAt) > approvalTTL {
+		mc.pending = &modeRequest{
+			target:    target,
+			approvals: make(map[int64]bool),
+			createdAt: time.Now(),
+		}
+	}
+
+	if mc.pending.approvals[adminID] {
+		mc.mu.Unlock()
+		return false, ErrDuplicateApproval
+	}
+	mc.pending.approvals[adminID] = true
+	approvals := len(mc.pending.approvals)
+
+	if approvals < required {
+		mc.mu.Unlock()
+		mc.notify(fmt.Sprintf("⏳ Запрос на режим %s: %d/%d подтверждений админов (admin %d)",
+			target, approvals, required, adminID))
+		return false, nil
+	}
+
+	err := mc.applyLocked(target, fmt.Sprintf("%d approvals", approvals))
+	mc.mu.Unlock()
+	if err != nil {
+		return false, err
+	}
+
+	mc.notify(fmt.Sprintf("🔼 Режим повышен: %s → %s (%d подтверждений)", current, target, approvals))
+	return true, nil
+}
+
+// Demote принудительно переводит в shadow (например, при срабатывании circuit breaker)
+func (mc *ModeController) Demote(reason string) {
+	mc.mu.Lock()
+	current := mc.mode
+	if current == ModeShadow {
+		mc.pending = nil
+		mc.mu.Unlock()
+		return
+	}
+
+	if err := mc.applyLocked(ModeShadow, reason); err != nil {
+		// Режим в памяти уже понижен, ошибку сохранения только логируем
+		mc.logger.Warn("⚠️ Failed to persist demoted mode: %v", err)
+	}
+	mc.mu.Unlock()
+
+	mc.notify(fmt.Sprintf("🚨 Авто-понижение режима: %s → %s\nПричина: %s", current, ModeShadow, reason))
+}
+
+// SetEventPublisher подключает шину событий (mode.changed)
+func (mc *ModeController) SetEventPublisher(publisher EventPublisher) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.events = publisher
+}
+
+// applyLocked применяет режим (вызывается под mc.mu)
+func (mc *ModeController) applyLocked(target Mode, reason string) error {
+	now := time.Now()
+	previous := mc.mode
+
+	// Понижение применяется даже если сохранить не удалось
+	mc.pending = nil
+	if modeRank(target) < modeRank(previous) {
+		mc.mode = target
+		mc.since = now
+		mc.logger.Info("🔄 Switching mode: %s → %s", previous, target)
+		mc.publishLocked(previous, target, reason)
+		return mc.persist(target, now)
+	}
+
+	if err := mc.persist(target, now); err != nil {
+		return fmt.Errorf("failed to persist mode: %w", err)
+	}
+	mc.mode = target
+	mc.since = now
+	mc.logger.Info("🔄 Switching mode: %s → %s", previous, target)
+	mc.publishLocked(previous, target, reason)
+	return nil
+}
+
+func (mc *ModeController) publishLocked(from, to Mode, reason string) {
+	if mc.events != nil {
+		mc.events.Publish(events.ModeChanged, events.ModeData{From: string(from), To: string(to), Reason: reason})
+	}
+}
+
+// notify отправляет уведомление о смене режима
+func (mc *ModeController) notify(message string) {
+	if mc.notifyFunc != nil {
+		mc.notifyFunc(message)
+	}
+}
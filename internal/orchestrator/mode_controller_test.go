@@ -0,0 +1,141 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/events"
+)
+
+// memoryModeStore хранилище config_params в памяти для тестов
+type memoryModeStore struct {
+	params map[string]string
+}
+
+func newMemoryModeStore() *memoryModeStore {
+	return &memoryModeStore{params: make(map[string]string)}
+}
+
+func (s *memoryModeStore) Set(ctx context.Context, key, value string) error {
+	s.params[key] = value
+	return nil
+}
+
+func (s *memoryModeStore) Get(ctx context.Context, key string) (string, error) {
+	return s.params[key], nil
+}
+
+func TestModeController_Transitions(t *testing.T) {
+	cfg := ModeControllerConfig{MinShadowDuration: time.Hour, PilotApprovals: 1, FullApprovals: 2}
+
+	tests := []struct {
+		name        string
+		start       Mode
+		shadowAge   time.Duration
+		target      string
+		wantApplied bool
+		wantErr     error
+		wantMode    Mode
+	}{
+		{"invalid mode", ModeShadow, 2 * time.Hour, "turbo", false, ErrInvalidMode, ModeShadow},
+		{"same mode", ModePilot, 0, "pilot", false, ErrSameMode, ModePilot},
+		{"demote full to shadow", ModeFull, 0, "shadow", true, nil, ModeShadow},
+		{"skip pilot", ModeShadow, 2 * time.Hour, "full", false, ErrTransitionNotAllowed, ModeShadow},
+		{"shadow too short", ModeShadow, time.Minute, "pilot", false, ErrInsufficientHistory, ModeShadow},
+		{"shadow to pilot", ModeShadow, 2 * time.Hour, "pilot", true, nil, ModePilot},
+		{"pilot to full needs second admin", ModePilot, 0, "full", false, nil, ModePilot},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := NewModeController(tt.start, cfg, nil, nil)
+			mc.since = time.Now().Add(-tt.shadowAge)
+
+			applied, err := mc.RequestMode(tt.target, 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RequestMode() error = %v, want %v", err, tt.wantErr)
+			}
+			if applied != tt.wantApplied {
+				t.Errorf("RequestMode() applied = %v, want %v", applied, tt.wantApplied)
+			}
+			if mc.GetMode() != tt.wantMode {
+				t.Errorf("GetMode() = %v, want %v", mc.GetMode(), tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestModeController_FullRequiresTwoAdmins(t *testing.T) {
+	mc := NewModeController(ModePilot, DefaultModeControllerConfig(), nil, nil)
+
+	if applied, err := mc.RequestMode("full", 1); err != nil || applied {
+		t.Fatalf("first approval: applied=%v err=%v", applied, err)
+	}
+	if _, err := mc.RequestMode("full", 1); !errors.Is(err, ErrDuplicateApproval) {
+		t.Fatalf("duplicate approval error = %v, want %v", err, ErrDuplicateApproval)
+	}
+	if applied, err := mc.RequestMode("full", 2); err != nil || !applied {
+		t.Fatalf("second approval: applied=%v err=%v", applied, err)
+	}
+	if mc.GetMode() != ModeFull {
+		t.Errorf("GetMode() = %v, want %v", mc.GetMode(), ModeFull)
+	}
+}
+
+func TestModeController_PersistAndDemote(t *testing.T) {
+	store := newMemoryModeStore()
+	var notifications []string
+	notify := func(msg string) { notifications = append(notifications, msg) }
+
+	mc := NewModeController(ModeFull, DefaultModeControllerConfig(), store, notify)
+	if store.params[ConfigKeyMode] != string(ModeFull) {
+		t.Fatalf("stored mode = %q, want %q", store.params[ConfigKeyMode], ModeFull)
+	}
+
+	mc.Demote("circuit breaker drawdown")
+	if mc.GetMode() != ModeShadow {
+		t.Errorf("GetMode() after Demote = %v, want %v", mc.GetMode(), ModeShadow)
+	}
+	if len(notifications) != 1 {
+		t.Errorf("notifications = %d, want 1", len(notifications))
+	}
+
+	// Новый контроллер восстанавливает режим из config_params
+	restored := NewModeController(ModeFull, DefaultModeControllerConfig(), store, nil)
+	if restored.GetMode() != ModeShadow {
+		t.Errorf("restored mode = %v, want %v", restored.GetMode(), ModeShadow)
+	}
+}
+
+func TestModeController_PublishesModeChanges(t *testing.T) {
+	bus := events.NewBus(10)
+	sub, _ := bus.Subscribe([]string{"mode"}, 0)
+	defer sub.Close()
+
+	mc := NewModeController(ModeFull, ModeControllerConfig{FullApprovals: 2}, nil, nil)
+	mc.SetEventPublisher(bus)
+
+	mc.RequestMode("pilot", 1)
+	mc.Demote("circuit breaker drawdown")
+	mc.Demote("already shadow") // без смены режима события нет
+
+	want := []events.ModeData{
+		{From: "full", To: "pilot", Reason: "admin 1"},
+		{From: "pilot", To: "shadow", Reason: "circuit breaker drawdown"},
+	}
+	for _, w := range want {
+		select {
+		case event := <-sub.Events():
+			if event.Type != events.ModeChanged || event.Data != w {
+				t.Errorf("event = %+v, want %+v", event, w)
+			}
+		default:
+			t.Fatalf("missing event %+v", w)
+		}
+	}
+	if bus.LastID() != 2 {
+		t.Errorf("published %d events, want 2", bus.LastID())
+	}
+}
@@ -3,12 +3,18 @@ package orchestrator
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/events"
 	"github.com/kirillm/dca-bot/internal/execution"
+	"github.com/kirillm/dca-bot/internal/incidents"
+	"github.com/kirillm/dca-bot/internal/marketdata"
+	"github.com/kirillm/dca-bot/internal/metrics"
 	"github.com/kirillm/dca-bot/internal/policy"
+	"github.com/kirillm/dca-bot/internal/tracing"
+	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
 // Mode режим работы orchestrator
@@ -22,8 +28,8 @@ const (
 
 // Storage интерфейс для сохранения данных
 type Storage interface {
-	SaveAIDecision(decision *ai.DecisionResponse, mode string, approved bool) error
-	SavePolicyViolation(violation *policy.Violation) error
+	SaveAIDecision(ctx context.Context, decision *ai.DecisionResponse, mode string, approved bool) error
+	SavePolicyViolation(ctx context.Context, violation *policy.Violation) error
 }
 
 // DataProvider интерфейс для получения данных портфеля
@@ -33,6 +39,30 @@ type DataProvider interface {
 	GetPrice(symbol string) (float64, error)
 }
 
+// MarketDataProvider интерфейс для получения рыночных индикаторов
+type MarketDataProvider interface {
+	GetIndicators(symbol string) (*marketdata.Indicators, error)
+}
+
+// NewsProvider интерфейс для получения новостных сигналов (NewsSignalRepository)
+type NewsProvider interface {
+	GetUnprocessed(ctx context.Context, limit int) ([]domain.NewsSignal, error)
+	MarkAsProcessedBatch(ctx context.Context, ids []int64) error
+}
+
+// IncidentReporter разбирает сбои и отправляет алерт с разбором (incidents.Analyzer)
+type IncidentReporter interface {
+	Report(event incidents.Event)
+}
+
+// EventPublisher шина событий для дашбордов (events.Bus)
+type EventPublisher interface {
+	Publish(eventType string, data interface{})
+}
+
+// maxNewsPerCycle максимум новостей в контексте одного решения
+const maxNewsPerCycle = 20
+
 // Balance упрощенная структура баланса
 type Balance struct {
 	Symbol        string
@@ -51,15 +81,21 @@ type Asset struct {
 
 // Orchestrator координатор автономной торговли
 type Orchestrator struct {
-	mode          Mode
+	modes         *ModeController
 	aiClient      *ai.DecisionClient
 	policyEngine  *policy.Engine
 	executor      *execution.Executor
 	storage       Storage
 	dataProvider  DataProvider
+	marketData    MarketDataProvider
+	news          NewsProvider
 	//portfolioMgr  PortfolioManager
 	//infoService   NewsService
 
+	incidents     IncidentReporter
+	events        EventPublisher
+	logger        *utils.Logger
+	breakerActive bool // срабатывание уже отправлено на разбор
 	ticker        *time.Ticker
 	stopChan      chan struct{}
 	isRunning     bool
@@ -73,7 +109,7 @@ type Config struct {
 
 // New создает новый orchestrator
 func New(
-	mode Mode,
+	modes *ModeController,
 	interval time.Duration,
 	aiClient *ai.DecisionClient,
 	policyEngine *policy.Engine,
@@ -82,12 +118,13 @@ func New(
 	dataProvider DataProvider,
 ) *Orchestrator {
 	return &Orchestrator{
-		mode:         mode,
+		modes:        modes,
 		aiClient:     aiClient,
 		policyEngine: policyEngine,
 		executor:     executor,
 		storage:      storage,
 		dataProvider: dataProvider,
+		logger:       utils.DefaultLogger().With("component", "orchestrator"),
 		ticker:       time.NewTicker(interval),
 		stopChan:     make(chan struct{}),
 		isRunning:    false,
@@ -101,7 +138,7 @@ func (o *Orchestrator) Start(ctx context.Context) error {
 	}
 
 	o.isRunning = true
-	log.Printf("🚀 Orchestrator started in %s mode (interval: %v)", o.mode, o.ticker.C)
+	o.logger.Info("🚀 Orchestrator started in %s mode", o.modes.GetMode())
 
 	go o.run(ctx)
 
@@ -114,25 +151,25 @@ func (o *Orchestrator) Stop() {
 		return
 	}
 
-	log.Println("🛑 Stopping orchestrator...")
+	o.logger.Info("🛑 Stopping orchestrator...")
 	close(o.stopChan)
 	o.ticker.Stop()
 	o.isRunning = false
-	log.Println("✅ Orchestrator stopped")
+	o.logger.Info("✅ Orchestrator stopped")
 }
 
 // run основной цикл orchestrator
 func (o *Orchestrator) run(ctx context.Context) {
 	// Первый цикл сразу после старта
 	if err := o.runDecisionCycle(ctx); err != nil {
-		log.Printf("❌ Initial decision cycle error: %v", err)
+		o.logger.Error("❌ Initial decision cycle error: %v", err)
 	}
 
 	for {
 		select {
 		case <-o.ticker.C:
 			if err := o.runDecisionCycle(ctx); err != nil {
-				log.Printf("❌ Decision cycle error: %v", err)
+				o.logger.Error("❌ Decision cycle error: %v", err)
 				o.handleError(ctx, err)
 			}
 
@@ -146,50 +183,105 @@ func (o *Orchestrator) run(ctx context.Context) {
 }
 
 // runDecisionCycle выполняет один цикл принятия решений
-func (o *Orchestrator) runDecisionCycle(ctx context.Context) error {
-	log.Printf("🧠 Starting decision cycle (mode: %s)", o.mode)
+func (o *Orchestrator) runDecisionCycle(ctx context.Context) (err error) {
+	mode := o.modes.GetMode()
+
+	ctx, span := tracing.Start(ctx, "orchestrator.decision_cycle", tracing.KindInternal,
+		tracing.String("mode", string(mode)))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	// Все логи и ордера цикла связаны одним correlation_id - ID трейса, если трейсинг включен
+	correlationID := span.TraceID()
+	if correlationID == "" {
+		correlationID = utils.NewCorrelationID()
+	}
+	ctx = utils.WithCorrelationID(ctx, correlationID)
+	logger := o.logger.WithContext(ctx).With("mode", string(mode))
+	logger.Info("🧠 Starting decision cycle")
 
 	// 1. Проверяем circuit breakers
 	if triggered := o.policyEngine.CheckCircuitBreakers(ctx); triggered != nil {
-		log.Printf("⛔ Circuit breaker triggered: %s", triggered.Reason)
-		log.Printf("   Paused until: %s", triggered.PausedUntil.Format("2006-01-02 15:04:05"))
+		logger.Warn("⛔ Circuit breaker triggered: %s (paused until %s)",
+			triggered.Reason, triggered.PausedUntil.Format("2006-01-02 15:04:05"))
 
 		// Проверяем, не истекло ли время паузы
 		if time.Now().Before(triggered.PausedUntil) {
-			log.Println("   Skipping decision cycle due to active circuit breaker")
+			// Активный breaker понижает режим до shadow
+			o.modes.Demote(fmt.Sprintf("circuit breaker %s", triggered.Reason))
+			o.reportBreaker(triggered)
+			logger.Info("Skipping decision cycle due to active circuit breaker")
 			return nil
 		}
-		log.Println("   Circuit breaker pause expired, resuming operations")
+		logger.Info("Circuit breaker pause expired, resuming operations")
 	}
+	o.breakerActive = false
 
 	// 2. Собираем контекст для AI
-	request := o.gatherContext(ctx)
+	request := o.gatherContext(ctx, mode)
+	newsIDs := o.attachNews(ctx, &request)
 
-	// 3. Запрашиваем решение у AI
+	// 3. Запрашиваем решение у AI; версия промпта выбирается один раз на цикл
+	request.PromptVersion = o.aiClient.NextPromptVersion()
 	decision, err := o.aiClient.RequestDecision(ctx, request)
 	if err != nil {
 		return fmt.Errorf("AI decision request failed: %w", err)
 	}
 
-	log.Printf("🧠 AI Decision: regime=%s confidence=%.2f actions=%d",
-		decision.Regime, decision.Confidence, len(decision.Actions))
-	log.Printf("💡 Rationale: %s", decision.Rationale)
+	// Решение резервной модели (облако недоступно/бюджет исчерпан) не исполняется: цикл идет как shadow
+	if decision.ReducedTrust && mode != ModeShadow {
+		logger.With("provider", decision.Provider, "model", decision.Model).
+			Warn("⚠️ Reduced trust decision from fallback model: actions are not executed")
+		mode = ModeShadow
+	}
+
+	// Новости учтены в решении - помечаем как обработанные
+	if len(newsIDs) > 0 {
+		if err := o.news.MarkAsProcessedBatch(ctx, newsIDs); err != nil {
+			logger.Warn("⚠️ Failed to mark news signals as processed: %v", err)
+		}
+	}
+
+	logger.With(
+		"regime", decision.Regime,
+		"confidence", decision.Confidence,
+		"prompt_version", decision.PromptVersion,
+		"provider", decision.Provider,
+		"model", decision.Model,
+	).Info("🧠 AI Decision: %d actions. Rationale: %s", len(decision.Actions), decision.Rationale)
+	metrics.AIDecisionsTotal.With(string(mode), decision.Regime).Inc()
+	metrics.AIDecisionConfidence.Set(decision.Confidence)
+
+	if o.events != nil {
+		o.events.Publish(events.AIDecision, events.DecisionData{
+			Mode:          string(mode),
+			Regime:        decision.Regime,
+			Confidence:    decision.Confidence,
+			Actions:       len(decision.Actions),
+			Rationale:     decision.Rationale,
+			PromptVersion: decision.PromptVersion,
+			Provider:      decision.Provider,
+		})
+	}
 
 	// 4. Сохраняем решение в БД
 	if o.storage != nil {
 		// Decision will be marked as approved after validation
-		if err := o.storage.SaveAIDecision(decision, string(o.mode), true); err != nil {
-			log.Printf("⚠️  Failed to save AI decision to database: %v", err)
+		if err := o.storage.SaveAIDecision(ctx, decision, string(mode), true); err != nil {
+			logger.Warn("⚠️ Failed to save AI decision to database: %v", err)
 			// Continue execution даже если сохранение не удалось
 		} else {
-			log.Printf("💾 AI decision saved to database")
+			logger.Debug("💾 AI decision saved to database")
 		}
 	}
 
 	// 5. Валидируем и исполняем действия
 	approvedActions := 0
 	for i, action := range decision.Actions {
-		log.Printf("📝 Action %d/%d: %s %s", i+1, len(decision.Actions), action.Type, action.Symbol)
+		actionLogger := logger.With("symbol", action.Symbol, "action", action.Type)
+		actionLogger.Info("📝 Action %d/%d", i+1, len(decision.Actions))
 
 		// Валидация через policy engine
 		validation, err := o.policyEngine.ValidateAction(ctx, policy.ActionRequest{
@@ -199,19 +291,18 @@ func (o *Orchestrator) runDecisionCycle(ctx context.Context) error {
 		})
 
 		if err != nil {
-			log.Printf("❌ Policy validation error: %v", err)
+			actionLogger.Error("❌ Policy validation error: %v", err)
 			continue
 		}
 
 		if !validation.Approved {
-			log.Printf("🚫 Action rejected by policy engine:")
 			for _, v := range validation.Violations {
-				log.Printf("   - %s: %s", v.Type, v.Message)
+				actionLogger.With("violation", v.Type).Warn("🚫 Action rejected by policy engine: %s", v.Message)
 
 				// Сохраняем нарушение в БД
 				if o.storage != nil {
-					if err := o.storage.SavePolicyViolation(&v); err != nil {
-						log.Printf("⚠️  Failed to save policy violation to database: %v", err)
+					if err := o.storage.SavePolicyViolation(ctx, &v); err != nil {
+						actionLogger.Warn("⚠️ Failed to save policy violation to database: %v", err)
 					}
 				}
 			}
@@ -221,39 +312,45 @@ func (o *Orchestrator) runDecisionCycle(ctx context.Context) error {
 		approvedActions++
 
 		// Исполнение (только если не shadow mode)
-		if o.mode != ModeShadow {
+		if mode != ModeShadow {
 			if err := o.executeAction(ctx, action, decision); err != nil {
-				log.Printf("❌ Execution failed: %v", err)
+				actionLogger.Error("❌ Execution failed: %v", err)
 				o.handleExecutionError(ctx, action, err)
 			} else {
-				log.Printf("✅ Executed: %s %s", action.Type, action.Symbol)
+				actionLogger.Info("✅ Executed")
 			}
 		} else {
-			log.Printf("🔍 Shadow mode: would execute %s %s", action.Type, action.Symbol)
+			actionLogger.Info("🔍 Shadow mode: would execute")
 		}
 	}
 
-	log.Printf("📊 Cycle complete: %d/%d actions approved", approvedActions, len(decision.Actions))
+	logger.Info("📊 Cycle complete: %d/%d actions approved", approvedActions, len(decision.Actions))
 
 	return nil
 }
 
+// BuildDecisionRequest собирает актуальный контекст в текущем режиме (для AgentRouter)
+func (o *Orchestrator) BuildDecisionRequest(ctx context.Context) (ai.DecisionRequest, error) {
+	return o.gatherContext(ctx, o.modes.GetMode()), nil
+}
+
 // gatherContext собирает контекст для AI решения
-func (o *Orchestrator) gatherContext(ctx context.Context) ai.DecisionRequest {
+func (o *Orchestrator) gatherContext(ctx context.Context, mode Mode) ai.DecisionRequest {
 	var assets []ai.AssetStatus
 	var totalValueUSDT, totalInvested, totalPnL float64
+	logger := o.logger.WithContext(ctx)
 
 	// Если dataProvider не установлен, возвращаем минимальный контекст
 	if o.dataProvider == nil {
-		log.Printf("⚠️ DataProvider not set, using minimal context")
-		return o.buildMinimalContext()
+		logger.Warn("⚠️ DataProvider not set, using minimal context")
+		return o.buildMinimalContext(mode)
 	}
 
 	// Получаем балансы из БД
 	balances, err := o.dataProvider.GetAllBalances()
 	if err != nil {
-		log.Printf("⚠️ Failed to get balances: %v, using minimal context", err)
-		return o.buildMinimalContext()
+		logger.Warn("⚠️ Failed to get balances: %v, using minimal context", err)
+		return o.buildMinimalContext(mode)
 	}
 
 	// Обрабатываем каждый баланс
@@ -266,7 +363,7 @@ func (o *Orchestrator) gatherContext(ctx context.Context) ai.DecisionRequest {
 		// Получаем текущую цену актива
 		currentPrice, err := o.dataProvider.GetPrice(bal.Symbol)
 		if err != nil {
-			log.Printf("⚠️ Failed to get price for %s: %v, skipping", bal.Symbol, err)
+			logger.With("symbol", bal.Symbol).Warn("⚠️ Failed to get price: %v, skipping", err)
 			continue
 		}
 
@@ -299,10 +396,28 @@ func (o *Orchestrator) gatherContext(ctx context.Context) ai.DecisionRequest {
 	// Получаем BTC цену для market conditions
 	btcPrice, err := o.dataProvider.GetPrice("BTCUSDT")
 	if err != nil {
-		log.Printf("⚠️ Failed to get BTC price: %v", err)
+		logger.Warn("⚠️ Failed to get BTC price: %v", err)
 		btcPrice = 0
 	}
 
+	// Изменение за 24ч, волатильность и тренд из market data service
+	market := ai.MarketData{
+		BTCPrice:        btcPrice,
+		MarketSentiment: "neutral",
+	}
+	if o.marketData != nil {
+		if ind, err := o.marketData.GetIndicators("BTCUSDT"); err != nil {
+			logger.Warn("⚠️ Failed to get BTC indicators: %v", err)
+		} else {
+			market.BTCChange24h = ind.Change24h
+			market.Volatility = ind.Volatility
+			market.MarketSentiment = ind.Trend
+			if market.BTCPrice == 0 {
+				market.BTCPrice = ind.Price
+			}
+		}
+	}
+
 	// Рассчитываем общий процент P&L
 	totalPnLPercent := 0.0
 	if totalInvested > 0 {
@@ -310,7 +425,7 @@ func (o *Orchestrator) gatherContext(ctx context.Context) ai.DecisionRequest {
 	}
 
 	// Логируем собранные данные
-	log.Printf("📊 Portfolio context: %d assets, total value: $%.2f, P&L: $%.2f (%.2f%%)",
+	logger.Info("📊 Portfolio context: %d assets, total value: $%.2f, P&L: $%.2f (%.2f%%)",
 		len(assets), totalValueUSDT, totalPnL, totalPnLPercent)
 
 	return ai.DecisionRequest{
@@ -321,25 +436,45 @@ func (o *Orchestrator) gatherContext(ctx context.Context) ai.DecisionRequest {
 			TotalPnL:        totalPnL,
 			TotalPnLPercent: totalPnLPercent,
 		},
-		MarketConditions: ai.MarketData{
-			BTCPrice:        btcPrice,
-			BTCChange24h:    0, // TODO: рассчитать изменение за 24ч
-			MarketSentiment: "neutral",
-			Volatility:      0, // TODO: рассчитать волатильность
-		},
-		RecentNews: []ai.NewsSignal{}, // TODO: подключить NewsSignalRepository
+		MarketConditions: market,
+		RecentNews:       []ai.NewsSignal{},
 		RiskLimits: ai.RiskLimits{
-			MaxOrderUSDT:     o.getMaxOrderLimit(),
+			MaxOrderUSDT:     o.getMaxOrderLimit(mode),
 			MaxPositionUSDT:  o.policyEngine.GetPolicy().MaxPositionUSDT,
 			MaxTotalExposure: o.policyEngine.GetPolicy().MaxTotalExposure,
 			MaxDailyLoss:     o.policyEngine.GetPolicy().MaxDailyLossUSDT,
 		},
-		Mode: string(o.mode),
+		Mode: string(mode),
 	}
 }
 
+// attachNews добавляет необработанные новости в запрос и возвращает их ID
+func (o *Orchestrator) attachNews(ctx context.Context, request *ai.DecisionRequest) []int64 {
+	if o.news == nil {
+		return nil
+	}
+
+	signals, err := o.news.GetUnprocessed(ctx, maxNewsPerCycle)
+	if err != nil {
+		o.logger.WithContext(ctx).Warn("⚠️ Failed to get news signals: %v", err)
+		return nil
+	}
+
+	ids := make([]int64, 0, len(signals))
+	for _, s := range signals {
+		ids = append(ids, s.ID)
+	}
+	request.RecentNews = ai.NewsSignalsFromDomain(signals)
+
+	if len(signals) > 0 {
+		o.logger.WithContext(ctx).Info("📰 News context: %d unprocessed signals", len(signals))
+	}
+
+	return ids
+}
+
 // buildMinimalContext создает минимальный контекст для fallback
-func (o *Orchestrator) buildMinimalContext() ai.DecisionRequest {
+func (o *Orchestrator) buildMinimalContext(mode Mode) ai.DecisionRequest {
 	return ai.DecisionRequest{
 		CurrentPortfolio: ai.PortfolioSnapshot{
 			Assets:          []ai.AssetStatus{},
@@ -356,20 +491,20 @@ func (o *Orchestrator) buildMinimalContext() ai.DecisionRequest {
 		},
 		RecentNews: []ai.NewsSignal{},
 		RiskLimits: ai.RiskLimits{
-			MaxOrderUSDT:     o.getMaxOrderLimit(),
+			MaxOrderUSDT:     o.getMaxOrderLimit(mode),
 			MaxPositionUSDT:  o.policyEngine.GetPolicy().MaxPositionUSDT,
 			MaxTotalExposure: o.policyEngine.GetPolicy().MaxTotalExposure,
 			MaxDailyLoss:     o.policyEngine.GetPolicy().MaxDailyLossUSDT,
 		},
-		Mode: string(o.mode),
+		Mode: string(mode),
 	}
 }
 
 // getMaxOrderLimit возвращает лимит в зависимости от режима
-func (o *Orchestrator) getMaxOrderLimit() float64 {
+func (o *Orchestrator) getMaxOrderLimit(mode Mode) float64 {
 	baseLimit := o.policyEngine.GetPolicy().MaxOrderUSDT
 
-	switch o.mode {
+	switch mode {
 	case ModeShadow:
 		return baseLimit // Полный лимит (для логирования)
 	case ModePilot:
@@ -411,7 +546,7 @@ func (o *Orchestrator) executeAction(ctx context.Context, action ai.Action, deci
 
 // handleError обрабатывает ошибки цикла
 func (o *Orchestrator) handleError(ctx context.Context, err error) {
-	log.Printf("⚠️ Error in decision cycle: %v", err)
+	o.logger.WithContext(ctx).Error("⚠️ Error in decision cycle: %v", err)
 	// TODO: Implement error handling
 	// - Уведомление оператора
 	// - Логирование в БД
@@ -420,22 +555,79 @@ func (o *Orchestrator) handleError(ctx context.Context, err error) {
 
 // handleExecutionError обрабатывает ошибки исполнения
 func (o *Orchestrator) handleExecutionError(ctx context.Context, action ai.Action, err error) {
-	log.Printf("⚠️ Execution error for %s %s: %v", action.Type, action.Symbol, err)
-	// TODO: Implement execution error handling
-	// - Retry logic
-	// - Уведомление оператора
-	// - Сохранение в БД
+	o.logger.WithContext(ctx).With("symbol", action.Symbol, "action", action.Type).Error("⚠️ Execution error: %v", err)
+	// TODO: Retry logic
+
+	if o.incidents != nil {
+		o.incidents.Report(incidents.Event{
+			Kind:    incidents.KindExecutionFailed,
+			Source:  "orchestrator",
+			Symbol:  action.Symbol,
+			Message: fmt.Sprintf("❌ AI action %s %s failed: %v", action.Type, action.Symbol, err),
+			Err:     err,
+		})
+	}
+}
+
+// reportBreaker отправляет срабатывание breaker на разбор один раз за время паузы
+func (o *Orchestrator) reportBreaker(event *policy.CircuitBreakerEvent) {
+	if o.incidents == nil || o.breakerActive {
+		return
+	}
+	o.breakerActive = true
+
+	o.incidents.Report(incidents.Event{
+		Kind:   incidents.KindCircuitBreaker,
+		Source: "circuit_breaker",
+		Message: fmt.Sprintf("⛔ Circuit breaker triggered: %s\nPaused until %s",
+			event.Reason, event.PausedUntil.Format("2006-01-02 15:04")),
+	})
 }
 
-// SetMode изменяет режим работы
-func (o *Orchestrator) SetMode(mode Mode) {
-	log.Printf("🔄 Switching mode: %s → %s", o.mode, mode)
-	o.mode = mode
+// RequestMode запрашивает смену режима через ModeController
+func (o *Orchestrator) RequestMode(mode string, adminID int64) (bool, error) {
+	return o.modes.RequestMode(mode, adminID)
 }
 
 // GetMode возвращает текущий режим
 func (o *Orchestrator) GetMode() Mode {
-	return o.mode
+	return o.modes.GetMode()
+}
+
+// CurrentMode возвращает текущий режим строкой
+func (o *Orchestrator) CurrentMode() string {
+	return o.modes.CurrentMode()
+}
+
+// SetMarketData подключает сервис рыночных данных
+func (o *Orchestrator) SetMarketData(marketData MarketDataProvider) {
+	o.marketData = marketData
+}
+
+// SetNewsProvider подключает источник новостных сигналов
+func (o *Orchestrator) SetNewsProvider(news NewsProvider) {
+	o.news = news
+}
+
+// SetIncidentReporter подключает разбор сбоев исполнения и срабатываний breaker
+func (o *Orchestrator) SetIncidentReporter(reporter IncidentReporter) {
+	o.incidents = reporter
+}
+
+// SetEventPublisher подключает шину событий (ai.decision и mode.changed контроллера режима)
+func (o *Orchestrator) SetEventPublisher(publisher EventPublisher) {
+	o.events = publisher
+	o.modes.SetEventPublisher(publisher)
+}
+
+// SetLogger заменяет логгер orchestrator
+func (o *Orchestrator) SetLogger(logger *utils.Logger) {
+	o.logger = logger
+}
+
+// ModeController возвращает общий контроллер режима
+func (o *Orchestrator) ModeController() *ModeController {
+	return o.modes
 }
 
 // IsRunning проверяет запущен ли orchestrator
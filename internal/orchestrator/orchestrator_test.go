@@ -0,0 +1,136 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/execution"
+	"github.com/kirillm/dca-bot/internal/policy"
+)
+
+// scriptedProvider LLM, отвечающий заданным решением через submit_decision (или ошибкой)
+type scriptedProvider struct {
+	name     string
+	decision string
+	err      error
+}
+
+func (p *scriptedProvider) Name() string  { return p.name }
+func (p *scriptedProvider) Model() string { return p.name + "-model" }
+func (p *scriptedProvider) Cloud() bool   { return p.name == "cloud" }
+
+func (p *scriptedProvider) Chat(ctx context.Context, req ai.ChatRequest) (*ai.ChatResult, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	call := ai.ToolCall{ID: "call_1", Type: "function"}
+	call.Function.Name = ai.DecisionToolName
+	call.Function.Arguments = p.decision
+	return &ai.ChatResult{ToolCalls: []ai.ToolCall{call}, Provider: p.name, Model: p.Model()}, nil
+}
+
+// countingExchange биржа, считающая размещенные ордера
+type countingExchange struct {
+	orders int
+}
+
+func (e *countingExchange) GetPrice(ctx context.Context, symbol string) (float64, error) {
+	return 50000, nil
+}
+
+func (e *countingExchange) GetBalance(ctx context.Context, asset string) (float64, error) {
+	return 1000, nil
+}
+
+func (e *countingExchange) PlaceMarketOrder(ctx context.Context, symbol, side string, quantity float64) (string, error) {
+	e.orders++
+	return "order-1", nil
+}
+
+// emptyPolicyStorage пустая история для policy engine
+type emptyPolicyStorage struct{}
+
+func (emptyPolicyStorage) GetAllBalances(ctx context.Context) ([]policy.Balance, error) {
+	return nil, nil
+}
+
+func (emptyPolicyStorage) GetRecentTrades(ctx context.Context, since time.Time) ([]policy.Trade, error) {
+	return nil, nil
+}
+
+func (emptyPolicyStorage) SavePolicyViolation(ctx context.Context, violation *policy.PolicyViolation) error {
+	return nil
+}
+
+func (emptyPolicyStorage) SaveCircuitBreakerEvent(ctx context.Context, event *policy.CircuitBreakerEvent) error {
+	return nil
+}
+
+// decisionLog запоминает режим сохраненных решений
+type decisionLog struct {
+	modes []string
+}
+
+func (s *decisionLog) SaveAIDecision(ctx context.Context, decision *ai.DecisionResponse, mode string, approved bool) error {
+	s.modes = append(s.modes, mode)
+	return nil
+}
+
+func (s *decisionLog) SavePolicyViolation(ctx context.Context, violation *policy.Violation) error {
+	return nil
+}
+
+const dcaDecision = `{"regime":"ACCUMULATE","confidence":0.8,"rationale":"dip","actions":[{"type":"set_dca","symbol":"BTCUSDT","parameters":{"quote_usdt":20,"interval_min":60}}]}`
+
+func TestRunDecisionCycleReducedTrust(t *testing.T) {
+	local := &scriptedProvider{name: "local", decision: dcaDecision}
+
+	tests := []struct {
+		name       string
+		llm        ai.Provider
+		wantOrders int
+		wantMode   string
+	}{
+		{"primary model executes", local, 1, "pilot"},
+		{
+			"fallback model runs as shadow",
+			ai.NewFallbackChain(&scriptedProvider{name: "cloud", err: errors.New("503 service unavailable")}, local),
+			0, "shadow",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, err := policy.NewEngine("../../configs/policy.yaml", emptyPolicyStorage{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			exchange := &countingExchange{}
+			store := &decisionLog{}
+
+			o := New(
+				NewModeController(ModePilot, DefaultModeControllerConfig(), nil, nil),
+				time.Hour,
+				ai.NewDecisionClient(ai.NewAIClientWithProvider(tt.llm)),
+				engine,
+				execution.NewExecutor(exchange, engine, execution.NewKillSwitch()),
+				store,
+				nil,
+			)
+			defer o.ticker.Stop()
+
+			if err := o.runDecisionCycle(context.Background()); err != nil {
+				t.Fatalf("runDecisionCycle: %v", err)
+			}
+			if exchange.orders != tt.wantOrders {
+				t.Errorf("orders placed = %d, want %d", exchange.orders, tt.wantOrders)
+			}
+			if len(store.modes) != 1 || store.modes[0] != tt.wantMode {
+				t.Errorf("saved decision modes = %v, want [%s]", store.modes, tt.wantMode)
+			}
+		})
+	}
+}
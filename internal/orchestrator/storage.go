@@ -0,0 +1,60 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/policy"
+	"github.com/kirillm/dca-bot/internal/storage/repository"
+)
+
+// RepositoryStorage реализация Storage поверх репозиториев Postgres
+type RepositoryStorage struct {
+	decisions  *repository.AIDecisionRepository
+	violations *repository.PolicyViolationRepository
+}
+
+// NewRepositoryStorage создает хранилище решений и нарушений
+func NewRepositoryStorage(decisions *repository.AIDecisionRepository, violations *repository.PolicyViolationRepository) *RepositoryStorage {
+	return &RepositoryStorage{
+		decisions:  decisions,
+		violations: violations,
+	}
+}
+
+// SaveAIDecision сохраняет решение вместе с версией промпта и моделью, которые его сгенерировали
+func (s *RepositoryStorage) SaveAIDecision(ctx context.Context, decision *ai.DecisionResponse, mode string, approved bool) error {
+	raw, err := json.Marshal(decision)
+	if err != nil {
+		return fmt.Errorf("failed to marshal decision: %w", err)
+	}
+
+	return s.decisions.Save(ctx, &domain.AIDecision{
+		Timestamp:     time.Now(),
+		Regime:        decision.Regime,
+		Confidence:    decision.Confidence,
+		Rationale:     decision.Rationale,
+		RawResponse:   string(raw),
+		Approved:      approved,
+		Mode:          mode,
+		PromptVersion: decision.PromptVersion,
+		Provider:      decision.Provider,
+		Model:         decision.Model,
+		TraceID:       decision.TraceID,
+	})
+}
+
+// SavePolicyViolation сохраняет нарушение политики
+func (s *RepositoryStorage) SavePolicyViolation(ctx context.Context, violation *policy.Violation) error {
+	return s.violations.Save(ctx, &domain.PolicyViolation{
+		ViolationType:  violation.Type,
+		LimitName:      violation.LimitName,
+		LimitValue:     violation.LimitValue,
+		AttemptedValue: violation.AttemptedValue,
+		Severity:       violation.Severity,
+	})
+}
@@ -6,6 +6,10 @@ import (
 	"os"
 	"time"
 
+	"github.com/kirillm/dca-bot/internal/events"
+	"github.com/kirillm/dca-bot/internal/metrics"
+	"github.com/kirillm/dca-bot/internal/tracing"
+	"github.com/kirillm/dca-bot/pkg/utils"
 	"gopkg.in/yaml.v3"
 )
 
@@ -17,6 +21,11 @@ type Storage interface {
 	SaveCircuitBreakerEvent(ctx context.Context, event *CircuitBreakerEvent) error
 }
 
+// VolatilitySource источник волатильности (marketdata.Service)
+type VolatilitySource interface {
+	GetVolatility(symbol string) (float64, error)
+}
+
 // Balance для расчета экспозиции
 type Balance struct {
 	Symbol         string
@@ -44,6 +53,7 @@ type PolicyViolation struct {
 
 // CircuitBreakerEvent событие триггера
 type CircuitBreakerEvent struct {
+	Type        string // drawdown, daily_loss, volatility
 	Reason      string
 	Details     string
 	PausedUntil time.Time
@@ -55,6 +65,18 @@ type Engine struct {
 	storage     Storage
 	metrics     *RiskMetrics
 	lastCheck   time.Time
+
+	volatility        VolatilitySource
+	volatilitySymbols []string
+
+	events  EventPublisher
+	tripped bool // breaker уже опубликован, ждем сброса
+	logger  *utils.Logger
+}
+
+// EventPublisher шина событий для дашбордов (events.Bus)
+type EventPublisher interface {
+	Publish(eventType string, data interface{})
 }
 
 // NewEngine создает новый policy engine
@@ -69,6 +91,7 @@ func NewEngine(policyPath string, storage Storage) (*Engine, error) {
 		storage:   storage,
 		metrics:   &RiskMetrics{},
 		lastCheck: time.Now(),
+		logger:    utils.DefaultLogger().With("component", "policy"),
 	}, nil
 }
 
@@ -104,6 +127,23 @@ func loadPolicy(path string) (*Policy, error) {
 
 // ValidateAction проверяет действие на соответствие политике
 func (e *Engine) ValidateAction(ctx context.Context, action ActionRequest) (*ValidationResult, error) {
+	ctx, span := tracing.Start(ctx, "policy.validate", tracing.KindInternal,
+		tracing.String("action.type", action.Type), tracing.String("symbol", action.Symbol))
+	defer span.End()
+
+	result, err := e.validateAction(ctx, action)
+	span.RecordError(err)
+	if result != nil {
+		span.SetAttributes(
+			tracing.Bool("policy.approved", result.Approved),
+			tracing.Float("policy.risk_score", result.RiskScore),
+			tracing.Int("policy.violations", len(result.Violations)),
+		)
+	}
+	return result, err
+}
+
+func (e *Engine) validateAction(ctx context.Context, action ActionRequest) (*ValidationResult, error) {
 	// Обновляем метрики
 	if err := e.updateMetrics(ctx); err != nil {
 		return nil, fmt.Errorf("failed to update metrics: %w", err)
@@ -124,6 +164,7 @@ func (e *Engine) ValidateAction(ctx context.Context, action ActionRequest) (*Val
 			Severity: "critical",
 			Message:  fmt.Sprintf("Circuit breaker triggered: %s", triggered.Reason),
 		})
+		observeValidation(result)
 		return result, nil
 	}
 
@@ -178,26 +219,38 @@ func (e *Engine) ValidateAction(ctx context.Context, action ActionRequest) (*Val
 				Severity:       v.Severity,
 			}); err != nil {
 				// Логируем но не фейлим
-				fmt.Printf("Failed to save policy violation: %v\n", err)
+				e.logger.WithContext(ctx).With("violation", v.Type).Warn("Failed to save policy violation: %v", err)
 			}
 		}
 	}
 
 	// Расчет risk score (0.0 - 1.0)
 	result.RiskScore = e.calculateRiskScore()
+	observeValidation(result)
 
 	return result, nil
 }
 
+// observeValidation учитывает результат проверки в метриках Prometheus
+func observeValidation(result *ValidationResult) {
+	status := "approved"
+	if !result.Approved {
+		status = "rejected"
+	}
+	metrics.PolicyValidationsTotal.With(status).Inc()
+	for _, v := range result.Violations {
+		metrics.PolicyViolationsTotal.With(v.Type, v.Severity).Inc()
+	}
+}
+
 // validateBuyAction проверяет действия покупки
 func (e *Engine) validateBuyAction(action ActionRequest, result *ValidationResult) {
-	// Получаем сумму из параметров
-	var amount float64
-	if val, ok := action.Parameters["quote_usdt"].(float64); ok {
-		amount = val
-	} else if val, ok := action.Parameters["quoteAmount"].(float64); ok {
-		amount = val
+	var params BuyParams
+	if err := action.DecodeParams(&params); err != nil {
+		result.Violations = append(result.Violations, invalidParamsViolation(action, err))
+		return
 	}
+	amount := params.Amount()
 
 	// Проверка размера ордера
 	if amount > e.policy.MaxOrderUSDT {
@@ -234,10 +287,13 @@ func (e *Engine) validateSellAction(action ActionRequest, result *ValidationResu
 // validateGridAction проверяет Grid стратегию
 func (e *Engine) validateGridAction(action ActionRequest, result *ValidationResult) {
 	// Grid может создать несколько ордеров
-	levels, _ := action.Parameters["levels"].(float64)
-	orderSize, _ := action.Parameters["order_size_quote"].(float64)
+	var params GridParams
+	if err := action.DecodeParams(&params); err != nil {
+		result.Violations = append(result.Violations, invalidParamsViolation(action, err))
+		return
+	}
 
-	totalGridCapital := levels * orderSize
+	totalGridCapital := float64(params.Levels) * params.OrderSizeQuote
 
 	if totalGridCapital > e.policy.MaxPositionUSDT {
 		result.Violations = append(result.Violations, Violation{
@@ -251,13 +307,40 @@ func (e *Engine) validateGridAction(action ActionRequest, result *ValidationResu
 	}
 }
 
-// checkCircuitBreakers проверяет все предохранители
+// invalidParamsViolation нарушение для параметров неверного типа
+func invalidParamsViolation(action ActionRequest, err error) Violation {
+	return Violation{
+		Type:     "invalid_parameters",
+		Severity: "critical",
+		Message:  fmt.Sprintf("Invalid %s parameters: %v", action.Type, err),
+	}
+}
+
+// checkCircuitBreakers проверяет предохранители и публикует breaker.tripped один раз до сброса
 func (e *Engine) checkCircuitBreakers(ctx context.Context) *CircuitBreakerEvent {
+	triggered := e.findTrippedBreaker()
+	if triggered != nil && !e.tripped {
+		metrics.CircuitBreakerTriggers.With(triggered.Type).Inc()
+		if e.events != nil {
+			e.events.Publish(events.BreakerTripped, events.BreakerData{
+				Reason:      triggered.Reason,
+				PausedUntil: triggered.PausedUntil,
+			})
+		}
+	}
+	e.tripped = triggered != nil
+	metrics.CircuitBreakerActive.SetBool(e.tripped)
+	return triggered
+}
+
+// findTrippedBreaker возвращает первый сработавший предохранитель
+func (e *Engine) findTrippedBreaker() *CircuitBreakerEvent {
 	for _, cb := range e.policy.CircuitBreakers {
 		switch cb.Type {
 		case "drawdown":
 			if e.metrics.CurrentDrawdown >= cb.Threshold {
 				return &CircuitBreakerEvent{
+					Type:        cb.Type,
 					Reason:      fmt.Sprintf("drawdown %.2f%% >= %.2f%%", e.metrics.CurrentDrawdown, cb.Threshold),
 					PausedUntil: time.Now().Add(1 * time.Hour),
 				}
@@ -265,6 +348,7 @@ func (e *Engine) checkCircuitBreakers(ctx context.Context) *CircuitBreakerEvent
 		case "daily_loss":
 			if e.metrics.DailyLossUSDT >= cb.Threshold {
 				return &CircuitBreakerEvent{
+					Type:        cb.Type,
 					Reason:      fmt.Sprintf("daily loss $%.2f >= $%.2f", e.metrics.DailyLossUSDT, cb.Threshold),
 					PausedUntil: time.Now().Add(24 * time.Hour),
 				}
@@ -272,6 +356,7 @@ func (e *Engine) checkCircuitBreakers(ctx context.Context) *CircuitBreakerEvent
 		case "volatility":
 			if e.metrics.VolatilityPct >= cb.Threshold {
 				return &CircuitBreakerEvent{
+					Type:        cb.Type,
 					Reason:      fmt.Sprintf("volatility %.2f%% >= %.2f%%", e.metrics.VolatilityPct, cb.Threshold),
 					PausedUntil: time.Now().Add(30 * time.Minute),
 				}
@@ -323,8 +408,32 @@ func (e *Engine) updateMetrics(ctx context.Context) error {
 		}
 	}
 	e.metrics.DailyLossUSDT = dailyLoss
+
+	// Волатильность: максимум по отслеживаемым символам
+	if e.volatility != nil {
+		maxVolatility := 0.0
+		for _, symbol := range e.volatilitySymbols {
+			vol, err := e.volatility.GetVolatility(symbol)
+			if err != nil {
+				e.logger.With("symbol", symbol).Warn("Failed to get volatility: %v", err)
+				continue
+			}
+			if vol > maxVolatility {
+				maxVolatility = vol
+			}
+		}
+		e.metrics.VolatilityPct = maxVolatility
+	}
+
 	e.metrics.LastUpdated = time.Now()
 
+	metrics.RiskExposureUSDT.Set(e.metrics.TotalExposureUSDT)
+	metrics.RiskDailyLossUSDT.Set(e.metrics.DailyLossUSDT)
+	metrics.RiskDrawdown.Set(e.metrics.CurrentDrawdown)
+	metrics.RiskVolatility.Set(e.metrics.VolatilityPct)
+	metrics.RiskTrades24h.Set(float64(e.metrics.DailyTradeCount))
+	metrics.PolicyRiskScore.Set(e.calculateRiskScore())
+
 	return nil
 }
 
@@ -352,11 +461,25 @@ func (e *Engine) calculateRiskScore() float64 {
 	return score
 }
 
+// SetVolatilitySource подключает источник волатильности для circuit breaker
+func (e *Engine) SetVolatilitySource(source VolatilitySource, symbols ...string) {
+	if len(symbols) == 0 {
+		symbols = []string{"BTCUSDT"}
+	}
+	e.volatility = source
+	e.volatilitySymbols = symbols
+}
+
 // GetPolicy возвращает текущую политику
 func (e *Engine) GetPolicy() *Policy {
 	return e.policy
 }
 
+// SetEventPublisher подключает шину событий (breaker.tripped)
+func (e *Engine) SetEventPublisher(publisher EventPublisher) {
+	e.events = publisher
+}
+
 // GetMetrics возвращает текущие метрики
 func (e *Engine) GetMetrics() *RiskMetrics {
 	return e.metrics
@@ -367,7 +490,7 @@ func (e *Engine) CheckCircuitBreakers(ctx context.Context) *CircuitBreakerEvent
 	// Обновляем метрики перед проверкой
 	if err := e.updateMetrics(ctx); err != nil {
 		// В случае ошибки не блокируем, но логируем
-		fmt.Printf("Failed to update metrics for circuit breaker check: %v\n", err)
+		e.logger.WithContext(ctx).Warn("Failed to update metrics for circuit breaker check: %v", err)
 	}
 
 	return e.checkCircuitBreakers(ctx)
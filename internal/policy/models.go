@@ -1,6 +1,9 @@
 package policy
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Policy представляет профиль риск-менеджмента
 type Policy struct {
@@ -28,6 +31,35 @@ type ActionRequest struct {
 	Parameters map[string]interface{} `json:"parameters"`
 }
 
+// BuyParams параметры покупки (set_dca, buy)
+type BuyParams struct {
+	QuoteUSDT   float64 `json:"quote_usdt"`
+	QuoteAmount float64 `json:"quoteAmount"` // старое имя поля
+}
+
+// Amount возвращает сумму покупки в USDT
+func (p BuyParams) Amount() float64 {
+	if p.QuoteUSDT > 0 {
+		return p.QuoteUSDT
+	}
+	return p.QuoteAmount
+}
+
+// GridParams параметры set_grid, влияющие на риск
+type GridParams struct {
+	Levels         int     `json:"levels"`
+	OrderSizeQuote float64 `json:"order_size_quote"`
+}
+
+// DecodeParams декодирует Parameters в типизированную структуру
+func (a ActionRequest) DecodeParams(out interface{}) error {
+	data, err := json.Marshal(a.Parameters)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
 // ValidationResult результат проверки действия политикой
 type ValidationResult struct {
 	Approved        bool
@@ -0,0 +1,27 @@
+package rbac
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kirillm/dca-bot/internal/config"
+)
+
+// ConfigFrom лимиты ордеров по ролям из RBAC_ORDER_CAPS (trader:100,admin:0)
+func ConfigFrom(cfg config.AccessConfig) (Config, error) {
+	caps := make(map[string]float64, len(cfg.OrderCaps))
+	for _, item := range cfg.OrderCaps {
+		role, value, ok := strings.Cut(strings.ToLower(item), ":")
+		if !ok || !ValidRole(role) {
+			return Config{}, fmt.Errorf("RBAC_ORDER_CAPS: invalid entry %q", item)
+		}
+		limit, err := strconv.ParseFloat(value, 64)
+		if err != nil || limit < 0 {
+			return Config{}, fmt.Errorf("RBAC_ORDER_CAPS: invalid limit for %s: %q", role, value)
+		}
+		caps[role] = limit
+	}
+
+	return Config{OrderCaps: caps}, nil
+}
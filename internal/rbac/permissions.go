@@ -0,0 +1,59 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// Permission право на группу команд Telegram и эндпоинтов API
+type Permission string
+
+const (
+	PermView     Permission = "view"     // статус, история, портфель, отчеты
+	PermTrade    Permission = "trade"    // ручные покупки/продажи, auto-sell
+	PermGrid     Permission = "grid"     // запуск grid
+	PermRisk     Permission = "risk"     // риск-лимиты, panic stop, остановка grid
+	PermAutonomy Permission = "autonomy" // режим автономности и исполнение AI решений
+	PermConfig   Permission = "config"   // версии промптов, настройки активов
+	PermUsers    Permission = "users"    // пользователи, роли, API ключи
+)
+
+// ErrPermissionDenied недостаточно прав у роли
+var ErrPermissionDenied = errors.New("permission denied")
+
+// rolePermissions права ролей
+var rolePermissions = map[string][]Permission{
+	domain.RoleViewer:      {PermView},
+	domain.RoleTrader:      {PermView, PermTrade, PermGrid},
+	domain.RoleRiskOfficer: {PermView, PermRisk},
+	domain.RoleAdmin:       {PermView, PermTrade, PermGrid, PermRisk, PermAutonomy, PermConfig, PermUsers},
+}
+
+// Roles все роли по возрастанию прав
+var Roles = []string{domain.RoleViewer, domain.RoleTrader, domain.RoleRiskOfficer, domain.RoleAdmin}
+
+// ValidRole проверяет, что роль известна
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Can проверяет, есть ли у роли право
+func Can(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize возвращает ErrPermissionDenied, если у роли нет права
+func Authorize(role string, perm Permission) error {
+	if !Can(role, perm) {
+		return fmt.Errorf("%w: %s required", ErrPermissionDenied, perm)
+	}
+	return nil
+}
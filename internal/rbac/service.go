@@ -0,0 +1,366 @@
+package rbac
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/pkg/utils"
+)
+
+// apiKeyPrefix префикс ключей API, по нему ключ легко узнать в логах и конфигах
+const apiKeyPrefix = "dcab_"
+
+// ErrInvalidAPIKey ключ не найден или отозван
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// Config параметры контроля доступа
+type Config struct {
+	OrderCaps map[string]float64 // максимальный ордер в USDT по роли (нет или 0 - без лимита)
+}
+
+// UserStore пользователи (UserRepository)
+type UserStore interface {
+	Upsert(ctx context.Context, user *domain.User) error
+	GetAll(ctx context.Context) ([]domain.User, error)
+	Delete(ctx context.Context, telegramID int64) error
+}
+
+// APIKeyStore ключи API (APIKeyRepository)
+type APIKeyStore interface {
+	Create(ctx context.Context, key *domain.APIKey) error
+	GetByHash(ctx context.Context, hash string) (*domain.APIKey, error)
+	GetByID(ctx context.Context, id int64) (*domain.APIKey, error)
+	GetByUser(ctx context.Context, userID int64) ([]domain.APIKey, error)
+	UpdateSettings(ctx context.Context, key *domain.APIKey) error
+	Revoke(ctx context.Context, id int64) error
+	Touch(ctx context.Context, id int64) error
+}
+
+// Service роли пользователей Telegram и API. Пользователи кешируются в памяти,
+// изменения сразу пишутся в хранилище
+type Service struct {
+	config Config
+	users  UserStore
+	keys   APIKeyStore
+
+	mu         sync.RWMutex
+	byTelegram map[int64]domain.User
+	byID       map[int64]domain.User
+}
+
+// NewService создает сервис и загружает пользователей из хранилища
+func NewService(ctx context.Context, cfg Config, users UserStore, keys APIKeyStore) (*Service, error) {
+	s := &Service{
+		config:     cfg,
+		users:      users,
+		keys:       keys,
+		byTelegram: make(map[int64]domain.User),
+		byID:       make(map[int64]domain.User),
+	}
+	if err := s.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload перечитывает пользователей из хранилища
+func (s *Service) Reload(ctx context.Context) error {
+	users, err := s.users.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load users: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.byTelegram = make(map[int64]domain.User, len(users))
+	s.byID = make(map[int64]domain.User, len(users))
+	for _, u := range users {
+		s.byTelegram[u.TelegramID] = u
+		s.byID[u.ID] = u
+	}
+	return nil
+}
+
+// Bootstrap добавляет первичных админов и трейдеров (TG_ADMINS, TG_CHAT_WHITELIST).
+// Существующих пользователей не трогает - роли, выданные через /users, сохраняются
+func (s *Service) Bootstrap(ctx context.Context, admins, traders []int64) error {
+	seed := func(ids []int64, role string) error {
+		for _, id := range ids {
+			if _, ok := s.User(id); ok {
+				continue
+			}
+			if _, err := s.SetRole(ctx, id, role, ""); err != nil {
+				return err
+			}
+			utils.LogInfo(fmt.Sprintf("[RBAC] Bootstrapped user %d as %s", id, role))
+		}
+		return nil
+	}
+
+	if err := seed(admins, domain.RoleAdmin); err != nil {
+		return err
+	}
+	return seed(traders, domain.RoleTrader)
+}
+
+// HasUsers есть ли хотя бы один пользователь
+func (s *Service) HasUsers() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.byTelegram) > 0
+}
+
+// User возвращает пользователя по Telegram ID
+func (s *Service) User(telegramID int64) (domain.User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.byTelegram[telegramID]
+	return u, ok
+}
+
+// Role роль пользователя ("" - неизвестный пользователь)
+func (s *Service) Role(telegramID int64) string {
+	u, _ := s.User(telegramID)
+	return u.Role
+}
+
+// Users все пользователи по возрастанию ID
+func (s *Service) Users() []domain.User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]domain.User, 0, len(s.byTelegram))
+	for _, u := range s.byTelegram {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users
+}
+
+// SetRole добавляет пользователя или меняет его роль
+func (s *Service) SetRole(ctx context.Context, telegramID int64, role, name string) (domain.User, error) {
+	if !ValidRole(role) {
+		return domain.User{}, fmt.Errorf("unknown role %q (roles: %v)", role, Roles)
+	}
+	// Смена роли без имени сохраняет прежнее имя
+	if current, ok := s.User(telegramID); ok && name == "" {
+		name = current.Name
+	}
+
+	// Последнего админа хранилище не даст понизить: проверка и запись в одной транзакции
+	user := domain.User{TelegramID: telegramID, Name: name, Role: role}
+	if err := s.users.Upsert(ctx, &user); err != nil {
+		if errors.Is(err, domain.ErrLastAdmin) {
+			return domain.User{}, err
+		}
+		return domain.User{}, fmt.Errorf("failed to save user: %w", err)
+	}
+
+	s.mu.Lock()
+	s.byTelegram[telegramID] = user
+	s.byID[user.ID] = user
+	s.mu.Unlock()
+	return user, nil
+}
+
+// Remove удаляет пользователя вместе с его API ключами
+func (s *Service) Remove(ctx context.Context, telegramID int64) error {
+	user, ok := s.User(telegramID)
+	if !ok {
+		return fmt.Errorf("user %d not found", telegramID)
+	}
+	if err := s.users.Delete(ctx, telegramID); err != nil {
+		if errors.Is(err, domain.ErrLastAdmin) {
+			return err
+		}
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	s.mu.Lock()
+	delete(s.byTelegram, telegramID)
+	delete(s.byID, user.ID)
+	s.mu.Unlock()
+	return nil
+}
+
+// CheckOrderSize проверяет размер ордера по лимиту роли
+func (s *Service) CheckOrderSize(role string, amountUSD float64) error {
+	limit := s.config.OrderCaps[role]
+	if limit > 0 && amountUSD > limit {
+		return fmt.Errorf("%w: order %.2f USDT exceeds %s limit %.2f USDT", ErrPermissionDenied, amountUSD, role, limit)
+	}
+	return nil
+}
+
+// OrderCap лимит ордера роли (0 - без лимита)
+func (s *Service) OrderCap(role string) float64 {
+	return s.config.OrderCaps[role]
+}
+
+// CreateAPIKey выпускает ключ API для пользователя. Ключ возвращается один раз,
+// в хранилище остается только его хеш. Секрет подписи запросов - в key.SigningSecret
+func (s *Service) CreateAPIKey(ctx context.Context, telegramID int64, name string) (string, *domain.APIKey, error) {
+	user, ok := s.User(telegramID)
+	if !ok {
+		return "", nil, fmt.Errorf("user %d not found", telegramID)
+	}
+
+	secret, err := randomHex(24)
+	if err != nil {
+		return "", nil, err
+	}
+	secret = apiKeyPrefix + secret
+
+	signingSecret, err := randomHex(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	key := &domain.APIKey{
+		UserID:        user.ID,
+		Name:          name,
+		Prefix:        secret[:len(apiKeyPrefix)+6],
+		KeyHash:       hashAPIKey(secret),
+		SigningSecret: signingSecret,
+	}
+	if err := s.keys.Create(ctx, key); err != nil {
+		return "", nil, fmt.Errorf("failed to save API key: %w", err)
+	}
+	return secret, key, nil
+}
+
+// APIKeys ключи пользователя
+func (s *Service) APIKeys(ctx context.Context, telegramID int64) ([]domain.APIKey, error) {
+	user, ok := s.User(telegramID)
+	if !ok {
+		return nil, fmt.Errorf("user %d not found", telegramID)
+	}
+	return s.keys.GetByUser(ctx, user.ID)
+}
+
+// RevokeAPIKey отзывает ключ
+func (s *Service) RevokeAPIKey(ctx context.Context, id int64) error {
+	return s.keys.Revoke(ctx, id)
+}
+
+// SetAPIKeyIPs ограничивает ключ списком IP/CIDR (пустой список - любые адреса)
+func (s *Service) SetAPIKeyIPs(ctx context.Context, id int64, ips []string) (*domain.APIKey, error) {
+	for _, ip := range ips {
+		if _, err := ParseIPNet(ip); err != nil {
+			return nil, err
+		}
+	}
+	return s.updateAPIKey(ctx, id, func(key *domain.APIKey) error {
+		key.AllowedIPs = ips
+		return nil
+	})
+}
+
+// SetAPIKeyRateLimit задает лимит запросов в минуту (0 - лимит по умолчанию)
+func (s *Service) SetAPIKeyRateLimit(ctx context.Context, id int64, perMinute int) (*domain.APIKey, error) {
+	if perMinute < 0 {
+		return nil, fmt.Errorf("rate limit must not be negative")
+	}
+	return s.updateAPIKey(ctx, id, func(key *domain.APIKey) error {
+		key.RateLimit = perMinute
+		return nil
+	})
+}
+
+// SetAPIKeySignature включает обязательную HMAC подпись запросов ключа
+func (s *Service) SetAPIKeySignature(ctx context.Context, id int64, required bool) (*domain.APIKey, error) {
+	return s.updateAPIKey(ctx, id, func(key *domain.APIKey) error {
+		if required && key.SigningSecret == "" {
+			return fmt.Errorf("API key #%d has no signing secret, issue a new key", id)
+		}
+		key.RequireSignature = required
+		return nil
+	})
+}
+
+func (s *Service) updateAPIKey(ctx context.Context, id int64, update func(key *domain.APIKey) error) (*domain.APIKey, error) {
+	key, err := s.keys.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	if key == nil || key.Revoked {
+		return nil, fmt.Errorf("API key #%d not found", id)
+	}
+
+	if err := update(key); err != nil {
+		return nil, err
+	}
+	if err := s.keys.UpdateSettings(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to save API key: %w", err)
+	}
+	return key, nil
+}
+
+// Authenticate находит ключ API и его владельца
+func (s *Service) Authenticate(ctx context.Context, secret string) (domain.User, domain.APIKey, error) {
+	key, err := s.keys.GetByHash(ctx, hashAPIKey(secret))
+	if err != nil {
+		return domain.User{}, domain.APIKey{}, fmt.Errorf("failed to check API key: %w", err)
+	}
+	if key == nil {
+		return domain.User{}, domain.APIKey{}, ErrInvalidAPIKey
+	}
+
+	s.mu.RLock()
+	user, ok := s.byID[key.UserID]
+	s.mu.RUnlock()
+	if !ok {
+		return domain.User{}, domain.APIKey{}, ErrInvalidAPIKey
+	}
+
+	if err := s.keys.Touch(ctx, key.ID); err != nil {
+		utils.LogWarn(fmt.Sprintf("[RBAC] Failed to update API key %d usage: %v", key.ID, err))
+	}
+	return user, *key, nil
+}
+
+// ParseIPNet разбирает IP или CIDR; одиночный IP становится сетью /32 (/128)
+func ParseIPNet(value string) (*net.IPNet, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "/") {
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", value)
+		}
+		return ipNet, nil
+	}
+
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP %q", value)
+	}
+	bits := 128
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+func randomHex(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashAPIKey SHA-256 ключа в hex
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
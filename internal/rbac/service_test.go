@@ -0,0 +1,296 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/kirillm/dca-bot/internal/config"
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// memoryStore пользователи и ключи в памяти для тестов
+type memoryStore struct {
+	users   []domain.User
+	keys    []domain.APIKey
+	touched int
+}
+
+func (m *memoryStore) Upsert(ctx context.Context, user *domain.User) error {
+	if user.Role != domain.RoleAdmin && m.lastAdmin(user.TelegramID) {
+		return domain.ErrLastAdmin
+	}
+	for i, u := range m.users {
+		if u.TelegramID == user.TelegramID {
+			user.ID = u.ID
+			m.users[i] = *user
+			return nil
+		}
+	}
+	user.ID = int64(len(m.users) + 1)
+	m.users = append(m.users, *user)
+	return nil
+}
+
+func (m *memoryStore) GetAll(ctx context.Context) ([]domain.User, error) { return m.users, nil }
+
+func (m *memoryStore) Delete(ctx context.Context, telegramID int64) error {
+	if m.lastAdmin(telegramID) {
+		return domain.ErrLastAdmin
+	}
+	for i, u := range m.users {
+		if u.TelegramID == telegramID {
+			m.users = append(m.users[:i], m.users[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// lastAdmin telegramID - единственный админ (как requireAnotherAdmin в UserRepository)
+func (m *memoryStore) lastAdmin(telegramID int64) bool {
+	target, others := false, false
+	for _, u := range m.users {
+		if u.Role == domain.RoleAdmin {
+			if u.TelegramID == telegramID {
+				target = true
+			} else {
+				others = true
+			}
+		}
+	}
+	return target && !others
+}
+
+func (m *memoryStore) Create(ctx context.Context, key *domain.APIKey) error {
+	key.ID = int64(len(m.keys) + 1)
+	m.keys = append(m.keys, *key)
+	return nil
+}
+
+func (m *memoryStore) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	for _, k := range m.keys {
+		if k.KeyHash == hash && !k.Revoked {
+			return &k, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryStore) GetByID(ctx context.Context, id int64) (*domain.APIKey, error) {
+	if id < 1 || int(id) > len(m.keys) {
+		return nil, nil
+	}
+	k := m.keys[id-1]
+	return &k, nil
+}
+
+func (m *memoryStore) GetByUser(ctx context.Context, userID int64) ([]domain.APIKey, error) {
+	return m.keys, nil
+}
+
+func (m *memoryStore) UpdateSettings(ctx context.Context, key *domain.APIKey) error {
+	m.keys[key.ID-1] = *key
+	return nil
+}
+
+func (m *memoryStore) Revoke(ctx context.Context, id int64) error {
+	m.keys[id-1].Revoked = true
+	return nil
+}
+
+func (m *memoryStore) Touch(ctx context.Context, id int64) error {
+	m.touched++
+	return nil
+}
+
+func newService(t *testing.T) (*Service, *memoryStore) {
+	t.Helper()
+	store := &memoryStore{}
+	s, err := NewService(context.Background(), Config{OrderCaps: map[string]float64{domain.RoleTrader: 100}}, store, store)
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	return s, store
+}
+
+func TestPermissions(t *testing.T) {
+	tests := []struct {
+		role string
+		perm Permission
+		want bool
+	}{
+		{domain.RoleViewer, PermView, true},
+		{domain.RoleViewer, PermTrade, false},
+		{domain.RoleTrader, PermGrid, true},
+		{domain.RoleTrader, PermRisk, false},
+		{domain.RoleRiskOfficer, PermRisk, true},
+		{domain.RoleRiskOfficer, PermTrade, false},
+		{domain.RoleAdmin, PermUsers, true},
+		{"", PermView, false},
+	}
+
+	for _, tt := range tests {
+		if got := Can(tt.role, tt.perm); got != tt.want {
+			t.Errorf("Can(%q, %s) = %v, want %v", tt.role, tt.perm, got, tt.want)
+		}
+	}
+
+	if err := Authorize(domain.RoleViewer, PermTrade); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Authorize viewer trade = %v", err)
+	}
+}
+
+func TestService_RolesAndBootstrap(t *testing.T) {
+	ctx := context.Background()
+	s, store := newService(t)
+
+	if err := s.Bootstrap(ctx, []int64{111}, []int64{222}); err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+	if s.Role(111) != domain.RoleAdmin || s.Role(222) != domain.RoleTrader {
+		t.Errorf("bootstrap roles = %q, %q", s.Role(111), s.Role(222))
+	}
+
+	// Роль, выданная через /users, переживает повторный bootstrap (перезапуск)
+	if _, err := s.SetRole(ctx, 222, domain.RoleViewer, "Ann"); err != nil {
+		t.Fatalf("SetRole: %v", err)
+	}
+	s.Bootstrap(ctx, []int64{111}, []int64{222})
+	if s.Role(222) != domain.RoleViewer {
+		t.Errorf("bootstrap overwrote role: %q", s.Role(222))
+	}
+
+	// Пользователи читаются из хранилища
+	reloaded, _ := NewService(context.Background(), Config{}, store, store)
+	if reloaded.Role(222) != domain.RoleViewer || len(reloaded.Users()) != 2 {
+		t.Errorf("reloaded users = %+v", reloaded.Users())
+	}
+
+	if _, err := s.SetRole(ctx, 333, "superuser", ""); err == nil {
+		t.Error("unknown role accepted")
+	}
+
+	// Смена роли без имени сохраняет имя
+	if u, err := s.SetRole(ctx, 222, domain.RoleRiskOfficer, ""); err != nil || u.Name != "Ann" {
+		t.Errorf("SetRole without name = %+v, %v; want name Ann", u, err)
+	}
+
+	// Последнего админа нельзя удалить или понизить
+	if err := s.Remove(ctx, 111); !errors.Is(err, domain.ErrLastAdmin) {
+		t.Errorf("last admin removed: %v", err)
+	}
+	if _, err := s.SetRole(ctx, 111, domain.RoleTrader, ""); !errors.Is(err, domain.ErrLastAdmin) {
+		t.Errorf("last admin demoted: %v", err)
+	}
+	s.SetRole(ctx, 333, domain.RoleAdmin, "")
+	if err := s.Remove(ctx, 111); err != nil || s.Role(111) != "" {
+		t.Errorf("Remove admin with another admin: %v", err)
+	}
+}
+
+func TestService_OrderCaps(t *testing.T) {
+	s, _ := newService(t)
+
+	if err := s.CheckOrderSize(domain.RoleTrader, 150); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("trader over cap = %v", err)
+	}
+	if err := s.CheckOrderSize(domain.RoleTrader, 100); err != nil {
+		t.Errorf("trader at cap = %v", err)
+	}
+	if err := s.CheckOrderSize(domain.RoleAdmin, 10000); err != nil {
+		t.Errorf("admin without cap = %v", err)
+	}
+}
+
+func TestService_APIKeys(t *testing.T) {
+	ctx := context.Background()
+	s, store := newService(t)
+	s.SetRole(ctx, 111, domain.RoleTrader, "")
+
+	if _, _, err := s.CreateAPIKey(ctx, 999, "ci"); err == nil {
+		t.Error("key issued for unknown user")
+	}
+
+	secret, key, err := s.CreateAPIKey(ctx, 111, "ci")
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if !strings.HasPrefix(secret, apiKeyPrefix) || strings.Contains(key.KeyHash, secret) || !strings.HasPrefix(secret, key.Prefix) {
+		t.Errorf("secret=%q key=%+v", secret, key)
+	}
+
+	if len(key.SigningSecret) != 64 || strings.Contains(key.SigningSecret, secret) {
+		t.Errorf("signing secret = %q", key.SigningSecret)
+	}
+
+	user, authKey, err := s.Authenticate(ctx, secret)
+	if err != nil || user.TelegramID != 111 || authKey.ID != key.ID || store.touched != 1 {
+		t.Errorf("Authenticate = %+v, %+v, %v (touched %d)", user, authKey, err, store.touched)
+	}
+
+	if _, _, err := s.Authenticate(ctx, secret+"x"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("wrong key = %v", err)
+	}
+
+	// Ограничения ключа
+	if _, err := s.SetAPIKeyIPs(ctx, key.ID, []string{"10.0.0.0/8", "bad"}); err == nil {
+		t.Error("invalid IP accepted")
+	}
+	s.SetAPIKeyIPs(ctx, key.ID, []string{"10.0.0.0/8", "192.168.1.5"})
+	s.SetAPIKeyRateLimit(ctx, key.ID, 10)
+	s.SetAPIKeySignature(ctx, key.ID, true)
+	_, authKey, _ = s.Authenticate(ctx, secret)
+	if len(authKey.AllowedIPs) != 2 || authKey.RateLimit != 10 || !authKey.RequireSignature {
+		t.Errorf("settings = %+v", authKey)
+	}
+
+	s.RevokeAPIKey(ctx, key.ID)
+	if _, _, err := s.Authenticate(ctx, secret); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("revoked key = %v", err)
+	}
+	if _, err := s.SetAPIKeyRateLimit(ctx, key.ID, 5); err == nil {
+		t.Error("revoked key updated")
+	}
+}
+
+func TestParseIPNet(t *testing.T) {
+	tests := []struct {
+		value string
+		ip    string
+		want  bool
+	}{
+		{"10.0.0.0/8", "10.1.2.3", true},
+		{"10.0.0.0/8", "11.1.2.3", false},
+		{"192.168.1.5", "192.168.1.5", true},
+		{"192.168.1.5", "192.168.1.6", false},
+		{"2001:db8::/32", "2001:db8::1", true},
+	}
+	for _, tt := range tests {
+		ipNet, err := ParseIPNet(tt.value)
+		if err != nil {
+			t.Fatalf("ParseIPNet(%q): %v", tt.value, err)
+		}
+		if got := ipNet.Contains(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("%s contains %s = %v", tt.value, tt.ip, got)
+		}
+	}
+	if _, err := ParseIPNet("10.0.0.300"); err == nil {
+		t.Error("invalid IP accepted")
+	}
+}
+
+func TestConfigFrom(t *testing.T) {
+	cfg, err := ConfigFrom(config.AccessConfig{OrderCaps: []string{"TRADER:100", "ADMIN:0"}})
+	if err != nil || cfg.OrderCaps[domain.RoleTrader] != 100 || cfg.OrderCaps[domain.RoleAdmin] != 0 {
+		t.Errorf("ConfigFrom = %+v, %v", cfg, err)
+	}
+
+	for _, bad := range []string{"trader", "boss:10", "trader:-1", "trader:abc"} {
+		if _, err := ConfigFrom(config.AccessConfig{OrderCaps: []string{bad}}); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package reports
+
+import (
+	"fmt"
+
+	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/ai/agents"
+	"github.com/kirillm/dca-bot/internal/config"
+)
+
+// NewNarratorFromConfig создает narrator на локальной модели.
+// Возвращает nil, если локальная модель выключена - отчеты уходят без резюме.
+func NewNarratorFromConfig(cfg config.AIConfig, tracker *ai.CostTracker) (Narrator, error) {
+	if !cfg.Local.Enabled {
+		return nil, nil
+	}
+	return agents.NewLocalClientFromConfig(cfg, tracker, ai.PurposeReport)
+}
+
+// SchedulerConfigFrom параметры расписания из REPORT_* переменных
+func SchedulerConfigFrom(cfg config.ReportsConfig) (SchedulerConfig, error) {
+	periods := make([]string, 0, len(cfg.Periods))
+	for _, p := range cfg.Periods {
+		period, err := ParsePeriod(p)
+		if err != nil {
+			return SchedulerConfig{}, fmt.Errorf("REPORT_PERIODS: %w", err)
+		}
+		periods = append(periods, period)
+	}
+
+	return SchedulerConfig{
+		DeliveryTime: cfg.DeliveryTime,
+		Periods:      periods,
+	}, nil
+}
@@ -0,0 +1,174 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/ai/prompts"
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/pkg/utils"
+)
+
+// PnLStore снимки P&L (PnLRepository)
+type PnLStore interface {
+	GetLatestBefore(ctx context.Context, t time.Time) ([]domain.PnLHistory, error)
+	GetLastSnapshotTime(ctx context.Context, snapshotType string) (time.Time, error)
+}
+
+// TradeStore сделки (TradeRepository)
+type TradeStore interface {
+	GetBetween(ctx context.Context, from, to time.Time) ([]domain.Trade, error)
+}
+
+// DecisionStore решения DecisionAgent (AIDecisionRepository)
+type DecisionStore interface {
+	GetBetween(ctx context.Context, from, to time.Time) ([]domain.AIDecision, error)
+}
+
+// ActionStore действия AI (AIActionRepository)
+type ActionStore interface {
+	GetStatusCounts(ctx context.Context, from, to time.Time) (map[string]int, error)
+}
+
+// BreakerStore события circuit breaker (CircuitBreakerRepository)
+type BreakerStore interface {
+	GetBetween(ctx context.Context, from, to time.Time) ([]domain.CircuitBreakerEvent, error)
+}
+
+// Narrator LLM для краткого резюме отчета (ai.AIClient локальной модели)
+type Narrator interface {
+	Complete(systemPrompt, userPrompt string) (string, error)
+}
+
+// Stores источники данных отчета
+type Stores struct {
+	PnL       PnLStore
+	Trades    TradeStore
+	Decisions DecisionStore
+	Actions   ActionStore
+	Breakers  BreakerStore
+}
+
+// Generator собирает отчет за период и дополняет его резюме от локальной модели
+type Generator struct {
+	stores   Stores
+	narrator Narrator
+	prompts  *prompts.Registry
+	feeRate  float64
+}
+
+// NewGenerator создает генератор отчетов.
+// narrator может быть nil - тогда отчет отправляется без резюме.
+func NewGenerator(stores Stores, narrator Narrator, registry *prompts.Registry, feeRate float64) *Generator {
+	if registry == nil {
+		registry = prompts.Default()
+	}
+	if feeRate <= 0 {
+		feeRate = DefaultFeeRate
+	}
+
+	return &Generator{
+		stores:   stores,
+		narrator: narrator,
+		prompts:  registry,
+		feeRate:  feeRate,
+	}
+}
+
+// Build собирает факты отчета за [from, to)
+func (g *Generator) Build(ctx context.Context, period string, from, to time.Time) (*Report, error) {
+	report := &Report{Period: period, From: from, To: to}
+
+	start, err := g.stores.PnL.GetLatestBefore(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load PnL snapshots: %w", err)
+	}
+	end, err := g.stores.PnL.GetLatestBefore(ctx, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load PnL snapshots: %w", err)
+	}
+	report.addSnapshots(start, end)
+
+	trades, err := g.stores.Trades.GetBetween(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trades: %w", err)
+	}
+	report.addTrades(trades, g.feeRate)
+
+	decisions, err := g.stores.Decisions.GetBetween(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AI decisions: %w", err)
+	}
+	statuses, err := g.stores.Actions.GetStatusCounts(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AI actions: %w", err)
+	}
+	report.addDecisions(decisions, statuses)
+
+	report.BreakerEvents, err = g.stores.Breakers.GetBetween(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load circuit breaker events: %w", err)
+	}
+
+	return report, nil
+}
+
+// Generate собирает отчет и резюме. Ошибка модели не прерывает отчет - он уходит без резюме.
+func (g *Generator) Generate(ctx context.Context, period string, from, to time.Time) (*domain.PortfolioReport, error) {
+	report, err := g.Build(ctx, period, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	facts := report.Format()
+	content := facts
+
+	narrative, err := g.narrate(ctx, facts)
+	if err != nil {
+		utils.LogWarn(fmt.Sprintf("Report narrative failed: %v", err))
+	}
+	if narrative != "" {
+		content += "\n📝 Резюме\n" + narrative + "\n"
+	}
+
+	return &domain.PortfolioReport{
+		Period:      period,
+		PeriodStart: from,
+		PeriodEnd:   to,
+		Content:     content,
+		Narrative:   narrative,
+	}, nil
+}
+
+// OnDemand отчет за скользящее окно, заканчивающееся сейчас (/report)
+func (g *Generator) OnDemand(ctx context.Context, period string) (string, error) {
+	from, to := RollingWindow(period, time.Now())
+	report, err := g.Generate(ctx, period, from, to)
+	if err != nil {
+		return "", err
+	}
+	return report.Content, nil
+}
+
+// narrate просит локальную модель кратко пересказать отчет
+func (g *Generator) narrate(ctx context.Context, facts string) (string, error) {
+	if g.narrator == nil {
+		return "", nil
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	system, prompt, err := g.prompts.Render(prompts.Report, prompts.SectionSystem, nil)
+	if err != nil {
+		return "", err
+	}
+
+	narrative, err := g.narrator.Complete(system, facts)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", prompt.ID(), err)
+	}
+	return strings.TrimSpace(narrative), nil
+}
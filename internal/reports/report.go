@@ -0,0 +1,271 @@
+package reports
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// Периоды отчетов (совпадают с типами снимков pnl_history)
+const (
+	PeriodDaily   = domain.SnapshotDaily
+	PeriodWeekly  = domain.SnapshotWeekly
+	PeriodMonthly = domain.SnapshotMonthly
+)
+
+// DefaultFeeRate комиссия спотовой сделки Bybit (taker 0.1%) для оценки комиссий
+const DefaultFeeRate = 0.001
+
+// ParsePeriod разбирает период из команды: daily/day/д, weekly/week/н, monthly/month/м
+func ParsePeriod(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "daily", "day", "d", "день", "д":
+		return PeriodDaily, nil
+	case "weekly", "week", "w", "неделя", "н":
+		return PeriodWeekly, nil
+	case "monthly", "month", "m", "месяц", "м":
+		return PeriodMonthly, nil
+	default:
+		return "", fmt.Errorf("unknown report period %q (daily, weekly, monthly)", s)
+	}
+}
+
+// PeriodBounds календарный период, содержащий t: [start, end).
+// HOURLY - час, DAILY - сутки, WEEKLY - неделя с понедельника, MONTHLY - месяц.
+func PeriodBounds(period string, t time.Time) (time.Time, time.Time) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	switch period {
+	case domain.SnapshotHourly:
+		start := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+		return start, start.Add(time.Hour)
+	case PeriodWeekly:
+		offset := (int(day.Weekday()) + 6) % 7 // дней с понедельника
+		start := day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 7)
+	case PeriodMonthly:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		return start, start.AddDate(0, 1, 0)
+	default:
+		return day, day.AddDate(0, 0, 1)
+	}
+}
+
+// PreviousPeriod последний завершившийся календарный период на момент t
+func PreviousPeriod(period string, t time.Time) (time.Time, time.Time) {
+	current, _ := PeriodBounds(period, t)
+	return PeriodBounds(period, current.Add(-time.Nanosecond))
+}
+
+// RollingWindow скользящее окно длиной в период, заканчивающееся в t (для /report)
+func RollingWindow(period string, t time.Time) (time.Time, time.Time) {
+	switch period {
+	case PeriodWeekly:
+		return t.AddDate(0, 0, -7), t
+	case PeriodMonthly:
+		return t.AddDate(0, -1, 0), t
+	default:
+		return t.AddDate(0, 0, -1), t
+	}
+}
+
+// AssetChange изменение P&L актива за период
+type AssetChange struct {
+	Symbol     string
+	StartValue float64
+	EndValue   float64
+	PnLChange  float64 // изменение total_pnl за период
+	TotalPnL   float64 // total_pnl на конец периода
+	ReturnPct  float64 // доходность позиции на конец периода
+}
+
+// Report факты отчета за период
+type Report struct {
+	Period string
+	From   time.Time
+	To     time.Time
+
+	StartValue float64
+	EndValue   float64
+	PnLChange  float64
+	TotalPnL   float64
+	Assets     []AssetChange // по убыванию PnLChange
+
+	Buys         int
+	Sells        int
+	Volume       float64
+	FeesEstimate float64
+
+	Decisions         int
+	DecisionsApproved int
+	Regimes           map[string]int
+	ActionStatuses    map[string]int
+
+	BreakerEvents []domain.CircuitBreakerEvent
+}
+
+// Best актив с наибольшим приростом P&L (nil, если активов нет)
+func (r *Report) Best() *AssetChange {
+	if len(r.Assets) == 0 {
+		return nil
+	}
+	return &r.Assets[0]
+}
+
+// Worst актив с наименьшим приростом P&L (nil, если активов меньше двух)
+func (r *Report) Worst() *AssetChange {
+	if len(r.Assets) < 2 {
+		return nil
+	}
+	return &r.Assets[len(r.Assets)-1]
+}
+
+// addSnapshots считает изменение P&L по снимкам на начало и конец периода.
+// Актив без снимка на начало считается открытым в течение периода.
+func (r *Report) addSnapshots(start, end []domain.PnLHistory) {
+	before := make(map[string]domain.PnLHistory, len(start))
+	for _, s := range start {
+		before[s.Symbol] = s
+		r.StartValue += s.CurrentValue
+	}
+
+	for _, s := range end {
+		prev := before[s.Symbol]
+		r.Assets = append(r.Assets, AssetChange{
+			Symbol:     s.Symbol,
+			StartValue: prev.CurrentValue,
+			EndValue:   s.CurrentValue,
+			PnLChange:  s.TotalPnL - prev.TotalPnL,
+			TotalPnL:   s.TotalPnL,
+			ReturnPct:  s.ReturnPercent,
+		})
+		r.EndValue += s.CurrentValue
+		r.PnLChange += s.TotalPnL - prev.TotalPnL
+		r.TotalPnL += s.TotalPnL
+	}
+
+	sort.Slice(r.Assets, func(i, j int) bool {
+		if r.Assets[i].PnLChange == r.Assets[j].PnLChange {
+			return r.Assets[i].Symbol < r.Assets[j].Symbol
+		}
+		return r.Assets[i].PnLChange > r.Assets[j].PnLChange
+	})
+}
+
+// addTrades учитывает исполненные сделки; комиссии оцениваются по feeRate от объема
+func (r *Report) addTrades(trades []domain.Trade, feeRate float64) {
+	for _, t := range trades {
+		if t.Status != domain.StatusFilled {
+			continue
+		}
+		switch t.Side {
+		case domain.SideBuy:
+			r.Buys++
+		case domain.SideSell:
+			r.Sells++
+		}
+		r.Volume += t.Amount
+	}
+	r.FeesEstimate = r.Volume * feeRate
+}
+
+// addDecisions учитывает решения DecisionAgent и статусы их действий
+func (r *Report) addDecisions(decisions []domain.AIDecision, actionStatuses map[string]int) {
+	r.Regimes = make(map[string]int)
+	for _, d := range decisions {
+		r.Decisions++
+		if d.Approved {
+			r.DecisionsApproved++
+		}
+		r.Regimes[d.Regime]++
+	}
+	r.ActionStatuses = actionStatuses
+}
+
+// periodTitle заголовок периода на русском
+func periodTitle(period string) string {
+	switch period {
+	case PeriodWeekly:
+		return "Недельный отчет"
+	case PeriodMonthly:
+		return "Месячный отчет"
+	default:
+		return "Дневной отчет"
+	}
+}
+
+// Format текст отчета для Telegram (без narrative)
+func (r *Report) Format() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("📊 %s\n%s — %s\n\n", periodTitle(r.Period),
+		r.From.Format("02.01.2006 15:04"), r.To.Format("02.01.2006 15:04")))
+
+	sb.WriteString("💼 Портфель\n")
+	if len(r.Assets) == 0 {
+		sb.WriteString("Нет снимков P&L за период\n")
+	} else {
+		changePct := 0.0
+		if r.StartValue > 0 {
+			changePct = (r.EndValue - r.StartValue) / r.StartValue * 100
+		}
+		sb.WriteString(fmt.Sprintf("Стоимость: $%.2f → $%.2f (%+.2f%%)\n", r.StartValue, r.EndValue, changePct))
+		sb.WriteString(fmt.Sprintf("P&L за период: %+.2f$\n", r.PnLChange))
+		sb.WriteString(fmt.Sprintf("P&L всего: %+.2f$\n", r.TotalPnL))
+		if best := r.Best(); best != nil {
+			sb.WriteString(fmt.Sprintf("🟢 Лучший: %s %+.2f$ (%.2f%%)\n", best.Symbol, best.PnLChange, best.ReturnPct))
+		}
+		if worst := r.Worst(); worst != nil {
+			sb.WriteString(fmt.Sprintf("🔴 Худший: %s %+.2f$ (%.2f%%)\n", worst.Symbol, worst.PnLChange, worst.ReturnPct))
+		}
+	}
+
+	sb.WriteString("\n💱 Сделки\n")
+	sb.WriteString(fmt.Sprintf("Покупок: %d, продаж: %d, объем: $%.2f\n", r.Buys, r.Sells, r.Volume))
+	sb.WriteString(fmt.Sprintf("Комиссии (оценка): $%.2f\n", r.FeesEstimate))
+
+	sb.WriteString("\n🧠 AI решения\n")
+	if r.Decisions == 0 {
+		sb.WriteString("Решений не было\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("Решений: %d, одобрено политикой: %d\n", r.Decisions, r.DecisionsApproved))
+		sb.WriteString("Режимы: " + formatCounts(r.Regimes) + "\n")
+	}
+	if len(r.ActionStatuses) > 0 {
+		sb.WriteString("Действия: " + formatCounts(r.ActionStatuses) + "\n")
+	}
+
+	sb.WriteString("\n🛡️ Circuit breaker\n")
+	if len(r.BreakerEvents) == 0 {
+		sb.WriteString("Срабатываний не было\n")
+	} else {
+		for _, e := range r.BreakerEvents {
+			sb.WriteString(fmt.Sprintf("• %s: %s\n", e.TriggeredAt.Format("02.01 15:04"), e.Reason))
+		}
+	}
+
+	return sb.String()
+}
+
+// formatCounts "a: 2, b: 1" в порядке убывания
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] == counts[keys[j]] {
+			return keys[i] < keys[j]
+		}
+		return counts[keys[i]] > counts[keys[j]]
+	})
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s: %d", k, counts[k]))
+	}
+	return strings.Join(parts, ", ")
+}
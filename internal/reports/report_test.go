@@ -0,0 +1,351 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// memoryStores данные отчета в памяти для тестов
+type memoryStores struct {
+	snapshots []domain.PnLHistory
+	trades    []domain.Trade
+	decisions []domain.AIDecision
+	actions   map[string]int
+	breakers  []domain.CircuitBreakerEvent
+	reports   map[string]domain.PortfolioReport
+	saved     []string // снимки, сохраненные через SavePnLSnapshot
+	markErr   error    // ошибка MarkDelivered
+	marks     int      // вызовы MarkDelivered
+}
+
+func (m *memoryStores) GetLatestBefore(ctx context.Context, t time.Time) ([]domain.PnLHistory, error) {
+	latest := make(map[string]domain.PnLHistory)
+	for _, s := range m.snapshots {
+		if s.CreatedAt.After(t) {
+			continue
+		}
+		if prev, ok := latest[s.Symbol]; !ok || s.CreatedAt.After(prev.CreatedAt) {
+			latest[s.Symbol] = s
+		}
+	}
+	var result []domain.PnLHistory
+	for _, s := range latest {
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+func (m *memoryStores) GetLastSnapshotTime(ctx context.Context, snapshotType string) (time.Time, error) {
+	var last time.Time
+	for _, s := range m.snapshots {
+		if s.SnapshotType == snapshotType && s.CreatedAt.After(last) {
+			last = s.CreatedAt
+		}
+	}
+	return last, nil
+}
+
+func (m *memoryStores) GetBetween(ctx context.Context, from, to time.Time) ([]domain.Trade, error) {
+	var result []domain.Trade
+	for _, t := range m.trades {
+		if !t.CreatedAt.Before(from) && t.CreatedAt.Before(to) {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryStores) GetStatusCounts(ctx context.Context, from, to time.Time) (map[string]int, error) {
+	return m.actions, nil
+}
+
+func (m *memoryStores) Save(ctx context.Context, report *domain.PortfolioReport) error {
+	key := report.Period + report.PeriodStart.String()
+	if prev, ok := m.reports[key]; ok {
+		report.ID = prev.ID
+	} else {
+		report.ID = int64(len(m.reports) + 1)
+	}
+	m.reports[key] = *report
+	return nil
+}
+
+func (m *memoryStores) Exists(ctx context.Context, period string, periodStart time.Time) (bool, error) {
+	_, ok := m.reports[period+periodStart.String()]
+	return ok, nil
+}
+
+func (m *memoryStores) MarkDelivered(ctx context.Context, id int64) error {
+	m.marks++
+	if m.markErr != nil {
+		return m.markErr
+	}
+	for key, r := range m.reports {
+		if r.ID == id {
+			r.Delivered = true
+			m.reports[key] = r
+		}
+	}
+	return nil
+}
+
+type decisionStore struct{ m *memoryStores }
+
+func (d decisionStore) GetBetween(ctx context.Context, from, to time.Time) ([]domain.AIDecision, error) {
+	return d.m.decisions, nil
+}
+
+type breakerStore struct{ m *memoryStores }
+
+func (b breakerStore) GetBetween(ctx context.Context, from, to time.Time) ([]domain.CircuitBreakerEvent, error) {
+	return b.m.breakers, nil
+}
+
+// snapshotter пишет снимок типа в memoryStores
+type snapshotter struct {
+	m   *memoryStores
+	now time.Time
+}
+
+func (s *snapshotter) SavePnLSnapshot(snapshotType string) error {
+	s.m.saved = append(s.m.saved, snapshotType)
+	s.m.snapshots = append(s.m.snapshots, domain.PnLHistory{Symbol: "BTCUSDT", SnapshotType: snapshotType, CreatedAt: s.now})
+	return nil
+}
+
+type fakeNarrator struct {
+	response string
+	err      error
+}
+
+func (n *fakeNarrator) Complete(systemPrompt, userPrompt string) (string, error) {
+	return n.response, n.err
+}
+
+func newStores() *memoryStores {
+	return &memoryStores{reports: make(map[string]domain.PortfolioReport)}
+}
+
+func (m *memoryStores) stores() Stores {
+	return Stores{PnL: m, Trades: m, Decisions: decisionStore{m}, Actions: m, Breakers: breakerStore{m}}
+}
+
+func TestPeriodBounds(t *testing.T) {
+	// Среда, 15 октября 2025, 14:30
+	now := time.Date(2025, 10, 15, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		period     string
+		start, end time.Time
+	}{
+		{domain.SnapshotHourly, time.Date(2025, 10, 15, 14, 0, 0, 0, time.UTC), time.Date(2025, 10, 15, 15, 0, 0, 0, time.UTC)},
+		{PeriodDaily, time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC), time.Date(2025, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{PeriodWeekly, time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC), time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC)},
+		{PeriodMonthly, time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		start, end := PeriodBounds(tt.period, now)
+		if !start.Equal(tt.start) || !end.Equal(tt.end) {
+			t.Errorf("%s: got [%v, %v), want [%v, %v)", tt.period, start, end, tt.start, tt.end)
+		}
+	}
+
+	// Воскресенье относится к неделе, начавшейся в понедельник
+	start, _ := PeriodBounds(PeriodWeekly, time.Date(2025, 10, 19, 23, 0, 0, 0, time.UTC))
+	if !start.Equal(time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("sunday week start = %v", start)
+	}
+
+	from, to := PreviousPeriod(PeriodMonthly, now)
+	if !from.Equal(time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("previous month = [%v, %v)", from, to)
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	for input, want := range map[string]string{"": PeriodDaily, "Weekly": PeriodWeekly, "месяц": PeriodMonthly} {
+		got, err := ParsePeriod(input)
+		if err != nil || got != want {
+			t.Errorf("ParsePeriod(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParsePeriod("yearly"); err == nil {
+		t.Error("expected error for unknown period")
+	}
+}
+
+func TestGenerator_Build(t *testing.T) {
+	from := time.Date(2025, 10, 14, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	m := newStores()
+	m.snapshots = []domain.PnLHistory{
+		{Symbol: "BTCUSDT", TotalPnL: 100, CurrentValue: 1000, CreatedAt: from.Add(-time.Hour)},
+		{Symbol: "ETHUSDT", TotalPnL: 50, CurrentValue: 500, CreatedAt: from.Add(-time.Hour)},
+		{Symbol: "BTCUSDT", TotalPnL: 130, CurrentValue: 1030, ReturnPercent: 13, CreatedAt: to.Add(-time.Hour)},
+		{Symbol: "ETHUSDT", TotalPnL: 40, CurrentValue: 490, ReturnPercent: 4, CreatedAt: to.Add(-time.Hour)},
+		{Symbol: "SOLUSDT", TotalPnL: 5, CurrentValue: 105, CreatedAt: to.Add(-time.Hour)},   // открыт за период
+		{Symbol: "BTCUSDT", TotalPnL: 999, CurrentValue: 9999, CreatedAt: to.Add(time.Hour)}, // после периода
+	}
+	m.trades = []domain.Trade{
+		{Side: domain.SideBuy, Amount: 100, Status: domain.StatusFilled, CreatedAt: from.Add(time.Hour)},
+		{Side: domain.SideSell, Amount: 50, Status: domain.StatusFilled, CreatedAt: from.Add(2 * time.Hour)},
+		{Side: domain.SideBuy, Amount: 70, Status: domain.StatusCancelled, CreatedAt: from.Add(3 * time.Hour)},
+	}
+	m.decisions = []domain.AIDecision{
+		{Regime: "ACCUMULATE", Approved: true},
+		{Regime: "ACCUMULATE", Approved: false},
+		{Regime: "DEFENSE", Approved: true},
+	}
+	m.actions = map[string]int{"executed": 2, "failed": 1}
+	m.breakers = []domain.CircuitBreakerEvent{{TriggeredAt: from.Add(5 * time.Hour), Reason: "drawdown"}}
+
+	g := NewGenerator(m.stores(), nil, nil, 0.001)
+	report, err := g.Build(context.Background(), PeriodDaily, from, to)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	if report.PnLChange != 30-10+5 {
+		t.Errorf("PnLChange = %v, want 25", report.PnLChange)
+	}
+	if report.StartValue != 1500 || report.EndValue != 1625 {
+		t.Errorf("values = %v → %v, want 1500 → 1625", report.StartValue, report.EndValue)
+	}
+	if best := report.Best(); best == nil || best.Symbol != "BTCUSDT" {
+		t.Errorf("best = %+v, want BTCUSDT", best)
+	}
+	if worst := report.Worst(); worst == nil || worst.Symbol != "ETHUSDT" {
+		t.Errorf("worst = %+v, want ETHUSDT", worst)
+	}
+	if report.Buys != 1 || report.Sells != 1 || report.Volume != 150 {
+		t.Errorf("trades = %d buys, %d sells, $%v volume", report.Buys, report.Sells, report.Volume)
+	}
+	if report.FeesEstimate != 0.15 {
+		t.Errorf("fees = %v, want 0.15", report.FeesEstimate)
+	}
+	if report.Decisions != 3 || report.DecisionsApproved != 2 || report.Regimes["ACCUMULATE"] != 2 {
+		t.Errorf("decisions = %d/%d %v", report.DecisionsApproved, report.Decisions, report.Regimes)
+	}
+
+	text := report.Format()
+	for _, want := range []string{"Дневной отчет", "Лучший: BTCUSDT", "Худший: ETHUSDT", "executed: 2", "drawdown"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("report text missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestGenerator_NarrativeFailureKeepsReport(t *testing.T) {
+	m := newStores()
+	now := time.Now()
+
+	g := NewGenerator(m.stores(), &fakeNarrator{err: errors.New("ollama down")}, nil, 0)
+	report, err := g.Generate(context.Background(), PeriodDaily, now.AddDate(0, 0, -1), now)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if report.Narrative != "" || strings.Contains(report.Content, "Резюме") {
+		t.Errorf("unexpected narrative: %q", report.Narrative)
+	}
+
+	g = NewGenerator(m.stores(), &fakeNarrator{response: "  Портфель вырос.  "}, nil, 0)
+	report, err = g.Generate(context.Background(), PeriodDaily, now.AddDate(0, 0, -1), now)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if report.Narrative != "Портфель вырос." || !strings.Contains(report.Content, "Резюме\nПортфель вырос.") {
+		t.Errorf("narrative not included: %q", report.Content)
+	}
+}
+
+func TestScheduler_Tick(t *testing.T) {
+	m := newStores()
+	// Понедельник 20 октября 2025, 08:00 - до времени отправки
+	now := time.Date(2025, 10, 20, 8, 0, 0, 0, time.UTC)
+	snap := &snapshotter{m: m, now: now}
+
+	var sent []string
+	s := NewScheduler(
+		SchedulerConfig{DeliveryTime: 9 * time.Hour, Periods: []string{PeriodDaily, PeriodWeekly, PeriodMonthly}, Location: time.UTC},
+		snap, m, m, NewGenerator(m.stores(), nil, nil, 0),
+		func(text string) { sent = append(sent, text) },
+	)
+
+	s.Tick(context.Background(), now)
+	if len(m.saved) != len(snapshotTypes) {
+		t.Errorf("first tick saved %v, want all snapshot types", m.saved)
+	}
+	if len(sent) != 0 {
+		t.Errorf("reports sent before delivery time: %d", len(sent))
+	}
+
+	// Тот же час: снимки уже есть
+	s.Tick(context.Background(), now.Add(30*time.Minute))
+	if len(m.saved) != len(snapshotTypes) {
+		t.Errorf("duplicate snapshots: %v", m.saved)
+	}
+
+	// После 09:00: новый час → HOURLY, дневной и недельный отчеты (месячный - только 1 числа)
+	later := now.Add(90 * time.Minute)
+	snap.now = later
+	s.Tick(context.Background(), later)
+	if got := m.saved[len(m.saved)-1]; len(m.saved) != len(snapshotTypes)+1 || got != domain.SnapshotHourly {
+		t.Errorf("saved = %v, want one more HOURLY", m.saved)
+	}
+	if len(sent) != 2 {
+		t.Fatalf("sent %d reports, want 2 (daily and weekly)", len(sent))
+	}
+	if !strings.Contains(sent[1], "Недельный отчет\n13.10.2025 00:00 — 20.10.2025 00:00") {
+		t.Errorf("weekly report period wrong:\n%s", sent[1])
+	}
+
+	// Отправленные отчеты не повторяются
+	s.Tick(context.Background(), later.Add(time.Minute))
+	if len(sent) != 2 {
+		t.Errorf("reports resent: %d", len(sent))
+	}
+	for key, r := range m.reports {
+		if !r.Delivered {
+			t.Errorf("report %s not marked delivered", key)
+		}
+	}
+}
+
+func TestScheduler_MarkDeliveredFailureDoesNotResend(t *testing.T) {
+	m := newStores()
+	m.markErr = errors.New("db down")
+	now := time.Date(2025, 10, 21, 10, 0, 0, 0, time.UTC)
+
+	var sent int
+	s := NewScheduler(
+		SchedulerConfig{DeliveryTime: 9 * time.Hour, Periods: []string{PeriodDaily}, Location: time.UTC},
+		&snapshotter{m: m, now: now}, m, m, NewGenerator(m.stores(), nil, nil, 0),
+		func(string) { sent++ },
+	)
+
+	// Отметка не записалась: отчет уже сохранен, поэтому повторно не шлется,
+	// а отметка повторяется ограниченное число раз
+	for i := 0; i < markDeliveredAttempts+3; i++ {
+		s.Tick(context.Background(), now.Add(time.Duration(i)*time.Minute))
+	}
+	if sent != 1 {
+		t.Errorf("report sent %d times, want 1", sent)
+	}
+	if m.marks != markDeliveredAttempts {
+		t.Errorf("MarkDelivered called %d times, want %d", m.marks, markDeliveredAttempts)
+	}
+
+	// БД вернулась, но попытки исчерпаны - отчет остается неотмеченным, без повторной отправки
+	m.markErr = nil
+	s.Tick(context.Background(), now.Add(time.Hour))
+	if sent != 1 || m.marks != markDeliveredAttempts {
+		t.Errorf("after recovery: sent %d, marks %d", sent, m.marks)
+	}
+}
@@ -0,0 +1,220 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/pkg/utils"
+)
+
+// tickInterval как часто планировщик проверяет, не пора ли сделать снимок или отчет
+const tickInterval = time.Minute
+
+// markDeliveredAttempts сколько проверок подряд пытаться отметить отправленный отчет
+const markDeliveredAttempts = 5
+
+// snapshotTypes типы снимков pnl_history, которые пишет планировщик
+var snapshotTypes = []string{
+	domain.SnapshotHourly,
+	domain.SnapshotDaily,
+	domain.SnapshotWeekly,
+	domain.SnapshotMonthly,
+}
+
+// Snapshotter сохраняет снимок P&L по всем активам (manager.PortfolioManager)
+type Snapshotter interface {
+	SavePnLSnapshot(snapshotType string) error
+}
+
+// ReportStore отправленные отчеты (ReportRepository)
+type ReportStore interface {
+	Save(ctx context.Context, report *domain.PortfolioReport) error
+	Exists(ctx context.Context, period string, periodStart time.Time) (bool, error)
+	MarkDelivered(ctx context.Context, id int64) error
+}
+
+// SchedulerConfig параметры расписания
+type SchedulerConfig struct {
+	DeliveryTime time.Duration // смещение от начала суток, когда отправлять отчеты (9h = 09:00)
+	Periods      []string      // DAILY, WEEKLY, MONTHLY
+	Location     *time.Location
+}
+
+// Scheduler пишет HOURLY/DAILY/WEEKLY/MONTHLY снимки P&L и отправляет отчеты
+// за завершившиеся периоды в заданное время
+type Scheduler struct {
+	config      SchedulerConfig
+	snapshotter Snapshotter
+	pnl         PnLStore
+	reports     ReportStore
+	generator   *Generator
+	notifyFunc  func(string)
+
+	// undelivered отправленные отчеты, которые не удалось отметить в БД: id → попыток
+	undelivered map[int64]int
+
+	mu       sync.Mutex
+	stopChan chan struct{}
+	running  bool
+}
+
+// NewScheduler создает планировщик. Снимок каждого типа делается в первой проверке
+// нового периода, поэтому перезапуск не дублирует и не пропускает снимки.
+func NewScheduler(config SchedulerConfig, snapshotter Snapshotter, pnl PnLStore, reports ReportStore, generator *Generator, notifyFunc func(string)) *Scheduler {
+	if config.Location == nil {
+		config.Location = time.Local
+	}
+	if len(config.Periods) == 0 {
+		config.Periods = []string{PeriodDaily, PeriodWeekly}
+	}
+
+	return &Scheduler{
+		config:      config,
+		snapshotter: snapshotter,
+		pnl:         pnl,
+		reports:     reports,
+		generator:   generator,
+		notifyFunc:  notifyFunc,
+		undelivered: make(map[int64]int),
+	}
+}
+
+// Start запускает периодическую проверку
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.stopChan = make(chan struct{})
+	stopChan := s.stopChan
+	s.mu.Unlock()
+
+	utils.LogInfo(fmt.Sprintf("🗓 Report scheduler started: reports %v at %s",
+		s.config.Periods, formatClock(s.config.DeliveryTime)))
+
+	go func() {
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+
+		s.Tick(ctx, time.Now())
+		for {
+			select {
+			case now := <-ticker.C:
+				s.Tick(ctx, now)
+			case <-stopChan:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop останавливает планировщик
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.running {
+		return
+	}
+	close(s.stopChan)
+	s.running = false
+}
+
+// Tick делает снимки за новые периоды и отправляет причитающиеся отчеты
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) {
+	now = now.In(s.config.Location)
+
+	for _, snapshotType := range snapshotTypes {
+		if err := s.snapshotIfDue(ctx, snapshotType, now); err != nil {
+			utils.LogError(fmt.Sprintf("PnL snapshot %s failed: %v", snapshotType, err))
+		}
+	}
+
+	s.retryMarkDelivered(ctx)
+	for _, period := range s.config.Periods {
+		if err := s.reportIfDue(ctx, period, now); err != nil {
+			utils.LogError(fmt.Sprintf("Report %s failed: %v", period, err))
+		}
+	}
+}
+
+// snapshotIfDue сохраняет снимок, если за текущий период его еще нет
+func (s *Scheduler) snapshotIfDue(ctx context.Context, snapshotType string, now time.Time) error {
+	start, _ := PeriodBounds(snapshotType, now)
+
+	last, err := s.pnl.GetLastSnapshotTime(ctx, snapshotType)
+	if err != nil {
+		return err
+	}
+	if !last.Before(start) {
+		return nil
+	}
+
+	return s.snapshotter.SavePnLSnapshot(snapshotType)
+}
+
+// reportIfDue отправляет отчет за завершившийся период в день его окончания после
+// времени доставки, если он еще не был сохранен. Более старые отчеты не догоняются.
+func (s *Scheduler) reportIfDue(ctx context.Context, period string, now time.Time) error {
+	from, to := PreviousPeriod(period, now)
+	if now.Before(to.Add(s.config.DeliveryTime)) || !now.Before(to.AddDate(0, 0, 1)) {
+		return nil
+	}
+
+	// Сохраненный отчет уже занят: повторная отправка возможна только до записи в БД
+	claimed, err := s.reports.Exists(ctx, period, from)
+	if err != nil || claimed {
+		return err
+	}
+
+	report, err := s.generator.Generate(ctx, period, from, to)
+	if err != nil {
+		return err
+	}
+
+	// Сначала сохраняем: при недоступной БД лучше пропустить отчет, чем слать его каждую минуту
+	if err := s.reports.Save(ctx, report); err != nil {
+		return fmt.Errorf("failed to save report: %w", err)
+	}
+
+	if s.notifyFunc != nil {
+		s.notifyFunc(report.Content)
+	}
+
+	utils.LogInfo(fmt.Sprintf("📊 Report %s %s delivered", period, from.Format("2006-01-02")))
+
+	if err := s.reports.MarkDelivered(ctx, report.ID); err != nil {
+		s.undelivered[report.ID] = 1
+		return fmt.Errorf("failed to mark report delivered: %w", err)
+	}
+	return nil
+}
+
+// retryMarkDelivered повторяет отметку отправленных отчетов; сам отчет повторно не шлется
+func (s *Scheduler) retryMarkDelivered(ctx context.Context) {
+	for id, attempts := range s.undelivered {
+		err := s.reports.MarkDelivered(ctx, id)
+		if err == nil {
+			delete(s.undelivered, id)
+			continue
+		}
+		if attempts+1 >= markDeliveredAttempts {
+			utils.LogError(fmt.Sprintf("Report #%d delivered but not marked after %d attempts: %v", id, attempts+1, err))
+			delete(s.undelivered, id)
+			continue
+		}
+		s.undelivered[id] = attempts + 1
+	}
+}
+
+// formatClock 9h30m → 09:30
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,122 @@
+-- Базовая схема: сделки, балансы, активы, Grid, PnL, лимиты риска, конфигурация, логи.
+-- IF NOT EXISTS: базы, созданные до версионных миграций, принимают эту миграцию без изменений.
+
+CREATE TABLE IF NOT EXISTS trades (
+	id SERIAL PRIMARY KEY,
+	symbol VARCHAR(20) NOT NULL,
+	side VARCHAR(10) NOT NULL,
+	quantity DECIMAL(20, 8) NOT NULL,
+	price DECIMAL(20, 8) NOT NULL,
+	amount DECIMAL(20, 8) NOT NULL,
+	order_id VARCHAR(100),
+	status VARCHAR(20) NOT NULL,
+	strategy_type VARCHAR(20) DEFAULT 'DCA',
+	grid_level INTEGER DEFAULT 0,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS balances (
+	id SERIAL PRIMARY KEY,
+	symbol VARCHAR(20) NOT NULL UNIQUE,
+	total_quantity DECIMAL(20, 8) NOT NULL DEFAULT 0,
+	available_qty DECIMAL(20, 8) NOT NULL DEFAULT 0,
+	avg_entry_price DECIMAL(20, 8) NOT NULL DEFAULT 0,
+	total_invested DECIMAL(20, 8) NOT NULL DEFAULT 0,
+	total_sold DECIMAL(20, 8) NOT NULL DEFAULT 0,
+	realized_profit DECIMAL(20, 8) NOT NULL DEFAULT 0,
+	unrealized_pnl DECIMAL(20, 8) NOT NULL DEFAULT 0,
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS assets (
+	id SERIAL PRIMARY KEY,
+	symbol VARCHAR(20) NOT NULL UNIQUE,
+	enabled BOOLEAN NOT NULL DEFAULT true,
+	strategy_type VARCHAR(20) NOT NULL DEFAULT 'DCA',
+	allocated_capital DECIMAL(20, 8) NOT NULL DEFAULT 0,
+	max_position_size DECIMAL(20, 8) NOT NULL DEFAULT 0,
+	dca_amount DECIMAL(20, 8) NOT NULL DEFAULT 10,
+	dca_interval_minutes INTEGER NOT NULL DEFAULT 1440,
+	auto_sell_enabled BOOLEAN NOT NULL DEFAULT false,
+	auto_sell_trigger_percent DECIMAL(10, 2) NOT NULL DEFAULT 10,
+	auto_sell_amount_percent DECIMAL(10, 2) NOT NULL DEFAULT 50,
+	grid_levels INTEGER DEFAULT 0,
+	grid_spacing_percent DECIMAL(10, 2) DEFAULT 0,
+	grid_order_size DECIMAL(20, 8) DEFAULT 0,
+	stop_loss_percent DECIMAL(10, 2) DEFAULT 0,
+	take_profit_percent DECIMAL(10, 2) DEFAULT 0,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS grid_orders (
+	id SERIAL PRIMARY KEY,
+	symbol VARCHAR(20) NOT NULL,
+	level INTEGER NOT NULL,
+	side VARCHAR(10) NOT NULL,
+	price DECIMAL(20, 8) NOT NULL,
+	quantity DECIMAL(20, 8) NOT NULL,
+	order_id VARCHAR(100),
+	status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+	filled_qty DECIMAL(20, 8) NOT NULL DEFAULT 0,
+	filled_price DECIMAL(20, 8) NOT NULL DEFAULT 0,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS pnl_history (
+	id SERIAL PRIMARY KEY,
+	symbol VARCHAR(20) NOT NULL,
+	realized_pnl DECIMAL(20, 8) NOT NULL,
+	unrealized_pnl DECIMAL(20, 8) NOT NULL,
+	total_pnl DECIMAL(20, 8) NOT NULL,
+	total_invested DECIMAL(20, 8) NOT NULL,
+	current_value DECIMAL(20, 8) NOT NULL,
+	return_percent DECIMAL(10, 2) NOT NULL,
+	snapshot_type VARCHAR(20) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS risk_limits (
+	id SERIAL PRIMARY KEY,
+	max_daily_loss DECIMAL(20, 8) NOT NULL DEFAULT 0,
+	max_total_exposure DECIMAL(20, 8) NOT NULL DEFAULT 0,
+	max_position_size_usd DECIMAL(20, 8) NOT NULL DEFAULT 0,
+	max_order_size_usd DECIMAL(20, 8) NOT NULL DEFAULT 0,
+	enable_emergency_stop BOOLEAN NOT NULL DEFAULT false,
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS config_params (
+	id SERIAL PRIMARY KEY,
+	key VARCHAR(100) NOT NULL UNIQUE,
+	value TEXT NOT NULL,
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS logs (
+	id SERIAL PRIMARY KEY,
+	level VARCHAR(10) NOT NULL,
+	message TEXT NOT NULL,
+	data TEXT,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_trades_symbol ON trades(symbol);
+CREATE INDEX IF NOT EXISTS idx_trades_created_at ON trades(created_at);
+CREATE INDEX IF NOT EXISTS idx_trades_strategy ON trades(strategy_type);
+CREATE INDEX IF NOT EXISTS idx_grid_orders_symbol ON grid_orders(symbol);
+CREATE INDEX IF NOT EXISTS idx_grid_orders_status ON grid_orders(status);
+CREATE INDEX IF NOT EXISTS idx_pnl_history_symbol ON pnl_history(symbol);
+CREATE INDEX IF NOT EXISTS idx_pnl_history_created_at ON pnl_history(created_at);
+CREATE INDEX IF NOT EXISTS idx_logs_created_at ON logs(created_at);
+
+-- Колонки, добавленные в таблицы самых первых версий
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS strategy_type VARCHAR(20) DEFAULT 'DCA';
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS grid_level INTEGER DEFAULT 0;
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS unrealized_pnl DECIMAL(20, 8) DEFAULT 0;
+
+-- Лимиты риска по умолчанию
+INSERT INTO risk_limits (max_daily_loss, max_total_exposure, max_position_size_usd, max_order_size_usd, enable_emergency_stop, updated_at)
+SELECT 1000, 10000, 5000, 500, false, NOW()
+WHERE NOT EXISTS (SELECT 1 FROM risk_limits LIMIT 1);
@@ -0,0 +1,74 @@
+-- Stage 4: решения AI, действия, новости, circuit breaker, нарушения политики
+
+CREATE TABLE IF NOT EXISTS ai_decisions (
+	id BIGSERIAL PRIMARY KEY,
+	timestamp TIMESTAMPTZ DEFAULT NOW(),
+	regime VARCHAR(50) NOT NULL,
+	confidence NUMERIC(3,2),
+	rationale TEXT,
+	raw_response JSONB,
+	approved BOOLEAN DEFAULT false,
+	rejection_reason TEXT,
+	mode VARCHAR(20) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ai_actions (
+	id BIGSERIAL PRIMARY KEY,
+	decision_id BIGINT REFERENCES ai_decisions(id),
+	action_type VARCHAR(50) NOT NULL,
+	symbol VARCHAR(20),
+	parameters JSONB NOT NULL,
+	status VARCHAR(20) DEFAULT 'pending',
+	risk_score NUMERIC(3,2),
+	policy_check_result JSONB,
+	executed_at TIMESTAMPTZ,
+	error_message TEXT,
+	created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS news_signals (
+	id BIGSERIAL PRIMARY KEY,
+	timestamp TIMESTAMPTZ DEFAULT NOW(),
+	source VARCHAR(100),
+	headline TEXT,
+	url TEXT,
+	sentiment VARCHAR(20),
+	sentiment_score NUMERIC(3,2),
+	topics TEXT[],
+	signal VARCHAR(10),
+	symbols TEXT[],
+	processed BOOLEAN DEFAULT false
+);
+
+CREATE TABLE IF NOT EXISTS circuit_breaker_events (
+	id BIGSERIAL PRIMARY KEY,
+	triggered_at TIMESTAMPTZ DEFAULT NOW(),
+	reason VARCHAR(100),
+	details JSONB,
+	paused_until TIMESTAMPTZ,
+	resumed_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS policy_violations (
+	id BIGSERIAL PRIMARY KEY,
+	timestamp TIMESTAMPTZ DEFAULT NOW(),
+	action_id BIGINT REFERENCES ai_actions(id),
+	violation_type VARCHAR(50),
+	limit_name VARCHAR(50),
+	limit_value NUMERIC,
+	attempted_value NUMERIC,
+	severity VARCHAR(20)
+);
+
+ALTER TABLE pnl_history ADD COLUMN IF NOT EXISTS regime VARCHAR(50);
+ALTER TABLE pnl_history ADD COLUMN IF NOT EXISTS auto_mode BOOLEAN DEFAULT false;
+ALTER TABLE pnl_history ADD COLUMN IF NOT EXISTS risk_score NUMERIC(3,2);
+
+CREATE INDEX IF NOT EXISTS idx_ai_decisions_timestamp ON ai_decisions(timestamp);
+CREATE INDEX IF NOT EXISTS idx_ai_decisions_mode ON ai_decisions(mode);
+CREATE INDEX IF NOT EXISTS idx_ai_actions_decision_id ON ai_actions(decision_id);
+CREATE INDEX IF NOT EXISTS idx_ai_actions_status ON ai_actions(status);
+CREATE INDEX IF NOT EXISTS idx_news_signals_timestamp ON news_signals(timestamp);
+CREATE INDEX IF NOT EXISTS idx_news_signals_processed ON news_signals(processed);
+CREATE INDEX IF NOT EXISTS idx_circuit_breaker_triggered_at ON circuit_breaker_events(triggered_at);
+CREATE INDEX IF NOT EXISTS idx_policy_violations_timestamp ON policy_violations(timestamp);
@@ -0,0 +1,3 @@
+ALTER TABLE ai_decisions DROP COLUMN IF EXISTS model;
+ALTER TABLE ai_decisions DROP COLUMN IF EXISTS provider;
+ALTER TABLE ai_decisions DROP COLUMN IF EXISTS prompt_version;
@@ -0,0 +1,4 @@
+-- Происхождение решения: версия промпта и модель
+ALTER TABLE ai_decisions ADD COLUMN IF NOT EXISTS prompt_version VARCHAR(50);
+ALTER TABLE ai_decisions ADD COLUMN IF NOT EXISTS provider VARCHAR(50);
+ALTER TABLE ai_decisions ADD COLUMN IF NOT EXISTS model VARCHAR(100);
@@ -0,0 +1,28 @@
+-- Отчеты по портфелю (одна запись на период - защита от повторной отправки)
+CREATE TABLE IF NOT EXISTS portfolio_reports (
+	id BIGSERIAL PRIMARY KEY,
+	period VARCHAR(10) NOT NULL,
+	period_start TIMESTAMPTZ NOT NULL,
+	period_end TIMESTAMPTZ NOT NULL,
+	content TEXT NOT NULL,
+	narrative TEXT,
+	delivered BOOLEAN DEFAULT false,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	UNIQUE (period, period_start)
+);
+
+-- Инциденты: контекст сбоя и разбор локальной моделью
+CREATE TABLE IF NOT EXISTS incidents (
+	id BIGSERIAL PRIMARY KEY,
+	kind VARCHAR(30) NOT NULL,
+	source VARCHAR(50),
+	symbol VARCHAR(20),
+	error TEXT NOT NULL,
+	context TEXT,
+	root_cause TEXT,
+	suggested_fix TEXT,
+	model VARCHAR(100),
+	created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_incidents_created_at ON incidents(created_at);
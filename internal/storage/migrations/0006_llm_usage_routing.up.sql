@@ -0,0 +1,35 @@
+-- Учет вызовов LLM: токены, стоимость, fallback
+CREATE TABLE IF NOT EXISTS llm_usage (
+	id BIGSERIAL PRIMARY KEY,
+	timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	provider VARCHAR(50) NOT NULL,
+	model VARCHAR(100) NOT NULL,
+	purpose VARCHAR(30) NOT NULL,
+	prompt_tokens INTEGER DEFAULT 0,
+	completion_tokens INTEGER DEFAULT 0,
+	cost_usd DECIMAL(12, 6) DEFAULT 0,
+	latency_ms BIGINT DEFAULT 0,
+	cloud BOOLEAN DEFAULT false,
+	success BOOLEAN DEFAULT true,
+	reduced_trust BOOLEAN DEFAULT false,
+	error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_usage_timestamp ON llm_usage(timestamp);
+
+-- Результаты маршрутизации AgentRouter (для настройки классификатора)
+CREATE TABLE IF NOT EXISTS routing_outcomes (
+	id BIGSERIAL PRIMARY KEY,
+	timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	message TEXT NOT NULL,
+	intent VARCHAR(20) NOT NULL,
+	agent VARCHAR(20) NOT NULL,
+	confidence DECIMAL(4, 3) DEFAULT 0,
+	method VARCHAR(20) NOT NULL,
+	fallback BOOLEAN DEFAULT false,
+	success BOOLEAN DEFAULT true,
+	error TEXT,
+	latency_ms BIGINT DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_routing_outcomes_timestamp ON routing_outcomes(timestamp);
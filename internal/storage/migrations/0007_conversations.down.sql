@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS conversation_state;
+DROP TABLE IF EXISTS conversation_messages;
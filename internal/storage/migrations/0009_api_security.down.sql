@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS api_audit_log;
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS require_signature;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS signing_secret;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS rate_limit;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS allowed_ips;
@@ -0,0 +1,24 @@
+-- Ограничения API ключей: IP allowlist, rate limit, подпись запросов
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS allowed_ips TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS rate_limit INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS signing_secret VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS require_signature BOOLEAN NOT NULL DEFAULT false;
+
+-- Аудит изменяющих вызовов HTTP API
+CREATE TABLE IF NOT EXISTS api_audit_log (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	key_id BIGINT NOT NULL DEFAULT 0,
+	telegram_id BIGINT NOT NULL DEFAULT 0,
+	role VARCHAR(20) NOT NULL DEFAULT '',
+	remote_ip VARCHAR(64) NOT NULL DEFAULT '',
+	method VARCHAR(10) NOT NULL,
+	path VARCHAR(255) NOT NULL,
+	params TEXT NOT NULL DEFAULT '',
+	status INTEGER NOT NULL,
+	result TEXT NOT NULL DEFAULT '',
+	duration_ms BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_audit_created ON api_audit_log(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_api_audit_key ON api_audit_log(key_id, created_at DESC);
@@ -0,0 +1,2 @@
+ALTER TABLE ai_decisions DROP COLUMN IF EXISTS trace_id;
+ALTER TABLE trades DROP COLUMN IF EXISTS trace_id;
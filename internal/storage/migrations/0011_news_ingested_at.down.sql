@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_news_signals_url;
+DROP INDEX IF EXISTS idx_news_signals_ingested_at;
+ALTER TABLE news_signals DROP COLUMN IF EXISTS ingested_at;
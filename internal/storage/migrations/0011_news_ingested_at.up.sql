@@ -0,0 +1,5 @@
+-- Время загрузки новости: окно дедупликации считается от него, а не от даты публикации
+ALTER TABLE news_signals ADD COLUMN IF NOT EXISTS ingested_at TIMESTAMPTZ;
+UPDATE news_signals SET ingested_at = COALESCE(timestamp, NOW()) WHERE ingested_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_news_signals_ingested_at ON news_signals(ingested_at);
+CREATE INDEX IF NOT EXISTS idx_news_signals_url ON news_signals(url);
@@ -0,0 +1,334 @@
+// Package migrations версионные миграции схемы PostgreSQL и SQLite.
+//
+// Миграции лежат рядом в файлах NNNN_name.up.sql / NNNN_name.down.sql (SQLite - в sqlite/ с теми же
+// версиями) и встраиваются в бинарник.
+// Примененные версии хранятся в schema_migrations вместе с контрольной суммой up-файла:
+// изменение уже примененной миграции останавливает Up, новые изменения схемы - только новым файлом.
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed *.sql sqlite/*.sql
+var files embed.FS
+
+// Dialect набор миграций и способ блокировки конкретной СУБД
+type Dialect struct {
+	Name string
+	dir  string
+	// timeType тип колонки applied_at; драйверы SQLite разбирают время только у колонок TIMESTAMP/DATETIME
+	timeType string
+	// lock выполняется первым в транзакции миграции: несколько экземпляров бота не применяют миграции одновременно
+	lock string
+}
+
+var (
+	// Postgres блокировка - pg_advisory_xact_lock
+	Postgres = Dialect{Name: "postgres", dir: ".", timeType: "TIMESTAMPTZ", lock: `SELECT pg_advisory_xact_lock(7305212048)`}
+	// SQLite пишущий запрос сразу берет блокировку записи файла базы
+	SQLite = Dialect{Name: "sqlite", dir: "sqlite", timeType: "TIMESTAMP", lock: `DELETE FROM schema_migrations WHERE version < 0`}
+)
+
+// Migrations встроенные миграции диалекта по возрастанию версии
+func (d Dialect) Migrations() ([]Migration, error) {
+	fsys, err := fs.Sub(files, d.dir)
+	if err != nil {
+		return nil, err
+	}
+	return Load(fsys)
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration одна версия схемы
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status состояние миграции в базе
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// All встроенные миграции Postgres по возрастанию версии
+func All() ([]Migration, error) {
+	return Postgres.Migrations()
+}
+
+// Load читает миграции из fsys. Каждой версии нужны оба файла, версии идут подряд с 1
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		m := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || m == nil {
+			if path.Ext(entry.Name()) == ".sql" {
+				return nil, fmt.Errorf("invalid migration file name %q (expected NNNN_name.up.sql)", entry.Name())
+			}
+			continue
+		}
+
+		version, _ := strconv.Atoi(m[1])
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		} else if migration.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, m[2])
+		}
+		if m[3] == "up" {
+			migration.Up = string(data)
+			sum := sha256.Sum256(data)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both .up.sql and .down.sql", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must be sequential: expected %d, got %d", i+1, m.Version)
+		}
+	}
+	return migrations, nil
+}
+
+// Migrator применяет и откатывает миграции. Каждая миграция выполняется в своей транзакции
+// вместе с записью в schema_migrations
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// New мигратор встроенных миграций Postgres
+func New(db *sql.DB) (*Migrator, error) {
+	return NewForDialect(db, Postgres)
+}
+
+// NewForDialect мигратор встроенных миграций диалекта
+func NewForDialect(db *sql.DB, dialect Dialect) (*Migrator, error) {
+	migrations, err := dialect.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// NewWithMigrations мигратор произвольного набора для Postgres (для тестов)
+func NewWithMigrations(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, dialect: Postgres, migrations: migrations}
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		checksum VARCHAR(64) NOT NULL,
+		applied_at `+m.dialect.timeType+` NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// lock берет транзакционную блокировку и читает примененные версии под ней
+func (m *Migrator) lock(ctx context.Context) (*sql.Tx, map[int]appliedMigration, error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := tx.ExecContext(ctx, m.dialect.lock); err != nil {
+		tx.Rollback()
+		return nil, nil, fmt.Errorf("failed to lock migrations: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT version, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.checksum, &a.appliedAt); err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+		applied[version] = a
+	}
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	return tx, applied, nil
+}
+
+// verify проверяет, что примененные миграции совпадают с файлами и схема не новее бинарника
+func (m *Migrator) verify(applied map[int]appliedMigration) error {
+	known := make(map[int]bool, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		if a, ok := applied[migration.Version]; ok && a.checksum != migration.Checksum {
+			return fmt.Errorf("migration %04d_%s was modified after it was applied (checksum mismatch)", migration.Version, migration.Name)
+		}
+	}
+	for version := range applied {
+		if !known[version] {
+			return fmt.Errorf("database has migration %d unknown to this build - schema is newer than the binary", version)
+		}
+	}
+	return nil
+}
+
+// Up применяет все ожидающие миграции. Возвращает примененные
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for {
+		migration, err := m.step(ctx, true)
+		if err != nil || migration == nil {
+			return done, err
+		}
+		done = append(done, *migration)
+	}
+}
+
+// Down откатывает последние steps миграций. Возвращает откаченные
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := 0; i < steps; i++ {
+		migration, err := m.step(ctx, false)
+		if err != nil || migration == nil {
+			return done, err
+		}
+		done = append(done, *migration)
+	}
+	return done, nil
+}
+
+// step применяет следующую (up) или откатывает последнюю (down) миграцию. nil - делать нечего
+func (m *Migrator) step(ctx context.Context, up bool) (*Migration, error) {
+	tx, applied, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := m.verify(applied); err != nil {
+		return nil, err
+	}
+
+	var target *Migration
+	if up {
+		for i := range m.migrations {
+			if _, ok := applied[m.migrations[i].Version]; !ok {
+				target = &m.migrations[i]
+				break
+			}
+		}
+	} else {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				target = &m.migrations[i]
+				break
+			}
+		}
+	}
+	if target == nil {
+		return nil, nil
+	}
+
+	if up {
+		if _, err := tx.ExecContext(ctx, target.Up); err != nil {
+			return nil, fmt.Errorf("migration %04d_%s failed: %w", target.Version, target.Name, err)
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+			target.Version, target.Name, target.Checksum)
+	} else {
+		if _, err := tx.ExecContext(ctx, target.Down); err != nil {
+			return nil, fmt.Errorf("rollback of %04d_%s failed: %w", target.Version, target.Name, err)
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, target.Version)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// Status все миграции с отметкой о применении
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	tx, applied, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		a, ok := applied[migration.Version]
+		statuses = append(statuses, Status{Migration: migration, Applied: ok, AppliedAt: a.appliedAt})
+	}
+	return statuses, m.verify(applied)
+}
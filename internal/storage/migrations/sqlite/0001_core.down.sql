@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS logs;
+DROP TABLE IF EXISTS config_params;
+DROP TABLE IF EXISTS risk_limits;
+DROP TABLE IF EXISTS pnl_history;
+DROP TABLE IF EXISTS grid_orders;
+DROP TABLE IF EXISTS assets;
+DROP TABLE IF EXISTS balances;
+DROP TABLE IF EXISTS trades;
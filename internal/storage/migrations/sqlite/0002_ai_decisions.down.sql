@@ -0,0 +1,9 @@
+ALTER TABLE pnl_history DROP COLUMN risk_score;
+ALTER TABLE pnl_history DROP COLUMN auto_mode;
+ALTER TABLE pnl_history DROP COLUMN regime;
+
+DROP TABLE IF EXISTS policy_violations;
+DROP TABLE IF EXISTS circuit_breaker_events;
+DROP TABLE IF EXISTS news_signals;
+DROP TABLE IF EXISTS ai_actions;
+DROP TABLE IF EXISTS ai_decisions;
@@ -0,0 +1,74 @@
+-- Stage 4: решения AI, действия, новости, circuit breaker, нарушения политики
+
+CREATE TABLE IF NOT EXISTS ai_decisions (
+	id INTEGER PRIMARY KEY,
+	timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	regime VARCHAR(50) NOT NULL,
+	confidence NUMERIC(3,2),
+	rationale TEXT,
+	raw_response TEXT,
+	approved BOOLEAN DEFAULT false,
+	rejection_reason TEXT,
+	mode VARCHAR(20) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ai_actions (
+	id INTEGER PRIMARY KEY,
+	decision_id BIGINT REFERENCES ai_decisions(id),
+	action_type VARCHAR(50) NOT NULL,
+	symbol VARCHAR(20),
+	parameters TEXT NOT NULL,
+	status VARCHAR(20) DEFAULT 'pending',
+	risk_score NUMERIC(3,2),
+	policy_check_result TEXT,
+	executed_at TIMESTAMP,
+	error_message TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS news_signals (
+	id INTEGER PRIMARY KEY,
+	timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	source VARCHAR(100),
+	headline TEXT,
+	url TEXT,
+	sentiment VARCHAR(20),
+	sentiment_score NUMERIC(3,2),
+	topics TEXT, -- литерал массива Postgres {a,b} (pq.Array)
+	signal VARCHAR(10),
+	symbols TEXT,
+	processed BOOLEAN DEFAULT false
+);
+
+CREATE TABLE IF NOT EXISTS circuit_breaker_events (
+	id INTEGER PRIMARY KEY,
+	triggered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	reason VARCHAR(100),
+	details TEXT,
+	paused_until TIMESTAMP,
+	resumed_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS policy_violations (
+	id INTEGER PRIMARY KEY,
+	timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	action_id BIGINT REFERENCES ai_actions(id),
+	violation_type VARCHAR(50),
+	limit_name VARCHAR(50),
+	limit_value NUMERIC,
+	attempted_value NUMERIC,
+	severity VARCHAR(20)
+);
+
+ALTER TABLE pnl_history ADD COLUMN regime VARCHAR(50);
+ALTER TABLE pnl_history ADD COLUMN auto_mode BOOLEAN DEFAULT false;
+ALTER TABLE pnl_history ADD COLUMN risk_score NUMERIC(3,2);
+
+CREATE INDEX IF NOT EXISTS idx_ai_decisions_timestamp ON ai_decisions(timestamp);
+CREATE INDEX IF NOT EXISTS idx_ai_decisions_mode ON ai_decisions(mode);
+CREATE INDEX IF NOT EXISTS idx_ai_actions_decision_id ON ai_actions(decision_id);
+CREATE INDEX IF NOT EXISTS idx_ai_actions_status ON ai_actions(status);
+CREATE INDEX IF NOT EXISTS idx_news_signals_timestamp ON news_signals(timestamp);
+CREATE INDEX IF NOT EXISTS idx_news_signals_processed ON news_signals(processed);
+CREATE INDEX IF NOT EXISTS idx_circuit_breaker_triggered_at ON circuit_breaker_events(triggered_at);
+CREATE INDEX IF NOT EXISTS idx_policy_violations_timestamp ON policy_violations(timestamp);
@@ -0,0 +1,3 @@
+ALTER TABLE ai_decisions DROP COLUMN model;
+ALTER TABLE ai_decisions DROP COLUMN provider;
+ALTER TABLE ai_decisions DROP COLUMN prompt_version;
@@ -0,0 +1,4 @@
+-- Происхождение решения: версия промпта и модель
+ALTER TABLE ai_decisions ADD COLUMN prompt_version VARCHAR(50);
+ALTER TABLE ai_decisions ADD COLUMN provider VARCHAR(50);
+ALTER TABLE ai_decisions ADD COLUMN model VARCHAR(100);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS incidents;
+DROP TABLE IF EXISTS portfolio_reports;
@@ -0,0 +1,17 @@
+-- Market data: свечи по таймфреймам
+CREATE TABLE IF NOT EXISTS candles (
+	id INTEGER PRIMARY KEY,
+	symbol VARCHAR(20) NOT NULL,
+	interval VARCHAR(5) NOT NULL,
+	open_time TIMESTAMP NOT NULL,
+	open DECIMAL(20, 8) NOT NULL,
+	high DECIMAL(20, 8) NOT NULL,
+	low DECIMAL(20, 8) NOT NULL,
+	close DECIMAL(20, 8) NOT NULL,
+	volume DECIMAL(30, 8) DEFAULT 0,
+	turnover DECIMAL(30, 8) DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (symbol, interval, open_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_candles_symbol_interval_time ON candles(symbol, interval, open_time DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS routing_outcomes;
+DROP TABLE IF EXISTS llm_usage;
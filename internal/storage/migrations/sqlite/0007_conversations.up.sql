@@ -0,0 +1,19 @@
+-- Память диалогов AI чата
+CREATE TABLE IF NOT EXISTS conversation_messages (
+	id INTEGER PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	role VARCHAR(20) NOT NULL,
+	content TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversation_messages_user ON conversation_messages(user_id, id);
+
+CREATE TABLE IF NOT EXISTS conversation_state (
+	user_id BIGINT PRIMARY KEY,
+	summary TEXT NOT NULL DEFAULT '',
+	summarized_until BIGINT NOT NULL DEFAULT 0,
+	pending_actions TEXT,
+	pending_at TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,22 @@
+-- RBAC: пользователи Telegram/API с ролями и API ключи
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY,
+	telegram_id BIGINT NOT NULL UNIQUE,
+	name VARCHAR(100) NOT NULL DEFAULT '',
+	role VARCHAR(20) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	id INTEGER PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name VARCHAR(100) NOT NULL DEFAULT '',
+	prefix VARCHAR(16) NOT NULL,
+	key_hash VARCHAR(64) NOT NULL UNIQUE,
+	revoked BOOLEAN NOT NULL DEFAULT false,
+	last_used_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys(user_id);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS api_audit_log;
+
+ALTER TABLE api_keys DROP COLUMN require_signature;
+ALTER TABLE api_keys DROP COLUMN signing_secret;
+ALTER TABLE api_keys DROP COLUMN rate_limit;
+ALTER TABLE api_keys DROP COLUMN allowed_ips;
@@ -0,0 +1,2 @@
+ALTER TABLE ai_decisions DROP COLUMN trace_id;
+ALTER TABLE trades DROP COLUMN trace_id;
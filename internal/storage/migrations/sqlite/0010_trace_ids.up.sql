@@ -0,0 +1,3 @@
+-- Трейсы OpenTelemetry: связь сделки и решения с трейсом запроса
+ALTER TABLE trades ADD COLUMN trace_id VARCHAR(32);
+ALTER TABLE ai_decisions ADD COLUMN trace_id VARCHAR(32);
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -18,7 +19,7 @@ func NewAIActionRepository(db *sql.DB) *AIActionRepository {
 }
 
 // Save сохраняет AI действие
-func (r *AIActionRepository) Save(action *domain.AIAction) error {
+func (r *AIActionRepository) Save(ctx context.Context, action *domain.AIAction) error {
 	if action.CreatedAt.IsZero() {
 		action.CreatedAt = time.Now()
 	}
@@ -31,7 +32,8 @@ func (r *AIActionRepository) Save(action *domain.AIAction) error {
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id
 	`
-	return r.db.QueryRow(
+	return r.db.QueryRowContext(
+		ctx,
 		query,
 		action.DecisionID,
 		action.ActionType,
@@ -47,18 +49,18 @@ func (r *AIActionRepository) Save(action *domain.AIAction) error {
 }
 
 // UpdateStatus обновляет статус действия
-func (r *AIActionRepository) UpdateStatus(id int64, status string, errorMsg string) error {
+func (r *AIActionRepository) UpdateStatus(ctx context.Context, id int64, status string, errorMsg string) error {
 	query := `
 		UPDATE ai_actions
 		SET status = $1, error_message = $2, executed_at = $3
 		WHERE id = $4
 	`
-	_, err := r.db.Exec(query, status, errorMsg, time.Now(), id)
+	_, err := r.db.ExecContext(ctx, query, status, errorMsg, time.Now(), id)
 	return err
 }
 
 // GetByDecisionID получает действия по ID решения
-func (r *AIActionRepository) GetByDecisionID(decisionID int64) ([]domain.AIAction, error) {
+func (r *AIActionRepository) GetByDecisionID(ctx context.Context, decisionID int64) ([]domain.AIAction, error) {
 	query := `
 		SELECT id, decision_id, action_type, symbol, parameters, status,
 		       risk_score, policy_check_result, executed_at, error_message, created_at
@@ -66,7 +68,7 @@ func (r *AIActionRepository) GetByDecisionID(decisionID int64) ([]domain.AIActio
 		WHERE decision_id = $1
 		ORDER BY created_at
 	`
-	rows, err := r.db.Query(query, decisionID)
+	rows, err := r.db.QueryContext(ctx, query, decisionID)
 	if err != nil {
 		return nil, err
 	}
@@ -98,7 +100,7 @@ func (r *AIActionRepository) GetByDecisionID(decisionID int64) ([]domain.AIActio
 }
 
 // GetByStatus получает действия по статусу
-func (r *AIActionRepository) GetByStatus(status string, limit int) ([]domain.AIAction, error) {
+func (r *AIActionRepository) GetByStatus(ctx context.Context, status string, limit int) ([]domain.AIAction, error) {
 	query := `
 		SELECT id, decision_id, action_type, symbol, parameters, status,
 		       risk_score, policy_check_result, executed_at, error_message, created_at
@@ -107,7 +109,7 @@ func (r *AIActionRepository) GetByStatus(status string, limit int) ([]domain.AIA
 		ORDER BY created_at DESC
 		LIMIT $2
 	`
-	rows, err := r.db.Query(query, status, limit)
+	rows, err := r.db.QueryContext(ctx, query, status, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -139,7 +141,7 @@ func (r *AIActionRepository) GetByStatus(status string, limit int) ([]domain.AIA
 }
 
 // GetRecent получает последние N действий
-func (r *AIActionRepository) GetRecent(limit int) ([]domain.AIAction, error) {
+func (r *AIActionRepository) GetRecent(ctx context.Context, limit int) ([]domain.AIAction, error) {
 	query := `
 		SELECT id, decision_id, action_type, symbol, parameters, status,
 		       risk_score, policy_check_result, executed_at, error_message, created_at
@@ -147,7 +149,7 @@ func (r *AIActionRepository) GetRecent(limit int) ([]domain.AIAction, error) {
 		ORDER BY created_at DESC
 		LIMIT $1
 	`
-	rows, err := r.db.Query(query, limit)
+	rows, err := r.db.QueryContext(ctx, query, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -179,15 +181,15 @@ func (r *AIActionRepository) GetRecent(limit int) ([]domain.AIAction, error) {
 }
 
 // GetSuccessRate получает процент успешных действий
-func (r *AIActionRepository) GetSuccessRate(since time.Time) (float64, error) {
+func (r *AIActionRepository) GetSuccessRate(ctx context.Context, since time.Time) (float64, error) {
 	query := `
 		SELECT
-			COUNT(CASE WHEN status = 'executed' THEN 1 END)::float / NULLIF(COUNT(*)::float, 0) * 100 as success_rate
+			COUNT(CASE WHEN status = 'executed' THEN 1 END) * 100.0 / NULLIF(COUNT(*), 0) as success_rate
 		FROM ai_actions
 		WHERE created_at >= $1 AND status IN ('executed', 'failed')
 	`
 	var rate sql.NullFloat64
-	err := r.db.QueryRow(query, since).Scan(&rate)
+	err := r.db.QueryRowContext(ctx, query, since).Scan(&rate)
 	if err != nil {
 		return 0, err
 	}
@@ -196,3 +198,30 @@ func (r *AIActionRepository) GetSuccessRate(since time.Time) (float64, error) {
 	}
 	return rate.Float64, nil
 }
+
+// GetStatusCounts число действий по статусам за период [from, to)
+func (r *AIActionRepository) GetStatusCounts(ctx context.Context, from, to time.Time) (map[string]int, error) {
+	query := `
+		SELECT status, COUNT(*)
+		FROM ai_actions
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY status
+	`
+	rows, err := r.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -18,13 +19,15 @@ func NewAIDecisionRepository(db *sql.DB) *AIDecisionRepository {
 }
 
 // Save сохраняет AI решение
-func (r *AIDecisionRepository) Save(decision *domain.AIDecision) error {
+func (r *AIDecisionRepository) Save(ctx context.Context, decision *domain.AIDecision) error {
 	query := `
-		INSERT INTO ai_decisions (timestamp, regime, confidence, rationale, raw_response, approved, rejection_reason, mode)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO ai_decisions (timestamp, regime, confidence, rationale, raw_response, approved, rejection_reason, mode,
+			prompt_version, provider, model, trace_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''))
 		RETURNING id
 	`
-	return r.db.QueryRow(
+	return r.db.QueryRowContext(
+		ctx,
 		query,
 		decision.Timestamp,
 		decision.Regime,
@@ -34,18 +37,23 @@ func (r *AIDecisionRepository) Save(decision *domain.AIDecision) error {
 		decision.Approved,
 		decision.RejectionReason,
 		decision.Mode,
+		decision.PromptVersion,
+		decision.Provider,
+		decision.Model,
+		decision.TraceID,
 	).Scan(&decision.ID)
 }
 
 // GetRecent получает последние N решений
-func (r *AIDecisionRepository) GetRecent(limit int) ([]domain.AIDecision, error) {
+func (r *AIDecisionRepository) GetRecent(ctx context.Context, limit int) ([]domain.AIDecision, error) {
 	query := `
-		SELECT id, timestamp, regime, confidence, rationale, raw_response, approved, rejection_reason, mode
+		SELECT id, timestamp, regime, confidence, rationale, raw_response, approved, rejection_reason, mode,
+		       COALESCE(prompt_version, ''), COALESCE(provider, ''), COALESCE(model, ''), COALESCE(trace_id, '')
 		FROM ai_decisions
 		ORDER BY timestamp DESC
 		LIMIT $1
 	`
-	rows, err := r.db.Query(query, limit)
+	rows, err := r.db.QueryContext(ctx, query, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -64,6 +72,10 @@ func (r *AIDecisionRepository) GetRecent(limit int) ([]domain.AIDecision, error)
 			&d.Approved,
 			&d.RejectionReason,
 			&d.Mode,
+			&d.PromptVersion,
+			&d.Provider,
+			&d.Model,
+			&d.TraceID,
 		)
 		if err != nil {
 			return nil, err
@@ -75,14 +87,15 @@ func (r *AIDecisionRepository) GetRecent(limit int) ([]domain.AIDecision, error)
 }
 
 // GetByID получает решение по ID
-func (r *AIDecisionRepository) GetByID(id int64) (*domain.AIDecision, error) {
+func (r *AIDecisionRepository) GetByID(ctx context.Context, id int64) (*domain.AIDecision, error) {
 	query := `
-		SELECT id, timestamp, regime, confidence, rationale, raw_response, approved, rejection_reason, mode
+		SELECT id, timestamp, regime, confidence, rationale, raw_response, approved, rejection_reason, mode,
+		       COALESCE(prompt_version, ''), COALESCE(provider, ''), COALESCE(model, ''), COALESCE(trace_id, '')
 		FROM ai_decisions
 		WHERE id = $1
 	`
 	var d domain.AIDecision
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&d.ID,
 		&d.Timestamp,
 		&d.Regime,
@@ -92,6 +105,10 @@ func (r *AIDecisionRepository) GetByID(id int64) (*domain.AIDecision, error) {
 		&d.Approved,
 		&d.RejectionReason,
 		&d.Mode,
+		&d.PromptVersion,
+		&d.Provider,
+		&d.Model,
+		&d.TraceID,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -100,15 +117,16 @@ func (r *AIDecisionRepository) GetByID(id int64) (*domain.AIDecision, error) {
 }
 
 // GetByMode получает решения по режиму
-func (r *AIDecisionRepository) GetByMode(mode string, limit int) ([]domain.AIDecision, error) {
+func (r *AIDecisionRepository) GetByMode(ctx context.Context, mode string, limit int) ([]domain.AIDecision, error) {
 	query := `
-		SELECT id, timestamp, regime, confidence, rationale, raw_response, approved, rejection_reason, mode
+		SELECT id, timestamp, regime, confidence, rationale, raw_response, approved, rejection_reason, mode,
+		       COALESCE(prompt_version, ''), COALESCE(provider, ''), COALESCE(model, ''), COALESCE(trace_id, '')
 		FROM ai_decisions
 		WHERE mode = $1
 		ORDER BY timestamp DESC
 		LIMIT $2
 	`
-	rows, err := r.db.Query(query, mode, limit)
+	rows, err := r.db.QueryContext(ctx, query, mode, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -127,6 +145,10 @@ func (r *AIDecisionRepository) GetByMode(mode string, limit int) ([]domain.AIDec
 			&d.Approved,
 			&d.RejectionReason,
 			&d.Mode,
+			&d.PromptVersion,
+			&d.Provider,
+			&d.Model,
+			&d.TraceID,
 		)
 		if err != nil {
 			return nil, err
@@ -138,14 +160,14 @@ func (r *AIDecisionRepository) GetByMode(mode string, limit int) ([]domain.AIDec
 }
 
 // GetStats получает статистику решений
-func (r *AIDecisionRepository) GetStats(since time.Time) (map[string]int, error) {
+func (r *AIDecisionRepository) GetStats(ctx context.Context, since time.Time) (map[string]int, error) {
 	query := `
 		SELECT regime, COUNT(*) as count
 		FROM ai_decisions
 		WHERE timestamp >= $1
 		GROUP BY regime
 	`
-	rows, err := r.db.Query(query, since)
+	rows, err := r.db.QueryContext(ctx, query, since)
 	if err != nil {
 		return nil, err
 	}
@@ -163,3 +185,73 @@ func (r *AIDecisionRepository) GetStats(since time.Time) (map[string]int, error)
 
 	return stats, rows.Err()
 }
+
+// GetPromptStats статистика решений по версиям промпта и моделям (сравнение A/B)
+func (r *AIDecisionRepository) GetPromptStats(ctx context.Context, since time.Time) ([]domain.PromptVersionStats, error) {
+	query := `
+		SELECT COALESCE(prompt_version, ''), COALESCE(model, ''), COUNT(*),
+		       COUNT(*) FILTER (WHERE approved), COALESCE(AVG(confidence), 0)
+		FROM ai_decisions
+		WHERE timestamp >= $1
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []domain.PromptVersionStats
+	for rows.Next() {
+		var s domain.PromptVersionStats
+		if err := rows.Scan(&s.PromptVersion, &s.Model, &s.Decisions, &s.Approved, &s.AvgConfidence); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
+
+// GetBetween получает решения за период [from, to)
+func (r *AIDecisionRepository) GetBetween(ctx context.Context, from, to time.Time) ([]domain.AIDecision, error) {
+	query := `
+		SELECT id, timestamp, regime, confidence, rationale, raw_response, approved, rejection_reason, mode,
+		       COALESCE(prompt_version, ''), COALESCE(provider, ''), COALESCE(model, ''), COALESCE(trace_id, '')
+		FROM ai_decisions
+		WHERE timestamp >= $1 AND timestamp < $2
+		ORDER BY timestamp
+	`
+	rows, err := r.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var decisions []domain.AIDecision
+	for rows.Next() {
+		var d domain.AIDecision
+		err := rows.Scan(
+			&d.ID,
+			&d.Timestamp,
+			&d.Regime,
+			&d.Confidence,
+			&d.Rationale,
+			&d.RawResponse,
+			&d.Approved,
+			&d.RejectionReason,
+			&d.Mode,
+			&d.PromptVersion,
+			&d.Provider,
+			&d.Model,
+			&d.TraceID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		decisions = append(decisions, d)
+	}
+
+	return decisions, rows.Err()
+}
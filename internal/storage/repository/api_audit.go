@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// APIAuditRepository журнал изменяющих вызовов HTTP API
+type APIAuditRepository struct {
+	db *sql.DB
+}
+
+// NewAPIAuditRepository создает новый репозиторий
+func NewAPIAuditRepository(db *sql.DB) *APIAuditRepository {
+	return &APIAuditRepository{db: db}
+}
+
+// Save сохраняет запись аудита
+func (r *APIAuditRepository) Save(ctx context.Context, entry *domain.APIAuditEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO api_audit_log (created_at, key_id, telegram_id, role, remote_ip, method, path, params, status, result, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		entry.CreatedAt,
+		entry.KeyID,
+		entry.TelegramID,
+		entry.Role,
+		entry.RemoteIP,
+		entry.Method,
+		entry.Path,
+		entry.Params,
+		entry.Status,
+		entry.Result,
+		entry.DurationMs,
+	).Scan(&entry.ID)
+}
+
+// GetRecent получает последние N записей
+func (r *APIAuditRepository) GetRecent(ctx context.Context, limit int) ([]domain.APIAuditEntry, error) {
+	query := `
+		SELECT id, created_at, key_id, telegram_id, role, remote_ip, method, path, params, status, result, duration_ms
+		FROM api_audit_log
+		ORDER BY created_at DESC
+		LIMIT $1
+	`
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.APIAuditEntry
+	for rows.Next() {
+		var e domain.APIAuditEntry
+		err := rows.Scan(
+			&e.ID,
+			&e.CreatedAt,
+			&e.KeyID,
+			&e.TelegramID,
+			&e.Role,
+			&e.RemoteIP,
+			&e.Method,
+			&e.Path,
+			&e.Params,
+			&e.Status,
+			&e.Result,
+			&e.DurationMs,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
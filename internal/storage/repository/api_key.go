@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/lib/pq"
+)
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, revoked, last_used_at, created_at,
+		       allowed_ips, rate_limit, signing_secret, require_signature`
+
+// APIKeyRepository управляет ключами HTTP API
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepository создает новый репозиторий
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Create сохраняет ключ (только хеш)
+func (r *APIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, created_at,
+		                      allowed_ips, rate_limit, signing_secret, require_signature)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+	return r.db.QueryRowContext(ctx, query, key.UserID, key.Name, key.Prefix, key.KeyHash, key.CreatedAt,
+		pq.Array(nonNil(key.AllowedIPs)), key.RateLimit, key.SigningSecret, key.RequireSignature).Scan(&key.ID)
+}
+
+// GetByHash находит действующий ключ по хешу (nil, если нет или отозван)
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	return r.getOne(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1 AND NOT revoked`, hash)
+}
+
+// GetByID получает ключ по ID (nil, если нет)
+func (r *APIKeyRepository) GetByID(ctx context.Context, id int64) (*domain.APIKey, error) {
+	return r.getOne(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, id)
+}
+
+// GetByUser получает ключи пользователя
+func (r *APIKeyRepository) GetByUser(ctx context.Context, userID int64) ([]domain.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = $1 ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []domain.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+
+	return keys, rows.Err()
+}
+
+// UpdateSettings сохраняет ограничения ключа: IP, лимит запросов, обязательность подписи
+func (r *APIKeyRepository) UpdateSettings(ctx context.Context, key *domain.APIKey) error {
+	query := `
+		UPDATE api_keys SET allowed_ips = $1, rate_limit = $2, require_signature = $3
+		WHERE id = $4
+	`
+	_, err := r.db.ExecContext(ctx, query, pq.Array(nonNil(key.AllowedIPs)), key.RateLimit, key.RequireSignature, key.ID)
+	return err
+}
+
+func (r *APIKeyRepository) getOne(ctx context.Context, query string, arg interface{}) (*domain.APIKey, error) {
+	k, err := scanAPIKey(r.db.QueryRowContext(ctx, query, arg))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return k, err
+}
+
+// scanAPIKey читает строку с колонками apiKeyColumns
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (*domain.APIKey, error) {
+	var k domain.APIKey
+	err := row.Scan(
+		&k.ID,
+		&k.UserID,
+		&k.Name,
+		&k.Prefix,
+		&k.KeyHash,
+		&k.Revoked,
+		&k.LastUsedAt,
+		&k.CreatedAt,
+		pq.Array(&k.AllowedIPs),
+		&k.RateLimit,
+		&k.SigningSecret,
+		&k.RequireSignature,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// nonNil пустой список вместо nil, чтобы в TEXT[] попал '{}', а не NULL
+func nonNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
+
+// Revoke отзывает ключ
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE api_keys SET revoked = true WHERE id = $1`, id)
+	return err
+}
+
+// Touch отмечает использование ключа
+func (r *APIKeyRepository) Touch(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`, time.Now(), id)
+	return err
+}
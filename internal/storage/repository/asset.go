@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -9,16 +10,16 @@ import (
 
 // AssetRepository реализует работу с активами
 type AssetRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewAssetRepository создает новый репозиторий для активов
-func NewAssetRepository(db *sql.DB) *AssetRepository {
+func NewAssetRepository(db DBTX) *AssetRepository {
 	return &AssetRepository{db: db}
 }
 
 // CreateOrUpdate создает или обновляет актив
-func (r *AssetRepository) CreateOrUpdate(asset *domain.Asset) error {
+func (r *AssetRepository) CreateOrUpdate(ctx context.Context, asset *domain.Asset) error {
 	asset.UpdatedAt = time.Now()
 	if asset.CreatedAt.IsZero() {
 		asset.CreatedAt = time.Now()
@@ -50,7 +51,8 @@ func (r *AssetRepository) CreateOrUpdate(asset *domain.Asset) error {
 			updated_at = EXCLUDED.updated_at
 		RETURNING id
 	`
-	return r.db.QueryRow(
+	return r.db.QueryRowContext(
+		ctx,
 		query,
 		asset.Symbol,
 		asset.Enabled,
@@ -73,7 +75,7 @@ func (r *AssetRepository) CreateOrUpdate(asset *domain.Asset) error {
 }
 
 // Get получает актив по символу
-func (r *AssetRepository) Get(symbol string) (*domain.Asset, error) {
+func (r *AssetRepository) Get(ctx context.Context, symbol string) (*domain.Asset, error) {
 	asset := &domain.Asset{}
 	query := `
 		SELECT id, symbol, enabled, strategy_type, allocated_capital, max_position_size,
@@ -83,7 +85,7 @@ func (r *AssetRepository) Get(symbol string) (*domain.Asset, error) {
 		       stop_loss_percent, take_profit_percent, created_at, updated_at
 		FROM assets WHERE symbol = $1
 	`
-	err := r.db.QueryRow(query, symbol).Scan(
+	err := r.db.QueryRowContext(ctx, query, symbol).Scan(
 		&asset.ID,
 		&asset.Symbol,
 		&asset.Enabled,
@@ -112,7 +114,7 @@ func (r *AssetRepository) Get(symbol string) (*domain.Asset, error) {
 }
 
 // GetEnabled получает все активные активы
-func (r *AssetRepository) GetEnabled() ([]domain.Asset, error) {
+func (r *AssetRepository) GetEnabled(ctx context.Context) ([]domain.Asset, error) {
 	query := `
 		SELECT id, symbol, enabled, strategy_type, allocated_capital, max_position_size,
 		       dca_amount, dca_interval_minutes, auto_sell_enabled,
@@ -122,11 +124,11 @@ func (r *AssetRepository) GetEnabled() ([]domain.Asset, error) {
 		FROM assets WHERE enabled = true
 		ORDER BY symbol
 	`
-	return r.queryAssets(query)
+	return r.queryAssets(ctx, query)
 }
 
 // GetAll получает все активы
-func (r *AssetRepository) GetAll() ([]domain.Asset, error) {
+func (r *AssetRepository) GetAll(ctx context.Context) ([]domain.Asset, error) {
 	query := `
 		SELECT id, symbol, enabled, strategy_type, allocated_capital, max_position_size,
 		       dca_amount, dca_interval_minutes, auto_sell_enabled,
@@ -136,26 +138,36 @@ func (r *AssetRepository) GetAll() ([]domain.Asset, error) {
 		FROM assets
 		ORDER BY symbol
 	`
-	return r.queryAssets(query)
+	return r.queryAssets(ctx, query)
 }
 
 // Disable отключает актив
-func (r *AssetRepository) Disable(symbol string) error {
+func (r *AssetRepository) Disable(ctx context.Context, symbol string) error {
 	query := `UPDATE assets SET enabled = false, updated_at = $1 WHERE symbol = $2`
-	_, err := r.db.Exec(query, time.Now(), symbol)
+	_, err := r.db.ExecContext(ctx, query, time.Now(), symbol)
 	return err
 }
 
 // Enable включает актив
-func (r *AssetRepository) Enable(symbol string) error {
+func (r *AssetRepository) Enable(ctx context.Context, symbol string) error {
 	query := `UPDATE assets SET enabled = true, updated_at = $1 WHERE symbol = $2`
-	_, err := r.db.Exec(query, time.Now(), symbol)
+	_, err := r.db.ExecContext(ctx, query, time.Now(), symbol)
 	return err
 }
 
+// Delete удаляет актив, возвращает false если его не было
+func (r *AssetRepository) Delete(ctx context.Context, symbol string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM assets WHERE symbol = $1`, symbol)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
 // queryAssets выполняет запрос и возвращает список активов
-func (r *AssetRepository) queryAssets(query string, args ...interface{}) ([]domain.Asset, error) {
-	rows, err := r.db.Query(query, args...)
+func (r *AssetRepository) queryAssets(ctx context.Context, query string, args ...interface{}) ([]domain.Asset, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
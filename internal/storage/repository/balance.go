@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -9,16 +10,16 @@ import (
 
 // BalanceRepository реализует работу с балансами
 type BalanceRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewBalanceRepository создает новый репозиторий для балансов
-func NewBalanceRepository(db *sql.DB) *BalanceRepository {
+func NewBalanceRepository(db DBTX) *BalanceRepository {
 	return &BalanceRepository{db: db}
 }
 
 // Get получает баланс для символа
-func (r *BalanceRepository) Get(symbol string) (*domain.Balance, error) {
+func (r *BalanceRepository) Get(ctx context.Context, symbol string) (*domain.Balance, error) {
 	balance := &domain.Balance{}
 	query := `
 		SELECT id, symbol, total_quantity, available_qty, avg_entry_price,
@@ -26,7 +27,7 @@ func (r *BalanceRepository) Get(symbol string) (*domain.Balance, error) {
 		       COALESCE(unrealized_pnl, 0), updated_at
 		FROM balances WHERE symbol = $1
 	`
-	err := r.db.QueryRow(query, symbol).Scan(
+	err := r.db.QueryRowContext(ctx, query, symbol).Scan(
 		&balance.ID,
 		&balance.Symbol,
 		&balance.TotalQuantity,
@@ -61,8 +62,45 @@ func (r *BalanceRepository) Get(symbol string) (*domain.Balance, error) {
 	return balance, nil
 }
 
+// GetForUpdate получает баланс и блокирует строку до конца транзакции: параллельные сделки
+// по символу не перезапишут изменения друг друга. Пустая строка создается, если ее еще нет
+func (r *BalanceRepository) GetForUpdate(ctx context.Context, symbol string) (*domain.Balance, error) {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO balances (symbol, updated_at) VALUES ($1, $2)
+		ON CONFLICT (symbol) DO NOTHING
+	`, symbol, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &domain.Balance{}
+	query := `
+		SELECT id, symbol, total_quantity, available_qty, avg_entry_price,
+		       total_invested, total_sold, realized_profit,
+		       COALESCE(unrealized_pnl, 0), updated_at
+		FROM balances WHERE symbol = $1
+		FOR UPDATE
+	`
+	err = r.db.QueryRowContext(ctx, query, symbol).Scan(
+		&balance.ID,
+		&balance.Symbol,
+		&balance.TotalQuantity,
+		&balance.AvailableQty,
+		&balance.AvgEntryPrice,
+		&balance.TotalInvested,
+		&balance.TotalSold,
+		&balance.RealizedProfit,
+		&balance.UnrealizedPnL,
+		&balance.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
 // GetAll получает все балансы с позициями
-func (r *BalanceRepository) GetAll() ([]domain.Balance, error) {
+func (r *BalanceRepository) GetAll(ctx context.Context) ([]domain.Balance, error) {
 	query := `
 		SELECT id, symbol, total_quantity, available_qty, avg_entry_price,
 		       total_invested, total_sold, realized_profit,
@@ -71,7 +109,7 @@ func (r *BalanceRepository) GetAll() ([]domain.Balance, error) {
 		WHERE total_quantity > 0 OR total_invested > 0
 		ORDER BY symbol
 	`
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -102,7 +140,7 @@ func (r *BalanceRepository) GetAll() ([]domain.Balance, error) {
 }
 
 // Update обновляет или создает баланс
-func (r *BalanceRepository) Update(balance *domain.Balance) error {
+func (r *BalanceRepository) Update(ctx context.Context, balance *domain.Balance) error {
 	balance.UpdatedAt = time.Now()
 	query := `
 		INSERT INTO balances (symbol, total_quantity, available_qty, avg_entry_price,
@@ -118,7 +156,8 @@ func (r *BalanceRepository) Update(balance *domain.Balance) error {
 			unrealized_pnl = EXCLUDED.unrealized_pnl,
 			updated_at = EXCLUDED.updated_at
 	`
-	_, err := r.db.Exec(
+	_, err := r.db.ExecContext(
+		ctx,
 		query,
 		balance.Symbol,
 		balance.TotalQuantity,
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// CandleRepository реализует работу со свечами (klines)
+type CandleRepository struct {
+	db DBTX
+}
+
+// NewCandleRepository создает новый репозиторий для свечей
+func NewCandleRepository(db DBTX) *CandleRepository {
+	return &CandleRepository{db: db}
+}
+
+// SaveBatch сохраняет свечи, обновляя уже существующие (незакрытая свеча меняется)
+func (r *CandleRepository) SaveBatch(ctx context.Context, candles []domain.Candle) error {
+	if len(candles) == 0 {
+		return nil
+	}
+
+	return inTx(ctx, r.db, func(db DBTX) error {
+		stmt, err := db.PrepareContext(ctx, `
+			INSERT INTO candles (symbol, interval, open_time, open, high, low, close, volume, turnover, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (symbol, interval, open_time) DO UPDATE SET
+				open = EXCLUDED.open,
+				high = EXCLUDED.high,
+				low = EXCLUDED.low,
+				close = EXCLUDED.close,
+				volume = EXCLUDED.volume,
+				turnover = EXCLUDED.turnover
+		`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		now := time.Now()
+		for _, c := range candles {
+			if _, err := stmt.ExecContext(
+				ctx,
+				c.Symbol,
+				c.Interval,
+				c.OpenTime,
+				c.Open,
+				c.High,
+				c.Low,
+				c.Close,
+				c.Volume,
+				c.Turnover,
+				now,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetRecent получает последние N свечей (от старых к новым)
+func (r *CandleRepository) GetRecent(ctx context.Context, symbol, interval string, limit int) ([]domain.Candle, error) {
+	query := `
+		SELECT id, symbol, interval, open_time, open, high, low, close, volume, turnover, created_at
+		FROM (
+			SELECT * FROM candles
+			WHERE symbol = $1 AND interval = $2
+			ORDER BY open_time DESC
+			LIMIT $3
+		) recent
+		ORDER BY open_time ASC
+	`
+	return r.query(ctx, query, symbol, interval, limit)
+}
+
+// GetRange получает свечи за период (от старых к новым)
+func (r *CandleRepository) GetRange(ctx context.Context, symbol, interval string, from, to time.Time) ([]domain.Candle, error) {
+	query := `
+		SELECT id, symbol, interval, open_time, open, high, low, close, volume, turnover, created_at
+		FROM candles
+		WHERE symbol = $1 AND interval = $2 AND open_time >= $3 AND open_time <= $4
+		ORDER BY open_time ASC
+	`
+	return r.query(ctx, query, symbol, interval, from, to)
+}
+
+// DeleteOlderThan удаляет свечи старше указанного времени
+func (r *CandleRepository) DeleteOlderThan(ctx context.Context, interval string, before time.Time) (int64, error) {
+	query := `DELETE FROM candles WHERE interval = $1 AND open_time < $2`
+	result, err := r.db.ExecContext(ctx, query, interval, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// query выполняет запрос и возвращает список свечей
+func (r *CandleRepository) query(ctx context.Context, query string, args ...interface{}) ([]domain.Candle, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candles []domain.Candle
+	for rows.Next() {
+		var c domain.Candle
+		err := rows.Scan(
+			&c.ID,
+			&c.Symbol,
+			&c.Interval,
+			&c.OpenTime,
+			&c.Open,
+			&c.High,
+			&c.Low,
+			&c.Close,
+			&c.Volume,
+			&c.Turnover,
+			&c.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, c)
+	}
+
+	return candles, rows.Err()
+}
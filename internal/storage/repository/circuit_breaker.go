@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -18,82 +19,89 @@ func NewCircuitBreakerRepository(db *sql.DB) *CircuitBreakerRepository {
 }
 
 // SaveEvent сохраняет событие триггера
-func (r *CircuitBreakerRepository) SaveEvent(event *domain.CircuitBreakerEvent) error {
+func (r *CircuitBreakerRepository) SaveEvent(ctx context.Context, event *domain.CircuitBreakerEvent) error {
 	if event.TriggeredAt.IsZero() {
 		event.TriggeredAt = time.Now()
 	}
 
+	// Нулевое время - событие еще активно (NULL), иначе GetActive его не найдет
+	var resumedAt interface{}
+	if !event.ResumedAt.IsZero() {
+		resumedAt = event.ResumedAt
+	}
+
 	query := `
 		INSERT INTO circuit_breaker_events (triggered_at, reason, details, paused_until, resumed_at)
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id
 	`
-	return r.db.QueryRow(
+	return r.db.QueryRowContext(
+		ctx,
 		query,
 		event.TriggeredAt,
 		event.Reason,
 		event.Details,
 		event.PausedUntil,
-		event.ResumedAt,
+		resumedAt,
 	).Scan(&event.ID)
 }
 
 // GetActive получает активные события (не resumed)
-func (r *CircuitBreakerRepository) GetActive() ([]domain.CircuitBreakerEvent, error) {
+func (r *CircuitBreakerRepository) GetActive(ctx context.Context) ([]domain.CircuitBreakerEvent, error) {
 	query := `
 		SELECT id, triggered_at, reason, details, paused_until, resumed_at
 		FROM circuit_breaker_events
-		WHERE resumed_at IS NULL AND paused_until > NOW()
+		WHERE resumed_at IS NULL AND paused_until > $1
 		ORDER BY triggered_at DESC
 	`
-	return r.query(query)
+	return r.query(ctx, query, time.Now())
 }
 
 // GetRecent получает последние N событий
-func (r *CircuitBreakerRepository) GetRecent(limit int) ([]domain.CircuitBreakerEvent, error) {
+func (r *CircuitBreakerRepository) GetRecent(ctx context.Context, limit int) ([]domain.CircuitBreakerEvent, error) {
 	query := `
 		SELECT id, triggered_at, reason, details, paused_until, resumed_at
 		FROM circuit_breaker_events
 		ORDER BY triggered_at DESC
 		LIMIT $1
 	`
-	return r.query(query, limit)
+	return r.query(ctx, query, limit)
 }
 
 // Resume возобновляет circuit breaker
-func (r *CircuitBreakerRepository) Resume(id int64) error {
+func (r *CircuitBreakerRepository) Resume(ctx context.Context, id int64) error {
 	query := `
 		UPDATE circuit_breaker_events
 		SET resumed_at = $1
 		WHERE id = $2
 	`
-	_, err := r.db.Exec(query, time.Now(), id)
+	_, err := r.db.ExecContext(ctx, query, time.Now(), id)
 	return err
 }
 
 // IsAnyActive проверяет есть ли активные circuit breakers
-func (r *CircuitBreakerRepository) IsAnyActive() (bool, error) {
+func (r *CircuitBreakerRepository) IsAnyActive(ctx context.Context) (bool, error) {
 	query := `
 		SELECT EXISTS(
 			SELECT 1
 			FROM circuit_breaker_events
-			WHERE resumed_at IS NULL AND paused_until > NOW()
+			WHERE resumed_at IS NULL AND paused_until > $1
 		)
 	`
 	var exists bool
-	err := r.db.QueryRow(query).Scan(&exists)
+	err := r.db.QueryRowContext(ctx, query, time.Now()).Scan(&exists)
 	return exists, err
 }
 
 // GetStatsByReason получает статистику по причинам
-func (r *CircuitBreakerRepository) GetStatsByReason(since time.Time) (map[string]int, error) {
+func (r *CircuitBreakerRepository) GetStatsByReason(ctx context.Context, since time.Time) (map[string]int, error) {
 	query := `
 		SELECT reason, COUNT(*) as count
 		FROM circuit_breaker_events
 		WHERE triggered_at >= $1
 		GROUP BY reason
 	`
-	rows, err := r.db.Query(query, since)
+	rows, err := r.db.QueryContext(ctx, query, since)
 	if err != nil {
 		return nil, err
 	}
@@ -113,8 +121,8 @@ func (r *CircuitBreakerRepository) GetStatsByReason(since time.Time) (map[string
 }
 
 // query helper
-func (r *CircuitBreakerRepository) query(query string, args ...interface{}) ([]domain.CircuitBreakerEvent, error) {
-	rows, err := r.db.Query(query, args...)
+func (r *CircuitBreakerRepository) query(ctx context.Context, query string, args ...interface{}) ([]domain.CircuitBreakerEvent, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -143,3 +151,14 @@ func (r *CircuitBreakerRepository) query(query string, args ...interface{}) ([]d
 
 	return events, rows.Err()
 }
+
+// GetBetween получает события, сработавшие за период [from, to)
+func (r *CircuitBreakerRepository) GetBetween(ctx context.Context, from, to time.Time) ([]domain.CircuitBreakerEvent, error) {
+	query := `
+		SELECT id, triggered_at, reason, details, paused_until, resumed_at
+		FROM circuit_breaker_events
+		WHERE triggered_at >= $1 AND triggered_at < $2
+		ORDER BY triggered_at
+	`
+	return r.query(ctx, query, from, to)
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// ConversationRepository хранит историю диалогов с AI и состояние пользователя
+type ConversationRepository struct {
+	db *sql.DB
+}
+
+// NewConversationRepository создает новый репозиторий
+func NewConversationRepository(db *sql.DB) *ConversationRepository {
+	return &ConversationRepository{db: db}
+}
+
+// AddMessage сохраняет сообщение диалога
+func (r *ConversationRepository) AddMessage(ctx context.Context, msg *domain.ConversationMessage) error {
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO conversation_messages (user_id, role, content, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	return r.db.QueryRowContext(ctx, query, msg.UserID, msg.Role, msg.Content, msg.CreatedAt).Scan(&msg.ID)
+}
+
+// GetMessagesAfter возвращает сообщения пользователя с ID больше afterID (по возрастанию)
+func (r *ConversationRepository) GetMessagesAfter(ctx context.Context, userID, afterID int64) ([]domain.ConversationMessage, error) {
+	query := `
+		SELECT id, user_id, role, content, created_at
+		FROM conversation_messages
+		WHERE user_id = $1 AND id > $2
+		ORDER BY id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, afterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []domain.ConversationMessage
+	for rows.Next() {
+		var m domain.ConversationMessage
+		if err := rows.Scan(&m.ID, &m.UserID, &m.Role, &m.Content, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+
+	return messages, rows.Err()
+}
+
+// GetState возвращает состояние диалога пользователя (nil, если его нет)
+func (r *ConversationRepository) GetState(ctx context.Context, userID int64) (*domain.ConversationState, error) {
+	query := `
+		SELECT user_id, summary, summarized_until, COALESCE(pending_actions, ''),
+		       pending_at, updated_at
+		FROM conversation_state
+		WHERE user_id = $1
+	`
+	var s domain.ConversationState
+	var pendingAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&s.UserID,
+		&s.Summary,
+		&s.SummarizedUntil,
+		&s.PendingActions,
+		&pendingAt,
+		&s.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.PendingAt = pendingAt.Time
+	return &s, nil
+}
+
+// SaveState создает или обновляет состояние диалога
+func (r *ConversationRepository) SaveState(ctx context.Context, state *domain.ConversationState) error {
+	state.UpdatedAt = time.Now()
+
+	var pendingAt interface{}
+	if !state.PendingAt.IsZero() {
+		pendingAt = state.PendingAt
+	}
+
+	query := `
+		INSERT INTO conversation_state (user_id, summary, summarized_until, pending_actions, pending_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET
+			summary = EXCLUDED.summary,
+			summarized_until = EXCLUDED.summarized_until,
+			pending_actions = EXCLUDED.pending_actions,
+			pending_at = EXCLUDED.pending_at,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		state.UserID,
+		state.Summary,
+		state.SummarizedUntil,
+		state.PendingActions,
+		pendingAt,
+		state.UpdatedAt,
+	)
+	return err
+}
+
+// DeleteUser удаляет историю и состояние пользователя (/forget)
+func (r *ConversationRepository) DeleteUser(ctx context.Context, userID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM conversation_messages WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM conversation_state WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteSummarized удаляет сообщения, уже вошедшие в summary
+func (r *ConversationRepository) DeleteSummarized(ctx context.Context, userID, untilID int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM conversation_messages WHERE user_id = $1 AND id <= $2`, userID, untilID)
+	return err
+}
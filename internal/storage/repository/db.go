@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// DBTX общий интерфейс *sql.DB и *sql.Tx: один и тот же репозиторий работает
+// и с пулом соединений, и внутри транзакции
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// inTx выполняет fn в транзакции. Если db уже транзакция - в ней же (без вложенных транзакций)
+func inTx(ctx context.Context, db DBTX, fn func(DBTX) error) error {
+	conn, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+	return WithinTx(ctx, conn, fn)
+}
+
+// WithinTx выполняет fn в новой транзакции: commit при успехе, rollback при ошибке или панике
+func WithinTx(ctx context.Context, db *sql.DB, fn func(DBTX) error) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Set репозитории домена поверх одного соединения или одной транзакции (domain.Repositories)
+type Set struct {
+	trades     *TradeRepository
+	balances   *BalanceRepository
+	assets     *AssetRepository
+	gridOrders *GridOrderRepository
+	pnl        *PnLRepository
+	risk       *RiskRepository
+	config     *ConfigRepository
+	logs       *LogRepository
+	candles    *CandleRepository
+}
+
+// NewSet создает набор репозиториев поверх db
+func NewSet(db DBTX) *Set {
+	return &Set{
+		trades:     NewTradeRepository(db),
+		balances:   NewBalanceRepository(db),
+		assets:     NewAssetRepository(db),
+		gridOrders: NewGridOrderRepository(db),
+		pnl:        NewPnLRepository(db),
+		risk:       NewRiskRepository(db),
+		config:     NewConfigRepository(db),
+		logs:       NewLogRepository(db),
+		candles:    NewCandleRepository(db),
+	}
+}
+
+func (s *Set) Trades() domain.TradeRepository         { return s.trades }
+func (s *Set) Balances() domain.BalanceRepository     { return s.balances }
+func (s *Set) Assets() domain.AssetRepository         { return s.assets }
+func (s *Set) GridOrders() domain.GridOrderRepository { return s.gridOrders }
+func (s *Set) PnL() domain.PnLRepository              { return s.pnl }
+func (s *Set) Risk() domain.RiskRepository            { return s.risk }
+func (s *Set) Config() domain.ConfigRepository        { return s.config }
+func (s *Set) Logs() domain.LogRepository             { return s.logs }
+func (s *Set) Candles() domain.CandleRepository       { return s.candles }
@@ -1,7 +1,7 @@
 package repository
 
 import (
-	"database/sql"
+	"context"
 	"time"
 
 	"github.com/kirillm/dca-bot/internal/domain"
@@ -9,16 +9,16 @@ import (
 
 // GridOrderRepository реализует работу с Grid ордерами
 type GridOrderRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewGridOrderRepository создает новый репозиторий для Grid ордеров
-func NewGridOrderRepository(db *sql.DB) *GridOrderRepository {
+func NewGridOrderRepository(db DBTX) *GridOrderRepository {
 	return &GridOrderRepository{db: db}
 }
 
 // Save сохраняет новый Grid ордер
-func (r *GridOrderRepository) Save(order *domain.GridOrder) error {
+func (r *GridOrderRepository) Save(ctx context.Context, order *domain.GridOrder) error {
 	order.UpdatedAt = time.Now()
 	if order.CreatedAt.IsZero() {
 		order.CreatedAt = time.Now()
@@ -29,7 +29,8 @@ func (r *GridOrderRepository) Save(order *domain.GridOrder) error {
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING id
 	`
-	return r.db.QueryRow(
+	return r.db.QueryRowContext(
+		ctx,
 		query,
 		order.Symbol,
 		order.Level,
@@ -46,14 +47,15 @@ func (r *GridOrderRepository) Save(order *domain.GridOrder) error {
 }
 
 // Update обновляет существующий Grid ордер
-func (r *GridOrderRepository) Update(order *domain.GridOrder) error {
+func (r *GridOrderRepository) Update(ctx context.Context, order *domain.GridOrder) error {
 	order.UpdatedAt = time.Now()
 	query := `
 		UPDATE grid_orders
 		SET status = $1, filled_qty = $2, filled_price = $3, order_id = $4, updated_at = $5
 		WHERE id = $6
 	`
-	_, err := r.db.Exec(
+	_, err := r.db.ExecContext(
+		ctx,
 		query,
 		order.Status,
 		order.FilledQty,
@@ -66,14 +68,14 @@ func (r *GridOrderRepository) Update(order *domain.GridOrder) error {
 }
 
 // GetActive получает активные Grid ордера для символа
-func (r *GridOrderRepository) GetActive(symbol string) ([]domain.GridOrder, error) {
+func (r *GridOrderRepository) GetActive(ctx context.Context, symbol string) ([]domain.GridOrder, error) {
 	query := `
 		SELECT id, symbol, level, side, price, quantity, order_id, status, filled_qty, filled_price, created_at, updated_at
 		FROM grid_orders
 		WHERE symbol = $1 AND status IN ('PENDING', 'PLACED')
 		ORDER BY level
 	`
-	rows, err := r.db.Query(query, symbol)
+	rows, err := r.db.QueryContext(ctx, query, symbol)
 	if err != nil {
 		return nil, err
 	}
@@ -106,12 +108,12 @@ func (r *GridOrderRepository) GetActive(symbol string) ([]domain.GridOrder, erro
 }
 
 // CancelAll отменяет все Grid ордера для символа
-func (r *GridOrderRepository) CancelAll(symbol string) error {
+func (r *GridOrderRepository) CancelAll(ctx context.Context, symbol string) error {
 	query := `
 		UPDATE grid_orders
 		SET status = 'CANCELLED', updated_at = $1
 		WHERE symbol = $2 AND status IN ('PENDING', 'PLACED')
 	`
-	_, err := r.db.Exec(query, time.Now(), symbol)
+	_, err := r.db.ExecContext(ctx, query, time.Now(), symbol)
 	return err
 }
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// IncidentRepository управляет разобранными инцидентами
+type IncidentRepository struct {
+	db *sql.DB
+}
+
+// NewIncidentRepository создает новый репозиторий
+func NewIncidentRepository(db *sql.DB) *IncidentRepository {
+	return &IncidentRepository{db: db}
+}
+
+// Save сохраняет инцидент
+func (r *IncidentRepository) Save(ctx context.Context, incident *domain.Incident) error {
+	if incident.CreatedAt.IsZero() {
+		incident.CreatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO incidents (kind, source, symbol, error, context, root_cause, suggested_fix, model, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		incident.Kind,
+		incident.Source,
+		incident.Symbol,
+		incident.Error,
+		incident.Context,
+		incident.RootCause,
+		incident.SuggestedFix,
+		incident.Model,
+		incident.CreatedAt,
+	).Scan(&incident.ID)
+}
+
+// GetRecent получает последние N инцидентов
+func (r *IncidentRepository) GetRecent(ctx context.Context, limit int) ([]domain.Incident, error) {
+	query := `
+		SELECT id, kind, COALESCE(source, ''), COALESCE(symbol, ''), error, COALESCE(context, ''),
+		       COALESCE(root_cause, ''), COALESCE(suggested_fix, ''), COALESCE(model, ''), created_at
+		FROM incidents
+		ORDER BY created_at DESC
+		LIMIT $1
+	`
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incidents []domain.Incident
+	for rows.Next() {
+		var inc domain.Incident
+		err := rows.Scan(
+			&inc.ID,
+			&inc.Kind,
+			&inc.Source,
+			&inc.Symbol,
+			&inc.Error,
+			&inc.Context,
+			&inc.RootCause,
+			&inc.SuggestedFix,
+			&inc.Model,
+			&inc.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		incidents = append(incidents, inc)
+	}
+
+	return incidents, rows.Err()
+}
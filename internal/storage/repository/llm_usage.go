@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// LLMUsageRepository учет токенов и стоимости вызовов LLM
+type LLMUsageRepository struct {
+	db *sql.DB
+}
+
+// NewLLMUsageRepository создает новый репозиторий
+func NewLLMUsageRepository(db *sql.DB) *LLMUsageRepository {
+	return &LLMUsageRepository{db: db}
+}
+
+// Save сохраняет запись о вызове
+func (r *LLMUsageRepository) Save(ctx context.Context, usage *domain.LLMUsage) error {
+	if usage.Timestamp.IsZero() {
+		usage.Timestamp = time.Now()
+	}
+
+	query := `
+		INSERT INTO llm_usage (
+			timestamp, provider, model, purpose, prompt_tokens, completion_tokens,
+			cost_usd, latency_ms, cloud, success, reduced_trust, error
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id
+	`
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		usage.Timestamp,
+		usage.Provider,
+		usage.Model,
+		usage.Purpose,
+		usage.PromptTokens,
+		usage.CompletionTokens,
+		usage.CostUSD,
+		usage.LatencyMs,
+		usage.Cloud,
+		usage.Success,
+		usage.ReducedTrust,
+		usage.Error,
+	).Scan(&usage.ID)
+}
+
+// GetCostSince возвращает суммарную стоимость облачных вызовов с указанного момента
+func (r *LLMUsageRepository) GetCostSince(ctx context.Context, since time.Time) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(cost_usd), 0)
+		FROM llm_usage
+		WHERE cloud = true AND timestamp >= $1
+	`
+	var total float64
+	err := r.db.QueryRowContext(ctx, query, since).Scan(&total)
+	return total, err
+}
+
+// GetRecent возвращает последние вызовы
+func (r *LLMUsageRepository) GetRecent(ctx context.Context, limit int) ([]domain.LLMUsage, error) {
+	query := `
+		SELECT id, timestamp, provider, model, purpose, prompt_tokens, completion_tokens,
+		       cost_usd, latency_ms, cloud, success, reduced_trust, COALESCE(error, '')
+		FROM llm_usage
+		ORDER BY timestamp DESC
+		LIMIT $1
+	`
+	return r.query(ctx, query, limit)
+}
+
+// query выполняет запрос и сканирует результаты
+func (r *LLMUsageRepository) query(ctx context.Context, query string, args ...interface{}) ([]domain.LLMUsage, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usages []domain.LLMUsage
+	for rows.Next() {
+		var u domain.LLMUsage
+		err := rows.Scan(
+			&u.ID,
+			&u.Timestamp,
+			&u.Provider,
+			&u.Model,
+			&u.Purpose,
+			&u.PromptTokens,
+			&u.CompletionTokens,
+			&u.CostUSD,
+			&u.LatencyMs,
+			&u.Cloud,
+			&u.Success,
+			&u.ReducedTrust,
+			&u.Error,
+		)
+		if err != nil {
+			return nil, err
+		}
+		usages = append(usages, u)
+	}
+
+	return usages, rows.Err()
+}
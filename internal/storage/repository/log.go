@@ -1,23 +1,32 @@
 package repository
 
 import (
-	"database/sql"
+	"context"
 	"time"
 )
 
 // LogRepository реализует работу с логами
 type LogRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewLogRepository создает новый репозиторий для логов
-func NewLogRepository(db *sql.DB) *LogRepository {
+func NewLogRepository(db DBTX) *LogRepository {
 	return &LogRepository{db: db}
 }
 
 // Save сохраняет лог
-func (r *LogRepository) Save(level, message, data string) error {
+func (r *LogRepository) Save(ctx context.Context, level, message, data string) error {
 	query := `INSERT INTO logs (level, message, data, created_at) VALUES ($1, $2, $3, $4)`
-	_, err := r.db.Exec(query, level, message, data, time.Now())
+	_, err := r.db.ExecContext(ctx, query, level, message, data, time.Now())
 	return err
 }
+
+// DeleteBefore удаляет логи старше before (retention)
+func (r *LogRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM logs WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
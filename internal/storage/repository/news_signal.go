@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
@@ -19,20 +22,24 @@ func NewNewsSignalRepository(db *sql.DB) *NewsSignalRepository {
 }
 
 // Save сохраняет новостной сигнал
-func (r *NewsSignalRepository) Save(signal *domain.NewsSignal) error {
+func (r *NewsSignalRepository) Save(ctx context.Context, signal *domain.NewsSignal) error {
 	if signal.Timestamp.IsZero() {
 		signal.Timestamp = time.Now()
 	}
+	if signal.IngestedAt.IsZero() {
+		signal.IngestedAt = time.Now()
+	}
 
 	query := `
 		INSERT INTO news_signals (
 			timestamp, source, headline, url, sentiment, sentiment_score,
-			topics, signal, symbols, processed
+			topics, signal, symbols, processed, ingested_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING id
 	`
-	return r.db.QueryRow(
+	return r.db.QueryRowContext(
+		ctx,
 		query,
 		signal.Timestamp,
 		signal.Source,
@@ -44,11 +51,12 @@ func (r *NewsSignalRepository) Save(signal *domain.NewsSignal) error {
 		signal.Signal,
 		pq.Array(signal.Symbols),
 		signal.Processed,
+		signal.IngestedAt,
 	).Scan(&signal.ID)
 }
 
 // GetUnprocessed получает необработанные сигналы
-func (r *NewsSignalRepository) GetUnprocessed(limit int) ([]domain.NewsSignal, error) {
+func (r *NewsSignalRepository) GetUnprocessed(ctx context.Context, limit int) ([]domain.NewsSignal, error) {
 	query := `
 		SELECT id, timestamp, source, headline, url, sentiment, sentiment_score,
 		       topics, signal, symbols, processed
@@ -57,11 +65,11 @@ func (r *NewsSignalRepository) GetUnprocessed(limit int) ([]domain.NewsSignal, e
 		ORDER BY timestamp DESC
 		LIMIT $1
 	`
-	return r.query(query, limit)
+	return r.query(ctx, query, limit)
 }
 
 // GetRecent получает последние N сигналов
-func (r *NewsSignalRepository) GetRecent(limit int) ([]domain.NewsSignal, error) {
+func (r *NewsSignalRepository) GetRecent(ctx context.Context, limit int) ([]domain.NewsSignal, error) {
 	query := `
 		SELECT id, timestamp, source, headline, url, sentiment, sentiment_score,
 		       topics, signal, symbols, processed
@@ -69,11 +77,11 @@ func (r *NewsSignalRepository) GetRecent(limit int) ([]domain.NewsSignal, error)
 		ORDER BY timestamp DESC
 		LIMIT $1
 	`
-	return r.query(query, limit)
+	return r.query(ctx, query, limit)
 }
 
 // GetBySentiment получает сигналы по сентименту
-func (r *NewsSignalRepository) GetBySentiment(sentiment string, limit int) ([]domain.NewsSignal, error) {
+func (r *NewsSignalRepository) GetBySentiment(ctx context.Context, sentiment string, limit int) ([]domain.NewsSignal, error) {
 	query := `
 		SELECT id, timestamp, source, headline, url, sentiment, sentiment_score,
 		       topics, signal, symbols, processed
@@ -82,25 +90,57 @@ func (r *NewsSignalRepository) GetBySentiment(sentiment string, limit int) ([]do
 		ORDER BY timestamp DESC
 		LIMIT $2
 	`
-	return r.query(query, sentiment, limit)
+	return r.query(ctx, query, sentiment, limit)
 }
 
 // MarkAsProcessed помечает сигнал как обработанный
-func (r *NewsSignalRepository) MarkAsProcessed(id int64) error {
+func (r *NewsSignalRepository) MarkAsProcessed(ctx context.Context, id int64) error {
 	query := `UPDATE news_signals SET processed = true WHERE id = $1`
-	_, err := r.db.Exec(query, id)
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// MarkAsProcessedBatch помечает несколько сигналов как обработанные
+func (r *NewsSignalRepository) MarkAsProcessedBatch(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	// IN со списком параметров вместо = ANY($1): работает и на SQLite
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+	query := `UPDATE news_signals SET processed = true WHERE id IN (` + strings.Join(placeholders, ", ") + `)`
+	_, err := r.db.ExecContext(ctx, query, args...)
 	return err
 }
 
+// ExistsSince проверяет, есть ли уже сигнал с таким URL (за все время) или заголовком,
+// загруженный начиная с since. Дата публикации не учитывается: RSS держит статьи дольше окна
+func (r *NewsSignalRepository) ExistsSince(ctx context.Context, url, headline string, since time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM news_signals
+			WHERE (url <> '' AND url = $1)
+			   OR (ingested_at >= $3 AND LOWER(headline) = LOWER($2))
+		)
+	`
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, url, headline, since).Scan(&exists)
+	return exists, err
+}
+
 // GetSentimentStats получает статистику сентимента
-func (r *NewsSignalRepository) GetSentimentStats(since time.Time) (map[string]int, error) {
+func (r *NewsSignalRepository) GetSentimentStats(ctx context.Context, since time.Time) (map[string]int, error) {
 	query := `
 		SELECT sentiment, COUNT(*) as count
 		FROM news_signals
 		WHERE timestamp >= $1
 		GROUP BY sentiment
 	`
-	rows, err := r.db.Query(query, since)
+	rows, err := r.db.QueryContext(ctx, query, since)
 	if err != nil {
 		return nil, err
 	}
@@ -120,8 +160,8 @@ func (r *NewsSignalRepository) GetSentimentStats(since time.Time) (map[string]in
 }
 
 // query helper для выполнения запросов
-func (r *NewsSignalRepository) query(query string, args ...interface{}) ([]domain.NewsSignal, error) {
-	rows, err := r.db.Query(query, args...)
+func (r *NewsSignalRepository) query(ctx context.Context, query string, args ...interface{}) ([]domain.NewsSignal, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
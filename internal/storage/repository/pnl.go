@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/kirillm/dca-bot/internal/domain"
@@ -9,16 +11,16 @@ import (
 
 // PnLRepository реализует работу с историей PnL
 type PnLRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewPnLRepository создает новый репозиторий для PnL
-func NewPnLRepository(db *sql.DB) *PnLRepository {
+func NewPnLRepository(db DBTX) *PnLRepository {
 	return &PnLRepository{db: db}
 }
 
 // SaveSnapshot сохраняет снимок PnL
-func (r *PnLRepository) SaveSnapshot(pnl *domain.PnLHistory) error {
+func (r *PnLRepository) SaveSnapshot(ctx context.Context, pnl *domain.PnLHistory) error {
 	if pnl.CreatedAt.IsZero() {
 		pnl.CreatedAt = time.Now()
 	}
@@ -28,7 +30,8 @@ func (r *PnLRepository) SaveSnapshot(pnl *domain.PnLHistory) error {
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id
 	`
-	return r.db.QueryRow(
+	return r.db.QueryRowContext(
+		ctx,
 		query,
 		pnl.Symbol,
 		pnl.RealizedPnL,
@@ -43,7 +46,7 @@ func (r *PnLRepository) SaveSnapshot(pnl *domain.PnLHistory) error {
 }
 
 // GetHistory получает историю PnL для символа
-func (r *PnLRepository) GetHistory(symbol string, snapshotType string, limit int) ([]domain.PnLHistory, error) {
+func (r *PnLRepository) GetHistory(ctx context.Context, symbol string, snapshotType string, limit int) ([]domain.PnLHistory, error) {
 	query := `
 		SELECT id, symbol, realized_pnl, unrealized_pnl, total_pnl, total_invested, current_value, return_percent, snapshot_type, created_at
 		FROM pnl_history
@@ -51,7 +54,7 @@ func (r *PnLRepository) GetHistory(symbol string, snapshotType string, limit int
 		ORDER BY created_at DESC
 		LIMIT $3
 	`
-	rows, err := r.db.Query(query, symbol, snapshotType, limit)
+	rows, err := r.db.QueryContext(ctx, query, symbol, snapshotType, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -80,3 +83,156 @@ func (r *PnLRepository) GetHistory(symbol string, snapshotType string, limit int
 
 	return history, rows.Err()
 }
+
+// portfolioBuckets период агрегации снимков портфеля по типу снимка
+var portfolioBuckets = map[string]string{
+	"HOURLY":  "hour",
+	"DAILY":   "day",
+	"WEEKLY":  "week",
+	"MONTHLY": "month",
+}
+
+// periodStart начало периода bucket, содержащего t (как date_trunc в Postgres: неделя с понедельника)
+func periodStart(t time.Time, bucket string) time.Time {
+	year, month, day := t.Date()
+	switch bucket {
+	case "hour":
+		return time.Date(year, month, day, t.Hour(), 0, 0, 0, t.Location())
+	case "week":
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+	case "month":
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// GetPortfolioHistory суммирует снимки всех символов по периодам типа снимка.
+// В каждом периоде берется последний снимок символа; Symbol = "PORTFOLIO".
+// Периоды считаются в Go: date_trunc и DISTINCT ON есть только в Postgres
+func (r *PnLRepository) GetPortfolioHistory(ctx context.Context, snapshotType string, limit int) ([]domain.PnLHistory, error) {
+	bucket, ok := portfolioBuckets[snapshotType]
+	if !ok {
+		return nil, fmt.Errorf("%w: snapshot type %s", domain.ErrInvalidInput, snapshotType)
+	}
+
+	query := `
+		SELECT symbol, realized_pnl, unrealized_pnl, total_pnl, total_invested, current_value, created_at
+		FROM pnl_history
+		WHERE snapshot_type = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, snapshotType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []domain.PnLHistory
+	var current time.Time
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var symbol string
+		var snapshot domain.PnLHistory
+		err := rows.Scan(
+			&symbol,
+			&snapshot.RealizedPnL,
+			&snapshot.UnrealizedPnL,
+			&snapshot.TotalPnL,
+			&snapshot.TotalInvested,
+			&snapshot.CurrentValue,
+			&snapshot.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// Снимки идут от новых к старым: новый период начинается при смене начала периода
+		start := periodStart(snapshot.CreatedAt, bucket)
+		if len(history) == 0 || !start.Equal(current) {
+			if len(history) == limit {
+				break
+			}
+			current = start
+			seen = make(map[string]bool)
+			history = append(history, domain.PnLHistory{Symbol: "PORTFOLIO", SnapshotType: snapshotType, CreatedAt: snapshot.CreatedAt})
+		}
+		if seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+
+		pnl := &history[len(history)-1]
+		pnl.RealizedPnL += snapshot.RealizedPnL
+		pnl.UnrealizedPnL += snapshot.UnrealizedPnL
+		pnl.TotalPnL += snapshot.TotalPnL
+		pnl.TotalInvested += snapshot.TotalInvested
+		pnl.CurrentValue += snapshot.CurrentValue
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range history {
+		if history[i].TotalInvested > 0 {
+			history[i].ReturnPercent = history[i].TotalPnL / history[i].TotalInvested * 100
+		}
+	}
+	return history, nil
+}
+
+// GetLatestBefore последний снимок каждого символа на момент t (любого типа)
+func (r *PnLRepository) GetLatestBefore(ctx context.Context, t time.Time) ([]domain.PnLHistory, error) {
+	query := `
+		SELECT id, symbol, realized_pnl, unrealized_pnl, total_pnl, total_invested, current_value, return_percent, snapshot_type, created_at
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY symbol ORDER BY created_at DESC) AS rn
+			FROM pnl_history
+			WHERE created_at <= $1
+		) latest
+		WHERE rn = 1
+		ORDER BY symbol
+	`
+	rows, err := r.db.QueryContext(ctx, query, t)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []domain.PnLHistory
+	for rows.Next() {
+		var pnl domain.PnLHistory
+		err := rows.Scan(
+			&pnl.ID,
+			&pnl.Symbol,
+			&pnl.RealizedPnL,
+			&pnl.UnrealizedPnL,
+			&pnl.TotalPnL,
+			&pnl.TotalInvested,
+			&pnl.CurrentValue,
+			&pnl.ReturnPercent,
+			&pnl.SnapshotType,
+			&pnl.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, pnl)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// GetLastSnapshotTime время последнего снимка типа (нулевое, если снимков нет)
+func (r *PnLRepository) GetLastSnapshotTime(ctx context.Context, snapshotType string) (time.Time, error) {
+	// ORDER BY вместо MAX: у агрегата в SQLite нет типа колонки, и время приходит строкой
+	query := `SELECT created_at FROM pnl_history WHERE snapshot_type = $1 ORDER BY created_at DESC LIMIT 1`
+
+	var last time.Time
+	err := r.db.QueryRowContext(ctx, query, snapshotType).Scan(&last)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return last, err
+}
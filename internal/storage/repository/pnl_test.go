@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodStart(t *testing.T) {
+	// Четверг, 5 марта 2026, 14:35:20
+	at := time.Date(2026, 3, 5, 14, 35, 20, 0, time.UTC)
+
+	tests := []struct {
+		bucket string
+		want   time.Time
+	}{
+		{"hour", time.Date(2026, 3, 5, 14, 0, 0, 0, time.UTC)},
+		{"day", time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"week", time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{"month", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := periodStart(at, tt.bucket); !got.Equal(tt.want) {
+			t.Errorf("periodStart(%s) = %v, want %v", tt.bucket, got, tt.want)
+		}
+	}
+
+	// Воскресенье относится к неделе, начавшейся в понедельник
+	sunday := time.Date(2026, 3, 8, 23, 0, 0, 0, time.UTC)
+	if got := periodStart(sunday, "week"); !got.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("periodStart(sunday, week) = %v", got)
+	}
+}
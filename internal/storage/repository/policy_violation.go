@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -18,7 +19,7 @@ func NewPolicyViolationRepository(db *sql.DB) *PolicyViolationRepository {
 }
 
 // Save сохраняет нарушение политики
-func (r *PolicyViolationRepository) Save(violation *domain.PolicyViolation) error {
+func (r *PolicyViolationRepository) Save(ctx context.Context, violation *domain.PolicyViolation) error {
 	if violation.Timestamp.IsZero() {
 		violation.Timestamp = time.Now()
 	}
@@ -31,7 +32,8 @@ func (r *PolicyViolationRepository) Save(violation *domain.PolicyViolation) erro
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id
 	`
-	return r.db.QueryRow(
+	return r.db.QueryRowContext(
+		ctx,
 		query,
 		violation.Timestamp,
 		violation.ActionID,
@@ -44,7 +46,7 @@ func (r *PolicyViolationRepository) Save(violation *domain.PolicyViolation) erro
 }
 
 // GetRecent получает последние N нарушений
-func (r *PolicyViolationRepository) GetRecent(limit int) ([]domain.PolicyViolation, error) {
+func (r *PolicyViolationRepository) GetRecent(ctx context.Context, limit int) ([]domain.PolicyViolation, error) {
 	query := `
 		SELECT id, timestamp, action_id, violation_type, limit_name,
 		       limit_value, attempted_value, severity
@@ -52,11 +54,11 @@ func (r *PolicyViolationRepository) GetRecent(limit int) ([]domain.PolicyViolati
 		ORDER BY timestamp DESC
 		LIMIT $1
 	`
-	return r.query(query, limit)
+	return r.query(ctx, query, limit)
 }
 
 // GetByActionID получает нарушения по ID действия
-func (r *PolicyViolationRepository) GetByActionID(actionID int64) ([]domain.PolicyViolation, error) {
+func (r *PolicyViolationRepository) GetByActionID(ctx context.Context, actionID int64) ([]domain.PolicyViolation, error) {
 	query := `
 		SELECT id, timestamp, action_id, violation_type, limit_name,
 		       limit_value, attempted_value, severity
@@ -64,11 +66,11 @@ func (r *PolicyViolationRepository) GetByActionID(actionID int64) ([]domain.Poli
 		WHERE action_id = $1
 		ORDER BY timestamp DESC
 	`
-	return r.query(query, actionID)
+	return r.query(ctx, query, actionID)
 }
 
 // GetBySeverity получает нарушения по severity
-func (r *PolicyViolationRepository) GetBySeverity(severity string, limit int) ([]domain.PolicyViolation, error) {
+func (r *PolicyViolationRepository) GetBySeverity(ctx context.Context, severity string, limit int) ([]domain.PolicyViolation, error) {
 	query := `
 		SELECT id, timestamp, action_id, violation_type, limit_name,
 		       limit_value, attempted_value, severity
@@ -77,30 +79,30 @@ func (r *PolicyViolationRepository) GetBySeverity(severity string, limit int) ([
 		ORDER BY timestamp DESC
 		LIMIT $2
 	`
-	return r.query(query, severity, limit)
+	return r.query(ctx, query, severity, limit)
 }
 
 // GetRecentByHours получает нарушения за последние N часов
-func (r *PolicyViolationRepository) GetRecentByHours(hours int) ([]domain.PolicyViolation, error) {
+func (r *PolicyViolationRepository) GetRecentByHours(ctx context.Context, hours int) ([]domain.PolicyViolation, error) {
 	query := `
 		SELECT id, timestamp, action_id, violation_type, limit_name,
 		       limit_value, attempted_value, severity
 		FROM policy_violations
-		WHERE timestamp >= NOW() - INTERVAL '1 hour' * $1
+		WHERE timestamp >= $1
 		ORDER BY timestamp DESC
 	`
-	return r.query(query, hours)
+	return r.query(ctx, query, time.Now().Add(-time.Duration(hours)*time.Hour))
 }
 
 // GetStatsByType получает статистику по типам нарушений
-func (r *PolicyViolationRepository) GetStatsByType(since time.Time) (map[string]int, error) {
+func (r *PolicyViolationRepository) GetStatsByType(ctx context.Context, since time.Time) (map[string]int, error) {
 	query := `
 		SELECT violation_type, COUNT(*) as count
 		FROM policy_violations
 		WHERE timestamp >= $1
 		GROUP BY violation_type
 	`
-	rows, err := r.db.Query(query, since)
+	rows, err := r.db.QueryContext(ctx, query, since)
 	if err != nil {
 		return nil, err
 	}
@@ -120,20 +122,32 @@ func (r *PolicyViolationRepository) GetStatsByType(since time.Time) (map[string]
 }
 
 // CountCritical считает критические нарушения за период
-func (r *PolicyViolationRepository) CountCritical(since time.Time) (int, error) {
+func (r *PolicyViolationRepository) CountCritical(ctx context.Context, since time.Time) (int, error) {
 	query := `
 		SELECT COUNT(*)
 		FROM policy_violations
 		WHERE severity = 'critical' AND timestamp >= $1
 	`
 	var count int
-	err := r.db.QueryRow(query, since).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, since).Scan(&count)
 	return count, err
 }
 
+// GetBetween получает нарушения за период [from, to)
+func (r *PolicyViolationRepository) GetBetween(ctx context.Context, from, to time.Time) ([]domain.PolicyViolation, error) {
+	query := `
+		SELECT id, timestamp, COALESCE(action_id, 0), violation_type, limit_name,
+		       limit_value, attempted_value, severity
+		FROM policy_violations
+		WHERE timestamp >= $1 AND timestamp < $2
+		ORDER BY timestamp
+	`
+	return r.query(ctx, query, from, to)
+}
+
 // query helper
-func (r *PolicyViolationRepository) query(query string, args ...interface{}) ([]domain.PolicyViolation, error) {
-	rows, err := r.db.Query(query, args...)
+func (r *PolicyViolationRepository) query(ctx context.Context, query string, args ...interface{}) ([]domain.PolicyViolation, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// ReportRepository управляет отчетами по портфелю
+type ReportRepository struct {
+	db *sql.DB
+}
+
+// NewReportRepository создает новый репозиторий
+func NewReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// Save сохраняет отчет; повторный отчет за тот же период перезаписывает предыдущий
+func (r *ReportRepository) Save(ctx context.Context, report *domain.PortfolioReport) error {
+	if report.CreatedAt.IsZero() {
+		report.CreatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO portfolio_reports (period, period_start, period_end, content, narrative, delivered, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (period, period_start) DO UPDATE SET
+			period_end = EXCLUDED.period_end,
+			content = EXCLUDED.content,
+			narrative = EXCLUDED.narrative,
+			delivered = EXCLUDED.delivered,
+			created_at = EXCLUDED.created_at
+		RETURNING id
+	`
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		report.Period,
+		report.PeriodStart,
+		report.PeriodEnd,
+		report.Content,
+		report.Narrative,
+		report.Delivered,
+		report.CreatedAt,
+	).Scan(&report.ID)
+}
+
+// Exists проверяет, сохранен ли уже отчет за период (отправленный или нет)
+func (r *ReportRepository) Exists(ctx context.Context, period string, periodStart time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM portfolio_reports
+			WHERE period = $1 AND period_start = $2
+		)
+	`
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, period, periodStart).Scan(&exists)
+	return exists, err
+}
+
+// MarkDelivered отмечает отчет отправленным
+func (r *ReportRepository) MarkDelivered(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE portfolio_reports SET delivered = $1 WHERE id = $2`, true, id)
+	return err
+}
+
+// GetRecent получает последние N отчетов
+func (r *ReportRepository) GetRecent(ctx context.Context, limit int) ([]domain.PortfolioReport, error) {
+	query := `
+		SELECT id, period, period_start, period_end, content, COALESCE(narrative, ''), delivered, created_at
+		FROM portfolio_reports
+		ORDER BY created_at DESC
+		LIMIT $1
+	`
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []domain.PortfolioReport
+	for rows.Next() {
+		var rep domain.PortfolioReport
+		err := rows.Scan(
+			&rep.ID,
+			&rep.Period,
+			&rep.PeriodStart,
+			&rep.PeriodEnd,
+			&rep.Content,
+			&rep.Narrative,
+			&rep.Delivered,
+			&rep.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, rep)
+	}
+
+	return reports, rows.Err()
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -9,16 +10,16 @@ import (
 
 // RiskRepository реализует работу с лимитами риска
 type RiskRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewRiskRepository создает новый репозиторий для лимитов риска
-func NewRiskRepository(db *sql.DB) *RiskRepository {
+func NewRiskRepository(db DBTX) *RiskRepository {
 	return &RiskRepository{db: db}
 }
 
 // GetLimits получает текущие лимиты риска
-func (r *RiskRepository) GetLimits() (*domain.RiskLimit, error) {
+func (r *RiskRepository) GetLimits(ctx context.Context) (*domain.RiskLimit, error) {
 	limits := &domain.RiskLimit{}
 	query := `
 		SELECT id, max_daily_loss, max_total_exposure, max_position_size_usd, max_order_size_usd, enable_emergency_stop, updated_at
@@ -26,7 +27,7 @@ func (r *RiskRepository) GetLimits() (*domain.RiskLimit, error) {
 		ORDER BY id DESC
 		LIMIT 1
 	`
-	err := r.db.QueryRow(query).Scan(
+	err := r.db.QueryRowContext(ctx, query).Scan(
 		&limits.ID,
 		&limits.MaxDailyLoss,
 		&limits.MaxTotalExposure,
@@ -52,7 +53,7 @@ func (r *RiskRepository) GetLimits() (*domain.RiskLimit, error) {
 }
 
 // UpdateLimits обновляет лимиты риска
-func (r *RiskRepository) UpdateLimits(limits *domain.RiskLimit) error {
+func (r *RiskRepository) UpdateLimits(ctx context.Context, limits *domain.RiskLimit) error {
 	limits.UpdatedAt = time.Now()
 	query := `
 		UPDATE risk_limits
@@ -60,7 +61,8 @@ func (r *RiskRepository) UpdateLimits(limits *domain.RiskLimit) error {
 		    max_order_size_usd = $4, enable_emergency_stop = $5, updated_at = $6
 		WHERE id = (SELECT id FROM risk_limits ORDER BY id DESC LIMIT 1)
 	`
-	_, err := r.db.Exec(
+	_, err := r.db.ExecContext(
+		ctx,
 		query,
 		limits.MaxDailyLoss,
 		limits.MaxTotalExposure,
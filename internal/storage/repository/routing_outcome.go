@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// RoutingOutcomeRepository хранит результаты маршрутизации AgentRouter
+type RoutingOutcomeRepository struct {
+	db *sql.DB
+}
+
+// NewRoutingOutcomeRepository создает новый репозиторий
+func NewRoutingOutcomeRepository(db *sql.DB) *RoutingOutcomeRepository {
+	return &RoutingOutcomeRepository{db: db}
+}
+
+// Save сохраняет результат маршрутизации
+func (r *RoutingOutcomeRepository) Save(ctx context.Context, outcome *domain.RoutingOutcome) error {
+	if outcome.Timestamp.IsZero() {
+		outcome.Timestamp = time.Now()
+	}
+
+	query := `
+		INSERT INTO routing_outcomes (
+			timestamp, message, intent, agent, confidence, method, fallback, success, error, latency_ms
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		outcome.Timestamp,
+		outcome.Message,
+		outcome.Intent,
+		outcome.Agent,
+		outcome.Confidence,
+		outcome.Method,
+		outcome.Fallback,
+		outcome.Success,
+		outcome.Error,
+		outcome.LatencyMs,
+	).Scan(&outcome.ID)
+}
+
+// GetRecent возвращает последние результаты маршрутизации
+func (r *RoutingOutcomeRepository) GetRecent(ctx context.Context, limit int) ([]domain.RoutingOutcome, error) {
+	query := `
+		SELECT id, timestamp, message, intent, agent, confidence, method, fallback, success,
+		       COALESCE(error, ''), latency_ms
+		FROM routing_outcomes
+		ORDER BY timestamp DESC
+		LIMIT $1
+	`
+	return r.query(ctx, query, limit)
+}
+
+// GetLowConfidence возвращает сообщения с fallback для разметки и настройки классификатора
+func (r *RoutingOutcomeRepository) GetLowConfidence(ctx context.Context, since time.Time, limit int) ([]domain.RoutingOutcome, error) {
+	query := `
+		SELECT id, timestamp, message, intent, agent, confidence, method, fallback, success,
+		       COALESCE(error, ''), latency_ms
+		FROM routing_outcomes
+		WHERE fallback = true AND timestamp >= $1
+		ORDER BY timestamp DESC
+		LIMIT $2
+	`
+	return r.query(ctx, query, since, limit)
+}
+
+// query выполняет запрос и сканирует результаты
+func (r *RoutingOutcomeRepository) query(ctx context.Context, query string, args ...interface{}) ([]domain.RoutingOutcome, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var outcomes []domain.RoutingOutcome
+	for rows.Next() {
+		var o domain.RoutingOutcome
+		err := rows.Scan(
+			&o.ID,
+			&o.Timestamp,
+			&o.Message,
+			&o.Intent,
+			&o.Agent,
+			&o.Confidence,
+			&o.Method,
+			&o.Fallback,
+			&o.Success,
+			&o.Error,
+			&o.LatencyMs,
+		)
+		if err != nil {
+			return nil, err
+		}
+		outcomes = append(outcomes, o)
+	}
+
+	return outcomes, rows.Err()
+}
@@ -1,29 +1,33 @@
 package repository
 
 import (
-	"database/sql"
+	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/kirillm/dca-bot/internal/domain"
 )
 
 // TradeRepository реализует работу с торговыми операциями
 type TradeRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewTradeRepository создает новый репозиторий для торговых операций
-func NewTradeRepository(db *sql.DB) *TradeRepository {
+func NewTradeRepository(db DBTX) *TradeRepository {
 	return &TradeRepository{db: db}
 }
 
 // Save сохраняет новую торговую операцию
-func (r *TradeRepository) Save(trade *domain.Trade) error {
+func (r *TradeRepository) Save(ctx context.Context, trade *domain.Trade) error {
 	query := `
-		INSERT INTO trades (symbol, side, quantity, price, amount, order_id, status, strategy_type, grid_level, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO trades (symbol, side, quantity, price, amount, order_id, status, strategy_type, grid_level, created_at, trace_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''))
 		RETURNING id
 	`
-	return r.db.QueryRow(
+	return r.db.QueryRowContext(
+		ctx,
 		query,
 		trade.Symbol,
 		trade.Side,
@@ -35,37 +39,38 @@ func (r *TradeRepository) Save(trade *domain.Trade) error {
 		trade.StrategyType,
 		trade.GridLevel,
 		trade.CreatedAt,
+		trade.TraceID,
 	).Scan(&trade.ID)
 }
 
 // GetRecent получает последние N торговых операций для символа
-func (r *TradeRepository) GetRecent(symbol string, limit int) ([]domain.Trade, error) {
+func (r *TradeRepository) GetRecent(ctx context.Context, symbol string, limit int) ([]domain.Trade, error) {
 	query := `
 		SELECT id, symbol, side, quantity, price, amount, order_id, status,
-		       COALESCE(strategy_type, 'DCA'), COALESCE(grid_level, 0), created_at
+		       COALESCE(strategy_type, 'DCA'), COALESCE(grid_level, 0), created_at, COALESCE(trace_id, '')
 		FROM trades
 		WHERE symbol = $1
 		ORDER BY created_at DESC
 		LIMIT $2
 	`
-	return r.queryTrades(query, symbol, limit)
+	return r.queryTrades(ctx, query, symbol, limit)
 }
 
 // GetAllRecent получает последние N торговых операций по всем символам
-func (r *TradeRepository) GetAllRecent(limit int) ([]domain.Trade, error) {
+func (r *TradeRepository) GetAllRecent(ctx context.Context, limit int) ([]domain.Trade, error) {
 	query := `
 		SELECT id, symbol, side, quantity, price, amount, order_id, status,
-		       COALESCE(strategy_type, 'DCA'), COALESCE(grid_level, 0), created_at
+		       COALESCE(strategy_type, 'DCA'), COALESCE(grid_level, 0), created_at, COALESCE(trace_id, '')
 		FROM trades
 		ORDER BY created_at DESC
 		LIMIT $1
 	`
-	return r.queryTrades(query, limit)
+	return r.queryTrades(ctx, query, limit)
 }
 
 // queryTrades выполняет запрос и возвращает список торговых операций
-func (r *TradeRepository) queryTrades(query string, args ...interface{}) ([]domain.Trade, error) {
-	rows, err := r.db.Query(query, args...)
+func (r *TradeRepository) queryTrades(ctx context.Context, query string, args ...interface{}) ([]domain.Trade, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -86,6 +91,7 @@ func (r *TradeRepository) queryTrades(query string, args ...interface{}) ([]doma
 			&trade.StrategyType,
 			&trade.GridLevel,
 			&trade.CreatedAt,
+			&trade.TraceID,
 		)
 		if err != nil {
 			return nil, err
@@ -95,3 +101,62 @@ func (r *TradeRepository) queryTrades(query string, args ...interface{}) ([]doma
 
 	return trades, rows.Err()
 }
+
+// GetBetween получает торговые операции за период [from, to)
+func (r *TradeRepository) GetBetween(ctx context.Context, from, to time.Time) ([]domain.Trade, error) {
+	query := `
+		SELECT id, symbol, side, quantity, price, amount, order_id, status,
+		       COALESCE(strategy_type, 'DCA'), COALESCE(grid_level, 0), created_at, COALESCE(trace_id, '')
+		FROM trades
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY created_at
+	`
+	return r.queryTrades(ctx, query, from, to)
+}
+
+// Find получает страницу сделок по фильтру и общее число подходящих сделок
+func (r *TradeRepository) Find(ctx context.Context, filter domain.TradeFilter) ([]domain.Trade, int, error) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Symbol != "" {
+		add("symbol = $%d", filter.Symbol)
+	}
+	if filter.Side != "" {
+		add("side = $%d", filter.Side)
+	}
+	if filter.Strategy != "" {
+		add("COALESCE(strategy_type, 'DCA') = $%d", filter.Strategy)
+	}
+	if !filter.From.IsZero() {
+		add("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("created_at < $%d", filter.To)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM trades "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, symbol, side, quantity, price, amount, order_id, status,
+		       COALESCE(strategy_type, 'DCA'), COALESCE(grid_level, 0), created_at, COALESCE(trace_id, '')
+		FROM trades
+		%s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)+1, len(args)+2)
+	trades, err := r.queryTrades(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	return trades, total, err
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// UserRepository управляет пользователями и их ролями
+type UserRepository struct {
+	db *sql.DB
+}
+
+// NewUserRepository создает новый репозиторий
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Upsert создает пользователя или меняет его роль и имя. Понижение последнего
+// админа отклоняется с domain.ErrLastAdmin
+func (r *UserRepository) Upsert(ctx context.Context, user *domain.User) error {
+	now := time.Now()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	user.UpdatedAt = now
+
+	return WithinTx(ctx, r.db, func(tx DBTX) error {
+		if user.Role != domain.RoleAdmin {
+			if err := requireAnotherAdmin(ctx, tx, user.TelegramID); err != nil {
+				return err
+			}
+		}
+
+		query := `
+			INSERT INTO users (telegram_id, name, role, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (telegram_id) DO UPDATE SET
+				name = CASE WHEN EXCLUDED.name = '' THEN users.name ELSE EXCLUDED.name END,
+				role = EXCLUDED.role,
+				updated_at = EXCLUDED.updated_at
+			RETURNING id, name, created_at
+		`
+		return tx.QueryRowContext(
+			ctx,
+			query,
+			user.TelegramID,
+			user.Name,
+			user.Role,
+			user.CreatedAt,
+			user.UpdatedAt,
+		).Scan(&user.ID, &user.Name, &user.CreatedAt)
+	})
+}
+
+// GetAll получает всех пользователей
+func (r *UserRepository) GetAll(ctx context.Context) ([]domain.User, error) {
+	query := `
+		SELECT id, telegram_id, name, role, created_at, updated_at
+		FROM users
+		ORDER BY id
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		var u domain.User
+		if err := rows.Scan(&u.ID, &u.TelegramID, &u.Name, &u.Role, &u.CreatedAt, &u.UpdatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
+
+// Delete удаляет пользователя (его API ключи удаляются каскадно). Удаление
+// последнего админа отклоняется с domain.ErrLastAdmin
+func (r *UserRepository) Delete(ctx context.Context, telegramID int64) error {
+	return WithinTx(ctx, r.db, func(tx DBTX) error {
+		if err := requireAnotherAdmin(ctx, tx, telegramID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM users WHERE telegram_id = $1`, telegramID)
+		return err
+	})
+}
+
+// requireAnotherAdmin проверяет, что без telegramID останется хотя бы один админ.
+// Строки админов блокируются до конца транзакции: параллельные понижения
+// выполняются по очереди и видят результат друг друга
+func requireAnotherAdmin(ctx context.Context, tx DBTX, telegramID int64) error {
+	rows, err := tx.QueryContext(ctx, `SELECT telegram_id FROM users WHERE role = $1 FOR UPDATE`, domain.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	target, others := false, false
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		if id == telegramID {
+			target = true
+		} else {
+			others = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if target && !others {
+		return domain.ErrLastAdmin
+	}
+	return nil
+}
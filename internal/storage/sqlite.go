@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/storage/migrations"
+)
+
+// SQLiteDriver имя зарегистрированного database/sql драйвера SQLite. По умолчанию - modernc.org/sqlite
+// (чистый Go, без cgo, см. sqlite_driver.go), для mattn/go-sqlite3 - "sqlite3"
+var SQLiteDriver = "sqlite"
+
+// sqlitePragmas выполняются на каждом новом соединении
+var sqlitePragmas = []string{
+	"PRAGMA foreign_keys = ON",
+	"PRAGMA busy_timeout = 5000",
+	"PRAGMA journal_mode = WAL",
+}
+
+// NewSQLiteStorage хранилище в одном файле SQLite (":memory:" - в памяти, для тестов)
+func NewSQLiteStorage(path string) (*Storage, error) {
+	db, err := OpenSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	return newStorage(db, DriverSQLite, migrations.SQLite)
+}
+
+// OpenSQLite открывает файл базы через SQLiteDriver. Репозитории пишут запросы в синтаксисе Postgres:
+// соединение переписывает плейсхолдеры $N в ?N и передает время в UTC, чтобы строки времени
+// в SQLite сравнивались лексически
+func OpenSQLite(path string) (*sql.DB, error) {
+	probe, err := sql.Open(SQLiteDriver, "")
+	if err != nil {
+		return nil, fmt.Errorf("sqlite driver %q is not registered: %w", SQLiteDriver, err)
+	}
+	base := probe.Driver()
+	probe.Close()
+
+	if path != ":memory:" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	var connector driver.Connector = dsnConnector{driver: base, dsn: path}
+	if dc, ok := base.(driver.DriverContext); ok {
+		if connector, err = dc.OpenConnector(path); err != nil {
+			return nil, fmt.Errorf("failed to open sqlite database %s: %w", path, err)
+		}
+	}
+
+	db := sql.OpenDB(sqliteConnector{base: connector})
+	// Одно соединение: SQLite допускает одного писателя, а база :memory: живет в пределах соединения
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open sqlite database %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// sqliteQuery переписывает запрос Postgres для SQLite: плейсхолдеры (rebind) и блокировку строк.
+// FOR UPDATE не нужен: соединение одно, и транзакции выполняются по очереди
+func sqliteQuery(query string) string {
+	query = rebind(query)
+	if trimmed, ok := strings.CutSuffix(strings.TrimSpace(query), "FOR UPDATE"); ok {
+		return strings.TrimSpace(trimmed)
+	}
+	return query
+}
+
+// rebind переписывает плейсхолдеры Postgres $N в нумерованные ?N (вне строковых литералов)
+func rebind(query string) string {
+	if !strings.Contains(query, "$") {
+		return query
+	}
+
+	b := []byte(query)
+	inString := false
+	for i, ch := range b {
+		switch {
+		case ch == '\'':
+			inString = !inString
+		case ch == '$' && !inString && i+1 < len(b) && b[i+1] >= '0' && b[i+1] <= '9':
+			b[i] = '?'
+		}
+	}
+	return string(b)
+}
+
+// checkSQLiteValue конвертирует аргумент (через checker драйвера, если он есть) и приводит время к UTC
+func checkSQLiteValue(checker interface{}, nv *driver.NamedValue) error {
+	err := driver.ErrSkip
+	if c, ok := checker.(driver.NamedValueChecker); ok {
+		err = c.CheckNamedValue(nv)
+	}
+	if err == driver.ErrSkip {
+		nv.Value, err = driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	}
+	if err != nil {
+		return err
+	}
+	if t, ok := nv.Value.(time.Time); ok {
+		nv.Value = t.UTC()
+	}
+	return nil
+}
+
+// dsnConnector connector для драйверов без driver.DriverContext
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.driver }
+
+// sqliteConnector открывает соединения драйвера и оборачивает их в sqliteConn
+type sqliteConnector struct {
+	base driver.Connector
+}
+
+func (c sqliteConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := &sqliteConn{Conn: conn}
+	for _, pragma := range sqlitePragmas {
+		if err := wrapped.exec(ctx, pragma); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s: %w", pragma, err)
+		}
+	}
+	return wrapped, nil
+}
+
+func (c sqliteConnector) Driver() driver.Driver { return c.base.Driver() }
+
+// sqliteConn соединение драйвера SQLite с переписыванием запросов
+type sqliteConn struct {
+	driver.Conn
+}
+
+func (c *sqliteConn) exec(ctx context.Context, query string) error {
+	if _, err := c.ExecContext(ctx, query, nil); err != driver.ErrSkip {
+		return err
+	}
+	stmt, err := c.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.(*sqliteStmt).ExecContext(ctx, nil)
+	return err
+}
+
+func (c *sqliteConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *sqliteConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, sqliteQuery(query))
+	} else {
+		stmt, err = c.Conn.Prepare(sqliteQuery(query))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteStmt{Stmt: stmt}, nil
+}
+
+func (c *sqliteConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *sqliteConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return e.ExecContext(ctx, sqliteQuery(query), args)
+}
+
+func (c *sqliteConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return q.QueryContext(ctx, sqliteQuery(query), args)
+}
+
+func (c *sqliteConn) CheckNamedValue(nv *driver.NamedValue) error {
+	return checkSQLiteValue(c.Conn, nv)
+}
+
+func (c *sqliteConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *sqliteConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *sqliteConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// sqliteStmt подготовленный запрос: аргументы проходят ту же конвертацию, что и у соединения
+type sqliteStmt struct {
+	driver.Stmt
+}
+
+func (s *sqliteStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return e.ExecContext(ctx, args)
+	}
+	return s.Stmt.Exec(namedValues(args))
+}
+
+func (s *sqliteStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return q.QueryContext(ctx, args)
+	}
+	return s.Stmt.Query(namedValues(args))
+}
+
+func (s *sqliteStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	return checkSQLiteValue(s.Stmt, nv)
+}
+
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
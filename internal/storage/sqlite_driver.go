@@ -0,0 +1,4 @@
+package storage
+
+// Драйвер SQLite на чистом Go (без cgo), регистрируется под именем SQLiteDriver
+import _ "modernc.org/sqlite"
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT 1", "SELECT 1"},
+		{"SELECT * FROM trades WHERE symbol = $1 LIMIT $2", "SELECT * FROM trades WHERE symbol = ?1 LIMIT ?2"},
+		{"UPDATE t SET a = $10 WHERE b = $1", "UPDATE t SET a = ?10 WHERE b = ?1"},
+		// Литералы и $ без номера не трогаются
+		{"SELECT '$1', 'it''s $2' FROM t WHERE a = $3", "SELECT '$1', 'it''s $2' FROM t WHERE a = ?3"},
+		{"SELECT $name", "SELECT $name"},
+	}
+
+	for _, tt := range tests {
+		if got := rebind(tt.query); got != tt.want {
+			t.Errorf("rebind(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSQLiteQuery(t *testing.T) {
+	query := "SELECT id FROM balances WHERE symbol = $1\n\t\tFOR UPDATE\n\t"
+	if got, want := sqliteQuery(query), "SELECT id FROM balances WHERE symbol = ?1"; got != want {
+		t.Errorf("sqliteQuery = %q, want %q", got, want)
+	}
+}
+
+// recordingDriver фейковый драйвер: запоминает запросы и аргументы
+type recordingDriver struct {
+	mu      sync.Mutex
+	queries []string
+	args    [][]driver.NamedValue
+}
+
+func (d *recordingDriver) record(query string, args []driver.NamedValue) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries = append(d.queries, query)
+	d.args = append(d.args, args)
+}
+
+func (d *recordingDriver) Open(dsn string) (driver.Conn, error) { return &recordingConn{d: d}, nil }
+
+type recordingConn struct{ d *recordingDriver }
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{d: c.d, query: query}, nil
+}
+func (c *recordingConn) Close() error              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) { return recordingTx{}, nil }
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.d.record(query, args)
+	return driver.RowsAffected(1), nil
+}
+
+type recordingStmt struct {
+	d     *recordingDriver
+	query string
+}
+
+func (s *recordingStmt) Close() error  { return nil }
+func (s *recordingStmt) NumInput() int { return -1 }
+
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	s.d.record(s.query, named)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.Exec(args)
+	return &recordingRows{}, nil
+}
+
+type recordingRows struct{ done bool }
+
+func (r *recordingRows) Columns() []string { return []string{"n"} }
+func (r *recordingRows) Close() error      { return nil }
+func (r *recordingRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+type recordingTx struct{}
+
+func (recordingTx) Commit() error   { return nil }
+func (recordingTx) Rollback() error { return nil }
+
+var registerRecording sync.Once
+
+func TestOpenSQLiteRewritesQueries(t *testing.T) {
+	registerRecording.Do(func() { sql.Register("sqlite-recording", &recordingDriver{}) })
+	fake := sqlDriver(t, "sqlite-recording").(*recordingDriver)
+	fake.queries, fake.args = nil, nil
+
+	previous := SQLiteDriver
+	SQLiteDriver = "sqlite-recording"
+	defer func() { SQLiteDriver = previous }()
+
+	db, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Новое соединение выполняет PRAGMA
+	if len(fake.queries) < len(sqlitePragmas) || fake.queries[0] != sqlitePragmas[0] {
+		t.Fatalf("pragmas not executed: %q", fake.queries)
+	}
+
+	moscow := time.FixedZone("MSK", 3*60*60)
+	at := time.Date(2026, 1, 2, 15, 0, 0, 0, moscow)
+	ctx := context.Background()
+
+	// Exec напрямую через соединение
+	if _, err := db.ExecContext(ctx, "UPDATE assets SET updated_at = $1 WHERE symbol = $2", at, "BTCUSDT"); err != nil {
+		t.Fatal(err)
+	}
+	last := len(fake.queries) - 1
+	if fake.queries[last] != "UPDATE assets SET updated_at = ?1 WHERE symbol = ?2" {
+		t.Errorf("exec query = %q", fake.queries[last])
+	}
+	if got := fake.args[last][0].Value.(time.Time); got.Location() != time.UTC || !got.Equal(at) {
+		t.Errorf("time arg = %v, want %v in UTC", got, at)
+	}
+
+	// Подготовленный запрос в транзакции
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO candles (open_time, close) VALUES ($1, $2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stmt.ExecContext(ctx, at, 1.5); err != nil {
+		t.Fatal(err)
+	}
+	stmt.Close()
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	last = len(fake.queries) - 1
+	if !strings.Contains(fake.queries[last], "VALUES (?1, ?2)") {
+		t.Errorf("prepared query = %q", fake.queries[last])
+	}
+	if got := fake.args[last][0].Value.(time.Time); got.Location() != time.UTC {
+		t.Errorf("prepared time arg not in UTC: %v", got)
+	}
+
+	// Query без QueryerContext идет через Prepare
+	var n int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM trades WHERE id = $1", 7).Scan(&n); err != nil || n != 1 {
+		t.Fatalf("query: n=%d err=%v", n, err)
+	}
+	if q := fake.queries[len(fake.queries)-1]; q != "SELECT COUNT(*) FROM trades WHERE id = ?1" {
+		t.Errorf("query = %q", q)
+	}
+}
+
+func TestOpenSQLiteWithoutDriver(t *testing.T) {
+	previous := SQLiteDriver
+	SQLiteDriver = "sqlite-missing"
+	defer func() { SQLiteDriver = previous }()
+
+	if _, err := OpenSQLite(":memory:"); err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Errorf("expected missing driver error, got %v", err)
+	}
+}
+
+// sqlDriver зарегистрированный драйвер по имени
+func sqlDriver(t *testing.T, name string) driver.Driver {
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	return db.Driver()
+}
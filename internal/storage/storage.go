@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/kirillm/dca-bot/internal/config"
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/storage/migrations"
+	"github.com/kirillm/dca-bot/internal/storage/repository"
+	"github.com/kirillm/dca-bot/pkg/utils"
+)
+
+// Драйверы базы (DB_DRIVER)
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Storage хранилище: репозитории домена (domain.UnitOfWork) и *sql.DB для репозиториев Stage 4.
+// Одинаково работает на PostgreSQL и SQLite
+type Storage struct {
+	*repository.Set
+	db     *sql.DB
+	driver string
+}
+
+// Open открывает хранилище драйвера из конфигурации и применяет миграции
+func Open(cfg config.DatabaseConfig) (*Storage, error) {
+	switch cfg.Driver {
+	case DriverPostgres, "":
+		return NewPostgresStorage(cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode,
+			cfg.MaxOpenConns, cfg.MaxIdleConns, cfg.ConnMaxLifetime)
+	case DriverSQLite:
+		return NewSQLiteStorage(cfg.Path)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
+
+// OpenDB открывает *sql.DB драйвера из конфигурации без миграций (cmd/migrate)
+func OpenDB(cfg config.DatabaseConfig) (*sql.DB, migrations.Dialect, error) {
+	switch cfg.Driver {
+	case DriverPostgres, "":
+		db, err := sql.Open("postgres", PostgresDSN(cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode))
+		return db, migrations.Postgres, err
+	case DriverSQLite:
+		db, err := OpenSQLite(cfg.Path)
+		return db, migrations.SQLite, err
+	default:
+		return nil, migrations.Dialect{}, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
+
+// newStorage применяет ожидающие миграции диалекта и собирает репозитории поверх db
+func newStorage(db *sql.DB, driver string, dialect migrations.Dialect) (*Storage, error) {
+	migrator, err := migrations.NewForDialect(db, dialect)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	applied, err := migrator.Up(context.Background())
+	for _, m := range applied {
+		utils.DefaultLogger().Info("Applied migration %04d_%s", m.Version, m.Name)
+	}
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	return &Storage{
+		Set:    repository.NewSet(db),
+		db:     db,
+		driver: driver,
+	}, nil
+}
+
+// WithinTx выполняет fn в транзакции: сделка, баланс и Grid ордер сохраняются вместе или не сохраняются вовсе
+func (s *Storage) WithinTx(ctx context.Context, fn func(tx domain.Repositories) error) error {
+	return repository.WithinTx(ctx, s.db, func(tx repository.DBTX) error {
+		return fn(repository.NewSet(tx))
+	})
+}
+
+// Driver драйвер базы: DriverPostgres или DriverSQLite
+func (s *Storage) Driver() string {
+	return s.driver
+}
+
+// Close закрывает соединение с базой данных
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// DB возвращает указатель на *sql.DB для использования в репозиториях Stage 4
+func (s *Storage) DB() *sql.DB {
+	return s.db
+}
@@ -0,0 +1,929 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/storage/migrations"
+	"github.com/kirillm/dca-bot/internal/storage/repository"
+)
+
+// backends хранилища, на которых гоняется набор тестов репозиториев:
+//   - sqlite в памяти - всегда, без внешней базы;
+//   - postgres, если задан TEST_DATABASE_URL (тест работает в отдельной временной схеме)
+func backends(t *testing.T) map[string]*Storage {
+	t.Helper()
+	s, err := NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatalf("sqlite: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	stores := map[string]*Storage{DriverSQLite: s}
+
+	if dsn := os.Getenv("TEST_DATABASE_URL"); dsn != "" {
+		stores[DriverPostgres] = postgresStorage(t, dsn)
+	}
+	return stores
+}
+
+// postgresStorage хранилище в новой схеме, удаляемой после теста
+func postgresStorage(t *testing.T, dsn string) *Storage {
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	schema := "storage_test_" + hex.EncodeToString(suffix)
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	sep := " "
+	if strings.Contains(dsn, "://") {
+		sep = "&"
+		if !strings.Contains(dsn, "?") {
+			sep = "?"
+		}
+	}
+	db, err := sql.Open("postgres", dsn+sep+"search_path="+schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := newStorage(db, DriverPostgres, migrations.Postgres)
+	if err != nil {
+		t.Fatalf("postgres: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// forEachBackend запускает подтест на каждом доступном хранилище (у каждого теста своя база)
+func forEachBackend(t *testing.T, fn func(t *testing.T, s *Storage)) {
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) { fn(t, s) })
+	}
+}
+
+// base опорное время теста: в UTC и без долей секунды - одинаково хранится в обеих базах
+var base = time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+
+func TestTradeRepository(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+		trades := []domain.Trade{
+			{Symbol: "BTCUSDT", Side: "BUY", Quantity: 0.01, Price: 50000, Amount: 500, Status: "FILLED", StrategyType: "DCA", CreatedAt: base, TraceID: "trace-1"},
+			{Symbol: "BTCUSDT", Side: "SELL", Quantity: 0.005, Price: 52000, Amount: 260, Status: "FILLED", StrategyType: "AUTO_SELL", CreatedAt: base.Add(time.Hour)},
+			{Symbol: "ETHUSDT", Side: "BUY", Quantity: 0.1, Price: 3000, Amount: 300, Status: "FILLED", StrategyType: "GRID", GridLevel: 2, CreatedAt: base.Add(2 * time.Hour)},
+		}
+		for i := range trades {
+			if err := s.Trades().Save(ctx, &trades[i]); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			if trades[i].ID == 0 {
+				t.Fatal("Save did not return id")
+			}
+		}
+
+		recent, err := s.Trades().GetRecent(ctx, "BTCUSDT", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recent) != 2 || recent[0].Side != "SELL" || recent[1].TraceID != "trace-1" {
+			t.Fatalf("GetRecent = %+v", recent)
+		}
+		if !recent[1].CreatedAt.Equal(base) {
+			t.Errorf("created_at = %v, want %v", recent[1].CreatedAt, base)
+		}
+
+		between, err := s.Trades().GetBetween(ctx, base.Add(30*time.Minute), base.Add(3*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(between) != 2 {
+			t.Errorf("GetBetween returned %d trades, want 2", len(between))
+		}
+
+		// Фильтр и страница: total считает все подходящие сделки
+		found, total, err := s.Trades().Find(ctx, domain.TradeFilter{Side: "BUY", Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 2 || len(found) != 1 || found[0].Symbol != "ETHUSDT" || found[0].GridLevel != 2 {
+			t.Errorf("Find = %+v, total %d", found, total)
+		}
+	})
+}
+
+func TestBalanceRepository(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+
+		// Отсутствующий баланс - пустой, а не ошибка
+		empty, err := s.Balances().Get(ctx, "BTCUSDT")
+		if err != nil || empty == nil || empty.TotalQuantity != 0 {
+			t.Fatalf("Get missing = %+v, %v", empty, err)
+		}
+
+		balance := &domain.Balance{Symbol: "BTCUSDT", TotalQuantity: 0.01, AvailableQty: 0.01, AvgEntryPrice: 50000, TotalInvested: 500}
+		if err := s.Balances().Update(ctx, balance); err != nil {
+			t.Fatal(err)
+		}
+		balance.TotalQuantity, balance.RealizedProfit = 0.02, 12.5
+		if err := s.Balances().Update(ctx, balance); err != nil {
+			t.Fatal(err)
+		}
+
+		all, err := s.Balances().GetAll(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != 1 || all[0].TotalQuantity != 0.02 || all[0].RealizedProfit != 12.5 {
+			t.Errorf("GetAll after upsert = %+v", all)
+		}
+
+		// Блокирующее чтение в транзакции: существующий баланс читается, для нового создается пустая строка
+		err = s.WithinTx(ctx, func(tx domain.Repositories) error {
+			locked, err := tx.Balances().GetForUpdate(ctx, "BTCUSDT")
+			if err != nil || locked.TotalQuantity != 0.02 {
+				t.Errorf("GetForUpdate existing = %+v, %v", locked, err)
+			}
+			fresh, err := tx.Balances().GetForUpdate(ctx, "ETHUSDT")
+			if err != nil || fresh.ID == 0 || fresh.TotalQuantity != 0 {
+				t.Errorf("GetForUpdate new = %+v, %v", fresh, err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if all, _ := s.Balances().GetAll(ctx); len(all) != 1 {
+			t.Errorf("empty locked balance listed by GetAll: %+v", all)
+		}
+	})
+}
+
+func TestAssetRepository(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+		for _, symbol := range []string{"ETHUSDT", "BTCUSDT"} {
+			asset := &domain.Asset{Symbol: symbol, Enabled: true, StrategyType: "DCA", AllocatedCapital: 1000, DCAAmount: 50, DCAInterval: 60}
+			if err := s.Assets().CreateOrUpdate(ctx, asset); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.Assets().Disable(ctx, "ETHUSDT"); err != nil {
+			t.Fatal(err)
+		}
+
+		enabled, err := s.Assets().GetEnabled(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(enabled) != 1 || enabled[0].Symbol != "BTCUSDT" || enabled[0].DCAInterval != 60 {
+			t.Errorf("GetEnabled = %+v", enabled)
+		}
+
+		deleted, err := s.Assets().Delete(ctx, "ETHUSDT")
+		if err != nil || !deleted {
+			t.Errorf("Delete = %v, %v", deleted, err)
+		}
+		if all, _ := s.Assets().GetAll(ctx); len(all) != 1 {
+			t.Errorf("GetAll after delete returned %d assets", len(all))
+		}
+	})
+}
+
+func TestGridOrderRepository(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+		for level := 2; level >= 1; level-- {
+			order := &domain.GridOrder{Symbol: "BTCUSDT", Level: level, Side: "BUY", Price: 49000 - float64(level)*500, Quantity: 0.001, Status: "PLACED"}
+			if err := s.GridOrders().Save(ctx, order); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		active, err := s.GridOrders().GetActive(ctx, "BTCUSDT")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(active) != 2 || active[0].Level != 1 {
+			t.Fatalf("GetActive = %+v", active)
+		}
+
+		active[0].Status, active[0].FilledQty = "FILLED", 0.001
+		if err := s.GridOrders().Update(ctx, &active[0]); err != nil {
+			t.Fatal(err)
+		}
+		if left, _ := s.GridOrders().GetActive(ctx, "BTCUSDT"); len(left) != 1 {
+			t.Errorf("active after fill: %d, want 1", len(left))
+		}
+
+		if err := s.GridOrders().CancelAll(ctx, "BTCUSDT"); err != nil {
+			t.Fatal(err)
+		}
+		if left, _ := s.GridOrders().GetActive(ctx, "BTCUSDT"); len(left) != 0 {
+			t.Errorf("active after CancelAll: %d", len(left))
+		}
+	})
+}
+
+func TestPnLRepository(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+
+		last, err := s.PnL().GetLastSnapshotTime(ctx, "DAILY")
+		if err != nil || !last.IsZero() {
+			t.Fatalf("GetLastSnapshotTime without snapshots = %v, %v", last, err)
+		}
+
+		day1, day2 := base, base.Add(24*time.Hour)
+		snapshots := []domain.PnLHistory{
+			{Symbol: "BTCUSDT", TotalPnL: 10, TotalInvested: 100, SnapshotType: "DAILY", CreatedAt: day1},
+			{Symbol: "BTCUSDT", TotalPnL: 15, TotalInvested: 100, SnapshotType: "DAILY", CreatedAt: day1.Add(time.Hour)},
+			{Symbol: "ETHUSDT", TotalPnL: 5, TotalInvested: 100, SnapshotType: "DAILY", CreatedAt: day1.Add(2 * time.Hour)},
+			{Symbol: "BTCUSDT", TotalPnL: 20, TotalInvested: 100, SnapshotType: "DAILY", CreatedAt: day2},
+		}
+		for i := range snapshots {
+			if err := s.PnL().SaveSnapshot(ctx, &snapshots[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		last, err = s.PnL().GetLastSnapshotTime(ctx, "DAILY")
+		if err != nil || !last.Equal(day2) {
+			t.Errorf("GetLastSnapshotTime = %v, %v; want %v", last, err, day2)
+		}
+
+		// Последний снимок каждого символа на конец первого дня
+		latest, err := s.PnL().GetLatestBefore(ctx, day1.Add(12*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(latest) != 2 || latest[0].Symbol != "BTCUSDT" || latest[0].TotalPnL != 15 || latest[1].TotalPnL != 5 {
+			t.Errorf("GetLatestBefore = %+v", latest)
+		}
+
+		// Портфель по дням: в первом дне BTC берется последний (15), плюс ETH (5)
+		history, err := s.PnL().GetPortfolioHistory(ctx, "DAILY", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 2 || history[0].TotalPnL != 20 || history[1].TotalPnL != 20 || history[1].TotalInvested != 200 {
+			t.Errorf("GetPortfolioHistory = %+v", history)
+		}
+		if history[1].ReturnPercent != 10 {
+			t.Errorf("return percent = %v, want 10", history[1].ReturnPercent)
+		}
+	})
+}
+
+func TestRiskAndConfigRepositories(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+
+		// Лимиты по умолчанию создает миграция
+		limits, err := s.Risk().GetLimits(ctx)
+		if err != nil || limits == nil || limits.MaxDailyLoss == 0 {
+			t.Fatalf("GetLimits = %+v, %v", limits, err)
+		}
+		limits.MaxDailyLoss = 123
+		if err := s.Risk().UpdateLimits(ctx, limits); err != nil {
+			t.Fatal(err)
+		}
+		if updated, _ := s.Risk().GetLimits(ctx); updated.MaxDailyLoss != 123 {
+			t.Errorf("MaxDailyLoss = %v after update", updated.MaxDailyLoss)
+		}
+
+		for _, value := range []string{"semi", "auto"} {
+			if err := s.Config().Set(ctx, "ai_mode", value); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if value, err := s.Config().Get(ctx, "ai_mode"); err != nil || value != "auto" {
+			t.Errorf("Config.Get = %q, %v", value, err)
+		}
+	})
+}
+
+func TestLogRepository(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+		if err := s.Logs().Save(ctx, "INFO", "started", `{"component":"test"}`); err != nil {
+			t.Fatal(err)
+		}
+
+		deleted, err := s.Logs().DeleteBefore(ctx, time.Now().Add(-time.Hour))
+		if err != nil || deleted != 0 {
+			t.Errorf("DeleteBefore past = %d, %v", deleted, err)
+		}
+		deleted, err = s.Logs().DeleteBefore(ctx, time.Now().Add(time.Hour))
+		if err != nil || deleted != 1 {
+			t.Errorf("DeleteBefore future = %d, %v", deleted, err)
+		}
+	})
+}
+
+func TestCandleRepository(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+		var candles []domain.Candle
+		for i := 0; i < 5; i++ {
+			candles = append(candles, domain.Candle{
+				Symbol: "BTCUSDT", Interval: "1h", OpenTime: base.Add(time.Duration(i) * time.Hour),
+				Open: 100, High: 110, Low: 90, Close: 100 + float64(i), Volume: 1,
+			})
+		}
+		if err := s.Candles().SaveBatch(ctx, candles); err != nil {
+			t.Fatal(err)
+		}
+
+		// Повторная свеча с тем же open_time обновляет существующую
+		candles[4].Close = 999
+		if err := s.Candles().SaveBatch(ctx, candles[4:]); err != nil {
+			t.Fatal(err)
+		}
+
+		recent, err := s.Candles().GetRecent(ctx, "BTCUSDT", "1h", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recent) != 2 || recent[0].Close != 103 || recent[1].Close != 999 {
+			t.Fatalf("GetRecent = %+v", recent)
+		}
+
+		ranged, err := s.Candles().GetRange(ctx, "BTCUSDT", "1h", base.Add(time.Hour), base.Add(3*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ranged) != 3 || !ranged[0].OpenTime.Equal(base.Add(time.Hour)) {
+			t.Errorf("GetRange = %+v", ranged)
+		}
+
+		deleted, err := s.Candles().DeleteOlderThan(ctx, "1h", base.Add(2*time.Hour))
+		if err != nil || deleted != 2 {
+			t.Errorf("DeleteOlderThan = %d, %v", deleted, err)
+		}
+	})
+}
+
+func TestWithinTxRollback(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+		failure := errors.New("exchange rejected")
+
+		err := s.WithinTx(ctx, func(tx domain.Repositories) error {
+			trade := &domain.Trade{Symbol: "BTCUSDT", Side: "BUY", Quantity: 0.01, Price: 50000, Amount: 500, Status: "FILLED", CreatedAt: base}
+			if err := tx.Trades().Save(ctx, trade); err != nil {
+				return err
+			}
+			if err := tx.Balances().Update(ctx, &domain.Balance{Symbol: "BTCUSDT", TotalQuantity: 0.01}); err != nil {
+				return err
+			}
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Fatalf("WithinTx error = %v", err)
+		}
+
+		if trades, _ := s.Trades().GetAllRecent(ctx, 10); len(trades) != 0 {
+			t.Errorf("%d trades saved after rollback", len(trades))
+		}
+		if balances, _ := s.Balances().GetAll(ctx); len(balances) != 0 {
+			t.Errorf("%d balances saved after rollback", len(balances))
+		}
+	})
+}
+
+func TestNewsSignalRepository(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+		repo := repository.NewNewsSignalRepository(s.DB())
+
+		// Массивы хранятся литералом pq.Array: TEXT[] в Postgres, TEXT в SQLite
+		topics := []string{"regulation", "etf, spot", `quote "x"`}
+		var ids []int64
+		for i := 0; i < 3; i++ {
+			signal := &domain.NewsSignal{
+				Timestamp: base.Add(time.Duration(i) * time.Minute), Source: "test", Headline: "headline",
+				Sentiment: "positive", Topics: topics, Signal: "BUY", Symbols: []string{"BTCUSDT"},
+			}
+			if err := repo.Save(ctx, signal); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, signal.ID)
+		}
+
+		recent, err := repo.GetRecent(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recent) != 1 || !reflect.DeepEqual(recent[0].Topics, topics) || !reflect.DeepEqual(recent[0].Symbols, []string{"BTCUSDT"}) {
+			t.Fatalf("GetRecent = %+v", recent)
+		}
+
+		if err := repo.MarkAsProcessedBatch(ctx, ids[:2]); err != nil {
+			t.Fatal(err)
+		}
+		unprocessed, err := repo.GetUnprocessed(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(unprocessed) != 1 || unprocessed[0].ID != ids[2] {
+			t.Errorf("GetUnprocessed = %+v", unprocessed)
+		}
+
+		// Дедупликация не зависит от даты публикации: статья месячной давности загружена сейчас
+		old := &domain.NewsSignal{Timestamp: time.Now().Add(-30 * 24 * time.Hour), Source: "rss", Headline: "Old ETF news", URL: "https://example.com/old"}
+		if err := repo.Save(ctx, old); err != nil {
+			t.Fatal(err)
+		}
+		since := time.Now().Add(-48 * time.Hour)
+		for _, tc := range []struct{ url, headline string }{{old.URL, "other"}, {"", "old etf NEWS"}} {
+			if exists, err := repo.ExistsSince(ctx, tc.url, tc.headline, since); err != nil || !exists {
+				t.Errorf("ExistsSince(%q, %q) = %v, %v; want true", tc.url, tc.headline, exists, err)
+			}
+		}
+		if exists, _ := repo.ExistsSince(ctx, "", "old etf news", time.Now().Add(time.Minute)); exists {
+			t.Error("headline ingested before since must not be a duplicate")
+		}
+	})
+}
+
+func TestAIDecisionRepositories(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+		decisions := repository.NewAIDecisionRepository(s.DB())
+		actions := repository.NewAIActionRepository(s.DB())
+		violations := repository.NewPolicyViolationRepository(s.DB())
+
+		var saved []*domain.AIDecision
+		for i, d := range []struct {
+			version, model string
+			approved       bool
+			confidence     float64
+		}{{"v1", "m1", true, 0.5}, {"v1", "m1", false, 1}, {"v2", "m2", true, 1}} {
+			decision := &domain.AIDecision{
+				Timestamp: base.Add(time.Duration(i) * time.Minute), Regime: "ACCUMULATE", Confidence: d.confidence,
+				RawResponse: "{}", Approved: d.approved, Mode: "shadow", PromptVersion: d.version, Model: d.model,
+			}
+			if i == 0 {
+				decision.TraceID = "trace-1"
+			}
+			if err := decisions.Save(ctx, decision); err != nil {
+				t.Fatal(err)
+			}
+			saved = append(saved, decision)
+		}
+
+		got, err := decisions.GetByID(ctx, saved[0].ID)
+		if err != nil || got == nil || got.TraceID != "trace-1" || !got.Timestamp.Equal(base) {
+			t.Fatalf("GetByID = %+v, %v", got, err)
+		}
+		// Пустой trace_id хранится как NULL и читается пустой строкой
+		if got, _ := decisions.GetByID(ctx, saved[1].ID); got == nil || got.TraceID != "" {
+			t.Errorf("GetByID without trace = %+v", got)
+		}
+		if got, err := decisions.GetByID(ctx, saved[2].ID+100); got != nil || err != nil {
+			t.Errorf("GetByID(missing) = %+v, %v; want nil, nil", got, err)
+		}
+
+		// COUNT(*) FILTER поддерживают обе базы
+		stats, err := decisions.GetPromptStats(ctx, base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []domain.PromptVersionStats{
+			{PromptVersion: "v1", Model: "m1", Decisions: 2, Approved: 1, AvgConfidence: 0.75},
+			{PromptVersion: "v2", Model: "m2", Decisions: 1, Approved: 1, AvgConfidence: 1},
+		}
+		if !reflect.DeepEqual(stats, want) {
+			t.Errorf("GetPromptStats = %+v, want %+v", stats, want)
+		}
+
+		between, err := decisions.GetBetween(ctx, base, base.Add(2*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(between) != 2 || between[0].ID != saved[0].ID || between[1].ID != saved[1].ID {
+			t.Errorf("GetBetween = %+v", between)
+		}
+
+		var actionIDs []int64
+		for i := 0; i < 2; i++ {
+			action := &domain.AIAction{
+				DecisionID: saved[0].ID, ActionType: "set_dca", Symbol: "BTCUSDT", Parameters: "{}",
+				Status: "pending", PolicyCheckResult: "{}", CreatedAt: base.Add(time.Duration(i) * time.Minute),
+			}
+			if err := actions.Save(ctx, action); err != nil {
+				t.Fatal(err)
+			}
+			actionIDs = append(actionIDs, action.ID)
+		}
+		if err := actions.UpdateStatus(ctx, actionIDs[0], "executed", ""); err != nil {
+			t.Fatal(err)
+		}
+		if err := actions.UpdateStatus(ctx, actionIDs[1], "failed", "timeout"); err != nil {
+			t.Fatal(err)
+		}
+
+		counts, err := actions.GetStatusCounts(ctx, base, base.Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(counts, map[string]int{"executed": 1, "failed": 1}) {
+			t.Errorf("GetStatusCounts = %v", counts)
+		}
+		if rate, err := actions.GetSuccessRate(ctx, base); err != nil || rate != 50 {
+			t.Errorf("GetSuccessRate = %v, %v; want 50", rate, err)
+		}
+		byDecision, err := actions.GetByDecisionID(ctx, saved[0].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(byDecision) != 2 || byDecision[1].Status != "failed" || byDecision[1].ErrorMessage != "timeout" {
+			t.Errorf("GetByDecisionID = %+v", byDecision)
+		}
+
+		for i, severity := range []string{"critical", "warning"} {
+			violation := &domain.PolicyViolation{
+				Timestamp: base.Add(time.Duration(i) * time.Minute), ActionID: actionIDs[i], ViolationType: "order_size",
+				LimitName: "max_order_usdt", LimitValue: 100, AttemptedValue: 150, Severity: severity,
+			}
+			if err := violations.Save(ctx, violation); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if n, err := violations.CountCritical(ctx, base); err != nil || n != 1 {
+			t.Errorf("CountCritical = %d, %v; want 1", n, err)
+		}
+		if stats, _ := violations.GetStatsByType(ctx, base); stats["order_size"] != 2 {
+			t.Errorf("GetStatsByType = %v", stats)
+		}
+		period, err := violations.GetBetween(ctx, base, base.Add(time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(period) != 1 || period[0].ActionID != actionIDs[0] || period[0].AttemptedValue != 150 {
+			t.Errorf("GetBetween = %+v", period)
+		}
+	})
+}
+
+func TestCircuitBreakerRepository(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+		repo := repository.NewCircuitBreakerRepository(s.DB())
+
+		// Пауза активного события отсчитывается от текущего времени: GetActive сравнивает с time.Now()
+		now := time.Now().UTC().Truncate(time.Second)
+		active := &domain.CircuitBreakerEvent{TriggeredAt: base, Reason: "drawdown", Details: "{}", PausedUntil: now.Add(time.Hour)}
+		expired := &domain.CircuitBreakerEvent{TriggeredAt: base.Add(time.Minute), Reason: "volatility", Details: "{}", PausedUntil: now.Add(-time.Hour)}
+		for _, e := range []*domain.CircuitBreakerEvent{active, expired} {
+			if err := repo.SaveEvent(ctx, e); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		got, err := repo.GetActive(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].ID != active.ID || !got[0].ResumedAt.IsZero() {
+			t.Fatalf("GetActive = %+v", got)
+		}
+		if ok, err := repo.IsAnyActive(ctx); err != nil || !ok {
+			t.Fatalf("IsAnyActive = %v, %v; want true", ok, err)
+		}
+
+		if err := repo.Resume(ctx, active.ID); err != nil {
+			t.Fatal(err)
+		}
+		if ok, _ := repo.IsAnyActive(ctx); ok {
+			t.Error("breaker still active after Resume")
+		}
+
+		between, err := repo.GetBetween(ctx, base, base.Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(between) != 2 || between[0].ID != active.ID || between[0].ResumedAt.IsZero() {
+			t.Errorf("GetBetween = %+v", between)
+		}
+		if stats, _ := repo.GetStatsByReason(ctx, base); !reflect.DeepEqual(stats, map[string]int{"drawdown": 1, "volatility": 1}) {
+			t.Errorf("GetStatsByReason = %v", stats)
+		}
+	})
+}
+
+func TestAccessRepositories(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+		users := repository.NewUserRepository(s.DB())
+		keys := repository.NewAPIKeyRepository(s.DB())
+
+		user := &domain.User{TelegramID: 100, Name: "owner", Role: "admin"}
+		if err := users.Upsert(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		// Последнего админа нельзя понизить или удалить
+		if err := users.Upsert(ctx, &domain.User{TelegramID: 100, Role: "viewer"}); !errors.Is(err, domain.ErrLastAdmin) {
+			t.Errorf("demote last admin = %v, want ErrLastAdmin", err)
+		}
+		if err := users.Delete(ctx, 100); !errors.Is(err, domain.ErrLastAdmin) {
+			t.Errorf("delete last admin = %v, want ErrLastAdmin", err)
+		}
+		if err := users.Upsert(ctx, &domain.User{TelegramID: 200, Role: "admin"}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Повторный Upsert без имени меняет роль и сохраняет имя
+		again := &domain.User{TelegramID: 100, Role: "viewer"}
+		if err := users.Upsert(ctx, again); err != nil {
+			t.Fatal(err)
+		}
+		if again.ID != user.ID || again.Name != "owner" {
+			t.Errorf("Upsert = %+v, want id %d and name owner", again, user.ID)
+		}
+		all, err := users.GetAll(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != 2 || all[0].Role != "viewer" || all[1].Role != "admin" {
+			t.Fatalf("GetAll = %+v", all)
+		}
+		if err := users.Upsert(ctx, &domain.User{TelegramID: 200, Role: "trader"}); !errors.Is(err, domain.ErrLastAdmin) {
+			t.Errorf("demote remaining admin = %v, want ErrLastAdmin", err)
+		}
+
+		// allowed_ips: TEXT[] в Postgres, литерал pq.Array в SQLite
+		ips := []string{"10.0.0.0/8", "192.168.1.5"}
+		key := &domain.APIKey{UserID: user.ID, Name: "ci", Prefix: "dca_ab", KeyHash: "hash-1", AllowedIPs: ips, RateLimit: 30, SigningSecret: "secret", RequireSignature: true}
+		if err := keys.Create(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		got, err := keys.GetByHash(ctx, "hash-1")
+		if err != nil || got == nil {
+			t.Fatalf("GetByHash = %+v, %v", got, err)
+		}
+		if !reflect.DeepEqual(got.AllowedIPs, ips) || got.RateLimit != 30 || !got.RequireSignature || got.LastUsedAt != nil {
+			t.Errorf("GetByHash = %+v", got)
+		}
+
+		got.AllowedIPs = nil
+		got.RequireSignature = false
+		if err := keys.UpdateSettings(ctx, got); err != nil {
+			t.Fatal(err)
+		}
+		if err := keys.Touch(ctx, key.ID); err != nil {
+			t.Fatal(err)
+		}
+		got, err = keys.GetByID(ctx, key.ID)
+		if err != nil || got == nil {
+			t.Fatalf("GetByID = %+v, %v", got, err)
+		}
+		if len(got.AllowedIPs) != 0 || got.RequireSignature || got.LastUsedAt == nil {
+			t.Errorf("GetByID after UpdateSettings/Touch = %+v", got)
+		}
+
+		if err := keys.Revoke(ctx, key.ID); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := keys.GetByHash(ctx, "hash-1"); got != nil || err != nil {
+			t.Errorf("GetByHash(revoked) = %+v, %v; want nil, nil", got, err)
+		}
+
+		// Ключи удаляются вместе с пользователем (ON DELETE CASCADE)
+		if err := users.Delete(ctx, 100); err != nil {
+			t.Fatal(err)
+		}
+		if rest, _ := keys.GetByUser(ctx, user.ID); len(rest) != 0 {
+			t.Errorf("%d keys left after user delete", len(rest))
+		}
+	})
+}
+
+func TestReportAndIncidentRepositories(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+		reports := repository.NewReportRepository(s.DB())
+		incidents := repository.NewIncidentRepository(s.DB())
+
+		report := &domain.PortfolioReport{Period: "DAILY", PeriodStart: base, PeriodEnd: base.Add(24 * time.Hour), Content: "draft", CreatedAt: base}
+		if err := reports.Save(ctx, report); err != nil {
+			t.Fatal(err)
+		}
+		if exists, err := reports.Exists(ctx, "DAILY", base); err != nil || !exists {
+			t.Errorf("Exists(undelivered) = %v, %v; want true", exists, err)
+		}
+		if exists, err := reports.Exists(ctx, "WEEKLY", base); err != nil || exists {
+			t.Errorf("Exists(other period) = %v, %v; want false", exists, err)
+		}
+		if err := reports.MarkDelivered(ctx, report.ID); err != nil {
+			t.Fatal(err)
+		}
+		if recent, err := reports.GetRecent(ctx, 10); err != nil || len(recent) != 1 || !recent[0].Delivered {
+			t.Errorf("GetRecent after MarkDelivered = %+v, %v", recent, err)
+		}
+
+		// Повторный отчет за тот же период перезаписывает предыдущий
+		delivered := &domain.PortfolioReport{Period: "DAILY", PeriodStart: base, PeriodEnd: base.Add(24 * time.Hour), Content: "final", Delivered: true, CreatedAt: base.Add(time.Minute)}
+		if err := reports.Save(ctx, delivered); err != nil {
+			t.Fatal(err)
+		}
+		if exists, err := reports.Exists(ctx, "DAILY", base); err != nil || !exists {
+			t.Errorf("Exists(delivered) = %v, %v; want true", exists, err)
+		}
+		recent, err := reports.GetRecent(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recent) != 1 || recent[0].ID != report.ID || recent[0].Content != "final" || recent[0].Narrative != "" {
+			t.Errorf("GetRecent = %+v", recent)
+		}
+
+		for i, kind := range []string{"dca_failed", "execution_failed"} {
+			incident := &domain.Incident{Kind: kind, Error: "boom", RootCause: "cause", CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+			if err := incidents.Save(ctx, incident); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err := incidents.GetRecent(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].Kind != "execution_failed" || got[0].RootCause != "cause" || got[0].Symbol != "" {
+			t.Errorf("GetRecent = %+v", got)
+		}
+	})
+}
+
+func TestConversationRepository(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+		repo := repository.NewConversationRepository(s.DB())
+
+		if state, err := repo.GetState(ctx, 1); state != nil || err != nil {
+			t.Fatalf("GetState(empty) = %+v, %v; want nil, nil", state, err)
+		}
+
+		var ids []int64
+		for i, userID := range []int64{1, 1, 1, 2} {
+			msg := &domain.ConversationMessage{UserID: userID, Role: "user", Content: "hi", CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+			if err := repo.AddMessage(ctx, msg); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, msg.ID)
+		}
+		if msgs, _ := repo.GetMessagesAfter(ctx, 1, ids[0]); len(msgs) != 2 || msgs[0].ID != ids[1] {
+			t.Fatalf("GetMessagesAfter = %+v", msgs)
+		}
+
+		// Без ожидающих действий pending_actions и pending_at хранятся как NULL
+		state := &domain.ConversationState{UserID: 1, Summary: "summary", SummarizedUntil: ids[1]}
+		if err := repo.SaveState(ctx, state); err != nil {
+			t.Fatal(err)
+		}
+		got, err := repo.GetState(ctx, 1)
+		if err != nil || got == nil {
+			t.Fatalf("GetState = %+v, %v", got, err)
+		}
+		if got.Summary != "summary" || got.SummarizedUntil != ids[1] || got.PendingActions != "" || !got.PendingAt.IsZero() {
+			t.Errorf("GetState = %+v", got)
+		}
+
+		state.PendingActions = "[]"
+		state.PendingAt = base
+		if err := repo.SaveState(ctx, state); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := repo.GetState(ctx, 1); got == nil || got.PendingActions != "[]" || !got.PendingAt.Equal(base) {
+			t.Errorf("GetState with pending = %+v", got)
+		}
+
+		if err := repo.DeleteSummarized(ctx, 1, ids[1]); err != nil {
+			t.Fatal(err)
+		}
+		if msgs, _ := repo.GetMessagesAfter(ctx, 1, 0); len(msgs) != 1 || msgs[0].ID != ids[2] {
+			t.Errorf("GetMessagesAfter after DeleteSummarized = %+v", msgs)
+		}
+
+		if err := repo.DeleteUser(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+		if msgs, _ := repo.GetMessagesAfter(ctx, 1, 0); len(msgs) != 0 {
+			t.Errorf("%d messages left after DeleteUser", len(msgs))
+		}
+		if state, _ := repo.GetState(ctx, 1); state != nil {
+			t.Errorf("state left after DeleteUser: %+v", state)
+		}
+		// История других пользователей не затрагивается
+		if msgs, _ := repo.GetMessagesAfter(ctx, 2, 0); len(msgs) != 1 {
+			t.Errorf("other user messages = %+v", msgs)
+		}
+	})
+}
+
+func TestLLMUsageRepository(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+		repo := repository.NewLLMUsageRepository(s.DB())
+
+		for _, u := range []domain.LLMUsage{
+			{Timestamp: base.Add(-time.Hour), Provider: "openai", Cloud: true, CostUSD: 2},
+			{Timestamp: base, Provider: "openai", Cloud: true, CostUSD: 0.25},
+			{Timestamp: base.Add(time.Minute), Provider: "ollama", CostUSD: 1},
+			{Timestamp: base.Add(2 * time.Minute), Provider: "openai", Cloud: true, CostUSD: 0.5, ReducedTrust: true, Error: "slow"},
+		} {
+			usage := u
+			usage.Model, usage.Purpose, usage.PromptTokens, usage.CompletionTokens = "m", "chat", 100, 20
+			if err := repo.Save(ctx, &usage); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Учитываются только облачные вызовы с since
+		if cost, err := repo.GetCostSince(ctx, base); err != nil || cost != 0.75 {
+			t.Errorf("GetCostSince = %v, %v; want 0.75", cost, err)
+		}
+		recent, err := repo.GetRecent(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recent) != 1 || !recent[0].Cloud || !recent[0].ReducedTrust || recent[0].Error != "slow" || recent[0].PromptTokens != 100 {
+			t.Errorf("GetRecent = %+v", recent)
+		}
+	})
+}
+
+// TestMigrateSQLite применяет все миграции к новой базе и откатывает их - без внешней базы,
+// как TestMigratePostgres в пакете migrations с TEST_DATABASE_URL
+func TestMigrateSQLite(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	migrator, err := migrations.NewForDialect(db, migrations.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, _ := migrations.SQLite.Migrations()
+	if applied, err := migrator.Up(ctx); err != nil || len(applied) != len(all) {
+		t.Fatalf("Up: applied=%d err=%v", len(applied), err)
+	}
+
+	// Схема рабочая: репозитории пишут в новые колонки
+	if _, err := db.Exec(`INSERT INTO trades (symbol, side, quantity, price, amount, status, trace_id)
+		VALUES ('BTCUSDT', 'BUY', 0.001, 50000, 50, 'FILLED', 'abc')`); err != nil {
+		t.Fatalf("insert trade: %v", err)
+	}
+	var limits int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM risk_limits`).Scan(&limits); err != nil || limits != 1 {
+		t.Fatalf("default risk limits: count=%d err=%v", limits, err)
+	}
+
+	// Повторный Up ничего не делает
+	if again, err := migrator.Up(ctx); err != nil || len(again) != 0 {
+		t.Fatalf("second Up: applied=%d err=%v", len(again), err)
+	}
+
+	// Полный откат оставляет только schema_migrations, повторный Up проходит
+	if _, err := migrator.Down(ctx, len(all)); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	var tables int
+	db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name <> 'schema_migrations'`).Scan(&tables)
+	if tables != 0 {
+		t.Errorf("%d tables left after full rollback", tables)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up after Down: %v", err)
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if !s.Applied {
+			t.Errorf("%04d_%s not applied", s.Version, s.Name)
+		}
+	}
+}
@@ -1,17 +1,18 @@
 package strategy
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/kirillm/dca-bot/internal/domain"
 	"github.com/kirillm/dca-bot/internal/exchange"
-	"github.com/kirillm/dca-bot/internal/storage"
 	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
 type AutoSellStrategy struct {
 	exchange           *exchange.BybitClient
-	storage            *storage.PostgresStorage
+	storage            domain.UnitOfWork
 	logger             *utils.Logger
 	symbol             string
 	triggerPercent     float64  // процент роста для активации продажи
@@ -20,11 +21,12 @@ type AutoSellStrategy struct {
 	enabled            bool
 	stopChan           chan bool
 	notifyFunc         func(string)
+	events             EventPublisher
 }
 
 func NewAutoSellStrategy(
 	ex *exchange.BybitClient,
-	st *storage.PostgresStorage,
+	st domain.UnitOfWork,
 	logger *utils.Logger,
 	symbol string,
 	triggerPercent float64,
@@ -46,6 +48,11 @@ func NewAutoSellStrategy(
 	}
 }
 
+// SetEventPublisher подключает шину событий (trade.filled)
+func (a *AutoSellStrategy) SetEventPublisher(publisher EventPublisher) {
+	a.events = publisher
+}
+
 // Start запускает Auto-Sell стратегию
 func (a *AutoSellStrategy) Start() {
 	a.logger.Info("Auto-Sell strategy started for %s with trigger %.2f%% and sell amount %.2f%%",
@@ -100,7 +107,7 @@ func (a *AutoSellStrategy) IsEnabled() bool {
 // checkAndExecuteSell проверяет условия и выполняет продажу
 func (a *AutoSellStrategy) checkAndExecuteSell() error {
 	// Получаем баланс
-	balance, err := a.storage.GetBalance(a.symbol)
+	balance, err := a.storage.Balances().Get(context.Background(), a.symbol)
 	if err != nil {
 		return fmt.Errorf("failed to get balance: %w", err)
 	}
@@ -131,27 +138,27 @@ func (a *AutoSellStrategy) checkAndExecuteSell() error {
 }
 
 // executeSell выполняет продажу
-func (a *AutoSellStrategy) executeSell(balance *storage.Balance, currentPrice, profitPercent float64) error {
+func (a *AutoSellStrategy) executeSell(balance *domain.Balance, currentPrice, profitPercent float64) error {
 	// Рассчитываем количество для продажи
 	sellQuantity := balance.AvailableQty * (a.sellAmountPercent / 100)
 
-	a.logger.Info("Executing Auto-Sell: %.8f %s at price %.2f (profit: %.2f%%)",
-		sellQuantity, a.symbol, currentPrice, profitPercent)
+	ctx, span, logger := startOrder(context.Background(), a.logger, a.symbol, "AUTO_SELL")
+	defer span.End()
+	logger.Info("Executing Auto-Sell: %.8f at price %.2f (profit: %.2f%%)",
+		sellQuantity, currentPrice, profitPercent)
 
 	// Размещаем ордер на продажу
-	orderInfo, err := a.exchange.PlaceOrder(a.symbol, "Sell", sellQuantity)
+	orderInfo, err := a.exchange.PlaceOrderContext(ctx, a.symbol, "Sell", sellQuantity)
+	logger = observeOrder(ctx, logger, a.symbol, "SELL", "AUTO_SELL", sellQuantity, orderInfo, err)
 	if err != nil {
 		return fmt.Errorf("failed to place sell order: %w", err)
 	}
 
-	a.logger.Info("Sell order placed successfully: %s", orderInfo.OrderID)
-
-	// Рассчитываем сумму продажи и прибыль
+	// Рассчитываем сумму продажи
 	sellAmount := sellQuantity * currentPrice
-	profit := sellQuantity * (currentPrice - balance.AvgEntryPrice)
 
 	// Сохраняем сделку в БД
-	trade := &storage.Trade{
+	trade := &domain.Trade{
 		Symbol:    a.symbol,
 		Side:      "SELL",
 		Quantity:  sellQuantity,
@@ -160,16 +167,16 @@ func (a *AutoSellStrategy) executeSell(balance *storage.Balance, currentPrice, p
 		OrderID:   orderInfo.OrderID,
 		Status:    orderInfo.Status,
 		CreatedAt: time.Now(),
+		TraceID:   span.TraceID(),
 	}
 
-	if err := a.storage.SaveTrade(trade); err != nil {
-		a.logger.Error("Failed to save trade: %v", err)
-	}
-
-	// Обновляем баланс
-	if err := a.updateBalanceAfterSell(balance, sellQuantity, sellAmount, profit); err != nil {
-		a.logger.Error("Failed to update balance: %v", err)
+	// Сделка и баланс сохраняются в одной транзакции; ордер уже исполнен, поэтому сбой только логируется
+	profit, err := recordFill(ctx, a.storage, trade, nil)
+	if err != nil {
+		logger.Error("Failed to record trade: %v", err)
+		profit = sellQuantity * (currentPrice - balance.AvgEntryPrice)
 	}
+	publishTrade(a.events, trade, "AUTO_SELL")
 
 	// Отправляем уведомление
 	message := fmt.Sprintf(
@@ -200,16 +207,6 @@ func (a *AutoSellStrategy) executeSell(balance *storage.Balance, currentPrice, p
 	return nil
 }
 
-// updateBalanceAfterSell обновляет баланс после продажи
-func (a *AutoSellStrategy) updateBalanceAfterSell(balance *storage.Balance, soldQty, sellAmount, profit float64) error {
-	balance.AvailableQty -= soldQty
-	balance.TotalQuantity -= soldQty
-	balance.TotalSold += sellAmount
-	balance.RealizedProfit += profit
-
-	return a.storage.UpdateBalance(balance)
-}
-
 // UpdateTriggerPercent обновляет процент триггера
 func (a *AutoSellStrategy) UpdateTriggerPercent(newPercent float64) {
 	a.triggerPercent = newPercent
@@ -230,7 +227,7 @@ func (a *AutoSellStrategy) UpdateSellAmountPercent(newPercent float64) {
 
 // GetStatus возвращает информацию о статусе Auto-Sell
 func (a *AutoSellStrategy) GetStatus() (string, error) {
-	balance, err := a.storage.GetBalance(a.symbol)
+	balance, err := a.storage.Balances().Get(context.Background(), a.symbol)
 	if err != nil {
 		return "", err
 	}
@@ -271,7 +268,7 @@ func (a *AutoSellStrategy) GetStatus() (string, error) {
 
 // ExecuteManualSell выполняет ручную продажу указанного процента позиции
 func (a *AutoSellStrategy) ExecuteManualSell(percent float64) error {
-	balance, err := a.storage.GetBalance(a.symbol)
+	balance, err := a.storage.Balances().Get(context.Background(), a.symbol)
 	if err != nil {
 		return fmt.Errorf("failed to get balance: %w", err)
 	}
@@ -1,28 +1,37 @@
 package strategy
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/kirillm/dca-bot/internal/domain"
 	"github.com/kirillm/dca-bot/internal/exchange"
-	"github.com/kirillm/dca-bot/internal/storage"
+	"github.com/kirillm/dca-bot/internal/incidents"
 	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
 type DCAStrategy struct {
 	exchange      *exchange.BybitClient
-	storage       *storage.PostgresStorage
+	storage       domain.UnitOfWork
 	logger        *utils.Logger
 	symbol        string
 	amount        float64
 	interval      time.Duration
 	stopChan      chan bool
 	notifyFunc    func(string)
+	incidents     IncidentReporter
+	events        EventPublisher
+}
+
+// IncidentReporter разбирает сбои и отправляет алерт с разбором (incidents.Analyzer)
+type IncidentReporter interface {
+	Report(event incidents.Event)
 }
 
 func NewDCAStrategy(
 	ex *exchange.BybitClient,
-	st *storage.PostgresStorage,
+	st domain.UnitOfWork,
 	logger *utils.Logger,
 	symbol string,
 	amount float64,
@@ -58,9 +67,7 @@ func (d *DCAStrategy) Start() {
 		case <-ticker.C:
 			if err := d.executeDCA(); err != nil {
 				d.logger.Error("DCA execution failed: %v", err)
-				if d.notifyFunc != nil {
-					d.notifyFunc(fmt.Sprintf("❌ DCA failed: %v", err))
-				}
+				d.reportFailure(err)
 			}
 		case <-d.stopChan:
 			d.logger.Info("DCA strategy stopped")
@@ -74,12 +81,42 @@ func (d *DCAStrategy) Stop() {
 	d.stopChan <- true
 }
 
+// SetIncidentReporter подключает разбор сбоев: алерт о неудачной покупке уходит с причиной
+func (d *DCAStrategy) SetIncidentReporter(reporter IncidentReporter) {
+	d.incidents = reporter
+}
+
+// reportFailure отправляет алерт о неудачной покупке (через разбор инцидента, если подключен)
+func (d *DCAStrategy) reportFailure(err error) {
+	message := fmt.Sprintf("❌ DCA failed: %v", err)
+	if d.incidents != nil {
+		d.incidents.Report(incidents.Event{
+			Kind:    incidents.KindDCAFailed,
+			Source:  "dca",
+			Symbol:  d.symbol,
+			Message: message,
+			Err:     err,
+		})
+		return
+	}
+	if d.notifyFunc != nil {
+		d.notifyFunc(message)
+	}
+}
+
+// SetEventPublisher подключает шину событий (trade.filled)
+func (d *DCAStrategy) SetEventPublisher(publisher EventPublisher) {
+	d.events = publisher
+}
+
 // executeDCA выполняет одну DCA покупку
 func (d *DCAStrategy) executeDCA() error {
-	d.logger.Info("Executing DCA buy for %s", d.symbol)
+	ctx, span, logger := startOrder(context.Background(), d.logger, d.symbol, "DCA")
+	defer span.End()
+	logger.Info("Executing DCA buy")
 
 	// Проверяем баланс USDT
-	usdtBalance, err := d.exchange.GetBalance("USDT")
+	usdtBalance, err := d.exchange.GetBalanceContext(ctx, "USDT")
 	if err != nil {
 		return fmt.Errorf("failed to get USDT balance: %w", err)
 	}
@@ -89,29 +126,25 @@ func (d *DCAStrategy) executeDCA() error {
 	}
 
 	// Получаем текущую цену
-	currentPrice, err := d.exchange.GetPrice(d.symbol)
+	currentPrice, err := d.exchange.GetPriceContext(ctx, d.symbol)
 	if err != nil {
 		return fmt.Errorf("failed to get price: %w", err)
 	}
 
-	// Рассчитываем количество актива для покупки
-	quantity, err := d.exchange.CalculateOrderAmount(d.symbol, d.amount)
-	if err != nil {
-		return fmt.Errorf("failed to calculate order amount: %w", err)
-	}
+	// Рассчитываем количество актива для покупки по уже полученной цене
+	quantity := d.amount / currentPrice
 
-	d.logger.Info("Buying %.8f %s at price %.2f", quantity, d.symbol, currentPrice)
+	logger.Info("Buying %.8f at price %.2f", quantity, currentPrice)
 
 	// Размещаем рыночный ордер
-	orderInfo, err := d.exchange.PlaceOrder(d.symbol, "Buy", quantity)
+	orderInfo, err := d.exchange.PlaceOrderContext(ctx, d.symbol, "Buy", quantity)
+	logger = observeOrder(ctx, logger, d.symbol, "BUY", "DCA", quantity, orderInfo, err)
 	if err != nil {
 		return fmt.Errorf("failed to place order: %w", err)
 	}
 
-	d.logger.Info("Order placed successfully: %s", orderInfo.OrderID)
-
 	// Сохраняем сделку в БД
-	trade := &storage.Trade{
+	trade := &domain.Trade{
 		Symbol:    d.symbol,
 		Side:      "BUY",
 		Quantity:  quantity,
@@ -120,16 +153,14 @@ func (d *DCAStrategy) executeDCA() error {
 		OrderID:   orderInfo.OrderID,
 		Status:    orderInfo.Status,
 		CreatedAt: time.Now(),
+		TraceID:   span.TraceID(),
 	}
 
-	if err := d.storage.SaveTrade(trade); err != nil {
-		d.logger.Error("Failed to save trade: %v", err)
-	}
-
-	// Обновляем баланс
-	if err := d.updateBalance(quantity, currentPrice, d.amount); err != nil {
-		d.logger.Error("Failed to update balance: %v", err)
+	// Сделка и баланс сохраняются в одной транзакции; ордер уже исполнен, поэтому сбой только логируется
+	if _, err := recordFill(ctx, d.storage, trade, nil); err != nil {
+		logger.Error("Failed to record trade: %v", err)
 	}
+	publishTrade(d.events, trade, "DCA")
 
 	// Отправляем уведомление
 	message := fmt.Sprintf(
@@ -149,26 +180,6 @@ func (d *DCAStrategy) executeDCA() error {
 	return nil
 }
 
-// updateBalance обновляет баланс после покупки
-func (d *DCAStrategy) updateBalance(quantity, price, amount float64) error {
-	balance, err := d.storage.GetBalance(d.symbol)
-	if err != nil {
-		return err
-	}
-
-	// Рассчитываем новую среднюю цену входа
-	totalQuantity := balance.TotalQuantity + quantity
-	totalInvested := balance.TotalInvested + amount
-	avgPrice := totalInvested / totalQuantity
-
-	balance.TotalQuantity = totalQuantity
-	balance.AvailableQty = totalQuantity
-	balance.AvgEntryPrice = avgPrice
-	balance.TotalInvested = totalInvested
-
-	return d.storage.UpdateBalance(balance)
-}
-
 // ExecuteManualBuy выполняет ручную покупку
 func (d *DCAStrategy) ExecuteManualBuy() error {
 	return d.executeDCA()
@@ -182,7 +193,7 @@ func (d *DCAStrategy) UpdateAmount(newAmount float64) {
 
 // GetStatus возвращает информацию о статусе стратегии
 func (d *DCAStrategy) GetStatus() (string, error) {
-	balance, err := d.storage.GetBalance(d.symbol)
+	balance, err := d.storage.Balances().Get(context.Background(), d.symbol)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,94 @@
+package strategy
+
+import (
+	"context"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/events"
+	"github.com/kirillm/dca-bot/internal/exchange"
+	"github.com/kirillm/dca-bot/internal/metrics"
+	"github.com/kirillm/dca-bot/internal/tracing"
+	"github.com/kirillm/dca-bot/pkg/utils"
+)
+
+// EventPublisher шина событий для дашбордов (events.Bus)
+type EventPublisher interface {
+	Publish(eventType string, data interface{})
+}
+
+// publishTrade отправляет trade.filled, если шина подключена
+func publishTrade(publisher EventPublisher, trade *domain.Trade, strategy string) {
+	if publisher == nil {
+		return
+	}
+	publisher.Publish(events.TradeFilled, events.TradeData{
+		TradeID:   trade.ID,
+		Symbol:    trade.Symbol,
+		Side:      trade.Side,
+		Strategy:  strategy,
+		Quantity:  trade.Quantity,
+		Price:     trade.Price,
+		AmountUSD: trade.Amount,
+		OrderID:   trade.OrderID,
+	})
+}
+
+// publishGridOrder отправляет order.placed для нового уровня сетки
+func publishGridOrder(publisher EventPublisher, order *domain.GridOrder) {
+	if publisher == nil {
+		return
+	}
+	publisher.Publish(events.OrderPlaced, events.OrderData{
+		Symbol:    order.Symbol,
+		Side:      order.Side,
+		Strategy:  "GRID",
+		Quantity:  order.Quantity,
+		Price:     order.Price,
+		OrderID:   order.OrderID,
+		GridLevel: order.Level,
+	})
+}
+
+// startOrder открывает спан операции с ордером и логгер с полями symbol, strategy и correlation_id.
+// Без correlation_id в ctx им становится ID трейса (или случайный ID при выключенном трейсинге)
+func startOrder(ctx context.Context, base *utils.Logger, symbol, strategy string) (context.Context, *tracing.Span, *utils.Logger) {
+	ctx, span := tracing.Start(ctx, "strategy.order", tracing.KindInternal,
+		tracing.String("symbol", symbol), tracing.String("strategy", strategy))
+
+	if utils.CorrelationID(ctx) == "" {
+		id := span.TraceID()
+		if id == "" {
+			id = utils.NewCorrelationID()
+		}
+		ctx = utils.WithCorrelationID(ctx, id)
+	}
+
+	if base == nil {
+		base = utils.DefaultLogger()
+	}
+	return ctx, span, base.WithContext(ctx).With("symbol", symbol, "strategy", strategy)
+}
+
+// observeOrder учитывает ордер в метриках Prometheus, спане и логе.
+// Возвращает логгер с order_id для записей после размещения
+func observeOrder(ctx context.Context, logger *utils.Logger, symbol, side, strategy string, quantity float64, order *exchange.OrderInfo, err error) *utils.Logger {
+	status := ""
+	if order != nil {
+		status = order.Status
+	}
+	metrics.ObserveOrder(symbol, side, strategy, status, err)
+
+	span := tracing.SpanFromContext(ctx)
+	span.SetAttributes(tracing.String("side", side), tracing.Float("quantity", quantity))
+
+	logger = logger.With("side", side)
+	if err != nil {
+		span.RecordError(err)
+		logger.Error("Order failed (qty %.8f): %v", quantity, err)
+		return logger
+	}
+	span.SetAttributes(tracing.String("order_id", order.OrderID))
+	logger = logger.With("order_id", order.OrderID)
+	logger.Info("Order placed (qty %.8f, status %s)", quantity, status)
+	return logger
+}
@@ -1,22 +1,34 @@
 package strategy
 
 import (
+	"context"
 	"fmt"
 	"math"
+	"strings"
 	"time"
 
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/events"
 	"github.com/kirillm/dca-bot/internal/exchange"
-	"github.com/kirillm/dca-bot/internal/storage"
+	"github.com/kirillm/dca-bot/internal/marketdata"
+	"github.com/kirillm/dca-bot/internal/tracing"
 	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
+// IndicatorProvider источник рыночных индикаторов
+type IndicatorProvider interface {
+	GetIndicators(symbol string) (*marketdata.Indicators, error)
+}
+
 // GridStrategy реализует Grid торговую стратегию
 type GridStrategy struct {
-	storage  *storage.PostgresStorage
-	exchange *exchange.BybitClient
+	storage    domain.UnitOfWork
+	exchange   *exchange.BybitClient
+	marketData IndicatorProvider
+	events     EventPublisher
 }
 
-func NewGridStrategy(storage *storage.PostgresStorage, exchange *exchange.BybitClient) *GridStrategy {
+func NewGridStrategy(storage domain.UnitOfWork, exchange *exchange.BybitClient) *GridStrategy {
 	return &GridStrategy{
 		storage:  storage,
 		exchange: exchange,
@@ -24,7 +36,7 @@ func NewGridStrategy(storage *storage.PostgresStorage, exchange *exchange.BybitC
 }
 
 // InitializeGrid создает начальную сетку ордеров
-func (g *GridStrategy) InitializeGrid(asset *storage.Asset) error {
+func (g *GridStrategy) InitializeGrid(asset *domain.Asset) error {
 	utils.LogInfo(fmt.Sprintf("Инициализация Grid для %s с %d уровнями", asset.Symbol, asset.GridLevels))
 
 	// Получаем текущую цену
@@ -35,62 +47,83 @@ func (g *GridStrategy) InitializeGrid(asset *storage.Asset) error {
 
 	utils.LogInfo(fmt.Sprintf("Текущая цена %s: %.8f", asset.Symbol, currentPrice))
 
-	// Отменяем все существующие grid ордера
-	if err := g.storage.CancelGridOrders(asset.Symbol); err != nil {
-		return fmt.Errorf("не удалось отменить существующие ордера: %w", err)
+	// Spacing не задан - рассчитываем по ATR
+	if asset.GridSpacingPercent <= 0 {
+		asset.GridSpacingPercent = g.atrSpacing(asset.Symbol)
+		utils.LogInfo(fmt.Sprintf("Grid spacing для %s по ATR: %.2f%%", asset.Symbol, asset.GridSpacingPercent))
 	}
 
 	// Рассчитываем уровни сетки
 	gridLevels := g.calculateGridLevels(currentPrice, asset.GridLevels, asset.GridSpacingPercent)
 
-	// Размещаем buy ордера ниже текущей цены
-	for i := 0; i < len(gridLevels)/2; i++ {
-		level := -i - 1
-		price := gridLevels[i]
-
-		order := &storage.GridOrder{
+	// Buy ордера ниже текущей цены, sell - выше
+	orders := make([]*domain.GridOrder, 0, len(gridLevels))
+	for i, price := range gridLevels {
+		level, side := -i-1, "BUY"
+		if i >= len(gridLevels)/2 {
+			level, side = i-len(gridLevels)/2+1, "SELL"
+		}
+		orders = append(orders, &domain.GridOrder{
 			Symbol:    asset.Symbol,
 			Level:     level,
-			Side:      "BUY",
+			Side:      side,
 			Price:     price,
 			Quantity:  asset.GridOrderSize / price, // Количество монет по цене
 			Status:    "PENDING",
 			CreatedAt: time.Now(),
-		}
+		})
+	}
 
-		if err := g.storage.SaveGridOrder(order); err != nil {
-			utils.LogError(fmt.Sprintf("Не удалось сохранить buy ордер: %v", err))
-			continue
+	// Старая сетка отменяется и новая сохраняется в одной транзакции
+	ctx := context.Background()
+	if err := g.storage.WithinTx(ctx, func(tx domain.Repositories) error {
+		if err := tx.GridOrders().CancelAll(ctx, asset.Symbol); err != nil {
+			return fmt.Errorf("не удалось отменить существующие ордера: %w", err)
 		}
+		for _, order := range orders {
+			if err := tx.GridOrders().Save(ctx, order); err != nil {
+				return fmt.Errorf("не удалось сохранить %s ордер уровня %d: %w", order.Side, order.Level, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
 
-		utils.LogInfo(fmt.Sprintf("Создан buy ордер: уровень %d, цена %.8f, количество %.8f", level, price, order.Quantity))
+	for _, order := range orders {
+		publishGridOrder(g.events, order)
+		utils.LogInfo(fmt.Sprintf("Создан %s ордер: уровень %d, цена %.8f, количество %.8f", strings.ToLower(order.Side), order.Level, order.Price, order.Quantity))
 	}
 
-	// Размещаем sell ордера выше текущей цены
-	for i := len(gridLevels) / 2; i < len(gridLevels); i++ {
-		level := i - len(gridLevels)/2 + 1
-		price := gridLevels[i]
+	utils.LogInfo(fmt.Sprintf("Grid инициализирована для %s: %d уровней", asset.Symbol, asset.GridLevels))
+	return nil
+}
 
-		order := &storage.GridOrder{
-			Symbol:    asset.Symbol,
-			Level:     level,
-			Side:      "SELL",
-			Price:     price,
-			Quantity:  asset.GridOrderSize / price,
-			Status:    "PENDING",
-			CreatedAt: time.Now(),
-		}
+// SetEventPublisher подключает шину событий (order.placed, grid.level_filled, trade.filled)
+func (g *GridStrategy) SetEventPublisher(publisher EventPublisher) {
+	g.events = publisher
+}
 
-		if err := g.storage.SaveGridOrder(order); err != nil {
-			utils.LogError(fmt.Sprintf("Не удалось сохранить sell ордер: %v", err))
-			continue
-		}
+// SetMarketData подключает источник индикаторов для расчета spacing
+func (g *GridStrategy) SetMarketData(marketData IndicatorProvider) {
+	g.marketData = marketData
+}
 
-		utils.LogInfo(fmt.Sprintf("Создан sell ордер: уровень %d, цена %.8f, количество %.8f", level, price, order.Quantity))
+// atrSpacing рассчитывает spacing сетки как ATR(14) на часовых свечах
+func (g *GridStrategy) atrSpacing(symbol string) float64 {
+	const defaultSpacing = 1.0
+
+	if g.marketData == nil {
+		return defaultSpacing
 	}
 
-	utils.LogInfo(fmt.Sprintf("Grid инициализирована для %s: %d уровней", asset.Symbol, asset.GridLevels))
-	return nil
+	ind, err := g.marketData.GetIndicators(symbol)
+	if err != nil || ind.ATRPercent <= 0 {
+		return defaultSpacing
+	}
+
+	// Ограничиваем диапазоном 0.5% - 5%
+	return math.Min(math.Max(ind.ATRPercent, 0.5), 5.0)
 }
 
 // calculateGridLevels рассчитывает ценовые уровни для сетки
@@ -114,14 +147,14 @@ func (g *GridStrategy) calculateGridLevels(currentPrice float64, numLevels int,
 }
 
 // MonitorGrid проверяет и обновляет состояние сетки
-func (g *GridStrategy) MonitorGrid(asset *storage.Asset) error {
+func (g *GridStrategy) MonitorGrid(asset *domain.Asset) error {
 	currentPrice, err := g.exchange.GetCurrentPrice(asset.Symbol)
 	if err != nil {
 		return fmt.Errorf("не удалось получить текущую цену: %w", err)
 	}
 
 	// Получаем активные ордера
-	activeOrders, err := g.storage.GetActiveGridOrders(asset.Symbol)
+	activeOrders, err := g.storage.GridOrders().GetActive(context.Background(), asset.Symbol)
 	if err != nil {
 		return fmt.Errorf("не удалось получить активные ордера: %w", err)
 	}
@@ -153,28 +186,28 @@ func (g *GridStrategy) MonitorGrid(asset *storage.Asset) error {
 }
 
 // executeGridOrder исполняет Grid ордер
-func (g *GridStrategy) executeGridOrder(order *storage.GridOrder, asset *storage.Asset, currentPrice float64) error {
-	utils.LogInfo(fmt.Sprintf("Исполнение Grid ордера: %s %s %.8f @ %.8f", order.Symbol, order.Side, order.Quantity, currentPrice))
+func (g *GridStrategy) executeGridOrder(order *domain.GridOrder, asset *domain.Asset, currentPrice float64) error {
+	ctx, span, logger := startOrder(context.Background(), nil, order.Symbol, "GRID")
+	defer span.End()
+	span.SetAttributes(tracing.Int("grid_level", order.Level))
+	logger = logger.With("grid_level", order.Level)
+	logger.Info("Исполнение Grid ордера: %s %.8f @ %.8f", order.Side, order.Quantity, currentPrice)
 
 	// Выполняем сделку через биржу
-	orderInfo, err := g.exchange.PlaceOrder(order.Symbol, order.Side, order.Quantity)
+	orderInfo, err := g.exchange.PlaceOrderContext(ctx, order.Symbol, order.Side, order.Quantity)
+	observeOrder(ctx, logger, order.Symbol, order.Side, "GRID", order.Quantity, orderInfo, err)
 	if err != nil {
 		return fmt.Errorf("не удалось разместить ордер: %w", err)
 	}
 
 	executedPrice := currentPrice // Используем текущую цену как приближение для рыночного ордера
 
-	// Обновляем статус ордера
 	order.Status = "FILLED"
 	order.OrderID = orderInfo.OrderID
 	order.FilledQty = order.Quantity
 	order.FilledPrice = executedPrice
-	if err := g.storage.UpdateGridOrder(order); err != nil {
-		return fmt.Errorf("не удалось обновить ордер: %w", err)
-	}
 
-	// Сохраняем сделку в историю
-	trade := &storage.Trade{
+	trade := &domain.Trade{
 		Symbol:       order.Symbol,
 		Side:         order.Side,
 		Quantity:     order.Quantity,
@@ -185,27 +218,43 @@ func (g *GridStrategy) executeGridOrder(order *storage.GridOrder, asset *storage
 		StrategyType: "GRID",
 		GridLevel:    order.Level,
 		CreatedAt:    time.Now(),
+		TraceID:      span.TraceID(),
 	}
-	if err := g.storage.SaveTrade(trade); err != nil {
-		return fmt.Errorf("не удалось сохранить сделку: %w", err)
-	}
+	counter := g.counterOrder(order, asset, executedPrice)
 
-	// Обновляем баланс
-	if err := g.updateBalanceAfterGridTrade(order, executedPrice); err != nil {
-		return fmt.Errorf("не удалось обновить баланс: %w", err)
+	// Статус ордера, сделка, баланс и встречный ордер сохраняются в одной транзакции
+	if _, err := recordFill(ctx, g.storage, trade, func(tx domain.Repositories) error {
+		if err := tx.GridOrders().Update(ctx, order); err != nil {
+			return fmt.Errorf("не удалось обновить ордер: %w", err)
+		}
+		if err := tx.GridOrders().Save(ctx, counter); err != nil {
+			return fmt.Errorf("не удалось создать противоположный ордер: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return err
 	}
 
-	// Создаем противоположный ордер
-	if err := g.createCounterOrder(order, asset, executedPrice); err != nil {
-		return fmt.Errorf("не удалось создать противоположный ордер: %w", err)
+	if g.events != nil {
+		g.events.Publish(events.GridLevelFilled, events.GridFillData{
+			Symbol:   order.Symbol,
+			Level:    order.Level,
+			Side:     order.Side,
+			Quantity: order.Quantity,
+			Price:    executedPrice,
+			OrderID:  orderInfo.OrderID,
+		})
 	}
+	publishTrade(g.events, trade, "GRID")
+	publishGridOrder(g.events, counter)
 
+	logger.Info("Создан новый Grid ордер: уровень %d, %s %.8f @ %.8f", counter.Level, counter.Side, counter.Quantity, counter.Price)
 	utils.LogInfo(fmt.Sprintf("Grid ордер успешно исполнен: %s %s", order.Symbol, order.Side))
 	return nil
 }
 
-// createCounterOrder создает противоположный ордер после исполнения
-func (g *GridStrategy) createCounterOrder(executedOrder *storage.GridOrder, asset *storage.Asset, executedPrice float64) error {
+// counterOrder противоположный ордер после исполнения
+func (g *GridStrategy) counterOrder(executedOrder *domain.GridOrder, asset *domain.Asset, executedPrice float64) *domain.GridOrder {
 	var newSide string
 	var newPrice float64
 	var newLevel int
@@ -222,7 +271,7 @@ func (g *GridStrategy) createCounterOrder(executedOrder *storage.GridOrder, asse
 		newLevel = executedOrder.Level - 1
 	}
 
-	newOrder := &storage.GridOrder{
+	return &domain.GridOrder{
 		Symbol:    executedOrder.Symbol,
 		Level:     newLevel,
 		Side:      newSide,
@@ -231,61 +280,17 @@ func (g *GridStrategy) createCounterOrder(executedOrder *storage.GridOrder, asse
 		Status:    "PENDING",
 		CreatedAt: time.Now(),
 	}
-
-	if err := g.storage.SaveGridOrder(newOrder); err != nil {
-		return fmt.Errorf("не удалось создать новый ордер: %w", err)
-	}
-
-	utils.LogInfo(fmt.Sprintf("Создан новый Grid ордер: уровень %d, %s %.8f @ %.8f", newLevel, newSide, newOrder.Quantity, newPrice))
-	return nil
-}
-
-// updateBalanceAfterGridTrade обновляет баланс после исполнения Grid сделки
-func (g *GridStrategy) updateBalanceAfterGridTrade(order *storage.GridOrder, executedPrice float64) error {
-	balance, err := g.storage.GetBalance(order.Symbol)
-	if err != nil {
-		return err
-	}
-
-	if order.Side == "BUY" {
-		// При покупке увеличиваем количество и инвестиции
-		totalAmount := order.Quantity * executedPrice
-		newTotalQty := balance.TotalQuantity + order.Quantity
-		newInvested := balance.TotalInvested + totalAmount
-
-		// Пересчитываем среднюю цену входа
-		if newTotalQty > 0 {
-			balance.AvgEntryPrice = newInvested / newTotalQty
-		}
-
-		balance.TotalQuantity = newTotalQty
-		balance.AvailableQty = newTotalQty
-		balance.TotalInvested = newInvested
-	} else {
-		// При продаже уменьшаем количество и фиксируем прибыль
-		totalAmount := order.Quantity * executedPrice
-		balance.TotalQuantity -= order.Quantity
-		balance.AvailableQty = balance.TotalQuantity
-
-		// Рассчитываем прибыль от продажи
-		costBasis := order.Quantity * balance.AvgEntryPrice
-		profit := totalAmount - costBasis
-
-		balance.TotalSold += totalAmount
-		balance.RealizedProfit += profit
-	}
-
-	return g.storage.UpdateBalance(balance)
 }
 
 // CalculateGridMetrics рассчитывает метрики Grid стратегии
 func (g *GridStrategy) CalculateGridMetrics(symbol string) (map[string]interface{}, error) {
-	activeOrders, err := g.storage.GetActiveGridOrders(symbol)
+	ctx := context.Background()
+	activeOrders, err := g.storage.GridOrders().GetActive(ctx, symbol)
 	if err != nil {
 		return nil, err
 	}
 
-	balance, err := g.storage.GetBalance(symbol)
+	balance, err := g.storage.Balances().Get(ctx, symbol)
 	if err != nil {
 		return nil, err
 	}
@@ -328,13 +333,13 @@ func (g *GridStrategy) CalculateGridMetrics(symbol string) (map[string]interface
 }
 
 // RebalanceGrid перебалансирует сетку при сильном отклонении цены
-func (g *GridStrategy) RebalanceGrid(asset *storage.Asset) error {
+func (g *GridStrategy) RebalanceGrid(asset *domain.Asset) error {
 	currentPrice, err := g.exchange.GetCurrentPrice(asset.Symbol)
 	if err != nil {
 		return err
 	}
 
-	activeOrders, err := g.storage.GetActiveGridOrders(asset.Symbol)
+	activeOrders, err := g.storage.GridOrders().GetActive(context.Background(), asset.Symbol)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,68 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// recordFill сохраняет исполненную сделку и изменение баланса в одной транзакции.
+// extra выполняется в той же транзакции (Grid: статус исполненного и встречный ордер).
+// Баланс читается внутри транзакции с блокировкой строки. Возвращает реализованную прибыль продажи (0 для покупки)
+func recordFill(ctx context.Context, store domain.UnitOfWork, trade *domain.Trade, extra func(tx domain.Repositories) error) (profit float64, err error) {
+	err = store.WithinTx(ctx, func(tx domain.Repositories) error {
+		if err := tx.Trades().Save(ctx, trade); err != nil {
+			return fmt.Errorf("failed to save trade: %w", err)
+		}
+
+		balance, err := tx.Balances().GetForUpdate(ctx, trade.Symbol)
+		if err != nil {
+			return fmt.Errorf("failed to get balance: %w", err)
+		}
+		if balance == nil {
+			balance = &domain.Balance{Symbol: trade.Symbol}
+		}
+
+		if strings.EqualFold(trade.Side, "BUY") {
+			applyBuy(balance, trade.Quantity, trade.Amount)
+		} else {
+			profit = applySell(balance, trade.Quantity, trade.Amount)
+		}
+		if err := tx.Balances().Update(ctx, balance); err != nil {
+			return fmt.Errorf("failed to update balance: %w", err)
+		}
+
+		if extra != nil {
+			return extra(tx)
+		}
+		return nil
+	})
+	if err != nil {
+		// Транзакция откачена - ID сделки недействителен
+		trade.ID = 0
+		return 0, err
+	}
+	return profit, nil
+}
+
+// applyBuy увеличивает позицию и пересчитывает среднюю цену входа
+func applyBuy(balance *domain.Balance, quantity, amount float64) {
+	balance.TotalQuantity += quantity
+	balance.AvailableQty = balance.TotalQuantity
+	balance.TotalInvested += amount
+	if balance.TotalQuantity > 0 {
+		balance.AvgEntryPrice = balance.TotalInvested / balance.TotalQuantity
+	}
+}
+
+// applySell уменьшает позицию и фиксирует прибыль относительно средней цены входа
+func applySell(balance *domain.Balance, quantity, amount float64) float64 {
+	profit := amount - quantity*balance.AvgEntryPrice
+	balance.TotalQuantity -= quantity
+	balance.AvailableQty -= quantity
+	balance.TotalSold += amount
+	balance.RealizedProfit += profit
+	return profit
+}
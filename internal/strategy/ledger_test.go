@@ -0,0 +1,185 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// memoryLedger in-memory UnitOfWork: транзакция работает на копии и применяется только при успехе
+type memoryLedger struct {
+	trades   []domain.Trade
+	balances map[string]domain.Balance
+	grid     []domain.GridOrder
+}
+
+func newMemoryLedger() *memoryLedger {
+	return &memoryLedger{balances: make(map[string]domain.Balance)}
+}
+
+func (m *memoryLedger) clone() *memoryLedger {
+	c := &memoryLedger{
+		trades:   append([]domain.Trade(nil), m.trades...),
+		balances: make(map[string]domain.Balance, len(m.balances)),
+		grid:     append([]domain.GridOrder(nil), m.grid...),
+	}
+	for k, v := range m.balances {
+		c.balances[k] = v
+	}
+	return c
+}
+
+func (m *memoryLedger) WithinTx(ctx context.Context, fn func(tx domain.Repositories) error) error {
+	tx := m.clone()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	*m = *tx
+	return nil
+}
+
+func (m *memoryLedger) Trades() domain.TradeRepository         { return (*ledgerTrades)(m) }
+func (m *memoryLedger) Balances() domain.BalanceRepository     { return (*ledgerBalances)(m) }
+func (m *memoryLedger) GridOrders() domain.GridOrderRepository { return (*ledgerGrid)(m) }
+func (m *memoryLedger) Assets() domain.AssetRepository         { return nil }
+func (m *memoryLedger) PnL() domain.PnLRepository              { return nil }
+func (m *memoryLedger) Risk() domain.RiskRepository            { return nil }
+func (m *memoryLedger) Config() domain.ConfigRepository        { return nil }
+func (m *memoryLedger) Logs() domain.LogRepository             { return nil }
+func (m *memoryLedger) Candles() domain.CandleRepository       { return nil }
+
+type ledgerTrades memoryLedger
+
+func (r *ledgerTrades) Save(ctx context.Context, trade *domain.Trade) error {
+	trade.ID = int64(len(r.trades) + 1)
+	r.trades = append(r.trades, *trade)
+	return nil
+}
+
+func (r *ledgerTrades) GetRecent(ctx context.Context, symbol string, limit int) ([]domain.Trade, error) {
+	return r.trades, nil
+}
+
+func (r *ledgerTrades) GetAllRecent(ctx context.Context, limit int) ([]domain.Trade, error) {
+	return r.trades, nil
+}
+
+func (r *ledgerTrades) GetBetween(ctx context.Context, from, to time.Time) ([]domain.Trade, error) {
+	return r.trades, nil
+}
+
+func (r *ledgerTrades) Find(ctx context.Context, filter domain.TradeFilter) ([]domain.Trade, int, error) {
+	return r.trades, len(r.trades), nil
+}
+
+type ledgerBalances memoryLedger
+
+func (r *ledgerBalances) Get(ctx context.Context, symbol string) (*domain.Balance, error) {
+	balance, ok := r.balances[symbol]
+	if !ok {
+		return nil, nil
+	}
+	return &balance, nil
+}
+
+func (r *ledgerBalances) GetForUpdate(ctx context.Context, symbol string) (*domain.Balance, error) {
+	return r.Get(ctx, symbol)
+}
+
+func (r *ledgerBalances) GetAll(ctx context.Context) ([]domain.Balance, error) {
+	var all []domain.Balance
+	for _, b := range r.balances {
+		all = append(all, b)
+	}
+	return all, nil
+}
+
+func (r *ledgerBalances) Update(ctx context.Context, balance *domain.Balance) error {
+	r.balances[balance.Symbol] = *balance
+	return nil
+}
+
+type ledgerGrid memoryLedger
+
+func (r *ledgerGrid) Save(ctx context.Context, order *domain.GridOrder) error {
+	r.grid = append(r.grid, *order)
+	return nil
+}
+
+func (r *ledgerGrid) Update(ctx context.Context, order *domain.GridOrder) error {
+	return errors.New("grid order not found")
+}
+
+func (r *ledgerGrid) GetActive(ctx context.Context, symbol string) ([]domain.GridOrder, error) {
+	return r.grid, nil
+}
+
+func (r *ledgerGrid) CancelAll(ctx context.Context, symbol string) error {
+	r.grid = nil
+	return nil
+}
+
+func TestRecordFill(t *testing.T) {
+	ctx := context.Background()
+	ledger := newMemoryLedger()
+
+	// Две покупки: средняя цена взвешена по объему
+	for _, trade := range []*domain.Trade{
+		{Symbol: "BTCUSDT", Side: "BUY", Quantity: 1, Price: 100, Amount: 100},
+		{Symbol: "BTCUSDT", Side: "BUY", Quantity: 1, Price: 200, Amount: 200},
+	} {
+		if _, err := recordFill(ctx, ledger, trade, nil); err != nil {
+			t.Fatal(err)
+		}
+		if trade.ID == 0 {
+			t.Error("trade ID not set")
+		}
+	}
+	balance := ledger.balances["BTCUSDT"]
+	if balance.TotalQuantity != 2 || balance.AvgEntryPrice != 150 || balance.TotalInvested != 300 {
+		t.Fatalf("balance after buys = %+v", balance)
+	}
+
+	// Продажа половины по 250: прибыль (250-150)*1
+	profit, err := recordFill(ctx, ledger, &domain.Trade{Symbol: "BTCUSDT", Side: "SELL", Quantity: 1, Price: 250, Amount: 250}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(profit-100) > 1e-9 {
+		t.Errorf("profit = %v, want 100", profit)
+	}
+	balance = ledger.balances["BTCUSDT"]
+	if balance.AvailableQty != 1 || balance.TotalSold != 250 || balance.RealizedProfit != profit {
+		t.Errorf("balance after sell = %+v", balance)
+	}
+	if len(ledger.trades) != 3 {
+		t.Errorf("trades = %d, want 3", len(ledger.trades))
+	}
+}
+
+func TestRecordFillRollback(t *testing.T) {
+	ctx := context.Background()
+	ledger := newMemoryLedger()
+
+	// Ошибка обновления Grid ордера откатывает сделку и баланс
+	trade := &domain.Trade{Symbol: "ETHUSDT", Side: "BUY", Quantity: 2, Price: 10, Amount: 20}
+	_, err := recordFill(ctx, ledger, trade, func(tx domain.Repositories) error {
+		if err := tx.GridOrders().Save(ctx, &domain.GridOrder{Symbol: "ETHUSDT", Side: "SELL"}); err != nil {
+			return err
+		}
+		return tx.GridOrders().Update(ctx, &domain.GridOrder{ID: 42, Status: "FILLED"})
+	})
+	if err == nil {
+		t.Fatal("expected error from extra")
+	}
+	if trade.ID != 0 {
+		t.Errorf("trade ID = %d after rollback, want 0", trade.ID)
+	}
+	if len(ledger.trades) != 0 || len(ledger.balances) != 0 || len(ledger.grid) != 0 {
+		t.Errorf("rollback left state: trades=%d balances=%d grid=%d", len(ledger.trades), len(ledger.balances), len(ledger.grid))
+	}
+}
@@ -0,0 +1,183 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/exchange"
+)
+
+// ManualTrader ручные сделки по рынку (Telegram /buy, /sell и HTTP API)
+type ManualTrader struct {
+	storage  domain.UnitOfWork
+	exchange *exchange.BybitClient
+	events   EventPublisher
+}
+
+// ManualSell результат ручной продажи
+type ManualSell struct {
+	Trade   *domain.Trade
+	Percent float64
+	Profit  float64
+}
+
+func NewManualTrader(storage domain.UnitOfWork, exchange *exchange.BybitClient) *ManualTrader {
+	return &ManualTrader{
+		storage:  storage,
+		exchange: exchange,
+	}
+}
+
+// SetEventPublisher подключает шину событий (trade.filled)
+func (m *ManualTrader) SetEventPublisher(publisher EventPublisher) {
+	m.events = publisher
+}
+
+// Buy покупает symbol на amountUSD и обновляет баланс
+func (m *ManualTrader) Buy(ctx context.Context, symbol string, amountUSD float64) (*domain.Trade, error) {
+	if symbol == "" || amountUSD <= 0 {
+		return nil, fmt.Errorf("%w: symbol and positive amount required", domain.ErrInvalidInput)
+	}
+
+	ctx, span, logger := startOrder(ctx, nil, symbol, "MANUAL")
+	defer span.End()
+
+	limits, err := m.storage.Risk().GetLimits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get risk limits: %w", err)
+	}
+	if limits.EnableEmergencyStop {
+		return nil, domain.ErrEmergencyStop
+	}
+	if limits.MaxOrderSizeUSD > 0 && amountUSD > limits.MaxOrderSizeUSD {
+		return nil, fmt.Errorf("%w: order size %.2f exceeds max %.2f USDT",
+			domain.ErrRiskLimitExceeded, amountUSD, limits.MaxOrderSizeUSD)
+	}
+
+	usdtBalance, err := m.exchange.GetBalanceContext(ctx, "USDT")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get USDT balance: %w", err)
+	}
+	if usdtBalance < amountUSD {
+		return nil, fmt.Errorf("%w: have %.2f USDT, need %.2f", domain.ErrInsufficientBalance, usdtBalance, amountUSD)
+	}
+
+	currentPrice, err := m.exchange.GetPriceContext(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price: %w", err)
+	}
+
+	quantity := amountUSD / currentPrice
+
+	orderInfo, err := m.exchange.PlaceOrderContext(ctx, symbol, "BUY", quantity)
+	observeOrder(ctx, logger, symbol, "BUY", "MANUAL", quantity, orderInfo, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to place order: %w", err)
+	}
+
+	trade := &domain.Trade{
+		Symbol:       symbol,
+		Side:         "BUY",
+		Quantity:     quantity,
+		Price:        currentPrice,
+		Amount:       amountUSD,
+		OrderID:      orderInfo.OrderID,
+		Status:       "FILLED",
+		StrategyType: "MANUAL",
+		CreatedAt:    time.Now(),
+		TraceID:      span.TraceID(),
+	}
+
+	if _, err := recordFill(ctx, m.storage, trade, nil); err != nil {
+		return nil, fmt.Errorf("failed to save trade: %w", err)
+	}
+	publishTrade(m.events, trade, trade.StrategyType)
+
+	return trade, nil
+}
+
+// Quote объем и стоимость продажи percent% позиции без размещения ордера
+func (m *ManualTrader) Quote(ctx context.Context, symbol string, percent float64) (quantity, price float64, err error) {
+	if percent <= 0 || percent > 100 {
+		return 0, 0, fmt.Errorf("%w: percent must be between 1 and 100", domain.ErrInvalidInput)
+	}
+
+	balance, err := m.storage.Balances().Get(ctx, symbol)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get balance: %w", err)
+	}
+	if balance.AvailableQty <= 0 {
+		return 0, 0, fmt.Errorf("%w: no position in %s", domain.ErrNotFound, symbol)
+	}
+
+	price, err = m.exchange.GetPriceContext(ctx, symbol)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get price: %w", err)
+	}
+
+	return balance.AvailableQty * (percent / 100.0), price, nil
+}
+
+// Sell продает percent% доступной позиции и фиксирует прибыль в балансе
+func (m *ManualTrader) Sell(ctx context.Context, symbol string, percent float64) (*ManualSell, error) {
+	if percent <= 0 || percent > 100 {
+		return nil, fmt.Errorf("%w: percent must be between 1 and 100", domain.ErrInvalidInput)
+	}
+
+	ctx, span, logger := startOrder(ctx, nil, symbol, "MANUAL")
+	defer span.End()
+
+	limits, err := m.storage.Risk().GetLimits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get risk limits: %w", err)
+	}
+	if limits.EnableEmergencyStop {
+		return nil, domain.ErrEmergencyStop
+	}
+
+	balance, err := m.storage.Balances().Get(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+	if balance.AvailableQty <= 0 {
+		return nil, fmt.Errorf("%w: no position in %s", domain.ErrNotFound, symbol)
+	}
+
+	sellQuantity := balance.AvailableQty * (percent / 100.0)
+
+	currentPrice, err := m.exchange.GetPriceContext(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price: %w", err)
+	}
+
+	orderInfo, err := m.exchange.PlaceOrderContext(ctx, symbol, "SELL", sellQuantity)
+	observeOrder(ctx, logger, symbol, "SELL", "MANUAL", sellQuantity, orderInfo, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to place order: %w", err)
+	}
+
+	sellAmount := sellQuantity * currentPrice
+
+	trade := &domain.Trade{
+		Symbol:       symbol,
+		Side:         "SELL",
+		Quantity:     sellQuantity,
+		Price:        currentPrice,
+		Amount:       sellAmount,
+		OrderID:      orderInfo.OrderID,
+		Status:       "FILLED",
+		StrategyType: "MANUAL",
+		CreatedAt:    time.Now(),
+		TraceID:      span.TraceID(),
+	}
+
+	profit, err := recordFill(ctx, m.storage, trade, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save trade: %w", err)
+	}
+	publishTrade(m.events, trade, trade.StrategyType)
+
+	return &ManualSell{Trade: trade, Percent: percent, Profit: profit}, nil
+}
@@ -1,20 +1,22 @@
 package strategy
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/kirillm/dca-bot/internal/domain"
 	"github.com/kirillm/dca-bot/internal/exchange"
-	"github.com/kirillm/dca-bot/internal/storage"
+	"github.com/kirillm/dca-bot/internal/metrics"
 	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
 // PortfolioManager управляет портфелем и распределением капитала
 type PortfolioManager struct {
-	storage  *storage.PostgresStorage
+	storage  domain.Repositories
 	exchange *exchange.BybitClient
 }
 
-func NewPortfolioManager(storage *storage.PostgresStorage, exchange *exchange.BybitClient) *PortfolioManager {
+func NewPortfolioManager(storage domain.Repositories, exchange *exchange.BybitClient) *PortfolioManager {
 	return &PortfolioManager{
 		storage:  storage,
 		exchange: exchange,
@@ -23,10 +25,11 @@ func NewPortfolioManager(storage *storage.PostgresStorage, exchange *exchange.By
 
 // AllocateCapital распределяет капитал между активами
 func (p *PortfolioManager) AllocateCapital(totalCapital float64) error {
+	ctx := context.Background()
 	utils.LogInfo(fmt.Sprintf("Распределение капитала: %.2f USDT", totalCapital))
 
 	// Получаем все активные активы
-	assets, err := p.storage.GetEnabledAssets()
+	assets, err := p.storage.Assets().GetEnabled(ctx)
 	if err != nil {
 		return fmt.Errorf("не удалось получить активы: %w", err)
 	}
@@ -46,7 +49,7 @@ func (p *PortfolioManager) AllocateCapital(totalCapital float64) error {
 		capitalPerAsset := totalCapital / float64(len(assets))
 		for i := range assets {
 			assets[i].AllocatedCapital = capitalPerAsset
-			if err := p.storage.CreateOrUpdateAsset(&assets[i]); err != nil {
+			if err := p.storage.Assets().CreateOrUpdate(ctx, &assets[i]); err != nil {
 				utils.LogError(fmt.Sprintf("Не удалось обновить актив %s: %v", assets[i].Symbol, err))
 			}
 		}
@@ -56,7 +59,7 @@ func (p *PortfolioManager) AllocateCapital(totalCapital float64) error {
 		for i := range assets {
 			proportion := assets[i].AllocatedCapital / totalAllocated
 			assets[i].AllocatedCapital = totalCapital * proportion
-			if err := p.storage.CreateOrUpdateAsset(&assets[i]); err != nil {
+			if err := p.storage.Assets().CreateOrUpdate(ctx, &assets[i]); err != nil {
 				utils.LogError(fmt.Sprintf("Не удалось обновить актив %s: %v", assets[i].Symbol, err))
 			}
 		}
@@ -68,12 +71,13 @@ func (p *PortfolioManager) AllocateCapital(totalCapital float64) error {
 
 // GetPortfolioSummary возвращает сводку по портфелю
 func (p *PortfolioManager) GetPortfolioSummary() (map[string]interface{}, error) {
-	assets, err := p.storage.GetEnabledAssets()
+	ctx := context.Background()
+	assets, err := p.storage.Assets().GetEnabled(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	balances, err := p.storage.GetAllBalances()
+	balances, err := p.storage.Balances().GetAll(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -107,7 +111,7 @@ func (p *PortfolioManager) GetPortfolioSummary() (map[string]interface{}, error)
 
 		// Обновляем unrealized PnL в базе
 		balance.UnrealizedPnL = unrealizedPnL
-		if err := p.storage.UpdateBalance(&balance); err != nil {
+		if err := p.storage.Balances().Update(ctx, &balance); err != nil {
 			utils.LogError(fmt.Sprintf("Не удалось обновить баланс %s: %v", balance.Symbol, err))
 		}
 
@@ -117,7 +121,7 @@ func (p *PortfolioManager) GetPortfolioSummary() (map[string]interface{}, error)
 		totalUnrealizedPnL += unrealizedPnL
 
 		// Найти соответствующий актив
-		var assetInfo *storage.Asset
+		var assetInfo *domain.Asset
 		for _, asset := range assets {
 			if asset.Symbol == balance.Symbol {
 				assetInfo = &asset
@@ -169,6 +173,7 @@ func (p *PortfolioManager) GetPortfolioSummary() (map[string]interface{}, error)
 
 // RebalancePortfolio перебалансирует портфель
 func (p *PortfolioManager) RebalancePortfolio() error {
+	ctx := context.Background()
 	utils.LogInfo("Начало ребалансировки портфеля")
 
 	summary, err := p.GetPortfolioSummary()
@@ -177,7 +182,7 @@ func (p *PortfolioManager) RebalancePortfolio() error {
 	}
 
 	totalCurrentValue := summary["total_current_value"].(float64)
-	assets, err := p.storage.GetEnabledAssets()
+	assets, err := p.storage.Assets().GetEnabled(ctx)
 	if err != nil {
 		return err
 	}
@@ -194,7 +199,7 @@ func (p *PortfolioManager) RebalancePortfolio() error {
 
 	// Для каждого актива проверяем отклонение от целевого распределения
 	for _, asset := range assets {
-		balance, err := p.storage.GetBalance(asset.Symbol)
+		balance, err := p.storage.Balances().Get(ctx, asset.Symbol)
 		if err != nil {
 			utils.LogError(fmt.Sprintf("Не удалось получить баланс для %s: %v", asset.Symbol, err))
 			continue
@@ -228,12 +233,13 @@ func (p *PortfolioManager) RebalancePortfolio() error {
 
 // GetAssetAllocation возвращает распределение портфеля
 func (p *PortfolioManager) GetAssetAllocation() ([]map[string]interface{}, error) {
-	assets, err := p.storage.GetEnabledAssets()
+	ctx := context.Background()
+	assets, err := p.storage.Assets().GetEnabled(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	balances, err := p.storage.GetAllBalances()
+	balances, err := p.storage.Balances().GetAll(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -250,7 +256,7 @@ func (p *PortfolioManager) GetAssetAllocation() ([]map[string]interface{}, error
 	}
 
 	for _, asset := range assets {
-		balance, err := p.storage.GetBalance(asset.Symbol)
+		balance, err := p.storage.Balances().Get(ctx, asset.Symbol)
 		if err != nil {
 			continue
 		}
@@ -285,9 +291,10 @@ func (p *PortfolioManager) GetAssetAllocation() ([]map[string]interface{}, error
 
 // CalculateDailyPnL рассчитывает и сохраняет дневной PnL snapshot
 func (p *PortfolioManager) CalculateDailyPnL() error {
+	ctx := context.Background()
 	utils.LogInfo("Создание дневного PnL snapshot")
 
-	balances, err := p.storage.GetAllBalances()
+	balances, err := p.storage.Balances().GetAll(ctx)
 	if err != nil {
 		return err
 	}
@@ -312,7 +319,7 @@ func (p *PortfolioManager) CalculateDailyPnL() error {
 			returnPercent = (totalPnL / balance.TotalInvested) * 100
 		}
 
-		pnlSnapshot := &storage.PnLHistory{
+		pnlSnapshot := &domain.PnLHistory{
 			Symbol:        balance.Symbol,
 			RealizedPnL:   balance.RealizedProfit,
 			UnrealizedPnL: unrealizedPnL,
@@ -323,9 +330,10 @@ func (p *PortfolioManager) CalculateDailyPnL() error {
 			SnapshotType:  "DAILY",
 		}
 
-		if err := p.storage.SavePnLSnapshot(pnlSnapshot); err != nil {
+		if err := p.storage.PnL().SaveSnapshot(ctx, pnlSnapshot); err != nil {
 			utils.LogError(fmt.Sprintf("Не удалось сохранить PnL snapshot для %s: %v", balance.Symbol, err))
 		}
+		metrics.ObservePosition(balance.Symbol, currentValue, balance.RealizedProfit, unrealizedPnL)
 	}
 
 	utils.LogInfo("Дневной PnL snapshot создан")
@@ -334,8 +342,9 @@ func (p *PortfolioManager) CalculateDailyPnL() error {
 
 // GetPerformanceMetrics возвращает метрики производительности
 func (p *PortfolioManager) GetPerformanceMetrics(symbol string, days int) (map[string]interface{}, error) {
+	ctx := context.Background()
 	// Получаем историю PnL
-	history, err := p.storage.GetPnLHistory(symbol, "DAILY", days)
+	history, err := p.storage.PnL().GetHistory(ctx, symbol, "DAILY", days)
 	if err != nil {
 		return nil, err
 	}
@@ -1,21 +1,22 @@
 package strategy
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/kirillm/dca-bot/internal/domain"
 	"github.com/kirillm/dca-bot/internal/exchange"
-	"github.com/kirillm/dca-bot/internal/storage"
 	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
 // RiskManager управляет рисками и лимитами
 type RiskManager struct {
-	storage  *storage.PostgresStorage
+	storage  domain.UnitOfWork
 	exchange *exchange.BybitClient
 }
 
-func NewRiskManager(storage *storage.PostgresStorage, exchange *exchange.BybitClient) *RiskManager {
+func NewRiskManager(storage domain.UnitOfWork, exchange *exchange.BybitClient) *RiskManager {
 	return &RiskManager{
 		storage:  storage,
 		exchange: exchange,
@@ -23,12 +24,13 @@ func NewRiskManager(storage *storage.PostgresStorage, exchange *exchange.BybitCl
 }
 
 // CheckStopLoss проверяет условия stop-loss
-func (r *RiskManager) CheckStopLoss(asset *storage.Asset) (bool, error) {
+func (r *RiskManager) CheckStopLoss(asset *domain.Asset) (bool, error) {
+	ctx := context.Background()
 	if asset.StopLossPercent == 0 {
 		return false, nil // Stop-loss не установлен
 	}
 
-	balance, err := r.storage.GetBalance(asset.Symbol)
+	balance, err := r.storage.Balances().Get(ctx, asset.Symbol)
 	if err != nil {
 		return false, err
 	}
@@ -62,20 +64,22 @@ func (r *RiskManager) CheckStopLoss(asset *storage.Asset) (bool, error) {
 }
 
 // executeStopLoss исполняет stop-loss
-func (r *RiskManager) executeStopLoss(asset *storage.Asset, balance *storage.Balance, currentPrice float64) error {
-	utils.LogWarn(fmt.Sprintf("Исполнение Stop-Loss для %s: продажа %.8f по цене %.8f",
-		asset.Symbol, balance.AvailableQty, currentPrice))
+func (r *RiskManager) executeStopLoss(asset *domain.Asset, balance *domain.Balance, currentPrice float64) error {
+	ctx, span, logger := startOrder(context.Background(), nil, asset.Symbol, "STOP_LOSS")
+	defer span.End()
+	logger.Warn("Исполнение Stop-Loss: продажа %.8f по цене %.8f", balance.AvailableQty, currentPrice)
 
 	// Размещаем рыночный ордер на продажу
-	orderInfo, err := r.exchange.PlaceOrder(asset.Symbol, "SELL", balance.AvailableQty)
+	orderInfo, err := r.exchange.PlaceOrderContext(ctx, asset.Symbol, "SELL", balance.AvailableQty)
+	observeOrder(ctx, logger, asset.Symbol, "SELL", "STOP_LOSS", balance.AvailableQty, orderInfo, err)
 	if err != nil {
 		return fmt.Errorf("не удалось разместить stop-loss ордер: %w", err)
 	}
 
 	executedPrice := currentPrice // Используем текущую цену для рыночного ордера
 
-	// Сохраняем сделку
-	trade := &storage.Trade{
+	// Сохраняем сделку и закрываем позицию в одной транзакции
+	trade := &domain.Trade{
 		Symbol:       asset.Symbol,
 		Side:         "SELL",
 		Quantity:     balance.AvailableQty,
@@ -85,36 +89,25 @@ func (r *RiskManager) executeStopLoss(asset *storage.Asset, balance *storage.Bal
 		Status:       "FILLED",
 		StrategyType: "STOP_LOSS",
 		CreatedAt:    time.Now(),
+		TraceID:      span.TraceID(),
 	}
-	if err := r.storage.SaveTrade(trade); err != nil {
+	loss, err := recordFill(ctx, r.storage, trade, nil)
+	if err != nil {
 		return fmt.Errorf("не удалось сохранить сделку: %w", err)
 	}
 
-	// Обновляем баланс
-	totalAmount := balance.AvailableQty * executedPrice
-	costBasis := balance.AvailableQty * balance.AvgEntryPrice
-	loss := totalAmount - costBasis
-
-	balance.TotalQuantity = 0
-	balance.AvailableQty = 0
-	balance.TotalSold += totalAmount
-	balance.RealizedProfit += loss
-
-	if err := r.storage.UpdateBalance(balance); err != nil {
-		return fmt.Errorf("не удалось обновить баланс: %w", err)
-	}
-
 	utils.LogInfo(fmt.Sprintf("Stop-Loss исполнен для %s: убыток %.2f USDT", asset.Symbol, loss))
 	return nil
 }
 
 // CheckTakeProfit проверяет условия take-profit
-func (r *RiskManager) CheckTakeProfit(asset *storage.Asset) (bool, error) {
+func (r *RiskManager) CheckTakeProfit(asset *domain.Asset) (bool, error) {
+	ctx := context.Background()
 	if asset.TakeProfitPercent == 0 {
 		return false, nil // Take-profit не установлен
 	}
 
-	balance, err := r.storage.GetBalance(asset.Symbol)
+	balance, err := r.storage.Balances().Get(ctx, asset.Symbol)
 	if err != nil {
 		return false, err
 	}
@@ -148,20 +141,22 @@ func (r *RiskManager) CheckTakeProfit(asset *storage.Asset) (bool, error) {
 }
 
 // executeTakeProfit исполняет take-profit
-func (r *RiskManager) executeTakeProfit(asset *storage.Asset, balance *storage.Balance, currentPrice float64) error {
-	utils.LogInfo(fmt.Sprintf("Исполнение Take-Profit для %s: продажа %.8f по цене %.8f",
-		asset.Symbol, balance.AvailableQty, currentPrice))
+func (r *RiskManager) executeTakeProfit(asset *domain.Asset, balance *domain.Balance, currentPrice float64) error {
+	ctx, span, logger := startOrder(context.Background(), nil, asset.Symbol, "TAKE_PROFIT")
+	defer span.End()
+	logger.Info("Исполнение Take-Profit: продажа %.8f по цене %.8f", balance.AvailableQty, currentPrice)
 
 	// Размещаем рыночный ордер на продажу
-	orderInfo, err := r.exchange.PlaceOrder(asset.Symbol, "SELL", balance.AvailableQty)
+	orderInfo, err := r.exchange.PlaceOrderContext(ctx, asset.Symbol, "SELL", balance.AvailableQty)
+	observeOrder(ctx, logger, asset.Symbol, "SELL", "TAKE_PROFIT", balance.AvailableQty, orderInfo, err)
 	if err != nil {
 		return fmt.Errorf("не удалось разместить take-profit ордер: %w", err)
 	}
 
 	executedPrice := currentPrice // Используем текущую цену для рыночного ордера
 
-	// Сохраняем сделку
-	trade := &storage.Trade{
+	// Сохраняем сделку и закрываем позицию в одной транзакции
+	trade := &domain.Trade{
 		Symbol:       asset.Symbol,
 		Side:         "SELL",
 		Quantity:     balance.AvailableQty,
@@ -171,32 +166,21 @@ func (r *RiskManager) executeTakeProfit(asset *storage.Asset, balance *storage.B
 		Status:       "FILLED",
 		StrategyType: "TAKE_PROFIT",
 		CreatedAt:    time.Now(),
+		TraceID:      span.TraceID(),
 	}
-	if err := r.storage.SaveTrade(trade); err != nil {
+	profit, err := recordFill(ctx, r.storage, trade, nil)
+	if err != nil {
 		return fmt.Errorf("не удалось сохранить сделку: %w", err)
 	}
 
-	// Обновляем баланс
-	totalAmount := balance.AvailableQty * executedPrice
-	costBasis := balance.AvailableQty * balance.AvgEntryPrice
-	profit := totalAmount - costBasis
-
-	balance.TotalQuantity = 0
-	balance.AvailableQty = 0
-	balance.TotalSold += totalAmount
-	balance.RealizedProfit += profit
-
-	if err := r.storage.UpdateBalance(balance); err != nil {
-		return fmt.Errorf("не удалось обновить баланс: %w", err)
-	}
-
 	utils.LogInfo(fmt.Sprintf("Take-Profit исполнен для %s: прибыль %.2f USDT", asset.Symbol, profit))
 	return nil
 }
 
 // CheckRiskLimits проверяет глобальные лимиты риска
 func (r *RiskManager) CheckRiskLimits() (bool, string, error) {
-	limits, err := r.storage.GetRiskLimits()
+	ctx := context.Background()
+	limits, err := r.storage.Risk().GetLimits(ctx)
 	if err != nil {
 		return false, "", err
 	}
@@ -230,7 +214,8 @@ func (r *RiskManager) CheckRiskLimits() (bool, string, error) {
 
 // calculateTotalExposure рассчитывает общую экспозицию
 func (r *RiskManager) calculateTotalExposure() (float64, error) {
-	balances, err := r.storage.GetAllBalances()
+	ctx := context.Background()
+	balances, err := r.storage.Balances().GetAll(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -250,7 +235,8 @@ func (r *RiskManager) calculateTotalExposure() (float64, error) {
 
 // calculateDailyLoss рассчитывает дневные убытки
 func (r *RiskManager) calculateDailyLoss() (float64, error) {
-	balances, err := r.storage.GetAllBalances()
+	ctx := context.Background()
+	balances, err := r.storage.Balances().GetAll(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -269,7 +255,8 @@ func (r *RiskManager) calculateDailyLoss() (float64, error) {
 
 // ValidateOrderSize проверяет размер ордера
 func (r *RiskManager) ValidateOrderSize(symbol string, orderSizeUSD float64) (bool, error) {
-	limits, err := r.storage.GetRiskLimits()
+	ctx := context.Background()
+	limits, err := r.storage.Risk().GetLimits(ctx)
 	if err != nil {
 		return false, err
 	}
@@ -279,7 +266,7 @@ func (r *RiskManager) ValidateOrderSize(symbol string, orderSizeUSD float64) (bo
 	}
 
 	// Проверяем размер позиции
-	balance, err := r.storage.GetBalance(symbol)
+	balance, err := r.storage.Balances().Get(ctx, symbol)
 	if err != nil {
 		return false, err
 	}
@@ -301,6 +288,7 @@ func (r *RiskManager) ValidateOrderSize(symbol string, orderSizeUSD float64) (bo
 
 // MonitorAllRisks проверяет все риски для всех активов
 func (r *RiskManager) MonitorAllRisks() error {
+	ctx := context.Background()
 	// Проверяем глобальные лимиты
 	ok, message, err := r.CheckRiskLimits()
 	if err != nil {
@@ -312,7 +300,7 @@ func (r *RiskManager) MonitorAllRisks() error {
 	}
 
 	// Получаем все активные активы
-	assets, err := r.storage.GetEnabledAssets()
+	assets, err := r.storage.Assets().GetEnabled(ctx)
 	if err != nil {
 		return err
 	}
@@ -328,7 +316,7 @@ func (r *RiskManager) MonitorAllRisks() error {
 		if stopLossTriggered {
 			utils.LogWarn(fmt.Sprintf("Stop-Loss сработал для %s, актив деактивирован", asset.Symbol))
 			// Опционально: деактивируем актив после stop-loss
-			if err := r.storage.DisableAsset(asset.Symbol); err != nil {
+			if err := r.storage.Assets().Disable(ctx, asset.Symbol); err != nil {
 				utils.LogError(fmt.Sprintf("Не удалось деактивировать актив %s: %v", asset.Symbol, err))
 			}
 			continue
@@ -350,13 +338,14 @@ func (r *RiskManager) MonitorAllRisks() error {
 
 // SetEmergencyStop устанавливает флаг экстренной остановки
 func (r *RiskManager) SetEmergencyStop(enabled bool) error {
-	limits, err := r.storage.GetRiskLimits()
+	ctx := context.Background()
+	limits, err := r.storage.Risk().GetLimits(ctx)
 	if err != nil {
 		return err
 	}
 
 	limits.EnableEmergencyStop = enabled
-	if err := r.storage.UpdateRiskLimits(limits); err != nil {
+	if err := r.storage.Risk().UpdateLimits(ctx, limits); err != nil {
 		return err
 	}
 
@@ -371,7 +360,8 @@ func (r *RiskManager) SetEmergencyStop(enabled bool) error {
 
 // GetRiskStatus возвращает текущий статус рисков
 func (r *RiskManager) GetRiskStatus() (map[string]interface{}, error) {
-	limits, err := r.storage.GetRiskLimits()
+	ctx := context.Background()
+	limits, err := r.storage.Risk().GetLimits(ctx)
 	if err != nil {
 		return nil, err
 	}
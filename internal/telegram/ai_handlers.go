@@ -3,98 +3,71 @@ package telegram
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/kirillm/dca-bot/internal/ai"
-	"github.com/kirillm/dca-bot/internal/ai/agents"
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
 // ==================== STAGE 5: Hybrid AI Handlers ====================
 
-// SetAIAgents устанавливает AI агенты (Stage 5)
-func (b *Bot) SetAIAgents(
-	agentRouter *agents.AgentRouter,
-	chatAgent *agents.ChatAgent,
-	analysisAgent *agents.AnalysisAgent,
-	decisionAgent *agents.DecisionAgent,
-	actionExecutor *ai.ActionExecutor,
-) {
-	b.agentRouter = agentRouter
-	b.chatAgent = chatAgent
-	b.analysisAgent = analysisAgent
-	b.decisionAgent = decisionAgent
-	b.actionExecutor = actionExecutor
-	b.logger.Info("Stage 5 AI agents configured")
+// NewsSource interface for recent news signals
+type NewsSource interface {
+	GetRecent(limit int) ([]domain.NewsSignal, error)
 }
 
-// handleAIChat обрабатывает сообщение через ChatAgent
-func (b *Bot) handleAIChat(text string) {
-	if b.agentRouter == nil {
-		// Fallback на legacy AI client
-		b.handleAIMessage(text)
-		return
-	}
-
-	b.logger.Info("[AI Chat] User message: %s", text)
-
-	// Получаем контекст
-	contextInfo := b.buildContextString()
-
-	// Обрабатываем через AgentRouter
-	response, actions, err := b.agentRouter.Process(
-		context.Background(),
-		text,
-		contextInfo,
-	)
-
+// BuildDecisionRequest собирает свежий контекст для DecisionAgent (портфель, рынок, новости, лимиты)
+func (h *Handlers) BuildDecisionRequest(ctx context.Context) (ai.DecisionRequest, error) {
+	portfolio, err := h.buildPortfolioSnapshot()
 	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ AI error: %v", err))
-		return
+		return ai.DecisionRequest{}, fmt.Errorf("error building portfolio: %w", err)
 	}
 
-	// Выполняем действия через ActionExecutor
-	for _, action := range actions {
-		result, err := b.actionExecutor.ExecuteAction(action)
-		if err != nil {
-			b.logger.Error("Failed to execute AI action %s: %v", action.Type, err)
-			response += fmt.Sprintf("\n❌ Action %s failed: %v", action.Type, err)
-		} else {
-			response += "\n" + result
-		}
+	req := ai.DecisionRequest{
+		CurrentPortfolio: portfolio,
+		MarketConditions: h.buildMarketConditions(),
+		RecentNews:       h.buildNewsSignals(),
+		RiskLimits:       h.buildRiskLimits(),
 	}
-
-	// Отправляем ответ
-	if response != "" {
-		b.SendMessage(response)
+	if h.decisionAgent != nil {
+		req.Mode = h.decisionAgent.GetMode()
 	}
+	return req, nil
 }
 
-// handleAIAnalysis запрашивает анализ через AnalysisAgent
-func (b *Bot) handleAIAnalysis(symbol string) {
-	if b.analysisAgent == nil {
-		// Fallback на legacy
-		b.handleAnalysis()
-		return
+// HandleAIAnalysis обрабатывает команды /analysis и /ai_analyze [SYMBOL]
+func (h *Handlers) HandleAIAnalysis(ctx context.Context, args *CommandArgs) (string, error) {
+	if h.analysisAgent == nil && h.aiClient == nil {
+		return h.notConfigured("AnalysisAgent"), nil
 	}
 
+	symbol := args.Symbol
 	if symbol == "" {
-		symbol = "BTCUSDT"
+		symbol = h.defaultSymbol
 	}
 
-	b.logger.Info("[AI Analysis] Symbol: %s", symbol)
-
-	// Получаем данные
-	price, err := b.exchange.GetPrice(symbol)
+	price, err := h.exchange.GetCurrentPrice(symbol)
 	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Error getting price: %v", err))
-		return
+		return "", fmt.Errorf("failed to get price for %s: %w", symbol, err)
 	}
 
-	balance, err := b.storage.GetBalance(symbol)
+	balance, err := h.storage.Balances().Get(ctx, symbol)
 	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Error getting balance: %v", err))
-		return
+		return "", fmt.Errorf("failed to get balance for %s: %w", symbol, err)
 	}
 
+	// Legacy fallback без AnalysisAgent
+	if h.analysisAgent == nil {
+		analysis, err := h.aiClient.GetMarketAnalysis(symbol, price, balance.AvgEntryPrice)
+		if err != nil {
+			return "", fmt.Errorf("AI analysis failed: %w", err)
+		}
+		return fmt.Sprintf("🧠 %s:\n\n%s", h.formatter.T("ai_analysis"), analysis), nil
+	}
+
+	utils.LogInfo(fmt.Sprintf("[AI Analysis] Symbol: %s", symbol))
+
 	// Формируем контекст для AnalysisAgent
 	contextInfo := fmt.Sprintf(
 		"Symbol: %s\nCurrent price: %.2f USDT\nAverage entry: %.2f USDT\nQuantity: %.8f",
@@ -104,144 +77,113 @@ func (b *Bot) handleAIAnalysis(symbol string) {
 		balance.TotalQuantity,
 	)
 
-	// Запрашиваем анализ
-	b.SendMessage("🤔 Analyzing market with local AI...")
-
-	analysis, _, err := b.analysisAgent.Process(
-		context.Background(),
-		fmt.Sprintf("Проанализируй %s", symbol),
-		contextInfo,
-	)
+	// Добавляем технические индикаторы
+	if h.marketData != nil {
+		if ind, err := h.marketData.GetIndicators(symbol); err != nil {
+			utils.LogWarn(fmt.Sprintf("Failed to get indicators for %s: %v", symbol, err))
+		} else {
+			contextInfo += "\n" + ind.Format()
+		}
+	}
 
+	analysis, _, err := h.analysisAgent.Process(ctx, fmt.Sprintf("Проанализируй %s", symbol), contextInfo)
 	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Analysis failed: %v", err))
-		return
+		return "", fmt.Errorf("analysis failed: %w", err)
 	}
 
-	b.SendMessage(fmt.Sprintf("🧠 AI Analysis:\n\n%s", analysis))
+	return fmt.Sprintf("🧠 %s:\n\n%s", h.formatter.T("ai_analysis"), analysis), nil
 }
 
-// handleAIDecision запрашивает стратегическое решение через DecisionAgent
-func (b *Bot) handleAIDecision() {
-	if b.decisionAgent == nil {
-		b.SendMessage("⚠️ DecisionAgent not configured. Check Cloud AI settings.")
-		return
+// HandleAIDecision обрабатывает команду /ai_decision
+func (h *Handlers) HandleAIDecision(ctx context.Context, args *CommandArgs) (string, error) {
+	if h.decisionAgent == nil {
+		return h.notConfigured("DecisionAgent"), nil
 	}
 
-	b.logger.Info("[AI Decision] Requesting strategic decision...")
-	b.SendMessage("🤖 Requesting strategic AI decision (cloud model)...")
+	utils.LogInfo("[AI Decision] Requesting strategic decision...")
 
-	// Собираем данные для DecisionRequest
-	portfolio, err := b.buildPortfolioSnapshot()
+	req, err := h.BuildDecisionRequest(ctx)
 	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Error building portfolio: %v", err))
-		return
+		return "", err
 	}
 
-	market := b.buildMarketConditions()
-	news := b.buildNewsSignals()
-	risks := b.buildRiskLimits()
-
-	// Создаем запрос
-	req := ai.DecisionRequest{
-		CurrentPortfolio: portfolio,
-		MarketConditions: market,
-		RecentNews:       news,
-		RiskLimits:       risks,
-		Mode:             b.decisionAgent.GetMode(),
-	}
-
-	// Запрашиваем решение
-	decision, err := b.decisionAgent.RequestDecision(context.Background(), req)
+	decision, err := h.decisionAgent.RequestDecision(ctx, req)
 	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Decision failed: %v", err))
-		return
+		return "", fmt.Errorf("decision failed: %w", err)
 	}
 
-	// Форматируем и отправляем решение
-	formattedDecision := b.decisionAgent.FormatDecision(decision)
-	b.SendMessage(formattedDecision)
+	var sb strings.Builder
+	sb.WriteString(h.decisionAgent.FormatDecision(decision))
 
 	// Выполняем действия (если режим не shadow)
-	if b.decisionAgent.GetMode() != "shadow" && len(decision.Actions) > 0 {
-		b.SendMessage("\n🔄 Executing AI decisions...")
-
-		for _, action := range decision.Actions {
-			result, err := b.actionExecutor.ExecuteAction(ai.AIAction{
-				Type:       action.Type,
-				Parameters: action.Parameters,
-			})
-
-			if err != nil {
-				b.SendMessage(fmt.Sprintf("❌ Action failed: %v", err))
-			} else {
-				b.SendMessage(fmt.Sprintf("✅ %s", result))
-			}
+	if h.decisionAgent.GetMode() == "shadow" || len(decision.Actions) == 0 {
+		return sb.String(), nil
+	}
+	if h.actionExecutor == nil {
+		return "", fmt.Errorf("action executor %s", h.formatter.T("not_configured"))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n\n🔄 %s:\n", h.formatter.T("ai_decision_actions")))
+	for _, action := range decision.Actions {
+		result, err := h.actionExecutor.ExecuteAction(ai.AIAction{
+			Type:       action.Type,
+			Parameters: action.Parameters,
+		})
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("❌ %s: %v\n", h.formatter.T("action_failed"), err))
+		} else {
+			sb.WriteString(fmt.Sprintf("✅ %s\n", result))
 		}
 	}
+
+	return sb.String(), nil
 }
 
-// handleAIMetrics показывает метрики AgentRouter
-func (b *Bot) handleAIMetrics() {
-	if b.agentRouter == nil {
-		b.SendMessage("⚠️ AgentRouter not configured")
-		return
+// HandleAIMetrics обрабатывает команду /ai_metrics
+func (h *Handlers) HandleAIMetrics(ctx context.Context, args *CommandArgs) (string, error) {
+	if h.agentRouter == nil {
+		return h.notConfigured("AgentRouter"), nil
 	}
 
-	metrics := b.agentRouter.FormatMetrics()
-	b.SendMessage(metrics)
+	return h.agentRouter.FormatMetrics(), nil
 }
 
-// handleAIMode показывает или изменяет режим DecisionAgent
-func (b *Bot) handleAIMode(args string) {
-	if b.decisionAgent == nil {
-		b.SendMessage("⚠️ DecisionAgent not configured")
-		return
+// HandleAIMode обрабатывает команду /ai_mode [shadow|pilot|full]
+func (h *Handlers) HandleAIMode(ctx context.Context, args *CommandArgs) (string, error) {
+	if h.decisionAgent == nil {
+		return h.notConfigured("DecisionAgent"), nil
 	}
 
-	// Показываем текущий режим
-	if args == "" {
-		currentMode := b.decisionAgent.GetMode()
-		message := fmt.Sprintf(
-			"🤖 AI Decision Mode\n\n"+
-				"Current: %s\n\n"+
-				"Available modes:\n"+
-				"• shadow - AI decides but doesn't execute\n"+
-				"• pilot - Conservative limits (50%%)\n"+
-				"• full - Full autonomy\n\n"+
-				"Commands:\n"+
-				"/ai_mode shadow\n"+
-				"/ai_mode pilot\n"+
-				"/ai_mode full",
-			currentMode,
-		)
-		b.SendMessage(message)
-		return
+	if args.Action == "" {
+		return h.formatter.FormatMode("ai_decision_mode", h.decisionAgent.GetMode(), nil), nil
 	}
 
-	// Изменяем режим
-	if err := b.decisionAgent.SetMode(args); err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Invalid mode: %v", err))
-		return
+	// Права на смену режима проверяет Router. Через общий ModeController, если подключен
+	applied, err := h.decisionAgent.RequestMode(args.Action, args.UserID)
+	if err != nil {
+		return "", err
 	}
-
-	b.SendMessage(fmt.Sprintf("✅ AI Decision mode changed to: %s", args))
+	return h.formatModeResult(args.Action, applied), nil
 }
 
-// ==================== Helper functions ====================
-
-// buildContextString строит контекст для AI
-func (b *Bot) buildContextString() string {
-	dcaStatus, _ := b.dcaStrategy.GetStatus()
-	autoSellStatus, _ := b.autoSell.GetStatus()
+// HandleForget обрабатывает команду /forget
+func (h *Handlers) HandleForget(ctx context.Context, args *CommandArgs) (string, error) {
+	if h.memory == nil {
+		return h.notConfigured("Conversation memory"), nil
+	}
 
-	return fmt.Sprintf("%s\n\n%s", dcaStatus, autoSellStatus)
+	if err := h.memory.Forget(ctx, args.UserID); err != nil {
+		return "", err
+	}
+	return "🧹 " + h.formatter.T("memory_cleared"), nil
 }
 
+// ==================== Helper functions ====================
+
 // buildPortfolioSnapshot создает снимок портфеля
-func (b *Bot) buildPortfolioSnapshot() (ai.PortfolioSnapshot, error) {
-	// Получаем все балансы
-	balances, err := b.storage.GetAllBalances()
+func (h *Handlers) buildPortfolioSnapshot() (ai.PortfolioSnapshot, error) {
+	ctx := context.Background()
+	balances, err := h.storage.Balances().GetAll(ctx)
 	if err != nil {
 		return ai.PortfolioSnapshot{}, err
 	}
@@ -254,8 +196,7 @@ func (b *Bot) buildPortfolioSnapshot() (ai.PortfolioSnapshot, error) {
 			continue
 		}
 
-		// Получаем текущую цену
-		currentPrice, err := b.exchange.GetPrice(bal.Symbol)
+		currentPrice, err := h.exchange.GetCurrentPrice(bal.Symbol)
 		if err != nil {
 			continue
 		}
@@ -299,31 +240,48 @@ func (b *Bot) buildPortfolioSnapshot() (ai.PortfolioSnapshot, error) {
 }
 
 // buildMarketConditions собирает рыночные данные
-func (b *Bot) buildMarketConditions() ai.MarketData {
-	// Получаем цену BTC
-	btcPrice, _ := b.exchange.GetPrice("BTCUSDT")
+func (h *Handlers) buildMarketConditions() ai.MarketData {
+	btcPrice, _ := h.exchange.GetCurrentPrice("BTCUSDT")
 
-	// TODO: Получить изменение за 24ч и волатильность
-	// Пока используем заглушки
-	return ai.MarketData{
+	market := ai.MarketData{
 		BTCPrice:        btcPrice,
-		BTCChange24h:    0.0, // TODO: реализовать
 		MarketSentiment: "neutral",
-		Volatility:      2.0, // TODO: реализовать
 	}
+
+	// Изменение за 24ч, волатильность и тренд
+	if h.marketData != nil {
+		if ind, err := h.marketData.GetIndicators("BTCUSDT"); err != nil {
+			utils.LogWarn(fmt.Sprintf("Failed to get BTC indicators: %v", err))
+		} else {
+			market.BTCChange24h = ind.Change24h
+			market.Volatility = ind.Volatility
+			market.MarketSentiment = ind.Trend
+		}
+	}
+
+	return market
 }
 
 // buildNewsSignals собирает новостные сигналы
-func (b *Bot) buildNewsSignals() []ai.NewsSignal {
-	// TODO: Интеграция с NewsSignalRepository
-	// Пока возвращаем пустой массив
-	return []ai.NewsSignal{}
+func (h *Handlers) buildNewsSignals() []ai.NewsSignal {
+	if h.newsSource == nil {
+		return []ai.NewsSignal{}
+	}
+
+	// Ручной запрос не помечает новости обработанными - это делает orchestrator
+	signals, err := h.newsSource.GetRecent(10)
+	if err != nil {
+		utils.LogWarn(fmt.Sprintf("Failed to get news signals: %v", err))
+		return []ai.NewsSignal{}
+	}
+
+	return ai.NewsSignalsFromDomain(signals)
 }
 
 // buildRiskLimits собирает лимиты рисков
-func (b *Bot) buildRiskLimits() ai.RiskLimits {
-	// Получаем из storage
-	limits, err := b.storage.GetRiskLimits()
+func (h *Handlers) buildRiskLimits() ai.RiskLimits {
+	ctx := context.Background()
+	limits, err := h.storage.Risk().GetLimits(ctx)
 	if err != nil {
 		// Используем дефолтные
 		return ai.RiskLimits{
@@ -6,6 +6,10 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/rbac"
 )
 
 // AuthManager управляет правами доступа и rate limiting
@@ -15,6 +19,7 @@ type AuthManager struct {
 	rateLimiters  map[int64]*RateLimiter
 	mu            sync.RWMutex
 	enableWhitelist bool
+	roles         RoleSource // nil - только adminIDs/whitelist
 }
 
 // RateLimiter ограничивает частоту запросов от пользователя
@@ -56,35 +61,66 @@ func NewAuthManager(adminIDsStr, whitelistStr string) *AuthManager {
 	return am
 }
 
-// IsAdmin проверяет, является ли пользователь администратором
-func (am *AuthManager) IsAdmin(userID int64) bool {
+// RoleSource роли пользователей из хранилища (rbac.Service)
+type RoleSource interface {
+	HasUsers() bool
+	Role(telegramID int64) string
+	CheckOrderSize(role string, amountUSD float64) error
+}
+
+// SetRoles подключает роли из хранилища. Пока в нем нет пользователей,
+// доступ определяется TG_ADMINS/TG_CHAT_WHITELIST
+func (am *AuthManager) SetRoles(roles RoleSource) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.roles = roles
+}
+
+// Role возвращает роль пользователя ("" - доступа нет)
+func (am *AuthManager) Role(userID int64) string {
 	am.mu.RLock()
 	defer am.mu.RUnlock()
 
-	// Если список админов пуст, разрешаем всем
-	if len(am.adminIDs) == 0 {
-		return true
+	if am.roles != nil && am.roles.HasUsers() {
+		return am.roles.Role(userID)
 	}
 
-	return am.adminIDs[userID]
+	// Без ролей: доступ по whitelist (если включен), админы из env
+	// (или все, если список пуст), остальным - трейдер
+	if am.enableWhitelist && !am.adminIDs[userID] && !am.whitelist[userID] {
+		return ""
+	}
+	if len(am.adminIDs) == 0 || am.adminIDs[userID] {
+		return domain.RoleAdmin
+	}
+	return domain.RoleTrader
+}
+
+// IsAdmin проверяет, является ли пользователь администратором
+func (am *AuthManager) IsAdmin(userID int64) bool {
+	return am.Role(userID) == domain.RoleAdmin
 }
 
 // IsAllowed проверяет, разрешен ли доступ пользователю
 func (am *AuthManager) IsAllowed(userID int64) bool {
-	am.mu.RLock()
-	defer am.mu.RUnlock()
+	return am.Role(userID) != ""
+}
 
-	// Если whitelist не включен, разрешаем всем
-	if !am.enableWhitelist {
-		return true
-	}
+// Authorize проверяет право роли пользователя
+func (am *AuthManager) Authorize(userID int64, perm rbac.Permission) error {
+	return rbac.Authorize(am.Role(userID), perm)
+}
 
-	// Админы всегда разрешены
-	if am.adminIDs[userID] {
-		return true
-	}
+// CheckOrderSize проверяет размер ордера по лимиту роли пользователя
+func (am *AuthManager) CheckOrderSize(userID int64, amountUSD float64) error {
+	am.mu.RLock()
+	roles := am.roles
+	am.mu.RUnlock()
 
-	return am.whitelist[userID]
+	if roles == nil {
+		return nil
+	}
+	return roles.CheckOrderSize(am.Role(userID), amountUSD)
 }
 
 // CheckRateLimit проверяет rate limit для пользователя
@@ -186,3 +222,30 @@ func (am *AuthManager) CleanupRateLimiters() {
 		limiter.mu.Unlock()
 	}
 }
+
+// AuthorizeAction проверяет право пользователя на действие AI данного класса:
+// чтение - просмотр, изменения - торговля, опасные - только админам
+func (am *AuthManager) AuthorizeAction(userID int64, class ai.ActionClass) error {
+	if !am.IsAllowed(userID) {
+		return fmt.Errorf("access denied")
+	}
+	switch class {
+	case ai.ActionClassDangerous:
+		return am.RequireAdmin(userID)
+	case ai.ActionClassMutate:
+		return am.Authorize(userID, rbac.PermTrade)
+	}
+	return am.Authorize(userID, rbac.PermView)
+}
+
+// GetWhitelist возвращает пользователей из whitelist
+func (am *AuthManager) GetWhitelist() []int64 {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	ids := make([]int64, 0, len(am.whitelist))
+	for id := range am.whitelist {
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -3,6 +3,8 @@ package telegram
 import (
 	"testing"
 	"time"
+
+	"github.com/kirillm/dca-bot/internal/ai"
 )
 
 func TestNewAuthManager(t *testing.T) {
@@ -250,3 +252,26 @@ func TestAuthManager_CleanupRateLimiters(t *testing.T) {
 	}
 	am.mu.RUnlock()
 }
+
+func TestAuthManager_AuthorizeAction(t *testing.T) {
+	am := NewAuthManager("111", "222")
+
+	tests := []struct {
+		userID  int64
+		class   ai.ActionClass
+		wantErr bool
+	}{
+		{111, ai.ActionClassDangerous, false},
+		{222, ai.ActionClassRead, false},
+		{222, ai.ActionClassMutate, false},
+		{222, ai.ActionClassDangerous, true},
+		{333, ai.ActionClassRead, true},
+	}
+
+	for _, tt := range tests {
+		err := am.AuthorizeAction(tt.userID, tt.class)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("AuthorizeAction(%d, %s) error = %v, wantErr %v", tt.userID, tt.class, err, tt.wantErr)
+		}
+	}
+}
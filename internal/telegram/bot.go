@@ -1,65 +1,60 @@
 package telegram
 
 import (
+	"context"
 	"fmt"
-	"strconv"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/kirillm/dca-bot/internal/ai"
 	"github.com/kirillm/dca-bot/internal/ai/agents"
+	"github.com/kirillm/dca-bot/internal/ai/memory"
 	"github.com/kirillm/dca-bot/internal/exchange"
+	"github.com/kirillm/dca-bot/internal/marketdata"
+	"github.com/kirillm/dca-bot/internal/rbac"
 	"github.com/kirillm/dca-bot/internal/storage"
 	"github.com/kirillm/dca-bot/internal/strategy"
+	"github.com/kirillm/dca-bot/internal/tracing"
 	"github.com/kirillm/dca-bot/pkg/utils"
 )
 
+// maxRequestsPerSecond лимит команд и AI сообщений от одного пользователя
+const maxRequestsPerSecond = 2
+
+// Bot Telegram бот: команды через Router/Handlers, свободный текст - через AI
 type Bot struct {
-	api              *tgbotapi.BotAPI
-	chatID           int64
-	logger           *utils.Logger
-	exchange         *exchange.BybitClient
-	storage          *storage.PostgresStorage
-	aiClient         *ai.AIClient // Legacy AI client
-	dcaStrategy      *strategy.DCAStrategy
-	autoSell         *strategy.AutoSellStrategy
-	gridStrategy     *strategy.GridStrategy
-	portfolioManager *strategy.PortfolioManager
-	orchestrator     Orchestrator // Stage 4
-	policyEngine     PolicyEngine // Stage 4
-
-	// Stage 5: Hybrid AI
-	agentRouter    *agents.AgentRouter
-	chatAgent      *agents.ChatAgent
-	analysisAgent  *agents.AnalysisAgent
-	decisionAgent  *agents.DecisionAgent
+	api            *tgbotapi.BotAPI
+	chatID         int64 // чат для уведомлений (0 - всем админам)
+	logger         *utils.Logger
+	router         *Router
+	handlers       *Handlers
+	authManager    *AuthManager
+	formatter      *Formatter
+	aiClient       *ai.AIClient // legacy AI чат без AgentRouter
 	actionExecutor *ai.ActionExecutor
+	userLangs      map[int64]Lang
+	userLangsMu    sync.RWMutex
+	previewMode    bool
+	memory         *memory.Memory // история диалогов AI чата (nil - без истории)
 }
 
-// Orchestrator interface for Stage 4
-type Orchestrator interface {
-	GetMode() string
-	SetMode(mode string) error
-	IsRunning() bool
-}
-
-// PolicyEngine interface for Stage 4
-type PolicyEngine interface {
-	GetPolicy() interface{}
-	GetMetrics() interface{}
-}
-
+// NewBot создает бота
 func NewBot(
 	token string,
 	chatID int64,
 	logger *utils.Logger,
-	ex *exchange.BybitClient,
-	st *storage.PostgresStorage,
+	exchange *exchange.BybitClient,
+	storage *storage.Storage,
 	aiClient *ai.AIClient,
 	dcaStrategy *strategy.DCAStrategy,
 	autoSell *strategy.AutoSellStrategy,
 	gridStrategy *strategy.GridStrategy,
 	portfolioManager *strategy.PortfolioManager,
+	riskManager *strategy.RiskManager,
+	defaultSymbol string,
 ) (*Bot, error) {
 	bot, err := tgbotapi.NewBotAPI(token)
 	if err != nil {
@@ -68,18 +63,128 @@ func NewBot(
 
 	logger.Info("Telegram bot authorized: @%s", bot.Self.UserName)
 
-	return &Bot{
-		api:              bot,
-		chatID:           chatID,
-		logger:           logger,
-		exchange:         ex,
-		storage:          st,
-		aiClient:         aiClient,
-		dcaStrategy:      dcaStrategy,
-		autoSell:         autoSell,
-		gridStrategy:     gridStrategy,
-		portfolioManager: portfolioManager,
-	}, nil
+	// Создаем auth manager
+	adminIDsStr := os.Getenv("TG_ADMINS")
+	whitelistStr := os.Getenv("TG_CHAT_WHITELIST")
+	authManager := NewAuthManager(adminIDsStr, whitelistStr)
+
+	// Создаем formatter с дефолтным языком
+	defaultLang := LangEN
+	if os.Getenv("DEFAULT_LANG") == "ru" {
+		defaultLang = LangRU
+	}
+	formatter := NewFormatter(defaultLang)
+
+	// Создаем validator
+	validator := NewValidator(storage, exchange)
+
+	// Создаем handlers
+	handlers := NewHandlers(
+		exchange,
+		storage,
+		validator,
+		formatter,
+		authManager,
+		dcaStrategy,
+		autoSell,
+		gridStrategy,
+		portfolioManager,
+		riskManager,
+		defaultSymbol,
+	)
+	handlers.aiClient = aiClient
+
+	// Создаем router и регистрируем обработчики
+	router := NewRouter(authManager, validator, formatter)
+	registerHandlers(router, handlers)
+
+	// Action executor для AI
+	actionExecutor := ai.NewActionExecutor(
+		storage,
+		exchange,
+		portfolioManager,
+		riskManager,
+		gridStrategy,
+	)
+	actionExecutor.SetManualTrader(handlers.trader)
+	handlers.actionExecutor = actionExecutor
+
+	// Preview mode
+	previewMode := os.Getenv("PREVIEW_MODE") == "true"
+
+	b := &Bot{
+		api:            bot,
+		chatID:         chatID,
+		logger:         logger,
+		router:         router,
+		handlers:       handlers,
+		authManager:    authManager,
+		formatter:      formatter,
+		aiClient:       aiClient,
+		actionExecutor: actionExecutor,
+		userLangs:      make(map[int64]Lang),
+		previewMode:    previewMode,
+	}
+	router.SetAIActionHandler(b.confirmAIActions)
+
+	// Запускаем периодическую очистку rate limiters
+	go b.cleanupRateLimiters()
+
+	return b, nil
+}
+
+// registerHandlers регистрирует все обработчики команд
+func registerHandlers(router *Router, handlers *Handlers) {
+	// Info commands
+	router.RegisterHandler("status", handlers.HandleStatus)
+	router.RegisterHandler("history", handlers.HandleHistory)
+	router.RegisterHandler("config", handlers.HandleConfig)
+	router.RegisterHandler("price", handlers.HandlePrice)
+	router.RegisterHandler("portfolio", handlers.HandlePortfolio)
+	router.RegisterHandler("report", handlers.HandleReport)
+	router.RegisterHandler("help", handlers.HandleHelp)
+	router.RegisterHandler("start", handlers.HandleHelp)
+
+	// Trading commands
+	router.RegisterProtectedHandler("buy", rbac.PermTrade, handlers.HandleBuy)
+	router.RegisterProtectedHandler("sell", rbac.PermTrade, handlers.HandleSell)
+	router.RegisterPreview("sell", handlers.PreviewSell)
+
+	// Auto-Sell commands
+	router.RegisterProtectedHandler("autosellon", rbac.PermTrade, handlers.HandleAutoSellOn)
+	router.RegisterProtectedHandler("autoselloff", rbac.PermTrade, handlers.HandleAutoSellOff)
+	router.RegisterProtectedHandler("autosell", rbac.PermTrade, handlers.HandleAutoSell)
+
+	// Grid commands
+	router.RegisterProtectedHandler("gridinit", rbac.PermGrid, handlers.HandleGridInit)
+	router.RegisterHandler("gridstatus", handlers.HandleGridStatus)
+	router.RegisterProtectedHandler("gridstop", rbac.PermRisk, handlers.HandleGridStop)
+
+	// Risk commands
+	router.RegisterProtectedHandler("risk", rbac.PermRisk, handlers.HandleRisk)
+	router.RegisterProtectedHandler("panicstop", rbac.PermRisk, handlers.HandlePanicStop)
+
+	// Stage 4: Autonomous Trading commands
+	router.RegisterHandler("mode", handlers.HandleMode)
+	router.RegisterProtectedHandler("mode_shadow", rbac.PermRisk, handlers.HandleModeSwitch("shadow"))
+	router.RegisterProtectedHandler("mode_pilot", rbac.PermAutonomy, handlers.HandleModeSwitch("pilot"))
+	router.RegisterProtectedHandler("mode_full", rbac.PermAutonomy, handlers.HandleModeSwitch("full"))
+	router.RegisterHandler("decisions", handlers.HandleDecisions)
+	router.RegisterHandler("circuit", handlers.HandleCircuit)
+	router.RegisterHandler("policy", handlers.HandlePolicy)
+	router.RegisterHandler("metrics", handlers.HandleMetrics)
+
+	// Stage 5: Hybrid AI commands
+	router.RegisterHandler("analysis", handlers.HandleAIAnalysis)
+	router.RegisterHandler("ai_analyze", handlers.HandleAIAnalysis)
+	router.RegisterProtectedHandler("ai_decision", rbac.PermAutonomy, handlers.HandleAIDecision)
+	router.RegisterHandler("ai_metrics", handlers.HandleAIMetrics)
+	router.RegisterHandler("ai_mode", handlers.HandleAIMode)
+	router.RegisterHandler("forget", handlers.HandleForget)
+	router.RegisterProtectedHandler("prompts", rbac.PermConfig, handlers.HandlePrompts)
+
+	// Access control
+	router.RegisterProtectedHandler("users", rbac.PermUsers, handlers.HandleUsers)
 }
 
 // Start запускает обработку сообщений
@@ -89,453 +194,297 @@ func (b *Bot) Start() {
 
 	updates := b.api.GetUpdatesChan(u)
 
-	b.SendMessage("🤖 Crypto Trading Bot started!\nUse /help to see available commands.")
+	b.SendMessage(0, "🤖 "+b.formatter.T("bot_started"))
 
 	for update := range updates {
-		if update.Message == nil {
-			continue
+		if update.Message != nil {
+			go b.handleMessage(update.Message)
+		} else if update.CallbackQuery != nil {
+			go b.handleCallbackQuery(update.CallbackQuery)
 		}
-
-		// Проверяем, что сообщение от правильного пользователя
-		if update.Message.Chat.ID != b.chatID {
-			b.logger.Warn("Unauthorized access attempt from chat ID: %d", update.Message.Chat.ID)
-			continue
-		}
-
-		go b.handleMessage(update.Message)
 	}
 }
 
 // handleMessage обрабатывает входящее сообщение
 func (b *Bot) handleMessage(message *tgbotapi.Message) {
-	b.logger.Info("Received message: %s", message.Text)
+	chatID := message.Chat.ID
+	userID := message.From.ID
 
-	// Обработка команд
+	name := "telegram.message"
 	if message.IsCommand() {
-		b.handleCommand(message)
-		return
-	}
-
-	// Обработка текстовых сообщений через AI
-	b.handleAIMessage(message.Text)
-}
-
-// handleCommand обрабатывает команды
-func (b *Bot) handleCommand(message *tgbotapi.Message) {
-	switch message.Command() {
-	case "start", "help":
-		b.sendHelp()
-
-	case "status":
-		b.handleStatus()
-
-	case "history":
-		b.handleHistory()
-
-	case "config":
-		b.handleConfig()
-
-	case "buy":
-		b.handleManualBuy()
-
-	case "sell":
-		b.handleManualSell(message.CommandArguments())
-
-	case "autosell_on":
-		b.autoSell.Enable()
-
-	case "autosell_off":
-		b.autoSell.Disable()
-
-	case "price":
-		b.handlePrice(message.CommandArguments())
-
-	case "analysis":
-		b.handleAnalysis()
-
-	case "portfolio":
-		b.handlePortfolio()
-
-	case "grid_init":
-		b.handleGridInit(message.CommandArguments())
-
-	case "grid_status":
-		b.handleGridStatus(message.CommandArguments())
-
-	// Stage 4: Autonomous Trading Commands
-	case "mode":
-		b.handleMode(message.CommandArguments())
-
-	case "mode_shadow":
-		b.handleMode("shadow")
-
-	case "mode_pilot":
-		b.handleMode("pilot")
-
-	case "mode_full":
-		b.handleMode("full")
-
-	case "decisions":
-		b.handleDecisions()
-
-	case "circuit":
-		b.handleCircuit()
-
-	case "policy":
-		b.handlePolicyStatus()
-
-	case "metrics":
-		b.handleMetrics()
-
-	// Stage 5: Hybrid AI Commands
-	case "ai_analyze":
-		b.handleAIAnalysis(message.CommandArguments())
-
-	case "ai_decision":
-		b.handleAIDecision()
-
-	case "ai_metrics":
-		b.handleAIMetrics()
-
-	case "ai_mode":
-		b.handleAIMode(message.CommandArguments())
-
-	default:
-		b.SendMessage("Unknown command. Use /help to see available commands.")
-	}
-}
-
-// sendHelp отправляет справку
-func (b *Bot) sendHelp() {
-	help := `🤖 AI Криптотрейдинг Бот
-
-📊 МОНИТОРИНГ
-/status - Текущая позиция и статистика
-/history - История последних сделок
-/portfolio - Обзор портфеля
-/price [SYMBOL] - Текущая цена актива
-
-💬 HYBRID AI (Stage 5)
-/ai_analyze [SYMBOL] - Рыночный анализ (локальный)
-/ai_decision - Стратегическое решение (облачный)
-/ai_metrics - Метрики AI агентов
-/ai_mode [shadow|pilot|full] - Режим DecisionAgent
-
-Просто пишите сообщение боту для общения с ChatAgent!
-
-🧠 АВТОНОМНЫЙ AI (Stage 4)
-/mode - Показать текущий режим Orchestrator
-/mode_shadow - Режим Тени (без сделок)
-/mode_pilot - Режим Пилота (50% лимиты)
-/mode_full - Полная Автоматизация
-/decisions - История AI решений
-/policy - Текущая политика рисков
-/metrics - Метрики рисков
-
-📈 СТРАТЕГИИ
-/buy - Ручная покупка DCA
-/sell <PERCENT> - Ручная продажа
-/autosell_on - Включить Auto-Sell
-/autosell_off - Выключить Auto-Sell
-/grid_init <SYMBOL> - Инициализировать Grid
-/grid_status [SYMBOL] - Статус Grid стратегии
-
-Примечание: AI Orchestrator работает каждые 15 минут автоматически.
-DecisionAgent вызывается по команде или по расписанию.
-`
-	// Send without markdown parsing
-	message := tgbotapi.NewMessage(b.chatID, help)
-	message.ParseMode = "" // No parsing
-	if _, err := b.api.Send(message); err != nil {
-		b.logger.Error("Failed to send help message: %v", err)
+		name = "telegram.command"
 	}
-}
-
-// handleStatus показывает текущий статус
-func (b *Bot) handleStatus() {
-	dcaStatus, err := b.dcaStrategy.GetStatus()
-	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Error getting DCA status: %v", err))
-		return
+	ctx, span := b.startRequest(name, userID, chatID)
+	defer span.End()
+	if message.IsCommand() {
+		span.SetAttributes(tracing.String("telegram.command", message.Command()))
 	}
+	logger := b.requestLogger(ctx, userID, chatID)
+	logger.Info("Received message: %s", message.Text)
 
-	autoSellStatus, err := b.autoSell.GetStatus()
-	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Error getting Auto-Sell status: %v", err))
+	// Проверяем доступ
+	if !b.authManager.IsAllowed(userID) {
+		b.SendMessage(chatID, b.formatter.T("access_denied"))
+		logger.Warn("Unauthorized access attempt")
 		return
 	}
 
-	message := fmt.Sprintf("%s\n\n%s", dcaStatus, autoSellStatus)
-	b.SendMessage(message)
-}
+	// Устанавливаем язык пользователя
+	b.setUserLang(userID, chatID)
 
-// handleHistory показывает историю сделок
-func (b *Bot) handleHistory() {
-	symbol := "BTCUSDT" // TODO: get from config
-	trades, err := b.storage.GetRecentTrades(symbol, 10)
-	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Error getting trade history: %v", err))
+	// Обработка команд
+	if message.IsCommand() {
+		b.handleCommand(ctx, message)
 		return
 	}
 
-	if len(trades) == 0 {
-		b.SendMessage("No trades yet.")
+	// AI сообщения ограничиваются так же, как команды (Router проверяет лимит сам)
+	if err := b.authManager.CheckRateLimit(userID, maxRequestsPerSecond); err != nil {
+		b.SendMessage(chatID, b.formatter.FormatError(err))
 		return
 	}
 
-	message := "📜 Recent Trades:\n\n"
-	for i, trade := range trades {
-		message += fmt.Sprintf("%d. %s %s\n   Qty: %.8f\n   Price: %.2f USDT\n   Amount: %.2f USDT\n   Time: %s\n\n",
-			i+1, trade.Side, trade.Symbol, trade.Quantity, trade.Price, trade.Amount,
-			trade.CreatedAt.Format("2006-01-02 15:04"))
+	// Обработка текстовых сообщений через AI
+	if b.handlers.agentRouter != nil || b.aiClient != nil {
+		b.handleAIMessage(ctx, message)
+	} else {
+		b.SendMessage(chatID, b.formatter.T("use_help"))
 	}
-
-	b.SendMessage(message)
 }
 
-// handleConfig показывает текущую конфигурацию
-func (b *Bot) handleConfig() {
-	// TODO: Implement config display
-	b.SendMessage("⚙️ Configuration display - coming soon")
-}
+// startRequest открывает спан запроса пользователя. Логи и операции запроса связаны
+// correlation_id: ID трейса или случайный ID при выключенном трейсинге
+func (b *Bot) startRequest(name string, userID, chatID int64) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(context.Background(), name, tracing.KindServer,
+		tracing.Int64("telegram.user_id", userID), tracing.Int64("telegram.chat_id", chatID))
 
-// handleManualBuy выполняет ручную покупку
-func (b *Bot) handleManualBuy() {
-	b.SendMessage("🔄 Executing manual buy...")
-	if err := b.dcaStrategy.ExecuteManualBuy(); err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Manual buy failed: %v", err))
-		return
+	id := span.TraceID()
+	if id == "" {
+		id = utils.NewCorrelationID()
 	}
+	return utils.WithCorrelationID(ctx, id), span
 }
 
-// handleManualSell выполняет ручную продажу
-func (b *Bot) handleManualSell(args string) {
-	if args == "" {
-		b.SendMessage("❌ Please specify percentage to sell. Example: /sell 50")
-		return
-	}
-
-	percent, err := strconv.ParseFloat(args, 64)
-	if err != nil || percent <= 0 || percent > 100 {
-		b.SendMessage("❌ Invalid percentage. Use a number between 1 and 100.")
-		return
-	}
-
-	b.SendMessage(fmt.Sprintf("🔄 Executing manual sell of %.0f%% of position...", percent))
-	if err := b.autoSell.ExecuteManualSell(percent); err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Manual sell failed: %v", err))
-		return
-	}
+// requestLogger логгер запроса пользователя: correlation_id, user_id, chat_id
+func (b *Bot) requestLogger(ctx context.Context, userID, chatID int64) *utils.Logger {
+	return b.logger.WithContext(ctx).With("user_id", userID, "chat_id", chatID)
 }
 
-// handlePrice показывает текущую цену
-func (b *Bot) handlePrice(args string) {
-	symbol := args
-	if symbol == "" {
-		symbol = "BTCUSDT" // default
-	}
+// handleCommand обрабатывает команду
+func (b *Bot) handleCommand(ctx context.Context, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	userID := message.From.ID
+	logger := b.requestLogger(ctx, userID, chatID)
 
-	price, err := b.exchange.GetPrice(symbol)
-	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Error getting price for %s: %v", symbol, err))
+	// Preview mode check
+	if b.previewMode {
+		b.SendMessage(chatID, fmt.Sprintf("🔍 PREVIEW MODE\nCommand: %s\nWould be executed, but preview mode is enabled.",
+			message.Text))
 		return
 	}
 
-	balance, err := b.storage.GetBalance(symbol)
+	// Обрабатываем через router
+	response, token, err := b.router.HandleCommand(ctx, userID, message.Text)
+
 	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Error getting balance for %s: %v", symbol, err))
-		return
+		tracing.SpanFromContext(ctx).RecordError(err)
+		logger.Error("Command error: %v", err)
 	}
 
-	profitPercent := 0.0
-	if balance.AvgEntryPrice > 0 {
-		profitPercent = ((price - balance.AvgEntryPrice) / balance.AvgEntryPrice) * 100
+	// Опасная команда отложена - отправляем предпросмотр с кнопками подтверждения
+	if token != "" {
+		msg := tgbotapi.NewMessage(chatID, response)
+		msg.ReplyMarkup = b.router.MakeConfirmationKeyboard(token)
+		if _, err := b.api.Send(msg); err != nil {
+			logger.Error("Failed to send confirmation: %v", err)
+		}
+	} else {
+		b.SendMessage(chatID, response)
 	}
-
-	message := fmt.Sprintf(
-		"💵 %s\n\n"+
-			"Current Price: %.2f USDT\n"+
-			"Avg Entry: %.2f USDT\n"+
-			"Change: %.2f%%",
-		symbol, price, balance.AvgEntryPrice, profitPercent,
-	)
-
-	b.SendMessage(message)
 }
 
-// handleAnalysis запрашивает AI анализ рынка (legacy)
-func (b *Bot) handleAnalysis() {
-	// Если есть AnalysisAgent, используем его
-	if b.analysisAgent != nil {
-		b.handleAIAnalysis("BTCUSDT")
+// handleCallbackQuery обрабатывает callback от inline кнопок
+func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
+	if query.Message == nil {
 		return
 	}
+	chatID := query.Message.Chat.ID
+	userID := query.From.ID
 
-	// Legacy fallback
-	if b.aiClient == nil {
-		b.SendMessage("AI client not configured")
-		return
-	}
-
-	symbol := "BTCUSDT" // TODO: get from config
+	ctx, span := b.startRequest("telegram.callback", userID, chatID)
+	defer span.End()
 
-	price, err := b.exchange.GetPrice(symbol)
+	// Обрабатываем callback
+	result, err := b.router.HandleCallback(ctx, userID, query.Data)
 	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Error getting price: %v", err))
-		return
+		span.RecordError(err)
+		b.requestLogger(ctx, userID, chatID).Error("Callback error: %v", err)
 	}
 
-	balance, err := b.storage.GetBalance(symbol)
-	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Error getting balance: %v", err))
-		return
+	// Отвечаем на callback (всплывающее уведомление для отказов)
+	notice := ""
+	if !result.Final {
+		notice = result.Text
 	}
+	b.api.Request(tgbotapi.NewCallback(query.ID, notice))
 
-	b.SendMessage("🤔 Analyzing market...")
-
-	analysis, err := b.aiClient.GetMarketAnalysis(symbol, price, balance.AvgEntryPrice)
-	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ AI analysis failed: %v", err))
+	if !result.Final {
 		return
 	}
 
-	b.SendMessage(fmt.Sprintf("🧠 AI Analysis:\n\n%s", analysis))
+	// Заменяем предпросмотр результатом, кнопки убираем
+	edit := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, result.Text)
+	b.api.Send(edit)
 }
 
-// handleAIMessage обрабатывает сообщение через AI (используется для не-команд)
-func (b *Bot) handleAIMessage(text string) {
-	// Если есть AgentRouter, используем его
-	if b.agentRouter != nil {
-		b.handleAIChat(text)
-		return
-	}
+// handleAIMessage обрабатывает сообщение через AgentRouter (Stage 5) или legacy AI client
+func (b *Bot) handleAIMessage(ctx context.Context, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	userID := message.From.ID
+	text := message.Text
+	logger := b.requestLogger(ctx, userID, chatID)
 
-	// Иначе используем legacy AI client
-	if b.aiClient == nil {
-		b.SendMessage("AI client not configured")
-		return
-	}
+	logger.Info("Processing AI message")
 
-	// Получаем контекст
-	context, err := b.buildContext()
-	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Error building context: %v", err))
+	// Ответ на ожидающие подтверждения действия
+	if b.handlePendingReply(ctx, chatID, userID, text) {
 		return
 	}
 
-	// Отправляем AI
-	reply, actions, err := b.aiClient.ProcessMessage(text, context)
+	// Строим контекст
+	contextInfo, err := b.buildContext()
 	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ AI error: %v", err))
+		b.SendMessage(chatID, b.formatter.FormatError(err))
 		return
 	}
 
-	// Выполняем действия
-	for _, action := range actions {
-		if err := b.executeAIAction(action); err != nil {
-			b.logger.Error("Failed to execute AI action %s: %v", action.Type, err)
+	var reply string
+	var actions []ai.AIAction
+	if router := b.handlers.agentRouter; router != nil {
+		// AgentRouter сам ведет историю диалога, если подключена память
+		reply, actions, err = router.ProcessConversation(ctx, userID, text, contextInfo)
+	} else {
+		var history []ai.Message
+		if b.memory != nil {
+			if history, err = b.memory.History(ctx, userID); err != nil {
+				logger.Warn("Failed to load conversation history: %v", err)
+			}
+		}
+		reply, actions, err = b.aiClient.ProcessMessageWithHistory(text, contextInfo, history)
+		if err == nil {
+			b.remember(ctx, userID, text, reply)
 		}
 	}
-
-	// Отправляем ответ
-	if reply != "" {
-		b.SendMessage(reply)
-	}
-}
-
-// buildContext строит контекст для AI
-func (b *Bot) buildContext() (string, error) {
-	status, err := b.dcaStrategy.GetStatus()
 	if err != nil {
-		return "", err
+		b.SendMessage(chatID, b.formatter.FormatError(err))
+		return
 	}
 
-	autoSellStatus, err := b.autoSell.GetStatus()
-	if err != nil {
-		return "", err
+	// Отправляем ответ AI
+	if reply != "" {
+		b.SendMessage(chatID, reply)
 	}
 
-	return fmt.Sprintf("%s\n\n%s", status, autoSellStatus), nil
+	// Чтение выполняется сразу, изменения - после подтверждения с проверкой прав
+	b.proposeAndRun(chatID, userID, actions)
 }
 
-// executeAIAction выполняет действие, предложенное AI
-func (b *Bot) executeAIAction(action ai.AIAction) error {
-	b.logger.Info("Executing AI action: %s", action.Type)
-
-	switch action.Type {
-	case "get_status":
-		b.handleStatus()
-
-	case "update_dca_amount":
-		if amount, ok := action.Parameters["amount"].(float64); ok {
-			b.dcaStrategy.UpdateAmount(amount)
-		}
-
-	case "update_autosell_trigger":
-		if percent, ok := action.Parameters["percent"].(float64); ok {
-			b.autoSell.UpdateTriggerPercent(percent)
+// executeAIActions выполняет действия AI (или показывает их в preview режиме)
+func (b *Bot) executeAIActions(chatID int64, actions []ai.AIAction) {
+	for _, action := range actions {
+		if result := b.runAIAction(action); result != "" {
+			b.SendMessage(chatID, result)
 		}
+	}
+}
 
-	case "update_autosell_amount":
-		if percent, ok := action.Parameters["percent"].(float64); ok {
-			b.autoSell.UpdateSellAmountPercent(percent)
-		}
+// runAIAction выполняет одно действие AI и возвращает текст для пользователя
+func (b *Bot) runAIAction(action ai.AIAction) string {
+	b.logger.Info("Executing AI action: %s", action.Type)
 
-	case "enable_autosell":
-		b.autoSell.Enable()
+	// Preview mode check
+	if b.previewMode {
+		return fmt.Sprintf("🔍 PREVIEW MODE\nAI Action: %s\nParams: %v\nWould be executed.",
+			action.Type, action.Parameters)
+	}
 
-	case "disable_autosell":
-		b.autoSell.Disable()
+	actionResult, err := b.actionExecutor.ExecuteAction(action)
+	if err != nil {
+		b.logger.Error("Failed to execute AI action %s: %v", action.Type, err)
+		return b.formatter.FormatError(err)
+	}
+	return actionResult
+}
 
-	case "manual_buy":
-		if err := b.dcaStrategy.ExecuteManualBuy(); err != nil {
-			return err
+// buildContext строит контекст для AI
+func (b *Bot) buildContext() (string, error) {
+	ctx := context.Background()
+	var sb strings.Builder
+
+	// Получаем активные активы
+	assets, err := b.handlers.storage.Assets().GetEnabled(ctx)
+	if err == nil && len(assets) > 0 {
+		sb.WriteString("Active Assets:\n")
+		for _, asset := range assets {
+			sb.WriteString(fmt.Sprintf("- %s (%s)\n", asset.Symbol, asset.StrategyType))
 		}
-
-	case "manual_sell":
-		if percent, ok := action.Parameters["percent"].(float64); ok {
-			if err := b.autoSell.ExecuteManualSell(percent); err != nil {
-				return err
-			}
+		sb.WriteString("\n")
+	}
+
+	// Получаем балансы
+	balances, err := b.handlers.storage.Balances().GetAll(ctx)
+	if err == nil && len(balances) > 0 {
+		sb.WriteString("Balances:\n")
+		for _, balance := range balances {
+			price, _ := b.handlers.exchange.GetCurrentPrice(balance.Symbol)
+			currentValue := balance.TotalQuantity * price
+			pnl := currentValue - balance.TotalInvested + balance.RealizedProfit
+			sb.WriteString(fmt.Sprintf("- %s: %.8f (Avg: $%.2f, Current: $%.2f, P&L: $%.2f)\n",
+				balance.Symbol, balance.TotalQuantity, balance.AvgEntryPrice, price, pnl))
 		}
+	}
 
-	case "get_history":
-		b.handleHistory()
-
-	case "get_price":
-		b.handlePrice("")
+	return sb.String(), nil
+}
 
-	case "get_portfolio":
-		b.handlePortfolio()
+// SendMessage отправляет сообщение пользователю
+func (b *Bot) SendMessage(chatID int64, text string) {
+	if text == "" {
+		return
+	}
 
-	case "init_grid":
-		if symbol, ok := action.Parameters["symbol"].(string); ok {
-			b.handleGridInit(symbol)
+	// Если chatID = 0, отправляем в чат уведомлений или всем админам
+	if chatID == 0 {
+		if b.chatID != 0 {
+			b.sendToChat(b.chatID, text)
+			return
 		}
-
-	case "get_grid_status":
-		if symbol, ok := action.Parameters["symbol"].(string); ok {
-			b.handleGridStatus(symbol)
+		adminIDs := b.authManager.GetAdminIDs()
+		for _, adminID := range adminIDs {
+			b.sendToChat(adminID, text)
 		}
-
-	default:
-		b.logger.Warn("Unknown AI action: %s", action.Type)
+		return
 	}
 
-	return nil
+	b.sendToChat(chatID, text)
 }
 
-// SendMessage отправляет сообщение пользователю
-func (b *Bot) SendMessage(text string) {
+// Notify отправляет уведомление (для notifyFunc orchestrator, отчетов, инцидентов)
+func (b *Bot) Notify(text string) {
+	b.SendMessage(0, text)
+}
+
+// sendToChat отправляет сообщение в конкретный чат
+func (b *Bot) sendToChat(chatID int64, text string) {
 	// Разбиваем длинные сообщения
 	const maxLength = 4096
 	messages := splitMessage(text, maxLength)
 
 	for _, msg := range messages {
-		message := tgbotapi.NewMessage(b.chatID, msg)
+		message := tgbotapi.NewMessage(chatID, msg)
 		message.ParseMode = "Markdown"
 		if _, err := b.api.Send(message); err != nil {
-			b.logger.Error("Failed to send telegram message: %v", err)
+			b.logger.Error("Failed to send telegram message to chat %d: %v", chatID, err)
 		}
 	}
 }
@@ -569,251 +518,119 @@ func splitMessage(text string, maxLength int) []string {
 	return messages
 }
 
-// handlePortfolio показывает портфель
-func (b *Bot) handlePortfolio() {
-	if b.portfolioManager == nil {
-		b.SendMessage("Portfolio manager not available")
-		return
-	}
+// setUserLang определяет и устанавливает язык пользователя
+func (b *Bot) setUserLang(userID, chatID int64) {
+	b.userLangsMu.Lock()
+	defer b.userLangsMu.Unlock()
 
-	status, err := b.portfolioManager.GetStatus()
-	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Error getting portfolio: %v", err))
+	// Если язык уже установлен, возвращаем
+	if _, exists := b.userLangs[userID]; exists {
 		return
 	}
 
-	b.SendMessage(status)
+	// Пытаемся определить язык по language_code пользователя
+	// В реальности нужно получить язык из user object
+	// Пока ставим дефолтный
+	lang := b.formatter.GetLang()
+	b.userLangs[userID] = lang
+	b.formatter.SetLang(lang)
 }
 
-// handleGridInit инициализирует Grid для символа
-func (b *Bot) handleGridInit(args string) {
-	if args == "" {
-		b.SendMessage("❌ Please specify symbol. Example: /grid_init ETHUSDT")
-		return
-	}
-
-	symbol := args
-	if b.gridStrategy == nil {
-		b.SendMessage("Grid strategy not available")
-		return
-	}
-
-	// Параметры по умолчанию
-	levels := 10
-	spacingPercent := 2.5
-	orderSizeQuote := 100.0
-
-	b.SendMessage(fmt.Sprintf("🔄 Initializing Grid for %s...", symbol))
-
-	// Инициализируем Grid через API
-	// Пока используем GetAsset для получения настроек
-	asset, err := b.storage.GetAsset(symbol)
-	if err != nil || asset == nil {
-		// Если актив не найден, создаем дефолтный
-		asset = &storage.Asset{
-			Symbol:             symbol,
-			Enabled:            true,
-			StrategyType:       "GRID",
-			AllocatedCapital:   1000,
-			MaxPositionSize:    5000,
-			GridLevels:         levels,
-			GridSpacingPercent: spacingPercent,
-			GridOrderSize:      orderSizeQuote,
-		}
-		if err := b.storage.CreateOrUpdateAsset(asset); err != nil {
-			b.SendMessage(fmt.Sprintf("❌ Failed to create asset: %v", err))
-			return
-		}
-	}
+// GetUserLang возвращает язык пользователя
+func (b *Bot) GetUserLang(userID int64) Lang {
+	b.userLangsMu.RLock()
+	defer b.userLangsMu.RUnlock()
 
-	if err := b.gridStrategy.InitializeGrid(asset); err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Grid initialization failed: %v", err))
-		return
+	if lang, exists := b.userLangs[userID]; exists {
+		return lang
 	}
 
-	b.SendMessage(fmt.Sprintf("✅ Grid initialized for %s\nLevels: %d\nSpacing: %.2f%%\nOrder Size: %.2f USDT",
-		symbol, levels, spacingPercent, orderSizeQuote))
+	return b.formatter.GetLang()
 }
 
-// handleGridStatus показывает статус Grid
-func (b *Bot) handleGridStatus(args string) {
-	symbol := args
-	if symbol == "" {
-		symbol = "BTCUSDT" // default
-	}
-
-	if b.gridStrategy == nil {
-		b.SendMessage("Grid strategy not available")
-		return
-	}
-
-	metrics, err := b.gridStrategy.CalculateGridMetrics(symbol)
-	if err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Error getting Grid status: %v", err))
-		return
-	}
+// SetUserLang устанавливает язык пользователя
+func (b *Bot) SetUserLang(userID int64, lang Lang) {
+	b.userLangsMu.Lock()
+	defer b.userLangsMu.Unlock()
 
-	message := fmt.Sprintf(
-		"🔷 Grid Status - %s\n\n"+
-			"Active Orders: %v\n"+
-			"Current Price: %.2f USDT\n"+
-			"Total Quantity: %.8f\n"+
-			"Avg Entry Price: %.2f USDT\n"+
-			"Total Invested: %.2f USDT\n"+
-			"Total Sold: %.2f USDT\n"+
-			"Realized Profit: %.2f USDT\n"+
-			"Unrealized P&L: %.2f USDT\n"+
-			"Total P&L: %.2f USDT (%.2f%%)",
-		symbol,
-		metrics["active_orders"],
-		metrics["current_price"],
-		metrics["total_quantity"],
-		metrics["avg_entry_price"],
-		metrics["total_invested"],
-		metrics["total_sold"],
-		metrics["realized_profit"],
-		metrics["unrealized_pnl"],
-		metrics["total_pnl"],
-		metrics["return_percent"],
-	)
-
-	b.SendMessage(message)
+	b.userLangs[userID] = lang
 }
 
-// ==================== STAGE 4: Autonomous Trading Handlers ====================
-
-// handleMode переключает или показывает режим AI
-func (b *Bot) handleMode(args string) {
-	if b.orchestrator == nil {
-		b.SendMessage("⚠️ Orchestrator not available. Stage 4 not enabled.")
-		return
-	}
-
-	// Если аргумент не указан - показываем текущий режим
-	if args == "" {
-		currentMode := b.orchestrator.GetMode()
-		running := "🔴 Остановлен"
-		if b.orchestrator.IsRunning() {
-			running = "🟢 Работает"
-		}
+// cleanupRateLimiters периодически очищает старые rate limiters
+func (b *Bot) cleanupRateLimiters() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
 
-		message := fmt.Sprintf(
-			"🧠 Режим AI Трейдинга\n\n"+
-				"Текущий режим: %s\n"+
-				"Статус: %s\n\n"+
-				"Доступные режимы:\n"+
-				"• shadow - AI решает, но не торгует (логирование)\n"+
-				"• pilot - Торговля с 50%% лимитами (тестирование)\n"+
-				"• full - Полная автономия в рамках лимитов\n\n"+
-				"Команды:\n"+
-				"/mode_shadow - Переключить на Тень\n"+
-				"/mode_pilot - Переключить на Пилот\n"+
-				"/mode_full - Переключить на Полную",
-			currentMode, running,
-		)
-		b.SendMessage(message)
-		return
+	for range ticker.C {
+		b.authManager.CleanupRateLimiters()
+		b.logger.Info("Cleaned up rate limiters")
 	}
+}
 
-	// Валидация режима
-	mode := strings.ToLower(args)
-	if mode != "shadow" && mode != "pilot" && mode != "full" {
-		b.SendMessage("❌ Invalid mode. Use: shadow, pilot, or full")
-		return
-	}
+// Stop останавливает бота
+func (b *Bot) Stop() {
+	b.logger.Info("Stopping Telegram bot...")
+	b.api.StopReceivingUpdates()
+}
 
-	// Переключаем режим
-	if err := b.orchestrator.SetMode(mode); err != nil {
-		b.SendMessage(fmt.Sprintf("❌ Ошибка переключения режима: %v", err))
-		return
-	}
+// ==================== Stage 4/5 wiring ====================
 
-	b.SendMessage(fmt.Sprintf("✅ Переключено на режим %s\n\n%s",
-		mode,
-		getModeDescription(mode),
-	))
+// SetOrchestrator подключает orchestrator к /mode (Stage 4)
+func (b *Bot) SetOrchestrator(orchestrator Orchestrator) {
+	b.handlers.orchestrator = orchestrator
 }
 
-// getModeDescription возвращает описание режима
-func getModeDescription(mode string) string {
-	descriptions := map[string]string{
-		"shadow": "🔍 Режим Тени:\n• AI генерирует решения\n• Без реального исполнения\n• Все решения логируются\n• Безопасно для тестирования",
-		"pilot":  "✈️ Режим Пилота:\n• AI решения исполняются\n• 50% от нормальных лимитов\n• Консервативный риск-профиль\n• Хорошо для валидации",
-		"full":   "🚀 Режим Полной Автоматизации:\n• Полная автономия\n• Полные лимиты рисков\n• Минимальное вмешательство\n• Максимальная производительность",
-	}
-	return descriptions[mode]
+// SetPolicyEngine подключает policy engine к /policy и /metrics (Stage 4)
+func (b *Bot) SetPolicyEngine(policyEngine PolicyEngine) {
+	b.handlers.policyEngine = policyEngine
 }
 
-// handleDecisions показывает последние решения AI
-func (b *Bot) handleDecisions() {
-	// TODO: Implement with storage repository
-	b.SendMessage("🧠 Recent AI Decisions:\n\n_Feature coming soon - requires database integration_\n\nThis command will show:\n• Last 10 AI decisions\n• Regime (ACCUMULATE/TREND_FOLLOW/RANGE_GRID/DEFENSE)\n• Confidence scores\n• Actions taken\n• Approval status")
+// SetDecisionStore подключает историю AI решений к /decisions
+func (b *Bot) SetDecisionStore(store DecisionStore) {
+	b.handlers.decisions = store
 }
 
-// handleCircuit проверяет статус circuit breakers
-func (b *Bot) handleCircuit() {
-	// TODO: Implement with storage repository
-	b.SendMessage("🛡️ Circuit Breaker Status:\n\n_Feature coming soon - requires database integration_\n\nThis command will show:\n• Active circuit breakers\n• Triggered reasons\n• Pause duration\n• Last trigger time")
+// SetBreakerStore подключает историю circuit breakers к /circuit
+func (b *Bot) SetBreakerStore(store BreakerStore) {
+	b.handlers.breakers = store
 }
 
-// handlePolicyStatus показывает текущую политику рисков
-func (b *Bot) handlePolicyStatus() {
-	if b.policyEngine == nil {
-		b.SendMessage("⚠️ Policy Engine not available. Stage 4 not enabled.")
-		return
-	}
-
-	// TODO: Extract actual values from policy engine once interface is extended
-	_ = b.policyEngine.GetPolicy() // Placeholder for future use
-
-	message := fmt.Sprintf(
-		"⚙️ Risk Management Policy\n\n"+
-			"Profile: *%v*\n\n"+
-			"Limits:\n"+
-			"• Max Order: $%v USDT\n"+
-			"• Max Position: $%v USDT\n"+
-			"• Max Exposure: $%v USDT\n"+
-			"• Max Daily Loss: $%v USDT\n"+
-			"• Trades/Hour: %v\n\n"+
-			"_Configured in configs/policy.yaml_",
-		"moderate",              // TODO: Get actual profile
-		100, 1000, 3000, 100, 5, // TODO: Get actual values from policy
-	)
-
-	b.SendMessage(message)
+// SetEventPublisher публикует ручные сделки /buy и /sell в шину событий
+func (b *Bot) SetEventPublisher(publisher strategy.EventPublisher) {
+	b.handlers.trader.SetEventPublisher(publisher)
 }
 
-// handleMetrics показывает метрики рисков
-func (b *Bot) handleMetrics() {
-	if b.policyEngine == nil {
-		b.SendMessage("⚠️ Policy Engine not available. Stage 4 not enabled.")
-		return
-	}
-
-	// TODO: Extract actual values from metrics once interface is extended
-	_ = b.policyEngine.GetMetrics() // Placeholder for future use
-
-	message := fmt.Sprintf(
-		"📊 Risk Metrics\n\n"+
-			"Current Metrics:\n"+
-			"• Total Exposure: $%.2f USDT\n"+
-			"• Daily Loss: $%.2f USDT\n"+
-			"• Daily Trades: %d\n"+
-			"• Current Drawdown: %.2f%%\n"+
-			"• Risk Score: %.2f/1.0\n\n"+
-			"_Updated in real-time_",
-		0.0, 0.0, 0, 0.0, 0.0, // TODO: Get actual metrics
-	)
-
-	b.SendMessage(message)
+// SetMarketData устанавливает сервис рыночных данных
+func (b *Bot) SetMarketData(marketData *marketdata.Service) {
+	b.handlers.marketData = marketData
 }
 
-// SetOrchestrator устанавливает orchestrator (для Stage 4)
-func (b *Bot) SetOrchestrator(orchestrator Orchestrator) {
-	b.orchestrator = orchestrator
+// SetNewsSource устанавливает источник новостных сигналов
+func (b *Bot) SetNewsSource(newsSource NewsSource) {
+	b.handlers.newsSource = newsSource
 }
 
-// SetPolicyEngine устанавливает policy engine (для Stage 4)
-func (b *Bot) SetPolicyEngine(policyEngine PolicyEngine) {
-	b.policyEngine = policyEngine
+// SetAIAgents устанавливает AI агенты (Stage 5). chatAgent используется через agentRouter.
+func (b *Bot) SetAIAgents(
+	agentRouter *agents.AgentRouter,
+	chatAgent *agents.ChatAgent,
+	analysisAgent *agents.AnalysisAgent,
+	decisionAgent *agents.DecisionAgent,
+	actionExecutor *ai.ActionExecutor,
+) {
+	h := b.handlers
+	h.agentRouter = agentRouter
+	h.analysisAgent = analysisAgent
+	h.decisionAgent = decisionAgent
+	if actionExecutor != nil {
+		h.actionExecutor = actionExecutor
+		b.actionExecutor = actionExecutor
+	}
+	if agentRouter != nil {
+		agentRouter.SetDecisionContext(h)
+		if b.memory != nil {
+			agentRouter.SetMemory(b.memory)
+		}
+	}
+	b.logger.Info("Stage 5 AI agents configured")
 }
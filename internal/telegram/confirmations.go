@@ -0,0 +1,200 @@
+package telegram
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// confirmationTTL время, в течение которого опасную команду можно подтвердить
+const confirmationTTL = 2 * time.Minute
+
+// Префиксы callback data: "cf:<token>" - подтвердить, "cx:<token>" - отменить.
+// Токен 16 hex символов, callback data всегда укладывается в лимит Telegram 64 байта
+const (
+	callbackConfirm = "cf:"
+	callbackCancel  = "cx:"
+)
+
+// Ошибки подтверждения
+var (
+	errConfirmationExpired    = errors.New("confirmation expired")
+	errConfirmationForeign    = errors.New("confirmation belongs to another user")
+	errConfirmationInProgress = errors.New("confirmation in progress")
+)
+
+// pendingState состояние отложенной команды
+type pendingState int
+
+const (
+	statePending pendingState = iota
+	stateExecuting
+	stateDone
+	stateCancelled
+)
+
+// pendingCommand опасная команда, ожидающая подтверждения
+type pendingCommand struct {
+	token   string
+	userID  int64
+	args    CommandArgs
+	created time.Time
+	state   pendingState
+	result  string // ответ после выполнения/отмены - повторное нажатие возвращает его же
+}
+
+// ConfirmationStore хранит отложенные команды по коротким токенам
+type ConfirmationStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*pendingCommand
+}
+
+// NewConfirmationStore создает хранилище подтверждений
+func NewConfirmationStore(ttl time.Duration) *ConfirmationStore {
+	return &ConfirmationStore{
+		ttl:     ttl,
+		entries: make(map[string]*pendingCommand),
+	}
+}
+
+// Stage сохраняет команду до подтверждения и возвращает токен
+func (s *ConfirmationStore) Stage(userID int64, args CommandArgs) (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweepLocked()
+	s.entries[token] = &pendingCommand{
+		token:   token,
+		userID:  userID,
+		args:    args,
+		created: time.Now(),
+	}
+	return token, nil
+}
+
+// Begin переводит команду в выполнение. Для уже выполненной команды возвращает
+// ее результат (done=true) - двойное нажатие не выполняет команду повторно
+func (s *ConfirmationStore) Begin(token string, userID int64) (args CommandArgs, result string, done bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.lookupLocked(token, userID)
+	if err != nil {
+		return CommandArgs{}, "", false, err
+	}
+
+	switch entry.state {
+	case stateExecuting:
+		return CommandArgs{}, "", false, errConfirmationInProgress
+	case stateDone, stateCancelled:
+		return CommandArgs{}, entry.result, true, nil
+	}
+
+	entry.state = stateExecuting
+	return entry.args, "", false, nil
+}
+
+// Finish сохраняет результат выполнения
+func (s *ConfirmationStore) Finish(token, result string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[token]; ok {
+		entry.state = stateDone
+		entry.result = result
+	}
+}
+
+// Cancel отменяет ожидающую команду. Выполненную команду отменить нельзя -
+// возвращается ее результат (done=true)
+func (s *ConfirmationStore) Cancel(token string, userID int64, result string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.lookupLocked(token, userID)
+	if err != nil {
+		return "", false, err
+	}
+
+	switch entry.state {
+	case stateExecuting:
+		return "", false, errConfirmationInProgress
+	case stateDone, stateCancelled:
+		return entry.result, true, nil
+	}
+
+	entry.state = stateCancelled
+	entry.result = result
+	return result, false, nil
+}
+
+// LatestPending токен последней ожидающей подтверждения команды пользователя
+// (для ответа «да/нет» текстом вместо нажатия кнопки)
+func (s *ConfirmationStore) LatestPending(userID int64, command string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var latest *pendingCommand
+	for _, entry := range s.entries {
+		if entry.userID != userID || entry.args.Command != command || entry.state != statePending {
+			continue
+		}
+		if time.Since(entry.created) > s.ttl {
+			continue
+		}
+		if latest == nil || entry.created.After(latest.created) {
+			latest = entry
+		}
+	}
+	if latest == nil {
+		return "", false
+	}
+	return latest.token, true
+}
+
+// lookupLocked находит команду и проверяет владельца и TTL
+func (s *ConfirmationStore) lookupLocked(token string, userID int64) (*pendingCommand, error) {
+	entry, ok := s.entries[token]
+	if !ok {
+		return nil, errConfirmationExpired
+	}
+	if entry.userID != userID {
+		return nil, errConfirmationForeign
+	}
+	// Истекшую команду нельзя подтвердить, но результат выполненной еще отдаем
+	if entry.state == statePending && time.Since(entry.created) > s.ttl {
+		delete(s.entries, token)
+		return nil, errConfirmationExpired
+	}
+	return entry, nil
+}
+
+// sweepLocked удаляет записи старше двух TTL
+func (s *ConfirmationStore) sweepLocked() {
+	for token, entry := range s.entries {
+		if entry.state != stateExecuting && time.Since(entry.created) > 2*s.ttl {
+			delete(s.entries, token)
+		}
+	}
+}
+
+// parseCallbackData разбирает callback data на действие и токен
+func parseCallbackData(data string) (confirm bool, token string, ok bool) {
+	switch {
+	case strings.HasPrefix(data, callbackConfirm):
+		return true, strings.TrimPrefix(data, callbackConfirm), true
+	case strings.HasPrefix(data, callbackCancel):
+		return false, strings.TrimPrefix(data, callbackCancel), true
+	}
+	return false, "", false
+}
@@ -0,0 +1,228 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/ai/memory"
+)
+
+// Ответы пользователя на запрос подтверждения AI действий
+var (
+	confirmWords = []string{"да", "yes", "y", "ok", "ок", "подтверждаю", "confirm"}
+	rejectWords  = []string{"нет", "no", "n", "отмена", "cancel", "стоп"}
+)
+
+// isConfirmation проверяет, подтверждает ли сообщение ожидающие действия
+func isConfirmation(text string) bool {
+	return matchesWord(text, confirmWords)
+}
+
+// isRejection проверяет, отклоняет ли сообщение ожидающие действия
+func isRejection(text string) bool {
+	return matchesWord(text, rejectWords)
+}
+
+func matchesWord(text string, words []string) bool {
+	text = strings.Trim(strings.ToLower(strings.TrimSpace(text)), ".!")
+	for _, word := range words {
+		if text == word {
+			return true
+		}
+	}
+	return false
+}
+
+// actionProposal результат разбора действий AI
+type actionProposal struct {
+	immediate []ai.AIAction // чтение - выполняется сразу
+	pending   []ai.AIAction // изменения - ждут подтверждения
+	previews  []string      // diff для pending
+	denied    []string      // недостаточно прав
+}
+
+// proposeActions классифицирует действия, проверяет права и строит предпросмотр изменений
+func proposeActions(auth *AuthManager, executor *ai.ActionExecutor, userID int64, actions []ai.AIAction) *actionProposal {
+	proposal := &actionProposal{}
+
+	for _, action := range actions {
+		class := action.Class()
+		if err := auth.AuthorizeAction(userID, class); err != nil {
+			proposal.denied = append(proposal.denied, fmt.Sprintf("⛔ %s (%s): %v", action.Type, class, err))
+			continue
+		}
+
+		if class == ai.ActionClassRead {
+			proposal.immediate = append(proposal.immediate, action)
+			continue
+		}
+
+		if err := checkOrderSizes(auth, executor, userID, action); err != nil {
+			proposal.denied = append(proposal.denied, fmt.Sprintf("⛔ %s: %v", action.Type, err))
+			continue
+		}
+
+		preview, err := previewAction(executor, action)
+		if err != nil {
+			proposal.denied = append(proposal.denied, fmt.Sprintf("❌ %s: %v", action.Type, err))
+			continue
+		}
+		proposal.pending = append(proposal.pending, action)
+		proposal.previews = append(proposal.previews, preview.Format())
+	}
+
+	return proposal
+}
+
+// checkOrderSizes применяет лимит ордера роли к суммам действия; без ActionExecutor -
+// только к суммам из параметров
+func checkOrderSizes(auth *AuthManager, executor *ai.ActionExecutor, userID int64, action ai.AIAction) error {
+	sizes := ai.ParamOrderSizes(action)
+	if executor != nil {
+		var err error
+		if sizes, err = executor.OrderSizes(action); err != nil {
+			return err
+		}
+	}
+
+	for _, size := range sizes {
+		if err := auth.CheckOrderSize(userID, size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// previewAction diff через ActionExecutor; без него - только параметры
+func previewAction(executor *ai.ActionExecutor, action ai.AIAction) (*ai.ActionPreview, error) {
+	if executor == nil {
+		preview := &ai.ActionPreview{Action: action, Class: action.Class()}
+		if len(action.Parameters) > 0 {
+			preview.Notes = []string{fmt.Sprintf("параметры: %v", action.Parameters)}
+		}
+		return preview, nil
+	}
+	return executor.Preview(action)
+}
+
+// format текст для пользователя: отказы и запрос подтверждения
+func (p *actionProposal) format(footer string) string {
+	var sb strings.Builder
+	for _, line := range p.denied {
+		sb.WriteString(line + "\n")
+	}
+	if len(p.pending) > 0 {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("⚠️ Требуется подтверждение:\n\n")
+		for _, preview := range p.previews {
+			sb.WriteString(preview)
+		}
+		sb.WriteString("\n" + footer)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// SetConversationMemory подключает память диалогов (история, /forget)
+func (b *Bot) SetConversationMemory(m *memory.Memory) {
+	if m == nil {
+		return
+	}
+	b.memory = m
+	b.handlers.memory = m
+	if b.handlers.agentRouter != nil {
+		b.handlers.agentRouter.SetMemory(m)
+	}
+	b.logger.Info("Conversation memory configured")
+}
+
+// proposeAndRun выполняет чтение сразу, изменения показывает с diff и ставит
+// в очередь подтверждений роутера (кнопки или ответ «да/нет»)
+func (b *Bot) proposeAndRun(chatID, userID int64, actions []ai.AIAction) {
+	if len(actions) == 0 {
+		return
+	}
+
+	proposal := proposeActions(b.authManager, b.actionExecutor, userID, actions)
+	b.executeAIActions(chatID, proposal.immediate)
+
+	text := proposal.format(b.formatter.T("confirm_ai_actions"))
+	if len(proposal.pending) == 0 {
+		if text != "" {
+			b.SendMessage(chatID, text)
+		}
+		return
+	}
+
+	token, err := b.router.StageAIActions(userID, proposal.pending)
+	if err != nil {
+		b.SendMessage(chatID, b.formatter.FormatError(err))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = b.router.MakeConfirmationKeyboard(token)
+	if _, err := b.api.Send(msg); err != nil {
+		b.logger.Error("Failed to send confirmation: %v", err)
+	}
+}
+
+// handlePendingReply ответ «да/нет» текстом - то же, что нажатие кнопки
+// подтверждения последних ожидающих AI действий
+func (b *Bot) handlePendingReply(ctx context.Context, chatID, userID int64, text string) bool {
+	confirm := isConfirmation(text)
+	if !confirm && !isRejection(text) {
+		return false
+	}
+
+	result, ok, err := b.router.ReplyAIActions(ctx, userID, confirm)
+	if !ok {
+		return false
+	}
+	if err != nil {
+		b.requestLogger(ctx, userID, chatID).Error("AI actions confirmation error: %v", err)
+	}
+	if !confirm {
+		b.remember(ctx, userID, text, "Действия отменены")
+	}
+	b.SendMessage(chatID, result.Text)
+	return true
+}
+
+// confirmAIActions выполняет подтвержденные AI действия, повторно проверяя права
+// и лимит ордера (роль или котировка могли измениться после предложения)
+func (b *Bot) confirmAIActions(ctx context.Context, args *CommandArgs) (string, error) {
+	var results []string
+	for _, action := range args.AIActions {
+		if err := b.authManager.AuthorizeAction(args.UserID, action.Class()); err != nil {
+			results = append(results, fmt.Sprintf("⛔ %s: %s", action.Type, b.formatter.T("admin_required")))
+			continue
+		}
+		if err := checkOrderSizes(b.authManager, b.actionExecutor, args.UserID, action); err != nil {
+			results = append(results, fmt.Sprintf("⛔ %s: %v", action.Type, err))
+			continue
+		}
+		if result := b.runAIAction(action); result != "" {
+			results = append(results, result)
+		}
+	}
+
+	b.remember(ctx, args.UserID, b.formatter.T("confirm"), "Подтвержденные действия выполнены")
+	if len(results) == 0 {
+		return "✅", nil
+	}
+	return strings.Join(results, "\n\n"), nil
+}
+
+// remember сохраняет обмен репликами в память диалога (если подключена)
+func (b *Bot) remember(ctx context.Context, userID int64, userText, reply string) {
+	if b.memory == nil {
+		return
+	}
+	b.memory.Append(ctx, userID, memory.RoleUser, userText)
+	b.memory.Append(ctx, userID, memory.RoleAssistant, reply)
+}
@@ -0,0 +1,140 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/pkg/utils"
+)
+
+func TestProposeActions(t *testing.T) {
+	am := NewAuthManager("111", "")
+	actions := []ai.AIAction{
+		{Type: "get_price", Parameters: map[string]interface{}{"symbol": "BTCUSDT"}},
+		{Type: "update_dca_amount", Parameters: map[string]interface{}{"amount": 20.0}},
+		{Type: "emergency_stop"},
+	}
+
+	// Обычный пользователь: чтение сразу, изменение ждет подтверждения, опасное запрещено
+	p := proposeActions(am, nil, 222, actions)
+	if len(p.immediate) != 1 || p.immediate[0].Type != "get_price" {
+		t.Fatalf("unexpected immediate %+v", p.immediate)
+	}
+	if len(p.pending) != 1 || p.pending[0].Type != "update_dca_amount" {
+		t.Fatalf("unexpected pending %+v", p.pending)
+	}
+	if len(p.denied) != 1 || !strings.Contains(p.denied[0], "emergency_stop") {
+		t.Fatalf("unexpected denied %+v", p.denied)
+	}
+
+	// Админ может предложить опасное действие, но оно тоже ждет подтверждения
+	p = proposeActions(am, nil, 111, actions)
+	if len(p.pending) != 2 || len(p.denied) != 0 {
+		t.Fatalf("admin: pending=%+v denied=%+v", p.pending, p.denied)
+	}
+	if text := p.format("reply yes/no"); !strings.Contains(text, "🚨 emergency_stop") || !strings.HasSuffix(text, "reply yes/no") {
+		t.Fatalf("unexpected confirmation text:\n%s", text)
+	}
+}
+
+func TestAIActionsOrderCap(t *testing.T) {
+	am := NewAuthManager("111", "")
+	am.SetRoles(fakeRoles{2: domain.RoleTrader, 111: domain.RoleAdmin})
+	buy := func(amount float64) ai.AIAction {
+		return ai.AIAction{Type: "manual_buy", Parameters: map[string]interface{}{"symbol": "BTCUSDT", "amount": amount}}
+	}
+	dca := ai.AIAction{Type: "update_dca_amount", Parameters: map[string]interface{}{"amount": 100.0}}
+
+	// Трейдер (лимит $50): сумма сверх лимита отклоняется еще при предложении
+	p := proposeActions(am, nil, 2, []ai.AIAction{buy(40), buy(80), dca})
+	if len(p.pending) != 1 || p.pending[0].Parameters["amount"] != 40.0 {
+		t.Fatalf("trader pending = %+v", p.pending)
+	}
+	if len(p.denied) != 2 {
+		t.Fatalf("trader denied = %+v", p.denied)
+	}
+	if p = proposeActions(am, nil, 111, []ai.AIAction{buy(80), dca}); len(p.pending) != 2 {
+		t.Fatalf("admin pending = %+v denied = %+v", p.pending, p.denied)
+	}
+
+	// При подтверждении лимит проверяется повторно
+	b := &Bot{authManager: am, formatter: NewFormatter(LangEN), logger: utils.NewLogger("error"), previewMode: true}
+	result, _ := b.confirmAIActions(context.Background(), &CommandArgs{UserID: 2, AIActions: []ai.AIAction{buy(40), buy(80)}})
+	if !strings.Contains(result, "PREVIEW MODE") || !strings.Contains(result, "⛔ manual_buy") {
+		t.Errorf("confirm result = %q", result)
+	}
+	if strings.Count(result, "PREVIEW MODE") != 1 {
+		t.Errorf("over-cap action executed: %q", result)
+	}
+}
+
+func TestAIActionsConfirmation(t *testing.T) {
+	router := newTestRouter(nil)
+	ctx := context.Background()
+
+	var calls int
+	router.SetAIActionHandler(func(ctx context.Context, args *CommandArgs) (string, error) {
+		calls++
+		return "executed " + args.AIActions[0].Type, nil
+	})
+	actions := []ai.AIAction{{Type: "update_dca_amount", Parameters: map[string]interface{}{"amount": 20.0}}}
+
+	// Внутреннюю команду нельзя вызвать напрямую
+	if _, _, _ = router.HandleCommand(ctx, 111, "/ai_actions"); calls != 0 {
+		t.Fatalf("/ai_actions executed directly")
+	}
+
+	token, err := router.StageAIActions(111, actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Чужой «да» не подтверждает
+	if _, ok, _ := router.ReplyAIActions(ctx, 222, true); ok || calls != 0 {
+		t.Fatalf("foreign reply: ok=%v calls=%d", ok, calls)
+	}
+
+	// «да» и нажатие кнопки подтверждают одну и ту же запись - выполнение один раз
+	result, ok, err := router.ReplyAIActions(ctx, 111, true)
+	if !ok || err != nil || result.Text != "executed update_dca_amount" {
+		t.Fatalf("reply = %+v ok=%v err=%v", result, ok, err)
+	}
+	if again, _ := router.HandleCallback(ctx, 111, callbackConfirm+token); again != result || calls != 1 {
+		t.Errorf("double confirm: calls=%d again=%+v", calls, again)
+	}
+	if _, ok, _ := router.ReplyAIActions(ctx, 111, true); ok {
+		t.Errorf("second reply found pending actions")
+	}
+
+	// «нет» отменяет, после отмены кнопка не выполняет
+	token, _ = router.StageAIActions(111, actions)
+	if result, ok, _ = router.ReplyAIActions(ctx, 111, false); !ok || !strings.Contains(result.Text, "Cancelled") {
+		t.Errorf("reject = %+v ok=%v", result, ok)
+	}
+	if router.HandleCallback(ctx, 111, callbackConfirm+token); calls != 1 {
+		t.Errorf("confirm after reject executed, calls=%d", calls)
+	}
+}
+
+func TestConfirmationWords(t *testing.T) {
+	tests := []struct {
+		text    string
+		confirm bool
+		reject  bool
+	}{
+		{"Да", true, false},
+		{"yes!", true, false},
+		{"нет", false, true},
+		{"Cancel.", false, true},
+		{"да, но купи ETH", false, false},
+	}
+
+	for _, tt := range tests {
+		if isConfirmation(tt.text) != tt.confirm || isRejection(tt.text) != tt.reject {
+			t.Errorf("%q: confirm=%v reject=%v", tt.text, isConfirmation(tt.text), isRejection(tt.text))
+		}
+	}
+}
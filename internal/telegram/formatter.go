@@ -5,7 +5,9 @@ import (
 	"strings"
 	"time"
 
-	"github.com/kirillm/dca-bot/internal/storage"
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/policy"
+	"github.com/kirillm/dca-bot/internal/rbac"
 )
 
 // Lang представляет язык
@@ -42,56 +44,107 @@ func (f *Formatter) GetLang() Lang {
 // T переводит строку
 func (f *Formatter) T(key string) string {
 	translations := map[string]map[Lang]string{
-		"status":              {LangEN: "Status", LangRU: "Статус"},
-		"history":             {LangEN: "Trade History", LangRU: "История сделок"},
-		"portfolio":           {LangEN: "Portfolio", LangRU: "Портфель"},
-		"config":              {LangEN: "Configuration", LangRU: "Конфигурация"},
-		"price":               {LangEN: "Price", LangRU: "Цена"},
-		"buy":                 {LangEN: "Buy", LangRU: "Покупка"},
-		"sell":                {LangEN: "Sell", LangRU: "Продажа"},
-		"autosell":            {LangEN: "Auto-Sell", LangRU: "Авто-продажа"},
-		"grid":                {LangEN: "Grid", LangRU: "Сетка"},
-		"risk":                {LangEN: "Risk", LangRU: "Риск"},
-		"enabled":             {LangEN: "Enabled", LangRU: "Включено"},
-		"disabled":            {LangEN: "Disabled", LangRU: "Выключено"},
-		"active":              {LangEN: "Active", LangRU: "Активно"},
-		"inactive":            {LangEN: "Inactive", LangRU: "Неактивно"},
-		"success":             {LangEN: "Success", LangRU: "Успешно"},
-		"error":               {LangEN: "Error", LangRU: "Ошибка"},
-		"executing":           {LangEN: "Executing", LangRU: "Выполняется"},
-		"completed":           {LangEN: "Completed", LangRU: "Завершено"},
-		"no_position":         {LangEN: "No position", LangRU: "Нет позиции"},
-		"no_trades":           {LangEN: "No trades yet", LangRU: "Нет сделок"},
-		"current_price":       {LangEN: "Current Price", LangRU: "Текущая цена"},
-		"avg_entry":           {LangEN: "Avg Entry Price", LangRU: "Средняя цена входа"},
-		"quantity":            {LangEN: "Quantity", LangRU: "Количество"},
-		"total_invested":      {LangEN: "Total Invested", LangRU: "Всего инвестировано"},
-		"current_value":       {LangEN: "Current Value", LangRU: "Текущая стоимость"},
-		"realized_profit":     {LangEN: "Realized Profit", LangRU: "Реализованная прибыль"},
-		"unrealized_pnl":      {LangEN: "Unrealized P&L", LangRU: "Нереализованный P&L"},
-		"total_pnl":           {LangEN: "Total P&L", LangRU: "Общий P&L"},
-		"return_percent":      {LangEN: "Return", LangRU: "Доходность"},
-		"active_orders":       {LangEN: "Active Orders", LangRU: "Активные ордера"},
-		"levels":              {LangEN: "Levels", LangRU: "Уровни"},
-		"spacing":             {LangEN: "Spacing", LangRU: "Интервал"},
-		"order_size":          {LangEN: "Order Size", LangRU: "Размер ордера"},
-		"trigger":             {LangEN: "Trigger", LangRU: "Триггер"},
-		"sell_amount":         {LangEN: "Sell Amount", LangRU: "Объем продажи"},
-		"emergency_stop":      {LangEN: "Emergency Stop", LangRU: "Экстренная остановка"},
-		"max_daily_loss":      {LangEN: "Max Daily Loss", LangRU: "Макс. дневной убыток"},
-		"max_exposure":        {LangEN: "Max Exposure", LangRU: "Макс. экспозиция"},
-		"max_position_size":   {LangEN: "Max Position Size", LangRU: "Макс. размер позиции"},
-		"max_order_size":      {LangEN: "Max Order Size", LangRU: "Макс. размер ордера"},
-		"confirm_action":      {LangEN: "Please confirm this action:", LangRU: "Пожалуйста, подтвердите действие:"},
-		"confirm":             {LangEN: "Confirm", LangRU: "Подтвердить"},
-		"cancel":              {LangEN: "Cancel", LangRU: "Отмена"},
-		"access_denied":       {LangEN: "Access denied", LangRU: "Доступ запрещен"},
-		"admin_required":      {LangEN: "Admin permission required", LangRU: "Требуются права администратора"},
-		"rate_limit_exceeded": {LangEN: "Too many requests, please wait", LangRU: "Слишком много запросов, подождите"},
-		"invalid_symbol":      {LangEN: "Invalid symbol", LangRU: "Неверный символ"},
-		"invalid_amount":      {LangEN: "Invalid amount", LangRU: "Неверная сумма"},
-		"invalid_percent":     {LangEN: "Invalid percentage", LangRU: "Неверный процент"},
-		"insufficient_balance": {LangEN: "Insufficient balance", LangRU: "Недостаточный баланс"},
+		"status":                   {LangEN: "Status", LangRU: "Статус"},
+		"history":                  {LangEN: "Trade History", LangRU: "История сделок"},
+		"portfolio":                {LangEN: "Portfolio", LangRU: "Портфель"},
+		"config":                   {LangEN: "Configuration", LangRU: "Конфигурация"},
+		"price":                    {LangEN: "Price", LangRU: "Цена"},
+		"buy":                      {LangEN: "Buy", LangRU: "Покупка"},
+		"sell":                     {LangEN: "Sell", LangRU: "Продажа"},
+		"autosell":                 {LangEN: "Auto-Sell", LangRU: "Авто-продажа"},
+		"grid":                     {LangEN: "Grid", LangRU: "Сетка"},
+		"risk":                     {LangEN: "Risk", LangRU: "Риск"},
+		"enabled":                  {LangEN: "Enabled", LangRU: "Включено"},
+		"disabled":                 {LangEN: "Disabled", LangRU: "Выключено"},
+		"active":                   {LangEN: "Active", LangRU: "Активно"},
+		"inactive":                 {LangEN: "Inactive", LangRU: "Неактивно"},
+		"success":                  {LangEN: "Success", LangRU: "Успешно"},
+		"error":                    {LangEN: "Error", LangRU: "Ошибка"},
+		"executing":                {LangEN: "Executing", LangRU: "Выполняется"},
+		"completed":                {LangEN: "Completed", LangRU: "Завершено"},
+		"no_position":              {LangEN: "No position", LangRU: "Нет позиции"},
+		"no_trades":                {LangEN: "No trades yet", LangRU: "Нет сделок"},
+		"current_price":            {LangEN: "Current Price", LangRU: "Текущая цена"},
+		"avg_entry":                {LangEN: "Avg Entry Price", LangRU: "Средняя цена входа"},
+		"quantity":                 {LangEN: "Quantity", LangRU: "Количество"},
+		"total_invested":           {LangEN: "Total Invested", LangRU: "Всего инвестировано"},
+		"current_value":            {LangEN: "Current Value", LangRU: "Текущая стоимость"},
+		"realized_profit":          {LangEN: "Realized Profit", LangRU: "Реализованная прибыль"},
+		"unrealized_pnl":           {LangEN: "Unrealized P&L", LangRU: "Нереализованный P&L"},
+		"total_pnl":                {LangEN: "Total P&L", LangRU: "Общий P&L"},
+		"return_percent":           {LangEN: "Return", LangRU: "Доходность"},
+		"active_orders":            {LangEN: "Active Orders", LangRU: "Активные ордера"},
+		"levels":                   {LangEN: "Levels", LangRU: "Уровни"},
+		"spacing":                  {LangEN: "Spacing", LangRU: "Интервал"},
+		"order_size":               {LangEN: "Order Size", LangRU: "Размер ордера"},
+		"trigger":                  {LangEN: "Trigger", LangRU: "Триггер"},
+		"sell_amount":              {LangEN: "Sell Amount", LangRU: "Объем продажи"},
+		"emergency_stop":           {LangEN: "Emergency Stop", LangRU: "Экстренная остановка"},
+		"max_daily_loss":           {LangEN: "Max Daily Loss", LangRU: "Макс. дневной убыток"},
+		"max_exposure":             {LangEN: "Max Exposure", LangRU: "Макс. экспозиция"},
+		"max_position_size":        {LangEN: "Max Position Size", LangRU: "Макс. размер позиции"},
+		"max_order_size":           {LangEN: "Max Order Size", LangRU: "Макс. размер ордера"},
+		"confirm_action":           {LangEN: "Please confirm this action:", LangRU: "Пожалуйста, подтвердите действие:"},
+		"confirm":                  {LangEN: "Confirm", LangRU: "Подтвердить"},
+		"cancel":                   {LangEN: "Cancel", LangRU: "Отмена"},
+		"cancelled":                {LangEN: "Cancelled", LangRU: "Отменено"},
+		"command":                  {LangEN: "Command", LangRU: "Команда"},
+		"confirm_expires":          {LangEN: "Confirmation expires in", LangRU: "Подтверждение действует"},
+		"confirmation_expired":     {LangEN: "Confirmation expired, send the command again", LangRU: "Подтверждение истекло, отправьте команду заново"},
+		"confirmation_foreign":     {LangEN: "Only the user who sent the command can confirm it", LangRU: "Подтвердить может только отправивший команду"},
+		"confirmation_in_progress": {LangEN: "Already executing", LangRU: "Уже выполняется"},
+		"memory_cleared":           {LangEN: "Conversation history cleared", LangRU: "История диалога очищена"},
+		"confirm_ai_actions":       {LangEN: "Press Confirm or reply \"yes\" to execute, \"no\" to cancel.", LangRU: "Нажмите «Подтвердить» или ответьте «да» для выполнения, «нет» - для отмены."},
+		"access_denied":            {LangEN: "Access denied", LangRU: "Доступ запрещен"},
+		"admin_required":           {LangEN: "Admin permission required", LangRU: "Требуются права администратора"},
+		"permission_denied":        {LangEN: "Permission required", LangRU: "Требуется право"},
+		"rate_limit_exceeded":      {LangEN: "Too many requests, please wait", LangRU: "Слишком много запросов, подождите"},
+		"invalid_symbol":           {LangEN: "Invalid symbol", LangRU: "Неверный символ"},
+		"invalid_amount":           {LangEN: "Invalid amount", LangRU: "Неверная сумма"},
+		"invalid_percent":          {LangEN: "Invalid percentage", LangRU: "Неверный процент"},
+		"insufficient_balance":     {LangEN: "Insufficient balance", LangRU: "Недостаточный баланс"},
+		"unknown_command":          {LangEN: "Unknown command. Use /help to see available commands.", LangRU: "Неизвестная команда. Список команд: /help"},
+		"use_help":                 {LangEN: "Please use /help to see available commands.", LangRU: "Список команд: /help"},
+		"not_configured":           {LangEN: "not configured", LangRU: "не настроен"},
+		"bot_started":              {LangEN: "Crypto Trading Bot started!\nUse /help to see available commands.", LangRU: "Криптотрейдинг бот запущен!\nСписок команд: /help"},
+		"ai_mode":                  {LangEN: "AI Trading Mode", LangRU: "Режим AI трейдинга"},
+		"ai_decision_mode":         {LangEN: "AI Decision Mode", LangRU: "Режим DecisionAgent"},
+		"current_mode":             {LangEN: "Current mode", LangRU: "Текущий режим"},
+		"running":                  {LangEN: "Running", LangRU: "Работает"},
+		"stopped":                  {LangEN: "Stopped", LangRU: "Остановлен"},
+		"available_modes":          {LangEN: "Available modes", LangRU: "Доступные режимы"},
+		"mode_shadow":              {LangEN: "AI decides but doesn't trade (logging only)", LangRU: "AI решает, но не торгует (логирование)"},
+		"mode_pilot":               {LangEN: "trading with 50% limits (validation)", LangRU: "торговля с 50% лимитами (тестирование)"},
+		"mode_full":                {LangEN: "full autonomy within risk limits", LangRU: "полная автономия в рамках лимитов"},
+		"mode_approval_note":       {LangEN: "Promotion requires admin approval, a circuit breaker trip demotes to shadow", LangRU: "Повышение режима требует подтверждения админов, при срабатывании circuit breaker режим понижается до shadow"},
+		"mode_switched":            {LangEN: "Switched to mode", LangRU: "Переключено на режим"},
+		"mode_pending":             {LangEN: "awaiting confirmation from other admins", LangRU: "ожидает подтверждения других админов"},
+		"decisions":                {LangEN: "Recent AI Decisions", LangRU: "Последние AI решения"},
+		"no_decisions":             {LangEN: "No AI decisions yet", LangRU: "AI решений пока нет"},
+		"approved":                 {LangEN: "approved", LangRU: "одобрено"},
+		"rejected":                 {LangEN: "rejected", LangRU: "отклонено"},
+		"confidence":               {LangEN: "Confidence", LangRU: "Уверенность"},
+		"circuit_breakers":         {LangEN: "Circuit Breakers", LangRU: "Circuit Breakers"},
+		"no_active_breakers":       {LangEN: "No active circuit breakers, trading allowed", LangRU: "Активных circuit breakers нет, торговля разрешена"},
+		"active_breakers":          {LangEN: "Active", LangRU: "Активные"},
+		"recent_triggers":          {LangEN: "Recent triggers", LangRU: "Последние срабатывания"},
+		"paused_until":             {LangEN: "paused until", LangRU: "пауза до"},
+		"resumed":                  {LangEN: "resumed", LangRU: "снят"},
+		"policy":                   {LangEN: "Risk Management Policy", LangRU: "Политика риск-менеджмента"},
+		"profile":                  {LangEN: "Profile", LangRU: "Профиль"},
+		"trades_per_hour":          {LangEN: "Trades/Hour", LangRU: "Сделок в час"},
+		"slippage_threshold":       {LangEN: "Slippage Threshold", LangRU: "Порог проскальзывания"},
+		"risk_metrics":             {LangEN: "Risk Metrics", LangRU: "Метрики рисков"},
+		"total_exposure":           {LangEN: "Total Exposure", LangRU: "Общая экспозиция"},
+		"daily_loss":               {LangEN: "Daily Loss", LangRU: "Дневной убыток"},
+		"daily_trades":             {LangEN: "Daily Trades", LangRU: "Сделок за день"},
+		"drawdown":                 {LangEN: "Current Drawdown", LangRU: "Текущая просадка"},
+		"volatility":               {LangEN: "Volatility", LangRU: "Волатильность"},
+		"updated":                  {LangEN: "Updated", LangRU: "Обновлено"},
+		"ai_analysis":              {LangEN: "AI Analysis", LangRU: "AI анализ"},
+		"ai_decision_actions":      {LangEN: "Executing AI decisions", LangRU: "Исполнение AI решений"},
+		"action_failed":            {LangEN: "Action failed", LangRU: "Действие не выполнено"},
+		"help_text":                {LangEN: helpEN, LangRU: helpRU},
 	}
 
 	if trans, ok := translations[key]; ok {
@@ -154,7 +207,7 @@ func (f *Formatter) FormatStatus(data map[string]interface{}) string {
 }
 
 // FormatHistory форматирует историю сделок
-func (f *Formatter) FormatHistory(trades []storage.Trade, symbol string, limit int) string {
+func (f *Formatter) FormatHistory(trades []domain.Trade, symbol string, limit int) string {
 	var sb strings.Builder
 
 	sb.WriteString("📜 ")
@@ -338,7 +391,7 @@ func (f *Formatter) FormatGridStatus(symbol string, metrics map[string]interface
 }
 
 // FormatRiskStatus форматирует статус рисков
-func (f *Formatter) FormatRiskStatus(limits *storage.RiskLimit, currentExposure, dailyLoss float64) string {
+func (f *Formatter) FormatRiskStatus(limits *domain.RiskLimit, currentExposure, dailyLoss float64) string {
 	var sb strings.Builder
 
 	sb.WriteString("🛡️ ")
@@ -379,6 +432,155 @@ func (f *Formatter) FormatRiskStatus(limits *storage.RiskLimit, currentExposure,
 	return sb.String()
 }
 
+// FormatMode форматирует режим AI трейдинга и доступные режимы
+func (f *Formatter) FormatMode(title, mode string, running *bool) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("🧠 %s\n\n", f.T(title)))
+	sb.WriteString(fmt.Sprintf("%s: %s\n", f.T("current_mode"), mode))
+	if running != nil {
+		status := "🔴 " + f.T("stopped")
+		if *running {
+			status = "🟢 " + f.T("running")
+		}
+		sb.WriteString(fmt.Sprintf("%s: %s\n", f.T("status"), status))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n%s:\n", f.T("available_modes")))
+	for _, m := range []string{"shadow", "pilot", "full"} {
+		sb.WriteString(fmt.Sprintf("• %s - %s\n", m, f.T("mode_"+m)))
+	}
+	sb.WriteString("\n" + f.T("mode_approval_note"))
+
+	return sb.String()
+}
+
+// FormatDecisions форматирует последние решения AI
+func (f *Formatter) FormatDecisions(decisions []domain.AIDecision) string {
+	var sb strings.Builder
+
+	sb.WriteString("🧠 ")
+	sb.WriteString(f.T("decisions"))
+	sb.WriteString("\n\n")
+
+	if len(decisions) == 0 {
+		sb.WriteString(f.T("no_decisions"))
+		return sb.String()
+	}
+
+	for _, d := range decisions {
+		status := "✅ " + f.T("approved")
+		if !d.Approved {
+			status = "⛔ " + f.T("rejected")
+		}
+
+		sb.WriteString(fmt.Sprintf("%s %s [%s] %s\n", d.Timestamp.Format("2006-01-02 15:04"), d.Regime, d.Mode, status))
+		sb.WriteString(fmt.Sprintf("   %s: %.2f", f.T("confidence"), d.Confidence))
+		if d.PromptVersion != "" {
+			sb.WriteString(fmt.Sprintf(", %s", d.PromptVersion))
+		}
+		sb.WriteString("\n")
+		if !d.Approved && d.RejectionReason != "" {
+			sb.WriteString(fmt.Sprintf("   %s\n", d.RejectionReason))
+		}
+	}
+
+	return sb.String()
+}
+
+// FormatCircuitBreakers форматирует активные и последние срабатывания circuit breakers
+func (f *Formatter) FormatCircuitBreakers(active, recent []domain.CircuitBreakerEvent) string {
+	var sb strings.Builder
+
+	sb.WriteString("🛡️ ")
+	sb.WriteString(f.T("circuit_breakers"))
+	sb.WriteString("\n\n")
+
+	if len(active) == 0 {
+		sb.WriteString("🟢 " + f.T("no_active_breakers") + "\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("🚨 %s:\n", f.T("active_breakers")))
+		for _, e := range active {
+			sb.WriteString(fmt.Sprintf("• %s - %s %s\n", e.Reason, f.T("paused_until"), e.PausedUntil.Format("2006-01-02 15:04")))
+		}
+	}
+
+	if len(recent) > 0 {
+		sb.WriteString(fmt.Sprintf("\n%s:\n", f.T("recent_triggers")))
+		for _, e := range recent {
+			sb.WriteString(fmt.Sprintf("• %s %s", e.TriggeredAt.Format("2006-01-02 15:04"), e.Reason))
+			if !e.ResumedAt.IsZero() {
+				sb.WriteString(fmt.Sprintf(" (%s %s)", f.T("resumed"), e.ResumedAt.Format("15:04")))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// FormatPolicy форматирует текущую политику рисков
+func (f *Formatter) FormatPolicy(p *policy.Policy) string {
+	var sb strings.Builder
+
+	sb.WriteString("⚙️ ")
+	sb.WriteString(f.T("policy"))
+	sb.WriteString("\n\n")
+
+	sb.WriteString(fmt.Sprintf("%s: %s\n\n", f.T("profile"), p.ProfileName))
+	sb.WriteString(fmt.Sprintf("%s: $%.2f\n", f.T("max_order_size"), p.MaxOrderUSDT))
+	sb.WriteString(fmt.Sprintf("%s: $%.2f\n", f.T("max_position_size"), p.MaxPositionUSDT))
+	sb.WriteString(fmt.Sprintf("%s: $%.2f\n", f.T("max_exposure"), p.MaxTotalExposure))
+	sb.WriteString(fmt.Sprintf("%s: $%.2f\n", f.T("max_daily_loss"), p.MaxDailyLossUSDT))
+	sb.WriteString(fmt.Sprintf("%s: %d\n", f.T("trades_per_hour"), p.TradesPerHour))
+	if p.SlippageThreshold > 0 {
+		sb.WriteString(fmt.Sprintf("%s: %.2f%%\n", f.T("slippage_threshold"), p.SlippageThreshold))
+	}
+
+	if len(p.CircuitBreakers) > 0 {
+		sb.WriteString(fmt.Sprintf("\n%s:\n", f.T("circuit_breakers")))
+		for _, cb := range p.CircuitBreakers {
+			sb.WriteString(fmt.Sprintf("• %s ≥ %.2f → %s\n", cb.Type, cb.Threshold, cb.Action))
+		}
+	}
+
+	return sb.String()
+}
+
+// FormatRiskMetrics форматирует текущие метрики рисков
+func (f *Formatter) FormatRiskMetrics(m *policy.RiskMetrics) string {
+	var sb strings.Builder
+
+	sb.WriteString("📊 ")
+	sb.WriteString(f.T("risk_metrics"))
+	sb.WriteString("\n\n")
+
+	sb.WriteString(fmt.Sprintf("%s: $%.2f\n", f.T("total_exposure"), m.TotalExposureUSDT))
+	sb.WriteString(fmt.Sprintf("%s: $%.2f\n", f.T("daily_loss"), m.DailyLossUSDT))
+	sb.WriteString(fmt.Sprintf("%s: %d\n", f.T("daily_trades"), m.DailyTradeCount))
+	sb.WriteString(fmt.Sprintf("%s: %.2f%%\n", f.T("drawdown"), m.CurrentDrawdown))
+	sb.WriteString(fmt.Sprintf("%s: %.2f%%\n", f.T("volatility"), m.VolatilityPct))
+	if !m.LastUpdated.IsZero() {
+		sb.WriteString(fmt.Sprintf("\n%s: %s\n", f.T("updated"), m.LastUpdated.Format("2006-01-02 15:04:05")))
+	}
+
+	return sb.String()
+}
+
+// FormatCommandPreview описание отложенной команды для подтверждения
+func (f *Formatter) FormatCommandPreview(args *CommandArgs) string {
+	command := "/" + args.Command
+	if len(args.Raw) > 0 {
+		command += " " + strings.Join(args.Raw, " ")
+	}
+	return fmt.Sprintf("%s: %s\n⏳ %s %s", f.T("command"), command, f.T("confirm_expires"), confirmationTTL)
+}
+
+// FormatPermissionDenied ответ на команду, недоступную роли пользователя
+func (f *Formatter) FormatPermissionDenied(perm rbac.Permission) string {
+	return fmt.Sprintf("⛔ %s: %s", f.T("permission_denied"), perm)
+}
+
 // FormatError форматирует сообщение об ошибке
 func (f *Formatter) FormatError(err error) string {
 	return fmt.Sprintf("❌ %s: %v", f.T("error"), err)
@@ -5,7 +5,8 @@ import (
 	"testing"
 	"time"
 
-	"github.com/kirillm/dca-bot/internal/storage"
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/policy"
 )
 
 func TestFormatter_T(t *testing.T) {
@@ -74,7 +75,7 @@ func TestFormatter_FormatStatus(t *testing.T) {
 func TestFormatter_FormatHistory(t *testing.T) {
 	f := NewFormatter(LangEN)
 
-	trades := []storage.Trade{
+	trades := []domain.Trade{
 		{
 			Symbol:       "BTCUSDT",
 			Side:         "BUY",
@@ -115,7 +116,7 @@ func TestFormatter_FormatHistory(t *testing.T) {
 func TestFormatter_FormatHistory_Empty(t *testing.T) {
 	f := NewFormatter(LangEN)
 
-	result := f.FormatHistory([]storage.Trade{}, "", 10)
+	result := f.FormatHistory([]domain.Trade{}, "", 10)
 
 	if !strings.Contains(result, "No trades") {
 		t.Error("Empty history should contain 'No trades' message")
@@ -225,7 +226,7 @@ func TestFormatter_FormatGridStatus(t *testing.T) {
 func TestFormatter_FormatRiskStatus(t *testing.T) {
 	f := NewFormatter(LangEN)
 
-	limits := &storage.RiskLimit{
+	limits := &domain.RiskLimit{
 		MaxDailyLoss:        1000.0,
 		MaxTotalExposure:    10000.0,
 		MaxPositionSizeUSD:  5000.0,
@@ -253,7 +254,7 @@ func TestFormatter_FormatRiskStatus(t *testing.T) {
 func TestFormatter_FormatRiskStatus_EmergencyStop(t *testing.T) {
 	f := NewFormatter(LangEN)
 
-	limits := &storage.RiskLimit{
+	limits := &domain.RiskLimit{
 		MaxDailyLoss:        1000.0,
 		MaxTotalExposure:    10000.0,
 		MaxPositionSizeUSD:  5000.0,
@@ -269,6 +270,87 @@ func TestFormatter_FormatRiskStatus_EmergencyStop(t *testing.T) {
 	}
 }
 
+func TestFormatter_FormatMode(t *testing.T) {
+	running := true
+	result := NewFormatter(LangRU).FormatMode("ai_mode", "pilot", &running)
+
+	for _, want := range []string{"Режим AI трейдинга", "Текущий режим: pilot", "🟢 Работает", "• full - "} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Mode should contain %q:\n%s", want, result)
+		}
+	}
+
+	// Режим DecisionAgent - без статуса orchestrator
+	if result := NewFormatter(LangEN).FormatMode("ai_decision_mode", "shadow", nil); strings.Contains(result, "Running") || strings.Contains(result, "Stopped") {
+		t.Errorf("Decision mode should not contain orchestrator status:\n%s", result)
+	}
+}
+
+func TestFormatter_FormatDecisions(t *testing.T) {
+	f := NewFormatter(LangEN)
+
+	decisions := []domain.AIDecision{
+		{Timestamp: time.Now(), Regime: "ACCUMULATE", Mode: "pilot", Confidence: 0.82, Approved: true, PromptVersion: "decision@v2"},
+		{Timestamp: time.Now(), Regime: "DEFENSE", Mode: "pilot", Confidence: 0.4, RejectionReason: "max_order_usdt exceeded"},
+	}
+
+	result := f.FormatDecisions(decisions)
+	for _, want := range []string{"ACCUMULATE [pilot] ✅ approved", "Confidence: 0.82, decision@v2", "DEFENSE [pilot] ⛔ rejected", "max_order_usdt exceeded"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Decisions should contain %q:\n%s", want, result)
+		}
+	}
+
+	if result := f.FormatDecisions(nil); !strings.Contains(result, "No AI decisions yet") {
+		t.Errorf("Empty decisions = %q", result)
+	}
+}
+
+func TestFormatter_FormatCircuitBreakers(t *testing.T) {
+	f := NewFormatter(LangEN)
+
+	if result := f.FormatCircuitBreakers(nil, nil); !strings.Contains(result, "🟢 No active circuit breakers") {
+		t.Errorf("No breakers = %q", result)
+	}
+
+	triggered := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	active := []domain.CircuitBreakerEvent{{Reason: "drawdown", TriggeredAt: triggered, PausedUntil: triggered.Add(time.Hour)}}
+	recent := append(active, domain.CircuitBreakerEvent{Reason: "volatility", TriggeredAt: triggered.Add(-time.Hour), ResumedAt: triggered.Add(-30 * time.Minute)})
+
+	result := f.FormatCircuitBreakers(active, recent)
+	for _, want := range []string{"🚨 Active:", "drawdown - paused until 2026-03-01 11:00", "volatility (resumed 09:30)"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Breakers should contain %q:\n%s", want, result)
+		}
+	}
+}
+
+func TestFormatter_FormatPolicyAndMetrics(t *testing.T) {
+	f := NewFormatter(LangEN)
+
+	p := &policy.Policy{
+		ProfileName:      "moderate",
+		MaxOrderUSDT:     100,
+		MaxTotalExposure: 3000,
+		TradesPerHour:    5,
+		CircuitBreakers:  []policy.CircuitBreaker{{Type: "drawdown", Threshold: 10, Action: "pause"}},
+	}
+	result := f.FormatPolicy(p)
+	for _, want := range []string{"Profile: moderate", "Max Order Size: $100.00", "Trades/Hour: 5", "drawdown ≥ 10.00 → pause"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Policy should contain %q:\n%s", want, result)
+		}
+	}
+
+	m := &policy.RiskMetrics{TotalExposureUSDT: 1250, DailyTradeCount: 3, CurrentDrawdown: 4.5}
+	result = f.FormatRiskMetrics(m)
+	for _, want := range []string{"Total Exposure: $1250.00", "Daily Trades: 3", "Current Drawdown: 4.50%"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Metrics should contain %q:\n%s", want, result)
+		}
+	}
+}
+
 func TestFormatter_FormatError(t *testing.T) {
 	f := NewFormatter(LangEN)
 
@@ -334,7 +416,8 @@ func TestFormatter_Languages(t *testing.T) {
 	enFormatter := NewFormatter(LangEN)
 	ruFormatter := NewFormatter(LangRU)
 
-	keys := []string{"status", "history", "portfolio", "buy", "sell", "error", "success"}
+	keys := []string{"status", "history", "portfolio", "buy", "sell", "error", "success",
+		"unknown_command", "not_configured", "decisions", "policy", "risk_metrics", "help_text"}
 
 	for _, key := range keys {
 		enTranslation := enFormatter.T(key)
@@ -5,7 +5,13 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/ai/agents"
+	"github.com/kirillm/dca-bot/internal/ai/memory"
+	"github.com/kirillm/dca-bot/internal/ai/prompts"
+	"github.com/kirillm/dca-bot/internal/domain"
 	"github.com/kirillm/dca-bot/internal/exchange"
+	"github.com/kirillm/dca-bot/internal/marketdata"
 	"github.com/kirillm/dca-bot/internal/storage"
 	"github.com/kirillm/dca-bot/internal/strategy"
 )
@@ -13,24 +19,48 @@ import (
 // Handlers содержит все обработчики команд
 type Handlers struct {
 	exchange         *exchange.BybitClient
-	storage          *storage.PostgresStorage
+	storage          *storage.Storage
 	validator        *Validator
 	formatter        *Formatter
+	authManager      *AuthManager
 	dcaStrategy      *strategy.DCAStrategy
 	autoSell         *strategy.AutoSellStrategy
 	gridStrategy     *strategy.GridStrategy
 	portfolioManager *strategy.PortfolioManager
 	riskManager      *strategy.RiskManager
+	trader           *strategy.ManualTrader
 	defaultSymbol    string
 	startTime        time.Time
+
+	// Stage 4: автономная торговля (nil - команды отвечают "не настроен")
+	orchestrator Orchestrator
+	policyEngine PolicyEngine
+	decisions    DecisionStore
+	breakers     BreakerStore
+
+	// Stage 5: Hybrid AI
+	aiClient       *ai.AIClient // legacy анализ без AnalysisAgent
+	marketData     *marketdata.Service
+	newsSource     NewsSource
+	agentRouter    *agents.AgentRouter
+	analysisAgent  *agents.AnalysisAgent
+	decisionAgent  *agents.DecisionAgent
+	actionExecutor *ai.ActionExecutor
+	memory         *memory.Memory
+	prompts        *prompts.Registry
+	promptStats    PromptStats
+	reports        ReportGenerator
+	access         AccessControl
+	apiAudit       APIAuditReader
 }
 
 // NewHandlers создает новый набор обработчиков
 func NewHandlers(
 	exchange *exchange.BybitClient,
-	storage *storage.PostgresStorage,
+	storage *storage.Storage,
 	validator *Validator,
 	formatter *Formatter,
+	authManager *AuthManager,
 	dcaStrategy *strategy.DCAStrategy,
 	autoSell *strategy.AutoSellStrategy,
 	gridStrategy *strategy.GridStrategy,
@@ -43,11 +73,13 @@ func NewHandlers(
 		storage:          storage,
 		validator:        validator,
 		formatter:        formatter,
+		authManager:      authManager,
 		dcaStrategy:      dcaStrategy,
 		autoSell:         autoSell,
 		gridStrategy:     gridStrategy,
 		portfolioManager: portfolioManager,
 		riskManager:      riskManager,
+		trader:           strategy.NewManualTrader(storage, exchange),
 		defaultSymbol:    defaultSymbol,
 		startTime:        time.Now(),
 	}
@@ -56,7 +88,7 @@ func NewHandlers(
 // HandleStatus обрабатывает команду /status
 func (h *Handlers) HandleStatus(ctx context.Context, args *CommandArgs) (string, error) {
 	// Получаем активные активы
-	assets, err := h.storage.GetEnabledAssets()
+	assets, err := h.storage.Assets().GetEnabled(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to get active assets: %w", err)
 	}
@@ -85,7 +117,7 @@ func (h *Handlers) HandleStatus(ctx context.Context, args *CommandArgs) (string,
 	// Проверяем активность Grid
 	gridActive := false
 	for _, asset := range assets {
-		gridOrders, err := h.storage.GetActiveGridOrders(asset.Symbol)
+		gridOrders, err := h.storage.GridOrders().GetActive(ctx, asset.Symbol)
 		if err == nil && len(gridOrders) > 0 {
 			gridActive = true
 			break
@@ -115,13 +147,13 @@ func (h *Handlers) HandleHistory(ctx context.Context, args *CommandArgs) (string
 		limit = 10
 	}
 
-	var trades []storage.Trade
+	var trades []domain.Trade
 	var err error
 
 	if symbol == "" || symbol == "ALL" {
-		trades, err = h.storage.GetAllRecentTrades(limit)
+		trades, err = h.storage.Trades().GetAllRecent(ctx, limit)
 	} else {
-		trades, err = h.storage.GetRecentTrades(symbol, limit)
+		trades, err = h.storage.Trades().GetRecent(ctx, symbol, limit)
 	}
 
 	if err != nil {
@@ -134,7 +166,7 @@ func (h *Handlers) HandleHistory(ctx context.Context, args *CommandArgs) (string
 // HandleConfig обрабатывает команду /config
 func (h *Handlers) HandleConfig(ctx context.Context, args *CommandArgs) (string, error) {
 	// Получаем конфигурацию из активов
-	assets, err := h.storage.GetEnabledAssets()
+	assets, err := h.storage.Assets().GetEnabled(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -178,7 +210,7 @@ func (h *Handlers) HandleConfig(ctx context.Context, args *CommandArgs) (string,
 	}
 
 	// Risk limits
-	limits, err := h.storage.GetRiskLimits()
+	limits, err := h.storage.Risk().GetLimits(ctx)
 	if err == nil {
 		response += "🛡️ Risk Limits:\n"
 		response += fmt.Sprintf("  Max Order Size: $%.2f\n", limits.MaxOrderSizeUSD)
@@ -208,7 +240,7 @@ func (h *Handlers) HandlePrice(ctx context.Context, args *CommandArgs) (string,
 	}
 
 	// Получаем баланс если есть позиция
-	balance, err := h.storage.GetBalance(symbol)
+	balance, err := h.storage.Balances().Get(ctx, symbol)
 	hasPosition := err == nil && balance != nil && balance.TotalQuantity > 0
 
 	avgEntry := 0.0
@@ -243,67 +275,31 @@ func (h *Handlers) HandleBuy(ctx context.Context, args *CommandArgs) (string, er
 	amount := args.Amount
 	if amount == 0 {
 		// Используем дефолтную сумму из конфига актива
-		asset, err := h.storage.GetAsset(symbol)
+		asset, err := h.storage.Assets().Get(ctx, symbol)
 		if err != nil || asset == nil {
 			return "", fmt.Errorf("asset %s not configured", symbol)
 		}
 		amount = asset.DCAAmount
 	}
 
+	// Лимит ордера по роли
+	if err := h.authManager.CheckOrderSize(args.UserID, amount); err != nil {
+		return "", err
+	}
+
 	// Валидация
 	if err := h.validator.ValidateBuy(symbol, amount); err != nil {
 		return "", err
 	}
 
 	// Выполняем покупку
-	currentPrice, err := h.exchange.GetCurrentPrice(symbol)
+	trade, err := h.trader.Buy(ctx, symbol, amount)
 	if err != nil {
-		return "", fmt.Errorf("failed to get price: %w", err)
-	}
-
-	quantity := amount / currentPrice
-
-	orderInfo, err := h.exchange.PlaceOrder(symbol, "BUY", quantity)
-	if err != nil {
-		return "", fmt.Errorf("failed to place order: %w", err)
-	}
-
-	// Сохраняем сделку
-	trade := &storage.Trade{
-		Symbol:       symbol,
-		Side:         "BUY",
-		Quantity:     quantity,
-		Price:        currentPrice,
-		Amount:       amount,
-		OrderID:      orderInfo.OrderID,
-		Status:       "FILLED",
-		StrategyType: "MANUAL",
-		CreatedAt:    time.Now(),
-	}
-
-	if err := h.storage.SaveTrade(trade); err != nil {
-		return "", fmt.Errorf("failed to save trade: %w", err)
-	}
-
-	// Обновляем баланс
-	balance, _ := h.storage.GetBalance(symbol)
-	if balance == nil {
-		balance = &storage.Balance{Symbol: symbol}
-	}
-
-	newTotalQty := balance.TotalQuantity + quantity
-	newInvested := balance.TotalInvested + amount
-	balance.AvgEntryPrice = newInvested / newTotalQty
-	balance.TotalQuantity = newTotalQty
-	balance.AvailableQty = newTotalQty
-	balance.TotalInvested = newInvested
-
-	if err := h.storage.UpdateBalance(balance); err != nil {
-		return "", fmt.Errorf("failed to update balance: %w", err)
+		return "", err
 	}
 
 	return h.formatter.FormatSuccess(fmt.Sprintf("Bought %.8f %s at $%.2f (total: $%.2f)",
-		quantity, symbol, currentPrice, amount)), nil
+		trade.Quantity, symbol, trade.Price, trade.Amount)), nil
 }
 
 // HandleSell обрабатывает команду /sell
@@ -323,60 +319,41 @@ func (h *Handlers) HandleSell(ctx context.Context, args *CommandArgs) (string, e
 		return "", err
 	}
 
-	// Получаем баланс
-	balance, err := h.storage.GetBalance(symbol)
+	// Лимит ордера по роли
+	sellQuantity, currentPrice, err := h.trader.Quote(ctx, symbol, percent)
 	if err != nil {
 		return "", err
 	}
-
-	// Вычисляем количество для продажи
-	sellQuantity := balance.AvailableQty * (percent / 100.0)
-
-	currentPrice, err := h.exchange.GetCurrentPrice(symbol)
-	if err != nil {
-		return "", fmt.Errorf("failed to get price: %w", err)
+	if err := h.authManager.CheckOrderSize(args.UserID, sellQuantity*currentPrice); err != nil {
+		return "", err
 	}
 
-	// Размещаем ордер
-	orderInfo, err := h.exchange.PlaceOrder(symbol, "SELL", sellQuantity)
+	result, err := h.trader.Sell(ctx, symbol, percent)
 	if err != nil {
-		return "", fmt.Errorf("failed to place order: %w", err)
+		return "", err
 	}
 
-	sellAmount := sellQuantity * currentPrice
+	return h.formatter.FormatSuccess(fmt.Sprintf("Sold %.8f %s (%.0f%%) at $%.2f\nProfit: $%.2f",
+		result.Trade.Quantity, symbol, percent, result.Trade.Price, result.Profit)), nil
+}
 
-	// Сохраняем сделку
-	trade := &storage.Trade{
-		Symbol:       symbol,
-		Side:         "SELL",
-		Quantity:     sellQuantity,
-		Price:        currentPrice,
-		Amount:       sellAmount,
-		OrderID:      orderInfo.OrderID,
-		Status:       "FILLED",
-		StrategyType: "MANUAL",
-		CreatedAt:    time.Now(),
+// PreviewSell показывает объем /sell до подтверждения, ордер не размещается
+func (h *Handlers) PreviewSell(ctx context.Context, args *CommandArgs) (string, error) {
+	symbol := args.Symbol
+	if symbol == "" {
+		symbol = h.defaultSymbol
 	}
 
-	if err := h.storage.SaveTrade(trade); err != nil {
-		return "", fmt.Errorf("failed to save trade: %w", err)
+	sellQuantity, currentPrice, err := h.trader.Quote(ctx, symbol, args.Percent)
+	if err != nil {
+		return "", err
 	}
-
-	// Обновляем баланс
-	costBasis := sellQuantity * balance.AvgEntryPrice
-	profit := sellAmount - costBasis
-
-	balance.TotalQuantity -= sellQuantity
-	balance.AvailableQty -= sellQuantity
-	balance.TotalSold += sellAmount
-	balance.RealizedProfit += profit
-
-	if err := h.storage.UpdateBalance(balance); err != nil {
-		return "", fmt.Errorf("failed to update balance: %w", err)
+	if err := h.authManager.CheckOrderSize(args.UserID, sellQuantity*currentPrice); err != nil {
+		return "", err
 	}
 
-	return h.formatter.FormatSuccess(fmt.Sprintf("Sold %.8f %s (%.0f%%) at $%.2f\nProfit: $%.2f",
-		sellQuantity, symbol, percent, currentPrice, profit)), nil
+	return fmt.Sprintf("Sell %.8f %s (%.0f%%) ≈ $%.2f at $%.2f",
+		sellQuantity, symbol, args.Percent, sellQuantity*currentPrice, currentPrice), nil
 }
 
 // HandleAutoSellOn обрабатывает команду /autosellon
@@ -386,13 +363,13 @@ func (h *Handlers) HandleAutoSellOn(ctx context.Context, args *CommandArgs) (str
 		symbol = h.defaultSymbol
 	}
 
-	asset, err := h.storage.GetAsset(symbol)
+	asset, err := h.storage.Assets().Get(ctx, symbol)
 	if err != nil || asset == nil {
 		return "", fmt.Errorf("asset %s not configured", symbol)
 	}
 
 	asset.AutoSellEnabled = true
-	if err := h.storage.CreateOrUpdateAsset(asset); err != nil {
+	if err := h.storage.Assets().CreateOrUpdate(ctx, asset); err != nil {
 		return "", err
 	}
 
@@ -406,13 +383,13 @@ func (h *Handlers) HandleAutoSellOff(ctx context.Context, args *CommandArgs) (st
 		symbol = h.defaultSymbol
 	}
 
-	asset, err := h.storage.GetAsset(symbol)
+	asset, err := h.storage.Assets().Get(ctx, symbol)
 	if err != nil || asset == nil {
 		return "", fmt.Errorf("asset %s not configured", symbol)
 	}
 
 	asset.AutoSellEnabled = false
-	if err := h.storage.CreateOrUpdateAsset(asset); err != nil {
+	if err := h.storage.Assets().CreateOrUpdate(ctx, asset); err != nil {
 		return "", err
 	}
 
@@ -434,7 +411,7 @@ func (h *Handlers) HandleAutoSell(ctx context.Context, args *CommandArgs) (strin
 		return "", err
 	}
 
-	asset, err := h.storage.GetAsset(symbol)
+	asset, err := h.storage.Assets().Get(ctx, symbol)
 	if err != nil || asset == nil {
 		return "", fmt.Errorf("asset %s not configured", symbol)
 	}
@@ -443,7 +420,7 @@ func (h *Handlers) HandleAutoSell(ctx context.Context, args *CommandArgs) (strin
 	asset.AutoSellAmountPercent = sellPercent
 	asset.AutoSellEnabled = true
 
-	if err := h.storage.CreateOrUpdateAsset(asset); err != nil {
+	if err := h.storage.Assets().CreateOrUpdate(ctx, asset); err != nil {
 		return "", err
 	}
 
@@ -463,10 +440,15 @@ func (h *Handlers) HandleGridInit(ctx context.Context, args *CommandArgs) (strin
 		return "", err
 	}
 
+	// Лимит ордера по роли - на каждый ордер сетки
+	if err := h.authManager.CheckOrderSize(args.UserID, orderSize); err != nil {
+		return "", err
+	}
+
 	// Получаем или создаем актив
-	asset, err := h.storage.GetAsset(symbol)
+	asset, err := h.storage.Assets().Get(ctx, symbol)
 	if err != nil || asset == nil {
-		asset = &storage.Asset{
+		asset = &domain.Asset{
 			Symbol:             symbol,
 			Enabled:            true,
 			StrategyType:       "GRID",
@@ -476,14 +458,14 @@ func (h *Handlers) HandleGridInit(ctx context.Context, args *CommandArgs) (strin
 			GridSpacingPercent: spacing,
 			GridOrderSize:      orderSize,
 		}
-		if err := h.storage.CreateOrUpdateAsset(asset); err != nil {
+		if err := h.storage.Assets().CreateOrUpdate(ctx, asset); err != nil {
 			return "", err
 		}
 	} else {
 		asset.GridLevels = levels
 		asset.GridSpacingPercent = spacing
 		asset.GridOrderSize = orderSize
-		if err := h.storage.CreateOrUpdateAsset(asset); err != nil {
+		if err := h.storage.Assets().CreateOrUpdate(ctx, asset); err != nil {
 			return "", err
 		}
 	}
@@ -520,7 +502,7 @@ func (h *Handlers) HandleGridStop(ctx context.Context, args *CommandArgs) (strin
 	}
 
 	// Отменяем все Grid ордера
-	if err := h.storage.CancelGridOrders(symbol); err != nil {
+	if err := h.storage.GridOrders().CancelAll(ctx, symbol); err != nil {
 		return "", fmt.Errorf("failed to cancel grid orders: %w", err)
 	}
 
@@ -538,7 +520,7 @@ func (h *Handlers) HandleRisk(ctx context.Context, args *CommandArgs) (string, e
 		return "", fmt.Errorf("failed to get risk status: %w", err)
 	}
 
-	limits, err := h.storage.GetRiskLimits()
+	limits, err := h.storage.Risk().GetLimits(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -558,7 +540,7 @@ func (h *Handlers) HandlePanicStop(ctx context.Context, args *CommandArgs) (stri
 	action := normalizeAction(args.Action)
 
 	if action == "status" || action == "" {
-		limits, err := h.storage.GetRiskLimits()
+		limits, err := h.storage.Risk().GetLimits(ctx)
 		if err != nil {
 			return "", err
 		}
@@ -586,7 +568,11 @@ func (h *Handlers) HandlePanicStop(ctx context.Context, args *CommandArgs) (stri
 
 // HandleHelp обрабатывает команду /help
 func (h *Handlers) HandleHelp(ctx context.Context, args *CommandArgs) (string, error) {
-	help := `🤖 Crypto Trading Bot Commands
+	return h.formatter.T("help_text"), nil
+}
+
+// Справка по всем командам бота
+const helpEN = `🤖 Crypto Trading Bot Commands
 
 📊 INFORMATION:
 /status - Current status and active strategies
@@ -594,6 +580,7 @@ func (h *Handlers) HandleHelp(ctx context.Context, args *CommandArgs) (string, e
 /config - Current configuration
 /price <SYMBOL> - Current market price
 /portfolio - Portfolio overview with P&L
+/report [daily|weekly|monthly] - Portfolio report with AI summary
 
 💰 TRADING:
 /buy [SYMBOL] [AMOUNT] - Execute buy order
@@ -611,11 +598,28 @@ func (h *Handlers) HandleHelp(ctx context.Context, args *CommandArgs) (string, e
 /gridinit <SYMBOL> <LEVELS> <SPACING%> <SIZE> - Init Grid
   Example: /gridinit ETHUSDT 10 2.5 100
 /gridstatus <SYMBOL> - Grid status
-/gridstop <SYMBOL> - Stop Grid (Admin only)
+/gridstop <SYMBOL> - Stop Grid (Risk officer)
+
+🧠 AUTONOMOUS AI (Stage 4):
+/mode [shadow|pilot|full] - Show or switch Orchestrator mode (shadow: Risk officer, pilot/full: Admin)
+/mode_shadow, /mode_pilot, /mode_full - Switch mode (shadow: Risk officer, pilot/full: Admin)
+/decisions [N] - Recent AI decisions
+/circuit - Circuit breaker status
+/policy - Current risk policy
+/metrics - Risk metrics
+
+💬 HYBRID AI (Stage 5):
+/analysis [SYMBOL], /ai_analyze [SYMBOL] - Market analysis (local model)
+/ai_decision - Strategic decision (cloud model, Admin only)
+/ai_metrics - AI agent metrics
+/ai_mode [shadow|pilot|full] - DecisionAgent mode (switch: same as /mode)
+/prompts [use|ab|reload] - AI prompt versions and A/B tests (Admin only)
+/users [add|remove|key|revoke] - Users, roles and API keys (Admin only)
 
 🛡️ RISK & ADMIN:
-/risk - Risk limits and exposure
-/panicstop [on|off] - Emergency stop (Admin only)
+/risk - Risk limits and exposure (Risk officer)
+/panicstop [on|off] - Emergency stop (Risk officer)
+Roles: viewer - read only, trader - trading and grid, risk_officer - risk controls, admin - everything
 
 🧠 AI NATURAL LANGUAGE:
 Just send a message:
@@ -623,10 +627,66 @@ Just send a message:
 • "Sell 50% of ETH"
 • "Set auto-sell at +15%"
 • "Show portfolio"
-• "Купи BTC на 20 USDT"
-• "Продай 30% позиции"
+Trades and setting changes ask for "yes" first.
+/forget - Clear AI conversation history
 
 Supports English and Russian! 🇬🇧🇷🇺`
 
-	return help, nil
-}
+const helpRU = `🤖 AI Криптотрейдинг Бот
+
+📊 МОНИТОРИНГ:
+/status - Текущий статус и активные стратегии
+/history [SYMBOL] [N] - Последние сделки (по умолчанию 10)
+/config - Текущая конфигурация
+/price <SYMBOL> - Текущая цена актива
+/portfolio - Обзор портфеля с P&L
+/report [daily|weekly|monthly] - Отчет по портфелю с резюме AI
+
+💰 ТОРГОВЛЯ:
+/buy [SYMBOL] [AMOUNT] - Покупка
+  Пример: /buy BTCUSDT 20
+/sell <PERCENT> [SYMBOL] - Продажа % позиции
+  Пример: /sell 50 BTCUSDT
+
+⚙️ AUTO-SELL:
+/autosellon [SYMBOL] - Включить Auto-Sell
+/autoselloff [SYMBOL] - Выключить Auto-Sell
+/autosell [SYMBOL] <TRIGGER%> <SELL%> - Настроить
+  Пример: /autosell BTCUSDT 15 50
+
+🔷 GRID:
+/gridinit <SYMBOL> <LEVELS> <SPACING%> <SIZE> - Инициализировать Grid
+  Пример: /gridinit ETHUSDT 10 2.5 100
+/gridstatus <SYMBOL> - Статус Grid
+/gridstop <SYMBOL> - Остановить Grid (риск-офицер)
+
+🧠 АВТОНОМНЫЙ AI (Stage 4):
+/mode [shadow|pilot|full] - Режим Orchestrator (shadow - риск-офицер, pilot/full - админ)
+/mode_shadow, /mode_pilot, /mode_full - Переключить режим (shadow - риск-офицер, pilot/full - админ)
+/decisions [N] - История AI решений
+/circuit - Статус circuit breakers
+/policy - Текущая политика рисков
+/metrics - Метрики рисков
+
+💬 HYBRID AI (Stage 5):
+/analysis [SYMBOL], /ai_analyze [SYMBOL] - Рыночный анализ (локальная модель)
+/ai_decision - Стратегическое решение (облачная модель, админ)
+/ai_metrics - Метрики AI агентов
+/ai_mode [shadow|pilot|full] - Режим DecisionAgent (переключение - как /mode)
+/prompts [use|ab|reload] - Версии промптов и A/B тесты (админ)
+/users [add|remove|key|revoke] - Пользователи, роли и API ключи (админ)
+
+🛡️ РИСКИ:
+/risk - Лимиты и экспозиция (риск-офицер)
+/panicstop [on|off] - Экстренная остановка (риск-офицер)
+Роли: viewer - просмотр, trader - торговля и grid, risk_officer - управление риском, admin - все
+
+🧠 ОБЩЕНИЕ С AI:
+Просто напишите сообщение:
+• "Купи BTC на 20 USDT"
+• "Продай 30% позиции"
+• "Покажи портфель"
+Сделки и изменения настроек выполняются после ответа «да».
+/forget - Очистить историю диалога с AI
+
+Поддерживаются русский и английский! 🇬🇧🇷🇺`
@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/policy"
+)
+
+// ==================== STAGE 4: Autonomous Trading Handlers ====================
+
+// recentBreakers сколько последних срабатываний показывает /circuit
+const recentBreakers = 5
+
+// Orchestrator interface for Stage 4
+type Orchestrator interface {
+	CurrentMode() string
+	RequestMode(mode string, adminID int64) (bool, error)
+	IsRunning() bool
+}
+
+// PolicyEngine interface for Stage 4 (policy.Engine)
+type PolicyEngine interface {
+	GetPolicy() *policy.Policy
+	GetMetrics() *policy.RiskMetrics
+}
+
+// DecisionStore история AI решений (AIDecisionRepository)
+type DecisionStore interface {
+	GetRecent(ctx context.Context, limit int) ([]domain.AIDecision, error)
+}
+
+// BreakerStore срабатывания circuit breakers (CircuitBreakerRepository)
+type BreakerStore interface {
+	GetActive(ctx context.Context) ([]domain.CircuitBreakerEvent, error)
+	GetRecent(ctx context.Context, limit int) ([]domain.CircuitBreakerEvent, error)
+}
+
+// HandleMode обрабатывает команду /mode [shadow|pilot|full]
+func (h *Handlers) HandleMode(ctx context.Context, args *CommandArgs) (string, error) {
+	if h.orchestrator == nil {
+		return h.notConfigured("Orchestrator"), nil
+	}
+
+	// Без аргумента - показываем текущий режим
+	if args.Action == "" {
+		running := h.orchestrator.IsRunning()
+		return h.formatter.FormatMode("ai_mode", h.orchestrator.CurrentMode(), &running), nil
+	}
+
+	// Права на смену режима проверяет Router; повышение режима может ждать подтверждений других админов
+	applied, err := h.orchestrator.RequestMode(args.Action, args.UserID)
+	if err != nil {
+		return "", err
+	}
+	return h.formatModeResult(args.Action, applied), nil
+}
+
+// HandleModeSwitch возвращает обработчик /mode_shadow, /mode_pilot, /mode_full
+func (h *Handlers) HandleModeSwitch(mode string) CommandHandler {
+	return func(ctx context.Context, args *CommandArgs) (string, error) {
+		switched := *args
+		switched.Action = mode
+		return h.HandleMode(ctx, &switched)
+	}
+}
+
+// HandleDecisions обрабатывает команду /decisions [N]
+func (h *Handlers) HandleDecisions(ctx context.Context, args *CommandArgs) (string, error) {
+	if h.decisions == nil {
+		return h.notConfigured("Decision history"), nil
+	}
+
+	limit := args.Count
+	if limit == 0 {
+		limit = 10
+	}
+
+	decisions, err := h.decisions.GetRecent(ctx, limit)
+	if err != nil {
+		return "", fmt.Errorf("failed to get AI decisions: %w", err)
+	}
+
+	return h.formatter.FormatDecisions(decisions), nil
+}
+
+// HandleCircuit обрабатывает команду /circuit
+func (h *Handlers) HandleCircuit(ctx context.Context, args *CommandArgs) (string, error) {
+	if h.breakers == nil {
+		return h.notConfigured("Circuit breaker history"), nil
+	}
+
+	active, err := h.breakers.GetActive(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get active circuit breakers: %w", err)
+	}
+
+	recent, err := h.breakers.GetRecent(ctx, recentBreakers)
+	if err != nil {
+		return "", fmt.Errorf("failed to get circuit breaker history: %w", err)
+	}
+
+	return h.formatter.FormatCircuitBreakers(active, recent), nil
+}
+
+// HandlePolicy обрабатывает команду /policy
+func (h *Handlers) HandlePolicy(ctx context.Context, args *CommandArgs) (string, error) {
+	if h.policyEngine == nil {
+		return h.notConfigured("Policy Engine"), nil
+	}
+
+	return h.formatter.FormatPolicy(h.policyEngine.GetPolicy()), nil
+}
+
+// HandleMetrics обрабатывает команду /metrics
+func (h *Handlers) HandleMetrics(ctx context.Context, args *CommandArgs) (string, error) {
+	if h.policyEngine == nil {
+		return h.notConfigured("Policy Engine"), nil
+	}
+
+	return h.formatter.FormatRiskMetrics(h.policyEngine.GetMetrics()), nil
+}
+
+// formatModeResult ответ на запрос смены режима
+func (h *Handlers) formatModeResult(mode string, applied bool) string {
+	if !applied {
+		return fmt.Sprintf("⏳ %s: %s", mode, h.formatter.T("mode_pending"))
+	}
+	return h.formatter.FormatSuccess(fmt.Sprintf("%s %s", h.formatter.T("mode_switched"), mode))
+}
+
+// notConfigured ответ команды, компонент которой не подключен
+func (h *Handlers) notConfigured(component string) string {
+	return fmt.Sprintf("⚠️ %s %s", component, h.formatter.T("not_configured"))
+}
@@ -5,11 +5,14 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/kirillm/dca-bot/internal/ai"
 )
 
 // CommandArgs представляет распарсенные аргументы команды
 type CommandArgs struct {
 	Command string
+	UserID  int64 // Telegram ID отправителя (заполняет Router)
 	Symbol  string
 	Amount  float64
 	Percent float64
@@ -19,6 +22,8 @@ type CommandArgs struct {
 	Trigger float64
 	Action  string // on/off для autosell, panic
 	Raw     []string
+
+	AIActions []ai.AIAction // изменения из AI чата (команда aiActionsCommand)
 }
 
 // CommandType представляет тип команды
@@ -51,8 +56,26 @@ const (
 	// Admin commands
 	CmdPanicStop CommandType = "panicstop"
 
+	// Autonomous trading commands (Orchestrator, PolicyEngine)
+	CmdMode       CommandType = "mode"
+	CmdModeShadow CommandType = "mode_shadow"
+	CmdModePilot  CommandType = "mode_pilot"
+	CmdModeFull   CommandType = "mode_full"
+	CmdDecisions  CommandType = "decisions"
+	CmdCircuit    CommandType = "circuit"
+	CmdPolicy     CommandType = "policy"
+	CmdMetrics    CommandType = "metrics"
+
 	// AI commands
-	CmdAnalysis CommandType = "analysis"
+	CmdAnalysis   CommandType = "analysis"
+	CmdAIAnalyze  CommandType = "ai_analyze"
+	CmdAIDecision CommandType = "ai_decision"
+	CmdAIMetrics  CommandType = "ai_metrics"
+	CmdAIMode     CommandType = "ai_mode"
+	CmdForget     CommandType = "forget"
+	CmdPrompts    CommandType = "prompts"
+	CmdReport     CommandType = "report"
+	CmdUsers      CommandType = "users"
 )
 
 // ParseCommand парсит команду и аргументы
@@ -67,7 +90,13 @@ func ParseCommand(text string) (*CommandArgs, error) {
 		return nil, fmt.Errorf("empty command")
 	}
 
-	cmd := strings.ToLower(strings.TrimPrefix(parts[0], "/"))
+	// /status@MyBot в группах - имя бота отбрасываем
+	cmd := strings.TrimPrefix(parts[0], "/")
+	if i := strings.Index(cmd, "@"); i != -1 {
+		cmd = cmd[:i]
+	}
+	cmd = normalizeCommand(cmd)
+
 	args := &CommandArgs{
 		Command: cmd,
 		Raw:     parts[1:],
@@ -75,7 +104,9 @@ func ParseCommand(text string) (*CommandArgs, error) {
 
 	// Парсим в зависимости от команды
 	switch cmd {
-	case "status", "help", "start", "portfolio", "risk", "config":
+	case "status", "help", "start", "portfolio", "risk", "config",
+		"mode_shadow", "mode_pilot", "mode_full", "circuit", "policy", "metrics",
+		"ai_decision", "ai_metrics", "forget":
 		// Команды без параметров
 		return args, nil
 
@@ -138,7 +169,7 @@ func ParseCommand(text string) (*CommandArgs, error) {
 		}
 		return args, nil
 
-	case "autosellon", "autosell_on":
+	case "autosellon":
 		// /autosellon [SYMBOL]
 		if len(parts) >= 2 {
 			args.Symbol = normalizeSymbol(parts[1])
@@ -146,7 +177,7 @@ func ParseCommand(text string) (*CommandArgs, error) {
 		args.Action = "on"
 		return args, nil
 
-	case "autoselloff", "autosell_off":
+	case "autoselloff":
 		// /autoselloff [SYMBOL]
 		if len(parts) >= 2 {
 			args.Symbol = normalizeSymbol(parts[1])
@@ -179,7 +210,7 @@ func ParseCommand(text string) (*CommandArgs, error) {
 		}
 		return args, nil
 
-	case "gridinit", "grid_init":
+	case "gridinit":
 		// /gridinit <SYMBOL> <LEVELS> <SPACING_%> <ORDER_SIZE>
 		if len(parts) < 5 {
 			return nil, fmt.Errorf("usage: /gridinit <SYMBOL> <LEVELS> <SPACING_%%> <ORDER_SIZE_USDT>")
@@ -200,7 +231,7 @@ func ParseCommand(text string) (*CommandArgs, error) {
 		}
 		return args, nil
 
-	case "gridstatus", "grid_status":
+	case "gridstatus":
 		// /gridstatus <SYMBOL>
 		if len(parts) < 2 {
 			return nil, fmt.Errorf("usage: /gridstatus <SYMBOL>")
@@ -208,7 +239,7 @@ func ParseCommand(text string) (*CommandArgs, error) {
 		args.Symbol = normalizeSymbol(parts[1])
 		return args, nil
 
-	case "gridstop", "grid_stop":
+	case "gridstop":
 		// /gridstop <SYMBOL>
 		if len(parts) < 2 {
 			return nil, fmt.Errorf("usage: /gridstop <SYMBOL>")
@@ -229,13 +260,38 @@ func ParseCommand(text string) (*CommandArgs, error) {
 		}
 		return args, nil
 
-	case "analysis":
+	case "analysis", "ai_analyze":
 		// /analysis [SYMBOL]
 		if len(parts) >= 2 {
 			args.Symbol = normalizeSymbol(parts[1])
 		}
 		return args, nil
 
+	case "mode", "ai_mode":
+		// /mode [shadow|pilot|full] - без параметра показать текущий режим
+		if len(parts) >= 2 {
+			args.Action = strings.ToLower(parts[1])
+			if args.Action != "shadow" && args.Action != "pilot" && args.Action != "full" {
+				return nil, fmt.Errorf("usage: /%s [shadow|pilot|full]", cmd)
+			}
+		}
+		return args, nil
+
+	case "decisions":
+		// /decisions [N]
+		args.Count = 10
+		if len(parts) >= 2 {
+			args.Count = parseInt(parts[1], 10)
+		}
+		if args.Count <= 0 || args.Count > 50 {
+			return nil, fmt.Errorf("count must be between 1 and 50")
+		}
+		return args, nil
+
+	case "report", "prompts", "users":
+		// /report [daily|weekly|monthly], /prompts [use|ab|reload ...], /users [add|remove|key ...] - Raw разбирает обработчик
+		return args, nil
+
 	default:
 		return nil, fmt.Errorf("unknown command: %s", cmd)
 	}
@@ -265,24 +321,41 @@ func normalizeSymbol(symbol string) string {
 func normalizeCommand(cmd string) string {
 	cmd = strings.ToLower(strings.TrimSpace(cmd))
 
-	// Маппинг русских команд на английские
-	ruToEn := map[string]string{
-		"статус":      "status",
-		"история":     "history",
-		"конфиг":      "config",
-		"цена":        "price",
-		"портфель":    "portfolio",
-		"риск":        "risk",
-		"помощь":      "help",
-		"купить":      "buy",
-		"продать":     "sell",
-		"автопродажа": "autosell",
-		"сетка":       "gridinit",
-		"стоп":        "gridstop",
-		"анализ":      "analysis",
+	// Маппинг русских команд и старых имен на канонические
+	aliases := map[string]string{
+		"статус":       "status",
+		"история":      "history",
+		"конфиг":       "config",
+		"цена":         "price",
+		"портфель":     "portfolio",
+		"риск":         "risk",
+		"помощь":       "help",
+		"купить":       "buy",
+		"продать":      "sell",
+		"автопродажа":  "autosell",
+		"сетка":        "gridinit",
+		"стоп":         "gridstop",
+		"анализ":       "analysis",
+		"забудь":       "forget",
+		"отчет":        "report",
+		"отчёт":        "report",
+		"режим":        "mode",
+		"решения":      "decisions",
+		"политика":     "policy",
+		"метрики":      "metrics",
+		"промпты":      "prompts",
+		"пользователи": "users",
+
+		// Старые имена команд с подчеркиванием
+		"autosell_on":  "autosellon",
+		"autosell_off": "autoselloff",
+		"grid_init":    "gridinit",
+		"grid_status":  "gridstatus",
+		"grid_stop":    "gridstop",
+		"panic_stop":   "panicstop",
 	}
 
-	if enCmd, ok := ruToEn[cmd]; ok {
+	if enCmd, ok := aliases[cmd]; ok {
 		return enCmd
 	}
 
@@ -1,6 +1,8 @@
 package telegram
 
 import (
+	"context"
+	"strings"
 	"testing"
 )
 
@@ -266,6 +268,236 @@ func TestParseCommand_PanicStop(t *testing.T) {
 	}
 }
 
+func TestParseCommand_Mode(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantCmd    string
+		wantAction string
+		wantErr    bool
+	}{
+		{"show", "/mode", "mode", "", false},
+		{"switch", "/mode pilot", "mode", "pilot", false},
+		{"uppercase", "/mode FULL", "mode", "full", false},
+		{"invalid mode", "/mode turbo", "", "", true},
+		{"ai mode", "/ai_mode shadow", "ai_mode", "shadow", false},
+		{"ai mode invalid", "/ai_mode auto", "", "", true},
+		{"shortcut", "/mode_pilot", "mode_pilot", "", false},
+		{"russian", "/режим shadow", "mode", "shadow", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := ParseCommand(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCommand() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (args.Command != tt.wantCmd || args.Action != tt.wantAction) {
+				t.Errorf("ParseCommand() = %s %q, want %s %q", args.Command, args.Action, tt.wantCmd, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestParseCommand_Decisions(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantCount int
+		wantErr   bool
+	}{
+		{"default", "/decisions", 10, false},
+		{"with count", "/decisions 5", 5, false},
+		{"too many", "/decisions 500", 0, true},
+		{"zero", "/decisions 0", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := ParseCommand(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCommand() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && args.Count != tt.wantCount {
+				t.Errorf("ParseCommand() count = %v, want %v", args.Count, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestParseCommand_Analysis(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantCmd    string
+		wantSymbol string
+	}{
+		{"default symbol", "/analysis", "analysis", ""},
+		{"with symbol", "/analysis eth", "analysis", "ETHUSDT"},
+		{"ai analyze", "/ai_analyze SOL", "ai_analyze", "SOLUSDT"},
+		{"russian", "/анализ BTC", "analysis", "BTCUSDT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := ParseCommand(tt.input)
+			if err != nil {
+				t.Fatalf("ParseCommand() error = %v", err)
+			}
+			if args.Command != tt.wantCmd || args.Symbol != tt.wantSymbol {
+				t.Errorf("ParseCommand() = %s %s, want %s %s", args.Command, args.Symbol, tt.wantCmd, tt.wantSymbol)
+			}
+		})
+	}
+}
+
+func TestParseCommand_RawArgs(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantCmd string
+		wantRaw []string
+	}{
+		{"/report weekly", "report", []string{"weekly"}},
+		{"/отчёт", "report", []string{}},
+		{"/prompts use decision v2", "prompts", []string{"use", "decision", "v2"}},
+		{"/prompts ab chat v1 v2", "prompts", []string{"ab", "chat", "v1", "v2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			args, err := ParseCommand(tt.input)
+			if err != nil {
+				t.Fatalf("ParseCommand() error = %v", err)
+			}
+			if args.Command != tt.wantCmd || strings.Join(args.Raw, " ") != strings.Join(tt.wantRaw, " ") {
+				t.Errorf("ParseCommand() = %s %v, want %s %v", args.Command, args.Raw, tt.wantCmd, tt.wantRaw)
+			}
+		})
+	}
+}
+
+func TestParseCommand_NoArgs(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantCmd string
+		wantRaw []string
+	}{
+		{"/config", "config", []string{}},
+		{"/конфиг", "config", []string{}},
+		{"/portfolio", "portfolio", []string{}},
+		{"/портфель", "portfolio", []string{}},
+		{"/risk", "risk", []string{}},
+		{"/риск", "risk", []string{}},
+		{"/help", "help", []string{}},
+		{"/помощь", "help", []string{}},
+		{"/mode_shadow", "mode_shadow", []string{}},
+		{"/mode_full", "mode_full", []string{}},
+		{"/circuit", "circuit", []string{}},
+		{"/ai_decision", "ai_decision", []string{}},
+		{"/ai_metrics", "ai_metrics", []string{}},
+		{"/users", "users", []string{}},
+		{"/пользователи", "users", []string{}},
+		{"/users add 42 trader Иван", "users", []string{"add", "42", "trader", "Иван"}},
+		// Лишние аргументы не ошибка: команда без параметров их игнорирует
+		{"/help buy", "help", []string{"buy"}},
+		{"/mode_full now", "mode_full", []string{"now"}},
+		{"/circuit reset", "circuit", []string{"reset"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			args, err := ParseCommand(tt.input)
+			if err != nil {
+				t.Fatalf("ParseCommand() error = %v", err)
+			}
+			if args.Command != tt.wantCmd || strings.Join(args.Raw, " ") != strings.Join(tt.wantRaw, " ") {
+				t.Errorf("ParseCommand() = %s %v, want %s %v", args.Command, args.Raw, tt.wantCmd, tt.wantRaw)
+			}
+		})
+	}
+}
+
+func TestParseCommand_UsersArgErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantUsage bool
+		wantErr   bool
+	}{
+		{"add without role", "/users add 42", true, false},
+		{"add bad id", "/users add abc trader", false, true},
+		{"add negative id", "/users add -5 trader", false, true},
+		{"remove without id", "/users remove", true, false},
+		{"remove bad id", "/users remove x", false, true},
+		{"keys bad id", "/users keys 0", false, true},
+		{"key without id", "/users key", true, false},
+		{"revoke bad id", "/users revoke abc", false, true},
+		{"keyip without list", "/users keyip 1", true, false},
+		{"keylimit bad value", "/users keylimit 1 fast", false, true},
+		{"keysign bad flag", "/users keysign 1 maybe", true, false},
+		{"unknown subcommand", "/users promote 42", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := ParseCommand(tt.input)
+			if err != nil {
+				t.Fatalf("ParseCommand() error = %v", err)
+			}
+			// Ошибки аргументов ловятся до обращения к AccessControl
+			reply, err := usersCommand(context.Background(), nil, nil, args.Raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("usersCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (reply == usersUsage) != tt.wantUsage {
+				t.Errorf("usersCommand() = %q, wantUsage %v", reply, tt.wantUsage)
+			}
+		})
+	}
+}
+
+func TestParseCommand_Aliases(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantCmd string
+	}{
+		{"/status@DcaBot", "status"},
+		{"/grid_init ETH 10 2.5 100", "gridinit"},
+		{"/grid_status ETH", "gridstatus"},
+		{"/grid_stop ETH", "gridstop"},
+		{"/autosell_on", "autosellon"},
+		{"/autosell_off BTC", "autoselloff"},
+		{"/статус", "status"},
+		{"/купить 20", "buy"},
+		{"/забудь", "forget"},
+		{"/политика", "policy"},
+		{"/метрики", "metrics"},
+		{"/решения", "decisions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			args, err := ParseCommand(tt.input)
+			if err != nil {
+				t.Fatalf("ParseCommand() error = %v", err)
+			}
+			if args.Command != tt.wantCmd {
+				t.Errorf("ParseCommand() command = %v, want %v", args.Command, tt.wantCmd)
+			}
+		})
+	}
+}
+
+func TestParseCommand_Unknown(t *testing.T) {
+	for _, input := range []string{"/unknown", "/", "status"} {
+		if _, err := ParseCommand(input); err == nil {
+			t.Errorf("ParseCommand(%q) should fail", input)
+		}
+	}
+}
+
 func TestNormalizeSymbol(t *testing.T) {
 	tests := []struct {
 		name  string
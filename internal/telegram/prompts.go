@@ -0,0 +1,119 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/ai/prompts"
+	"github.com/kirillm/dca-bot/internal/domain"
+)
+
+// promptStatsPeriod период статистики решений в /prompts
+const promptStatsPeriod = 7 * 24 * time.Hour
+
+// PromptStats статистика решений по версиям промптов (AIDecisionRepository)
+type PromptStats interface {
+	GetPromptStats(ctx context.Context, since time.Time) ([]domain.PromptVersionStats, error)
+}
+
+const promptsUsage = `Использование:
+/prompts - версии и статистика решений
+/prompts use <name> <version> - сделать версию активной
+/prompts ab <name> <A> <B> - A/B тест: версии чередуются между циклами
+/prompts ab <name> off - остановить A/B тест
+/prompts reload - перечитать шаблоны`
+
+// promptCommand выполняет /prompts и возвращает ответ
+func promptCommand(ctx context.Context, registry *prompts.Registry, stats PromptStats, args []string) (string, error) {
+	if len(args) == 0 {
+		return formatPrompts(ctx, registry, stats), nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "use":
+		if len(args) != 3 {
+			return promptsUsage, nil
+		}
+		if err := registry.SetActive(args[1], args[2]); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✅ Промпт `%s`: активна версия %s", args[1], args[2]), nil
+
+	case "ab":
+		if len(args) == 3 && strings.ToLower(args[2]) == "off" {
+			registry.StopExperiment(args[1])
+			return fmt.Sprintf("⏹ A/B тест `%s` остановлен, активна %s", args[1], registry.Active(args[1])), nil
+		}
+		if len(args) != 4 {
+			return promptsUsage, nil
+		}
+		if err := registry.StartExperiment(args[1], args[2], args[3]); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("🧪 A/B тест `%s`: %s ↔ %s", args[1], args[2], args[3]), nil
+
+	case "reload":
+		if err := registry.Reload(); err != nil {
+			return "", err
+		}
+		return "🔄 Шаблоны перечитаны\n\n" + formatPrompts(ctx, registry, stats), nil
+
+	default:
+		return promptsUsage, nil
+	}
+}
+
+// formatPrompts список промптов с активными версиями и статистика решений по версиям.
+// Имена в `code`, чтобы Markdown не ломался на "_".
+func formatPrompts(ctx context.Context, registry *prompts.Registry, stats PromptStats) string {
+	var sb strings.Builder
+	sb.WriteString("📝 Промпты\n\n")
+
+	for _, st := range registry.Status() {
+		sb.WriteString(fmt.Sprintf("• `%s`: %s (версии: %s)", st.Name, st.Active, strings.Join(st.Versions, ", ")))
+		if st.Experiment != nil {
+			sb.WriteString(fmt.Sprintf(" 🧪 A/B %s ↔ %s", st.Experiment.A, st.Experiment.B))
+		}
+		sb.WriteString("\n")
+	}
+
+	if stats == nil {
+		return sb.String()
+	}
+
+	rows, err := stats.GetPromptStats(ctx, time.Now().Add(-promptStatsPeriod))
+	if err != nil {
+		sb.WriteString(fmt.Sprintf("\n⚠️ Статистика недоступна: %v\n", err))
+		return sb.String()
+	}
+	if len(rows) == 0 {
+		return sb.String()
+	}
+
+	sb.WriteString("\n📊 Решения за 7 дней:\n")
+	for _, row := range rows {
+		version := row.PromptVersion
+		if version == "" {
+			version = "—"
+		}
+		sb.WriteString(fmt.Sprintf("• %s %s: %d решений, одобрено %d, ср. уверенность %.2f\n",
+			version, row.Model, row.Decisions, row.Approved, row.AvgConfidence))
+	}
+	return sb.String()
+}
+
+// HandlePrompts обрабатывает команду /prompts (только админы)
+func (h *Handlers) HandlePrompts(ctx context.Context, args *CommandArgs) (string, error) {
+	if h.prompts == nil {
+		return h.notConfigured("Prompt registry"), nil
+	}
+	return promptCommand(ctx, h.prompts, h.promptStats, args.Raw)
+}
+
+// SetPromptRegistry подключает реестр промптов к /prompts
+func (b *Bot) SetPromptRegistry(registry *prompts.Registry, stats PromptStats) {
+	b.handlers.prompts = registry
+	b.handlers.promptStats = stats
+}
@@ -0,0 +1,39 @@
+package telegram
+
+import (
+	"context"
+
+	"github.com/kirillm/dca-bot/internal/reports"
+)
+
+// ReportGenerator генерирует отчет по портфелю за скользящее окно (reports.Generator)
+type ReportGenerator interface {
+	OnDemand(ctx context.Context, period string) (string, error)
+}
+
+// reportCommand выполняет /report [daily|weekly|monthly]
+func reportCommand(ctx context.Context, generator ReportGenerator, args []string) (string, error) {
+	period := ""
+	if len(args) > 0 {
+		period = args[0]
+	}
+
+	parsed, err := reports.ParsePeriod(period)
+	if err != nil {
+		return "", err
+	}
+	return generator.OnDemand(ctx, parsed)
+}
+
+// HandleReport обрабатывает команду /report [daily|weekly|monthly]
+func (h *Handlers) HandleReport(ctx context.Context, args *CommandArgs) (string, error) {
+	if h.reports == nil {
+		return h.notConfigured("Reports"), nil
+	}
+	return reportCommand(ctx, h.reports, args.Raw)
+}
+
+// SetReportGenerator подключает генератор отчетов к /report
+func (b *Bot) SetReportGenerator(generator ReportGenerator) {
+	b.handlers.reports = generator
+}
@@ -2,9 +2,11 @@ package telegram
 
 import (
 	"context"
-	"fmt"
+	"errors"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kirillm/dca-bot/internal/ai"
+	"github.com/kirillm/dca-bot/internal/rbac"
 )
 
 // CommandHandler представляет обработчик команды
@@ -16,10 +18,17 @@ type Router struct {
 	authManager     *AuthManager
 	validator       *Validator
 	formatter       *Formatter
-	adminCommands   map[string]bool
+	permissions     map[string]rbac.Permission // право на команду (нет - просмотр)
 	dangerousCommands map[string]bool
+	previews        map[string]CommandHandler // предпросмотр опасных команд без выполнения
+	confirmations   *ConfirmationStore
+	aiActions       CommandHandler // исполнение подтвержденных AI действий из чата
 }
 
+// aiActionsCommand внутренняя команда для изменений из AI чата: ставится в очередь
+// подтверждений через StageAIActions, набрать ее как /команду нельзя
+const aiActionsCommand = "ai_actions"
+
 // NewRouter создает новый роутер
 func NewRouter(authManager *AuthManager, validator *Validator, formatter *Formatter) *Router {
 	r := &Router{
@@ -27,20 +36,24 @@ func NewRouter(authManager *AuthManager, validator *Validator, formatter *Format
 		authManager:   authManager,
 		validator:     validator,
 		formatter:     formatter,
-		adminCommands: make(map[string]bool),
+		permissions:   make(map[string]rbac.Permission),
 		dangerousCommands: make(map[string]bool),
+		previews:      make(map[string]CommandHandler),
+		confirmations: NewConfirmationStore(confirmationTTL),
 	}
 
-	// Регистрируем админские команды
-	r.adminCommands["panicstop"] = true
-	r.adminCommands["gridstop"] = true
-	r.adminCommands["risk"] = true
-
 	// Регистрируем опасные команды (требуют подтверждения)
 	r.dangerousCommands["sell"] = true
 	r.dangerousCommands["gridstop"] = true
 	r.dangerousCommands["panicstop"] = true
 
+	// AI мутации: смена режима и исполнение решений
+	r.dangerousCommands["mode"] = true
+	r.dangerousCommands["mode_pilot"] = true
+	r.dangerousCommands["mode_full"] = true
+	r.dangerousCommands["ai_mode"] = true
+	r.dangerousCommands["ai_decision"] = true
+
 	return r
 }
 
@@ -49,88 +62,227 @@ func (r *Router) RegisterHandler(command string, handler CommandHandler) {
 	r.handlers[command] = handler
 }
 
-// RegisterAdminHandler регистрирует обработчик с требованием админских прав
-func (r *Router) RegisterAdminHandler(command string, handler CommandHandler) {
-	r.adminCommands[command] = true
+// RegisterProtectedHandler регистрирует обработчик, требующий права роли
+func (r *Router) RegisterProtectedHandler(command string, perm rbac.Permission, handler CommandHandler) {
+	r.permissions[command] = perm
 	r.handlers[command] = handler
 }
 
-// HandleCommand обрабатывает команду
-func (r *Router) HandleCommand(ctx context.Context, userID int64, text string) (string, bool, error) {
+// RegisterPreview регистрирует предпросмотр опасной команды (показывается до подтверждения)
+func (r *Router) RegisterPreview(command string, preview CommandHandler) {
+	r.previews[command] = preview
+}
+
+// SetAIActionHandler задает исполнителя подтвержденных AI действий
+func (r *Router) SetAIActionHandler(handler CommandHandler) {
+	r.aiActions = handler
+}
+
+// StageAIActions ставит изменения из AI чата в очередь подтверждений и возвращает
+// токен: подтверждение идет тем же путем, что и опасные команды (кнопки cf:/cx:)
+func (r *Router) StageAIActions(userID int64, actions []ai.AIAction) (string, error) {
+	return r.confirmations.Stage(userID, CommandArgs{Command: aiActionsCommand, UserID: userID, AIActions: actions})
+}
+
+// ReplyAIActions подтверждает или отменяет текстом последние ожидающие AI действия
+// пользователя - то же, что нажатие кнопки. ok=false - подтверждать нечего
+func (r *Router) ReplyAIActions(ctx context.Context, userID int64, confirm bool) (result CallbackResult, ok bool, err error) {
+	token, ok := r.confirmations.LatestPending(userID, aiActionsCommand)
+	if !ok {
+		return CallbackResult{}, false, nil
+	}
+
+	data := callbackCancel + token
+	if confirm {
+		data = callbackConfirm + token
+	}
+	result, err = r.HandleCallback(ctx, userID, data)
+	return result, true, err
+}
+
+// HandleCommand обрабатывает команду. Опасные команды не выполняются сразу:
+// возвращается предпросмотр и токен подтверждения (пустой для обычных команд)
+func (r *Router) HandleCommand(ctx context.Context, userID int64, text string) (string, string, error) {
 	// Проверяем rate limit
-	if err := r.authManager.CheckRateLimit(userID, 2); err != nil {
-		return r.formatter.FormatError(err), false, nil
+	if err := r.authManager.CheckRateLimit(userID, maxRequestsPerSecond); err != nil {
+		return r.formatter.FormatError(err), "", nil
 	}
 
 	// Проверяем доступ пользователя
 	if !r.authManager.IsAllowed(userID) {
-		return r.formatter.T("access_denied"), false, nil
+		return r.formatter.T("access_denied"), "", nil
 	}
 
 	// Парсим команду
 	args, err := ParseCommand(text)
 	if err != nil {
-		return r.formatter.FormatError(err), false, nil
+		return r.formatter.FormatError(err), "", nil
 	}
 
 	// Нормализуем команду
 	args.Command = normalizeCommand(args.Command)
+	args.UserID = userID
 
-	// Проверяем права для админских команд
-	if r.adminCommands[args.Command] {
-		if err := r.authManager.RequireAdmin(userID); err != nil {
-			return r.formatter.T("admin_required"), false, nil
-		}
+	// Проверяем права роли на команду
+	if err := r.authManager.Authorize(userID, r.permission(args)); err != nil {
+		return r.formatter.FormatPermissionDenied(r.permission(args)), "", nil
 	}
 
 	// Получаем обработчик
 	handler, exists := r.handlers[args.Command]
 	if !exists {
-		return fmt.Sprintf("%s: %s", r.formatter.T("error"), "unknown command"), false, nil
+		return r.formatter.T("unknown_command"), "", nil
 	}
 
-	// Проверяем, требуется ли подтверждение
-	needsConfirmation := r.dangerousCommands[args.Command]
+	// Опасная команда - откладываем до подтверждения
+	if r.requiresConfirmation(args) {
+		return r.stage(ctx, args)
+	}
 
 	// Выполняем обработчик
-	response, err := handler(ctx, args)
+	return r.execute(ctx, handler, args)
+}
+
+// permission право на команду. Смена режима через /mode X, /ai_mode X:
+// понижение до shadow - риск, повышение - автономность
+func (r *Router) permission(args *CommandArgs) rbac.Permission {
+	switch args.Command {
+	case "mode", "ai_mode":
+		switch args.Action {
+		case "":
+			return rbac.PermView
+		case "shadow":
+			return rbac.PermRisk
+		}
+		return rbac.PermAutonomy
+	}
+
+	if perm, ok := r.permissions[args.Command]; ok {
+		return perm
+	}
+	return rbac.PermView
+}
+
+// requiresConfirmation проверяет, нужно ли подтверждение. Просмотр статуса
+// (/panicstop, /mode, /ai_mode без аргумента) выполняется сразу
+func (r *Router) requiresConfirmation(args *CommandArgs) bool {
+	if !r.dangerousCommands[args.Command] {
+		return false
+	}
+
+	switch args.Command {
+	case "panicstop", "mode", "ai_mode":
+		return args.Action != "" && args.Action != "status"
+	}
+	return true
+}
+
+// stage сохраняет команду и возвращает предпросмотр с токеном подтверждения
+func (r *Router) stage(ctx context.Context, args *CommandArgs) (string, string, error) {
+	preview := r.formatter.FormatCommandPreview(args)
+	if previewFn, ok := r.previews[args.Command]; ok {
+		details, err := previewFn(ctx, args)
+		if err != nil {
+			return r.formatter.FormatError(err), "", nil
+		}
+		preview += "\n\n" + details
+	}
+
+	token, err := r.confirmations.Stage(args.UserID, *args)
 	if err != nil {
-		return r.formatter.FormatError(err), false, err
+		return r.formatter.FormatError(err), "", err
 	}
 
-	return response, needsConfirmation, nil
+	return r.formatter.T("confirm_action") + "\n\n" + preview, token, nil
 }
 
-// MakeConfirmationKeyboard создает клавиатуру подтверждения
-func (r *Router) MakeConfirmationKeyboard(command string, args string) tgbotapi.InlineKeyboardMarkup {
-	confirmData := fmt.Sprintf("confirm_%s_%s", command, args)
-	cancelData := "cancel"
+// execute выполняет обработчик
+func (r *Router) execute(ctx context.Context, handler CommandHandler, args *CommandArgs) (string, string, error) {
+	response, err := handler(ctx, args)
+	if err != nil {
+		return r.formatter.FormatError(err), "", err
+	}
+	return response, "", nil
+}
 
+// MakeConfirmationKeyboard создает клавиатуру подтверждения для отложенной команды
+func (r *Router) MakeConfirmationKeyboard(token string) tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("✅ "+r.formatter.T("confirm"), confirmData),
-			tgbotapi.NewInlineKeyboardButtonData("❌ "+r.formatter.T("cancel"), cancelData),
+			tgbotapi.NewInlineKeyboardButtonData("✅ "+r.formatter.T("confirm"), callbackConfirm+token),
+			tgbotapi.NewInlineKeyboardButtonData("❌ "+r.formatter.T("cancel"), callbackCancel+token),
 		),
 	)
 }
 
-// HandleCallback обрабатывает callback от inline кнопок
-func (r *Router) HandleCallback(ctx context.Context, userID int64, data string) (string, error) {
-	if data == "cancel" {
-		return r.formatter.T("cancel"), nil
+// CallbackResult ответ на нажатие inline кнопки
+type CallbackResult struct {
+	Text  string
+	Final bool // true - заменить сообщение с кнопками; false - только всплывающее уведомление
+}
+
+// HandleCallback обрабатывает callback от inline кнопок. Команда выполняется
+// только по нажатию того же пользователя в пределах TTL и только один раз
+func (r *Router) HandleCallback(ctx context.Context, userID int64, data string) (CallbackResult, error) {
+	confirm, token, ok := parseCallbackData(data)
+	if !ok {
+		return CallbackResult{Text: r.formatter.T("confirmation_expired"), Final: true}, nil
+	}
+
+	if !confirm {
+		result, _, err := r.confirmations.Cancel(token, userID, "❌ "+r.formatter.T("cancelled"))
+		if err != nil {
+			return r.callbackError(err), nil
+		}
+		return CallbackResult{Text: result, Final: true}, nil
+	}
+
+	args, result, done, err := r.confirmations.Begin(token, userID)
+	if err != nil {
+		return r.callbackError(err), nil
+	}
+	if done {
+		// Повторное нажатие - тот же результат, без повторного выполнения
+		return CallbackResult{Text: result, Final: true}, nil
+	}
+
+	response, err := r.confirm(ctx, &args)
+	r.confirmations.Finish(token, response)
+	return CallbackResult{Text: response, Final: true}, err
+}
+
+// confirm выполняет подтвержденную команду, повторно проверяя права
+func (r *Router) confirm(ctx context.Context, args *CommandArgs) (string, error) {
+	if err := r.authManager.Authorize(args.UserID, r.permission(args)); err != nil {
+		return r.formatter.FormatPermissionDenied(r.permission(args)), nil
 	}
 
-	// Разбираем callback data
-	// Формат: confirm_<command>_<args>
-	// Для простоты можно просто вызвать команду повторно
-	// В продакшене лучше сохранять состояние
+	handler, exists := r.handlers[args.Command]
+	if args.Command == aiActionsCommand {
+		handler, exists = r.aiActions, r.aiActions != nil
+	}
+	if !exists {
+		return r.formatter.T("unknown_command"), nil
+	}
 
-	return "Callback handled", nil
+	response, _, err := r.execute(ctx, handler, args)
+	return response, err
+}
+
+// callbackError ответ на нажатие, которое нельзя выполнить
+func (r *Router) callbackError(err error) CallbackResult {
+	switch {
+	case errors.Is(err, errConfirmationForeign):
+		return CallbackResult{Text: r.formatter.T("confirmation_foreign")}
+	case errors.Is(err, errConfirmationInProgress):
+		return CallbackResult{Text: r.formatter.T("confirmation_in_progress")}
+	}
+	return CallbackResult{Text: r.formatter.T("confirmation_expired"), Final: true}
 }
 
-// IsAdminCommand проверяет, является ли команда админской
-func (r *Router) IsAdminCommand(command string) bool {
-	return r.adminCommands[command]
+// Permission возвращает право, необходимое для команды без аргументов
+func (r *Router) Permission(command string) rbac.Permission {
+	return r.permission(&CommandArgs{Command: command})
 }
 
 // IsDangerousCommand проверяет, является ли команда опасной
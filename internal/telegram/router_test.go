@@ -0,0 +1,274 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/rbac"
+)
+
+// fakeOrchestrator запоминает запросы смены режима
+type fakeOrchestrator struct {
+	mode     string
+	requests []int64
+}
+
+func (o *fakeOrchestrator) CurrentMode() string { return o.mode }
+func (o *fakeOrchestrator) IsRunning() bool     { return true }
+
+func (o *fakeOrchestrator) RequestMode(mode string, adminID int64) (bool, error) {
+	o.requests = append(o.requests, adminID)
+	o.mode = mode
+	return true, nil
+}
+
+func newTestRouter(orchestrator Orchestrator) *Router {
+	auth := NewAuthManager("111", "")
+	formatter := NewFormatter(LangEN)
+	handlers := NewHandlers(nil, nil, nil, formatter, auth, nil, nil, nil, nil, nil, "BTCUSDT")
+	if orchestrator != nil {
+		handlers.orchestrator = orchestrator
+	}
+
+	router := NewRouter(auth, nil, formatter)
+	registerHandlers(router, handlers)
+	return router
+}
+
+func TestRegisterHandlers_AllCommands(t *testing.T) {
+	router := newTestRouter(nil)
+
+	// Все команды из /help: парсятся и имеют обработчик
+	commands := []string{
+		"/start", "/help", "/status", "/history", "/config", "/price BTC", "/portfolio", "/report daily",
+		"/buy", "/sell 50", "/autosellon", "/autoselloff", "/autosell 15 50",
+		"/gridinit ETH 10 2.5 100", "/gridstatus ETH", "/gridstop ETH",
+		"/risk", "/panicstop",
+		"/mode", "/mode_shadow", "/mode_pilot", "/mode_full", "/decisions", "/circuit", "/policy", "/metrics",
+		"/analysis", "/ai_analyze BTC", "/ai_decision", "/ai_metrics", "/ai_mode", "/forget", "/prompts", "/users",
+	}
+
+	for _, text := range commands {
+		args, err := ParseCommand(text)
+		if err != nil {
+			t.Errorf("%s: parse error %v", text, err)
+			continue
+		}
+		if _, ok := router.handlers[args.Command]; !ok {
+			t.Errorf("%s: no handler for %q", text, args.Command)
+		}
+	}
+
+	permissions := map[string]rbac.Permission{
+		"status": rbac.PermView, "buy": rbac.PermTrade, "gridinit": rbac.PermGrid,
+		"gridstop": rbac.PermRisk, "risk": rbac.PermRisk, "panicstop": rbac.PermRisk, "mode_shadow": rbac.PermRisk,
+		"mode_pilot": rbac.PermAutonomy, "ai_decision": rbac.PermAutonomy, "prompts": rbac.PermConfig, "users": rbac.PermUsers,
+	}
+	for cmd, want := range permissions {
+		if got := router.Permission(cmd); got != want {
+			t.Errorf("%s permission = %s, want %s", cmd, got, want)
+		}
+	}
+}
+
+func TestRouter_NotConfigured(t *testing.T) {
+	router := newTestRouter(nil)
+
+	// Без подключенных компонентов команды отвечают, а не падают
+	for i, text := range []string{"/mode", "/decisions", "/circuit", "/policy", "/metrics", "/ai_metrics", "/forget", "/report"} {
+		response, _, err := router.HandleCommand(context.Background(), int64(1000+i), text)
+		if err != nil || !strings.Contains(response, "not configured") {
+			t.Errorf("%s: response=%q err=%v", text, response, err)
+		}
+	}
+}
+
+func TestRouter_ModeRequiresAdmin(t *testing.T) {
+	orchestrator := &fakeOrchestrator{mode: "shadow"}
+	router := newTestRouter(orchestrator)
+	ctx := context.Background()
+
+	// Просмотр режима доступен всем
+	response, _, _ := router.HandleCommand(ctx, 222, "/mode")
+	if !strings.Contains(response, "Current mode: shadow") {
+		t.Errorf("mode status = %q", response)
+	}
+
+	// Смена режима - только админам, и через /mode X, и через /mode_X (разные ID - без rate limit)
+	for i, text := range []string{"/mode pilot", "/mode_pilot"} {
+		response, _, _ = router.HandleCommand(ctx, int64(300+i), text)
+		if !strings.Contains(response, "Permission required: autonomy") || len(orchestrator.requests) != 0 {
+			t.Errorf("%s by user: %q, requests=%v", text, response, orchestrator.requests)
+		}
+	}
+
+	// Админ: сначала предпросмотр, смена режима - только после подтверждения
+	response, token, _ := router.HandleCommand(ctx, 111, "/mode_pilot")
+	if token == "" || len(orchestrator.requests) != 0 {
+		t.Fatalf("admin switch not staged: %q, requests=%v", response, orchestrator.requests)
+	}
+
+	result, _ := router.HandleCallback(ctx, 111, callbackConfirm+token)
+	if !strings.Contains(result.Text, "Switched to mode pilot") || orchestrator.mode != "pilot" || orchestrator.requests[0] != 111 {
+		t.Errorf("admin switch = %q, orchestrator=%+v", result.Text, orchestrator)
+	}
+}
+
+// countingHandler считает выполнения опасной команды
+func countingHandler(calls *int) CommandHandler {
+	return func(ctx context.Context, args *CommandArgs) (string, error) {
+		*calls++
+		return "grid stopped " + args.Symbol, nil
+	}
+}
+
+func TestRouter_ConfirmationFlow(t *testing.T) {
+	router := newTestRouter(nil)
+	ctx := context.Background()
+
+	var calls int
+	router.RegisterProtectedHandler("gridstop", rbac.PermRisk, countingHandler(&calls))
+
+	// Опасная команда не выполняется сразу
+	response, token, err := router.HandleCommand(ctx, 111, "/gridstop ETH")
+	if err != nil || token == "" || calls != 0 {
+		t.Fatalf("not staged: response=%q token=%q calls=%d err=%v", response, token, calls, err)
+	}
+	if !strings.Contains(response, "/gridstop ETH") {
+		t.Errorf("preview = %q", response)
+	}
+	if data := callbackConfirm + token; len(data) > 64 {
+		t.Errorf("callback data too long: %d", len(data))
+	}
+
+	// Чужое нажатие - только уведомление, команда ждет владельца
+	result, _ := router.HandleCallback(ctx, 222, callbackConfirm+token)
+	if result.Final || calls != 0 {
+		t.Errorf("foreign confirm = %+v, calls=%d", result, calls)
+	}
+
+	// Двойное нажатие выполняет команду один раз и возвращает тот же результат
+	first, _ := router.HandleCallback(ctx, 111, callbackConfirm+token)
+	second, _ := router.HandleCallback(ctx, 111, callbackConfirm+token)
+	if calls != 1 || first.Text != "grid stopped ETHUSDT" || second != first {
+		t.Errorf("double tap: calls=%d first=%+v second=%+v", calls, first, second)
+	}
+
+	// Отмена после выполнения ничего не меняет
+	if cancel, _ := router.HandleCallback(ctx, 111, callbackCancel+token); cancel.Text != first.Text {
+		t.Errorf("cancel after execute = %+v", cancel)
+	}
+}
+
+func TestRouter_ConfirmationCancelAndExpiry(t *testing.T) {
+	router := newTestRouter(nil)
+	ctx := context.Background()
+
+	var calls int
+	router.RegisterProtectedHandler("gridstop", rbac.PermRisk, countingHandler(&calls))
+
+	// Отмена
+	_, token, _ := router.HandleCommand(ctx, 111, "/gridstop ETH")
+	result, _ := router.HandleCallback(ctx, 111, callbackCancel+token)
+	if !result.Final || !strings.Contains(result.Text, "Cancelled") {
+		t.Errorf("cancel = %+v", result)
+	}
+	if result, _ = router.HandleCallback(ctx, 111, callbackConfirm+token); calls != 0 {
+		t.Errorf("confirm after cancel executed: %+v", result)
+	}
+
+	// Истекший TTL
+	router.confirmations.ttl = time.Millisecond
+	_, token, _ = router.HandleCommand(ctx, 111, "/gridstop BTC")
+	time.Sleep(5 * time.Millisecond)
+	result, _ = router.HandleCallback(ctx, 111, callbackConfirm+token)
+	if calls != 0 || !strings.Contains(result.Text, "expired") {
+		t.Errorf("expired confirm = %+v, calls=%d", result, calls)
+	}
+
+	// Старый формат callback data
+	if result, _ = router.HandleCallback(ctx, 111, "confirm_sell_50"); calls != 0 || !result.Final {
+		t.Errorf("legacy callback = %+v", result)
+	}
+}
+
+func TestRouter_StatusWithoutConfirmation(t *testing.T) {
+	router := newTestRouter(&fakeOrchestrator{mode: "shadow"})
+	ctx := context.Background()
+
+	// Просмотр статуса опасных команд выполняется сразу
+	for i, text := range []string{"/mode", "/panicstop", "/ai_mode"} {
+		if _, token, _ := router.HandleCommand(ctx, int64(400+i), text); token != "" {
+			t.Errorf("%s should not require confirmation", text)
+		}
+	}
+}
+
+// fakeRoles роли пользователей для тестов
+type fakeRoles map[int64]string
+
+func (f fakeRoles) HasUsers() bool               { return len(f) > 0 }
+func (f fakeRoles) Role(telegramID int64) string { return f[telegramID] }
+
+func (f fakeRoles) CheckOrderSize(role string, amountUSD float64) error {
+	if role == domain.RoleTrader && amountUSD > 50 {
+		return rbac.ErrPermissionDenied
+	}
+	return nil
+}
+
+func TestRouter_RolePermissions(t *testing.T) {
+	roles := fakeRoles{
+		1:   domain.RoleViewer,
+		2:   domain.RoleTrader,
+		3:   domain.RoleRiskOfficer,
+		111: domain.RoleAdmin,
+	}
+	ctx := context.Background()
+
+	cases := []struct {
+		user    int64
+		text    string
+		allowed bool
+	}{
+		{1, "/mode", true},
+		{1, "/gridinit ETH 10 2.5 100", false},
+		{2, "/gridstop ETH", false},
+		{3, "/gridstop ETH", true},
+		{3, "/mode shadow", true},
+		{3, "/mode full", false},
+		{2, "/users", false},
+		{111, "/users", true},
+		{999, "/status", false}, // нет в списке пользователей
+	}
+
+	for _, c := range cases {
+		// Отдельный роутер на случай - без rate limit между проверками
+		router := newTestRouter(&fakeOrchestrator{mode: "pilot"})
+		router.authManager.SetRoles(roles)
+
+		response, _, _ := router.HandleCommand(ctx, c.user, c.text)
+		denied := strings.Contains(response, "Permission required") || strings.Contains(response, "Access denied")
+		if denied == c.allowed {
+			t.Errorf("user %d %s: allowed=%v, response=%q", c.user, c.text, c.allowed, response)
+		}
+	}
+}
+
+func TestAuthManager_OrderCap(t *testing.T) {
+	am := NewAuthManager("111", "")
+	if err := am.CheckOrderSize(2, 1000); err != nil {
+		t.Errorf("without roles no cap expected: %v", err)
+	}
+
+	am.SetRoles(fakeRoles{2: domain.RoleTrader, 111: domain.RoleAdmin})
+	if err := am.CheckOrderSize(2, 100); err == nil {
+		t.Error("trader order over cap allowed")
+	}
+	if err := am.CheckOrderSize(111, 100); err != nil {
+		t.Errorf("admin order: %v", err)
+	}
+}
@@ -0,0 +1,334 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kirillm/dca-bot/internal/domain"
+	"github.com/kirillm/dca-bot/internal/rbac"
+)
+
+// AccessControl пользователи, роли и API ключи (rbac.Service)
+type AccessControl interface {
+	RoleSource
+	Users() []domain.User
+	SetRole(ctx context.Context, telegramID int64, role, name string) (domain.User, error)
+	Remove(ctx context.Context, telegramID int64) error
+	Bootstrap(ctx context.Context, admins, traders []int64) error
+	OrderCap(role string) float64
+	CreateAPIKey(ctx context.Context, telegramID int64, name string) (string, *domain.APIKey, error)
+	APIKeys(ctx context.Context, telegramID int64) ([]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) error
+	SetAPIKeyIPs(ctx context.Context, id int64, ips []string) (*domain.APIKey, error)
+	SetAPIKeyRateLimit(ctx context.Context, id int64, perMinute int) (*domain.APIKey, error)
+	SetAPIKeySignature(ctx context.Context, id int64, required bool) (*domain.APIKey, error)
+}
+
+// APIAuditReader журнал изменяющих вызовов HTTP API (APIAuditRepository)
+type APIAuditReader interface {
+	GetRecent(ctx context.Context, limit int) ([]domain.APIAuditEntry, error)
+}
+
+const usersUsage = `Использование:
+/users - пользователи и роли
+/users add <telegram_id> <role> [имя] - добавить или сменить роль
+/users remove <telegram_id> - удалить пользователя и его API ключи
+/users keys <telegram_id> - API ключи пользователя
+/users key <telegram_id> [название] - выпустить API ключ
+/users revoke <key_id> - отозвать API ключ
+/users keyip <key_id> <ip,cidr|any> - разрешенные IP ключа
+/users keylimit <key_id> <запросов/мин> - лимит запросов (0 - по умолчанию)
+/users keysign <key_id> on|off - обязательная HMAC подпись запросов
+/users audit [N] - последние изменяющие вызовы API
+Роли: viewer, trader, risk_officer, admin`
+
+// usersCommand выполняет /users и возвращает ответ
+func usersCommand(ctx context.Context, access AccessControl, audit APIAuditReader, args []string) (string, error) {
+	if len(args) == 0 {
+		return formatUsers(access), nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "add", "role":
+		if len(args) < 3 {
+			return usersUsage, nil
+		}
+		id, err := parseTelegramID(args[1])
+		if err != nil {
+			return "", err
+		}
+		user, err := access.SetRole(ctx, id, strings.ToLower(args[2]), strings.Join(args[3:], " "))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✅ %d: роль %s", user.TelegramID, user.Role), nil
+
+	case "remove":
+		if len(args) != 2 {
+			return usersUsage, nil
+		}
+		id, err := parseTelegramID(args[1])
+		if err != nil {
+			return "", err
+		}
+		if err := access.Remove(ctx, id); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("🗑 Пользователь %d удален", id), nil
+
+	case "keys":
+		if len(args) != 2 {
+			return usersUsage, nil
+		}
+		id, err := parseTelegramID(args[1])
+		if err != nil {
+			return "", err
+		}
+		keys, err := access.APIKeys(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		return formatAPIKeys(id, keys), nil
+
+	case "key":
+		if len(args) < 2 {
+			return usersUsage, nil
+		}
+		id, err := parseTelegramID(args[1])
+		if err != nil {
+			return "", err
+		}
+		secret, key, err := access.CreateAPIKey(ctx, id, strings.Join(args[2:], " "))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("🔑 API ключ #%d для %d:\n%s\n\nСекрет подписи (HMAC-SHA256):\n%s\n\n"+
+			"Показываются один раз - сохраните их. Заголовки: X-API-Key, для подписи X-Timestamp, X-Nonce, X-Signature",
+			key.ID, id, secret, key.SigningSecret), nil
+
+	case "revoke":
+		if len(args) != 2 {
+			return usersUsage, nil
+		}
+		keyID, err := parseKeyID(args[1])
+		if err != nil {
+			return "", err
+		}
+		if err := access.RevokeAPIKey(ctx, keyID); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("⛔ API ключ #%d отозван", keyID), nil
+
+	case "keyip":
+		if len(args) != 3 {
+			return usersUsage, nil
+		}
+		keyID, err := parseKeyID(args[1])
+		if err != nil {
+			return "", err
+		}
+		var ips []string
+		if strings.ToLower(args[2]) != "any" {
+			ips = strings.Split(args[2], ",")
+		}
+		key, err := access.SetAPIKeyIPs(ctx, keyID, ips)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("🌐 API ключ #%d: %s", key.ID, formatKeyRestrictions(*key)), nil
+
+	case "keylimit":
+		if len(args) != 3 {
+			return usersUsage, nil
+		}
+		keyID, err := parseKeyID(args[1])
+		if err != nil {
+			return "", err
+		}
+		perMinute, err := strconv.Atoi(args[2])
+		if err != nil {
+			return "", fmt.Errorf("invalid rate limit: %s", args[2])
+		}
+		key, err := access.SetAPIKeyRateLimit(ctx, keyID, perMinute)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("⏱ API ключ #%d: %s", key.ID, formatKeyRestrictions(*key)), nil
+
+	case "keysign":
+		if len(args) != 3 {
+			return usersUsage, nil
+		}
+		keyID, err := parseKeyID(args[1])
+		if err != nil {
+			return "", err
+		}
+		var required bool
+		switch strings.ToLower(args[2]) {
+		case "on":
+			required = true
+		case "off":
+		default:
+			return usersUsage, nil
+		}
+		key, err := access.SetAPIKeySignature(ctx, keyID, required)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✍️ API ключ #%d: %s", key.ID, formatKeyRestrictions(*key)), nil
+
+	case "audit":
+		if audit == nil {
+			return "⚠️ Аудит API не настроен", nil
+		}
+		limit := 20
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 || n > 100 {
+				return "", fmt.Errorf("invalid count: %s (1-100)", args[1])
+			}
+			limit = n
+		}
+		entries, err := audit.GetRecent(ctx, limit)
+		if err != nil {
+			return "", fmt.Errorf("failed to get API audit: %w", err)
+		}
+		return formatAPIAudit(entries), nil
+
+	default:
+		return usersUsage, nil
+	}
+}
+
+// formatUsers список пользователей с ролями и лимитами ордера
+func formatUsers(access AccessControl) string {
+	users := access.Users()
+	if len(users) == 0 {
+		return "👥 Пользователей нет - доступ по TG_ADMINS/TG_CHAT_WHITELIST\n\n" + usersUsage
+	}
+
+	var sb strings.Builder
+	sb.WriteString("👥 Пользователи\n\n")
+	for _, u := range users {
+		sb.WriteString(fmt.Sprintf("• %d", u.TelegramID))
+		if u.Name != "" {
+			sb.WriteString(" " + u.Name)
+		}
+		sb.WriteString(": " + u.Role)
+		if rbac.Can(u.Role, rbac.PermTrade) {
+			if limit := access.OrderCap(u.Role); limit > 0 {
+				sb.WriteString(fmt.Sprintf(" (ордер до $%.2f)", limit))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// formatAPIKeys ключи пользователя без секретов
+func formatAPIKeys(telegramID int64, keys []domain.APIKey) string {
+	if len(keys) == 0 {
+		return fmt.Sprintf("🔑 У %d нет API ключей", telegramID)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔑 API ключи %d\n\n", telegramID))
+	for _, k := range keys {
+		status := "активен"
+		if k.Revoked {
+			status = "отозван"
+		}
+		sb.WriteString(fmt.Sprintf("• #%d %s… %s - %s", k.ID, k.Prefix, k.Name, status))
+		if k.LastUsedAt != nil {
+			sb.WriteString(", использован " + k.LastUsedAt.Format("2006-01-02 15:04"))
+		}
+		if !k.Revoked {
+			sb.WriteString("\n  " + formatKeyRestrictions(k))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// formatKeyRestrictions IP, лимит запросов и подпись ключа
+func formatKeyRestrictions(k domain.APIKey) string {
+	ips := "любые IP"
+	if len(k.AllowedIPs) > 0 {
+		ips = "IP " + strings.Join(k.AllowedIPs, ", ")
+	}
+	limit := "лимит по умолчанию"
+	if k.RateLimit > 0 {
+		limit = fmt.Sprintf("до %d запросов/мин", k.RateLimit)
+	}
+	sign := "подпись по желанию"
+	if k.RequireSignature {
+		sign = "подпись обязательна"
+	}
+	return ips + "; " + limit + "; " + sign
+}
+
+// formatAPIAudit последние изменяющие вызовы API
+func formatAPIAudit(entries []domain.APIAuditEntry) string {
+	if len(entries) == 0 {
+		return "📜 Изменяющих вызовов API не было"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📜 Аудит API\n\n")
+	for _, e := range entries {
+		who := "без ключа"
+		if e.KeyID > 0 {
+			who = fmt.Sprintf("ключ #%d (%d, %s)", e.KeyID, e.TelegramID, e.Role)
+		}
+		sb.WriteString(fmt.Sprintf("• %s %s %s → %d %s\n  %s, %s\n",
+			e.CreatedAt.Format("01-02 15:04:05"), e.Method, e.Path, e.Status, e.Result, who, e.RemoteIP))
+		if params := []rune(e.Params); len(params) > 120 {
+			sb.WriteString("  " + string(params[:120]) + "…\n")
+		} else if len(params) > 0 {
+			sb.WriteString("  " + e.Params + "\n")
+		}
+	}
+	return sb.String()
+}
+
+func parseKeyID(value string) (int64, error) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid key id: %s", value)
+	}
+	return id, nil
+}
+
+func parseTelegramID(value string) (int64, error) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid telegram id: %s", value)
+	}
+	return id, nil
+}
+
+// HandleUsers обрабатывает команду /users (только админы)
+func (h *Handlers) HandleUsers(ctx context.Context, args *CommandArgs) (string, error) {
+	if h.access == nil {
+		return h.notConfigured("Access control"), nil
+	}
+	return usersCommand(ctx, h.access, h.apiAudit, args.Raw)
+}
+
+// SetAccessControl подключает роли из хранилища к командам и /users.
+// Админы и whitelist из env добавляются как admin и trader, если их еще нет
+func (b *Bot) SetAccessControl(ctx context.Context, access AccessControl) error {
+	if err := access.Bootstrap(ctx, b.authManager.GetAdminIDs(), b.authManager.GetWhitelist()); err != nil {
+		return fmt.Errorf("failed to bootstrap users: %w", err)
+	}
+	b.authManager.SetRoles(access)
+	b.handlers.access = access
+	return nil
+}
+
+// SetAPIAuditLog подключает журнал HTTP API к /users audit
+func (b *Bot) SetAPIAuditLog(audit APIAuditReader) {
+	b.handlers.apiAudit = audit
+}
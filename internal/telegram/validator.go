@@ -1,6 +1,7 @@
 package telegram
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/kirillm/dca-bot/internal/exchange"
@@ -9,11 +10,11 @@ import (
 
 // Validator валидирует команды перед выполнением
 type Validator struct {
-	storage  *storage.PostgresStorage
+	storage  *storage.Storage
 	exchange *exchange.BybitClient
 }
 
-func NewValidator(storage *storage.PostgresStorage, exchange *exchange.BybitClient) *Validator {
+func NewValidator(storage *storage.Storage, exchange *exchange.BybitClient) *Validator {
 	return &Validator{
 		storage:  storage,
 		exchange: exchange,
@@ -22,6 +23,7 @@ func NewValidator(storage *storage.PostgresStorage, exchange *exchange.BybitClie
 
 // ValidateBuy валидирует команду покупки
 func (v *Validator) ValidateBuy(symbol string, amount float64) error {
+	ctx := context.Background()
 	if symbol == "" {
 		return fmt.Errorf("symbol is required")
 	}
@@ -41,7 +43,7 @@ func (v *Validator) ValidateBuy(symbol string, amount float64) error {
 	}
 
 	// Проверяем риск-лимиты
-	limits, err := v.storage.GetRiskLimits()
+	limits, err := v.storage.Risk().GetLimits(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get risk limits: %w", err)
 	}
@@ -55,7 +57,7 @@ func (v *Validator) ValidateBuy(symbol string, amount float64) error {
 	}
 
 	// Проверяем текущую экспозицию
-	balances, err := v.storage.GetAllBalances()
+	balances, err := v.storage.Balances().GetAll(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get balances: %w", err)
 	}
@@ -79,6 +81,7 @@ func (v *Validator) ValidateBuy(symbol string, amount float64) error {
 
 // ValidateSell валидирует команду продажи
 func (v *Validator) ValidateSell(symbol string, percent float64) error {
+	ctx := context.Background()
 	if symbol == "" {
 		return fmt.Errorf("symbol is required")
 	}
@@ -88,7 +91,7 @@ func (v *Validator) ValidateSell(symbol string, percent float64) error {
 	}
 
 	// Проверяем наличие позиции
-	balance, err := v.storage.GetBalance(symbol)
+	balance, err := v.storage.Balances().Get(ctx, symbol)
 	if err != nil {
 		return fmt.Errorf("failed to get balance: %w", err)
 	}
@@ -98,7 +101,7 @@ func (v *Validator) ValidateSell(symbol string, percent float64) error {
 	}
 
 	// Проверяем риск-лимиты
-	limits, err := v.storage.GetRiskLimits()
+	limits, err := v.storage.Risk().GetLimits(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get risk limits: %w", err)
 	}
@@ -112,6 +115,7 @@ func (v *Validator) ValidateSell(symbol string, percent float64) error {
 
 // ValidateGridInit валидирует инициализацию Grid
 func (v *Validator) ValidateGridInit(symbol string, levels int, spacing, orderSize float64) error {
+	ctx := context.Background()
 	if symbol == "" {
 		return fmt.Errorf("symbol is required")
 	}
@@ -142,7 +146,7 @@ func (v *Validator) ValidateGridInit(symbol string, levels int, spacing, orderSi
 	}
 
 	// Проверяем риск-лимиты
-	limits, err := v.storage.GetRiskLimits()
+	limits, err := v.storage.Risk().GetLimits(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get risk limits: %w", err)
 	}
@@ -157,7 +161,7 @@ func (v *Validator) ValidateGridInit(symbol string, levels int, spacing, orderSi
 	}
 
 	// Проверяем позиционный лимит
-	balance, err := v.storage.GetBalance(symbol)
+	balance, err := v.storage.Balances().Get(ctx, symbol)
 	if err != nil {
 		return fmt.Errorf("failed to get balance: %w", err)
 	}
@@ -180,6 +184,7 @@ func (v *Validator) ValidateGridInit(symbol string, levels int, spacing, orderSi
 
 // ValidateAutoSell валидирует настройки Auto-Sell
 func (v *Validator) ValidateAutoSell(symbol string, trigger, sellPercent float64) error {
+	ctx := context.Background()
 	if symbol == "" {
 		return fmt.Errorf("symbol is required")
 	}
@@ -197,7 +202,7 @@ func (v *Validator) ValidateAutoSell(symbol string, trigger, sellPercent float64
 	}
 
 	// Проверяем наличие позиции
-	balance, err := v.storage.GetBalance(symbol)
+	balance, err := v.storage.Balances().Get(ctx, symbol)
 	if err != nil {
 		return fmt.Errorf("failed to get balance: %w", err)
 	}
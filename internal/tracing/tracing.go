@@ -0,0 +1,269 @@
+// Package tracing минимальная реализация трейсинга в модели OpenTelemetry без внешних
+// зависимостей: W3C trace/span ID, вложенные спаны через context и экспорт в формате
+// OTLP/JSON (OTLP/HTTP коллектор или локальный файл).
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SpanKind вид спана (значения как в OTLP)
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// StatusCode статус спана (значения как в OTLP)
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Attr атрибут спана
+type Attr struct {
+	Key   string
+	Value interface{}
+}
+
+func String(key, value string) Attr        { return Attr{Key: key, Value: value} }
+func Int(key string, value int) Attr       { return Attr{Key: key, Value: int64(value)} }
+func Int64(key string, value int64) Attr   { return Attr{Key: key, Value: value} }
+func Float(key string, value float64) Attr { return Attr{Key: key, Value: value} }
+func Bool(key string, value bool) Attr     { return Attr{Key: key, Value: value} }
+
+// Event событие внутри спана (например, exception)
+type Event struct {
+	Name  string
+	Time  time.Time
+	Attrs []Attr
+}
+
+// SpanData завершенный спан для экспорта
+type SpanData struct {
+	Name          string
+	TraceID       [16]byte
+	SpanID        [8]byte
+	ParentSpanID  [8]byte
+	Kind          SpanKind
+	Start         time.Time
+	End           time.Time
+	Attrs         []Attr
+	Events        []Event
+	StatusCode    StatusCode
+	StatusMessage string
+}
+
+// Span активный спан. Методы безопасны для nil: при выключенном трейсинге Start возвращает nil
+type Span struct {
+	provider *Provider
+	mu       sync.Mutex
+	data     SpanData
+	ended    bool
+}
+
+type spanKey struct{}
+
+var current atomic.Pointer[Provider]
+
+// Start открывает спан, дочерний к спану из ctx. Без Init возвращает ctx и nil
+func Start(ctx context.Context, name string, kind SpanKind, attrs ...Attr) (context.Context, *Span) {
+	p := current.Load()
+	if p == nil {
+		return ctx, nil
+	}
+
+	span := &Span{provider: p, data: SpanData{
+		Name:  name,
+		Kind:  kind,
+		Start: time.Now(),
+		Attrs: append([]Attr(nil), attrs...),
+	}}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentSpanID = parent.data.SpanID
+	} else {
+		rand.Read(span.data.TraceID[:])
+	}
+	rand.Read(span.data.SpanID[:])
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SpanFromContext текущий спан или nil
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// TraceID hex ID трейса из ctx или пустая строка, если трейсинг выключен
+func TraceID(ctx context.Context) string {
+	return SpanFromContext(ctx).TraceID()
+}
+
+// TraceID hex ID трейса спана
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.data.TraceID[:])
+}
+
+// SetAttributes добавляет атрибуты
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attrs = append(s.data.Attrs, attrs...)
+}
+
+// RecordError отмечает спан ошибкой и добавляет событие exception. nil err игнорируется
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.StatusCode = StatusError
+	s.data.StatusMessage = err.Error()
+	s.data.Events = append(s.data.Events, Event{
+		Name:  "exception",
+		Time:  time.Now(),
+		Attrs: []Attr{String("exception.type", fmt.Sprintf("%T", err)), String("exception.message", err.Error())},
+	})
+}
+
+// End закрывает спан и передает его на экспорт. Повторный вызов ничего не делает
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.provider.enqueue(&data)
+}
+
+// ==================== Provider ====================
+
+// Exporter отправляет пачку завершенных спанов
+type Exporter interface {
+	Export(ctx context.Context, service string, spans []*SpanData) error
+}
+
+const (
+	queueSize     = 2048
+	batchSize     = 256
+	flushInterval = 5 * time.Second
+	exportTimeout = 10 * time.Second
+)
+
+// Provider собирает завершенные спаны в пачки и отправляет их экспортеру в фоне
+type Provider struct {
+	service  string
+	exporter Exporter
+	queue    chan *SpanData
+	done     chan struct{}
+	wg       sync.WaitGroup
+	dropped  atomic.Int64
+}
+
+// Init включает трейсинг. Возвращает функцию остановки, которая отправляет оставшиеся спаны
+func Init(service string, exporter Exporter) (shutdown func()) {
+	p := &Provider{
+		service:  service,
+		exporter: exporter,
+		queue:    make(chan *SpanData, queueSize),
+		done:     make(chan struct{}),
+	}
+	current.Store(p)
+
+	p.wg.Add(1)
+	go p.run()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			current.CompareAndSwap(p, nil)
+			close(p.done)
+			p.wg.Wait()
+		})
+	}
+}
+
+// enqueue при переполненной очереди спан отбрасывается - трейсинг не тормозит торговлю
+func (p *Provider) enqueue(span *SpanData) {
+	select {
+	case p.queue <- span:
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+func (p *Provider) run() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*SpanData, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		if err := p.exporter.Export(ctx, p.service, batch); err != nil {
+			logger.Warn("Failed to export %d spans: %v", len(batch), err)
+		}
+		cancel()
+		batch = make([]*SpanData, 0, batchSize)
+	}
+
+	for {
+		select {
+		case span := <-p.queue:
+			batch = append(batch, span)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+			if n := p.dropped.Swap(0); n > 0 {
+				logger.Warn("Dropped %d spans: export queue full", n)
+			}
+		case <-p.done:
+			for {
+				select {
+				case span := <-p.queue:
+					batch = append(batch, span)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type memoryExporter struct {
+	mu    sync.Mutex
+	spans []*SpanData
+}
+
+func (e *memoryExporter) Export(ctx context.Context, service string, spans []*SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func TestStartWithoutInit(t *testing.T) {
+	ctx, span := Start(context.Background(), "noop", KindInternal)
+	if span != nil {
+		t.Fatal("expected nil span without Init")
+	}
+	// nil спан безопасен
+	span.SetAttributes(String("k", "v"))
+	span.RecordError(errors.New("boom"))
+	span.End()
+
+	if id := TraceID(ctx); id != "" {
+		t.Errorf("TraceID = %q, want empty", id)
+	}
+}
+
+func TestNestedSpans(t *testing.T) {
+	exporter := &memoryExporter{}
+	shutdown := Init("test", exporter)
+
+	ctx, root := Start(context.Background(), "telegram.command", KindServer, String("telegram.command", "buy"))
+	childCtx, child := Start(ctx, "bybit POST /v5/order/create", KindClient)
+	child.RecordError(errors.New("insufficient balance"))
+	child.End()
+	root.End()
+	root.End() // повторный End не дублирует спан
+
+	if TraceID(childCtx) != root.TraceID() || len(root.TraceID()) != 32 {
+		t.Errorf("child trace %q, root trace %q", TraceID(childCtx), root.TraceID())
+	}
+
+	shutdown()
+
+	if len(exporter.spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(exporter.spans))
+	}
+	childData, rootData := exporter.spans[0], exporter.spans[1]
+	if childData.ParentSpanID != rootData.SpanID {
+		t.Error("child span must reference root as parent")
+	}
+	if rootData.ParentSpanID != [8]byte{} {
+		t.Error("root span must not have parent")
+	}
+	if childData.StatusCode != StatusError || len(childData.Events) != 1 || childData.Events[0].Name != "exception" {
+		t.Errorf("unexpected child status: %+v", childData)
+	}
+
+	// После shutdown трейсинг выключен
+	if _, span := Start(context.Background(), "after", KindInternal); span != nil {
+		t.Error("expected nil span after shutdown")
+	}
+}
+
+func TestFileExporterWritesOTLPJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	exporter, err := NewFileExporter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exporter.Close()
+
+	shutdown := Init("dca-bot", exporter)
+	_, span := Start(context.Background(), "policy.validate", KindInternal,
+		String("symbol", "BTCUSDT"), Int("policy.violations", 2), Float("policy.risk_score", 0.4), Bool("policy.approved", false))
+	span.End()
+	shutdown()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var req otlpRequest
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &req); err != nil {
+		t.Fatalf("invalid OTLP JSON: %v\n%s", err, data)
+	}
+	rs := req.ResourceSpans[0]
+	if rs.Resource.Attributes[0].Value["stringValue"] != "dca-bot" {
+		t.Errorf("service.name = %v", rs.Resource.Attributes[0].Value)
+	}
+	got := rs.ScopeSpans[0].Spans[0]
+	if got.Name != "policy.validate" || len(got.TraceID) != 32 || len(got.SpanID) != 16 || got.ParentSpanID != "" {
+		t.Errorf("unexpected span: %+v", got)
+	}
+
+	values := map[string]interface{}{}
+	for _, a := range got.Attributes {
+		for _, v := range a.Value {
+			values[a.Key] = v
+		}
+	}
+	// int64 в OTLP/JSON передается строкой
+	if values["policy.violations"] != "2" || values["policy.risk_score"] != 0.4 || values["policy.approved"] != false {
+		t.Errorf("unexpected attributes: %v", values)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var path, contentType, auth string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, contentType, auth = r.URL.Path, r.Header.Get("Content-Type"), r.Header.Get("Authorization")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	exporter, err := NewExporter("otlp", "", server.URL+"/", ParseHeaders("Authorization=Bearer secret, ,bad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	span := &SpanData{Name: "llm.chat", Kind: KindClient}
+	if err := exporter.Export(context.Background(), "dca-bot", []*SpanData{span}); err != nil {
+		t.Fatal(err)
+	}
+
+	if path != "/v1/traces" || contentType != "application/json" || auth != "Bearer secret" {
+		t.Errorf("path=%s content-type=%s auth=%s", path, contentType, auth)
+	}
+	if !strings.Contains(string(body), `"name":"llm.chat"`) {
+		t.Errorf("unexpected body: %s", body)
+	}
+}
+
+func TestNewExporter(t *testing.T) {
+	if exporter, err := NewExporter("none", "", "", nil); exporter != nil || err != nil {
+		t.Errorf("none: exporter=%v err=%v", exporter, err)
+	}
+	if _, err := NewExporter("jaeger", "", "", nil); err == nil {
+		t.Error("expected error for unknown exporter")
+	}
+}
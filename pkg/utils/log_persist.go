@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogStore хранилище записей лога (таблица logs, domain.LogRepository)
+type LogStore interface {
+	Save(ctx context.Context, level, message, data string) error
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// logQueueSize записи сверх очереди отбрасываются, чтобы запись в БД не тормозила торговлю
+const logQueueSize = 1000
+
+// logRetentionInterval как часто удаляются записи старше срока хранения
+const logRetentionInterval = time.Hour
+
+type logPersister struct {
+	store     LogStore
+	level     LogLevel
+	retention time.Duration
+	queue     chan slog.Record
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+var persister *logPersister
+
+// PersistLogs сохраняет записи уровня minLevel и выше в store и удаляет записи старше retention
+// (0 - хранить бессрочно). Возвращает функцию остановки, дописывающую очередь
+func PersistLogs(store LogStore, minLevel string, retention time.Duration) (stop func()) {
+	p := &logPersister{
+		store:     store,
+		level:     parseLevel(minLevel),
+		retention: retention,
+		queue:     make(chan slog.Record, logQueueSize),
+		done:      make(chan struct{}),
+	}
+
+	outputMu.Lock()
+	persister = p
+	outputMu.Unlock()
+
+	p.wg.Add(1)
+	go p.run()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			outputMu.Lock()
+			if persister == p {
+				persister = nil
+			}
+			outputMu.Unlock()
+			close(p.done)
+			p.wg.Wait()
+		})
+	}
+}
+
+func (p *logPersister) enqueue(record slog.Record) {
+	select {
+	case p.queue <- record:
+	default:
+	}
+}
+
+func (p *logPersister) run() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(logRetentionInterval)
+	defer ticker.Stop()
+	p.cleanup()
+
+	for {
+		select {
+		case record := <-p.queue:
+			p.save(record)
+		case <-ticker.C:
+			p.cleanup()
+		case <-p.done:
+			for {
+				select {
+				case record := <-p.queue:
+					p.save(record)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *logPersister) save(record slog.Record) {
+	data := ""
+	if record.NumAttrs() > 0 {
+		fields := make(map[string]interface{}, record.NumAttrs())
+		record.Attrs(func(a slog.Attr) bool {
+			fields[a.Key] = a.Value.Any()
+			return true
+		})
+		if raw, err := json.Marshal(fields); err == nil {
+			data = string(raw)
+		}
+	}
+
+	// Ошибки пишем в stderr, а не в логгер: иначе сбой БД зациклит сохранение
+	if err := p.store.Save(context.Background(), record.Level.String(), record.Message, data); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to persist log: %v\n", err)
+	}
+}
+
+func (p *logPersister) cleanup() {
+	if p.retention <= 0 {
+		return
+	}
+	if _, err := p.store.DeleteBefore(context.Background(), time.Now().Add(-p.retention)); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete old logs: %v\n", err)
+	}
+}
@@ -1,73 +1,218 @@
 package utils
 
 import (
-	"log"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
+	"sync"
+	"time"
 )
 
-type LogLevel int
+type LogLevel = slog.Level
 
 const (
-	DEBUG LogLevel = iota
-	INFO
-	WARN
-	ERROR
+	DEBUG = slog.LevelDebug
+	INFO  = slog.LevelInfo
+	WARN  = slog.LevelWarn
+	ERROR = slog.LevelError
 )
 
+// Logger структурный логгер поверх slog: printf-сообщение плюс поля
+// (symbol, strategy, order_id, correlation_id), добавленные через With
 type Logger struct {
-	level  LogLevel
-	logger *log.Logger
+	level *slog.LevelVar // общий для логгеров, созданных через With
+	attrs []slog.Attr
 }
 
-var defaultLogger *Logger
+var (
+	outputMu sync.RWMutex
+	output   slog.Handler = newHandler(os.Stdout, "json")
 
-func init() {
 	defaultLogger = NewLogger("info")
+)
+
+// ConfigureLogging задает формат вывода (json или text) и уровень DefaultLogger
+// и всех логгеров, полученных из него через With
+func ConfigureLogging(level, format string) {
+	SetLogOutput(os.Stdout, format)
+	defaultLogger.level.Set(parseLevel(level))
 }
 
-func NewLogger(levelStr string) *Logger {
-	var level LogLevel
-	switch levelStr {
+// SetLogOutput перенаправляет вывод всех логгеров (в том числе созданных ранее)
+func SetLogOutput(w io.Writer, format string) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	output = newHandler(w, format)
+}
+
+func newHandler(w io.Writer, format string) slog.Handler {
+	// Уровень фильтрует сам Logger, handler пропускает все
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	if strings.EqualFold(format, "text") {
+		return slog.NewTextHandler(w, opts)
+	}
+	return slog.NewJSONHandler(w, opts)
+}
+
+func parseLevel(levelStr string) LogLevel {
+	switch strings.ToLower(levelStr) {
 	case "debug":
-		level = DEBUG
-	case "info":
-		level = INFO
+		return DEBUG
 	case "warn":
-		level = WARN
+		return WARN
 	case "error":
-		level = ERROR
+		return ERROR
 	default:
-		level = INFO
+		return INFO
 	}
+}
+
+func NewLogger(levelStr string) *Logger {
+	level := new(slog.LevelVar)
+	level.Set(parseLevel(levelStr))
+	return &Logger{level: level}
+}
 
-	return &Logger{
-		level:  level,
-		logger: log.New(os.Stdout, "", log.LstdFlags),
+// DefaultLogger логгер глобальных функций LogInfo/LogWarn/..., уровень задает ConfigureLogging
+func DefaultLogger() *Logger {
+	return defaultLogger
+}
+
+// With возвращает логгер с дополнительными полями: With("symbol", "BTCUSDT", "strategy", "DCA")
+func (l *Logger) With(args ...interface{}) *Logger {
+	attrs := make([]slog.Attr, len(l.attrs), len(l.attrs)+len(args)/2)
+	copy(attrs, l.attrs)
+	for i := 0; i+1 < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			key = fmt.Sprint(args[i])
+		}
+		attrs = append(attrs, slog.Any(key, args[i+1]))
 	}
+	return &Logger{level: l.level, attrs: attrs}
 }
 
-func (l *Logger) Debug(format string, v ...interface{}) {
-	if l.level <= DEBUG {
-		l.logger.Printf("[DEBUG] "+format, v...)
+// WithContext добавляет correlation_id из контекста
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	if id := CorrelationID(ctx); id != "" {
+		return l.With("correlation_id", id)
 	}
+	return l
 }
 
-func (l *Logger) Info(format string, v ...interface{}) {
-	if l.level <= INFO {
-		l.logger.Printf("[INFO] "+format, v...)
+func (l *Logger) Debug(format string, v ...interface{}) { l.log(DEBUG, format, v) }
+func (l *Logger) Info(format string, v ...interface{})  { l.log(INFO, format, v) }
+func (l *Logger) Warn(format string, v ...interface{})  { l.log(WARN, format, v) }
+func (l *Logger) Error(format string, v ...interface{}) { l.log(ERROR, format, v) }
+
+func (l *Logger) log(level LogLevel, format string, v []interface{}) {
+	if level < l.level.Level() {
+		return
+	}
+	msg := format
+	if len(v) > 0 {
+		msg = fmt.Sprintf(format, v...)
+	}
+
+	record := slog.NewRecord(time.Now(), level, msg, 0)
+	record.AddAttrs(l.attrs...)
+
+	outputMu.RLock()
+	handler, sink := output, persister
+	outputMu.RUnlock()
+
+	handler.Handle(context.Background(), record)
+	if level >= INFO {
+		recent.add(record)
 	}
+	if sink != nil && level >= sink.level {
+		sink.enqueue(record)
+	}
+}
+
+// ==================== Correlation ID ====================
+
+type correlationKey struct{}
+
+// NewCorrelationID случайный ID для связи логов одного цикла решения, ордера или запроса
+func NewCorrelationID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WithCorrelationID кладет ID в контекст
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID ID из контекста или пустая строка
+func CorrelationID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
 }
 
-func (l *Logger) Warn(format string, v ...interface{}) {
-	if l.level <= WARN {
-		l.logger.Printf("[WARN] "+format, v...)
+// ==================== Буфер последних строк ====================
+
+// recentLogSize сколько последних строк лога хранится в памяти (контекст для анализа инцидентов)
+const recentLogSize = 500
+
+// LogLine строка лога из буфера последних сообщений
+type LogLine struct {
+	Time    time.Time
+	Level   string
+	Message string
+}
+
+// logBuffer кольцевой буфер последних строк лога уровня INFO и выше
+type logBuffer struct {
+	mu    sync.Mutex
+	lines []LogLine
+	next  int
+}
+
+var recent = &logBuffer{lines: make([]LogLine, 0, recentLogSize)}
+
+func (b *logBuffer) add(record slog.Record) {
+	message := record.Message
+	record.Attrs(func(a slog.Attr) bool {
+		message += " " + a.Key + "=" + a.Value.String()
+		return true
+	})
+	line := LogLine{Time: record.Time, Level: record.Level.String(), Message: message}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.lines) < recentLogSize {
+		b.lines = append(b.lines, line)
+		return
 	}
+	b.lines[b.next] = line
+	b.next = (b.next + 1) % recentLogSize
 }
 
-func (l *Logger) Error(format string, v ...interface{}) {
-	if l.level <= ERROR {
-		l.logger.Printf("[ERROR] "+format, v...)
+// RecentLogs строки лога начиная с since в хронологическом порядке
+func RecentLogs(since time.Time) []LogLine {
+	recent.mu.Lock()
+	defer recent.mu.Unlock()
+
+	var result []LogLine
+	for i := 0; i < len(recent.lines); i++ {
+		line := recent.lines[(recent.next+i)%len(recent.lines)]
+		if !line.Time.Before(since) {
+			result = append(result, line)
+		}
 	}
+	return result
 }
 
 // Global logging functions
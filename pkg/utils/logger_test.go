@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	SetLogOutput(&buf, "json")
+	t.Cleanup(func() { SetLogOutput(os.Stdout, "json") })
+	return &buf
+}
+
+func TestLoggerJSONFields(t *testing.T) {
+	buf := captureLogs(t)
+
+	ctx := WithCorrelationID(context.Background(), "abc123")
+	logger := NewLogger("info").WithContext(ctx).With("symbol", "BTCUSDT", "strategy", "DCA")
+	logger.Info("Order placed: %s", "42")
+	logger.Debug("скрыто уровнем")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %d: %q", len(lines), buf.String())
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	want := map[string]string{
+		"level":          "INFO",
+		"msg":            "Order placed: 42",
+		"correlation_id": "abc123",
+		"symbol":         "BTCUSDT",
+		"strategy":       "DCA",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s = %v, want %s", key, entry[key], value)
+		}
+	}
+}
+
+func TestLoggerWithDoesNotMutateParent(t *testing.T) {
+	buf := captureLogs(t)
+
+	parent := NewLogger("info").With("component", "test")
+	parent.With("order_id", "1")
+	parent.Info("parent")
+
+	if strings.Contains(buf.String(), "order_id") {
+		t.Errorf("With leaked field into parent: %s", buf.String())
+	}
+}
+
+func TestConfigureLoggingLevel(t *testing.T) {
+	buf := captureLogs(t)
+
+	// Логгер получен до настройки уровня - уровень должен примениться и к нему
+	logger := DefaultLogger().With("component", "test")
+	defaultLogger.level.Set(WARN)
+	t.Cleanup(func() { defaultLogger.level.Set(INFO) })
+
+	logger.Info("info")
+	logger.Warn("warn")
+
+	if strings.Contains(buf.String(), `"msg":"info"`) || !strings.Contains(buf.String(), `"msg":"warn"`) {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+}
+
+func TestRecentLogsIncludeFields(t *testing.T) {
+	captureLogs(t)
+	since := time.Now()
+
+	NewLogger("info").With("symbol", "ETHUSDT").Warn("price unavailable")
+
+	lines := RecentLogs(since)
+	if len(lines) == 0 {
+		t.Fatal("expected recent log line")
+	}
+	last := lines[len(lines)-1]
+	if last.Level != "WARN" || last.Message != "price unavailable symbol=ETHUSDT" {
+		t.Errorf("unexpected line: %+v", last)
+	}
+}
+
+type fakeLogStore struct {
+	mu      sync.Mutex
+	saved   []string
+	data    []string
+	deleted []time.Time
+}
+
+func (s *fakeLogStore) Save(ctx context.Context, level, message, data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved = append(s.saved, level+" "+message)
+	s.data = append(s.data, data)
+	return nil
+}
+
+func (s *fakeLogStore) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleted = append(s.deleted, before)
+	return 0, nil
+}
+
+func TestPersistLogs(t *testing.T) {
+	captureLogs(t)
+	store := &fakeLogStore{}
+
+	stop := PersistLogs(store, "warn", 24*time.Hour)
+	logger := NewLogger("debug").With("order_id", "42")
+	logger.Info("не сохраняется")
+	logger.Warn("slippage high")
+	logger.Error("order failed")
+	stop()
+
+	// После остановки записи больше не сохраняются
+	logger.Error("after stop")
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if len(store.saved) != 2 || store.saved[0] != "WARN slippage high" || store.saved[1] != "ERROR order failed" {
+		t.Fatalf("unexpected saved logs: %v", store.saved)
+	}
+	if store.data[0] != `{"order_id":"42"}` {
+		t.Errorf("data = %s", store.data[0])
+	}
+
+	// Retention выполняется при старте
+	if len(store.deleted) != 1 {
+		t.Fatalf("expected retention cleanup, got %d", len(store.deleted))
+	}
+	if age := time.Since(store.deleted[0]); age < 24*time.Hour || age > 25*time.Hour {
+		t.Errorf("unexpected retention cutoff age: %v", age)
+	}
+}